@@ -1,15 +1,21 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/exitcode"
 )
 
 const (
 	EOutOfGas = iota + jsonrpc.FirstUserCode
 	EActorNotFound
+	EMessageWouldAbort
+	ERateLimited
 )
 
 type ErrOutOfGas struct{}
@@ -24,6 +30,52 @@ func (e *ErrActorNotFound) Error() string {
 	return "actor not found"
 }
 
+// ErrMessageWouldAbort is the error of a message rejected by the mpool as it
+// aborts with ExitCode when simulated on the head state.
+type ErrMessageWouldAbort struct {
+	ExitCode exitcode.ExitCode
+	Reason   string
+}
+
+func (e *ErrMessageWouldAbort) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("message would abort with exit code %d", e.ExitCode)
+	}
+	return fmt.Sprintf("message would abort with exit code %d: %s", e.ExitCode, e.Reason)
+}
+
+type errMessageWouldAbort ErrMessageWouldAbort
+
+func (e *ErrMessageWouldAbort) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*errMessageWouldAbort)(e))
+}
+
+func (e *ErrMessageWouldAbort) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, (*errMessageWouldAbort)(e))
+}
+
+// ErrRateLimited is the error of a call rejected for being over the Limit of
+// the rate of the calls of its caller.
+type ErrRateLimited struct {
+	Limit string
+	// RetryAfter is the time after which the call would be allowed
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("too many requests: over the %s rate limit, retry in %s", e.Limit, e.RetryAfter.Round(time.Millisecond))
+}
+
+type errRateLimited ErrRateLimited
+
+func (e *ErrRateLimited) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*errRateLimited)(e))
+}
+
+func (e *ErrRateLimited) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, (*errRateLimited)(e))
+}
+
 var RPCErrors = jsonrpc.NewErrors()
 
 func ErrorIsIn(err error, errorTypes []error) bool {
@@ -39,4 +91,6 @@ func ErrorIsIn(err error, errorTypes []error) bool {
 func init() {
 	RPCErrors.Register(EOutOfGas, new(*ErrOutOfGas))
 	RPCErrors.Register(EActorNotFound, new(*ErrActorNotFound))
+	RPCErrors.Register(EMessageWouldAbort, new(*ErrMessageWouldAbort))
+	RPCErrors.Register(ERateLimited, new(*ErrRateLimited))
 }
@@ -24,11 +24,15 @@ import (
 	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	abinetwork "github.com/filecoin-project/go-state-types/network"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	init_ "github.com/filecoin-project/lotus/chain/actors/builtin/init"
+	lmarket "github.com/filecoin-project/lotus/chain/actors/builtin/market"
 	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -145,6 +149,11 @@ type FullNode interface {
 	ChainTipSetWeight(context.Context, types.TipSetKey) (types.BigInt, error) //perm:read
 	ChainGetNode(ctx context.Context, p string) (*IpldObject, error)          //perm:read
 
+	// ChainTipSetWeightComponents breaks the weight of the specified tipset
+	// down into the terms of the weight function: the parent weight, the power
+	// table factor and the contribution of the win counts of its blocks.
+	ChainTipSetWeightComponents(context.Context, types.TipSetKey) (TipSetWeightComponents, error) //perm:read
+
 	// ChainGetMessage reads a message referenced by the specified CID from the
 	// chain blockstore.
 	ChainGetMessage(context.Context, cid.Cid) (*types.Message, error) //perm:read
@@ -169,12 +178,78 @@ type FullNode interface {
 	// back to genesis, the entire genesis state, and the most recent 'nroots'
 	// state trees.
 	// If oldmsgskip is set, messages from before the requested roots are also not included.
-	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
+	// The stream can optionally be compressed on the node, see ChainExportOpts.
+	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey, opts ChainExportOpts) (<-chan []byte, error) //perm:read
+
+	// ChainExportEstimate returns the size and number of blocks of the export
+	// ChainExport would produce for the same parameters, ignoring compression.
+	// It walks the same DAG as the export, but only fetches the sizes of leaf
+	// blocks, so it is cheaper than running the export itself.
+	ChainExportEstimate(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey, opts ChainExportOpts) (ExportSizeEstimate, error) //perm:read
+
+	// ChainExportProgress returns a channel of periodic progress reports for
+	// all chain exports running on the node. Each export sends a final report
+	// with Done set when it finishes.
+	ChainExportProgress(ctx context.Context) (<-chan ExportProgress, error) //perm:read
+
+	// ChainSnapshotStatus returns the status of the snapshots the node exports
+	// on its own, as configured in the Chainstore.Snapshots section of its
+	// config.
+	ChainSnapshotStatus(ctx context.Context) (SnapshotStatus, error) //perm:read
+
+	// ChainRestoreSnapshot imports the snapshot at path, on the filesystem of
+	// the node, into a standby blockstore while the node keeps serving from
+	// its current one. Once the snapshot is imported and verified, the node
+	// switches over to the standby blockstore, removes the current one, and
+	// takes the root of the snapshot as its head. path may be a CAR, a
+	// compressed CAR, or the manifest of a sharded snapshot; a manifest next
+	// to a CAR is checked too. Not supported with the splitstore.
+	ChainRestoreSnapshot(ctx context.Context, path string) (*types.TipSet, error) //perm:admin
+
+	// ChainGetForkTips returns the tips of the competing forks known to the
+	// node, heaviest first: the tipsets offered as heads that no other offered
+	// tipset extends, and the current head. Tips more than the fork length
+	// threshold below the head are not reported.
+	ChainGetForkTips(ctx context.Context) ([]ForkTip, error) //perm:read
+
+	// ChainGetBlockDAG returns the graph of the blocks known to the node in the
+	// given number of epochs ending at the head, forks included, with the
+	// parent links, weights and miners of the blocks, for rendering fork
+	// graphs. At most finality epochs are returned.
+	ChainGetBlockDAG(ctx context.Context, epochs abi.ChainEpoch) (*BlockDAG, error) //perm:read
+
+	// ChainGetPendingReorg returns the reorg held back until it is accepted or
+	// rejected, if any. Reorgs deeper than the Chainstore.ReorgConfirmDepth
+	// of the config are held back.
+	ChainGetPendingReorg(ctx context.Context) (*PendingReorg, error) //perm:read
+
+	// ChainResolveReorg accepts or rejects the pending reorg to the tipset to.
+	// Accepting it takes to as the head. Rejecting it ignores to, and the
+	// tipsets extending it, from then on.
+	ChainResolveReorg(ctx context.Context, to types.TipSetKey, accept bool) error //perm:admin
+
+	// ChainGetHeadJournal returns the head changes recorded by the node, with
+	// the tipsets each reverted and applied, in the order they happened or
+	// newest first with Reverse set. Entries are recorded before the head
+	// changes, so the journal also covers changes cut short by a crash.
+	ChainGetHeadJournal(ctx context.Context, q HeadJournalQuery) ([]HeadJournalEntry, error) //perm:read
+
+	// ChainTipSetCacheStats returns the occupancy and the hit counts of the
+	// tipset cache of the chainstore.
+	ChainTipSetCacheStats(ctx context.Context) (TipSetCacheStats, error) //perm:read
 
 	// ChainPrune prunes the stored chain state and garbage collects; only supported if you
 	// are using the splitstore
 	ChainPrune(ctx context.Context, opts PruneOpts) error //perm:admin
 
+	// ChainPruneHistory deletes the block headers, messages and receipts of the
+	// chain older than the retention, in epochs, which is at least finality.
+	// The genesis, the checkpoint and the roots of the snapshots exported by
+	// the node are kept. With DryRun set, it only reports what would be pruned.
+	// With Offload set, the history is moved to the cold store of the node,
+	// where it is still read from, instead of being deleted.
+	ChainPruneHistory(ctx context.Context, opts PruneHistoryOpts) (PruneHistoryResult, error) //perm:admin
+
 	// ChainCheckBlockstore performs an (asynchronous) health check on the chain/state blockstore
 	// if supported by the underlying implementation.
 	ChainCheckBlockstore(context.Context) error //perm:admin
@@ -182,6 +257,41 @@ type FullNode interface {
 	// ChainBlockstoreInfo returns some basic information about the blockstore
 	ChainBlockstoreInfo(context.Context) (map[string]interface{}, error) //perm:read
 
+	// ChainBlockstoreUsage scans the blockstores of the node and reports the
+	// objects and bytes of every tier (the hot and cold stores of the
+	// splitstore, the cold store of the pruned chain history), the blocks
+	// stored in more than one tier, the bytes per codec and the top largest
+	// objects. The scan reads every block, it takes a while on large stores.
+	ChainBlockstoreUsage(ctx context.Context, top int) (BlockstoreUsage, error) //perm:admin
+
+	// ChainStateGarbage estimates the objects of the state blockstore that
+	// are unreachable from the state trees of the given number of latest
+	// epochs. Those reachable from older state roots are reported per bucket
+	// of epochs of the newest root reaching them, the rest as dangling, e.g.
+	// left over by reorgs. Like ChainBlockstoreUsage, it walks the chain and
+	// reads every block of the store, which takes a while.
+	ChainStateGarbage(ctx context.Context, opts StateGarbageOpts) (StateGarbage, error) //perm:admin
+
+	// ChainBlockstoreGC starts a cycle of online garbage collection of the
+	// blockstore now, outside of the GC windows of the config. It fails if a
+	// cycle is already running.
+	ChainBlockstoreGC(context.Context) error //perm:admin
+	// ChainBlockstoreGCAbort aborts the running garbage collection cycle of the
+	// blockstore.
+	ChainBlockstoreGCAbort(context.Context) error //perm:admin
+	// ChainBlockstoreGCStatus returns the running and the last garbage
+	// collection cycles of the blockstore.
+	ChainBlockstoreGCStatus(context.Context) (BlockstoreGCStatus, error) //perm:read
+
+	// ChainSplitstoreCompactionProgress returns the progress of the running
+	// compaction of the splitstore, and whether compaction is paused.
+	ChainSplitstoreCompactionProgress(context.Context) (SplitstoreCompactionProgress, error) //perm:read
+	// ChainSplitstorePauseCompaction pauses the running compaction of the
+	// splitstore and holds off new compactions until it is resumed.
+	ChainSplitstorePauseCompaction(context.Context) error //perm:admin
+	// ChainSplitstoreResumeCompaction resumes the compaction of the splitstore.
+	ChainSplitstoreResumeCompaction(context.Context) error //perm:admin
+
 	// GasEstimateFeeCap estimates gas fee cap
 	GasEstimateFeeCap(context.Context, *types.Message, int64, types.TipSetKey) (types.BigInt, error) //perm:read
 
@@ -189,6 +299,13 @@ type FullNode interface {
 	// It fails if message fails to execute.
 	GasEstimateGasLimit(context.Context, *types.Message, types.TipSetKey) (int64, error) //perm:read
 
+	// GasEstimateFeeCapPercentile suggests gas premiums and fee caps from the
+	// premiums actually paid by the messages of the last ntipsets tipsets up to
+	// tsk: the premium under which each of the percentiles of the gas was paid,
+	// 25, 50 and 90 if none are given, and a fee cap allowing for 20 blocks of
+	// base fee increases over it.
+	GasEstimateFeeCapPercentile(ctx context.Context, ntipsets uint64, percentiles []float64, tsk types.TipSetKey) (*GasFeePercentiles, error) //perm:read
+
 	// GasEstimateGasPremium estimates what gas price should be used for a
 	// message to have high likelihood of inclusion in `nblocksincl` epochs.
 
@@ -213,6 +330,10 @@ type FullNode interface {
 	// yet synced block headers.
 	SyncIncomingBlocks(ctx context.Context) (<-chan *types.BlockHeader, error) //perm:read
 
+	// SyncProgress returns a channel streaming the SyncState every few
+	// seconds, starting with the current one, until the context is canceled.
+	SyncProgress(ctx context.Context) (<-chan *SyncState, error) //perm:read
+
 	// SyncCheckpoint marks a blocks as checkpointed, meaning that it won't ever fork away from it.
 	SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error //perm:admin
 
@@ -230,6 +351,17 @@ type FullNode interface {
 	// the reason.
 	SyncCheckBad(ctx context.Context, bcid cid.Cid) (string, error) //perm:read
 
+	// SyncInspectBad returns the entry of a block in the bad block cache, or
+	// nil if it isn't marked bad.
+	SyncInspectBad(ctx context.Context, bcid cid.Cid) (*BadBlock, error) //perm:read
+
+	// SyncListBad lists the blocks marked as bad, with the reasons.
+	SyncListBad(ctx context.Context) ([]BadBlock, error) //perm:read
+
+	// SyncPeerScores returns the scores of the peers the syncer requests chain
+	// data from, the peers requested first coming first.
+	SyncPeerScores(ctx context.Context) ([]SyncPeerScore, error) //perm:read
+
 	// SyncValidateTipset indicates whether the provided tipset is valid or not
 	SyncValidateTipset(ctx context.Context, tsk types.TipSetKey) (bool, error) //perm:read
 
@@ -263,6 +395,20 @@ type FullNode interface {
 	// MpoolBatchPushUntrusted batch pushes a signed message to mempool from untrusted sources.
 	MpoolBatchPushUntrusted(context.Context, []*types.SignedMessage) ([]cid.Cid, error) //perm:write
 
+	// MpoolPushBatch pushes a batch of signed messages to mempool all or
+	// nothing: if one of the messages is invalid, none is added. The messages
+	// of a sender must have consecutive nonces in the batch, the first one
+	// following the pending messages of the sender. The result has the error
+	// of every invalid message.
+	MpoolPushBatch(context.Context, []*types.SignedMessage) (*MpoolPushBatchResult, error) //perm:write
+
+	// MpoolRepricePending replaces the local pending messages of the given
+	// senders, of all the local senders if none is given, with messages of
+	// the same nonces and gas parameters estimated again, bumped by at least
+	// the replace by fee ratio and capped by the max fee of the spec. The
+	// local messages persisted which aren't pending anymore are then deleted.
+	MpoolRepricePending(ctx context.Context, addrs []address.Address, spec *MessageSendSpec) (*MpoolRepriceResult, error) //perm:sign
+
 	// MpoolBatchPushMessage batch pushes a unsigned message to mempool.
 	MpoolBatchPushMessage(context.Context, []*types.Message, *MessageSendSpec) ([]*types.SignedMessage, error) //perm:sign
 
@@ -277,14 +423,46 @@ type FullNode interface {
 	// Note that this method may not be atomic. Use MpoolPushMessage instead.
 	MpoolGetNonce(context.Context, address.Address) (uint64, error) //perm:read
 	MpoolSub(context.Context) (<-chan MpoolUpdate, error)           //perm:read
+	// MpoolSubFiltered is MpoolSub, with only the updates of the messages
+	// matching the filter.
+	MpoolSubFiltered(context.Context, MpoolSubFilter) (<-chan MpoolUpdate, error) //perm:read
+
+	// MpoolReserveNonce reserves count consecutive nonces of the sender, for
+	// messages signed out of band. The messages signed by the node, and the
+	// other reservations, don't use them. Reservations are persisted, and must
+	// be released with MpoolReleaseNonce.
+	MpoolReserveNonce(ctx context.Context, addr address.Address, count uint64) (*NonceReservation, error) //perm:sign
+	// MpoolReleaseNonce releases a reservation, the first used nonces of which
+	// were used by messages signed out of band. The other nonces are used by the
+	// next messages signed by the node.
+	MpoolReleaseNonce(ctx context.Context, id uuid.UUID, used uint64) error //perm:sign
+	// MpoolListNonceReservations lists the nonce reservations not released yet.
+	MpoolListNonceReservations(context.Context) ([]NonceReservation, error) //perm:read
 
 	// MpoolClear clears pending messages from the mpool
 	MpoolClear(context.Context, bool) error //perm:write
 
+	// MpoolPendingTimes returns the times this node added the pending messages
+	// to its mpool.
+	MpoolPendingTimes(context.Context) ([]MpoolPendingTime, error) //perm:read
+
+	// MpoolJournalList lists the local messages of the mpool journal with one
+	// of the statuses, all of them if none are given. The journal keeps every
+	// message pushed by the node, and tracks whether it landed, was replaced or
+	// expired.
+	MpoolJournalList(ctx context.Context, statuses []MpoolMessageStatus) ([]MpoolJournalEntry, error) //perm:read
+	// MpoolJournalRebroadcast publishes again the pending journaled messages
+	// with the given CIDs, all of them if none are given, adding them back to
+	// the mpool if needed. It returns the CIDs of the messages published.
+	MpoolJournalRebroadcast(ctx context.Context, cids []cid.Cid) ([]cid.Cid, error) //perm:write
+
 	// MpoolGetConfig returns (a copy of) the current mpool config
 	MpoolGetConfig(context.Context) (*types.MpoolConfig, error) //perm:read
 	// MpoolSetConfig sets the mpool config to (a copy of) the supplied config
 	MpoolSetConfig(context.Context, *types.MpoolConfig) error //perm:admin
+	// MpoolGetRBFPolicy returns the replace by fee policy in effect, as set by
+	// the mpool config
+	MpoolGetRBFPolicy(context.Context) (*MpoolRBFPolicy, error) //perm:read
 
 	// MethodGroup: Miner
 
@@ -424,12 +602,46 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*InvocResult, error) //perm:read
+	// StateReplayTipSet re-executes the messages of the given tipset against its
+	// parent state, and returns the resulting state roots along with those
+	// recorded by the chain, the execution of each message, and the actors
+	// changed. It is meant for debugging consensus faults and state mismatches;
+	// nothing it computes is persisted as the state of the tipset.
+	StateReplayTipSet(context.Context, types.TipSetKey) (*TipSetReplay, error) //perm:read
+	// StateDebugCall runs the given message like StateCall, and returns, along
+	// with its result, the invocation frames of the calls it made matching any
+	// of the breakpoints, with the stacks of calls leading to them. At most
+	// DebugMaxHits frames are returned.
+	StateDebugCall(ctx context.Context, msg *types.Message, bps []Breakpoint, tsk types.TipSetKey) (*DebugResult, error) //perm:read
+	// StateDebugReplay replays the given message like StateReplay, and returns,
+	// along with its result, the invocation frames of the calls it made
+	// matching any of the breakpoints, like StateDebugCall.
+	StateDebugReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid, bps []Breakpoint) (*DebugResult, error) //perm:read
+	// StateDiff returns the actors created, deleted and modified from the parent
+	// state of the first tipset to that of the second one, with the changes of
+	// their balance and nonce. With decode, the changes of the collections in
+	// the states of the builtin init, miner, market, power and multisig actors
+	// modified are decoded too.
+	StateDiff(ctx context.Context, from, to types.TipSetKey, decode bool) (*StateDiff, error) //perm:read
 	// StateGetActor returns the indicated actor's nonce and balance.
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) //perm:read
 	// StateReadState returns the indicated actor's state.
 	StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*ActorState, error) //perm:read
 	// StateListMessages looks back and returns all messages with a matching to or from address, stopping at the given height.
 	StateListMessages(ctx context.Context, match *MessageMatch, tsk types.TipSetKey, toht abi.ChainEpoch) ([]cid.Cid, error) //perm:read
+	// StateQueryMessages returns a page of the messages of the chain of tsk
+	// matching the query, from the newest to the oldest, along with the cursor
+	// of the next page if there may be more. A page holds at most Limit
+	// messages, and ends early when the query scanned too much of the chain,
+	// in which case it may be empty but still have a cursor. Queries on an
+	// address are answered from the message index when it is enabled.
+	StateQueryMessages(ctx context.Context, query *MessageQuery, tsk types.TipSetKey) (*MessageQueryResult, error) //perm:read
+	// StateGasProfile aggregates the gas used by the messages of the chain of
+	// tsk included from height from to height to, by the code of the actor
+	// they were sent to and the method called. The messages of tsk itself are
+	// left out, not being executed yet. At most GasProfileMaxEpochs epochs are
+	// walked.
+	StateGasProfile(ctx context.Context, from, to abi.ChainEpoch, tsk types.TipSetKey) (*GasProfile, error) //perm:read
 	// StateDecodeParams attempts to decode the provided params, based on the recipient actor address and method number.
 	StateDecodeParams(ctx context.Context, toAddr address.Address, method abi.MethodNum, params []byte, tsk types.TipSetKey) (interface{}, error) //perm:read
 	// StateEncodeParams attempts to encode the provided json params to the binary from
@@ -439,11 +651,21 @@ type FullNode interface {
 	StateNetworkName(context.Context) (dtypes.NetworkName, error) //perm:read
 	// StateMinerSectors returns info about the given miner's sectors. If the filter bitfield is nil, all sectors are included.
 	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) //perm:read
+	// StateMinersSectors returns a page of the sectors of the given miners, in
+	// the order of the miners and then of the sector numbers, along with the
+	// cursor of the next page if there may be more. A page holds at most Limit
+	// sectors; the miners without any sector are omitted.
+	StateMinersSectors(ctx context.Context, query *MinersSectorsQuery, tsk types.TipSetKey) (*MinersSectorsResult, error) //perm:read
 	// StateMinerActiveSectors returns info about sectors that a given miner is actively proving.
 	StateMinerActiveSectors(context.Context, address.Address, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) //perm:read
 	// StateMinerProvingDeadline calculates the deadline at some epoch for a proving period
 	// and returns the deadline-related calculations.
 	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) //perm:read
+	// StateMinerProvingSchedule projects the proving windows of the miner open
+	// at, or opening in, the given number of epochs from the height of tsk,
+	// along with the partitions and sectors of their deadlines in the state of
+	// tsk. The WindowPoSt of a window must land on chain before it closes.
+	StateMinerProvingSchedule(ctx context.Context, addr address.Address, epochs abi.ChainEpoch, tsk types.TipSetKey) ([]ProvingWindow, error) //perm:read
 	// StateMinerPower returns the power of the indicated miner
 	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*MinerPower, error) //perm:read
 	// StateMinerInfo returns info about the indicated miner
@@ -588,6 +810,11 @@ type FullNode interface {
 	// Messages in the `apply` parameter must have the correct nonces, and gas
 	// values set.
 	StateCompute(context.Context, abi.ChainEpoch, []*types.Message, types.TipSetKey) (*ComputeStateOutput, error) //perm:read
+	// StateComputeStream is StateCompute streaming the trace of every message
+	// as it is applied, rather than returning the whole trace at the end. The
+	// last update has the root of the state computed, or the error computing
+	// it. The computation waits for the updates to be read.
+	StateComputeStream(context.Context, abi.ChainEpoch, []*types.Message, types.TipSetKey) (<-chan ComputeStateUpdate, error) //perm:read
 	// StateVerifierStatus returns the data cap for the given address.
 	// Returns nil if there is no entry in the data cap table for the
 	// address.
@@ -1081,6 +1308,59 @@ type ActiveSync struct {
 	Start   time.Time
 	End     time.Time
 	Message string
+
+	// Stages is the progress through the stages entered so far.
+	Stages []SyncStageProgress
+	// EpochsRemaining is the number of epochs left to validate.
+	EpochsRemaining abi.ChainEpoch
+	// ETA is the estimated completion time of the sync, based on the rate at
+	// which the tipsets were fetched and validated so far. It is zero until
+	// the first tipsets are validated.
+	ETA time.Time
+}
+
+// SyncStageProgress is the progress of a sync through one of its stages.
+type SyncStageProgress struct {
+	Stage SyncStateStage
+	// Time is the time spent in the stage.
+	Time time.Duration
+	// Epochs is the number of epochs gone through by the stage: the epochs of
+	// the headers fetched while syncing headers, and of the tipsets validated
+	// while syncing messages or validating headers.
+	Epochs abi.ChainEpoch
+	// Rate is the number of epochs gone through per second spent in the stage.
+	Rate float64
+}
+
+// BadBlock is a block marked as bad.
+type BadBlock struct {
+	Cid cid.Cid
+	// Reason is why the block was marked bad, including the reason of the
+	// block it was marked bad because of, if any.
+	Reason string
+	// TipSet is the tipset found invalid, if the block was marked bad for it.
+	TipSet []cid.Cid
+	// Added is when the block was marked bad.
+	Added time.Time
+	// Expires is when the block stops being marked bad, zero for never.
+	Expires time.Time
+}
+
+// SyncPeerScore are the statistics kept for a peer the syncer requests chain
+// data from.
+type SyncPeerScore struct {
+	Peer      peer.ID
+	FirstSeen time.Time
+	Successes int
+	Failures  int
+	// Latency is the average time per item of the requests to the peer.
+	Latency time.Duration
+	// Bandwidth is the average bandwidth of the responses of the peer, in
+	// bytes per second.
+	Bandwidth float64
+	// Cost is the expected time per item of a request to the peer, weighing
+	// its statistics with the ChainExchange section of the config.
+	Cost time.Duration
 }
 
 type SyncState struct {
@@ -1099,6 +1379,7 @@ const (
 	StageSyncComplete
 	StageSyncErrored
 	StageFetchingMessages
+	StageHeaderValidation
 )
 
 func (v SyncStateStage) String() string {
@@ -1117,6 +1398,8 @@ func (v SyncStateStage) String() string {
 		return "error"
 	case StageFetchingMessages:
 		return "fetching messages"
+	case StageHeaderValidation:
+		return "header validation"
 	default:
 		return fmt.Sprintf("<unknown: %d>", v)
 	}
@@ -1129,16 +1412,133 @@ const (
 	MpoolRemove
 )
 
+// GasFeePercentiles are the fee suggestions of GasEstimateFeeCapPercentile.
+type GasFeePercentiles struct {
+	// BaseFee is the base fee of the next tipset
+	BaseFee abi.TokenAmount
+	// Tipsets is the number of tipsets looked at, and Messages the number of
+	// messages included in them
+	Tipsets     int
+	Messages    int
+	Suggestions []GasFeeSuggestion
+}
+
+// GasFeeSuggestion is the gas premium and fee cap suggested at a percentile of
+// the premiums paid.
+type GasFeeSuggestion struct {
+	Percentile float64
+	GasPremium abi.TokenAmount
+	GasFeeCap  abi.TokenAmount
+}
+
 type MpoolUpdate struct {
 	Type    MpoolChange
 	Message *types.SignedMessage
 }
 
+// MpoolSubFilter selects the updates of MpoolSubFiltered. Their message must
+// match all the fields set: have one of the From addresses as sender, one of
+// the To addresses as recipient, one of the Methods, and a recipient actor of
+// one of the ActorCodes. Addresses match their ID address.
+type MpoolSubFilter struct {
+	From       []address.Address
+	To         []address.Address
+	Methods    []abi.MethodNum
+	ActorCodes []cid.Cid
+}
+
 type ComputeStateOutput struct {
 	Root  cid.Cid
 	Trace []*InvocResult
 }
 
+// ComputeStateUpdate is an update of StateComputeStream: the trace of a message
+// applied or, in the last update, the root of the state computed or the error
+// computing it.
+type ComputeStateUpdate struct {
+	Trace *InvocResult `json:",omitempty"`
+	Root  *cid.Cid     `json:",omitempty"`
+	Error string       `json:",omitempty"`
+}
+
+// TipSetReplay is the result of the re-execution of a tipset against its
+// parent state.
+type TipSetReplay struct {
+	TipSet      types.TipSetKey
+	Height      abi.ChainEpoch
+	ParentState cid.Cid
+	// State and Receipts are the roots computed by the replay, ExpectedState
+	// and ExpectedReceipts those recorded in the headers of the child of the
+	// tipset, or computed by the node for a tipset without one. They differ on
+	// a state mismatch.
+	State            cid.Cid
+	Receipts         cid.Cid
+	ExpectedState    cid.Cid
+	ExpectedReceipts cid.Cid
+	// GasUsed is the gas used by all the messages of the tipset.
+	GasUsed int64
+	// Messages are the messages executed, implicit messages included, in
+	// execution order.
+	Messages []*MessageReplay
+	// Actors are the actors changed by the execution, by address.
+	Actors []*ActorChange
+}
+
+// MessageReplay is the execution of a message in a TipSetReplay.
+type MessageReplay struct {
+	MsgCid  cid.Cid
+	From    address.Address
+	To      address.Address
+	Method  abi.MethodNum
+	MsgRct  *types.MessageReceipt
+	GasCost MsgGasCost
+	Error   string
+}
+
+// ActorChange is the change of an actor in a TipSetReplay. Old is nil for the
+// actors created, and New for the actors deleted.
+type ActorChange struct {
+	Address address.Address
+	Old     *types.Actor
+	New     *types.Actor
+	// BalanceDelta is the change of the balance of the actor.
+	BalanceDelta abi.TokenAmount
+}
+
+// StateDiff is the difference between the parent states of two tipsets.
+type StateDiff struct {
+	From      types.TipSetKey
+	To        types.TipSetKey
+	FromState cid.Cid
+	ToState   cid.Cid
+	// Created, Deleted and Modified are the actors changed, by address.
+	Created  []*ActorDiff
+	Deleted  []*ActorDiff
+	Modified []*ActorDiff
+}
+
+// ActorDiff is the change of an actor in a StateDiff.
+type ActorDiff struct {
+	ActorChange
+	// NonceDelta is the change of the nonce of the actor.
+	NonceDelta int64
+	// State is the decoded change of the state of the actor, if requested and
+	// the actor is a builtin actor of the same code in both states.
+	State *ActorStateDiff `json:",omitempty"`
+}
+
+// ActorStateDiff is the decoded change of the state of a builtin actor; only
+// the collections of its actor type are set.
+type ActorStateDiff struct {
+	AddressMap          *init_.AddressMapChanges            `json:",omitempty"`
+	PreCommits          *lminer.PreCommitChanges            `json:",omitempty"`
+	Sectors             *lminer.SectorChanges               `json:",omitempty"`
+	DealProposals       *lmarket.DealProposalChanges        `json:",omitempty"`
+	DealStates          *lmarket.DealStateChanges           `json:",omitempty"`
+	Claims              *power.ClaimChanges                 `json:",omitempty"`
+	PendingTransactions *multisig.PendingTransactionChanges `json:",omitempty"`
+}
+
 type DealCollateralBounds struct {
 	Min abi.TokenAmount
 	Max abi.TokenAmount
@@ -1208,6 +1608,28 @@ type Deadline struct {
 	DisputableProofCount uint64
 }
 
+// ProvingScheduleMaxEpochs is the maximum number of epochs projected by
+// StateMinerProvingSchedule.
+const ProvingScheduleMaxEpochs = 30 * builtin.EpochsInDay
+
+// ProvingWindow is a challenge window of a miner deadline.
+type ProvingWindow struct {
+	Deadline uint64
+	// Open and Close bound the epochs [Open, Close) at which the WindowPoSt
+	// may be submitted, challenged at the Challenge epoch; faults must be
+	// declared before FaultCutoff.
+	Open        abi.ChainEpoch
+	Close       abi.ChainEpoch
+	Challenge   abi.ChainEpoch
+	FaultCutoff abi.ChainEpoch
+
+	// Partitions is the number of partitions with live sectors to prove, none
+	// for a window without WindowPoSt.
+	Partitions    int
+	LiveSectors   uint64
+	FaultySectors uint64
+}
+
 type Partition struct {
 	AllSectors        bitfield.BitField
 	FaultySectors     bitfield.BitField
@@ -1238,6 +1660,175 @@ type MessageMatch struct {
 	From address.Address
 }
 
+// MessageQueryMaxLimit is the maximum number of messages of a page of
+// StateQueryMessages.
+const MessageQueryMaxLimit = 1000
+
+// MessageQuery filters the messages returned by StateQueryMessages; its zero
+// value matches all the messages. The messages matching an exit code are the
+// executed ones.
+type MessageQuery struct {
+	From address.Address
+	To   address.Address
+	// Methods, if any, are the method numbers matched
+	Methods  []abi.MethodNum
+	ExitCode *exitcode.ExitCode
+	// MinValue and MaxValue, if set, bound the value of the messages
+	MinValue *abi.TokenAmount
+	MaxValue *abi.TokenAmount
+	// MinHeight and MaxHeight bound the heights of the tipsets including the
+	// messages; a MaxHeight of 0 is the height of the tipset queried.
+	MinHeight abi.ChainEpoch
+	MaxHeight abi.ChainEpoch
+
+	// Limit is the maximum number of messages returned, MessageQueryMaxLimit
+	// when 0 or above.
+	Limit int
+	// Cursor is the Next cursor of the previous page, nil for the first one.
+	Cursor *MessageCursor
+}
+
+// MessageCursor is the position of a page of messages: they are those
+// included below Height, and those at Height from Index in the messages of the
+// tipset.
+type MessageCursor struct {
+	Height abi.ChainEpoch
+	Index  int
+}
+
+type MessageQueryResult struct {
+	Messages []MessageQueryMatch
+	// Next is the cursor of the next page, nil after the last one
+	Next *MessageCursor
+}
+
+type MessageQueryMatch struct {
+	Cid     cid.Cid
+	Message *types.Message
+	// Height and TipSet are those of the tipset including the message
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+	// Receipt is nil for the messages not executed yet
+	Receipt *types.MessageReceipt
+}
+
+// GasProfileMaxEpochs is the maximum number of epochs walked by
+// StateGasProfile.
+const GasProfileMaxEpochs = 7 * builtin.EpochsInDay
+
+type GasProfile struct {
+	// From and To are the heights of the tipsets walked
+	From abi.ChainEpoch
+	To   abi.ChainEpoch
+
+	Messages uint64
+	GasUsed  int64
+	// Methods are sorted by decreasing gas used
+	Methods []GasProfileMethod
+}
+
+// GasProfileMethod is the gas used by the messages calling Method on the
+// actors of Code. The Code is undefined for the messages sent to addresses
+// with no actor.
+type GasProfileMethod struct {
+	Code   cid.Cid
+	Method abi.MethodNum
+
+	Messages uint64
+	// Failed is the number of messages with a non zero exit code
+	Failed   uint64
+	GasUsed  int64
+	GasLimit int64
+}
+
+// DebugMaxHits is the maximum number of frames returned by StateDebugCall and
+// StateDebugReplay.
+const DebugMaxHits = 100
+
+// Breakpoint matches the calls to the methods of an actor. The fields set
+// must all match, so that the zero Breakpoint matches all the calls.
+type Breakpoint struct {
+	// Actor, if set, is the address of the actor called
+	Actor address.Address
+	// Code, if set, is the code of the actor called. The actors created by
+	// the message have no known code, and are matched by Actor only.
+	Code cid.Cid
+	// Methods, if not empty, are the methods called
+	Methods []abi.MethodNum
+}
+
+type DebugResult struct {
+	Result *InvocResult
+	// Hits are the calls matching a breakpoint, in the order they were made
+	Hits []BreakpointHit
+	// Truncated is set when more than DebugMaxHits calls matched
+	Truncated bool
+}
+
+type BreakpointHit struct {
+	// Breakpoint is the index of the first breakpoint matched
+	Breakpoint int
+	Frame      InvocationFrame
+	// Stack are the frames of the calls leading to Frame, starting with
+	// that of the message
+	Stack []InvocationFrame
+}
+
+// InvocationFrame is a call to an actor method, as recorded in the execution
+// trace of a message.
+type InvocationFrame struct {
+	// Depth is 0 for the message, 1 for the calls it made, and so on
+	Depth    int
+	Caller   address.Address
+	Receiver address.Address
+	// Code is the code of the receiver, undefined if it was created by the
+	// message
+	Code   cid.Cid
+	Method abi.MethodNum
+	Params []byte
+	// DecodedParams are the params decoded, when the receiver is a builtin
+	// actor of known code
+	DecodedParams interface{} `json:",omitempty"`
+	Value         abi.TokenAmount
+	// GasLimit is the gas the call was given, as recorded by the VM
+	GasLimit int64
+	ExitCode exitcode.ExitCode
+	Return   []byte
+	GasUsed  int64
+}
+
+// MinersSectorsMaxLimit is the maximum number of sectors of a page of
+// StateMinersSectors.
+const MinersSectorsMaxLimit = 5000
+
+type MinersSectorsQuery struct {
+	Miners []address.Address
+	// Limit is the maximum number of sectors returned, MinersSectorsMaxLimit
+	// when 0 or above.
+	Limit int
+	// Cursor is the Next cursor of the previous page, nil for the first one.
+	Cursor *MinersSectorsCursor
+}
+
+// MinersSectorsCursor is the position of a page of sectors: they are those of
+// the miner at index Miner of the query from the sector number Sector, then
+// those of the following miners.
+type MinersSectorsCursor struct {
+	Miner  int
+	Sector abi.SectorNumber
+}
+
+type MinersSectorsResult struct {
+	Miners []MinerSectorInfos
+	// Next is the cursor of the next page, nil after the last one
+	Next *MinersSectorsCursor
+}
+
+type MinerSectorInfos struct {
+	Miner   address.Address
+	Sectors []*miner.SectorOnChainInfo
+}
+
 type MsigTransaction struct {
 	ID     int64
 	To     address.Address
@@ -1248,7 +1839,377 @@ type MsigTransaction struct {
 	Approved []address.Address
 }
 
+// TipSetWeightComponents are the terms of the weight of a tipset, which is
+// ParentWeight + PowerTerm + WinCountTerm.
+type TipSetWeightComponents struct {
+	ParentWeight types.BigInt
+	// TotalPower is the quality adjusted power of the network in the parent
+	// state of the tipset, and Log2Power the power table factor
+	// floor(log2(TotalPower)).
+	TotalPower types.BigInt
+	Log2Power  int64
+	// PowerTerm is Log2Power * 2^8.
+	PowerTerm types.BigInt
+	// WinCount is the sum of the win counts of the blocks of the tipset, and
+	// WinCountTerm its contribution, Log2Power * WinCount * WRatioNum * 2^8 /
+	// (BlocksPerEpoch * WRatioDen).
+	WinCount     int64
+	WinCountTerm types.BigInt
+
+	Weight types.BigInt
+}
+
 type PruneOpts struct {
 	MovingGC    bool
 	RetainState int64
 }
+
+type PruneHistoryOpts struct {
+	// Retention is the number of epochs of history kept below the head.
+	Retention abi.ChainEpoch
+	DryRun    bool
+	// Offload moves the history to the cold store configured in
+	// Chainstore.HistoryPruning.ColdStorePath instead of deleting it.
+	Offload bool
+}
+
+// PruneHistoryResult reports the history pruned, or that would be pruned in a
+// dry run.
+type PruneHistoryResult struct {
+	// Horizon is the height below which the chain was pruned.
+	Horizon abi.ChainEpoch
+	DryRun  bool
+	Offload bool
+
+	Tipsets uint64
+	Headers uint64
+	// Messages and Receipts are the numbers of blocks of the message and
+	// receipt AMTs, and of the messages, pruned.
+	Messages uint64
+	Receipts uint64
+	// Bytes is the size of the blocks pruned.
+	Bytes uint64
+}
+
+type BlockstoreUsage struct {
+	Tiers []BlockstoreTierUsage
+	// Total is the sum of the tiers, Duplicates the copies of blocks found in
+	// an earlier tier.
+	Total      BlockstoreObjects
+	Duplicates BlockstoreObjects
+	// Codecs are the objects of the tiers by codec name.
+	Codecs map[string]BlockstoreObjects
+	// Largest are the largest objects, largest first.
+	Largest []BlockstoreObject
+}
+
+type StateGarbageOpts struct {
+	// Keep is the number of epochs below the head whose state trees are kept,
+	// at least 1.
+	Keep abi.ChainEpoch
+	// Bucket is the number of epochs of the buckets of older state roots, a
+	// day of epochs when 0.
+	Bucket abi.ChainEpoch
+	// Lookback is the number of epochs below the kept ones whose state roots
+	// are walked, all of those in the store when 0.
+	Lookback abi.ChainEpoch
+}
+
+// StateGarbage reports the reachability of the objects of the state
+// blockstore from the state roots of the chain.
+type StateGarbage struct {
+	Head   types.TipSetKey
+	Height abi.ChainEpoch
+	Keep   abi.ChainEpoch
+
+	// Total are the objects of the store, and Live those reachable from the
+	// chain and the kept state trees.
+	Total BlockstoreObjects
+	Live  BlockstoreObjects
+	// Buckets are the objects only reachable from older state roots, the
+	// newest first; Lowest is the height of the oldest state root walked.
+	Buckets []StateGarbageBucket
+	Lowest  abi.ChainEpoch
+	// Dangling are the objects reachable from no state root walked.
+	Dangling BlockstoreObjects
+}
+
+// StateGarbageBucket are the objects reachable from the state roots at the
+// heights in [From, To] and from no newer one; they are reclaimable once the
+// states down to To are no longer kept.
+type StateGarbageBucket struct {
+	From abi.ChainEpoch
+	To   abi.ChainEpoch
+	// Roots is the number of state roots first reached in the bucket.
+	Roots   int
+	Objects BlockstoreObjects
+}
+
+type BlockstoreTierUsage struct {
+	Name    string
+	Objects BlockstoreObjects
+	// Duplicates are the objects of the tier also in an earlier tier.
+	Duplicates BlockstoreObjects
+}
+
+type BlockstoreObjects struct {
+	Count uint64
+	Bytes uint64
+}
+
+// Add adds an object of size bytes.
+func (o *BlockstoreObjects) Add(size int) {
+	o.Count++
+	o.Bytes += uint64(size)
+}
+
+type BlockstoreObject struct {
+	Cid   cid.Cid
+	Codec string
+	Size  uint64
+	Tier  string
+}
+
+type BlockstoreGCStatus struct {
+	// Interval is the time between scheduled GC cycles; 0 when only manual
+	// cycles run.
+	Interval time.Duration
+	Running  *BlockstoreGCCycle `json:",omitempty"`
+	Last     *BlockstoreGCCycle `json:",omitempty"`
+}
+
+// BlockstoreGCCycle describes a cycle of online garbage collection of the
+// blockstore.
+type BlockstoreGCCycle struct {
+	// Manual is whether the cycle was started through the API.
+	Manual   bool
+	Started  time.Time
+	Finished time.Time `json:",omitempty"`
+	// Rounds is the number of value log GC rounds run.
+	Rounds int
+	// Throttling is whether the cycle is waiting for the node to be less
+	// busy, and Throttled the time it waited.
+	Throttling bool
+	Throttled  time.Duration
+	Aborted    bool
+	Error      string `json:",omitempty"`
+}
+
+type SplitstoreCompactionProgress struct {
+	Compacting bool
+	Paused     bool
+
+	// Phase is the phase of the running compaction: marking, collecting,
+	// moving, purging or gc.
+	Phase        string
+	Started      time.Time
+	PhaseStarted time.Time
+
+	// Done and Total are the numbers of objects processed and to process in
+	// the phase; Total is 0 when it is unknown, and an estimate when marking.
+	Done    int64
+	Total   int64
+	Percent float64
+	// ETA is the estimated time left in the phase.
+	ETA time.Duration
+
+	// BytesMoved is the size of the objects moved to the coldstore.
+	BytesMoved int64
+}
+
+type TipSetCacheStats struct {
+	// Size is the number of historical tipsets the cache holds, and RecentEpochs
+	// the number of epochs below the highest cached tipset whose tipsets are all
+	// kept besides them.
+	Size         int
+	RecentEpochs abi.ChainEpoch
+
+	// Recent and Historical are the numbers of tipsets cached in the recent
+	// window and out of it.
+	Recent     int
+	Historical int
+
+	Hits   uint64
+	Misses uint64
+}
+
+type HeadJournalQuery struct {
+	// Since is the sequence number of the first entry returned.
+	Since uint64
+	// Limit is the maximum number of entries returned, 0 for all of them.
+	Limit int
+	// Reverse returns the newest entries first.
+	Reverse bool
+}
+
+// HeadJournalEntry is a head change recorded in the head journal.
+type HeadJournalEntry struct {
+	Seq  uint64
+	Time time.Time
+
+	From       types.TipSetKey
+	FromHeight abi.ChainEpoch
+	To         types.TipSetKey
+	ToHeight   abi.ChainEpoch
+
+	// Revert lists the reverted tipsets from the old head down, Apply the
+	// applied tipsets up to the new head.
+	Revert []types.TipSetKey
+	Apply  []types.TipSetKey
+}
+
+type ChainExportOpts struct {
+	// Compression is the format the CAR stream is compressed with: "zstd",
+	// "gzip", or empty for an uncompressed stream.
+	Compression string
+	// CompressionLevel is the compressor specific level; 0 selects the
+	// compressor's default.
+	CompressionLevel int
+	// IncludeReceipts includes the message receipts of every tipset whose
+	// messages are exported, for use by indexers.
+	IncludeReceipts bool
+	// Codecs, if not empty, are the codecs of the blocks written, e.g. only
+	// raw (0x55) for the code of the actors. The blocks of other codecs are
+	// still walked for the blocks they link to.
+	Codecs []uint64
+
+	// RangeStart and RangeEnd, when RangeEnd is not 0, export the chain
+	// segment of the tipsets with heights from RangeStart to RangeEnd of the
+	// chain of the tipset, with the parent state of the oldest of them,
+	// instead of a snapshot; the number of recent state roots and skipping
+	// old messages don't apply to segments.
+	RangeStart abi.ChainEpoch
+	RangeEnd   abi.ChainEpoch
+
+	// MaxBlocksPerSec and MaxBytesPerSec limit the rate of the export so it
+	// does not starve chain sync; 0 means unlimited.
+	MaxBlocksPerSec uint64
+	MaxBytesPerSec  uint64
+	// PauseBehind pauses the export while the node's head is more than this
+	// many epochs behind the current time; 0 never pauses.
+	PauseBehind abi.ChainEpoch
+}
+
+// ExportSizeEstimate is the expected size of a chain export.
+type ExportSizeEstimate struct {
+	Blocks uint64
+	// Bytes is the size of the uncompressed CARv1 file.
+	Bytes uint64
+}
+
+// ExportProgress is a snapshot of the progress of a chain export.
+type ExportProgress struct {
+	// ID identifies the export among the exports run since the node started.
+	ID uint64
+	// Tipset is the tipset the export started from.
+	Tipset types.TipSetKey
+	// Height is the height of the tipset the export started from.
+	Height abi.ChainEpoch
+	// Epoch is the lowest epoch of the header chain walked so far. The export
+	// walks the chain from Height down to genesis, or to the start of the
+	// chain segment exported.
+	Epoch abi.ChainEpoch
+
+	// Blocks is the number of blocks written so far.
+	Blocks uint64
+	// Bytes is the uncompressed size of the CAR sections written so far.
+	Bytes uint64
+
+	Elapsed time.Duration
+	// ETA is the estimated time left, extrapolated from the rate at which
+	// epochs have been walked so far; it is zero until that rate is known.
+	ETA time.Duration
+
+	Done  bool
+	Error string `json:",omitempty"`
+}
+
+// ForkTip is the tip of a fork known to the node.
+type ForkTip struct {
+	Key    types.TipSetKey
+	Height abi.ChainEpoch
+	Weight types.BigInt
+	// Blocks is the number of blocks in the tipset.
+	Blocks int
+	// Heaviest is set for the current head.
+	Heaviest bool
+	// ForkHeight is the height of the common ancestor of the tip and the
+	// current head.
+	ForkHeight abi.ChainEpoch
+	// FirstSeen is when the tip was offered as a head, it is unset for a
+	// head that wasn't.
+	FirstSeen time.Time `json:",omitempty"`
+}
+
+// BlockDAG is the graph of the recent blocks known to the node: those of the
+// heaviest chain, of the chains of the fork tips, and the other blocks
+// received.
+type BlockDAG struct {
+	Head types.TipSetKey
+	// From and To are the heights of the blocks included.
+	From abi.ChainEpoch
+	To   abi.ChainEpoch
+	// Blocks are sorted by height, the parent links being the edges of the
+	// graph; the parents below From are not included.
+	Blocks []*DAGBlock
+}
+
+// DAGBlock is a block of a BlockDAG.
+type DAGBlock struct {
+	Cid          cid.Cid
+	Height       abi.ChainEpoch
+	Miner        address.Address
+	Parents      []cid.Cid
+	ParentWeight types.BigInt
+	WinCount     int64
+	Timestamp    uint64
+	// Heaviest is set for the blocks of the heaviest chain.
+	Heaviest bool
+	// Tip is set for the blocks of the fork tips, the head included.
+	Tip bool
+}
+
+// PendingReorg is a reorg held back until an operator accepts or rejects it.
+type PendingReorg struct {
+	// From is the head when the reorg was held back.
+	From       types.TipSetKey
+	FromHeight abi.ChainEpoch
+	// To is the head of the fork.
+	To       types.TipSetKey
+	ToHeight abi.ChainEpoch
+	// Ancestor is the common ancestor of From and To.
+	Ancestor       types.TipSetKey
+	AncestorHeight abi.ChainEpoch
+	// Depth is the number of epochs of the chain of From that the reorg
+	// reverts.
+	Depth abi.ChainEpoch
+	Seen  time.Time
+}
+
+// SnapshotStatus is the status of the snapshots exported by the node.
+type SnapshotStatus struct {
+	// Enabled is set if the node exports snapshots.
+	Enabled bool
+	// Interval is the number of epochs between snapshots.
+	Interval abi.ChainEpoch
+	// Running is the snapshot being exported, if any.
+	Running *SnapshotInfo `json:",omitempty"`
+	// Last is the last snapshot export that finished, successfully or not.
+	Last *SnapshotInfo `json:",omitempty"`
+	// Snapshots are the retained snapshots, oldest first.
+	Snapshots []SnapshotInfo
+}
+
+// SnapshotInfo describes a snapshot exported by the node.
+type SnapshotInfo struct {
+	Tipset types.TipSetKey
+	Height abi.ChainEpoch
+	// Location is the path or object store url of the snapshot.
+	Location string
+	// Size is the uncompressed size of the snapshot.
+	Size uint64
+
+	Started  time.Time
+	Finished time.Time `json:",omitempty"`
+	Error    string    `json:",omitempty"`
+}
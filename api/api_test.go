@@ -2,6 +2,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"os/exec"
@@ -11,10 +12,15 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/chain/types"
 )
 
 func goCmd() string {
@@ -128,6 +134,28 @@ func TestPermTags(t *testing.T) {
 	_ = PermissionedWorkerAPI(&WorkerStruct{})
 }
 
+func TestReadOnlyFullAPI(t *testing.T) {
+	var s FullNodeStruct
+	s.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+		return &types.TipSet{}, nil
+	}
+	s.Internal.MpoolPush = func(context.Context, *types.SignedMessage) (cid.Cid, error) {
+		return cid.Undef, nil
+	}
+
+	a := ReadOnlyFullAPI(&s)
+	ctx := auth.WithPerm(context.Background(), AllPermissions)
+
+	ts, err := a.ChainHead(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, ts)
+
+	_, err = a.MpoolPush(ctx, &types.SignedMessage{})
+	require.ErrorContains(t, err, "read-only")
+	_, err = a.WalletSign(ctx, address.Undef, nil)
+	require.ErrorContains(t, err, "read-only")
+}
+
 func TestRetryErrorIsInTrue(t *testing.T) {
 	errorsToRetry := []error{&jsonrpc.RPCConnectionError{}}
 	require.True(t, ErrorIsIn(&jsonrpc.RPCConnectionError{}, errorsToRetry))
@@ -122,6 +122,8 @@ func init() {
 	addExample(abi.SectorNumber(9))
 	addExample(abi.SectorSize(32 * 1024 * 1024 * 1024))
 	addExample(api.MpoolChange(0))
+	addExample(api.MpoolMessagePending)
+	addExample([]api.MpoolMessageStatus{api.MpoolMessagePending})
 	addExample(network.Connected)
 	addExample(dtypes.NetworkName("lotus"))
 	addExample(api.SyncStateStage(1))
@@ -168,6 +170,9 @@ func init() {
 	addExample(map[string]api.MarketBalance{
 		"t026363": ExampleValue("init", reflect.TypeOf(api.MarketBalance{}), nil).(api.MarketBalance),
 	})
+	addExample(map[string]api.BlockstoreObjects{
+		"dag-cbor": {Count: 42, Bytes: 4200},
+	})
 	addExample(map[string]*pubsub.TopicScoreSnapshot{
 		"/blocks": {
 			TimeInMesh:               time.Minute,
@@ -0,0 +1,1327 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.20.3
+// source: lotus.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Cid struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cid []byte `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+}
+
+func (x *Cid) Reset() {
+	*x = Cid{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Cid) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cid) ProtoMessage() {}
+
+func (x *Cid) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cid.ProtoReflect.Descriptor instead.
+func (*Cid) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Cid) GetCid() []byte {
+	if x != nil {
+		return x.Cid
+	}
+	return nil
+}
+
+type Address struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address []byte `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Address) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+// TipSetKey is the key of a tipset, the empty key being the key of the head
+// of the chain.
+type TipSetKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cids [][]byte `protobuf:"bytes,1,rep,name=cids,proto3" json:"cids,omitempty"`
+}
+
+func (x *TipSetKey) Reset() {
+	*x = TipSetKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TipSetKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TipSetKey) ProtoMessage() {}
+
+func (x *TipSetKey) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TipSetKey.ProtoReflect.Descriptor instead.
+func (*TipSetKey) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TipSetKey) GetCids() [][]byte {
+	if x != nil {
+		return x.Cids
+	}
+	return nil
+}
+
+type TipSet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cids [][]byte `protobuf:"bytes,1,rep,name=cids,proto3" json:"cids,omitempty"`
+	// blocks are the CBOR encoded block headers.
+	Blocks [][]byte `protobuf:"bytes,2,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Height int64    `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *TipSet) Reset() {
+	*x = TipSet{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TipSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TipSet) ProtoMessage() {}
+
+func (x *TipSet) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TipSet.ProtoReflect.Descriptor instead.
+func (*TipSet) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TipSet) GetCids() [][]byte {
+	if x != nil {
+		return x.Cids
+	}
+	return nil
+}
+
+func (x *TipSet) GetBlocks() [][]byte {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *TipSet) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type ChainHeadRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ChainHeadRequest) Reset() {
+	*x = ChainHeadRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChainHeadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainHeadRequest) ProtoMessage() {}
+
+func (x *ChainHeadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainHeadRequest.ProtoReflect.Descriptor instead.
+func (*ChainHeadRequest) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{4}
+}
+
+type ChainGetTipSetByHeightRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height    int64      `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	TipsetKey *TipSetKey `protobuf:"bytes,2,opt,name=tipset_key,json=tipsetKey,proto3" json:"tipset_key,omitempty"`
+}
+
+func (x *ChainGetTipSetByHeightRequest) Reset() {
+	*x = ChainGetTipSetByHeightRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChainGetTipSetByHeightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainGetTipSetByHeightRequest) ProtoMessage() {}
+
+func (x *ChainGetTipSetByHeightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainGetTipSetByHeightRequest.ProtoReflect.Descriptor instead.
+func (*ChainGetTipSetByHeightRequest) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ChainGetTipSetByHeightRequest) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ChainGetTipSetByHeightRequest) GetTipsetKey() *TipSetKey {
+	if x != nil {
+		return x.TipsetKey
+	}
+	return nil
+}
+
+type ChainNotifyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ChainNotifyRequest) Reset() {
+	*x = ChainNotifyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChainNotifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainNotifyRequest) ProtoMessage() {}
+
+func (x *ChainNotifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainNotifyRequest.ProtoReflect.Descriptor instead.
+func (*ChainNotifyRequest) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{6}
+}
+
+type HeadChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// type is "current", "apply" or "revert".
+	Type   string  `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Tipset *TipSet `protobuf:"bytes,2,opt,name=tipset,proto3" json:"tipset,omitempty"`
+}
+
+func (x *HeadChange) Reset() {
+	*x = HeadChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeadChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeadChange) ProtoMessage() {}
+
+func (x *HeadChange) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeadChange.ProtoReflect.Descriptor instead.
+func (*HeadChange) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HeadChange) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HeadChange) GetTipset() *TipSet {
+	if x != nil {
+		return x.Tipset
+	}
+	return nil
+}
+
+type HeadChanges struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Changes []*HeadChange `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+}
+
+func (x *HeadChanges) Reset() {
+	*x = HeadChanges{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeadChanges) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeadChanges) ProtoMessage() {}
+
+func (x *HeadChanges) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeadChanges.ProtoReflect.Descriptor instead.
+func (*HeadChanges) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeadChanges) GetChanges() []*HeadChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cid []byte `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// message is the CBOR encoded unsigned message.
+	Message []byte `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Message) GetCid() []byte {
+	if x != nil {
+		return x.Cid
+	}
+	return nil
+}
+
+func (x *Message) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type Receipt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExitCode int64  `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Return   []byte `protobuf:"bytes,2,opt,name=return,proto3" json:"return,omitempty"`
+	GasUsed  int64  `protobuf:"varint,3,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Receipt) GetExitCode() int64 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *Receipt) GetReturn() []byte {
+	if x != nil {
+		return x.Return
+	}
+	return nil
+}
+
+func (x *Receipt) GetGasUsed() int64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+type Object struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *Object) Reset() {
+	*x = Object{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Object) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Object) ProtoMessage() {}
+
+func (x *Object) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Object.ProtoReflect.Descriptor instead.
+func (*Object) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Object) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type StateSearchMsgRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TipsetKey *TipSetKey `protobuf:"bytes,1,opt,name=tipset_key,json=tipsetKey,proto3" json:"tipset_key,omitempty"`
+	Message   []byte     `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// lookback_limit is the number of epochs to look back, -1 not limiting
+	// the search.
+	LookbackLimit int64 `protobuf:"varint,3,opt,name=lookback_limit,json=lookbackLimit,proto3" json:"lookback_limit,omitempty"`
+	AllowReplaced bool  `protobuf:"varint,4,opt,name=allow_replaced,json=allowReplaced,proto3" json:"allow_replaced,omitempty"`
+}
+
+func (x *StateSearchMsgRequest) Reset() {
+	*x = StateSearchMsgRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StateSearchMsgRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateSearchMsgRequest) ProtoMessage() {}
+
+func (x *StateSearchMsgRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateSearchMsgRequest.ProtoReflect.Descriptor instead.
+func (*StateSearchMsgRequest) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StateSearchMsgRequest) GetTipsetKey() *TipSetKey {
+	if x != nil {
+		return x.TipsetKey
+	}
+	return nil
+}
+
+func (x *StateSearchMsgRequest) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *StateSearchMsgRequest) GetLookbackLimit() int64 {
+	if x != nil {
+		return x.LookbackLimit
+	}
+	return 0
+}
+
+func (x *StateSearchMsgRequest) GetAllowReplaced() bool {
+	if x != nil {
+		return x.AllowReplaced
+	}
+	return false
+}
+
+type MsgLookup struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// message is the CID of the executed message, which differs from the
+	// searched one when it was replaced.
+	Message   []byte     `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Receipt   *Receipt   `protobuf:"bytes,2,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	TipsetKey *TipSetKey `protobuf:"bytes,3,opt,name=tipset_key,json=tipsetKey,proto3" json:"tipset_key,omitempty"`
+	Height    int64      `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *MsgLookup) Reset() {
+	*x = MsgLookup{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MsgLookup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgLookup) ProtoMessage() {}
+
+func (x *MsgLookup) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgLookup.ProtoReflect.Descriptor instead.
+func (*MsgLookup) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MsgLookup) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *MsgLookup) GetReceipt() *Receipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+func (x *MsgLookup) GetTipsetKey() *TipSetKey {
+	if x != nil {
+		return x.TipsetKey
+	}
+	return nil
+}
+
+func (x *MsgLookup) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type StateActorRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address   []byte     `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	TipsetKey *TipSetKey `protobuf:"bytes,2,opt,name=tipset_key,json=tipsetKey,proto3" json:"tipset_key,omitempty"`
+}
+
+func (x *StateActorRequest) Reset() {
+	*x = StateActorRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StateActorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateActorRequest) ProtoMessage() {}
+
+func (x *StateActorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateActorRequest.ProtoReflect.Descriptor instead.
+func (*StateActorRequest) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StateActorRequest) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *StateActorRequest) GetTipsetKey() *TipSetKey {
+	if x != nil {
+		return x.TipsetKey
+	}
+	return nil
+}
+
+type Actor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code  []byte `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Head  []byte `protobuf:"bytes,2,opt,name=head,proto3" json:"head,omitempty"`
+	Nonce uint64 `protobuf:"varint,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// balance is in attoFIL, in the CBOR encoding of big integers.
+	Balance []byte `protobuf:"bytes,4,opt,name=balance,proto3" json:"balance,omitempty"`
+	// address is the robust address of the actor, if any.
+	Address []byte `protobuf:"bytes,5,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *Actor) Reset() {
+	*x = Actor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lotus_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Actor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Actor) ProtoMessage() {}
+
+func (x *Actor) ProtoReflect() protoreflect.Message {
+	mi := &file_lotus_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Actor.ProtoReflect.Descriptor instead.
+func (*Actor) Descriptor() ([]byte, []int) {
+	return file_lotus_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Actor) GetCode() []byte {
+	if x != nil {
+		return x.Code
+	}
+	return nil
+}
+
+func (x *Actor) GetHead() []byte {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
+func (x *Actor) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Actor) GetBalance() []byte {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *Actor) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+var File_lotus_proto protoreflect.FileDescriptor
+
+var file_lotus_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x6c,
+	0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x17, 0x0a, 0x03, 0x43, 0x69, 0x64, 0x12, 0x10,
+	0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x63, 0x69, 0x64,
+	0x22, 0x23, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x1f, 0x0a, 0x09, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x4b,
+	0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c,
+	0x52, 0x04, 0x63, 0x69, 0x64, 0x73, 0x22, 0x4c, 0x0a, 0x06, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04,
+	0x63, 0x69, 0x64, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x0a, 0x06,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x22, 0x12, 0x0a, 0x10, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x48, 0x65, 0x61,
+	0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x6b, 0x0a, 0x1d, 0x43, 0x68, 0x61, 0x69,
+	0x6e, 0x47, 0x65, 0x74, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x42, 0x79, 0x48, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x12, 0x32, 0x0a, 0x0a, 0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x09, 0x74, 0x69, 0x70, 0x73,
+	0x65, 0x74, 0x4b, 0x65, 0x79, 0x22, 0x14, 0x0a, 0x12, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4e, 0x6f,
+	0x74, 0x69, 0x66, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x4a, 0x0a, 0x0a, 0x48,
+	0x65, 0x61, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x28, 0x0a,
+	0x06, 0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e,
+	0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x52,
+	0x06, 0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x22, 0x3d, 0x0a, 0x0b, 0x48, 0x65, 0x61, 0x64, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x2e, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x07, 0x63,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0x35, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x63, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x59, 0x0a,
+	0x07, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78, 0x69, 0x74,
+	0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65, 0x78, 0x69,
+	0x74, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x12, 0x19, 0x0a,
+	0x08, 0x67, 0x61, 0x73, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x67, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64, 0x22, 0x1c, 0x0a, 0x06, 0x4f, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0xb3, 0x01, 0x0a, 0x15, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x32, 0x0a, 0x0a, 0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x09, 0x74, 0x69, 0x70, 0x73, 0x65,
+	0x74, 0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25,
+	0x0a, 0x0e, 0x6c, 0x6f, 0x6f, 0x6b, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x6c, 0x6f, 0x6f, 0x6b, 0x62, 0x61, 0x63, 0x6b,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x72,
+	0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x61,
+	0x6c, 0x6c, 0x6f, 0x77, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x22, 0x9e, 0x01, 0x0a,
+	0x09, 0x4d, 0x73, 0x67, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x2b, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x52, 0x07, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70,
+	0x74, 0x12, 0x32, 0x0a, 0x0a, 0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x09, 0x74, 0x69, 0x70, 0x73,
+	0x65, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x61, 0x0a,
+	0x11, 0x53, 0x74, 0x61, 0x74, 0x65, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x32, 0x0a, 0x0a,
+	0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69, 0x70, 0x53,
+	0x65, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x09, 0x74, 0x69, 0x70, 0x73, 0x65, 0x74, 0x4b, 0x65, 0x79,
+	0x22, 0x79, 0x0a, 0x05, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x68, 0x65, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x65, 0x61,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e,
+	0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x32, 0xc8, 0x05, 0x0a, 0x05,
+	0x4c, 0x6f, 0x74, 0x75, 0x73, 0x12, 0x39, 0x0a, 0x09, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x48, 0x65,
+	0x61, 0x64, 0x12, 0x1a, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68,
+	0x61, 0x69, 0x6e, 0x48, 0x65, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10,
+	0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74,
+	0x12, 0x37, 0x0a, 0x0e, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x47, 0x65, 0x74, 0x54, 0x69, 0x70, 0x53,
+	0x65, 0x74, 0x12, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69,
+	0x70, 0x53, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x1a, 0x10, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x12, 0x53, 0x0a, 0x16, 0x43, 0x68, 0x61,
+	0x69, 0x6e, 0x47, 0x65, 0x74, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x42, 0x79, 0x48, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x12, 0x27, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x68, 0x61, 0x69, 0x6e, 0x47, 0x65, 0x74, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x42, 0x79, 0x48,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x6c,
+	0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x12, 0x44,
+	0x0a, 0x0b, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x12, 0x1c, 0x2e,
+	0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4e, 0x6f,
+	0x74, 0x69, 0x66, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x6c, 0x6f,
+	0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x73, 0x30, 0x01, 0x12, 0x33, 0x0a, 0x0f, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x47, 0x65, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x0d, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x43, 0x69, 0x64, 0x1a, 0x11, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x44, 0x0a, 0x18, 0x43, 0x68, 0x61,
+	0x69, 0x6e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x49, 0x6e, 0x54,
+	0x69, 0x70, 0x53, 0x65, 0x74, 0x12, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x54, 0x69, 0x70, 0x53, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x1a, 0x11, 0x2e, 0x6c, 0x6f, 0x74,
+	0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x30, 0x01, 0x12,
+	0x3c, 0x0a, 0x16, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x47, 0x65, 0x74, 0x50, 0x61, 0x72, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x73, 0x12, 0x0d, 0x2e, 0x6c, 0x6f, 0x74, 0x75,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x64, 0x1a, 0x11, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x30, 0x01, 0x12, 0x2f, 0x0a,
+	0x0c, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x61, 0x64, 0x4f, 0x62, 0x6a, 0x12, 0x0d, 0x2e,
+	0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x64, 0x1a, 0x10, 0x2e, 0x6c,
+	0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x46,
+	0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x73, 0x67,
+	0x12, 0x1f, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x13, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x73, 0x67,
+	0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x12, 0x3d, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x65, 0x47,
+	0x65, 0x74, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x1b, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x3f, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x65, 0x4c, 0x6f,
+	0x6f, 0x6b, 0x75, 0x70, 0x49, 0x44, 0x12, 0x1b, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x66, 0x69, 0x6c, 0x65, 0x63, 0x6f, 0x69, 0x6e, 0x2d, 0x70, 0x72,
+	0x6f, 0x6a, 0x65, 0x63, 0x74, 0x2f, 0x6c, 0x6f, 0x74, 0x75, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x2f,
+	0x67, 0x72, 0x70, 0x63, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_lotus_proto_rawDescOnce sync.Once
+	file_lotus_proto_rawDescData = file_lotus_proto_rawDesc
+)
+
+func file_lotus_proto_rawDescGZIP() []byte {
+	file_lotus_proto_rawDescOnce.Do(func() {
+		file_lotus_proto_rawDescData = protoimpl.X.CompressGZIP(file_lotus_proto_rawDescData)
+	})
+	return file_lotus_proto_rawDescData
+}
+
+var file_lotus_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_lotus_proto_goTypes = []interface{}{
+	(*Cid)(nil),                           // 0: lotus.v1.Cid
+	(*Address)(nil),                       // 1: lotus.v1.Address
+	(*TipSetKey)(nil),                     // 2: lotus.v1.TipSetKey
+	(*TipSet)(nil),                        // 3: lotus.v1.TipSet
+	(*ChainHeadRequest)(nil),              // 4: lotus.v1.ChainHeadRequest
+	(*ChainGetTipSetByHeightRequest)(nil), // 5: lotus.v1.ChainGetTipSetByHeightRequest
+	(*ChainNotifyRequest)(nil),            // 6: lotus.v1.ChainNotifyRequest
+	(*HeadChange)(nil),                    // 7: lotus.v1.HeadChange
+	(*HeadChanges)(nil),                   // 8: lotus.v1.HeadChanges
+	(*Message)(nil),                       // 9: lotus.v1.Message
+	(*Receipt)(nil),                       // 10: lotus.v1.Receipt
+	(*Object)(nil),                        // 11: lotus.v1.Object
+	(*StateSearchMsgRequest)(nil),         // 12: lotus.v1.StateSearchMsgRequest
+	(*MsgLookup)(nil),                     // 13: lotus.v1.MsgLookup
+	(*StateActorRequest)(nil),             // 14: lotus.v1.StateActorRequest
+	(*Actor)(nil),                         // 15: lotus.v1.Actor
+}
+var file_lotus_proto_depIdxs = []int32{
+	2,  // 0: lotus.v1.ChainGetTipSetByHeightRequest.tipset_key:type_name -> lotus.v1.TipSetKey
+	3,  // 1: lotus.v1.HeadChange.tipset:type_name -> lotus.v1.TipSet
+	7,  // 2: lotus.v1.HeadChanges.changes:type_name -> lotus.v1.HeadChange
+	2,  // 3: lotus.v1.StateSearchMsgRequest.tipset_key:type_name -> lotus.v1.TipSetKey
+	10, // 4: lotus.v1.MsgLookup.receipt:type_name -> lotus.v1.Receipt
+	2,  // 5: lotus.v1.MsgLookup.tipset_key:type_name -> lotus.v1.TipSetKey
+	2,  // 6: lotus.v1.StateActorRequest.tipset_key:type_name -> lotus.v1.TipSetKey
+	4,  // 7: lotus.v1.Lotus.ChainHead:input_type -> lotus.v1.ChainHeadRequest
+	2,  // 8: lotus.v1.Lotus.ChainGetTipSet:input_type -> lotus.v1.TipSetKey
+	5,  // 9: lotus.v1.Lotus.ChainGetTipSetByHeight:input_type -> lotus.v1.ChainGetTipSetByHeightRequest
+	6,  // 10: lotus.v1.Lotus.ChainNotify:input_type -> lotus.v1.ChainNotifyRequest
+	0,  // 11: lotus.v1.Lotus.ChainGetMessage:input_type -> lotus.v1.Cid
+	2,  // 12: lotus.v1.Lotus.ChainGetMessagesInTipSet:input_type -> lotus.v1.TipSetKey
+	0,  // 13: lotus.v1.Lotus.ChainGetParentReceipts:input_type -> lotus.v1.Cid
+	0,  // 14: lotus.v1.Lotus.ChainReadObj:input_type -> lotus.v1.Cid
+	12, // 15: lotus.v1.Lotus.StateSearchMsg:input_type -> lotus.v1.StateSearchMsgRequest
+	14, // 16: lotus.v1.Lotus.StateGetActor:input_type -> lotus.v1.StateActorRequest
+	14, // 17: lotus.v1.Lotus.StateLookupID:input_type -> lotus.v1.StateActorRequest
+	3,  // 18: lotus.v1.Lotus.ChainHead:output_type -> lotus.v1.TipSet
+	3,  // 19: lotus.v1.Lotus.ChainGetTipSet:output_type -> lotus.v1.TipSet
+	3,  // 20: lotus.v1.Lotus.ChainGetTipSetByHeight:output_type -> lotus.v1.TipSet
+	8,  // 21: lotus.v1.Lotus.ChainNotify:output_type -> lotus.v1.HeadChanges
+	9,  // 22: lotus.v1.Lotus.ChainGetMessage:output_type -> lotus.v1.Message
+	9,  // 23: lotus.v1.Lotus.ChainGetMessagesInTipSet:output_type -> lotus.v1.Message
+	10, // 24: lotus.v1.Lotus.ChainGetParentReceipts:output_type -> lotus.v1.Receipt
+	11, // 25: lotus.v1.Lotus.ChainReadObj:output_type -> lotus.v1.Object
+	13, // 26: lotus.v1.Lotus.StateSearchMsg:output_type -> lotus.v1.MsgLookup
+	15, // 27: lotus.v1.Lotus.StateGetActor:output_type -> lotus.v1.Actor
+	1,  // 28: lotus.v1.Lotus.StateLookupID:output_type -> lotus.v1.Address
+	18, // [18:29] is the sub-list for method output_type
+	7,  // [7:18] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_lotus_proto_init() }
+func file_lotus_proto_init() {
+	if File_lotus_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_lotus_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Cid); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Address); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TipSetKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TipSet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChainHeadRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChainGetTipSetByHeightRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChainNotifyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeadChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeadChanges); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Message); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Receipt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Object); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StateSearchMsgRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MsgLookup); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StateActorRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lotus_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Actor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_lotus_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_lotus_proto_goTypes,
+		DependencyIndexes: file_lotus_proto_depIdxs,
+		MessageInfos:      file_lotus_proto_msgTypes,
+	}.Build()
+	File_lotus_proto = out.File
+	file_lotus_proto_rawDesc = nil
+	file_lotus_proto_goTypes = nil
+	file_lotus_proto_depIdxs = nil
+}
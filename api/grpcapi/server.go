@@ -0,0 +1,309 @@
+// Package grpcapi serves the most used chain and state reads of the full node
+// API over gRPC, for the clients calling them at high rates, such as indexers.
+// The service is defined in lotus.proto; run `make grpc-gen` after changing it.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Server serves the Lotus service with a full node API.
+type Server struct {
+	UnimplementedLotusServer
+
+	api v1api.FullNode
+}
+
+var _ LotusServer = (*Server)(nil)
+
+// NewServer returns a server of the Lotus service calling a.
+func NewServer(a v1api.FullNode) *Server {
+	return &Server{api: a}
+}
+
+func (s *Server) ChainHead(ctx context.Context, _ *ChainHeadRequest) (*TipSet, error) {
+	ts, err := s.api.ChainHead(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return fromTipSet(ts)
+}
+
+func (s *Server) ChainGetTipSet(ctx context.Context, req *TipSetKey) (*TipSet, error) {
+	tsk, err := toTipSetKey(req)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.api.ChainGetTipSet(ctx, tsk)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return fromTipSet(ts)
+}
+
+func (s *Server) ChainGetTipSetByHeight(ctx context.Context, req *ChainGetTipSetByHeightRequest) (*TipSet, error) {
+	tsk, err := toTipSetKey(req.TipsetKey)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.api.ChainGetTipSetByHeight(ctx, abi.ChainEpoch(req.Height), tsk)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return fromTipSet(ts)
+}
+
+func (s *Server) ChainNotify(_ *ChainNotifyRequest, stream Lotus_ChainNotifyServer) error {
+	changes, err := s.api.ChainNotify(stream.Context())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for hcs := range changes {
+		out := &HeadChanges{Changes: make([]*HeadChange, len(hcs))}
+		for i, hc := range hcs {
+			ts, err := fromTipSet(hc.Val)
+			if err != nil {
+				return err
+			}
+			out.Changes[i] = &HeadChange{Type: hc.Type, Tipset: ts}
+		}
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+
+	// the changes end when the stream is closed, or when the node shuts down
+	return toStatus(stream.Context().Err())
+}
+
+func (s *Server) ChainGetMessage(ctx context.Context, req *Cid) (*Message, error) {
+	c, err := toCid(req.Cid)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := s.api.ChainGetMessage(ctx, c)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return fromMessage(c, msg)
+}
+
+func (s *Server) ChainGetMessagesInTipSet(req *TipSetKey, stream Lotus_ChainGetMessagesInTipSetServer) error {
+	tsk, err := toTipSetKey(req)
+	if err != nil {
+		return err
+	}
+	msgs, err := s.api.ChainGetMessagesInTipset(stream.Context(), tsk)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, m := range msgs {
+		out, err := fromMessage(m.Cid, m.Message)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) ChainGetParentReceipts(req *Cid, stream Lotus_ChainGetParentReceiptsServer) error {
+	c, err := toCid(req.Cid)
+	if err != nil {
+		return err
+	}
+	rcpts, err := s.api.ChainGetParentReceipts(stream.Context(), c)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, r := range rcpts {
+		if err := stream.Send(fromReceipt(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) ChainReadObj(ctx context.Context, req *Cid) (*Object, error) {
+	c, err := toCid(req.Cid)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.api.ChainReadObj(ctx, c)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Object{Data: data}, nil
+}
+
+func (s *Server) StateSearchMsg(ctx context.Context, req *StateSearchMsgRequest) (*MsgLookup, error) {
+	tsk, err := toTipSetKey(req.TipsetKey)
+	if err != nil {
+		return nil, err
+	}
+	c, err := toCid(req.Message)
+	if err != nil {
+		return nil, err
+	}
+	lookup, err := s.api.StateSearchMsg(ctx, tsk, c, abi.ChainEpoch(req.LookbackLimit), req.AllowReplaced)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if lookup == nil {
+		return nil, status.Errorf(codes.NotFound, "message %s not found", c)
+	}
+
+	return &MsgLookup{
+		Message:   lookup.Message.Bytes(),
+		Receipt:   fromReceipt(&lookup.Receipt),
+		TipsetKey: fromTipSetKey(lookup.TipSet),
+		Height:    int64(lookup.Height),
+	}, nil
+}
+
+func (s *Server) StateGetActor(ctx context.Context, req *StateActorRequest) (*Actor, error) {
+	addr, tsk, err := toActorRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	act, err := s.api.StateGetActor(ctx, addr, tsk)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	balance, err := act.Balance.Bytes()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding balance: %s", err)
+	}
+	out := &Actor{
+		Code:    act.Code.Bytes(),
+		Head:    act.Head.Bytes(),
+		Nonce:   act.Nonce,
+		Balance: balance,
+	}
+	if act.Address != nil {
+		out.Address = act.Address.Bytes()
+	}
+	return out, nil
+}
+
+func (s *Server) StateLookupID(ctx context.Context, req *StateActorRequest) (*Address, error) {
+	addr, tsk, err := toActorRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.api.StateLookupID(ctx, addr, tsk)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Address{Address: id.Bytes()}, nil
+}
+
+// toStatus returns the gRPC status error of an API error.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var rl *api.ErrRateLimited
+	switch {
+	case errors.As(err, &rl):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+func toCid(b []byte) (cid.Cid, error) {
+	c, err := cid.Cast(b)
+	if err != nil {
+		return cid.Undef, status.Errorf(codes.InvalidArgument, "invalid cid: %s", err)
+	}
+	return c, nil
+}
+
+func toTipSetKey(k *TipSetKey) (types.TipSetKey, error) {
+	if k == nil {
+		return types.EmptyTSK, nil
+	}
+	cids := make([]cid.Cid, len(k.Cids))
+	for i, b := range k.Cids {
+		c, err := toCid(b)
+		if err != nil {
+			return types.EmptyTSK, err
+		}
+		cids[i] = c
+	}
+	return types.NewTipSetKey(cids...), nil
+}
+
+func toActorRequest(req *StateActorRequest) (address.Address, types.TipSetKey, error) {
+	addr, err := address.NewFromBytes(req.Address)
+	if err != nil {
+		return address.Undef, types.EmptyTSK, status.Errorf(codes.InvalidArgument, "invalid address: %s", err)
+	}
+	tsk, err := toTipSetKey(req.TipsetKey)
+	return addr, tsk, err
+}
+
+func fromTipSetKey(tsk types.TipSetKey) *TipSetKey {
+	cids := tsk.Cids()
+	out := &TipSetKey{Cids: make([][]byte, len(cids))}
+	for i, c := range cids {
+		out.Cids[i] = c.Bytes()
+	}
+	return out
+}
+
+func fromTipSet(ts *types.TipSet) (*TipSet, error) {
+	out := &TipSet{
+		Cids:   fromTipSetKey(ts.Key()).Cids,
+		Blocks: make([][]byte, len(ts.Blocks())),
+		Height: int64(ts.Height()),
+	}
+	for i, blk := range ts.Blocks() {
+		b, err := blk.Serialize()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "encoding block %s: %s", blk.Cid(), err)
+		}
+		out.Blocks[i] = b
+	}
+	return out, nil
+}
+
+func fromMessage(c cid.Cid, msg *types.Message) (*Message, error) {
+	b, err := msg.Serialize()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding message %s: %s", c, err)
+	}
+	return &Message{Cid: c.Bytes(), Message: b}, nil
+}
+
+func fromReceipt(r *types.MessageReceipt) *Receipt {
+	return &Receipt{
+		ExitCode: int64(r.ExitCode),
+		Return:   r.Return,
+		GasUsed:  r.GasUsed,
+	}
+}
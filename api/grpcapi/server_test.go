@@ -0,0 +1,111 @@
+// stm: #unit
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/mocks"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	full := mocks.NewMockFullNode(ctrl)
+
+	lst := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterLotusServer(srv, NewServer(full))
+	go srv.Serve(lst) //nolint:errcheck
+	defer srv.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lst.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+	client := NewLotusClient(conn)
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	next := mock.TipSet(mock.MkBlock(ts, 1, 1))
+
+	t.Run("head", func(t *testing.T) {
+		full.EXPECT().ChainHead(gomock.Any()).Return(ts, nil)
+
+		out, err := client.ChainHead(ctx, &ChainHeadRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int64(ts.Height()), out.Height)
+		require.Equal(t, ts.Cids()[0].Bytes(), out.Cids[0])
+
+		var blk types.BlockHeader
+		require.NoError(t, blk.UnmarshalCBOR(bytes.NewReader(out.Blocks[0])))
+		require.Equal(t, ts.Blocks()[0].Cid(), blk.Cid())
+	})
+
+	t.Run("notify", func(t *testing.T) {
+		changes := make(chan []*api.HeadChange, 2)
+		changes <- []*api.HeadChange{{Type: "current", Val: ts}}
+		changes <- []*api.HeadChange{{Type: "apply", Val: next}}
+		close(changes)
+		full.EXPECT().ChainNotify(gomock.Any()).Return((<-chan []*api.HeadChange)(changes), nil)
+
+		stream, err := client.ChainNotify(ctx, &ChainNotifyRequest{})
+		require.NoError(t, err)
+
+		var got []string
+		for {
+			hcs, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			for _, hc := range hcs.Changes {
+				got = append(got, hc.Type)
+			}
+		}
+		require.Equal(t, []string{"current", "apply"}, got)
+	})
+
+	t.Run("message not found", func(t *testing.T) {
+		c := ts.Cids()[0]
+		full.EXPECT().StateSearchMsg(gomock.Any(), types.EmptyTSK, c, abi.ChainEpoch(-1), true).Return(nil, nil)
+
+		_, err := client.StateSearchMsg(ctx, &StateSearchMsgRequest{Message: c.Bytes(), LookbackLimit: -1, AllowReplaced: true})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		full.EXPECT().ChainHead(gomock.Any()).Return(nil, &api.ErrRateLimited{Limit: "token", RetryAfter: time.Second})
+
+		_, err := client.ChainHead(ctx, &ChainHeadRequest{})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := client.ChainReadObj(ctx, &Cid{Cid: []byte("nope")})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -95,6 +95,49 @@ func (mr *MockFullNodeMockRecorder) AuthVerify(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthVerify", reflect.TypeOf((*MockFullNode)(nil).AuthVerify), arg0, arg1)
 }
 
+// ChainBlockstoreGC mocks base method.
+func (m *MockFullNode) ChainBlockstoreGC(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreGC", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreGC indicates an expected call of ChainBlockstoreGC.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreGC(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreGC", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreGC), arg0)
+}
+
+// ChainBlockstoreGCAbort mocks base method.
+func (m *MockFullNode) ChainBlockstoreGCAbort(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreGCAbort", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreGCAbort indicates an expected call of ChainBlockstoreGCAbort.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreGCAbort(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreGCAbort", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreGCAbort), arg0)
+}
+
+// ChainBlockstoreGCStatus mocks base method.
+func (m *MockFullNode) ChainBlockstoreGCStatus(arg0 context.Context) (api.BlockstoreGCStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreGCStatus", arg0)
+	ret0, _ := ret[0].(api.BlockstoreGCStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreGCStatus indicates an expected call of ChainBlockstoreGCStatus.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreGCStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreGCStatus", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreGCStatus), arg0)
+}
+
 // ChainBlockstoreInfo mocks base method.
 func (m *MockFullNode) ChainBlockstoreInfo(arg0 context.Context) (map[string]interface{}, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +153,21 @@ func (mr *MockFullNodeMockRecorder) ChainBlockstoreInfo(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreInfo", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreInfo), arg0)
 }
 
+// ChainBlockstoreUsage mocks base method.
+func (m *MockFullNode) ChainBlockstoreUsage(arg0 context.Context, arg1 int) (api.BlockstoreUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreUsage", arg0, arg1)
+	ret0, _ := ret[0].(api.BlockstoreUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreUsage indicates an expected call of ChainBlockstoreUsage.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreUsage", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreUsage), arg0, arg1)
+}
+
 // ChainCheckBlockstore mocks base method.
 func (m *MockFullNode) ChainCheckBlockstore(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -139,18 +197,48 @@ func (mr *MockFullNodeMockRecorder) ChainDeleteObj(arg0, arg1 interface{}) *gomo
 }
 
 // ChainExport mocks base method.
-func (m *MockFullNode) ChainExport(arg0 context.Context, arg1 abi.ChainEpoch, arg2 bool, arg3 types.TipSetKey) (<-chan []byte, error) {
+func (m *MockFullNode) ChainExport(arg0 context.Context, arg1 abi.ChainEpoch, arg2 bool, arg3 types.TipSetKey, arg4 api.ChainExportOpts) (<-chan []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ChainExport", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "ChainExport", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(<-chan []byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ChainExport indicates an expected call of ChainExport.
-func (mr *MockFullNodeMockRecorder) ChainExport(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockFullNodeMockRecorder) ChainExport(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExport", reflect.TypeOf((*MockFullNode)(nil).ChainExport), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ChainExportEstimate mocks base method.
+func (m *MockFullNode) ChainExportEstimate(arg0 context.Context, arg1 abi.ChainEpoch, arg2 bool, arg3 types.TipSetKey, arg4 api.ChainExportOpts) (api.ExportSizeEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainExportEstimate", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(api.ExportSizeEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainExportEstimate indicates an expected call of ChainExportEstimate.
+func (mr *MockFullNodeMockRecorder) ChainExportEstimate(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExport", reflect.TypeOf((*MockFullNode)(nil).ChainExport), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportEstimate", reflect.TypeOf((*MockFullNode)(nil).ChainExportEstimate), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ChainExportProgress mocks base method.
+func (m *MockFullNode) ChainExportProgress(arg0 context.Context) (<-chan api.ExportProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainExportProgress", arg0)
+	ret0, _ := ret[0].(<-chan api.ExportProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainExportProgress indicates an expected call of ChainExportProgress.
+func (mr *MockFullNodeMockRecorder) ChainExportProgress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportProgress", reflect.TypeOf((*MockFullNode)(nil).ChainExportProgress), arg0)
 }
 
 // ChainGetBlock mocks base method.
@@ -168,6 +256,21 @@ func (mr *MockFullNodeMockRecorder) ChainGetBlock(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetBlock", reflect.TypeOf((*MockFullNode)(nil).ChainGetBlock), arg0, arg1)
 }
 
+// ChainGetBlockDAG mocks base method.
+func (m *MockFullNode) ChainGetBlockDAG(arg0 context.Context, arg1 abi.ChainEpoch) (*api.BlockDAG, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetBlockDAG", arg0, arg1)
+	ret0, _ := ret[0].(*api.BlockDAG)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetBlockDAG indicates an expected call of ChainGetBlockDAG.
+func (mr *MockFullNodeMockRecorder) ChainGetBlockDAG(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetBlockDAG", reflect.TypeOf((*MockFullNode)(nil).ChainGetBlockDAG), arg0, arg1)
+}
+
 // ChainGetBlockMessages mocks base method.
 func (m *MockFullNode) ChainGetBlockMessages(arg0 context.Context, arg1 cid.Cid) (*api.BlockMessages, error) {
 	m.ctrl.T.Helper()
@@ -183,6 +286,21 @@ func (mr *MockFullNodeMockRecorder) ChainGetBlockMessages(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetBlockMessages", reflect.TypeOf((*MockFullNode)(nil).ChainGetBlockMessages), arg0, arg1)
 }
 
+// ChainGetForkTips mocks base method.
+func (m *MockFullNode) ChainGetForkTips(arg0 context.Context) ([]api.ForkTip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetForkTips", arg0)
+	ret0, _ := ret[0].([]api.ForkTip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetForkTips indicates an expected call of ChainGetForkTips.
+func (mr *MockFullNodeMockRecorder) ChainGetForkTips(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetForkTips", reflect.TypeOf((*MockFullNode)(nil).ChainGetForkTips), arg0)
+}
+
 // ChainGetGenesis mocks base method.
 func (m *MockFullNode) ChainGetGenesis(arg0 context.Context) (*types.TipSet, error) {
 	m.ctrl.T.Helper()
@@ -198,6 +316,21 @@ func (mr *MockFullNodeMockRecorder) ChainGetGenesis(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetGenesis", reflect.TypeOf((*MockFullNode)(nil).ChainGetGenesis), arg0)
 }
 
+// ChainGetHeadJournal mocks base method.
+func (m *MockFullNode) ChainGetHeadJournal(arg0 context.Context, arg1 api.HeadJournalQuery) ([]api.HeadJournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetHeadJournal", arg0, arg1)
+	ret0, _ := ret[0].([]api.HeadJournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetHeadJournal indicates an expected call of ChainGetHeadJournal.
+func (mr *MockFullNodeMockRecorder) ChainGetHeadJournal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetHeadJournal", reflect.TypeOf((*MockFullNode)(nil).ChainGetHeadJournal), arg0, arg1)
+}
+
 // ChainGetMessage mocks base method.
 func (m *MockFullNode) ChainGetMessage(arg0 context.Context, arg1 cid.Cid) (*types.Message, error) {
 	m.ctrl.T.Helper()
@@ -288,6 +421,21 @@ func (mr *MockFullNodeMockRecorder) ChainGetPath(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetPath", reflect.TypeOf((*MockFullNode)(nil).ChainGetPath), arg0, arg1, arg2)
 }
 
+// ChainGetPendingReorg mocks base method.
+func (m *MockFullNode) ChainGetPendingReorg(arg0 context.Context) (*api.PendingReorg, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetPendingReorg", arg0)
+	ret0, _ := ret[0].(*api.PendingReorg)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetPendingReorg indicates an expected call of ChainGetPendingReorg.
+func (mr *MockFullNodeMockRecorder) ChainGetPendingReorg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetPendingReorg", reflect.TypeOf((*MockFullNode)(nil).ChainGetPendingReorg), arg0)
+}
+
 // ChainGetTipSet mocks base method.
 func (m *MockFullNode) ChainGetTipSet(arg0 context.Context, arg1 types.TipSetKey) (*types.TipSet, error) {
 	m.ctrl.T.Helper()
@@ -392,6 +540,21 @@ func (mr *MockFullNodeMockRecorder) ChainPrune(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainPrune", reflect.TypeOf((*MockFullNode)(nil).ChainPrune), arg0, arg1)
 }
 
+// ChainPruneHistory mocks base method.
+func (m *MockFullNode) ChainPruneHistory(arg0 context.Context, arg1 api.PruneHistoryOpts) (api.PruneHistoryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainPruneHistory", arg0, arg1)
+	ret0, _ := ret[0].(api.PruneHistoryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainPruneHistory indicates an expected call of ChainPruneHistory.
+func (mr *MockFullNodeMockRecorder) ChainPruneHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainPruneHistory", reflect.TypeOf((*MockFullNode)(nil).ChainPruneHistory), arg0, arg1)
+}
+
 // ChainPutObj mocks base method.
 func (m *MockFullNode) ChainPutObj(arg0 context.Context, arg1 blocks.Block) error {
 	m.ctrl.T.Helper()
@@ -421,6 +584,35 @@ func (mr *MockFullNodeMockRecorder) ChainReadObj(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainReadObj", reflect.TypeOf((*MockFullNode)(nil).ChainReadObj), arg0, arg1)
 }
 
+// ChainResolveReorg mocks base method.
+func (m *MockFullNode) ChainResolveReorg(arg0 context.Context, arg1 types.TipSetKey, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainResolveReorg", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainResolveReorg indicates an expected call of ChainResolveReorg.
+func (mr *MockFullNodeMockRecorder) ChainResolveReorg(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainResolveReorg", reflect.TypeOf((*MockFullNode)(nil).ChainResolveReorg), arg0, arg1, arg2)
+}
+
+// ChainRestoreSnapshot mocks base method.
+func (m *MockFullNode) ChainRestoreSnapshot(arg0 context.Context, arg1 string) (*types.TipSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainRestoreSnapshot", arg0, arg1)
+	ret0, _ := ret[0].(*types.TipSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainRestoreSnapshot indicates an expected call of ChainRestoreSnapshot.
+func (mr *MockFullNodeMockRecorder) ChainRestoreSnapshot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainRestoreSnapshot", reflect.TypeOf((*MockFullNode)(nil).ChainRestoreSnapshot), arg0, arg1)
+}
+
 // ChainSetHead mocks base method.
 func (m *MockFullNode) ChainSetHead(arg0 context.Context, arg1 types.TipSetKey) error {
 	m.ctrl.T.Helper()
@@ -435,6 +627,64 @@ func (mr *MockFullNodeMockRecorder) ChainSetHead(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSetHead", reflect.TypeOf((*MockFullNode)(nil).ChainSetHead), arg0, arg1)
 }
 
+// ChainSnapshotStatus mocks base method.
+func (m *MockFullNode) ChainSnapshotStatus(arg0 context.Context) (api.SnapshotStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSnapshotStatus", arg0)
+	ret0, _ := ret[0].(api.SnapshotStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainSnapshotStatus indicates an expected call of ChainSnapshotStatus.
+func (mr *MockFullNodeMockRecorder) ChainSnapshotStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSnapshotStatus", reflect.TypeOf((*MockFullNode)(nil).ChainSnapshotStatus), arg0)
+}
+
+// ChainSplitstoreCompactionProgress mocks base method.
+func (m *MockFullNode) ChainSplitstoreCompactionProgress(arg0 context.Context) (api.SplitstoreCompactionProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSplitstoreCompactionProgress", arg0)
+	ret0, _ := ret[0].(api.SplitstoreCompactionProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainSplitstoreCompactionProgress indicates an expected call of ChainSplitstoreCompactionProgress.
+func (mr *MockFullNodeMockRecorder) ChainSplitstoreCompactionProgress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSplitstoreCompactionProgress", reflect.TypeOf((*MockFullNode)(nil).ChainSplitstoreCompactionProgress), arg0)
+}
+
+// ChainSplitstorePauseCompaction mocks base method.
+func (m *MockFullNode) ChainSplitstorePauseCompaction(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSplitstorePauseCompaction", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainSplitstorePauseCompaction indicates an expected call of ChainSplitstorePauseCompaction.
+func (mr *MockFullNodeMockRecorder) ChainSplitstorePauseCompaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSplitstorePauseCompaction", reflect.TypeOf((*MockFullNode)(nil).ChainSplitstorePauseCompaction), arg0)
+}
+
+// ChainSplitstoreResumeCompaction mocks base method.
+func (m *MockFullNode) ChainSplitstoreResumeCompaction(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSplitstoreResumeCompaction", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainSplitstoreResumeCompaction indicates an expected call of ChainSplitstoreResumeCompaction.
+func (mr *MockFullNodeMockRecorder) ChainSplitstoreResumeCompaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSplitstoreResumeCompaction", reflect.TypeOf((*MockFullNode)(nil).ChainSplitstoreResumeCompaction), arg0)
+}
+
 // ChainStatObj mocks base method.
 func (m *MockFullNode) ChainStatObj(arg0 context.Context, arg1, arg2 cid.Cid) (api.ObjStat, error) {
 	m.ctrl.T.Helper()
@@ -450,6 +700,36 @@ func (mr *MockFullNodeMockRecorder) ChainStatObj(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainStatObj", reflect.TypeOf((*MockFullNode)(nil).ChainStatObj), arg0, arg1, arg2)
 }
 
+// ChainStateGarbage mocks base method.
+func (m *MockFullNode) ChainStateGarbage(arg0 context.Context, arg1 api.StateGarbageOpts) (api.StateGarbage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainStateGarbage", arg0, arg1)
+	ret0, _ := ret[0].(api.StateGarbage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainStateGarbage indicates an expected call of ChainStateGarbage.
+func (mr *MockFullNodeMockRecorder) ChainStateGarbage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainStateGarbage", reflect.TypeOf((*MockFullNode)(nil).ChainStateGarbage), arg0, arg1)
+}
+
+// ChainTipSetCacheStats mocks base method.
+func (m *MockFullNode) ChainTipSetCacheStats(arg0 context.Context) (api.TipSetCacheStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainTipSetCacheStats", arg0)
+	ret0, _ := ret[0].(api.TipSetCacheStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainTipSetCacheStats indicates an expected call of ChainTipSetCacheStats.
+func (mr *MockFullNodeMockRecorder) ChainTipSetCacheStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainTipSetCacheStats", reflect.TypeOf((*MockFullNode)(nil).ChainTipSetCacheStats), arg0)
+}
+
 // ChainTipSetWeight mocks base method.
 func (m *MockFullNode) ChainTipSetWeight(arg0 context.Context, arg1 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -465,6 +745,21 @@ func (mr *MockFullNodeMockRecorder) ChainTipSetWeight(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainTipSetWeight", reflect.TypeOf((*MockFullNode)(nil).ChainTipSetWeight), arg0, arg1)
 }
 
+// ChainTipSetWeightComponents mocks base method.
+func (m *MockFullNode) ChainTipSetWeightComponents(arg0 context.Context, arg1 types.TipSetKey) (api.TipSetWeightComponents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainTipSetWeightComponents", arg0, arg1)
+	ret0, _ := ret[0].(api.TipSetWeightComponents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainTipSetWeightComponents indicates an expected call of ChainTipSetWeightComponents.
+func (mr *MockFullNodeMockRecorder) ChainTipSetWeightComponents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainTipSetWeightComponents", reflect.TypeOf((*MockFullNode)(nil).ChainTipSetWeightComponents), arg0, arg1)
+}
+
 // ClientCalcCommP mocks base method.
 func (m *MockFullNode) ClientCalcCommP(arg0 context.Context, arg1 string) (*api.CommPRet, error) {
 	m.ctrl.T.Helper()
@@ -936,6 +1231,21 @@ func (mr *MockFullNodeMockRecorder) GasEstimateFeeCap(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasEstimateFeeCap", reflect.TypeOf((*MockFullNode)(nil).GasEstimateFeeCap), arg0, arg1, arg2, arg3)
 }
 
+// GasEstimateFeeCapPercentile mocks base method.
+func (m *MockFullNode) GasEstimateFeeCapPercentile(arg0 context.Context, arg1 uint64, arg2 []float64, arg3 types.TipSetKey) (*api.GasFeePercentiles, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasEstimateFeeCapPercentile", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.GasFeePercentiles)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasEstimateFeeCapPercentile indicates an expected call of GasEstimateFeeCapPercentile.
+func (mr *MockFullNodeMockRecorder) GasEstimateFeeCapPercentile(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasEstimateFeeCapPercentile", reflect.TypeOf((*MockFullNode)(nil).GasEstimateFeeCapPercentile), arg0, arg1, arg2, arg3)
+}
+
 // GasEstimateGasLimit mocks base method.
 func (m *MockFullNode) GasEstimateGasLimit(arg0 context.Context, arg1 *types.Message, arg2 types.TipSetKey) (int64, error) {
 	m.ctrl.T.Helper()
@@ -1278,6 +1588,66 @@ func (mr *MockFullNodeMockRecorder) MpoolGetNonce(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGetNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolGetNonce), arg0, arg1)
 }
 
+// MpoolGetRBFPolicy mocks base method.
+func (m *MockFullNode) MpoolGetRBFPolicy(arg0 context.Context) (*api.MpoolRBFPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolGetRBFPolicy", arg0)
+	ret0, _ := ret[0].(*api.MpoolRBFPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolGetRBFPolicy indicates an expected call of MpoolGetRBFPolicy.
+func (mr *MockFullNodeMockRecorder) MpoolGetRBFPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGetRBFPolicy", reflect.TypeOf((*MockFullNode)(nil).MpoolGetRBFPolicy), arg0)
+}
+
+// MpoolJournalList mocks base method.
+func (m *MockFullNode) MpoolJournalList(arg0 context.Context, arg1 []api.MpoolMessageStatus) ([]api.MpoolJournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolJournalList", arg0, arg1)
+	ret0, _ := ret[0].([]api.MpoolJournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolJournalList indicates an expected call of MpoolJournalList.
+func (mr *MockFullNodeMockRecorder) MpoolJournalList(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolJournalList", reflect.TypeOf((*MockFullNode)(nil).MpoolJournalList), arg0, arg1)
+}
+
+// MpoolJournalRebroadcast mocks base method.
+func (m *MockFullNode) MpoolJournalRebroadcast(arg0 context.Context, arg1 []cid.Cid) ([]cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolJournalRebroadcast", arg0, arg1)
+	ret0, _ := ret[0].([]cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolJournalRebroadcast indicates an expected call of MpoolJournalRebroadcast.
+func (mr *MockFullNodeMockRecorder) MpoolJournalRebroadcast(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolJournalRebroadcast", reflect.TypeOf((*MockFullNode)(nil).MpoolJournalRebroadcast), arg0, arg1)
+}
+
+// MpoolListNonceReservations mocks base method.
+func (m *MockFullNode) MpoolListNonceReservations(arg0 context.Context) ([]api.NonceReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolListNonceReservations", arg0)
+	ret0, _ := ret[0].([]api.NonceReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolListNonceReservations indicates an expected call of MpoolListNonceReservations.
+func (mr *MockFullNodeMockRecorder) MpoolListNonceReservations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolListNonceReservations", reflect.TypeOf((*MockFullNode)(nil).MpoolListNonceReservations), arg0)
+}
+
 // MpoolPending mocks base method.
 func (m *MockFullNode) MpoolPending(arg0 context.Context, arg1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1293,6 +1663,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPending(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPending", reflect.TypeOf((*MockFullNode)(nil).MpoolPending), arg0, arg1)
 }
 
+// MpoolPendingTimes mocks base method.
+func (m *MockFullNode) MpoolPendingTimes(arg0 context.Context) ([]api.MpoolPendingTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPendingTimes", arg0)
+	ret0, _ := ret[0].([]api.MpoolPendingTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPendingTimes indicates an expected call of MpoolPendingTimes.
+func (mr *MockFullNodeMockRecorder) MpoolPendingTimes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPendingTimes", reflect.TypeOf((*MockFullNode)(nil).MpoolPendingTimes), arg0)
+}
+
 // MpoolPush mocks base method.
 func (m *MockFullNode) MpoolPush(arg0 context.Context, arg1 *types.SignedMessage) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1308,6 +1693,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPush(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPush", reflect.TypeOf((*MockFullNode)(nil).MpoolPush), arg0, arg1)
 }
 
+// MpoolPushBatch mocks base method.
+func (m *MockFullNode) MpoolPushBatch(arg0 context.Context, arg1 []*types.SignedMessage) (*api.MpoolPushBatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPushBatch", arg0, arg1)
+	ret0, _ := ret[0].(*api.MpoolPushBatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPushBatch indicates an expected call of MpoolPushBatch.
+func (mr *MockFullNodeMockRecorder) MpoolPushBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushBatch", reflect.TypeOf((*MockFullNode)(nil).MpoolPushBatch), arg0, arg1)
+}
+
 // MpoolPushMessage mocks base method.
 func (m *MockFullNode) MpoolPushMessage(arg0 context.Context, arg1 *types.Message, arg2 *api.MessageSendSpec) (*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1338,6 +1738,50 @@ func (mr *MockFullNodeMockRecorder) MpoolPushUntrusted(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushUntrusted", reflect.TypeOf((*MockFullNode)(nil).MpoolPushUntrusted), arg0, arg1)
 }
 
+// MpoolReleaseNonce mocks base method.
+func (m *MockFullNode) MpoolReleaseNonce(arg0 context.Context, arg1 uuid.UUID, arg2 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolReleaseNonce", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MpoolReleaseNonce indicates an expected call of MpoolReleaseNonce.
+func (mr *MockFullNodeMockRecorder) MpoolReleaseNonce(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolReleaseNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolReleaseNonce), arg0, arg1, arg2)
+}
+
+// MpoolRepricePending mocks base method.
+func (m *MockFullNode) MpoolRepricePending(arg0 context.Context, arg1 []address.Address, arg2 *api.MessageSendSpec) (*api.MpoolRepriceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolRepricePending", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.MpoolRepriceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolRepricePending indicates an expected call of MpoolRepricePending.
+func (mr *MockFullNodeMockRecorder) MpoolRepricePending(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolRepricePending", reflect.TypeOf((*MockFullNode)(nil).MpoolRepricePending), arg0, arg1, arg2)
+}
+
+// MpoolReserveNonce mocks base method.
+func (m *MockFullNode) MpoolReserveNonce(arg0 context.Context, arg1 address.Address, arg2 uint64) (*api.NonceReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolReserveNonce", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.NonceReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolReserveNonce indicates an expected call of MpoolReserveNonce.
+func (mr *MockFullNodeMockRecorder) MpoolReserveNonce(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolReserveNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolReserveNonce), arg0, arg1, arg2)
+}
+
 // MpoolSelect mocks base method.
 func (m *MockFullNode) MpoolSelect(arg0 context.Context, arg1 types.TipSetKey, arg2 float64) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1382,6 +1826,21 @@ func (mr *MockFullNodeMockRecorder) MpoolSub(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSub", reflect.TypeOf((*MockFullNode)(nil).MpoolSub), arg0)
 }
 
+// MpoolSubFiltered mocks base method.
+func (m *MockFullNode) MpoolSubFiltered(arg0 context.Context, arg1 api.MpoolSubFilter) (<-chan api.MpoolUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSubFiltered", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.MpoolUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSubFiltered indicates an expected call of MpoolSubFiltered.
+func (mr *MockFullNodeMockRecorder) MpoolSubFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSubFiltered", reflect.TypeOf((*MockFullNode)(nil).MpoolSubFiltered), arg0, arg1)
+}
+
 // MsigAddApprove mocks base method.
 func (m *MockFullNode) MsigAddApprove(arg0 context.Context, arg1, arg2 address.Address, arg3 uint64, arg4, arg5 address.Address, arg6 bool) (*api.MessagePrototype, error) {
 	m.ctrl.T.Helper()
@@ -2453,6 +2912,21 @@ func (mr *MockFullNodeMockRecorder) StateComputeDataCID(arg0, arg1, arg2, arg3,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateComputeDataCID", reflect.TypeOf((*MockFullNode)(nil).StateComputeDataCID), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateComputeStream mocks base method.
+func (m *MockFullNode) StateComputeStream(arg0 context.Context, arg1 abi.ChainEpoch, arg2 []*types.Message, arg3 types.TipSetKey) (<-chan api.ComputeStateUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateComputeStream", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(<-chan api.ComputeStateUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateComputeStream indicates an expected call of StateComputeStream.
+func (mr *MockFullNodeMockRecorder) StateComputeStream(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateComputeStream", reflect.TypeOf((*MockFullNode)(nil).StateComputeStream), arg0, arg1, arg2, arg3)
+}
+
 // StateDealProviderCollateralBounds mocks base method.
 func (m *MockFullNode) StateDealProviderCollateralBounds(arg0 context.Context, arg1 abi.PaddedPieceSize, arg2 bool, arg3 types.TipSetKey) (api.DealCollateralBounds, error) {
 	m.ctrl.T.Helper()
@@ -2468,6 +2942,36 @@ func (mr *MockFullNodeMockRecorder) StateDealProviderCollateralBounds(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDealProviderCollateralBounds", reflect.TypeOf((*MockFullNode)(nil).StateDealProviderCollateralBounds), arg0, arg1, arg2, arg3)
 }
 
+// StateDebugCall mocks base method.
+func (m *MockFullNode) StateDebugCall(arg0 context.Context, arg1 *types.Message, arg2 []api.Breakpoint, arg3 types.TipSetKey) (*api.DebugResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDebugCall", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.DebugResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDebugCall indicates an expected call of StateDebugCall.
+func (mr *MockFullNodeMockRecorder) StateDebugCall(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDebugCall", reflect.TypeOf((*MockFullNode)(nil).StateDebugCall), arg0, arg1, arg2, arg3)
+}
+
+// StateDebugReplay mocks base method.
+func (m *MockFullNode) StateDebugReplay(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 []api.Breakpoint) (*api.DebugResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDebugReplay", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.DebugResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDebugReplay indicates an expected call of StateDebugReplay.
+func (mr *MockFullNodeMockRecorder) StateDebugReplay(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDebugReplay", reflect.TypeOf((*MockFullNode)(nil).StateDebugReplay), arg0, arg1, arg2, arg3)
+}
+
 // StateDecodeParams mocks base method.
 func (m *MockFullNode) StateDecodeParams(arg0 context.Context, arg1 address.Address, arg2 abi.MethodNum, arg3 []byte, arg4 types.TipSetKey) (interface{}, error) {
 	m.ctrl.T.Helper()
@@ -2483,6 +2987,21 @@ func (mr *MockFullNodeMockRecorder) StateDecodeParams(arg0, arg1, arg2, arg3, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeParams", reflect.TypeOf((*MockFullNode)(nil).StateDecodeParams), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateDiff mocks base method.
+func (m *MockFullNode) StateDiff(arg0 context.Context, arg1, arg2 types.TipSetKey, arg3 bool) (*api.StateDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDiff", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.StateDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDiff indicates an expected call of StateDiff.
+func (mr *MockFullNodeMockRecorder) StateDiff(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDiff", reflect.TypeOf((*MockFullNode)(nil).StateDiff), arg0, arg1, arg2, arg3)
+}
+
 // StateEncodeParams mocks base method.
 func (m *MockFullNode) StateEncodeParams(arg0 context.Context, arg1 cid.Cid, arg2 abi.MethodNum, arg3 json.RawMessage) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -2498,6 +3017,21 @@ func (mr *MockFullNodeMockRecorder) StateEncodeParams(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateEncodeParams", reflect.TypeOf((*MockFullNode)(nil).StateEncodeParams), arg0, arg1, arg2, arg3)
 }
 
+// StateGasProfile mocks base method.
+func (m *MockFullNode) StateGasProfile(arg0 context.Context, arg1, arg2 abi.ChainEpoch, arg3 types.TipSetKey) (*api.GasProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateGasProfile", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.GasProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateGasProfile indicates an expected call of StateGasProfile.
+func (mr *MockFullNodeMockRecorder) StateGasProfile(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGasProfile", reflect.TypeOf((*MockFullNode)(nil).StateGasProfile), arg0, arg1, arg2, arg3)
+}
+
 // StateGetActor mocks base method.
 func (m *MockFullNode) StateGetActor(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*types.ActorV5, error) {
 	m.ctrl.T.Helper()
@@ -2948,6 +3482,21 @@ func (mr *MockFullNodeMockRecorder) StateMinerProvingDeadline(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerProvingDeadline", reflect.TypeOf((*MockFullNode)(nil).StateMinerProvingDeadline), arg0, arg1, arg2)
 }
 
+// StateMinerProvingSchedule mocks base method.
+func (m *MockFullNode) StateMinerProvingSchedule(arg0 context.Context, arg1 address.Address, arg2 abi.ChainEpoch, arg3 types.TipSetKey) ([]api.ProvingWindow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinerProvingSchedule", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]api.ProvingWindow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinerProvingSchedule indicates an expected call of StateMinerProvingSchedule.
+func (mr *MockFullNodeMockRecorder) StateMinerProvingSchedule(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerProvingSchedule", reflect.TypeOf((*MockFullNode)(nil).StateMinerProvingSchedule), arg0, arg1, arg2, arg3)
+}
+
 // StateMinerRecoveries mocks base method.
 func (m *MockFullNode) StateMinerRecoveries(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (bitfield.BitField, error) {
 	m.ctrl.T.Helper()
@@ -3008,6 +3557,21 @@ func (mr *MockFullNodeMockRecorder) StateMinerSectors(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerSectors", reflect.TypeOf((*MockFullNode)(nil).StateMinerSectors), arg0, arg1, arg2, arg3)
 }
 
+// StateMinersSectors mocks base method.
+func (m *MockFullNode) StateMinersSectors(arg0 context.Context, arg1 *api.MinersSectorsQuery, arg2 types.TipSetKey) (*api.MinersSectorsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinersSectors", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.MinersSectorsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinersSectors indicates an expected call of StateMinersSectors.
+func (mr *MockFullNodeMockRecorder) StateMinersSectors(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinersSectors", reflect.TypeOf((*MockFullNode)(nil).StateMinersSectors), arg0, arg1, arg2)
+}
+
 // StateNetworkName mocks base method.
 func (m *MockFullNode) StateNetworkName(arg0 context.Context) (dtypes.NetworkName, error) {
 	m.ctrl.T.Helper()
@@ -3038,6 +3602,21 @@ func (mr *MockFullNodeMockRecorder) StateNetworkVersion(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateNetworkVersion", reflect.TypeOf((*MockFullNode)(nil).StateNetworkVersion), arg0, arg1)
 }
 
+// StateQueryMessages mocks base method.
+func (m *MockFullNode) StateQueryMessages(arg0 context.Context, arg1 *api.MessageQuery, arg2 types.TipSetKey) (*api.MessageQueryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateQueryMessages", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.MessageQueryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateQueryMessages indicates an expected call of StateQueryMessages.
+func (mr *MockFullNodeMockRecorder) StateQueryMessages(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateQueryMessages", reflect.TypeOf((*MockFullNode)(nil).StateQueryMessages), arg0, arg1, arg2)
+}
+
 // StateReadState mocks base method.
 func (m *MockFullNode) StateReadState(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*api.ActorState, error) {
 	m.ctrl.T.Helper()
@@ -3068,6 +3647,21 @@ func (mr *MockFullNodeMockRecorder) StateReplay(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplay", reflect.TypeOf((*MockFullNode)(nil).StateReplay), arg0, arg1, arg2)
 }
 
+// StateReplayTipSet mocks base method.
+func (m *MockFullNode) StateReplayTipSet(arg0 context.Context, arg1 types.TipSetKey) (*api.TipSetReplay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateReplayTipSet", arg0, arg1)
+	ret0, _ := ret[0].(*api.TipSetReplay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateReplayTipSet indicates an expected call of StateReplayTipSet.
+func (mr *MockFullNodeMockRecorder) StateReplayTipSet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplayTipSet", reflect.TypeOf((*MockFullNode)(nil).StateReplayTipSet), arg0, arg1)
+}
+
 // StateSearchMsg mocks base method.
 func (m *MockFullNode) StateSearchMsg(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 abi.ChainEpoch, arg4 bool) (*api.MsgLookup, error) {
 	m.ctrl.T.Helper()
@@ -3262,6 +3856,36 @@ func (mr *MockFullNodeMockRecorder) SyncIncomingBlocks(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncIncomingBlocks", reflect.TypeOf((*MockFullNode)(nil).SyncIncomingBlocks), arg0)
 }
 
+// SyncInspectBad mocks base method.
+func (m *MockFullNode) SyncInspectBad(arg0 context.Context, arg1 cid.Cid) (*api.BadBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncInspectBad", arg0, arg1)
+	ret0, _ := ret[0].(*api.BadBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncInspectBad indicates an expected call of SyncInspectBad.
+func (mr *MockFullNodeMockRecorder) SyncInspectBad(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncInspectBad", reflect.TypeOf((*MockFullNode)(nil).SyncInspectBad), arg0, arg1)
+}
+
+// SyncListBad mocks base method.
+func (m *MockFullNode) SyncListBad(arg0 context.Context) ([]api.BadBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncListBad", arg0)
+	ret0, _ := ret[0].([]api.BadBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncListBad indicates an expected call of SyncListBad.
+func (mr *MockFullNodeMockRecorder) SyncListBad(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncListBad", reflect.TypeOf((*MockFullNode)(nil).SyncListBad), arg0)
+}
+
 // SyncMarkBad mocks base method.
 func (m *MockFullNode) SyncMarkBad(arg0 context.Context, arg1 cid.Cid) error {
 	m.ctrl.T.Helper()
@@ -3276,6 +3900,36 @@ func (mr *MockFullNodeMockRecorder) SyncMarkBad(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncMarkBad", reflect.TypeOf((*MockFullNode)(nil).SyncMarkBad), arg0, arg1)
 }
 
+// SyncPeerScores mocks base method.
+func (m *MockFullNode) SyncPeerScores(arg0 context.Context) ([]api.SyncPeerScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncPeerScores", arg0)
+	ret0, _ := ret[0].([]api.SyncPeerScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncPeerScores indicates an expected call of SyncPeerScores.
+func (mr *MockFullNodeMockRecorder) SyncPeerScores(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncPeerScores", reflect.TypeOf((*MockFullNode)(nil).SyncPeerScores), arg0)
+}
+
+// SyncProgress mocks base method.
+func (m *MockFullNode) SyncProgress(arg0 context.Context) (<-chan *api.SyncState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncProgress", arg0)
+	ret0, _ := ret[0].(<-chan *api.SyncState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncProgress indicates an expected call of SyncProgress.
+func (mr *MockFullNodeMockRecorder) SyncProgress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncProgress", reflect.TypeOf((*MockFullNode)(nil).SyncProgress), arg0)
+}
+
 // SyncState mocks base method.
 func (m *MockFullNode) SyncState(arg0 context.Context) (*api.SyncState, error) {
 	m.ctrl.T.Helper()
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"reflect"
+
+	"golang.org/x/xerrors"
+
 	"github.com/filecoin-project/go-jsonrpc/auth"
 )
 
@@ -46,3 +50,36 @@ func PermissionedWalletAPI(a Wallet) Wallet {
 	permissionedProxies(a, &out)
 	return &out
 }
+
+// ReadOnlyFullAPI returns a, with the calls needing more than the read
+// permission failing, whatever the permissions of the caller.
+func ReadOnlyFullAPI(a FullNode) FullNode {
+	var out FullNodeStruct
+	readOnlyProxies(a, &out)
+	return &out
+}
+
+func readOnlyProxies(in, out interface{}) {
+	ra := reflect.ValueOf(in)
+	for _, o := range GetInternalStructs(out) {
+		rint := reflect.ValueOf(o).Elem()
+		for f := 0; f < rint.NumField(); f++ {
+			field := rint.Type().Field(f)
+			requiredPerm := auth.Permission(field.Tag.Get("perm"))
+			if requiredPerm == PermRead {
+				rint.Field(f).Set(ra.MethodByName(field.Name))
+				continue
+			}
+
+			err := xerrors.Errorf("'%s' can't be invoked on a read-only API (needs '%s')", field.Name, requiredPerm)
+			rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) []reflect.Value {
+				results := make([]reflect.Value, field.Type.NumOut())
+				for i := range results[:len(results)-1] {
+					results[i] = reflect.Zero(field.Type.Out(i))
+				}
+				results[len(results)-1] = reflect.ValueOf(&err).Elem()
+				return results
+			}))
+		}
+	}
+}
@@ -110,20 +110,38 @@ type FullNodeStruct struct {
 	NetStruct
 
 	Internal struct {
+		ChainBlockstoreGC func(p0 context.Context) error `perm:"admin"`
+
+		ChainBlockstoreGCAbort func(p0 context.Context) error `perm:"admin"`
+
+		ChainBlockstoreGCStatus func(p0 context.Context) (BlockstoreGCStatus, error) `perm:"read"`
+
 		ChainBlockstoreInfo func(p0 context.Context) (map[string]interface{}, error) `perm:"read"`
 
+		ChainBlockstoreUsage func(p0 context.Context, p1 int) (BlockstoreUsage, error) `perm:"admin"`
+
 		ChainCheckBlockstore func(p0 context.Context) error `perm:"admin"`
 
 		ChainDeleteObj func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
-		ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
+		ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey, p4 ChainExportOpts) (<-chan []byte, error) `perm:"read"`
+
+		ChainExportEstimate func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey, p4 ChainExportOpts) (ExportSizeEstimate, error) `perm:"read"`
+
+		ChainExportProgress func(p0 context.Context) (<-chan ExportProgress, error) `perm:"read"`
 
 		ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
+		ChainGetBlockDAG func(p0 context.Context, p1 abi.ChainEpoch) (*BlockDAG, error) `perm:"read"`
+
 		ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) `perm:"read"`
 
+		ChainGetForkTips func(p0 context.Context) ([]ForkTip, error) `perm:"read"`
+
 		ChainGetGenesis func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+		ChainGetHeadJournal func(p0 context.Context, p1 HeadJournalQuery) ([]HeadJournalEntry, error) `perm:"read"`
+
 		ChainGetMessage func(p0 context.Context, p1 cid.Cid) (*types.Message, error) `perm:"read"`
 
 		ChainGetMessagesInTipset func(p0 context.Context, p1 types.TipSetKey) ([]Message, error) `perm:"read"`
@@ -136,6 +154,8 @@ type FullNodeStruct struct {
 
 		ChainGetPath func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey) ([]*HeadChange, error) `perm:"read"`
 
+		ChainGetPendingReorg func(p0 context.Context) (*PendingReorg, error) `perm:"read"`
+
 		ChainGetTipSet func(p0 context.Context, p1 types.TipSetKey) (*types.TipSet, error) `perm:"read"`
 
 		ChainGetTipSetAfterHeight func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (*types.TipSet, error) `perm:"read"`
@@ -150,16 +170,36 @@ type FullNodeStruct struct {
 
 		ChainPrune func(p0 context.Context, p1 PruneOpts) error `perm:"admin"`
 
+		ChainPruneHistory func(p0 context.Context, p1 PruneHistoryOpts) (PruneHistoryResult, error) `perm:"admin"`
+
 		ChainPutObj func(p0 context.Context, p1 blocks.Block) error `perm:"admin"`
 
 		ChainReadObj func(p0 context.Context, p1 cid.Cid) ([]byte, error) `perm:"read"`
 
+		ChainResolveReorg func(p0 context.Context, p1 types.TipSetKey, p2 bool) error `perm:"admin"`
+
+		ChainRestoreSnapshot func(p0 context.Context, p1 string) (*types.TipSet, error) `perm:"admin"`
+
 		ChainSetHead func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		ChainSnapshotStatus func(p0 context.Context) (SnapshotStatus, error) `perm:"read"`
+
+		ChainSplitstoreCompactionProgress func(p0 context.Context) (SplitstoreCompactionProgress, error) `perm:"read"`
+
+		ChainSplitstorePauseCompaction func(p0 context.Context) error `perm:"admin"`
+
+		ChainSplitstoreResumeCompaction func(p0 context.Context) error `perm:"admin"`
+
 		ChainStatObj func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) `perm:"read"`
 
+		ChainStateGarbage func(p0 context.Context, p1 StateGarbageOpts) (StateGarbage, error) `perm:"admin"`
+
+		ChainTipSetCacheStats func(p0 context.Context) (TipSetCacheStats, error) `perm:"read"`
+
 		ChainTipSetWeight func(p0 context.Context, p1 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
+		ChainTipSetWeightComponents func(p0 context.Context, p1 types.TipSetKey) (TipSetWeightComponents, error) `perm:"read"`
+
 		ClientCalcCommP func(p0 context.Context, p1 string) (*CommPRet, error) `perm:"write"`
 
 		ClientCancelDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
@@ -220,6 +260,8 @@ type FullNodeStruct struct {
 
 		GasEstimateFeeCap func(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
+		GasEstimateFeeCapPercentile func(p0 context.Context, p1 uint64, p2 []float64, p3 types.TipSetKey) (*GasFeePercentiles, error) `perm:"read"`
+
 		GasEstimateGasLimit func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) `perm:"read"`
 
 		GasEstimateGasPremium func(p0 context.Context, p1 uint64, p2 address.Address, p3 int64, p4 types.TipSetKey) (types.BigInt, error) `perm:"read"`
@@ -258,20 +300,40 @@ type FullNodeStruct struct {
 
 		MpoolGetNonce func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"read"`
 
+		MpoolGetRBFPolicy func(p0 context.Context) (*MpoolRBFPolicy, error) `perm:"read"`
+
+		MpoolJournalList func(p0 context.Context, p1 []MpoolMessageStatus) ([]MpoolJournalEntry, error) `perm:"read"`
+
+		MpoolJournalRebroadcast func(p0 context.Context, p1 []cid.Cid) ([]cid.Cid, error) `perm:"write"`
+
+		MpoolListNonceReservations func(p0 context.Context) ([]NonceReservation, error) `perm:"read"`
+
 		MpoolPending func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) `perm:"read"`
 
+		MpoolPendingTimes func(p0 context.Context) ([]MpoolPendingTime, error) `perm:"read"`
+
 		MpoolPush func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+		MpoolPushBatch func(p0 context.Context, p1 []*types.SignedMessage) (*MpoolPushBatchResult, error) `perm:"write"`
+
 		MpoolPushMessage func(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*types.SignedMessage, error) `perm:"sign"`
 
 		MpoolPushUntrusted func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+		MpoolReleaseNonce func(p0 context.Context, p1 uuid.UUID, p2 uint64) error `perm:"sign"`
+
+		MpoolRepricePending func(p0 context.Context, p1 []address.Address, p2 *MessageSendSpec) (*MpoolRepriceResult, error) `perm:"sign"`
+
+		MpoolReserveNonce func(p0 context.Context, p1 address.Address, p2 uint64) (*NonceReservation, error) `perm:"sign"`
+
 		MpoolSelect func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) `perm:"read"`
 
 		MpoolSetConfig func(p0 context.Context, p1 *types.MpoolConfig) error `perm:"admin"`
 
 		MpoolSub func(p0 context.Context) (<-chan MpoolUpdate, error) `perm:"read"`
 
+		MpoolSubFiltered func(p0 context.Context, p1 MpoolSubFilter) (<-chan MpoolUpdate, error) `perm:"read"`
+
 		MsigAddApprove func(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 address.Address, p6 bool) (*MessagePrototype, error) `perm:"sign"`
 
 		MsigAddCancel func(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 bool) (*MessagePrototype, error) `perm:"sign"`
@@ -364,12 +426,22 @@ type FullNodeStruct struct {
 
 		StateComputeDataCID func(p0 context.Context, p1 address.Address, p2 abi.RegisteredSealProof, p3 []abi.DealID, p4 types.TipSetKey) (cid.Cid, error) `perm:"read"`
 
+		StateComputeStream func(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (<-chan ComputeStateUpdate, error) `perm:"read"`
+
 		StateDealProviderCollateralBounds func(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (DealCollateralBounds, error) `perm:"read"`
 
+		StateDebugCall func(p0 context.Context, p1 *types.Message, p2 []Breakpoint, p3 types.TipSetKey) (*DebugResult, error) `perm:"read"`
+
+		StateDebugReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 []Breakpoint) (*DebugResult, error) `perm:"read"`
+
 		StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
 
+		StateDiff func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 bool) (*StateDiff, error) `perm:"read"`
+
 		StateEncodeParams func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) `perm:"read"`
 
+		StateGasProfile func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 types.TipSetKey) (*GasProfile, error) `perm:"read"`
+
 		StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
 
 		StateGetAllocation func(p0 context.Context, p1 address.Address, p2 verifregtypes.AllocationId, p3 types.TipSetKey) (*verifregtypes.Allocation, error) `perm:"read"`
@@ -430,6 +502,8 @@ type FullNodeStruct struct {
 
 		StateMinerProvingDeadline func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*dline.Info, error) `perm:"read"`
 
+		StateMinerProvingSchedule func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) ([]ProvingWindow, error) `perm:"read"`
+
 		StateMinerRecoveries func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (bitfield.BitField, error) `perm:"read"`
 
 		StateMinerSectorAllocated func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (bool, error) `perm:"read"`
@@ -438,14 +512,20 @@ type FullNodeStruct struct {
 
 		StateMinerSectors func(p0 context.Context, p1 address.Address, p2 *bitfield.BitField, p3 types.TipSetKey) ([]*miner.SectorOnChainInfo, error) `perm:"read"`
 
+		StateMinersSectors func(p0 context.Context, p1 *MinersSectorsQuery, p2 types.TipSetKey) (*MinersSectorsResult, error) `perm:"read"`
+
 		StateNetworkName func(p0 context.Context) (dtypes.NetworkName, error) `perm:"read"`
 
 		StateNetworkVersion func(p0 context.Context, p1 types.TipSetKey) (apitypes.NetworkVersion, error) `perm:"read"`
 
+		StateQueryMessages func(p0 context.Context, p1 *MessageQuery, p2 types.TipSetKey) (*MessageQueryResult, error) `perm:"read"`
+
 		StateReadState func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorState, error) `perm:"read"`
 
 		StateReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*InvocResult, error) `perm:"read"`
 
+		StateReplayTipSet func(p0 context.Context, p1 types.TipSetKey) (*TipSetReplay, error) `perm:"read"`
+
 		StateSearchMsg func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
 		StateSectorExpiration func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*lminer.SectorExpiration, error) `perm:"read"`
@@ -472,8 +552,16 @@ type FullNodeStruct struct {
 
 		SyncIncomingBlocks func(p0 context.Context) (<-chan *types.BlockHeader, error) `perm:"read"`
 
+		SyncInspectBad func(p0 context.Context, p1 cid.Cid) (*BadBlock, error) `perm:"read"`
+
+		SyncListBad func(p0 context.Context) ([]BadBlock, error) `perm:"read"`
+
 		SyncMarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
+		SyncPeerScores func(p0 context.Context) ([]SyncPeerScore, error) `perm:"read"`
+
+		SyncProgress func(p0 context.Context) (<-chan *SyncState, error) `perm:"read"`
+
 		SyncState func(p0 context.Context) (*SyncState, error) `perm:"read"`
 
 		SyncSubmitBlock func(p0 context.Context, p1 *types.BlockMsg) error `perm:"write"`
@@ -1201,6 +1289,39 @@ func (s *CommonStub) Version(p0 context.Context) (APIVersion, error) {
 	return *new(APIVersion), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBlockstoreGC(p0 context.Context) error {
+	if s.Internal.ChainBlockstoreGC == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreGC(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGC(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreGCAbort(p0 context.Context) error {
+	if s.Internal.ChainBlockstoreGCAbort == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreGCAbort(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGCAbort(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreGCStatus(p0 context.Context) (BlockstoreGCStatus, error) {
+	if s.Internal.ChainBlockstoreGCStatus == nil {
+		return *new(BlockstoreGCStatus), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreGCStatus(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGCStatus(p0 context.Context) (BlockstoreGCStatus, error) {
+	return *new(BlockstoreGCStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainBlockstoreInfo(p0 context.Context) (map[string]interface{}, error) {
 	if s.Internal.ChainBlockstoreInfo == nil {
 		return *new(map[string]interface{}), ErrNotSupported
@@ -1212,6 +1333,17 @@ func (s *FullNodeStub) ChainBlockstoreInfo(p0 context.Context) (map[string]inter
 	return *new(map[string]interface{}), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBlockstoreUsage(p0 context.Context, p1 int) (BlockstoreUsage, error) {
+	if s.Internal.ChainBlockstoreUsage == nil {
+		return *new(BlockstoreUsage), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreUsage(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreUsage(p0 context.Context, p1 int) (BlockstoreUsage, error) {
+	return *new(BlockstoreUsage), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainCheckBlockstore(p0 context.Context) error {
 	if s.Internal.ChainCheckBlockstore == nil {
 		return ErrNotSupported
@@ -1234,14 +1366,36 @@ func (s *FullNodeStub) ChainDeleteObj(p0 context.Context, p1 cid.Cid) error {
 	return ErrNotSupported
 }
 
-func (s *FullNodeStruct) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) {
+func (s *FullNodeStruct) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey, p4 ChainExportOpts) (<-chan []byte, error) {
 	if s.Internal.ChainExport == nil {
 		return nil, ErrNotSupported
 	}
-	return s.Internal.ChainExport(p0, p1, p2, p3)
+	return s.Internal.ChainExport(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey, p4 ChainExportOpts) (<-chan []byte, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainExportEstimate(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey, p4 ChainExportOpts) (ExportSizeEstimate, error) {
+	if s.Internal.ChainExportEstimate == nil {
+		return *new(ExportSizeEstimate), ErrNotSupported
+	}
+	return s.Internal.ChainExportEstimate(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) ChainExportEstimate(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey, p4 ChainExportOpts) (ExportSizeEstimate, error) {
+	return *new(ExportSizeEstimate), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainExportProgress(p0 context.Context) (<-chan ExportProgress, error) {
+	if s.Internal.ChainExportProgress == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainExportProgress(p0)
 }
 
-func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) {
+func (s *FullNodeStub) ChainExportProgress(p0 context.Context) (<-chan ExportProgress, error) {
 	return nil, ErrNotSupported
 }
 
@@ -1256,6 +1410,17 @@ func (s *FullNodeStub) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.Blo
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetBlockDAG(p0 context.Context, p1 abi.ChainEpoch) (*BlockDAG, error) {
+	if s.Internal.ChainGetBlockDAG == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGetBlockDAG(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGetBlockDAG(p0 context.Context, p1 abi.ChainEpoch) (*BlockDAG, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetBlockMessages(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) {
 	if s.Internal.ChainGetBlockMessages == nil {
 		return nil, ErrNotSupported
@@ -1267,6 +1432,17 @@ func (s *FullNodeStub) ChainGetBlockMessages(p0 context.Context, p1 cid.Cid) (*B
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetForkTips(p0 context.Context) ([]ForkTip, error) {
+	if s.Internal.ChainGetForkTips == nil {
+		return *new([]ForkTip), ErrNotSupported
+	}
+	return s.Internal.ChainGetForkTips(p0)
+}
+
+func (s *FullNodeStub) ChainGetForkTips(p0 context.Context) ([]ForkTip, error) {
+	return *new([]ForkTip), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetGenesis(p0 context.Context) (*types.TipSet, error) {
 	if s.Internal.ChainGetGenesis == nil {
 		return nil, ErrNotSupported
@@ -1278,6 +1454,17 @@ func (s *FullNodeStub) ChainGetGenesis(p0 context.Context) (*types.TipSet, error
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetHeadJournal(p0 context.Context, p1 HeadJournalQuery) ([]HeadJournalEntry, error) {
+	if s.Internal.ChainGetHeadJournal == nil {
+		return *new([]HeadJournalEntry), ErrNotSupported
+	}
+	return s.Internal.ChainGetHeadJournal(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGetHeadJournal(p0 context.Context, p1 HeadJournalQuery) ([]HeadJournalEntry, error) {
+	return *new([]HeadJournalEntry), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetMessage(p0 context.Context, p1 cid.Cid) (*types.Message, error) {
 	if s.Internal.ChainGetMessage == nil {
 		return nil, ErrNotSupported
@@ -1344,6 +1531,17 @@ func (s *FullNodeStub) ChainGetPath(p0 context.Context, p1 types.TipSetKey, p2 t
 	return *new([]*HeadChange), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetPendingReorg(p0 context.Context) (*PendingReorg, error) {
+	if s.Internal.ChainGetPendingReorg == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGetPendingReorg(p0)
+}
+
+func (s *FullNodeStub) ChainGetPendingReorg(p0 context.Context) (*PendingReorg, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetTipSet(p0 context.Context, p1 types.TipSetKey) (*types.TipSet, error) {
 	if s.Internal.ChainGetTipSet == nil {
 		return nil, ErrNotSupported
@@ -1421,6 +1619,17 @@ func (s *FullNodeStub) ChainPrune(p0 context.Context, p1 PruneOpts) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainPruneHistory(p0 context.Context, p1 PruneHistoryOpts) (PruneHistoryResult, error) {
+	if s.Internal.ChainPruneHistory == nil {
+		return *new(PruneHistoryResult), ErrNotSupported
+	}
+	return s.Internal.ChainPruneHistory(p0, p1)
+}
+
+func (s *FullNodeStub) ChainPruneHistory(p0 context.Context, p1 PruneHistoryOpts) (PruneHistoryResult, error) {
+	return *new(PruneHistoryResult), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainPutObj(p0 context.Context, p1 blocks.Block) error {
 	if s.Internal.ChainPutObj == nil {
 		return ErrNotSupported
@@ -1443,6 +1652,28 @@ func (s *FullNodeStub) ChainReadObj(p0 context.Context, p1 cid.Cid) ([]byte, err
 	return *new([]byte), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainResolveReorg(p0 context.Context, p1 types.TipSetKey, p2 bool) error {
+	if s.Internal.ChainResolveReorg == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainResolveReorg(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ChainResolveReorg(p0 context.Context, p1 types.TipSetKey, p2 bool) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainRestoreSnapshot(p0 context.Context, p1 string) (*types.TipSet, error) {
+	if s.Internal.ChainRestoreSnapshot == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainRestoreSnapshot(p0, p1)
+}
+
+func (s *FullNodeStub) ChainRestoreSnapshot(p0 context.Context, p1 string) (*types.TipSet, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainSetHead(p0 context.Context, p1 types.TipSetKey) error {
 	if s.Internal.ChainSetHead == nil {
 		return ErrNotSupported
@@ -1454,6 +1685,50 @@ func (s *FullNodeStub) ChainSetHead(p0 context.Context, p1 types.TipSetKey) erro
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainSnapshotStatus(p0 context.Context) (SnapshotStatus, error) {
+	if s.Internal.ChainSnapshotStatus == nil {
+		return *new(SnapshotStatus), ErrNotSupported
+	}
+	return s.Internal.ChainSnapshotStatus(p0)
+}
+
+func (s *FullNodeStub) ChainSnapshotStatus(p0 context.Context) (SnapshotStatus, error) {
+	return *new(SnapshotStatus), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainSplitstoreCompactionProgress(p0 context.Context) (SplitstoreCompactionProgress, error) {
+	if s.Internal.ChainSplitstoreCompactionProgress == nil {
+		return *new(SplitstoreCompactionProgress), ErrNotSupported
+	}
+	return s.Internal.ChainSplitstoreCompactionProgress(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstoreCompactionProgress(p0 context.Context) (SplitstoreCompactionProgress, error) {
+	return *new(SplitstoreCompactionProgress), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainSplitstorePauseCompaction(p0 context.Context) error {
+	if s.Internal.ChainSplitstorePauseCompaction == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainSplitstorePauseCompaction(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstorePauseCompaction(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainSplitstoreResumeCompaction(p0 context.Context) error {
+	if s.Internal.ChainSplitstoreResumeCompaction == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainSplitstoreResumeCompaction(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstoreResumeCompaction(p0 context.Context) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainStatObj(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) {
 	if s.Internal.ChainStatObj == nil {
 		return *new(ObjStat), ErrNotSupported
@@ -1465,6 +1740,28 @@ func (s *FullNodeStub) ChainStatObj(p0 context.Context, p1 cid.Cid, p2 cid.Cid)
 	return *new(ObjStat), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainStateGarbage(p0 context.Context, p1 StateGarbageOpts) (StateGarbage, error) {
+	if s.Internal.ChainStateGarbage == nil {
+		return *new(StateGarbage), ErrNotSupported
+	}
+	return s.Internal.ChainStateGarbage(p0, p1)
+}
+
+func (s *FullNodeStub) ChainStateGarbage(p0 context.Context, p1 StateGarbageOpts) (StateGarbage, error) {
+	return *new(StateGarbage), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainTipSetCacheStats(p0 context.Context) (TipSetCacheStats, error) {
+	if s.Internal.ChainTipSetCacheStats == nil {
+		return *new(TipSetCacheStats), ErrNotSupported
+	}
+	return s.Internal.ChainTipSetCacheStats(p0)
+}
+
+func (s *FullNodeStub) ChainTipSetCacheStats(p0 context.Context) (TipSetCacheStats, error) {
+	return *new(TipSetCacheStats), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainTipSetWeight(p0 context.Context, p1 types.TipSetKey) (types.BigInt, error) {
 	if s.Internal.ChainTipSetWeight == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -1476,6 +1773,17 @@ func (s *FullNodeStub) ChainTipSetWeight(p0 context.Context, p1 types.TipSetKey)
 	return *new(types.BigInt), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainTipSetWeightComponents(p0 context.Context, p1 types.TipSetKey) (TipSetWeightComponents, error) {
+	if s.Internal.ChainTipSetWeightComponents == nil {
+		return *new(TipSetWeightComponents), ErrNotSupported
+	}
+	return s.Internal.ChainTipSetWeightComponents(p0, p1)
+}
+
+func (s *FullNodeStub) ChainTipSetWeightComponents(p0 context.Context, p1 types.TipSetKey) (TipSetWeightComponents, error) {
+	return *new(TipSetWeightComponents), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientCalcCommP(p0 context.Context, p1 string) (*CommPRet, error) {
 	if s.Internal.ClientCalcCommP == nil {
 		return nil, ErrNotSupported
@@ -1806,6 +2114,17 @@ func (s *FullNodeStub) GasEstimateFeeCap(p0 context.Context, p1 *types.Message,
 	return *new(types.BigInt), ErrNotSupported
 }
 
+func (s *FullNodeStruct) GasEstimateFeeCapPercentile(p0 context.Context, p1 uint64, p2 []float64, p3 types.TipSetKey) (*GasFeePercentiles, error) {
+	if s.Internal.GasEstimateFeeCapPercentile == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GasEstimateFeeCapPercentile(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) GasEstimateFeeCapPercentile(p0 context.Context, p1 uint64, p2 []float64, p3 types.TipSetKey) (*GasFeePercentiles, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) GasEstimateGasLimit(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) {
 	if s.Internal.GasEstimateGasLimit == nil {
 		return 0, ErrNotSupported
@@ -2015,6 +2334,50 @@ func (s *FullNodeStub) MpoolGetNonce(p0 context.Context, p1 address.Address) (ui
 	return 0, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolGetRBFPolicy(p0 context.Context) (*MpoolRBFPolicy, error) {
+	if s.Internal.MpoolGetRBFPolicy == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolGetRBFPolicy(p0)
+}
+
+func (s *FullNodeStub) MpoolGetRBFPolicy(p0 context.Context) (*MpoolRBFPolicy, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolJournalList(p0 context.Context, p1 []MpoolMessageStatus) ([]MpoolJournalEntry, error) {
+	if s.Internal.MpoolJournalList == nil {
+		return *new([]MpoolJournalEntry), ErrNotSupported
+	}
+	return s.Internal.MpoolJournalList(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolJournalList(p0 context.Context, p1 []MpoolMessageStatus) ([]MpoolJournalEntry, error) {
+	return *new([]MpoolJournalEntry), ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolJournalRebroadcast(p0 context.Context, p1 []cid.Cid) ([]cid.Cid, error) {
+	if s.Internal.MpoolJournalRebroadcast == nil {
+		return *new([]cid.Cid), ErrNotSupported
+	}
+	return s.Internal.MpoolJournalRebroadcast(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolJournalRebroadcast(p0 context.Context, p1 []cid.Cid) ([]cid.Cid, error) {
+	return *new([]cid.Cid), ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolListNonceReservations(p0 context.Context) ([]NonceReservation, error) {
+	if s.Internal.MpoolListNonceReservations == nil {
+		return *new([]NonceReservation), ErrNotSupported
+	}
+	return s.Internal.MpoolListNonceReservations(p0)
+}
+
+func (s *FullNodeStub) MpoolListNonceReservations(p0 context.Context) ([]NonceReservation, error) {
+	return *new([]NonceReservation), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolPending == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2026,6 +2389,17 @@ func (s *FullNodeStub) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*
 	return *new([]*types.SignedMessage), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolPendingTimes(p0 context.Context) ([]MpoolPendingTime, error) {
+	if s.Internal.MpoolPendingTimes == nil {
+		return *new([]MpoolPendingTime), ErrNotSupported
+	}
+	return s.Internal.MpoolPendingTimes(p0)
+}
+
+func (s *FullNodeStub) MpoolPendingTimes(p0 context.Context) ([]MpoolPendingTime, error) {
+	return *new([]MpoolPendingTime), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPush(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) {
 	if s.Internal.MpoolPush == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2037,6 +2411,17 @@ func (s *FullNodeStub) MpoolPush(p0 context.Context, p1 *types.SignedMessage) (c
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolPushBatch(p0 context.Context, p1 []*types.SignedMessage) (*MpoolPushBatchResult, error) {
+	if s.Internal.MpoolPushBatch == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolPushBatch(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolPushBatch(p0 context.Context, p1 []*types.SignedMessage) (*MpoolPushBatchResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPushMessage(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*types.SignedMessage, error) {
 	if s.Internal.MpoolPushMessage == nil {
 		return nil, ErrNotSupported
@@ -2059,6 +2444,39 @@ func (s *FullNodeStub) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMe
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolReleaseNonce(p0 context.Context, p1 uuid.UUID, p2 uint64) error {
+	if s.Internal.MpoolReleaseNonce == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MpoolReleaseNonce(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolReleaseNonce(p0 context.Context, p1 uuid.UUID, p2 uint64) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolRepricePending(p0 context.Context, p1 []address.Address, p2 *MessageSendSpec) (*MpoolRepriceResult, error) {
+	if s.Internal.MpoolRepricePending == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolRepricePending(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolRepricePending(p0 context.Context, p1 []address.Address, p2 *MessageSendSpec) (*MpoolRepriceResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolReserveNonce(p0 context.Context, p1 address.Address, p2 uint64) (*NonceReservation, error) {
+	if s.Internal.MpoolReserveNonce == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolReserveNonce(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolReserveNonce(p0 context.Context, p1 address.Address, p2 uint64) (*NonceReservation, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolSelect == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2092,6 +2510,17 @@ func (s *FullNodeStub) MpoolSub(p0 context.Context) (<-chan MpoolUpdate, error)
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolSubFiltered(p0 context.Context, p1 MpoolSubFilter) (<-chan MpoolUpdate, error) {
+	if s.Internal.MpoolSubFiltered == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolSubFiltered(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolSubFiltered(p0 context.Context, p1 MpoolSubFilter) (<-chan MpoolUpdate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MsigAddApprove(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 address.Address, p6 bool) (*MessagePrototype, error) {
 	if s.Internal.MsigAddApprove == nil {
 		return nil, ErrNotSupported
@@ -2598,6 +3027,17 @@ func (s *FullNodeStub) StateComputeDataCID(p0 context.Context, p1 address.Addres
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateComputeStream(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (<-chan ComputeStateUpdate, error) {
+	if s.Internal.StateComputeStream == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateComputeStream(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateComputeStream(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (<-chan ComputeStateUpdate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateDealProviderCollateralBounds(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (DealCollateralBounds, error) {
 	if s.Internal.StateDealProviderCollateralBounds == nil {
 		return *new(DealCollateralBounds), ErrNotSupported
@@ -2609,6 +3049,28 @@ func (s *FullNodeStub) StateDealProviderCollateralBounds(p0 context.Context, p1
 	return *new(DealCollateralBounds), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateDebugCall(p0 context.Context, p1 *types.Message, p2 []Breakpoint, p3 types.TipSetKey) (*DebugResult, error) {
+	if s.Internal.StateDebugCall == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateDebugCall(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDebugCall(p0 context.Context, p1 *types.Message, p2 []Breakpoint, p3 types.TipSetKey) (*DebugResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateDebugReplay(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 []Breakpoint) (*DebugResult, error) {
+	if s.Internal.StateDebugReplay == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateDebugReplay(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDebugReplay(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 []Breakpoint) (*DebugResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateDecodeParams(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
 	if s.Internal.StateDecodeParams == nil {
 		return nil, ErrNotSupported
@@ -2620,6 +3082,17 @@ func (s *FullNodeStub) StateDecodeParams(p0 context.Context, p1 address.Address,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateDiff(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 bool) (*StateDiff, error) {
+	if s.Internal.StateDiff == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateDiff(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDiff(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 bool) (*StateDiff, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateEncodeParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) {
 	if s.Internal.StateEncodeParams == nil {
 		return *new([]byte), ErrNotSupported
@@ -2631,6 +3104,17 @@ func (s *FullNodeStub) StateEncodeParams(p0 context.Context, p1 cid.Cid, p2 abi.
 	return *new([]byte), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateGasProfile(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 types.TipSetKey) (*GasProfile, error) {
+	if s.Internal.StateGasProfile == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateGasProfile(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateGasProfile(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 types.TipSetKey) (*GasProfile, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateGetActor(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) {
 	if s.Internal.StateGetActor == nil {
 		return nil, ErrNotSupported
@@ -2961,6 +3445,17 @@ func (s *FullNodeStub) StateMinerProvingDeadline(p0 context.Context, p1 address.
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateMinerProvingSchedule(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) ([]ProvingWindow, error) {
+	if s.Internal.StateMinerProvingSchedule == nil {
+		return *new([]ProvingWindow), ErrNotSupported
+	}
+	return s.Internal.StateMinerProvingSchedule(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateMinerProvingSchedule(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) ([]ProvingWindow, error) {
+	return *new([]ProvingWindow), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateMinerRecoveries(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (bitfield.BitField, error) {
 	if s.Internal.StateMinerRecoveries == nil {
 		return *new(bitfield.BitField), ErrNotSupported
@@ -3005,6 +3500,17 @@ func (s *FullNodeStub) StateMinerSectors(p0 context.Context, p1 address.Address,
 	return *new([]*miner.SectorOnChainInfo), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateMinersSectors(p0 context.Context, p1 *MinersSectorsQuery, p2 types.TipSetKey) (*MinersSectorsResult, error) {
+	if s.Internal.StateMinersSectors == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateMinersSectors(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateMinersSectors(p0 context.Context, p1 *MinersSectorsQuery, p2 types.TipSetKey) (*MinersSectorsResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateNetworkName(p0 context.Context) (dtypes.NetworkName, error) {
 	if s.Internal.StateNetworkName == nil {
 		return *new(dtypes.NetworkName), ErrNotSupported
@@ -3027,6 +3533,17 @@ func (s *FullNodeStub) StateNetworkVersion(p0 context.Context, p1 types.TipSetKe
 	return *new(apitypes.NetworkVersion), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateQueryMessages(p0 context.Context, p1 *MessageQuery, p2 types.TipSetKey) (*MessageQueryResult, error) {
+	if s.Internal.StateQueryMessages == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateQueryMessages(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateQueryMessages(p0 context.Context, p1 *MessageQuery, p2 types.TipSetKey) (*MessageQueryResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateReadState(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorState, error) {
 	if s.Internal.StateReadState == nil {
 		return nil, ErrNotSupported
@@ -3049,6 +3566,17 @@ func (s *FullNodeStub) StateReplay(p0 context.Context, p1 types.TipSetKey, p2 ci
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateReplayTipSet(p0 context.Context, p1 types.TipSetKey) (*TipSetReplay, error) {
+	if s.Internal.StateReplayTipSet == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateReplayTipSet(p0, p1)
+}
+
+func (s *FullNodeStub) StateReplayTipSet(p0 context.Context, p1 types.TipSetKey) (*TipSetReplay, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateSearchMsg(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) {
 	if s.Internal.StateSearchMsg == nil {
 		return nil, ErrNotSupported
@@ -3192,6 +3720,28 @@ func (s *FullNodeStub) SyncIncomingBlocks(p0 context.Context) (<-chan *types.Blo
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncInspectBad(p0 context.Context, p1 cid.Cid) (*BadBlock, error) {
+	if s.Internal.SyncInspectBad == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SyncInspectBad(p0, p1)
+}
+
+func (s *FullNodeStub) SyncInspectBad(p0 context.Context, p1 cid.Cid) (*BadBlock, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) SyncListBad(p0 context.Context) ([]BadBlock, error) {
+	if s.Internal.SyncListBad == nil {
+		return *new([]BadBlock), ErrNotSupported
+	}
+	return s.Internal.SyncListBad(p0)
+}
+
+func (s *FullNodeStub) SyncListBad(p0 context.Context) ([]BadBlock, error) {
+	return *new([]BadBlock), ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncMarkBad(p0 context.Context, p1 cid.Cid) error {
 	if s.Internal.SyncMarkBad == nil {
 		return ErrNotSupported
@@ -3203,6 +3753,28 @@ func (s *FullNodeStub) SyncMarkBad(p0 context.Context, p1 cid.Cid) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncPeerScores(p0 context.Context) ([]SyncPeerScore, error) {
+	if s.Internal.SyncPeerScores == nil {
+		return *new([]SyncPeerScore), ErrNotSupported
+	}
+	return s.Internal.SyncPeerScores(p0)
+}
+
+func (s *FullNodeStub) SyncPeerScores(p0 context.Context) ([]SyncPeerScore, error) {
+	return *new([]SyncPeerScore), ErrNotSupported
+}
+
+func (s *FullNodeStruct) SyncProgress(p0 context.Context) (<-chan *SyncState, error) {
+	if s.Internal.SyncProgress == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SyncProgress(p0)
+}
+
+func (s *FullNodeStub) SyncProgress(p0 context.Context) (<-chan *SyncState, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncState(p0 context.Context) (*SyncState, error) {
 	if s.Internal.SyncState == nil {
 		return nil, ErrNotSupported
@@ -59,6 +59,99 @@ type MessageSendSpec struct {
 	MsgUuid uuid.UUID
 }
 
+// NonceReservation is a range of nonces of an address reserved with
+// MpoolReserveNonce: the nonces from Start to Start+Count-1.
+type NonceReservation struct {
+	ID       uuid.UUID
+	Address  address.Address
+	Start    uint64
+	Count    uint64
+	Reserved time.Time
+}
+
+// MpoolRBFPolicy is the policy of the mpool for messages replacing others with
+// the same sender and nonce.
+type MpoolRBFPolicy struct {
+	// MinPremiumBumpPercent is the minimum increase of the gas premium of the
+	// replacing message, over the one it replaces.
+	MinPremiumBumpPercent float64
+	// MaxReplacementsPerEpoch is the number of times a message received from
+	// the network may be replaced per nonce and epoch, 0 for no limit.
+	MaxReplacementsPerEpoch int
+}
+
+// MpoolMessageStatus is the status of a local message in the mpool journal.
+type MpoolMessageStatus string
+
+const (
+	// MpoolMessagePending messages are in the mpool, waiting to land
+	MpoolMessagePending MpoolMessageStatus = "pending"
+	// MpoolMessageLanded messages were included in the chain
+	MpoolMessageLanded MpoolMessageStatus = "landed"
+	// MpoolMessageReplaced messages were replaced by another message with the
+	// same sender and nonce, in the mpool or in the chain
+	MpoolMessageReplaced MpoolMessageStatus = "replaced"
+	// MpoolMessageExpired messages were cleared from the mpool
+	MpoolMessageExpired MpoolMessageStatus = "expired"
+)
+
+// MpoolJournalEntry is a local message of the mpool journal.
+type MpoolJournalEntry struct {
+	Message *types.SignedMessage
+	Cid     cid.Cid
+	Status  MpoolMessageStatus
+	// Added is when the message was pushed, and Updated when its status last
+	// changed
+	Added   time.Time
+	Updated time.Time
+	// Epoch is the epoch the message landed at
+	Epoch abi.ChainEpoch
+	// ReplacedBy is the message replacing it, if known
+	ReplacedBy *cid.Cid
+}
+
+// MpoolPendingTime is the time a pending message was added to the mpool.
+type MpoolPendingTime struct {
+	Message cid.Cid
+	Added   time.Time
+}
+
+// MpoolPushBatchResult is the result of MpoolPushBatch.
+type MpoolPushBatchResult struct {
+	// Pushed is whether the messages were added, none being added otherwise
+	Pushed bool
+	// Results are those of the messages, in the order of the batch
+	Results []MpoolPushResult
+}
+
+// MpoolPushResult is the result of a message of a batch.
+type MpoolPushResult struct {
+	Cid cid.Cid
+	// Error is why the message is invalid, empty if it is valid
+	Error string
+}
+
+// MpoolRepriceResult is the result of MpoolRepricePending.
+type MpoolRepriceResult struct {
+	Messages []MpoolRepricedMessage
+	// Compacted is the number of local messages deleted, not pending anymore
+	Compacted int
+}
+
+// MpoolRepricedMessage is a local pending message repriced.
+type MpoolRepricedMessage struct {
+	From  address.Address
+	Nonce uint64
+	// Old is the message replaced, and New the message replacing it, undefined
+	// if it couldn't be replaced
+	Old        cid.Cid
+	New        cid.Cid
+	GasPremium abi.TokenAmount
+	GasFeeCap  abi.TokenAmount
+	// Error is why the message couldn't be replaced
+	Error string
+}
+
 type MpoolMessageWhole struct {
 	Msg  *types.Message
 	Spec *MessageSendSpec
@@ -66,6 +66,10 @@ func (w *WrapperV1Full) StateGetReceipt(ctx context.Context, msg cid.Cid, from t
 	return &ml.Receipt, nil
 }
 
+func (w *WrapperV1Full) ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) {
+	return w.FullNode.ChainExport(ctx, nroots, oldmsgskip, tsk, api.ChainExportOpts{})
+}
+
 func (w *WrapperV1Full) Version(ctx context.Context) (api.APIVersion, error) {
 	ver, err := w.FullNode.Version(ctx)
 	if err != nil {
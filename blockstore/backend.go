@@ -0,0 +1,61 @@
+package blockstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// BackendFactory opens a blockstore backend from the params of its config.
+// path is the directory the backend may keep local data in, such as a cache.
+type BackendFactory func(ctx context.Context, path string, params map[string]string) (Blockstore, error)
+
+var (
+	backendsLk sync.Mutex
+	backends   = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes a blockstore backend available under name, for the
+// chain blockstore to be selected from the config. Backends usually register
+// themselves from the init function of their package.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsLk.Lock()
+	defer backendsLk.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic("blockstore backend registered twice: " + name)
+	}
+	backends[name] = factory
+}
+
+// Backends returns the names of the registered blockstore backends.
+func Backends() []string {
+	backendsLk.Lock()
+	defer backendsLk.Unlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenBackend opens the blockstore backend registered under name.
+func OpenBackend(ctx context.Context, name, path string, params map[string]string) (Blockstore, error) {
+	backendsLk.Lock()
+	factory, ok := backends[name]
+	backendsLk.Unlock()
+
+	if !ok {
+		return nil, xerrors.Errorf("unknown blockstore backend %q (registered: %v)", name, Backends())
+	}
+
+	bs, err := factory(ctx, path, params)
+	if err != nil {
+		return nil, xerrors.Errorf("opening %s blockstore backend: %w", name, err)
+	}
+	return bs, nil
+}
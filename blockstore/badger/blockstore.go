@@ -123,6 +123,7 @@ type Blockstore struct {
 
 var _ blockstore.Blockstore = (*Blockstore)(nil)
 var _ blockstore.Viewer = (*Blockstore)(nil)
+var _ blockstore.BatchViewer = (*Blockstore)(nil)
 var _ blockstore.BlockstoreIterator = (*Blockstore)(nil)
 var _ blockstore.BlockstoreGC = (*Blockstore)(nil)
 var _ blockstore.BlockstoreSize = (*Blockstore)(nil)
@@ -439,7 +440,7 @@ func (b *Blockstore) deleteDB(path string) {
 	}
 }
 
-func (b *Blockstore) onlineGC() error {
+func (b *Blockstore) onlineGC(yield func() error) error {
 	b.lockDB()
 	defer b.unlockDB()
 
@@ -455,6 +456,11 @@ func (b *Blockstore) onlineGC() error {
 	}
 
 	for err == nil {
+		if yield != nil {
+			if err := yield(); err != nil {
+				return err
+			}
+		}
 		err = b.db.RunValueLogGC(0.125)
 	}
 
@@ -486,7 +492,7 @@ func (b *Blockstore) CollectGarbage(opts ...blockstore.BlockstoreGCOption) error
 		return b.movingGC()
 	}
 
-	return b.onlineGC()
+	return b.onlineGC(options.Yield)
 }
 
 // Size returns the aggregate size of the blockstore
@@ -551,6 +557,39 @@ func (b *Blockstore) View(ctx context.Context, cid cid.Cid, fn func([]byte) erro
 	})
 }
 
+// ViewMany implements blockstore.BatchViewer, viewing the blocks within a single
+// read transaction.
+func (b *Blockstore) ViewMany(ctx context.Context, cids []cid.Cid, fn func(int, []byte) error) error {
+	if err := b.access(); err != nil {
+		return err
+	}
+	defer b.viewers.Done()
+
+	b.lockDB()
+	defer b.unlockDB()
+
+	return b.db.View(func(txn *badger.Txn) error {
+		var k []byte
+		for i, c := range cids {
+			k = b.StorageKey(k, c)
+			item, err := txn.Get(k)
+			switch err {
+			case nil:
+			case badger.ErrKeyNotFound:
+				return ipld.ErrNotFound{Cid: c}
+			default:
+				return fmt.Errorf("failed to view block from badger blockstore: %w", err)
+			}
+			if err := item.Value(func(data []byte) error {
+				return fn(i, data)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Has implements Blockstore.Has.
 func (b *Blockstore) Has(ctx context.Context, cid cid.Cid) (bool, error) {
 	if err := b.access(); err != nil {
@@ -270,3 +270,24 @@ func TestMoveWithPrefix(t *testing.T) {
 		return opts
 	})
 }
+
+func TestOnlineGCYield(t *testing.T) {
+	//stm: @SPLITSTORE_BADGER_OPEN_001, @SPLITSTORE_BADGER_CLOSE_001
+	//stm: @SPLITSTORE_BADGER_COLLECT_GARBAGE_001
+	bs, _ := newBlockstore(DefaultOptions)(t)
+	db := bs.(*Blockstore)
+	defer db.Close() //nolint:errcheck
+
+	var rounds int
+	require.NoError(t, db.CollectGarbage(blockstore.WithGCYield(func() error {
+		rounds++
+		return nil
+	})))
+	require.Equal(t, 1, rounds)
+
+	// the gc stops with the error of yield
+	errStop := fmt.Errorf("stop")
+	require.ErrorIs(t, db.CollectGarbage(blockstore.WithGCYield(func() error {
+		return errStop
+	})), errStop)
+}
@@ -356,3 +356,33 @@ func collect(ch <-chan cid.Cid) []cid.Cid {
 	}
 	return keys
 }
+
+func (s *Suite) TestViewMany(t *testing.T) {
+	ctx := context.Background()
+	bs, _ := s.NewBlockstore(t)
+	if c, ok := bs.(io.Closer); ok {
+		defer func() { require.NoError(t, c.Close()) }()
+	}
+
+	blks := []blocks.Block{
+		blocks.NewBlock([]byte("foo1")),
+		blocks.NewBlock([]byte("foo2")),
+		blocks.NewBlock([]byte("foo3")),
+	}
+	require.NoError(t, bs.PutMany(ctx, blks))
+
+	cids := []cid.Cid{blks[2].Cid(), blks[0].Cid(), blks[2].Cid()}
+	got := make([][]byte, len(cids))
+	err := blockstore.ViewMany(ctx, blockstore.Adapt(bs), cids, func(i int, data []byte) error {
+		got[i] = append([]byte(nil), data...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{blks[2].RawData(), blks[0].RawData(), blks[2].RawData()}, got)
+
+	missing := blocks.NewBlock([]byte("missing")).Cid()
+	err = blockstore.ViewMany(ctx, blockstore.Adapt(bs), []cid.Cid{blks[1].Cid(), missing}, func(int, []byte) error {
+		return nil
+	})
+	require.True(t, ipld.IsNotFound(err))
+}
@@ -29,6 +29,31 @@ type BatchDeleter interface {
 	DeleteMany(ctx context.Context, cids []cid.Cid) error
 }
 
+// BatchViewer is a trait for blockstores that can view many blocks in a single
+// round-trip, e.g. within one transaction.
+type BatchViewer interface {
+	// ViewMany calls callback with the index in cids and the data of each
+	// block, in no particular order. It fails with ipld.ErrNotFound on the first block that
+	// is missing.
+	ViewMany(ctx context.Context, cids []cid.Cid, callback func(int, []byte) error) error
+}
+
+// ViewMany views the blocks of cids in bs, in a single round-trip if bs is a
+// BatchViewer, and one at a time otherwise.
+func ViewMany(ctx context.Context, bs Viewer, cids []cid.Cid, callback func(int, []byte) error) error {
+	if bv, ok := bs.(BatchViewer); ok {
+		return bv.ViewMany(ctx, cids, callback)
+	}
+	for i, c := range cids {
+		if err := bs.View(ctx, c, func(data []byte) error {
+			return callback(i, data)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // BlockstoreIterator is a trait for efficient iteration
 type BlockstoreIterator interface {
 	ForEachKey(func(cid.Cid) error) error
@@ -45,6 +70,9 @@ type BlockstoreGCOption = func(*BlockstoreGCOptions) error
 // BlockstoreGCOptions is a struct with GC options
 type BlockstoreGCOptions struct {
 	FullGC bool
+	// Yield is called before every round of an online GC; the GC stops with
+	// the error it returns, if any.
+	Yield func() error
 }
 
 func WithFullGC(fullgc bool) BlockstoreGCOption {
@@ -54,6 +82,15 @@ func WithFullGC(fullgc bool) BlockstoreGCOption {
 	}
 }
 
+// WithGCYield sets the function called before every round of an online GC, to
+// throttle or abort it.
+func WithGCYield(yield func() error) BlockstoreGCOption {
+	return func(opts *BlockstoreGCOptions) error {
+		opts.Yield = yield
+		return nil
+	}
+}
+
 // BlockstoreSize is a trait for on-disk blockstores that can report their size
 type BlockstoreSize interface {
 	Size() (int64, error)
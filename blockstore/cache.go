@@ -0,0 +1,329 @@
+package blockstore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+)
+
+// the eviction policies of CachedBlockstore
+const (
+	CachePolicyARC    = "arc"
+	CachePolicyS3FIFO = "s3-fifo"
+)
+
+// CacheConfig configures a CachedBlockstore.
+type CacheConfig struct {
+	// Name tags the metrics of the cache.
+	Name string
+	// Policy is the eviction policy, CachePolicyARC or CachePolicyS3FIFO.
+	Policy string
+	// MaxBytes is the size of the blocks the cache holds at most.
+	MaxBytes int64
+	// MaxBlockSize is the size of the largest blocks admitted into the cache;
+	// larger blocks are read through, so that a few of them don't evict many
+	// small blocks. 0 admits the blocks up to a hundredth of MaxBytes.
+	MaxBlockSize int
+}
+
+// CachedBlockstore is a read cache of the blocks of a blockstore, holding
+// the blocks read and written recently within a byte budget.
+//
+//   - Reads are served from the cache, else from the blockstore, caching the
+//     blocks read unless they are larger than MaxBlockSize.
+//   - Writes go through to the blockstore, caching the blocks put; deleted
+//     blocks are evicted.
+//
+// The hits and misses of the cache are emitted with the CacheMeasures by
+// EmitMetrics.
+type CachedBlockstore struct {
+	Blockstore
+
+	name         string
+	maxBlockSize int
+
+	lk     sync.Mutex
+	policy cachePolicy
+
+	hits, misses, adds, rejected, evictions, costAdded, costEvicted int64
+}
+
+var _ Blockstore = (*CachedBlockstore)(nil)
+var _ BatchViewer = (*CachedBlockstore)(nil)
+var _ BlockstoreIterator = (*CachedBlockstore)(nil)
+var _ BlockstoreGC = (*CachedBlockstore)(nil)
+var _ BlockstoreSize = (*CachedBlockstore)(nil)
+var _ Flusher = (*CachedBlockstore)(nil)
+
+// NewCached returns a blockstore caching the blocks of bs.
+func NewCached(bs Blockstore, cfg CacheConfig) (*CachedBlockstore, error) {
+	if cfg.MaxBytes <= 0 {
+		return nil, xerrors.Errorf("cache size must be positive, got %d", cfg.MaxBytes)
+	}
+
+	c := &CachedBlockstore{
+		Blockstore:   bs,
+		name:         cfg.Name,
+		maxBlockSize: cfg.MaxBlockSize,
+	}
+	if c.maxBlockSize <= 0 {
+		c.maxBlockSize = int(cfg.MaxBytes / 100)
+	}
+
+	switch cfg.Policy {
+	case CachePolicyARC, "":
+		c.policy = newARCCache(cfg.MaxBytes)
+	case CachePolicyS3FIFO:
+		c.policy = newS3FIFOCache(cfg.MaxBytes)
+	default:
+		return nil, xerrors.Errorf("unknown cache policy %q", cfg.Policy)
+	}
+	return c, nil
+}
+
+func cacheKey(c cid.Cid) string {
+	return string(c.Hash())
+}
+
+func (c *CachedBlockstore) get(k cid.Cid) ([]byte, bool) {
+	c.lk.Lock()
+	data, ok := c.policy.get(cacheKey(k))
+	c.lk.Unlock()
+
+	if ok {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+	return data, ok
+}
+
+// add caches data, which must not be modified afterwards.
+func (c *CachedBlockstore) add(k cid.Cid, data []byte) {
+	if len(data) > c.maxBlockSize {
+		atomic.AddInt64(&c.rejected, 1)
+		return
+	}
+
+	c.lk.Lock()
+	evicted, evictedBytes := c.policy.add(cacheKey(k), data)
+	c.lk.Unlock()
+
+	atomic.AddInt64(&c.adds, 1)
+	atomic.AddInt64(&c.costAdded, int64(len(data)))
+	atomic.AddInt64(&c.evictions, int64(evicted))
+	atomic.AddInt64(&c.costEvicted, evictedBytes)
+}
+
+func (c *CachedBlockstore) Has(ctx context.Context, k cid.Cid) (bool, error) {
+	if _, ok := c.get(k); ok {
+		return true, nil
+	}
+	return c.Blockstore.Has(ctx, k)
+}
+
+func (c *CachedBlockstore) Get(ctx context.Context, k cid.Cid) (blocks.Block, error) {
+	if data, ok := c.get(k); ok {
+		return blocks.NewBlockWithCid(data, k)
+	}
+
+	blk, err := c.Blockstore.Get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	c.add(k, blk.RawData())
+	return blk, nil
+}
+
+func (c *CachedBlockstore) View(ctx context.Context, k cid.Cid, callback func([]byte) error) error {
+	if data, ok := c.get(k); ok {
+		return callback(data)
+	}
+
+	return c.Blockstore.View(ctx, k, func(data []byte) error {
+		// the data of views is only valid within them
+		c.add(k, append([]byte(nil), data...))
+		return callback(data)
+	})
+}
+
+// ViewMany views the cached blocks, then the others in a single round-trip to
+// the blockstore if it is a BatchViewer.
+func (c *CachedBlockstore) ViewMany(ctx context.Context, cids []cid.Cid, callback func(int, []byte) error) error {
+	var missing []cid.Cid
+	var indexes []int
+	for i, k := range cids {
+		data, ok := c.get(k)
+		if !ok {
+			missing = append(missing, k)
+			indexes = append(indexes, i)
+			continue
+		}
+		if err := callback(i, data); err != nil {
+			return err
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return ViewMany(ctx, c.Blockstore, missing, func(i int, data []byte) error {
+		c.add(missing[i], append([]byte(nil), data...))
+		return callback(indexes[i], data)
+	})
+}
+
+func (c *CachedBlockstore) GetSize(ctx context.Context, k cid.Cid) (int, error) {
+	if data, ok := c.get(k); ok {
+		return len(data), nil
+	}
+	return c.Blockstore.GetSize(ctx, k)
+}
+
+func (c *CachedBlockstore) Put(ctx context.Context, blk blocks.Block) error {
+	if err := c.Blockstore.Put(ctx, blk); err != nil {
+		return err
+	}
+	c.add(blk.Cid(), blk.RawData())
+	return nil
+}
+
+func (c *CachedBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	if err := c.Blockstore.PutMany(ctx, blks); err != nil {
+		return err
+	}
+	for _, blk := range blks {
+		c.add(blk.Cid(), blk.RawData())
+	}
+	return nil
+}
+
+func (c *CachedBlockstore) DeleteBlock(ctx context.Context, k cid.Cid) error {
+	c.evict(k)
+	return c.Blockstore.DeleteBlock(ctx, k)
+}
+
+func (c *CachedBlockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	c.evict(cids...)
+	return c.Blockstore.DeleteMany(ctx, cids)
+}
+
+func (c *CachedBlockstore) evict(cids ...cid.Cid) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	for _, k := range cids {
+		c.policy.remove(cacheKey(k))
+	}
+}
+
+// ForEachKey iterates over the keys of the blockstore.
+func (c *CachedBlockstore) ForEachKey(f func(cid.Cid) error) error {
+	iterBstore, ok := c.Blockstore.(BlockstoreIterator)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore (type %T) doesn't support fast iteration", c.Blockstore)
+	}
+	return iterBstore.ForEachKey(f)
+}
+
+// CollectGarbage collects the garbage of the blockstore.
+func (c *CachedBlockstore) CollectGarbage(opts ...BlockstoreGCOption) error {
+	gcBstore, ok := c.Blockstore.(BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore (type %T) doesn't support garbage collection", c.Blockstore)
+	}
+	return gcBstore.CollectGarbage(opts...)
+}
+
+// Size returns the size of the blockstore.
+func (c *CachedBlockstore) Size() (int64, error) {
+	sizeBstore, ok := c.Blockstore.(BlockstoreSize)
+	if !ok {
+		return 0, xerrors.Errorf("underlying blockstore (type %T) doesn't report its size", c.Blockstore)
+	}
+	return sizeBstore.Size()
+}
+
+// Flush flushes the writes buffered by the blockstore, if it is a Flusher.
+func (c *CachedBlockstore) Flush(ctx context.Context) error {
+	if f, ok := c.Blockstore.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// CacheStats are the counters of a CachedBlockstore.
+type CacheStats struct {
+	Hits, Misses   int64
+	Entries        int
+	Bytes          int64
+	Adds, Rejected int64
+	Evictions      int64
+	CostAdded      int64
+	CostEvicted    int64
+}
+
+// Stats returns the counters of the cache.
+func (c *CachedBlockstore) Stats() CacheStats {
+	c.lk.Lock()
+	entries, size := c.policy.len(), c.policy.bytes()
+	c.lk.Unlock()
+
+	return CacheStats{
+		Hits:        atomic.LoadInt64(&c.hits),
+		Misses:      atomic.LoadInt64(&c.misses),
+		Entries:     entries,
+		Bytes:       size,
+		Adds:        atomic.LoadInt64(&c.adds),
+		Rejected:    atomic.LoadInt64(&c.rejected),
+		Evictions:   atomic.LoadInt64(&c.evictions),
+		CostAdded:   atomic.LoadInt64(&c.costAdded),
+		CostEvicted: atomic.LoadInt64(&c.costEvicted),
+	}
+}
+
+// EmitMetrics records the counters of the cache with the CacheMeasures, tagged
+// with its name, every CacheMetricsEmitInterval until ctx is done.
+func (c *CachedBlockstore) EmitMetrics(ctx context.Context) {
+	ctx, err := tag.New(ctx, tag.Upsert(CacheName, c.name))
+	if err != nil {
+		log.Warnf("tagging the metrics of cache %s: %s", c.name, err)
+		return
+	}
+
+	ticker := time.NewTicker(CacheMetricsEmitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		st := c.Stats()
+		var ratio float64
+		if queries := st.Hits + st.Misses; queries > 0 {
+			ratio = float64(st.Hits) / float64(queries)
+		}
+		stats.Record(ctx,
+			CacheMeasures.HitRatio.M(ratio),
+			CacheMeasures.Hits.M(st.Hits),
+			CacheMeasures.Misses.M(st.Misses),
+			CacheMeasures.Entries.M(int64(st.Entries)),
+			CacheMeasures.QueriesServed.M(st.Hits+st.Misses),
+			CacheMeasures.Adds.M(st.Adds),
+			CacheMeasures.Evictions.M(st.Evictions),
+			CacheMeasures.CostAdded.M(st.CostAdded),
+			CacheMeasures.CostEvicted.M(st.CostEvicted),
+			CacheMeasures.SetsRejected.M(st.Rejected),
+		)
+	}
+}
@@ -0,0 +1,293 @@
+package blockstore
+
+import (
+	"container/list"
+)
+
+// cachePolicy holds the blocks of a cache within a byte budget, choosing the
+// blocks evicted for the new ones. It is not safe for concurrent use.
+type cachePolicy interface {
+	// get returns the cached data of k, recording the access.
+	get(k string) ([]byte, bool)
+	// add caches data under k, which isn't cached, evicting blocks until the
+	// cache is within its budget; it returns the number and size of the
+	// evicted blocks.
+	add(k string, data []byte) (evicted int, evictedBytes int64)
+	remove(k string)
+	len() int
+	bytes() int64
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+	size int64
+
+	// the queue (arc) or the access count (s3-fifo) of the entry
+	tag int
+	// whether the entry is in the small queue (s3-fifo)
+	small bool
+}
+
+// cacheQueue is a list of entries with their total size, most recent first.
+type cacheQueue struct {
+	l    list.List
+	size int64
+}
+
+func (q *cacheQueue) pushFront(e *cacheEntry) *list.Element {
+	q.size += e.size
+	return q.l.PushFront(e)
+}
+
+func (q *cacheQueue) remove(el *list.Element) *cacheEntry {
+	e := q.l.Remove(el).(*cacheEntry)
+	q.size -= e.size
+	return e
+}
+
+// arcCache is an adaptive replacement cache weighted by the size of the
+// blocks: t1 holds the blocks read once recently and t2 those read more, b1
+// and b2 the keys and sizes of the blocks recently evicted from them. The
+// target size p of t1 grows on hits in b1 and shrinks on hits in b2.
+type arcCache struct {
+	max int64
+	p   int64
+
+	t1, t2, b1, b2 cacheQueue
+	entries        map[string]*list.Element
+}
+
+const (
+	arcT1 = iota
+	arcT2
+	arcB1
+	arcB2
+)
+
+func newARCCache(max int64) *arcCache {
+	return &arcCache{max: max, entries: map[string]*list.Element{}}
+}
+
+func (c *arcCache) queue(tag int) *cacheQueue {
+	switch tag {
+	case arcT1:
+		return &c.t1
+	case arcT2:
+		return &c.t2
+	case arcB1:
+		return &c.b1
+	default:
+		return &c.b2
+	}
+}
+
+// move moves the entry of el to the front of the queue tag.
+func (c *arcCache) move(el *list.Element, tag int) {
+	e := c.queue(el.Value.(*cacheEntry).tag).remove(el)
+	e.tag = tag
+	if tag == arcB1 || tag == arcB2 {
+		e.data = nil
+	}
+	c.entries[e.key] = c.queue(tag).pushFront(e)
+}
+
+func (c *arcCache) get(k string) ([]byte, bool) {
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if e.tag != arcT1 && e.tag != arcT2 {
+		return nil, false
+	}
+	c.move(el, arcT2)
+	return e.data, true
+}
+
+func (c *arcCache) add(k string, data []byte) (int, int64) {
+	size := int64(len(data))
+	tag := arcT1
+
+	if el, ok := c.entries[k]; ok {
+		// a hit in the ghosts adapts the target size of t1, and the block goes
+		// to t2 as it was read before
+		e := el.Value.(*cacheEntry)
+		switch e.tag {
+		case arcB1:
+			c.p = min64(c.max, c.p+size*max64(1, c.b2.size/max64(c.b1.size, 1)))
+		case arcB2:
+			c.p = max64(0, c.p-size*max64(1, c.b1.size/max64(c.b2.size, 1)))
+		default:
+			return 0, 0
+		}
+		c.queue(e.tag).remove(el)
+		delete(c.entries, k)
+		tag = arcT2
+	}
+
+	e := &cacheEntry{key: k, data: data, size: size, tag: tag}
+	c.entries[k] = c.queue(tag).pushFront(e)
+
+	var evicted int
+	var evictedBytes int64
+	for c.t1.size+c.t2.size > c.max {
+		from, to := &c.t2, arcB2
+		if c.t1.size > 0 && (c.t1.size > c.p || c.t2.size == 0) {
+			from, to = &c.t1, arcB1
+		}
+		el := from.l.Back()
+		evicted++
+		evictedBytes += el.Value.(*cacheEntry).size
+		c.move(el, to)
+	}
+
+	// the ghosts remember about as many bytes as the cache holds
+	for c.b1.size > 0 && c.t1.size+c.b1.size > c.max {
+		delete(c.entries, c.b1.remove(c.b1.l.Back()).key)
+	}
+	for c.b2.size > 0 && c.t1.size+c.t2.size+c.b1.size+c.b2.size > 2*c.max {
+		delete(c.entries, c.b2.remove(c.b2.l.Back()).key)
+	}
+	return evicted, evictedBytes
+}
+
+func (c *arcCache) remove(k string) {
+	if el, ok := c.entries[k]; ok {
+		c.queue(el.Value.(*cacheEntry).tag).remove(el)
+		delete(c.entries, k)
+	}
+}
+
+func (c *arcCache) len() int {
+	return c.t1.l.Len() + c.t2.l.Len()
+}
+
+func (c *arcCache) bytes() int64 {
+	return c.t1.size + c.t2.size
+}
+
+// s3fifoCache is an S3-FIFO cache weighted by the size of the blocks: new
+// blocks go to a small queue holding a tenth of the budget, and are only
+// promoted to the main queue if read again before leaving it. The keys of the
+// blocks evicted from the small queue are remembered in a ghost queue, and
+// they go straight to the main queue when added again. Blocks read while in
+// the main queue are given another round through it.
+type s3fifoCache struct {
+	max, smallMax int64
+
+	small, main cacheQueue
+	entries     map[string]*list.Element
+
+	ghost  list.List
+	ghosts map[string]*list.Element
+}
+
+const s3fifoMaxFreq = 3
+
+func newS3FIFOCache(max int64) *s3fifoCache {
+	return &s3fifoCache{
+		max:      max,
+		smallMax: max / 10,
+		entries:  map[string]*list.Element{},
+		ghosts:   map[string]*list.Element{},
+	}
+}
+
+func (c *s3fifoCache) get(k string) ([]byte, bool) {
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if e.tag < s3fifoMaxFreq {
+		e.tag++
+	}
+	return e.data, true
+}
+
+func (c *s3fifoCache) add(k string, data []byte) (int, int64) {
+	if _, ok := c.entries[k]; ok {
+		return 0, 0
+	}
+
+	e := &cacheEntry{key: k, data: data, size: int64(len(data))}
+	if g, ok := c.ghosts[k]; ok {
+		c.ghost.Remove(g)
+		delete(c.ghosts, k)
+		c.entries[k] = c.main.pushFront(e)
+	} else {
+		e.small = true
+		c.entries[k] = c.small.pushFront(e)
+	}
+
+	var evicted int
+	var evictedBytes int64
+	for c.small.size+c.main.size > c.max {
+		if c.small.size > c.smallMax || c.main.l.Len() == 0 {
+			e := c.small.remove(c.small.l.Back())
+			e.small = false
+			if e.tag > 0 {
+				e.tag = 0
+				c.entries[e.key] = c.main.pushFront(e)
+				continue
+			}
+			delete(c.entries, e.key)
+			c.ghosts[e.key] = c.ghost.PushFront(e.key)
+			evicted++
+			evictedBytes += e.size
+			continue
+		}
+
+		e := c.main.remove(c.main.l.Back())
+		if e.tag > 0 {
+			e.tag--
+			c.entries[e.key] = c.main.pushFront(e)
+			continue
+		}
+		delete(c.entries, e.key)
+		evicted++
+		evictedBytes += e.size
+	}
+
+	// the ghost queue remembers as many keys as the cache holds blocks
+	for c.ghost.Len() > len(c.entries) {
+		delete(c.ghosts, c.ghost.Remove(c.ghost.Back()).(string))
+	}
+	return evicted, evictedBytes
+}
+
+func (c *s3fifoCache) remove(k string) {
+	el, ok := c.entries[k]
+	if !ok {
+		return
+	}
+	delete(c.entries, k)
+	if el.Value.(*cacheEntry).small {
+		c.small.remove(el)
+		return
+	}
+	c.main.remove(el)
+}
+
+func (c *s3fifoCache) len() int {
+	return len(c.entries)
+}
+
+func (c *s3fifoCache) bytes() int64 {
+	return c.small.size + c.main.size
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+)
+
+func mkCacheBlock(i, size int) blocks.Block {
+	data := make([]byte, size)
+	copy(data, fmt.Sprintf("block %d", i))
+	return blocks.NewBlock(data)
+}
+
+func TestCachedBlockstore(t *testing.T) {
+	for _, policy := range []string{CachePolicyARC, CachePolicyS3FIFO} {
+		policy := policy
+		t.Run(policy, func(t *testing.T) {
+			ctx := context.Background()
+			under := NewMemory()
+			cbs, err := NewCached(under, CacheConfig{Name: "test", Policy: policy, MaxBytes: 1000, MaxBlockSize: 200})
+			require.NoError(t, err)
+
+			// puts are cached, huge blocks are not admitted
+			small, huge := mkCacheBlock(0, 100), mkCacheBlock(1, 300)
+			require.NoError(t, cbs.PutMany(ctx, []blocks.Block{small, huge}))
+			st := cbs.Stats()
+			require.Equal(t, 1, st.Entries)
+			require.EqualValues(t, 1, st.Rejected)
+
+			// reads are served from the cache
+			require.NoError(t, under.DeleteBlock(ctx, small.Cid()))
+			blk, err := cbs.Get(ctx, small.Cid())
+			require.NoError(t, err)
+			require.Equal(t, small.RawData(), blk.RawData())
+			has, err := cbs.Has(ctx, small.Cid())
+			require.NoError(t, err)
+			require.True(t, has)
+			require.NoError(t, cbs.View(ctx, huge.Cid(), func(data []byte) error {
+				require.Equal(t, huge.RawData(), data)
+				return nil
+			}))
+			st = cbs.Stats()
+			require.EqualValues(t, 2, st.Hits)
+			require.EqualValues(t, 1, st.Misses)
+
+			// deletes evict
+			require.NoError(t, cbs.DeleteBlock(ctx, small.Cid()))
+			has, err = cbs.Has(ctx, small.Cid())
+			require.NoError(t, err)
+			require.False(t, has)
+
+			// the cache stays within its budget
+			var cids []cid.Cid
+			for i := 0; i < 50; i++ {
+				b := mkCacheBlock(i+2, 100)
+				require.NoError(t, cbs.Put(ctx, b))
+				cids = append(cids, b.Cid())
+				if i%2 == 0 {
+					// read the even blocks again, they are kept over the others
+					_, err := cbs.GetSize(ctx, cids[i/2*2])
+					require.NoError(t, err)
+				}
+			}
+			st = cbs.Stats()
+			require.LessOrEqual(t, st.Bytes, int64(1000))
+			require.Positive(t, st.Evictions)
+
+			// batch views read the missing blocks through
+			seen := map[int]bool{}
+			require.NoError(t, cbs.ViewMany(ctx, cids, func(i int, data []byte) error {
+				require.Equal(t, mkCacheBlock(i+2, 100).RawData(), data)
+				seen[i] = true
+				return nil
+			}))
+			require.Len(t, seen, len(cids))
+		})
+	}
+}
+
+func TestARCCacheAdapts(t *testing.T) {
+	c := newARCCache(1000)
+	for i := 0; i < 10; i++ {
+		c.add(fmt.Sprint(i), make([]byte, 100))
+	}
+	// blocks read twice are kept by a scan of blocks read once
+	for i := 0; i < 5; i++ {
+		_, ok := c.get(fmt.Sprint(i))
+		require.True(t, ok)
+	}
+	for i := 10; i < 30; i++ {
+		c.add(fmt.Sprint(i), make([]byte, 100))
+	}
+	for i := 0; i < 5; i++ {
+		_, ok := c.get(fmt.Sprint(i))
+		require.True(t, ok, "block %d", i)
+	}
+	require.LessOrEqual(t, c.bytes(), int64(1000))
+
+	// a block recently evicted from t1 and added again goes to t2
+	_, ok := c.get("24")
+	require.False(t, ok)
+	c.add("24", make([]byte, 100))
+	require.Equal(t, arcT2, c.entries["24"].Value.(*cacheEntry).tag)
+}
+
+func TestS3FIFOCacheGhosts(t *testing.T) {
+	c := newS3FIFOCache(1000)
+	for i := 0; i < 20; i++ {
+		c.add(fmt.Sprint(i), make([]byte, 100))
+	}
+	require.LessOrEqual(t, c.bytes(), int64(1000))
+
+	// the blocks evicted from the small queue are remembered, and go to the
+	// main queue when added again
+	_, ok := c.get("0")
+	require.False(t, ok)
+	_, ghost := c.ghosts["0"]
+	require.True(t, ghost)
+	c.add("0", make([]byte, 100))
+	require.False(t, c.entries["0"].Value.(*cacheEntry).small)
+}
@@ -0,0 +1,33 @@
+package blockstore
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+)
+
+// NewCborStore returns a cbor store over bs that decodes objects in place,
+// viewing their data with bs.View instead of copying it out with Get. With a
+// badger blockstore, whose value logs are memory-mapped, the objects are
+// decoded straight from the mapped files; no heap buffer is allocated for
+// them, which matters for large state objects.
+//
+// The data is only valid within the view, which is fine since decoding copies
+// what it keeps.
+func NewCborStore(ctx context.Context, bs Blockstore) *cbor.BasicIpldStore {
+	cst := cbor.NewCborStore(bs)
+	cst.Viewer = &cborViewer{ctx: ctx, bs: bs}
+	return cst
+}
+
+// cborViewer adapts a blockstore to the viewer of the cbor store, whose View
+// takes no context.
+type cborViewer struct {
+	ctx context.Context
+	bs  Viewer
+}
+
+func (v *cborViewer) View(c cid.Cid, callback func([]byte) error) error {
+	return v.bs.View(v.ctx, c, callback)
+}
@@ -0,0 +1,32 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+)
+
+// noGetBlockstore fails the reads that copy the blocks out.
+type noGetBlockstore struct {
+	Blockstore
+}
+
+func (noGetBlockstore) Get(context.Context, cid.Cid) (blocks.Block, error) {
+	panic("Get called")
+}
+
+func TestCborStoreViews(t *testing.T) {
+	ctx := context.Background()
+	cst := NewCborStore(ctx, noGetBlockstore{NewMemory()})
+
+	c, err := cst.Put(ctx, map[string]uint64{"foo": 42})
+	require.NoError(t, err)
+
+	var out map[string]uint64
+	require.NoError(t, cst.Get(ctx, c, &out))
+	require.Equal(t, map[string]uint64{"foo": 42}, out)
+}
@@ -0,0 +1,296 @@
+// Package gcsched runs the online garbage collection of the blockstore in the
+// background, within configured windows of the day, holding it off while the
+// node is busy.
+package gcsched
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("gcsched")
+
+var (
+	// CheckInterval is the interval at which the scheduler checks whether a
+	// GC cycle is due.
+	CheckInterval = time.Minute
+	// ThrottleWait is how long a GC cycle waits, between its rounds, for the
+	// node to be less busy.
+	ThrottleWait = 30 * time.Second
+)
+
+var errWindowClosed = xerrors.New("gc window closed")
+
+// Window is a time of the day, from Start to End in local time, GC cycles
+// may run in. A window ending before it starts spans midnight.
+type Window struct {
+	Start, End time.Duration
+}
+
+// ParseWindow parses a window in the HH:MM-HH:MM format.
+func ParseWindow(s string) (Window, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, xerrors.Errorf("gc window %q is not in the HH:MM-HH:MM format", s)
+	}
+
+	var w Window
+	for _, t := range []struct {
+		s string
+		d *time.Duration
+	}{{start, &w.Start}, {end, &w.End}} {
+		tm, err := time.Parse("15:04", strings.TrimSpace(t.s))
+		if err != nil {
+			return Window{}, xerrors.Errorf("parsing gc window %q: %w", s, err)
+		}
+		*t.d = time.Duration(tm.Hour())*time.Hour + time.Duration(tm.Minute())*time.Minute
+	}
+	return w, nil
+}
+
+// Contains returns whether the time of the day of t is in the window.
+func (w Window) Contains(t time.Time) bool {
+	y, m, d := t.Date()
+	tod := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if w.Start <= w.End {
+		return tod >= w.Start && tod < w.End
+	}
+	return tod >= w.Start || tod < w.End
+}
+
+// Config configures the GC cycles run by a Scheduler.
+type Config struct {
+	// Interval is the time between the starts of GC cycles; 0 only runs the
+	// cycles started with Start.
+	Interval time.Duration
+	// Windows are the times of the day scheduled GC cycles run in; a cycle
+	// still running at the end of its window stops. No windows allow cycles
+	// at any time.
+	Windows []Window
+}
+
+// Scheduler runs GC cycles of a blockstore every Config.Interval. Between the
+// rounds of a cycle, it waits for the busy function to return false, so that
+// the GC doesn't compete with the node under load.
+type Scheduler struct {
+	bs   blockstore.BlockstoreGC
+	cfg  Config
+	busy func() bool
+
+	lk      sync.Mutex
+	running *api.BlockstoreGCCycle
+	last    *api.BlockstoreGCCycle
+	abort   context.CancelFunc
+
+	wg sync.WaitGroup
+}
+
+// New returns a scheduler collecting the garbage of bs. busy may be nil to
+// never throttle GC.
+func New(bs blockstore.BlockstoreGC, cfg Config, busy func() bool) *Scheduler {
+	if busy == nil {
+		busy = func() bool { return false }
+	}
+	return &Scheduler{bs: bs, cfg: cfg, busy: busy}
+}
+
+// Run starts the GC cycles as they are due, until ctx is canceled. The
+// running cycle, if any, is aborted before Run returns.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.wg.Wait()
+
+	if s.cfg.Interval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := build.Clock.Ticker(CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if s.due(build.Clock.Now()) {
+				if err := s.start(ctx, false); err != nil {
+					log.Warnw("starting gc cycle", "error", err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// due returns whether a scheduled GC cycle is due at now.
+func (s *Scheduler) due(now time.Time) bool {
+	if !s.inWindow(now) || s.busy() {
+		return false
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return s.running == nil && (s.last == nil || now.Sub(s.last.Started) >= s.cfg.Interval)
+}
+
+func (s *Scheduler) inWindow(now time.Time) bool {
+	if len(s.cfg.Windows) == 0 {
+		return true
+	}
+	for _, w := range s.cfg.Windows {
+		if w.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Start starts a GC cycle now, outside of the windows of the config. It fails
+// if a cycle is already running.
+func (s *Scheduler) Start(ctx context.Context) error {
+	return s.start(ctx, true)
+}
+
+func (s *Scheduler) start(ctx context.Context, manual bool) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.running != nil {
+		return xerrors.Errorf("a gc cycle is already running, started at %s", s.running.Started)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.abort = cancel
+	s.running = &api.BlockstoreGCCycle{
+		Manual:  manual,
+		Started: build.Clock.Now(),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.collect(ctx, manual)
+	}()
+	return nil
+}
+
+// Abort aborts the running GC cycle at the end of its current round.
+func (s *Scheduler) Abort() error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.running == nil {
+		return xerrors.Errorf("no gc cycle is running")
+	}
+	s.running.Aborted = true
+	s.abort()
+	return nil
+}
+
+func (s *Scheduler) collect(ctx context.Context, manual bool) {
+	log.Infow("collecting blockstore garbage", "manual", manual)
+
+	err := s.bs.CollectGarbage(blockstore.WithGCYield(func() error {
+		return s.yield(ctx, manual)
+	}))
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	cycle := s.running
+	cycle.Finished = build.Clock.Now()
+	switch {
+	case err == nil, xerrors.Is(err, errWindowClosed), cycle.Aborted && ctx.Err() != nil:
+		log.Infow("collected blockstore garbage", "rounds", cycle.Rounds, "throttled", cycle.Throttled, "aborted", cycle.Aborted, "took", cycle.Finished.Sub(cycle.Started))
+	default:
+		cycle.Error = err.Error()
+		log.Errorw("collecting blockstore garbage", "error", err)
+	}
+	s.running = nil
+	s.last = cycle
+}
+
+// yield is called before every round of the GC. It waits while the node is
+// busy, and stops the GC when it is aborted or its window closes.
+func (s *Scheduler) yield(ctx context.Context, manual bool) (err error) {
+	var throttled time.Duration
+	defer func() {
+		s.lk.Lock()
+		defer s.lk.Unlock()
+		if err == nil {
+			s.running.Rounds++
+		}
+		s.running.Throttled += throttled
+		s.running.Throttling = false
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !manual && !s.inWindow(build.Clock.Now()) {
+			return errWindowClosed
+		}
+		if !s.busy() {
+			return nil
+		}
+
+		s.lk.Lock()
+		s.running.Throttling = true
+		s.lk.Unlock()
+
+		start := build.Clock.Now()
+		select {
+		case <-build.Clock.After(ThrottleWait):
+		case <-ctx.Done():
+		}
+		throttled += build.Clock.Since(start)
+	}
+}
+
+// Status returns the running and the last GC cycles.
+func (s *Scheduler) Status() api.BlockstoreGCStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	st := api.BlockstoreGCStatus{Interval: s.cfg.Interval}
+	if s.running != nil {
+		running := *s.running
+		st.Running = &running
+	}
+	if s.last != nil {
+		last := *s.last
+		st.Last = &last
+	}
+	return st
+}
+
+// SystemLoad returns the one minute load average of the system per CPU. It
+// is only available on Linux.
+func SystemLoad() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, xerrors.Errorf("empty load average")
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, xerrors.Errorf("parsing load average: %w", err)
+	}
+	return load / float64(runtime.NumCPU()), nil
+}
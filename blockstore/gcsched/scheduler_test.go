@@ -0,0 +1,125 @@
+// stm: #unit
+package gcsched
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+// fakeGC runs rounds rounds of GC, or until yield fails.
+type fakeGC struct {
+	rounds int
+	runs   int32
+	start  chan struct{}
+}
+
+func (f *fakeGC) CollectGarbage(opts ...blockstore.BlockstoreGCOption) error {
+	atomic.AddInt32(&f.runs, 1)
+
+	var options blockstore.BlockstoreGCOptions
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+	if f.start != nil {
+		<-f.start
+	}
+	for i := 0; i < f.rounds; i++ {
+		if err := options.Yield(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func waitDone(t *testing.T, s *Scheduler) api.BlockstoreGCStatus {
+	require.Eventually(t, func() bool { return s.Status().Running == nil }, 5*time.Second, time.Millisecond)
+	return s.Status()
+}
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("22:30-04:00")
+	require.NoError(t, err)
+	require.Equal(t, Window{Start: 22*time.Hour + 30*time.Minute, End: 4 * time.Hour}, w)
+
+	at := func(h, m int) time.Time { return time.Date(2022, 10, 1, h, m, 0, 0, time.Local) }
+	require.True(t, w.Contains(at(23, 0)))
+	require.True(t, w.Contains(at(1, 0)))
+	require.False(t, w.Contains(at(4, 0)))
+	require.False(t, w.Contains(at(12, 0)))
+
+	w, err = ParseWindow("01:00 - 05:00")
+	require.NoError(t, err)
+	require.True(t, w.Contains(at(1, 0)))
+	require.False(t, w.Contains(at(5, 30)))
+
+	for _, s := range []string{"", "01:00", "1am-5am", "01:00-25:00"} {
+		_, err := ParseWindow(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestSchedulerDue(t *testing.T) {
+	now := time.Date(2022, 10, 1, 2, 0, 0, 0, time.Local)
+	busy := false
+	s := New(&fakeGC{}, Config{
+		Interval: time.Hour,
+		Windows:  []Window{{Start: time.Hour, End: 3 * time.Hour}},
+	}, func() bool { return busy })
+
+	require.True(t, s.due(now))
+	require.False(t, s.due(now.Add(2*time.Hour)), "outside of the window")
+	busy = true
+	require.False(t, s.due(now), "the node is busy")
+	busy = false
+
+	s.last = &api.BlockstoreGCCycle{Started: now.Add(-30 * time.Minute)}
+	require.False(t, s.due(now), "within the interval of the last cycle")
+	require.True(t, s.due(now.Add(30*time.Minute)))
+}
+
+func TestSchedulerThrottleAndAbort(t *testing.T) {
+	ThrottleWait = time.Millisecond
+
+	var busy int32
+	gc := &fakeGC{rounds: 3}
+	s := New(gc, Config{}, func() bool { return atomic.AddInt32(&busy, -1) >= 0 })
+
+	// the first two rounds wait for the node to be less busy
+	atomic.StoreInt32(&busy, 2)
+	require.NoError(t, s.Start(context.Background()))
+	st := waitDone(t, s)
+	require.True(t, st.Last.Manual)
+	require.Equal(t, 3, st.Last.Rounds)
+	require.Positive(t, st.Last.Throttled)
+	require.False(t, st.Last.Aborted)
+	require.Empty(t, st.Last.Error)
+
+	require.Error(t, s.Abort(), "no cycle is running")
+
+	// abort the cycle while it waits for the node
+	gc.start = make(chan struct{})
+	atomic.StoreInt32(&busy, 1<<30)
+	require.NoError(t, s.Start(context.Background()))
+	require.Error(t, s.Start(context.Background()), "a cycle is already running")
+	close(gc.start)
+	require.Eventually(t, func() bool {
+		st := s.Status()
+		return st.Running != nil && st.Running.Throttling
+	}, 5*time.Second, time.Millisecond)
+	require.NoError(t, s.Abort())
+
+	st = waitDone(t, s)
+	require.True(t, st.Last.Aborted)
+	require.Zero(t, st.Last.Rounds)
+	require.Empty(t, st.Last.Error)
+	require.EqualValues(t, 2, atomic.LoadInt32(&gc.runs))
+}
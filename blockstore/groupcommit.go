@@ -0,0 +1,349 @@
+package blockstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	block "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// Flusher is a trait for blockstores buffering writes, flushing them to the
+// underlying blockstore.
+type Flusher interface {
+	// Flush returns once the writes made before it are in the underlying
+	// blockstore.
+	Flush(ctx context.Context) error
+}
+
+// GroupCommitConfig configures a GroupCommitBlockstore.
+type GroupCommitConfig struct {
+	// FlushInterval is the longest time writes are pending for, 0 to only
+	// commit them once MaxBytes are pending or on flushes.
+	FlushInterval time.Duration
+	// MaxBytes is the size of the pending writes committed at once. Writers
+	// wait for the running commit once twice as much is pending.
+	MaxBytes int
+}
+
+// GroupCommitBlockstore aggregates the writes to a blockstore into large
+// batches committed in a single PutMany, such as the state written by the
+// execution of many tipsets during sync. Fewer and larger transactions mean
+// fewer fsyncs of the blockstore.
+//
+//   - Puts are pending until the next commit, which happens every
+//     FlushInterval, once MaxBytes are pending, or on Flush. Writers flushing
+//     while a commit is running share the next one.
+//   - Reads see the pending writes.
+//   - Batches are committed one at a time, in the order of the writes: a
+//     commit only starts once the previous one is in the blockstore. Data
+//     referencing the writes, such as the chain head referencing the state,
+//     must only be persisted after a Flush, so that it never references writes
+//     lost in a crash.
+//   - Deletes and iterations flush the pending writes first.
+type GroupCommitBlockstore struct {
+	backing Blockstore
+	cfg     GroupCommitConfig
+
+	lk          sync.Mutex
+	pending     blockBatch
+	pendingSize int
+	pendingSeq  uint64 // the number of the pending batch
+
+	committing    blockBatch // retried first when it failed
+	committingSeq uint64
+	committed     uint64 // the number of the last batch committed
+	commitErr     error
+	// committedCh is closed after each commit attempt
+	committedCh chan struct{}
+
+	closed   bool
+	flushCh  chan struct{}
+	shutdown context.CancelFunc
+	doneCh   chan struct{}
+}
+
+var _ Blockstore = (*GroupCommitBlockstore)(nil)
+var _ Flusher = (*GroupCommitBlockstore)(nil)
+var _ BlockstoreIterator = (*GroupCommitBlockstore)(nil)
+
+// NewGroupCommit returns a blockstore committing the writes to backing in
+// batches, until Shutdown.
+func NewGroupCommit(ctx context.Context, backing Blockstore, cfg GroupCommitConfig) *GroupCommitBlockstore {
+	ctx, cancel := context.WithCancel(ctx)
+	bs := &GroupCommitBlockstore{
+		backing:     backing,
+		cfg:         cfg,
+		pendingSeq:  1,
+		committedCh: make(chan struct{}),
+		flushCh:     make(chan struct{}, 1),
+		shutdown:    cancel,
+		doneCh:      make(chan struct{}),
+	}
+	bs.pending.blockMap = make(map[cid.Cid]block.Block)
+
+	go bs.commitWorker(ctx)
+
+	return bs
+}
+
+func (bs *GroupCommitBlockstore) commitWorker(ctx context.Context) {
+	defer close(bs.doneCh)
+
+	var tick <-chan time.Time
+	if bs.cfg.FlushInterval > 0 {
+		ticker := time.NewTicker(bs.cfg.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-bs.flushCh:
+		case <-tick:
+		case <-ctx.Done():
+			bs.lk.Lock()
+			bs.closed = true
+			bs.lk.Unlock()
+
+			// commit what is left, the blockstore is written through from now
+			for bs.hasPending() {
+				if err := bs.commit(context.Background()); err != nil {
+					log.Errorf("committing the pending writes on shutdown: %s", err)
+					break
+				}
+			}
+			return
+		}
+
+		if err := bs.commit(ctx); err != nil {
+			log.Errorf("committing pending writes failed, retrying: %s", err)
+		}
+	}
+}
+
+// commit commits the failed batch, if any, then the pending one.
+func (bs *GroupCommitBlockstore) commit(ctx context.Context) error {
+	bs.lk.Lock()
+	if len(bs.committing.blockList) == 0 {
+		if len(bs.pending.blockList) == 0 {
+			bs.lk.Unlock()
+			return nil
+		}
+		bs.committing, bs.committingSeq = bs.pending, bs.pendingSeq
+		bs.pending = blockBatch{blockMap: make(map[cid.Cid]block.Block)}
+		bs.pendingSize = 0
+		bs.pendingSeq++
+	}
+	batch, seq := bs.committing, bs.committingSeq
+	bs.lk.Unlock()
+
+	err := bs.backing.PutMany(ctx, batch.blockList)
+
+	bs.lk.Lock()
+	if err == nil {
+		bs.committing = blockBatch{}
+		bs.committed = seq
+	}
+	bs.commitErr = err
+	close(bs.committedCh)
+	bs.committedCh = make(chan struct{})
+	retry := err == nil && len(bs.pending.blockList) > 0 && bs.pendingSize >= bs.cfg.MaxBytes
+	bs.lk.Unlock()
+
+	if err != nil {
+		return xerrors.Errorf("committing %d blocks: %w", len(batch.blockList), err)
+	}
+	if retry {
+		bs.signal()
+	}
+	return nil
+}
+
+func (bs *GroupCommitBlockstore) hasPending() bool {
+	bs.lk.Lock()
+	defer bs.lk.Unlock()
+
+	return len(bs.pending.blockList) > 0 || len(bs.committing.blockList) > 0
+}
+
+func (bs *GroupCommitBlockstore) signal() {
+	select {
+	case bs.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// Flush commits the pending writes, or waits for the commit of the writes
+// already pending.
+func (bs *GroupCommitBlockstore) Flush(ctx context.Context) error {
+	bs.lk.Lock()
+	target := bs.pendingSeq
+	if len(bs.pending.blockList) == 0 {
+		// only the running or the failed commit, if any, is left
+		target = bs.committingSeq
+	}
+	closed := bs.closed
+	bs.lk.Unlock()
+
+	if closed {
+		// the blockstore is written through after shutdown
+		return bs.commit(ctx)
+	}
+
+	for {
+		bs.lk.Lock()
+		if bs.committed >= target {
+			bs.lk.Unlock()
+			return nil
+		}
+		err, ch := bs.commitErr, bs.committedCh
+		bs.lk.Unlock()
+
+		if err != nil {
+			return err
+		}
+		bs.signal()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Shutdown stops the commits, committing the pending writes. The blockstore is
+// written through afterwards.
+func (bs *GroupCommitBlockstore) Shutdown(ctx context.Context) error {
+	bs.shutdown()
+	select {
+	case <-bs.doneCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	bs.lk.Lock()
+	defer bs.lk.Unlock()
+
+	return bs.commitErr
+}
+
+func (bs *GroupCommitBlockstore) Put(ctx context.Context, blk block.Block) error {
+	return bs.PutMany(ctx, []block.Block{blk})
+}
+
+func (bs *GroupCommitBlockstore) PutMany(ctx context.Context, blks []block.Block) error {
+	bs.lk.Lock()
+	if bs.closed {
+		bs.lk.Unlock()
+		return bs.backing.PutMany(ctx, blks)
+	}
+
+	for _, blk := range blks {
+		if _, ok := bs.pending.blockMap[blk.Cid()]; ok {
+			continue
+		}
+		bs.pending.blockList = append(bs.pending.blockList, blk)
+		bs.pending.blockMap[blk.Cid()] = blk
+		bs.pendingSize += len(blk.RawData())
+	}
+
+	full := bs.cfg.MaxBytes > 0 && bs.pendingSize >= bs.cfg.MaxBytes
+	for full && bs.pendingSize >= 2*bs.cfg.MaxBytes && !bs.closed && bs.commitErr == nil {
+		// the running commit is behind, wait for it
+		ch := bs.committedCh
+		bs.lk.Unlock()
+		bs.signal()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		bs.lk.Lock()
+	}
+	bs.lk.Unlock()
+
+	if full {
+		bs.signal()
+	}
+	return nil
+}
+
+// buffered returns the block c if it is pending or being committed.
+func (bs *GroupCommitBlockstore) buffered(c cid.Cid) (block.Block, bool) {
+	bs.lk.Lock()
+	defer bs.lk.Unlock()
+
+	if blk, ok := bs.pending.blockMap[c]; ok {
+		return blk, true
+	}
+	blk, ok := bs.committing.blockMap[c]
+	return blk, ok
+}
+
+func (bs *GroupCommitBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	if _, ok := bs.buffered(c); ok {
+		return true, nil
+	}
+	return bs.backing.Has(ctx, c)
+}
+
+func (bs *GroupCommitBlockstore) Get(ctx context.Context, c cid.Cid) (block.Block, error) {
+	if blk, ok := bs.buffered(c); ok {
+		return blk, nil
+	}
+	return bs.backing.Get(ctx, c)
+}
+
+func (bs *GroupCommitBlockstore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	if blk, ok := bs.buffered(c); ok {
+		return callback(blk.RawData())
+	}
+	return bs.backing.View(ctx, c, callback)
+}
+
+func (bs *GroupCommitBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	if blk, ok := bs.buffered(c); ok {
+		return len(blk.RawData()), nil
+	}
+	return bs.backing.GetSize(ctx, c)
+}
+
+func (bs *GroupCommitBlockstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	if err := bs.Flush(ctx); err != nil {
+		return err
+	}
+	return bs.backing.DeleteBlock(ctx, c)
+}
+
+func (bs *GroupCommitBlockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	if err := bs.Flush(ctx); err != nil {
+		return err
+	}
+	return bs.backing.DeleteMany(ctx, cids)
+}
+
+func (bs *GroupCommitBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	if err := bs.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return bs.backing.AllKeysChan(ctx)
+}
+
+func (bs *GroupCommitBlockstore) ForEachKey(f func(cid.Cid) error) error {
+	iterBstore, ok := bs.backing.(BlockstoreIterator)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore (type %T) doesn't support fast iteration", bs.backing)
+	}
+	if err := bs.Flush(context.TODO()); err != nil {
+		return err
+	}
+	return iterBstore.ForEachKey(f)
+}
+
+func (bs *GroupCommitBlockstore) HashOnRead(enabled bool) {
+	bs.backing.HashOnRead(enabled)
+}
@@ -0,0 +1,101 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBlockstore counts the batches put into it.
+type countingBlockstore struct {
+	Blockstore
+
+	lk      sync.Mutex
+	batches int
+}
+
+func (c *countingBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	c.lk.Lock()
+	c.batches++
+	c.lk.Unlock()
+	return c.Blockstore.PutMany(ctx, blks)
+}
+
+func (c *countingBlockstore) count() int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.batches
+}
+
+func TestGroupCommit(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingBlockstore{Blockstore: NewMemory()}
+	gbs := NewGroupCommit(ctx, backing, GroupCommitConfig{MaxBytes: 1 << 20})
+
+	// writes are pending, and read from the pending batch
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		blk := mkCacheBlock(i, 100)
+		require.NoError(t, gbs.Put(ctx, blk))
+		blks = append(blks, blk)
+	}
+	has, err := backing.Has(ctx, blks[0].Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+	blk, err := gbs.Get(ctx, blks[0].Cid())
+	require.NoError(t, err)
+	require.Equal(t, blks[0].RawData(), blk.RawData())
+
+	// concurrent flushes share the commits
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, gbs.Flush(ctx))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, backing.count())
+	for _, blk := range blks {
+		has, err := backing.Has(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	// nothing is committed when nothing is pending
+	require.NoError(t, gbs.Flush(ctx))
+	require.Equal(t, 1, backing.count())
+
+	// the pending writes are committed on shutdown, then written through
+	require.NoError(t, gbs.Put(ctx, mkCacheBlock(10, 100)))
+	require.NoError(t, gbs.Shutdown(ctx))
+	require.Equal(t, 2, backing.count())
+	require.NoError(t, gbs.Put(ctx, mkCacheBlock(11, 100)))
+	require.Equal(t, 3, backing.count())
+}
+
+func TestGroupCommitTriggers(t *testing.T) {
+	ctx := context.Background()
+
+	// commits once MaxBytes are pending
+	backing := &countingBlockstore{Blockstore: NewMemory()}
+	gbs := NewGroupCommit(ctx, backing, GroupCommitConfig{MaxBytes: 500})
+	defer gbs.Shutdown(ctx) //nolint:errcheck
+	for i := 0; i < 5; i++ {
+		require.NoError(t, gbs.Put(ctx, mkCacheBlock(i, 100)))
+	}
+	require.Eventually(t, func() bool { return backing.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// and every FlushInterval
+	backing = &countingBlockstore{Blockstore: NewMemory()}
+	gbs = NewGroupCommit(ctx, backing, GroupCommitConfig{FlushInterval: 10 * time.Millisecond, MaxBytes: 1 << 20})
+	defer gbs.Shutdown(ctx) //nolint:errcheck
+	require.NoError(t, gbs.Put(ctx, mkCacheBlock(0, 100)))
+	require.Eventually(t, func() bool { return backing.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -11,6 +11,7 @@ import (
 )
 
 var _ Blockstore = (*idstore)(nil)
+var _ BatchViewer = (*idstore)(nil)
 
 type idstore struct {
 	bs Blockstore
@@ -89,6 +90,32 @@ func (b *idstore) View(ctx context.Context, cid cid.Cid, cb func([]byte) error)
 	return b.bs.View(ctx, cid, cb)
 }
 
+func (b *idstore) ViewMany(ctx context.Context, cids []cid.Cid, cb func(int, []byte) error) error {
+	// inlined blocks are handed out as they come, the others are viewed in
+	// a batch
+	stored := make([]cid.Cid, 0, len(cids))
+	idx := make([]int, 0, len(cids))
+	for i, c := range cids {
+		inline, data, err := decodeCid(c)
+		if err != nil {
+			return xerrors.Errorf("error decoding Cid: %w", err)
+		}
+
+		if inline {
+			if err := cb(i, data); err != nil {
+				return err
+			}
+			continue
+		}
+		stored = append(stored, c)
+		idx = append(idx, i)
+	}
+
+	return ViewMany(ctx, b.bs, stored, func(i int, data []byte) error {
+		return cb(idx[i], data)
+	})
+}
+
 func (b *idstore) Put(ctx context.Context, blk blocks.Block) error {
 	inline, _, err := decodeCid(blk.Cid())
 	if err != nil {
@@ -9,9 +9,8 @@ import (
 )
 
 //
-// Currently unused, but kept in repo in case we introduce one of the candidate
-// cache implementations (Freecache, Ristretto), both of which report these
-// metrics.
+// These metrics are reported by CachedBlockstore; they also match those of the
+// candidate cache implementations (Freecache, Ristretto).
 //
 
 // CacheMetricsEmitInterval is the interval at which metrics are emitted onto
@@ -0,0 +1,176 @@
+package blockstore
+
+import (
+	"context"
+	"sync/atomic"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"golang.org/x/xerrors"
+)
+
+// ReplicatedBlockstore is a blockstore spreading its reads over a primary
+// blockstore and read-only replicas of it, such as mirrors of a snapshot on
+// network storage, to increase read throughput.
+//
+//   - Reads go to the primary and the replicas in turn. A read falls back to
+//     the primary when the replica doesn't have the block, as the replicas
+//     lag behind the primary, or fails.
+//   - Writes (puts and deletes) only go to the primary.
+type ReplicatedBlockstore struct {
+	Primary  Blockstore
+	Replicas []Blockstore
+
+	next uint32
+}
+
+var _ Blockstore = (*ReplicatedBlockstore)(nil)
+var _ BatchViewer = (*ReplicatedBlockstore)(nil)
+var _ BlockstoreIterator = (*ReplicatedBlockstore)(nil)
+var _ BlockstoreGC = (*ReplicatedBlockstore)(nil)
+var _ BlockstoreSize = (*ReplicatedBlockstore)(nil)
+
+// NewReplicated returns a blockstore reading from primary and replicas, and
+// writing to primary.
+func NewReplicated(primary Blockstore, replicas ...Blockstore) *ReplicatedBlockstore {
+	return &ReplicatedBlockstore{Primary: primary, Replicas: replicas}
+}
+
+// replica returns the replica serving the next read, nil for the primary.
+func (r *ReplicatedBlockstore) replica() Blockstore {
+	if len(r.Replicas) == 0 {
+		return nil
+	}
+	i := int(atomic.AddUint32(&r.next, 1) % uint32(len(r.Replicas)+1))
+	if i == len(r.Replicas) {
+		return nil
+	}
+	return r.Replicas[i]
+}
+
+// fallback returns whether a read failing on a replica with err is retried on
+// the primary.
+func (r *ReplicatedBlockstore) fallback(err error) bool {
+	if err != nil && !ipld.IsNotFound(err) {
+		log.Warnw("reading from blockstore replica failed, reading from the primary", "error", err)
+	}
+	return err != nil
+}
+
+func (r *ReplicatedBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	if rep := r.replica(); rep != nil {
+		has, err := rep.Has(ctx, c)
+		if has && err == nil {
+			return true, nil
+		}
+		r.fallback(err)
+	}
+	return r.Primary.Has(ctx, c)
+}
+
+func (r *ReplicatedBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	if rep := r.replica(); rep != nil {
+		blk, err := rep.Get(ctx, c)
+		if !r.fallback(err) {
+			return blk, nil
+		}
+	}
+	return r.Primary.Get(ctx, c)
+}
+
+func (r *ReplicatedBlockstore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	if rep := r.replica(); rep != nil {
+		var cbErr error
+		err := rep.View(ctx, c, func(data []byte) error {
+			cbErr = callback(data)
+			return cbErr
+		})
+		if cbErr != nil || !r.fallback(err) {
+			return err
+		}
+	}
+	return r.Primary.View(ctx, c, callback)
+}
+
+// ViewMany views the blocks in the replica serving the read, then in the
+// primary if the replica misses some of them; the blocks viewed before are
+// viewed again.
+func (r *ReplicatedBlockstore) ViewMany(ctx context.Context, cids []cid.Cid, callback func(int, []byte) error) error {
+	if rep := r.replica(); rep != nil {
+		var cbErr error
+		err := ViewMany(ctx, rep, cids, func(i int, data []byte) error {
+			cbErr = callback(i, data)
+			return cbErr
+		})
+		if cbErr != nil || !r.fallback(err) {
+			return err
+		}
+	}
+	return ViewMany(ctx, r.Primary, cids, callback)
+}
+
+func (r *ReplicatedBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	if rep := r.replica(); rep != nil {
+		size, err := rep.GetSize(ctx, c)
+		if !r.fallback(err) {
+			return size, nil
+		}
+	}
+	return r.Primary.GetSize(ctx, c)
+}
+
+func (r *ReplicatedBlockstore) Put(ctx context.Context, blk blocks.Block) error {
+	return r.Primary.Put(ctx, blk)
+}
+
+func (r *ReplicatedBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return r.Primary.PutMany(ctx, blks)
+}
+
+func (r *ReplicatedBlockstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	return r.Primary.DeleteBlock(ctx, c)
+}
+
+func (r *ReplicatedBlockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	return r.Primary.DeleteMany(ctx, cids)
+}
+
+// AllKeysChan returns the keys of the primary.
+func (r *ReplicatedBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return r.Primary.AllKeysChan(ctx)
+}
+
+func (r *ReplicatedBlockstore) HashOnRead(enabled bool) {
+	r.Primary.HashOnRead(enabled)
+	for _, rep := range r.Replicas {
+		rep.HashOnRead(enabled)
+	}
+}
+
+// ForEachKey iterates over the keys of the primary.
+func (r *ReplicatedBlockstore) ForEachKey(f func(cid.Cid) error) error {
+	iterBstore, ok := r.Primary.(BlockstoreIterator)
+	if !ok {
+		return xerrors.Errorf("primary blockstore (type %T) doesn't support fast iteration", r.Primary)
+	}
+	return iterBstore.ForEachKey(f)
+}
+
+// CollectGarbage collects the garbage of the primary.
+func (r *ReplicatedBlockstore) CollectGarbage(opts ...BlockstoreGCOption) error {
+	gcBstore, ok := r.Primary.(BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("primary blockstore (type %T) doesn't support garbage collection", r.Primary)
+	}
+	return gcBstore.CollectGarbage(opts...)
+}
+
+// Size returns the size of the primary.
+func (r *ReplicatedBlockstore) Size() (int64, error) {
+	sizeBstore, ok := r.Primary.(BlockstoreSize)
+	if !ok {
+		return 0, xerrors.Errorf("primary blockstore (type %T) doesn't report its size", r.Primary)
+	}
+	return sizeBstore.Size()
+}
@@ -0,0 +1,78 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+// failingBlockstore fails every read.
+type failingBlockstore struct {
+	Blockstore
+}
+
+func (failingBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	return nil, xerrors.New("replica unavailable")
+}
+
+func TestReplicatedBlockstore(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemory()
+	replica := NewMemory()
+	require.NoError(t, primary.PutMany(ctx, []blocks.Block{b1, b2}))
+	// the replica lags behind the primary
+	require.NoError(t, replica.Put(ctx, b1))
+
+	rb := NewReplicated(primary, replica)
+
+	// reads alternate between the replica and the primary, and fall back to the
+	// primary for the blocks missing from the replica
+	for i := 0; i < 4; i++ {
+		for _, b := range []blocks.Block{b1, b2} {
+			v, err := rb.Get(ctx, b.Cid())
+			require.NoError(t, err)
+			require.Equal(t, b.RawData(), v.RawData())
+
+			has, err := rb.Has(ctx, b.Cid())
+			require.NoError(t, err)
+			require.True(t, has)
+
+			size, err := rb.GetSize(ctx, b.Cid())
+			require.NoError(t, err)
+			require.Equal(t, len(b.RawData()), size)
+		}
+		_, err := rb.Get(ctx, b3.Cid())
+		require.True(t, ipld.IsNotFound(err))
+	}
+
+	got := make([][]byte, 2)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, rb.ViewMany(ctx, []cid.Cid{b1.Cid(), b2.Cid()}, func(i int, data []byte) error {
+			got[i] = append([]byte{}, data...)
+			return nil
+		}))
+		require.Equal(t, [][]byte{b1.RawData(), b2.RawData()}, got)
+	}
+
+	// writes only go to the primary
+	require.NoError(t, rb.Put(ctx, b3))
+	has, _ := replica.Has(ctx, b3.Cid())
+	require.False(t, has)
+	require.NoError(t, rb.DeleteBlock(ctx, b1.Cid()))
+	has, _ = replica.Has(ctx, b1.Cid())
+	require.True(t, has)
+
+	// failing replicas fall back to the primary too
+	rb = NewReplicated(primary, failingBlockstore{replica})
+	for i := 0; i < 2; i++ {
+		v, err := rb.Get(ctx, b2.Cid())
+		require.NoError(t, err)
+		require.Equal(t, b2.RawData(), v.RawData())
+	}
+}
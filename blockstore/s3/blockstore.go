@@ -0,0 +1,379 @@
+// Package s3bs implements a blockstore over an S3 compatible object store,
+// storing each block as an object named after its CID, with a read-through
+// cache of the recently used blocks in memory. It registers itself as the "s3"
+// blockstore backend.
+package s3bs
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/lib/s3upload"
+)
+
+var log = logging.Logger("s3bs")
+
+const (
+	// DefaultCacheSize is the number of blocks cached when Options.CacheSize
+	// is not set.
+	DefaultCacheSize = 64 << 10
+	// DefaultConcurrency is the number of requests made in parallel by batch
+	// operations when Options.Concurrency is not set.
+	DefaultConcurrency = 16
+)
+
+func init() {
+	blockstore.RegisterBackend("s3", func(ctx context.Context, _ string, params map[string]string) (blockstore.Blockstore, error) {
+		opts, err := OptionsFromParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return Open(opts)
+	})
+}
+
+type Options struct {
+	// Store is the object store and the bucket the blocks are stored in; its
+	// Key is the prefix of the names of the objects.
+	Store s3upload.Config
+
+	// CacheSize is the number of blocks kept in the read-through cache; a
+	// negative value disables the cache.
+	CacheSize int
+	// Concurrency is the number of requests made in parallel by PutMany,
+	// DeleteMany and ViewMany.
+	Concurrency int
+}
+
+// OptionsFromParams returns the options of the params of the backend config:
+//
+//   - url: the s3://bucket/prefix or gs://bucket/prefix url of the blocks.
+//   - endpoint: the S3 compatible endpoint, overriding the one of the url.
+//   - cache-size: the number of blocks cached in memory.
+//   - concurrency: the number of requests made in parallel by batches.
+//
+// The credentials are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+// environment variables, and the region from AWS_REGION.
+func OptionsFromParams(params map[string]string) (Options, error) {
+	if params["url"] == "" {
+		return Options{}, xerrors.Errorf("the s3 blockstore backend needs a url param")
+	}
+
+	store, err := s3upload.ConfigFromURL(params["url"], params["endpoint"])
+	if err != nil {
+		return Options{}, err
+	}
+
+	opts := Options{Store: store}
+	for name, v := range map[string]*int{"cache-size": &opts.CacheSize, "concurrency": &opts.Concurrency} {
+		if params[name] == "" {
+			continue
+		}
+		if *v, err = strconv.Atoi(params[name]); err != nil {
+			return Options{}, xerrors.Errorf("parsing %s param: %w", name, err)
+		}
+	}
+	return opts, nil
+}
+
+// Blockstore is a blockstore over an S3 compatible object store.
+type Blockstore struct {
+	store  s3upload.Config
+	prefix string
+	cache  *lru.ARCCache
+	conc   int
+
+	rehash int32
+}
+
+var _ blockstore.Blockstore = (*Blockstore)(nil)
+var _ blockstore.BatchViewer = (*Blockstore)(nil)
+
+// Open returns a blockstore over the bucket of opts. The bucket is not
+// accessed until the blockstore is used.
+func Open(opts Options) (*Blockstore, error) {
+	if opts.Store.Bucket == "" {
+		return nil, xerrors.Errorf("no bucket to store the blocks in")
+	}
+
+	b := &Blockstore{
+		store:  opts.Store,
+		prefix: strings.Trim(opts.Store.Key, "/"),
+		conc:   opts.Concurrency,
+	}
+	if b.prefix != "" {
+		b.prefix += "/"
+	}
+	if b.conc < 1 {
+		b.conc = DefaultConcurrency
+	}
+
+	size := opts.CacheSize
+	if size == 0 {
+		size = DefaultCacheSize
+	}
+	if size > 0 {
+		cache, err := lru.NewARC(size)
+		if err != nil {
+			return nil, xerrors.Errorf("creating block cache: %w", err)
+		}
+		b.cache = cache
+	}
+
+	log.Infow("opened s3 blockstore", "endpoint", b.store.Endpoint, "bucket", b.store.Bucket, "prefix", b.prefix)
+	return b, nil
+}
+
+func (b *Blockstore) do(ctx context.Context, method, key string, q url.Values, body []byte, respHdr http.Header) ([]byte, error) {
+	cfg := b.store
+	cfg.Key = key
+	return s3upload.Do(ctx, cfg, method, q, body, respHdr)
+}
+
+func (b *Blockstore) cached(c cid.Cid) ([]byte, bool) {
+	if b.cache == nil {
+		return nil, false
+	}
+	v, ok := b.cache.Get(c)
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
+
+func (b *Blockstore) addCache(c cid.Cid, data []byte) {
+	if b.cache != nil {
+		b.cache.Add(c, data)
+	}
+}
+
+func (b *Blockstore) removeCache(c cid.Cid) {
+	if b.cache != nil {
+		b.cache.Remove(c)
+	}
+}
+
+func (b *Blockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	if _, ok := b.cached(c); ok {
+		return true, nil
+	}
+
+	_, err := b.do(ctx, http.MethodHead, b.prefix+c.String(), nil, nil, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case s3upload.IsNotFound(err):
+		return false, nil
+	default:
+		return false, xerrors.Errorf("checking for block %s: %w", c, err)
+	}
+}
+
+func (b *Blockstore) getData(ctx context.Context, c cid.Cid) ([]byte, error) {
+	if data, ok := b.cached(c); ok {
+		return data, nil
+	}
+
+	data, err := b.do(ctx, http.MethodGet, b.prefix+c.String(), nil, nil, nil)
+	if err != nil {
+		if s3upload.IsNotFound(err) {
+			return nil, ipld.ErrNotFound{Cid: c}
+		}
+		return nil, xerrors.Errorf("getting block %s: %w", c, err)
+	}
+
+	if atomic.LoadInt32(&b.rehash) == 1 {
+		rc, err := c.Prefix().Sum(data)
+		if err != nil {
+			return nil, xerrors.Errorf("hashing block %s: %w", c, err)
+		}
+		if !rc.Equals(c) {
+			return nil, blocks.ErrWrongHash
+		}
+	}
+
+	b.addCache(c, data)
+	return data, nil
+}
+
+func (b *Blockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	data, err := b.getData(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return blocks.NewBlockWithCid(data, c)
+}
+
+func (b *Blockstore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	data, err := b.getData(ctx, c)
+	if err != nil {
+		return err
+	}
+	return callback(data)
+}
+
+// ViewMany gets the blocks with Concurrency requests in parallel; callback is
+// called from a single goroutine at a time.
+func (b *Blockstore) ViewMany(ctx context.Context, cids []cid.Cid, callback func(int, []byte) error) error {
+	datas := make([][]byte, len(cids))
+
+	grp, gctx := errgroup.WithContext(ctx)
+	grp.SetLimit(b.conc)
+	for i, c := range cids {
+		i, c := i, c
+		grp.Go(func() error {
+			data, err := b.getData(gctx, c)
+			datas[i] = data
+			return err
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return err
+	}
+
+	for i, data := range datas {
+		if err := callback(i, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Blockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	if data, ok := b.cached(c); ok {
+		return len(data), nil
+	}
+
+	hdr := make(http.Header)
+	if _, err := b.do(ctx, http.MethodHead, b.prefix+c.String(), nil, nil, hdr); err != nil {
+		if s3upload.IsNotFound(err) {
+			return -1, ipld.ErrNotFound{Cid: c}
+		}
+		return -1, xerrors.Errorf("getting size of block %s: %w", c, err)
+	}
+
+	size, err := strconv.Atoi(hdr.Get("Content-Length"))
+	if err != nil {
+		return -1, xerrors.Errorf("parsing size of block %s: %w", c, err)
+	}
+	return size, nil
+}
+
+func (b *Blockstore) Put(ctx context.Context, blk blocks.Block) error {
+	if _, err := b.do(ctx, http.MethodPut, b.prefix+blk.Cid().String(), nil, blk.RawData(), nil); err != nil {
+		return xerrors.Errorf("putting block %s: %w", blk.Cid(), err)
+	}
+
+	b.addCache(blk.Cid(), blk.RawData())
+	return nil
+}
+
+// PutMany puts the blocks with Concurrency requests in parallel.
+func (b *Blockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	grp, gctx := errgroup.WithContext(ctx)
+	grp.SetLimit(b.conc)
+	for _, blk := range blks {
+		blk := blk
+		grp.Go(func() error {
+			return b.Put(gctx, blk)
+		})
+	}
+	return grp.Wait()
+}
+
+// DeleteBlock deletes the block; deleting a block that doesn't exist succeeds.
+func (b *Blockstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	b.removeCache(c)
+	if _, err := b.do(ctx, http.MethodDelete, b.prefix+c.String(), nil, nil, nil); err != nil {
+		return xerrors.Errorf("deleting block %s: %w", c, err)
+	}
+	return nil
+}
+
+// DeleteMany deletes the blocks with Concurrency requests in parallel.
+func (b *Blockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	grp, gctx := errgroup.WithContext(ctx)
+	grp.SetLimit(b.conc)
+	for _, c := range cids {
+		c := c
+		grp.Go(func() error {
+			return b.DeleteBlock(gctx, c)
+		})
+	}
+	return grp.Wait()
+}
+
+// AllKeysChan lists the objects under the prefix of the blockstore, skipping
+// those not named after a CID.
+func (b *Blockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	ch := make(chan cid.Cid)
+	go func() {
+		defer close(ch)
+
+		var token string
+		for {
+			q := url.Values{"list-type": {"2"}, "prefix": {b.prefix}}
+			if token != "" {
+				q.Set("continuation-token", token)
+			}
+
+			resp, err := b.do(ctx, http.MethodGet, "", q, nil, nil)
+			if err != nil {
+				log.Errorw("listing blocks", "error", err)
+				return
+			}
+
+			var res struct {
+				Contents []struct {
+					Key string
+				}
+				IsTruncated           bool
+				NextContinuationToken string
+			}
+			if err := xml.Unmarshal(resp, &res); err != nil {
+				log.Errorw("decoding block listing", "error", err)
+				return
+			}
+
+			for _, obj := range res.Contents {
+				c, err := cid.Decode(strings.TrimPrefix(obj.Key, b.prefix))
+				if err != nil {
+					continue
+				}
+				select {
+				case ch <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if !res.IsTruncated || res.NextContinuationToken == "" {
+				return
+			}
+			token = res.NextContinuationToken
+		}
+	}()
+	return ch, nil
+}
+
+func (b *Blockstore) HashOnRead(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&b.rehash, 1)
+	} else {
+		atomic.StoreInt32(&b.rehash, 0)
+	}
+}
@@ -0,0 +1,184 @@
+// stm: #unit
+package s3bs
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+// fakeS3 is an in-memory object store serving the object requests and the
+// listings of a single bucket.
+type fakeS3 struct {
+	lk      sync.Mutex
+	objects map[string][]byte
+	gets    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") || !strings.HasPrefix(r.URL.Path, "/bucket/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+
+	if key == "" && r.Method == http.MethodGet {
+		f.list(w, r)
+		return
+	}
+
+	data, ok := f.objects[key]
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			f.gets++
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		_, _ = w.Write(data)
+	}
+}
+
+// list lists the objects under the prefix, two at a time.
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, q.Get("prefix")) && k > q.Get("continuation-token") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct{ Key string }
+	var res struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string
+	}
+	for _, k := range keys {
+		if len(res.Contents) == 2 {
+			res.IsTruncated = true
+			res.NextContinuationToken = res.Contents[1].Key
+			break
+		}
+		res.Contents = append(res.Contents, content{Key: k})
+	}
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func TestS3Blockstore(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeS3{objects: map[string][]byte{"blocks/not-a-cid": {1}, "other/x": {2}}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	bs, err := blockstore.OpenBackend(ctx, "s3", t.TempDir(), map[string]string{
+		"url":        "s3://bucket/blocks/",
+		"endpoint":   srv.URL,
+		"cache-size": "2",
+	})
+	require.NoError(t, err)
+
+	var blks []blocks.Block
+	for i := 0; i < 5; i++ {
+		blks = append(blks, blocks.NewBlock([]byte("block "+strconv.Itoa(i))))
+	}
+	require.NoError(t, bs.Put(ctx, blks[0]))
+	require.NoError(t, bs.PutMany(ctx, blks[1:4]))
+	require.Len(t, f.objects, 6)
+	require.Contains(t, f.objects, "blocks/"+blks[0].Cid().String())
+
+	// reads of recently used blocks are served from the cache
+	gets := f.gets
+	v, err := bs.Get(ctx, blks[3].Cid())
+	require.NoError(t, err)
+	require.Equal(t, blks[3].RawData(), v.RawData())
+	require.Equal(t, gets, f.gets)
+
+	v, err = bs.Get(ctx, blks[0].Cid())
+	require.NoError(t, err)
+	require.Equal(t, blks[0].RawData(), v.RawData())
+	require.Equal(t, gets+1, f.gets)
+	_, err = bs.Get(ctx, blks[0].Cid())
+	require.NoError(t, err)
+	require.Equal(t, gets+1, f.gets)
+
+	has, err := bs.Has(ctx, blks[1].Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = bs.Has(ctx, blks[4].Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+	_, err = bs.Get(ctx, blks[4].Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	size, err := bs.GetSize(ctx, blks[2].Cid())
+	require.NoError(t, err)
+	require.Equal(t, len(blks[2].RawData()), size)
+
+	got := make([][]byte, 2)
+	require.NoError(t, blockstore.ViewMany(ctx, bs, []cid.Cid{blks[1].Cid(), blks[2].Cid()}, func(i int, data []byte) error {
+		got[i] = data
+		return nil
+	}))
+	require.Equal(t, [][]byte{blks[1].RawData(), blks[2].RawData()}, got)
+
+	// listing goes through the pages and skips the objects not named after a cid
+	ch, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	var keys []cid.Cid
+	for c := range ch {
+		keys = append(keys, c)
+	}
+	require.ElementsMatch(t, []cid.Cid{blks[0].Cid(), blks[1].Cid(), blks[2].Cid(), blks[3].Cid()}, keys)
+
+	require.NoError(t, bs.DeleteMany(ctx, []cid.Cid{blks[0].Cid(), blks[4].Cid()}))
+	has, err = bs.Has(ctx, blks[0].Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestS3BlockstoreParams(t *testing.T) {
+	_, err := OptionsFromParams(map[string]string{})
+	require.Error(t, err)
+
+	_, err = OptionsFromParams(map[string]string{"url": "s3://bucket", "cache-size": "many"})
+	require.Error(t, err)
+
+	opts, err := OptionsFromParams(map[string]string{"url": "gs://bucket/chain", "concurrency": "4"})
+	require.NoError(t, err)
+	require.Equal(t, "bucket", opts.Store.Bucket)
+	require.Equal(t, "chain", opts.Store.Key)
+	require.Equal(t, 4, opts.Concurrency)
+
+	_, err = blockstore.OpenBackend(context.Background(), "nope", "", nil)
+	require.Error(t, err)
+}
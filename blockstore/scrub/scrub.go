@@ -0,0 +1,331 @@
+// Package scrub verifies the blocks stored by the node in the background, so
+// that the blocks corrupted on disk are caught before they are needed.
+package scrub
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	exchange "github.com/ipfs/go-ipfs-exchange-interface"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log/v2"
+	mh "github.com/multiformats/go-multihash"
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("scrub")
+
+// RefetchTimeout is how long the refetch of a corrupted block may take.
+var RefetchTimeout = time.Minute
+
+// maxAlertCids is the number of corrupted blocks listed in alerts.
+const maxAlertCids = 100
+
+// Config configures a Scrubber.
+type Config struct {
+	// Interval is the time between scrub passes.
+	Interval time.Duration
+	// Fraction is the fraction of the stored blocks, sampled at random, that
+	// every pass re-hashes.
+	Fraction float64
+}
+
+// Store is a blockstore verified by a Scrubber. It must be a
+// blockstore.BlockstoreIterator.
+type Store struct {
+	Name string
+	blockstore.Blockstore
+}
+
+// Pass are the results of a scrub pass.
+type Pass struct {
+	Started, Finished time.Time
+	// Scrubbed is the number of blocks re-hashed.
+	Scrubbed int
+	// Corrupt is the number of blocks found whose data doesn't match their
+	// cid, not counting those already found by earlier passes.
+	Corrupt int
+	// Repaired is the number of corrupted blocks, from this pass or earlier
+	// ones, replaced with a copy fetched from the network.
+	Repaired int
+	// Unrepaired is the number of corrupted blocks left.
+	Unrepaired int
+}
+
+// CorruptionAlert is the message of the alerts raised for corrupted blocks.
+type CorruptionAlert struct {
+	// Corrupt are the corrupted blocks left, by store, listing at most 100 of
+	// them.
+	Corrupt map[string][]cid.Cid
+	// Unrepaired is the number of corrupted blocks left.
+	Unrepaired int
+}
+
+// Scrubber re-hashes a sample of the blocks of its stores every
+// Config.Interval, and raises an alert for the blocks that don't match their
+// cid. With a fetcher, the corrupted blocks are fetched from the network and
+// replaced; the alert is resolved once they all are.
+type Scrubber struct {
+	stores  []Store
+	cfg     Config
+	fetcher exchange.Fetcher
+	al      *alerting.Alerting
+	alert   alerting.AlertType
+
+	lk sync.Mutex
+	// corrupt are the corrupted blocks not repaired yet, with their store
+	corrupt map[cid.Cid]Store
+	last    *Pass
+}
+
+// New returns a scrubber verifying the blocks of stores. fetcher may be nil to
+// not refetch the corrupted blocks, and al nil to only log them.
+func New(stores []Store, cfg Config, fetcher exchange.Fetcher, al *alerting.Alerting) (*Scrubber, error) {
+	if cfg.Interval <= 0 {
+		return nil, xerrors.Errorf("scrub interval must be positive")
+	}
+	if cfg.Fraction <= 0 || cfg.Fraction > 1 {
+		return nil, xerrors.Errorf("scrub fraction must be in (0, 1], got %f", cfg.Fraction)
+	}
+	for _, s := range stores {
+		if _, ok := s.Blockstore.(blockstore.BlockstoreIterator); !ok {
+			return nil, xerrors.Errorf("blockstore %s (type %T) doesn't support fast iteration", s.Name, s.Blockstore)
+		}
+	}
+
+	s := &Scrubber{
+		stores:  stores,
+		cfg:     cfg,
+		fetcher: fetcher,
+		al:      al,
+		corrupt: map[cid.Cid]Store{},
+	}
+	if al != nil {
+		s.alert = al.AddAlertType("blockstore", "corruption")
+	}
+	return s, nil
+}
+
+// Run runs a pass every Config.Interval until ctx is canceled.
+func (s *Scrubber) Run(ctx context.Context) {
+	ticker := build.Clock.Ticker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.Scrub(ctx); err != nil && ctx.Err() == nil {
+				log.Errorw("scrubbing blockstore", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Last returns the results of the last pass, if any.
+func (s *Scrubber) Last() *Pass {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.last == nil {
+		return nil
+	}
+	last := *s.last
+	return &last
+}
+
+// Scrub runs a pass: it re-hashes a sample of the blocks of every store, then
+// tries to repair the corrupted blocks.
+func (s *Scrubber) Scrub(ctx context.Context) (Pass, error) {
+	pass := Pass{Started: build.Clock.Now()}
+
+	for _, st := range s.stores {
+		scrubbed, corrupt, err := s.scrubStore(ctx, st)
+		pass.Scrubbed += scrubbed
+		pass.Corrupt += corrupt
+		if err != nil {
+			return pass, xerrors.Errorf("scrubbing blockstore %s: %w", st.Name, err)
+		}
+	}
+
+	pass.Repaired = s.repair(ctx)
+
+	s.lk.Lock()
+	pass.Unrepaired = len(s.corrupt)
+	pass.Finished = build.Clock.Now()
+	s.last = &pass
+	s.lk.Unlock()
+
+	stats.Record(ctx,
+		metrics.BlockstoreScrubbed.M(int64(pass.Scrubbed)),
+		metrics.BlockstoreScrubCorrupt.M(int64(pass.Corrupt)),
+	)
+	log.Infow("scrubbed blockstore", "scrubbed", pass.Scrubbed, "corrupt", pass.Corrupt, "repaired", pass.Repaired, "unrepaired", pass.Unrepaired, "took", pass.Finished.Sub(pass.Started))
+
+	s.updateAlert(pass)
+	return pass, nil
+}
+
+// scrubStore re-hashes the sampled blocks of st. The keys are sampled first,
+// as the iteration of the blockstore can't read it.
+func (s *Scrubber) scrubStore(ctx context.Context, st Store) (scrubbed, corrupt int, err error) {
+	var sample []cid.Cid
+	err = st.Blockstore.(blockstore.BlockstoreIterator).ForEachKey(func(c cid.Cid) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.Prefix().MhType != mh.IDENTITY && rand.Float64() < s.cfg.Fraction {
+			sample = append(sample, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, xerrors.Errorf("sampling blocks: %w", err)
+	}
+
+	for _, c := range sample {
+		if err := ctx.Err(); err != nil {
+			return scrubbed, corrupt, err
+		}
+
+		ok, err := verify(ctx, st.Blockstore, c)
+		switch {
+		case ipld.IsNotFound(err):
+			// deleted since it was sampled
+			continue
+		case err != nil:
+			log.Warnw("reading block to scrub", "store", st.Name, "cid", c, "error", err)
+			continue
+		}
+
+		scrubbed++
+		if ok {
+			continue
+		}
+		s.lk.Lock()
+		_, known := s.corrupt[c]
+		s.corrupt[c] = st
+		s.lk.Unlock()
+		if !known {
+			log.Errorw("corrupted block", "store", st.Name, "cid", c)
+			corrupt++
+		}
+	}
+	return scrubbed, corrupt, nil
+}
+
+// verify returns whether the data of the block c in bs matches c.
+func verify(ctx context.Context, bs blockstore.Blockstore, c cid.Cid) (bool, error) {
+	var ok bool
+	err := bs.View(ctx, c, func(data []byte) error {
+		sum, err := c.Prefix().Sum(data)
+		if err != nil {
+			return err
+		}
+		ok = sum.Equals(c)
+		return nil
+	})
+	return ok, err
+}
+
+// repair fetches the corrupted blocks from the network, replacing the stored
+// copies, and returns the number of blocks repaired.
+func (s *Scrubber) repair(ctx context.Context) int {
+	if s.fetcher == nil {
+		return 0
+	}
+
+	s.lk.Lock()
+	corrupt := make(map[cid.Cid]Store, len(s.corrupt))
+	for c, st := range s.corrupt {
+		corrupt[c] = st
+	}
+	s.lk.Unlock()
+
+	var repaired int
+	for c, st := range corrupt {
+		if err := s.refetch(ctx, st, c); err != nil {
+			log.Warnw("refetching corrupted block", "store", st.Name, "cid", c, "error", err)
+			continue
+		}
+
+		log.Infow("repaired corrupted block", "store", st.Name, "cid", c)
+		repaired++
+		s.lk.Lock()
+		delete(s.corrupt, c)
+		s.lk.Unlock()
+	}
+	return repaired
+}
+
+func (s *Scrubber) refetch(ctx context.Context, st Store, c cid.Cid) error {
+	ctx, cancel := context.WithTimeout(ctx, RefetchTimeout)
+	defer cancel()
+
+	blk, err := s.fetcher.GetBlock(ctx, c)
+	if err != nil {
+		return err
+	}
+	sum, err := c.Prefix().Sum(blk.RawData())
+	if err != nil {
+		return err
+	}
+	if !sum.Equals(c) {
+		return xerrors.Errorf("fetched block doesn't match its cid either")
+	}
+	blk, err = blocks.NewBlockWithCid(blk.RawData(), c)
+	if err != nil {
+		return err
+	}
+
+	// the corrupted copy is deleted first, for the blockstores keeping the
+	// blocks they already have
+	if err := st.Blockstore.DeleteBlock(ctx, c); err != nil {
+		return xerrors.Errorf("deleting corrupted block: %w", err)
+	}
+	return st.Blockstore.Put(ctx, blk)
+}
+
+// updateAlert raises the corruption alert while corrupted blocks are left,
+// and resolves it once they are all repaired.
+func (s *Scrubber) updateAlert(pass Pass) {
+	if s.al == nil {
+		return
+	}
+
+	if pass.Unrepaired == 0 {
+		if pass.Repaired > 0 {
+			s.al.Resolve(s.alert, map[string]interface{}{
+				"message":  "the corrupted blocks were repaired",
+				"repaired": pass.Repaired,
+			})
+		}
+		return
+	}
+
+	msg := CorruptionAlert{Corrupt: map[string][]cid.Cid{}}
+	s.lk.Lock()
+	msg.Unrepaired = len(s.corrupt)
+	var listed int
+	for c, st := range s.corrupt {
+		if listed == maxAlertCids {
+			break
+		}
+		msg.Corrupt[st.Name] = append(msg.Corrupt[st.Name], c)
+		listed++
+	}
+	s.lk.Unlock()
+
+	s.al.Raise(s.alert, msg)
+}
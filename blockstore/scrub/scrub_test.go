@@ -0,0 +1,114 @@
+// stm: #unit
+package scrub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+// iterBlockstore is a memory blockstore iterating over its keys.
+type iterBlockstore struct {
+	blockstore.MemBlockstore
+}
+
+func (bs iterBlockstore) ForEachKey(f func(cid.Cid) error) error {
+	ch, err := bs.AllKeysChan(context.Background())
+	if err != nil {
+		return err
+	}
+	for c := range ch {
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapFetcher fetches the blocks it holds.
+type mapFetcher map[cid.Cid]blocks.Block
+
+func (f mapFetcher) GetBlock(_ context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, ok := f[c]
+	if !ok {
+		return nil, ipld.ErrNotFound{Cid: c}
+	}
+	return blk, nil
+}
+
+func (f mapFetcher) GetBlocks(context.Context, []cid.Cid) (<-chan blocks.Block, error) {
+	panic("not used")
+}
+
+func TestScrub(t *testing.T) {
+	ctx := context.Background()
+	bs := iterBlockstore{blockstore.NewMemory()}
+
+	var good []blocks.Block
+	for i := 0; i < 10; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("block %d", i)))
+		require.NoError(t, bs.Put(ctx, blk))
+		good = append(good, blk)
+	}
+	// bit-rot in the data of a block
+	corrupted, err := blocks.NewBlockWithCid([]byte("block X"), good[3].Cid())
+	require.NoError(t, err)
+	bs.MemBlockstore[string(good[3].Cid().Hash())] = corrupted
+
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	fetcher := mapFetcher{}
+	s, err := New([]Store{{Name: "test", Blockstore: bs}}, Config{Interval: time.Hour, Fraction: 1}, fetcher, al)
+	require.NoError(t, err)
+
+	// the corrupted block raises an alert, and is left while it can't be fetched
+	pass, err := s.Scrub(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 10, pass.Scrubbed)
+	require.Equal(t, 1, pass.Corrupt)
+	require.Equal(t, 1, pass.Unrepaired)
+	alerts := al.GetAlerts()
+	require.Len(t, alerts, 1)
+	require.True(t, alerts[0].Active)
+
+	// it is replaced once it can, resolving the alert
+	fetcher[good[3].Cid()] = good[3]
+	pass, err = s.Scrub(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, pass.Corrupt)
+	require.Equal(t, 1, pass.Repaired)
+	require.Equal(t, 0, pass.Unrepaired)
+	require.False(t, al.GetAlerts()[0].Active)
+
+	blk, err := bs.Get(ctx, good[3].Cid())
+	require.NoError(t, err)
+	require.Equal(t, good[3].RawData(), blk.RawData())
+	require.Equal(t, pass, *s.Last())
+}
+
+func TestScrubSamples(t *testing.T) {
+	ctx := context.Background()
+	bs := iterBlockstore{blockstore.NewMemory()}
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, bs.Put(ctx, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i)))))
+	}
+
+	s, err := New([]Store{{Name: "test", Blockstore: bs}}, Config{Interval: time.Hour, Fraction: 0.1}, nil, nil)
+	require.NoError(t, err)
+	pass, err := s.Scrub(ctx)
+	require.NoError(t, err)
+	require.Greater(t, pass.Scrubbed, 25)
+	require.Less(t, pass.Scrubbed, 250)
+
+	_, err = New([]Store{{Name: "mem", Blockstore: blockstore.NewMemory()}}, Config{Interval: time.Hour, Fraction: 0.1}, nil, nil)
+	require.Error(t, err)
+}
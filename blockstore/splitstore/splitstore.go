@@ -115,6 +115,10 @@ type Config struct {
 	// A positive value is the number of compactions before a full GC is performed;
 	// a value of 1 will perform full GC in every compaction.
 	HotStoreFullGCFrequency uint64
+
+	// ColdExporter, if set, receives the blocks moved to the coldstore by compaction, such as
+	// a CarArchive appending them to rolling CAR files. A failed export fails the compaction.
+	ColdExporter ColdExporter
 }
 
 // ChainAccessor allows the Splitstore to access the chain. It will most likely
@@ -195,6 +199,12 @@ type SplitStore struct {
 
 	// registered protectors
 	protectors []func(func(cid.Cid) error) error
+
+	// compaction progress, protected by mx, and pausing
+	progress  compactionProgress
+	paused    int32
+	pauseMx   sync.Mutex
+	pauseCond sync.Cond
 }
 
 var _ bstore.Blockstore = (*SplitStore)(nil)
@@ -232,6 +242,7 @@ func Open(path string, ds dstore.Datastore, hot, cold bstore.Blockstore, cfg *Co
 
 	ss.txnViewsCond.L = &ss.txnViewsMx
 	ss.txnSyncCond.L = &ss.txnSyncMx
+	ss.pauseCond.L = &ss.pauseMx
 	ss.ctx, ss.cancel = context.WithCancel(context.Background())
 
 	ss.reifyCond.L = &ss.reifyMx
@@ -776,6 +787,11 @@ func (s *SplitStore) Close() error {
 		s.txnSyncCond.Broadcast()
 		s.txnSyncMx.Unlock()
 
+		// a paused compaction wakes up to notice we are closing
+		s.pauseMx.Lock()
+		s.pauseCond.Broadcast()
+		s.pauseMx.Unlock()
+
 		log.Warn("close with ongoing compaction in progress; waiting for it to finish...")
 		for atomic.LoadInt32(&s.compacting) == 1 {
 			time.Sleep(time.Second)
@@ -0,0 +1,187 @@
+package splitstore
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/lib/s3upload"
+)
+
+// ColdExporter receives the blocks moved to the coldstore by compaction, so
+// that they can be archived as they leave the hotstore.
+type ColdExporter interface {
+	// ExportCold is called with every batch of blocks moved to the coldstore
+	// by the compaction with the given boundary epoch. The batch is only valid
+	// for the duration of the call.
+	ExportCold(ctx context.Context, boundary abi.ChainEpoch, blks []blocks.Block) error
+	// FlushCold is called once a compaction has moved all its cold blocks.
+	FlushCold(ctx context.Context) error
+}
+
+// CarArchiveConfig configures a CarArchive.
+type CarArchiveConfig struct {
+	// Destination is a directory, or an s3:// or gs:// url prefix.
+	Destination string
+	// Endpoint overrides the object store endpoint, see
+	// s3upload.ConfigFromURL.
+	Endpoint string
+	// MaxFileSize is the size after which an archive file is committed and the
+	// next one is started; 0 only commits them at the end of compactions.
+	MaxFileSize int64
+}
+
+// CarArchive is a ColdExporter appending the cold blocks to rolling CARv1
+// files named cold-<boundary epoch>-<part>.car, so that the history leaving
+// the hotstore is archived incrementally instead of by periodic full exports.
+//
+// A file is committed once it reaches MaxFileSize, at the end of every
+// compaction and on Close; the root of each file is its first block. The
+// blocks of a compaction interrupted while moving them are archived again by
+// the next one, which moves them again.
+type CarArchive struct {
+	cfg CarArchiveConfig
+
+	lk    sync.Mutex
+	epoch abi.ChainEpoch
+	part  int
+	sink  store.ExportSink
+	w     *bufio.Writer
+	size  int64
+}
+
+var _ ColdExporter = (*CarArchive)(nil)
+
+// NewCarArchive returns a CarArchive writing to cfg.Destination.
+func NewCarArchive(cfg CarArchiveConfig) (*CarArchive, error) {
+	if cfg.Destination == "" {
+		return nil, xerrors.Errorf("cold archive has no destination")
+	}
+	if !remoteDestination(cfg.Destination) {
+		if err := os.MkdirAll(cfg.Destination, 0755); err != nil {
+			return nil, xerrors.Errorf("creating cold archive directory: %w", err)
+		}
+	}
+	return &CarArchive{cfg: cfg}, nil
+}
+
+func remoteDestination(dest string) bool {
+	return strings.Contains(dest, "://")
+}
+
+func (a *CarArchive) ExportCold(ctx context.Context, boundary abi.ChainEpoch, blks []blocks.Block) error {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	if a.sink != nil && boundary != a.epoch {
+		// the previous compaction was interrupted
+		if err := a.commit(ctx); err != nil {
+			return err
+		}
+	}
+	if boundary != a.epoch {
+		a.epoch, a.part = boundary, 0
+	}
+
+	for _, blk := range blks {
+		if a.sink == nil {
+			if err := a.open(ctx, blk.Cid()); err != nil {
+				return err
+			}
+		}
+
+		if err := carutil.LdWrite(a.w, blk.Cid().Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("writing block %s to cold archive: %w", blk.Cid(), err)
+		}
+		a.size += int64(carutil.LdSize(blk.Cid().Bytes(), blk.RawData()))
+
+		if a.cfg.MaxFileSize > 0 && a.size >= a.cfg.MaxFileSize {
+			if err := a.commit(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *CarArchive) FlushCold(ctx context.Context) error {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	return a.commit(ctx)
+}
+
+// Close commits the file being written, if any.
+func (a *CarArchive) Close() error {
+	return a.FlushCold(context.Background())
+}
+
+// open starts the next archive file, rooted at its first block.
+func (a *CarArchive) open(ctx context.Context, root cid.Cid) error {
+	name := fmt.Sprintf("cold-%d-%d.car", a.epoch, a.part)
+
+	var sink store.ExportSink
+	var err error
+	if remoteDestination(a.cfg.Destination) {
+		var cfg s3upload.Config
+		cfg, err = s3upload.ConfigFromURL(strings.TrimSuffix(a.cfg.Destination, "/")+"/"+name, a.cfg.Endpoint)
+		if err == nil {
+			sink, err = s3upload.New(ctx, cfg)
+		}
+	} else {
+		sink, err = store.NewFileSink(filepath.Join(a.cfg.Destination, name))
+	}
+	if err != nil {
+		return xerrors.Errorf("opening cold archive %s: %w", name, err)
+	}
+
+	w := bufio.NewWriter(sink)
+	h := &car.CarHeader{
+		Roots:   []cid.Cid{root},
+		Version: 1,
+	}
+	if err := car.WriteHeader(h, w); err != nil {
+		_ = sink.Abort(ctx)
+		return xerrors.Errorf("writing cold archive header: %w", err)
+	}
+	hn, err := car.HeaderSize(h)
+	if err != nil {
+		_ = sink.Abort(ctx)
+		return xerrors.Errorf("computing cold archive header size: %w", err)
+	}
+
+	a.sink, a.w, a.size = sink, w, int64(hn)
+	a.part++
+	return nil
+}
+
+// commit commits the file being written, if any.
+func (a *CarArchive) commit(ctx context.Context) error {
+	if a.sink == nil {
+		return nil
+	}
+
+	sink, w := a.sink, a.w
+	a.sink, a.w, a.size = nil, nil, 0
+	if err := w.Flush(); err != nil {
+		_ = sink.Abort(ctx)
+		return xerrors.Errorf("writing cold archive: %w", err)
+	}
+	if err := sink.Commit(ctx); err != nil {
+		return xerrors.Errorf("committing cold archive: %w", err)
+	}
+	return nil
+}
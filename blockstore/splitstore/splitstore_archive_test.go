@@ -0,0 +1,106 @@
+// stm: #unit
+package splitstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// recordingExporter records the blocks exported by compactions.
+type recordingExporter struct {
+	lk      sync.Mutex
+	blocks  map[cid.Cid]abi.ChainEpoch
+	flushes int
+}
+
+func (e *recordingExporter) ExportCold(_ context.Context, boundary abi.ChainEpoch, blks []blocks.Block) error {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	for _, blk := range blks {
+		e.blocks[blk.Cid()] = boundary
+	}
+	return nil
+}
+
+func (e *recordingExporter) FlushCold(context.Context) error {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	e.flushes++
+	return nil
+}
+
+func TestSplitStoreCompactionWithColdExporter(t *testing.T) {
+	exp := &recordingExporter{blocks: map[cid.Cid]abi.ChainEpoch{}}
+	testSplitStore(t, &Config{MarkSetType: "map", UniversalColdBlocks: true, ColdExporter: exp})
+
+	// the blocks moved to the coldstore by the compaction are exported
+	exp.lk.Lock()
+	defer exp.lk.Unlock()
+	require.Len(t, exp.blocks, 4)
+	require.Equal(t, 1, exp.flushes)
+	require.Contains(t, exp.blocks, blocks.NewBlock([]byte("unprotected!")).Cid())
+}
+
+func readArchive(t *testing.T, path string) []cid.Cid {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	cr, err := car.NewCarReader(f)
+	require.NoError(t, err)
+
+	var cids []cid.Cid
+	for {
+		blk, err := cr.Next()
+		if err != nil {
+			break
+		}
+		cids = append(cids, blk.Cid())
+	}
+	require.Equal(t, cids[0], cr.Header.Roots[0])
+	return cids
+}
+
+func TestCarArchive(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a, err := NewCarArchive(CarArchiveConfig{Destination: dir, MaxFileSize: 250})
+	require.NoError(t, err)
+
+	var blks []blocks.Block
+	for i := 0; i < 5; i++ {
+		data := make([]byte, 100)
+		data[0] = byte(i)
+		blks = append(blks, blocks.NewBlock(data))
+	}
+
+	// files roll once they reach MaxFileSize, and are committed on flushes
+	require.NoError(t, a.ExportCold(ctx, 10, blks[:3]))
+	require.NoError(t, a.ExportCold(ctx, 10, blks[3:4]))
+	require.NoError(t, a.FlushCold(ctx))
+	require.Equal(t, []cid.Cid{blks[0].Cid(), blks[1].Cid()}, readArchive(t, filepath.Join(dir, "cold-10-0.car")))
+	require.Equal(t, []cid.Cid{blks[2].Cid(), blks[3].Cid()}, readArchive(t, filepath.Join(dir, "cold-10-1.car")))
+
+	// the file of an interrupted compaction is committed by the next one
+	require.NoError(t, a.ExportCold(ctx, 20, blks[4:]))
+	require.NoError(t, a.ExportCold(ctx, 30, blks[:1]))
+	require.Equal(t, []cid.Cid{blks[4].Cid()}, readArchive(t, filepath.Join(dir, "cold-20-0.car")))
+	require.NoError(t, a.Close())
+	require.Equal(t, []cid.Cid{blks[0].Cid()}, readArchive(t, filepath.Join(dir, "cold-30-0.car")))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+}
@@ -162,3 +162,13 @@ func (s *SplitStore) Info() map[string]interface{} {
 
 	return info
 }
+
+// HotStore returns the hotstore of the splitstore.
+func (s *SplitStore) HotStore() bstore.Blockstore {
+	return s.hot
+}
+
+// ColdStore returns the coldstore of the splitstore.
+func (s *SplitStore) ColdStore() bstore.Blockstore {
+	return s.cold
+}
@@ -101,6 +101,12 @@ func (s *SplitStore) HeadChange(_, apply []*types.TipSet) error {
 		return nil
 	}
 
+	if atomic.LoadInt32(&s.paused) == 1 {
+		// compaction is held off by the operator
+		atomic.StoreInt32(&s.compacting, 0)
+		return nil
+	}
+
 	timestamp := time.Unix(int64(curTs.MinTimestamp()), 0)
 
 	if CheckSyncGap && time.Since(timestamp) > SyncGapTime {
@@ -495,6 +501,7 @@ func (s *SplitStore) compact(curTs *types.TipSet) {
 
 	start = time.Now()
 	err := s.doCompact(curTs)
+	s.endCompactionProgress()
 	took := time.Since(start).Milliseconds()
 	stats.Record(s.ctx, metrics.SplitstoreCompactionTimeSeconds.M(float64(took)/1e3))
 
@@ -534,7 +541,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	}
 	defer coldSet.Close() //nolint:errcheck
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
@@ -550,6 +557,8 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	//   and messages until the boundary epoch.
 	log.Info("marking reachable objects")
 	startMark := time.Now()
+	// the size of the mark set is estimated from the last compaction
+	s.beginCompactionPhase(phaseMarking, s.markSetSize)
 
 	count := new(int64)
 
@@ -594,6 +603,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		}
 
 		atomic.AddInt64(count, 1)
+		s.compactionStep(1)
 		return nil
 	}
 
@@ -607,7 +617,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 
 	log.Infow("marking done", "took", time.Since(startMark), "marked", *count)
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
@@ -617,13 +627,14 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		return xerrors.Errorf("error protecting transactional refs: %w", err)
 	}
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
 	// 2. iterate through the hotstore to collect cold objects
 	log.Info("collecting cold objects")
 	startCollect := time.Now()
+	s.beginCompactionPhase(phaseCollecting, 0)
 
 	coldw, err := NewColdSetWriter(s.coldSetPath())
 	if err != nil {
@@ -640,6 +651,8 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	// some stats for logging
 	var hotCnt, coldCnt, purgeCnt int
 	err = s.hot.ForEachKey(func(c cid.Cid) error {
+		s.compactionStep(1)
+
 		// was it marked?
 		mark, err := markSet.Has(c)
 		if err != nil {
@@ -693,7 +706,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	stats.Record(s.ctx, metrics.SplitstoreCompactionHot.M(int64(hotCnt)))
 	stats.Record(s.ctx, metrics.SplitstoreCompactionCold.M(int64(coldCnt)))
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
@@ -702,7 +715,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	// possibly delete objects we didn't have when we were collecting cold objects)
 	s.waitForMissingRefs(markSet)
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
@@ -716,13 +729,14 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	if !s.cfg.DiscardColdBlocks {
 		log.Info("moving cold objects to the coldstore")
 		startMove := time.Now()
-		err = s.moveColdBlocks(coldr)
+		s.beginCompactionPhase(phaseMoving, int64(coldCnt))
+		err = s.moveColdBlocks(coldr, boundaryEpoch)
 		if err != nil {
 			return xerrors.Errorf("error moving cold objects: %w", err)
 		}
 		log.Infow("moving done", "took", time.Since(startMove))
 
-		if err := s.checkClosing(); err != nil {
+		if err := s.checkYield(); err != nil {
 			return err
 		}
 
@@ -748,14 +762,14 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		return xerrors.Errorf("error beginning critical section: %w", err)
 	}
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
 	// wait for the head to catch up so that the current tipset is marked
 	s.waitForSync()
 
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return err
 	}
 
@@ -768,6 +782,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	// 5. purge cold objects from the hotstore, taking protected references into account
 	log.Info("purging cold objects from the hotstore")
 	startPurge := time.Now()
+	s.beginCompactionPhase(phasePurging, int64(purgeCnt))
 	err = s.purge(purger, checkpoint, markSet)
 	if err != nil {
 		return xerrors.Errorf("error purging cold objects: %w", err)
@@ -791,6 +806,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 
 	// we are done; do some housekeeping
 	s.endTxnProtect()
+	s.beginCompactionPhase(phaseGC, 0)
 	s.gcHotstore()
 
 	err = s.setBaseEpoch(boundaryEpoch)
@@ -983,7 +999,7 @@ func (s *SplitStore) walkChain(ts *types.TipSet, inclState, inclMsgs abi.ChainEp
 
 	for len(toWalk) > 0 {
 		// walking can take a while, so check this with every opportunity
-		if err := s.checkClosing(); err != nil {
+		if err := s.checkYield(); err != nil {
 			return err
 		}
 
@@ -1198,11 +1214,11 @@ func (s *SplitStore) getSize(c cid.Cid) (int, error) {
 	}
 }
 
-func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
+func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader, boundary abi.ChainEpoch) error {
 	batch := make([]blocks.Block, 0, batchSize)
 
 	err := coldr.ForEach(func(c cid.Cid) error {
-		if err := s.checkClosing(); err != nil {
+		if err := s.checkYield(); err != nil {
 			return err
 		}
 		blk, err := s.hot.Get(s.ctx, c)
@@ -1216,10 +1232,12 @@ func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
 		}
 
 		batch = append(batch, blk)
+		s.compactionStep(1)
+		atomic.AddInt64(&s.progress.bytesMoved, int64(len(blk.RawData())))
 		if len(batch) == batchSize {
-			err = s.cold.PutMany(s.ctx, batch)
+			err = s.putCold(batch, boundary)
 			if err != nil {
-				return xerrors.Errorf("error putting batch to coldstore: %w", err)
+				return err
 			}
 			batch = batch[:0]
 
@@ -1233,9 +1251,31 @@ func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
 	}
 
 	if len(batch) > 0 {
-		err := s.cold.PutMany(s.ctx, batch)
+		err := s.putCold(batch, boundary)
 		if err != nil {
-			return xerrors.Errorf("error putting batch to coldstore: %w", err)
+			return err
+		}
+	}
+
+	if s.cfg.ColdExporter != nil {
+		if err := s.cfg.ColdExporter.FlushCold(s.ctx); err != nil {
+			return xerrors.Errorf("error flushing cold export: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// putCold puts a batch of cold blocks to the coldstore, and exports them if there is a
+// ColdExporter.
+func (s *SplitStore) putCold(batch []blocks.Block, boundary abi.ChainEpoch) error {
+	if err := s.cold.PutMany(s.ctx, batch); err != nil {
+		return xerrors.Errorf("error putting batch to coldstore: %w", err)
+	}
+
+	if s.cfg.ColdExporter != nil {
+		if err := s.cfg.ColdExporter.ExportCold(s.ctx, boundary, batch); err != nil {
+			return xerrors.Errorf("error exporting cold batch: %w", err)
 		}
 	}
 
@@ -1256,6 +1296,7 @@ func (s *SplitStore) purge(coldr *ColdSetReader, checkpoint *Checkpoint, markSet
 
 		purgeCnt += pc
 		liveCnt += lc
+		s.compactionStep(int64(len(batch)))
 		batch = batch[:0]
 
 		return err
@@ -1282,7 +1323,7 @@ func (s *SplitStore) purge(coldr *ColdSetReader, checkpoint *Checkpoint, markSet
 }
 
 func (s *SplitStore) purgeBatch(batch, deadCids []cid.Cid, checkpoint *Checkpoint, markSet MarkSet) (purgeCnt int, liveCnt int, err error) {
-	if err := s.checkClosing(); err != nil {
+	if err := s.checkYield(); err != nil {
 		return 0, 0, err
 	}
 
@@ -1431,6 +1472,7 @@ func (s *SplitStore) completePurge(coldr *ColdSetReader, checkpoint *Checkpoint,
 
 		purgeCnt += pc
 		liveCnt += lc
+		s.compactionStep(int64(len(batch)))
 		batch = batch[:0]
 
 		return err
@@ -1489,7 +1531,7 @@ func (s *SplitStore) waitForMissingRefs(markSet MarkSet) {
 	}()
 
 	for i := 0; i < 3 && len(missing) > 0; i++ {
-		if err := s.checkClosing(); err != nil {
+		if err := s.checkYield(); err != nil {
 			return
 		}
 
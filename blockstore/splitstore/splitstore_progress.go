@@ -0,0 +1,130 @@
+package splitstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// compaction phases, as reported in the progress of the compaction
+const (
+	phaseMarking    = "marking"
+	phaseCollecting = "collecting"
+	phaseMoving     = "moving"
+	phasePurging    = "purging"
+	phaseGC         = "gc"
+)
+
+// compactionProgress tracks the progress of the running compaction. The phase
+// is protected by the mutex of the splitstore; the counters are updated
+// atomically as the compaction goes.
+type compactionProgress struct {
+	phase        string
+	started      time.Time
+	phaseStarted time.Time
+
+	done       int64
+	total      int64
+	bytesMoved int64
+}
+
+// beginCompactionPhase starts a phase of the compaction, with the number of
+// objects it is expected to process, 0 if unknown.
+func (s *SplitStore) beginCompactionPhase(phase string, total int64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := time.Now()
+	if s.progress.phase == "" {
+		s.progress.started = now
+	}
+	s.progress.phase = phase
+	s.progress.phaseStarted = now
+	atomic.StoreInt64(&s.progress.done, 0)
+	atomic.StoreInt64(&s.progress.total, total)
+}
+
+func (s *SplitStore) endCompactionProgress() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.progress = compactionProgress{}
+}
+
+func (s *SplitStore) compactionStep(n int64) {
+	atomic.AddInt64(&s.progress.done, n)
+}
+
+// CompactionProgress returns the progress of the running compaction.
+func (s *SplitStore) CompactionProgress() api.SplitstoreCompactionProgress {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	p := api.SplitstoreCompactionProgress{
+		Paused: atomic.LoadInt32(&s.paused) == 1,
+	}
+	if s.progress.phase == "" {
+		return p
+	}
+
+	p.Compacting = true
+	p.Phase = s.progress.phase
+	p.Started = s.progress.started
+	p.PhaseStarted = s.progress.phaseStarted
+	p.Done = atomic.LoadInt64(&s.progress.done)
+	p.Total = atomic.LoadInt64(&s.progress.total)
+	p.BytesMoved = atomic.LoadInt64(&s.progress.bytesMoved)
+
+	if p.Total > 0 {
+		p.Percent = 100 * float64(p.Done) / float64(p.Total)
+		if p.Percent > 100 {
+			// the totals of some phases are estimates
+			p.Percent = 100
+		}
+		if p.Done > 0 && p.Done < p.Total && !p.Paused {
+			elapsed := time.Since(p.PhaseStarted)
+			p.ETA = time.Duration(float64(elapsed) * float64(p.Total-p.Done) / float64(p.Done))
+		}
+	}
+
+	return p
+}
+
+// PauseCompaction pauses the running compaction at its next checkpoint, and
+// holds off new compactions until ResumeCompaction is called.
+func (s *SplitStore) PauseCompaction() {
+	s.pauseMx.Lock()
+	defer s.pauseMx.Unlock()
+
+	if atomic.CompareAndSwapInt32(&s.paused, 0, 1) {
+		log.Info("compaction paused")
+	}
+}
+
+// ResumeCompaction resumes the compaction paused by PauseCompaction.
+func (s *SplitStore) ResumeCompaction() {
+	s.pauseMx.Lock()
+	defer s.pauseMx.Unlock()
+
+	if atomic.CompareAndSwapInt32(&s.paused, 1, 0) {
+		log.Info("compaction resumed")
+	}
+	s.pauseCond.Broadcast()
+}
+
+// checkYield blocks while the compaction is paused, then checks whether the
+// splitstore is closing. It is only called from the compaction goroutine,
+// never from the transactional protection of concurrent writes; warmup and
+// checks share the walks of the compaction but are not paused.
+func (s *SplitStore) checkYield() error {
+	if (s.compactType == hot || s.compactType == cold) && atomic.LoadInt32(&s.paused) == 1 {
+		s.pauseMx.Lock()
+		for atomic.LoadInt32(&s.paused) == 1 && atomic.LoadInt32(&s.closing) == 0 {
+			s.pauseCond.Wait()
+		}
+		s.pauseMx.Unlock()
+	}
+
+	return s.checkClosing()
+}
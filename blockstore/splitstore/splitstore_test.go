@@ -385,6 +385,127 @@ func TestSplitStoreSuppressCompactionNearUpgrade(t *testing.T) {
 	}
 }
 
+func TestSplitStorePauseCompaction(t *testing.T) {
+	ctx := context.Background()
+	chain := &mockChain{t: t}
+
+	// the myriads of stores
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := newMockStore()
+	cold := newMockStore()
+
+	// this is necessary to avoid the garbage mock puts in the blocks
+	garbage := blocks.NewBlock([]byte{1, 2, 3})
+	err := cold.Put(ctx, garbage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// genesis
+	genBlock := mock.MkBlock(nil, 0, 0)
+	genBlock.Messages = garbage.Cid()
+	genBlock.ParentMessageReceipts = garbage.Cid()
+	genBlock.ParentStateRoot = garbage.Cid()
+	genBlock.Timestamp = uint64(time.Now().Unix())
+
+	genTs := mock.TipSet(genBlock)
+	chain.push(genTs)
+
+	// put the genesis block to cold store
+	blk, err := genBlock.ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cold.Put(ctx, blk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := t.TempDir()
+
+	// open the splitstore
+	ss, err := Open(path, ds, hot, cold, &Config{MarkSetType: "map", UniversalColdBlocks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	ss.PauseCompaction()
+
+	err = ss.Start(chain, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mkBlock := func(curTs *types.TipSet, i int, stateRoot blocks.Block) *types.TipSet {
+		blk := mock.MkBlock(curTs, uint64(i), uint64(i))
+
+		blk.Messages = garbage.Cid()
+		blk.ParentMessageReceipts = garbage.Cid()
+		blk.ParentStateRoot = stateRoot.Cid()
+		blk.Timestamp = uint64(time.Now().Unix())
+
+		sblk, err := blk.ToStorageBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ss.Put(ctx, stateRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ss.Put(ctx, sblk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := mock.TipSet(blk)
+		chain.push(ts)
+
+		return ts
+	}
+
+	waitForCompaction := func() {
+		ss.txnSyncMx.Lock()
+		ss.txnSync = true
+		ss.txnSyncCond.Broadcast()
+		ss.txnSyncMx.Unlock()
+		for atomic.LoadInt32(&ss.compacting) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	curTs := genTs
+	for i := 1; i < 10; i++ {
+		stateRoot := blocks.NewBlock([]byte{byte(i), 3, 3, 7})
+		curTs = mkBlock(curTs, i, stateRoot)
+		waitForCompaction()
+	}
+
+	// we should not have compacted while paused
+	if ss.baseEpoch != 0 {
+		t.Errorf("expected no compaction while paused, but compacted at %d", ss.baseEpoch)
+	}
+
+	p := ss.CompactionProgress()
+	if !p.Paused || p.Compacting {
+		t.Errorf("expected paused and not compacting, but got %+v", p)
+	}
+
+	// resume and put one more block, now we should compact
+	ss.ResumeCompaction()
+	curTs = mkBlock(curTs, 10, blocks.NewBlock([]byte{10, 3, 3, 7}))
+	waitForCompaction()
+
+	if ss.baseEpoch != curTs.Height()-CompactionBoundary {
+		t.Errorf("expected compaction at %d, but base epoch is %d", curTs.Height()-CompactionBoundary, ss.baseEpoch)
+	}
+
+	p = ss.CompactionProgress()
+	if p.Paused || p.Compacting {
+		t.Errorf("expected no compaction running, but got %+v", p)
+	}
+}
+
 func testSplitStoreReification(t *testing.T, f func(context.Context, blockstore.Blockstore, cid.Cid) error) {
 	ds := dssync.MutexWrap(datastore.NewMapDatastore())
 	hot := newMockStore()
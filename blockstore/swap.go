@@ -0,0 +1,123 @@
+package blockstore
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// StandbyBlockstore is a blockstore that is filled while the blockstore it is
+// the standby of keeps serving, and then takes its place.
+type StandbyBlockstore interface {
+	Blockstore
+
+	// Promote puts the standby blockstore in the place of the blockstore it is
+	// the standby of, which is closed and removed.
+	Promote() error
+	// Discard closes and removes the standby blockstore.
+	Discard() error
+}
+
+// SwapStore is a blockstore whose underlying blockstore can be swapped while it
+// is in use. Every operation is served by the underlying blockstore at the time
+// it is called.
+type SwapStore struct {
+	bs atomic.Value // swapped
+}
+
+type swapped struct {
+	Blockstore
+}
+
+var _ Blockstore = (*SwapStore)(nil)
+var _ BatchViewer = (*SwapStore)(nil)
+
+// NewSwapStore returns a SwapStore serving from bs.
+func NewSwapStore(bs Blockstore) *SwapStore {
+	s := &SwapStore{}
+	s.bs.Store(swapped{bs})
+	return s
+}
+
+func (s *SwapStore) get() Blockstore {
+	return s.bs.Load().(swapped).Blockstore
+}
+
+// Swap makes bs the underlying blockstore and returns the previous one.
+// Operations that are running on the previous blockstore are not waited for.
+func (s *SwapStore) Swap(bs Blockstore) Blockstore {
+	return s.bs.Swap(swapped{bs}).(swapped).Blockstore
+}
+
+func (s *SwapStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	return s.get().Has(ctx, c)
+}
+
+func (s *SwapStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	return s.get().Get(ctx, c)
+}
+
+func (s *SwapStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	return s.get().GetSize(ctx, c)
+}
+
+func (s *SwapStore) View(ctx context.Context, c cid.Cid, cb func([]byte) error) error {
+	return s.get().View(ctx, c, cb)
+}
+
+func (s *SwapStore) ViewMany(ctx context.Context, cids []cid.Cid, cb func(int, []byte) error) error {
+	return ViewMany(ctx, s.get(), cids, cb)
+}
+
+func (s *SwapStore) Put(ctx context.Context, blk blocks.Block) error {
+	return s.get().Put(ctx, blk)
+}
+
+func (s *SwapStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return s.get().PutMany(ctx, blks)
+}
+
+func (s *SwapStore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	return s.get().DeleteBlock(ctx, c)
+}
+
+func (s *SwapStore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	return s.get().DeleteMany(ctx, cids)
+}
+
+func (s *SwapStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return s.get().AllKeysChan(ctx)
+}
+
+func (s *SwapStore) HashOnRead(enabled bool) {
+	s.get().HashOnRead(enabled)
+}
+
+func (s *SwapStore) ForEachKey(f func(cid.Cid) error) error {
+	bs := s.get()
+	iterBstore, ok := bs.(BlockstoreIterator)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore (type %T) doesn't support fast iteration", bs)
+	}
+	return iterBstore.ForEachKey(f)
+}
+
+func (s *SwapStore) CollectGarbage(opts ...BlockstoreGCOption) error {
+	bs := s.get()
+	gcBstore, ok := bs.(BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore (type %T) doesn't support garbage collection", bs)
+	}
+	return gcBstore.CollectGarbage(opts...)
+}
+
+func (s *SwapStore) Close() error {
+	if c, ok := s.get().(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
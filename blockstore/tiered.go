@@ -0,0 +1,107 @@
+package blockstore
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// TieredBlockstore is a blockstore over a hot and a cold tier, for data moved
+// from the hot tier to cheaper and slower storage once it is rarely read.
+//
+//   - Reads return from the hot tier, falling back to the cold tier for the
+//     blocks it doesn't have.
+//   - Writes (puts and deletes) only go to the hot tier; the cold tier is filled
+//     by moving blocks to it explicitly.
+type TieredBlockstore struct {
+	Hot, Cold Blockstore
+}
+
+var _ Blockstore = (*TieredBlockstore)(nil)
+var _ BatchViewer = (*TieredBlockstore)(nil)
+
+// NewTiered returns a blockstore reading from hot, then cold.
+func NewTiered(hot, cold Blockstore) *TieredBlockstore {
+	return &TieredBlockstore{Hot: hot, Cold: cold}
+}
+
+func (t *TieredBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	has, err := t.Hot.Has(ctx, c)
+	if has || err != nil {
+		return has, err
+	}
+	return t.Cold.Has(ctx, c)
+}
+
+func (t *TieredBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := t.Hot.Get(ctx, c)
+	if ipld.IsNotFound(err) {
+		return t.Cold.Get(ctx, c)
+	}
+	return blk, err
+}
+
+func (t *TieredBlockstore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	err := t.Hot.View(ctx, c, callback)
+	if ipld.IsNotFound(err) {
+		return t.Cold.View(ctx, c, callback)
+	}
+	return err
+}
+
+// ViewMany views the blocks in a single round-trip to the hot tier when it is
+// a BatchViewer and has them all, and one at a time otherwise.
+func (t *TieredBlockstore) ViewMany(ctx context.Context, cids []cid.Cid, callback func(int, []byte) error) error {
+	if bv, ok := t.Hot.(BatchViewer); ok {
+		// batch views stop at the first missing block, the blocks viewed
+		// before it are viewed again
+		err := bv.ViewMany(ctx, cids, callback)
+		if !ipld.IsNotFound(err) {
+			return err
+		}
+	}
+	for i, c := range cids {
+		if err := t.View(ctx, c, func(data []byte) error {
+			return callback(i, data)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TieredBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	size, err := t.Hot.GetSize(ctx, c)
+	if ipld.IsNotFound(err) {
+		return t.Cold.GetSize(ctx, c)
+	}
+	return size, err
+}
+
+func (t *TieredBlockstore) Put(ctx context.Context, blk blocks.Block) error {
+	return t.Hot.Put(ctx, blk)
+}
+
+func (t *TieredBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return t.Hot.PutMany(ctx, blks)
+}
+
+func (t *TieredBlockstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	return t.Hot.DeleteBlock(ctx, c)
+}
+
+func (t *TieredBlockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	return t.Hot.DeleteMany(ctx, cids)
+}
+
+// AllKeysChan returns the keys of the hot tier.
+func (t *TieredBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return t.Hot.AllKeysChan(ctx)
+}
+
+func (t *TieredBlockstore) HashOnRead(enabled bool) {
+	t.Hot.HashOnRead(enabled)
+	t.Cold.HashOnRead(enabled)
+}
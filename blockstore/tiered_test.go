@@ -0,0 +1,53 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredBlockstore(t *testing.T) {
+	ctx := context.Background()
+	hot := NewMemory()
+	cold := NewMemory()
+	require.NoError(t, hot.Put(ctx, b1))
+	require.NoError(t, cold.Put(ctx, b2))
+
+	tb := NewTiered(hot, cold)
+
+	// reads fall back to the cold tier
+	for _, b := range []cid.Cid{b1.Cid(), b2.Cid()} {
+		has, err := tb.Has(ctx, b)
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+	v, err := tb.Get(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b2.RawData(), v.RawData())
+	size, err := tb.GetSize(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.Equal(t, len(b2.RawData()), size)
+	_, err = tb.Get(ctx, b3.Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	got := make([][]byte, 2)
+	require.NoError(t, ViewMany(ctx, tb, []cid.Cid{b1.Cid(), b2.Cid()}, func(i int, data []byte) error {
+		got[i] = append([]byte{}, data...)
+		return nil
+	}))
+	require.Equal(t, [][]byte{b1.RawData(), b2.RawData()}, got)
+
+	// writes only go to the hot tier
+	require.NoError(t, tb.Put(ctx, b3))
+	has, _ := cold.Has(ctx, b3.Cid())
+	require.False(t, has)
+	require.NoError(t, tb.DeleteMany(ctx, []cid.Cid{b2.Cid(), b3.Cid()}))
+	has, _ = cold.Has(ctx, b2.Cid())
+	require.True(t, has)
+	has, _ = hot.Has(ctx, b3.Cid())
+	require.False(t, has)
+}
@@ -0,0 +1,145 @@
+// Package usage reports how the space of the blockstores of a node is used:
+// the objects and bytes of every tier, the blocks stored in more than one
+// tier, the bytes per codec and the largest objects.
+package usage
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multicodec"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+var log = logging.Logger("bsusage")
+
+// DefaultTop is the number of largest objects reported when none is given.
+const DefaultTop = 20
+
+// progressInterval is the number of objects between progress logs.
+const progressInterval = 1 << 20
+
+// Tier is a blockstore of the node, such as the hot or the cold store of the
+// splitstore.
+type Tier struct {
+	Name       string
+	Blockstore blockstore.Blockstore
+}
+
+// Scan scans the tiers in order and reports their usage, with the top largest
+// objects. A block is a duplicate in a tier when an earlier tier also has it;
+// the bytes of the tiers and the codecs include the duplicates, as they take
+// space, while the largest objects don't.
+//
+// Duplicates are looked up in the earlier tiers rather than tracked in
+// memory, so that scanning billions of blocks doesn't take as much memory.
+func Scan(ctx context.Context, tiers []Tier, top int) (api.BlockstoreUsage, error) {
+	if top <= 0 {
+		top = DefaultTop
+	}
+
+	res := api.BlockstoreUsage{
+		Codecs: map[string]api.BlockstoreObjects{},
+	}
+	largest := &objectHeap{}
+
+	for i, tier := range tiers {
+		tu := api.BlockstoreTierUsage{Name: tier.Name}
+
+		err := forEachKey(ctx, tier.Blockstore, func(c cid.Cid) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			size, err := tier.Blockstore.GetSize(ctx, c)
+			if err != nil {
+				return xerrors.Errorf("getting size of %s: %w", c, err)
+			}
+
+			tu.Objects.Add(size)
+			codec := multicodec.Code(c.Prefix().Codec).String()
+			cu := res.Codecs[codec]
+			cu.Add(size)
+			res.Codecs[codec] = cu
+
+			for _, earlier := range tiers[:i] {
+				dup, err := earlier.Blockstore.Has(ctx, c)
+				if err != nil {
+					return xerrors.Errorf("looking up %s in %s: %w", c, earlier.Name, err)
+				}
+				if dup {
+					tu.Duplicates.Add(size)
+					res.Duplicates.Add(size)
+					return nil
+				}
+			}
+
+			if largest.Len() < top || uint64(size) > (*largest)[0].Size {
+				heap.Push(largest, api.BlockstoreObject{Cid: c, Codec: codec, Size: uint64(size), Tier: tier.Name})
+				if largest.Len() > top {
+					heap.Pop(largest)
+				}
+			}
+
+			if tu.Objects.Count%progressInterval == 0 {
+				log.Infow("scanning blockstore", "tier", tier.Name, "objects", tu.Objects.Count, "bytes", tu.Objects.Bytes)
+			}
+			return nil
+		})
+		if err != nil {
+			return api.BlockstoreUsage{}, xerrors.Errorf("scanning %s: %w", tier.Name, err)
+		}
+
+		log.Infow("scanned blockstore", "tier", tier.Name, "objects", tu.Objects.Count, "bytes", tu.Objects.Bytes, "duplicates", tu.Duplicates.Count)
+		res.Tiers = append(res.Tiers, tu)
+		res.Total.Count += tu.Objects.Count
+		res.Total.Bytes += tu.Objects.Bytes
+	}
+
+	res.Largest = append([]api.BlockstoreObject{}, (*largest)...)
+	sort.Slice(res.Largest, func(i, j int) bool {
+		return res.Largest[i].Size > res.Largest[j].Size
+	})
+	return res, nil
+}
+
+func forEachKey(ctx context.Context, bs blockstore.Blockstore, f func(cid.Cid) error) error {
+	if iter, ok := bs.(blockstore.BlockstoreIterator); ok {
+		return iter.ForEachKey(f)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return err
+	}
+	for c := range ch {
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// objectHeap is a min-heap of objects by size, holding the largest objects.
+type objectHeap []api.BlockstoreObject
+
+func (h objectHeap) Len() int            { return len(h) }
+func (h objectHeap) Less(i, j int) bool  { return h[i].Size < h[j].Size }
+func (h objectHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *objectHeap) Push(x interface{}) { *h = append(*h, x.(api.BlockstoreObject)) }
+func (h *objectHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
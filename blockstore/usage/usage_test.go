@@ -0,0 +1,56 @@
+// stm: #unit
+package usage
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+
+	mkBlock := func(codec uint64, size int) blocks.Block {
+		data := make([]byte, size)
+		data[0] = byte(codec)
+		c, err := cid.V1Builder{Codec: codec, MhType: multihash.SHA2_256}.Sum(data)
+		require.NoError(t, err)
+		b, err := blocks.NewBlockWithCid(data, c)
+		require.NoError(t, err)
+		return b
+	}
+	small := mkBlock(cid.DagCBOR, 10)
+	large := mkBlock(cid.DagCBOR, 100)
+	raw := mkBlock(cid.Raw, 50)
+
+	hot, cold := blockstore.NewMemory(), blockstore.NewMemory()
+	require.NoError(t, hot.PutMany(ctx, []blocks.Block{small, raw}))
+	require.NoError(t, cold.PutMany(ctx, []blocks.Block{small, large}))
+
+	u, err := Scan(ctx, []Tier{{Name: "hot", Blockstore: hot}, {Name: "cold", Blockstore: cold}}, 2)
+	require.NoError(t, err)
+
+	require.Equal(t, []api.BlockstoreTierUsage{
+		{Name: "hot", Objects: api.BlockstoreObjects{Count: 2, Bytes: 60}},
+		{Name: "cold", Objects: api.BlockstoreObjects{Count: 2, Bytes: 110}, Duplicates: api.BlockstoreObjects{Count: 1, Bytes: 10}},
+	}, u.Tiers)
+	require.Equal(t, api.BlockstoreObjects{Count: 4, Bytes: 170}, u.Total)
+	require.Equal(t, api.BlockstoreObjects{Count: 1, Bytes: 10}, u.Duplicates)
+	require.Equal(t, map[string]api.BlockstoreObjects{
+		"dag-cbor": {Count: 3, Bytes: 120},
+		"raw":      {Count: 1, Bytes: 50},
+	}, u.Codecs)
+
+	// the top 2 are the largest, the duplicate isn't counted twice
+	require.Equal(t, []api.BlockstoreObject{
+		{Cid: large.Cid(), Codec: "dag-cbor", Size: 100, Tier: "cold"},
+		{Cid: raw.Cid(), Codec: "raw", Size: 50, Tier: "hot"},
+	}, u.Largest)
+}
@@ -0,0 +1,476 @@
+// Package actorindex indexes the changes of the actors of the state trees of
+// the chain as the node follows it, so that the actors of old states are read
+// from the index rather than resolved through the state tree HAMT.
+package actorindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("actorindex")
+
+var metaKey = datastore.NewKey("/meta")
+
+// backfillStep is the number of tipsets indexed below the indexed range at
+// each catch up, so that backfilling doesn't hold back following the head.
+const backfillStep = 20
+
+// Config configures an Index.
+type Config struct {
+	// Enable enables the index; a disabled index answers no lookup.
+	Enable bool
+	// Backfill is the number of epochs below the head at which the index was
+	// started that get indexed too.
+	Backfill abi.ChainEpoch
+}
+
+// meta is the persisted range of the index.
+type meta struct {
+	Head types.TipSetKey
+	From abi.ChainEpoch
+}
+
+// Index records, at the height of every tipset of the indexed range, the
+// actors whose state differs between the parent state of the tipset and that
+// of its parent. The actor of an indexed state is then the last one recorded
+// at or below the height of its tipset.
+//
+// The indexed range goes from the tipset at From, whose parent state is the
+// base of the index and which records no change, up to the indexed head.
+type Index struct {
+	cs  *store.ChainStore
+	ds  datastore.Batching
+	cfg Config
+
+	lk   sync.RWMutex
+	head *types.TipSet
+	from abi.ChainEpoch
+}
+
+// New returns the index of the chain of cs, persisted in ds.
+func New(ctx context.Context, cs *store.ChainStore, ds datastore.Batching, cfg Config) (*Index, error) {
+	idx := &Index{
+		cs:  cs,
+		ds:  ds,
+		cfg: cfg,
+	}
+	if !cfg.Enable {
+		return idx, nil
+	}
+
+	data, err := ds.Get(ctx, metaKey)
+	switch err {
+	case nil:
+		var m meta
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, xerrors.Errorf("parsing actor index range: %w", err)
+		}
+		head, err := cs.LoadTipSet(ctx, m.Head)
+		if err != nil {
+			log.Warnw("indexed head not found, resetting the actor index", "head", m.Head, "error", err)
+			if err := idx.reset(ctx); err != nil {
+				return nil, err
+			}
+			return idx, nil
+		}
+		idx.head = head
+		idx.from = m.From
+	case datastore.ErrNotFound:
+	default:
+		return nil, xerrors.Errorf("loading actor index range: %w", err)
+	}
+
+	return idx, nil
+}
+
+// Enabled returns whether the index is enabled.
+func (idx *Index) Enabled() bool {
+	return idx.cfg.Enable
+}
+
+// Run keeps the index up to date with the head of the chain until ctx is
+// canceled.
+func (idx *Index) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		// the head change subscription is closed when lagging behind, catch
+		// up with the head then and subscribe again
+		for range idx.cs.SubHeadChanges(ctx) {
+			if err := idx.catchUp(ctx, idx.cs.GetHeaviestTipSet()); err != nil {
+				log.Errorw("updating actor index", "error", err)
+			}
+		}
+	}
+}
+
+// catchUp moves the indexed head to target, reverting the tipsets of the
+// indexed chain that aren't on that of target and applying those of target,
+// then extends the indexed range down towards the backfill height.
+func (idx *Index) catchUp(ctx context.Context, target *types.TipSet) error {
+	if target == nil {
+		return nil
+	}
+
+	idx.lk.RLock()
+	head := idx.head
+	idx.lk.RUnlock()
+
+	if head == nil {
+		if err := idx.start(ctx, target); err != nil {
+			return err
+		}
+	} else {
+		revert, apply, err := store.ReorgOps(ctx, idx.cs.LoadTipSet, head, target)
+		if err != nil {
+			return xerrors.Errorf("computing actor index reorg: %w", err)
+		}
+
+		for _, ts := range revert {
+			if ts.Height() <= idx.from {
+				log.Warnw("reverting below the indexed range, resetting the actor index", "height", ts.Height())
+				if err := idx.reset(ctx); err != nil {
+					return err
+				}
+				return idx.start(ctx, target)
+			}
+			if err := idx.revert(ctx, ts); err != nil {
+				return xerrors.Errorf("reverting tipset at %d: %w", ts.Height(), err)
+			}
+		}
+		for i := len(apply) - 1; i >= 0; i-- {
+			if err := idx.apply(ctx, apply[i]); err != nil {
+				return xerrors.Errorf("indexing tipset at %d: %w", apply[i].Height(), err)
+			}
+		}
+	}
+
+	return idx.backfill(ctx, backfillStep)
+}
+
+// start starts the index at ts, with an empty range.
+func (idx *Index) start(ctx context.Context, ts *types.TipSet) error {
+	if err := idx.ds.Put(ctx, tipsetKey(ts.Height()), ts.Key().Bytes()); err != nil {
+		return xerrors.Errorf("indexing tipset: %w", err)
+	}
+	return idx.setRange(ctx, ts, ts.Height())
+}
+
+// backfill indexes up to n tipsets below the indexed range, down to the
+// backfill height.
+func (idx *Index) backfill(ctx context.Context, n int) error {
+	idx.lk.RLock()
+	head, from := idx.head, idx.from
+	idx.lk.RUnlock()
+
+	if head == nil || idx.cfg.Backfill <= 0 {
+		return nil
+	}
+
+	stop := head.Height() - idx.cfg.Backfill
+	if stop < 0 {
+		stop = 0
+	}
+	if from <= stop {
+		return nil
+	}
+
+	ts, err := idx.cs.GetTipsetByHeight(ctx, from, head, false)
+	if err != nil {
+		return xerrors.Errorf("loading the first indexed tipset: %w", err)
+	}
+	for i := 0; i < n && ts.Height() > stop; i++ {
+		pts, err := idx.cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading the parent of the first indexed tipset: %w", err)
+		}
+
+		b, err := idx.ds.Batch(ctx)
+		if err != nil {
+			return err
+		}
+		if err := idx.diff(ctx, b, pts, ts); err != nil {
+			return xerrors.Errorf("backfilling tipset at %d: %w", ts.Height(), err)
+		}
+		if err := b.Put(ctx, tipsetKey(pts.Height()), pts.Key().Bytes()); err != nil {
+			return err
+		}
+		if err := b.Commit(ctx); err != nil {
+			return xerrors.Errorf("committing backfilled tipset: %w", err)
+		}
+
+		if err := idx.setRange(ctx, head, pts.Height()); err != nil {
+			return err
+		}
+		ts = pts
+	}
+
+	return nil
+}
+
+// apply indexes the tipset ts on top of the indexed head.
+func (idx *Index) apply(ctx context.Context, ts *types.TipSet) error {
+	pts, err := idx.cs.LoadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	b, err := idx.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := idx.diff(ctx, b, pts, ts); err != nil {
+		return err
+	}
+	if err := b.Put(ctx, tipsetKey(ts.Height()), ts.Key().Bytes()); err != nil {
+		return err
+	}
+	if err := b.Commit(ctx); err != nil {
+		return xerrors.Errorf("committing indexed tipset: %w", err)
+	}
+
+	idx.lk.RLock()
+	from := idx.from
+	idx.lk.RUnlock()
+	return idx.setRange(ctx, ts, from)
+}
+
+// diff records in b the changes of the actors between the parent states of
+// pts and of its child ts, at the height of ts.
+func (idx *Index) diff(ctx context.Context, b datastore.Batch, pts, ts *types.TipSet) error {
+	cst := cbor.NewCborStore(idx.cs.StateBlockstore())
+	oldTree, err := state.LoadStateTree(cst, pts.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading parent state tree: %w", err)
+	}
+	newTree, err := state.LoadStateTree(cst, ts.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	changes, err := state.DiffActors(ctx, oldTree, newTree)
+	if err != nil {
+		return xerrors.Errorf("diffing state trees: %w", err)
+	}
+
+	addrs := make([]address.Address, 0, len(changes))
+	for _, ch := range changes {
+		var value []byte
+		if ch.New != nil {
+			buf := new(bytes.Buffer)
+			if err := ch.New.MarshalCBOR(buf); err != nil {
+				return xerrors.Errorf("serializing actor %s: %w", ch.Address, err)
+			}
+			value = buf.Bytes()
+		}
+		if err := b.Put(ctx, actorKey(ch.Address, ts.Height()), value); err != nil {
+			return err
+		}
+		addrs = append(addrs, ch.Address)
+	}
+
+	data, err := json.Marshal(addrs)
+	if err != nil {
+		return err
+	}
+	return b.Put(ctx, changesKey(ts.Height()), data)
+}
+
+// revert removes the indexed head ts from the index.
+func (idx *Index) revert(ctx context.Context, ts *types.TipSet) error {
+	pts, err := idx.cs.LoadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	idx.lk.RLock()
+	from := idx.from
+	idx.lk.RUnlock()
+
+	// move the head first, so that no lookup reads the entries being removed
+	if err := idx.setRange(ctx, pts, from); err != nil {
+		return err
+	}
+
+	data, err := idx.ds.Get(ctx, changesKey(ts.Height()))
+	if err != nil {
+		return xerrors.Errorf("loading indexed changes: %w", err)
+	}
+	var addrs []address.Address
+	if err := json.Unmarshal(data, &addrs); err != nil {
+		return xerrors.Errorf("parsing indexed changes: %w", err)
+	}
+
+	b, err := idx.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := b.Delete(ctx, actorKey(addr, ts.Height())); err != nil {
+			return err
+		}
+	}
+	if err := b.Delete(ctx, changesKey(ts.Height())); err != nil {
+		return err
+	}
+	if err := b.Delete(ctx, tipsetKey(ts.Height())); err != nil {
+		return err
+	}
+	return b.Commit(ctx)
+}
+
+// reset empties the index.
+func (idx *Index) reset(ctx context.Context) error {
+	idx.lk.Lock()
+	idx.head = nil
+	idx.from = 0
+	idx.lk.Unlock()
+
+	res, err := idx.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return xerrors.Errorf("listing actor index: %w", err)
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return xerrors.Errorf("listing actor index: %w", err)
+	}
+
+	b, err := idx.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := b.Delete(ctx, datastore.NewKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	if err := b.Commit(ctx); err != nil {
+		return xerrors.Errorf("resetting actor index: %w", err)
+	}
+	return nil
+}
+
+// setRange persists and sets the indexed range.
+func (idx *Index) setRange(ctx context.Context, head *types.TipSet, from abi.ChainEpoch) error {
+	data, err := json.Marshal(meta{Head: head.Key(), From: from})
+	if err != nil {
+		return err
+	}
+	if err := idx.ds.Put(ctx, metaKey, data); err != nil {
+		return xerrors.Errorf("persisting actor index range: %w", err)
+	}
+
+	idx.lk.Lock()
+	idx.head = head
+	idx.from = from
+	idx.lk.Unlock()
+	return nil
+}
+
+// Lookup returns the actor with the ID address addr in the parent state of
+// ts. It returns false when the index can't answer, ts being out of the
+// indexed range or the actor not having changed within it; nil, true when the
+// actor doesn't exist in that state.
+func (idx *Index) Lookup(ctx context.Context, addr address.Address, ts *types.TipSet) (*types.Actor, bool, error) {
+	if !idx.cfg.Enable || addr.Protocol() != address.ID {
+		return nil, false, nil
+	}
+
+	idx.lk.RLock()
+	defer idx.lk.RUnlock()
+
+	if idx.head == nil || ts.Height() <= idx.from || ts.Height() > idx.head.Height() {
+		return nil, false, nil
+	}
+
+	indexed, err := idx.ds.Get(ctx, tipsetKey(ts.Height()))
+	switch err {
+	case nil:
+		if !bytes.Equal(indexed, ts.Key().Bytes()) {
+			// a tipset of a fork
+			return nil, false, nil
+		}
+	case datastore.ErrNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, xerrors.Errorf("loading indexed tipset: %w", err)
+	}
+
+	res, err := idx.ds.Query(ctx, query.Query{
+		Prefix: actorPrefix(addr),
+		Orders: []query.Order{query.OrderByKeyDescending{}},
+	})
+	if err != nil {
+		return nil, false, xerrors.Errorf("querying actor index: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, false, xerrors.Errorf("querying actor index: %w", r.Error)
+		}
+
+		h, err := entryHeight(r.Key)
+		if err != nil {
+			return nil, false, err
+		}
+		if h > ts.Height() {
+			continue
+		}
+		if h <= idx.from {
+			break
+		}
+
+		if len(r.Value) == 0 {
+			return nil, true, nil
+		}
+		var act types.Actor
+		if err := act.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+			return nil, false, xerrors.Errorf("parsing indexed actor %s: %w", addr, err)
+		}
+		return &act, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func tipsetKey(h abi.ChainEpoch) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("/tipsets/%020d", h))
+}
+
+func changesKey(h abi.ChainEpoch) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("/changes/%020d", h))
+}
+
+func actorPrefix(addr address.Address) string {
+	return "/actors/" + addr.String()
+}
+
+func actorKey(addr address.Address, h abi.ChainEpoch) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s/%020d", actorPrefix(addr), h))
+}
+
+func entryHeight(key string) (abi.ChainEpoch, error) {
+	h, err := strconv.ParseInt(key[strings.LastIndex(key, "/")+1:], 10, 64)
+	if err != nil {
+		return 0, xerrors.Errorf("parsing actor index key %s: %w", key, err)
+	}
+	return abi.ChainEpoch(h), nil
+}
@@ -0,0 +1,191 @@
+// stm: #unit
+package actorindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// mkState returns the root of a state tree holding actors with the balances
+// of balances.
+func mkState(ctx context.Context, t *testing.T, cst cbor.IpldStore, balances map[uint64]int64) cid.Cid {
+	st, err := state.NewStateTree(cst, types.StateTreeVersion4)
+	require.NoError(t, err)
+	code, err := abi.CidBuilder.Sum([]byte("actor"))
+	require.NoError(t, err)
+	for id, bal := range balances {
+		addr, err := address.NewIDAddress(id)
+		require.NoError(t, err)
+		require.NoError(t, st.SetActor(addr, &types.Actor{
+			Code:    code,
+			Head:    code,
+			Balance: types.NewInt(uint64(bal)),
+		}))
+	}
+	root, err := st.Flush(ctx)
+	require.NoError(t, err)
+	return root
+}
+
+// mkChild returns the child tipset of parent with the parent state root.
+func mkChild(ctx context.Context, t *testing.T, cs *store.ChainStore, parent *types.TipSet, root cid.Cid, nonce uint64) *types.TipSet {
+	blk := mock.MkBlock(parent, 1, nonce)
+	blk.ParentStateRoot = root
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	return mock.TipSet(blk)
+}
+
+func requireLookups(ctx context.Context, t *testing.T, idx *Index, cst cbor.IpldStore, ts *types.TipSet, ids ...uint64) {
+	tree, err := state.LoadStateTree(cst, ts.ParentState())
+	require.NoError(t, err)
+
+	for _, id := range ids {
+		addr, err := address.NewIDAddress(id)
+		require.NoError(t, err)
+
+		act, ok, err := idx.Lookup(ctx, addr, ts)
+		require.NoError(t, err)
+		if !ok {
+			continue
+		}
+
+		expected, err := tree.GetActor(addr)
+		if err != nil {
+			require.ErrorIs(t, err, types.ErrActorNotFound)
+			require.Nil(t, act, "actor %s at %d", addr, ts.Height())
+			continue
+		}
+		require.Equal(t, expected, act, "actor %s at %d", addr, ts.Height())
+	}
+}
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+	cst := cbor.NewCborStore(bs)
+
+	states := []map[uint64]int64{
+		{100: 1},
+		{100: 2, 101: 1},
+		{100: 2, 101: 5},
+		{100: 3},
+		{100: 3, 102: 1},
+	}
+
+	genBlk := mock.MkBlock(nil, 1, 1)
+	genBlk.ParentStateRoot = mkState(ctx, t, cst, states[0])
+	require.NoError(t, cs.PersistBlockHeaders(ctx, genBlk))
+	gen := mock.TipSet(genBlk)
+
+	chain := []*types.TipSet{gen}
+	for _, balances := range states[1:] {
+		chain = append(chain, mkChild(ctx, t, cs, chain[len(chain)-1], mkState(ctx, t, cst, balances), 1))
+	}
+	head := chain[len(chain)-1]
+
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	idx, err := New(ctx, cs, ds, Config{Enable: true, Backfill: 2})
+	require.NoError(t, err)
+
+	// started at the head, backfilled 2 epochs down
+	require.NoError(t, idx.catchUp(ctx, head))
+	require.Equal(t, head.Height()-2, idx.from)
+
+	// changed actors are found, unchanged ones fall back
+	a100, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+	act, ok, err := idx.Lookup(ctx, a100, head)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, types.NewInt(3), act.Balance)
+
+	a101, err := address.NewIDAddress(101)
+	require.NoError(t, err)
+	act, ok, err = idx.Lookup(ctx, a101, head)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, act)
+
+	_, ok, err = idx.Lookup(ctx, a100, chain[1])
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	for _, ts := range chain {
+		requireLookups(ctx, t, idx, cst, ts, 100, 101, 102, 103)
+	}
+
+	// reorg to a longer fork replacing the last 2 tipsets
+	fork := mkChild(ctx, t, cs, chain[2], mkState(ctx, t, cst, map[uint64]int64{100: 7, 101: 5}), 2)
+	fork = mkChild(ctx, t, cs, fork, mkState(ctx, t, cst, map[uint64]int64{100: 7, 101: 6, 103: 1}), 2)
+	fork = mkChild(ctx, t, cs, fork, mkState(ctx, t, cst, map[uint64]int64{100: 8, 101: 6, 103: 1}), 2)
+	require.NoError(t, idx.catchUp(ctx, fork))
+	require.True(t, idx.head.Equals(fork))
+
+	act, ok, err = idx.Lookup(ctx, a101, fork)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, types.NewInt(6), act.Balance)
+
+	// the tipsets of the reverted chain aren't answered from the index
+	_, ok, err = idx.Lookup(ctx, a100, head)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	for ts := fork; ts.Height() > 0; {
+		requireLookups(ctx, t, idx, cst, ts, 100, 101, 102, 103)
+		ts, err = cs.LoadTipSet(ctx, ts.Parents())
+		require.NoError(t, err)
+	}
+
+	// the range is reloaded from the datastore
+	idx, err = New(ctx, cs, ds, Config{Enable: true, Backfill: 2})
+	require.NoError(t, err)
+	require.True(t, idx.head.Equals(fork))
+	require.Equal(t, head.Height()-2, idx.from)
+
+	// reverting below the indexed range resets the index
+	require.NoError(t, idx.catchUp(ctx, chain[1]))
+	require.True(t, idx.head.Equals(chain[1]))
+	for _, ts := range chain[:2] {
+		requireLookups(ctx, t, idx, cst, ts, 100, 101, 102, 103)
+	}
+	_, ok, err = idx.Lookup(ctx, a101, fork)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestIndexDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	idx, err := New(ctx, cs, datastore.NewMapDatastore(), Config{Backfill: abi.ChainEpoch(10)})
+	require.NoError(t, err)
+	require.False(t, idx.Enabled())
+
+	addr, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+	_, ok, err := idx.Lookup(ctx, addr, mock.TipSet(mock.MkBlock(nil, 1, 1)))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
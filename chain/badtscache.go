@@ -1,16 +1,48 @@
 package chain
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 
 	"github.com/filecoin-project/lotus/build"
 )
 
+// BadBlockTTL is how long the blocks found invalid by the syncer stay marked
+// bad; zero keeps them until they are evicted or unmarked. Blocks marked bad
+// manually never expire. It is set with the LOTUS_BAD_BLOCK_TTL env var.
+var BadBlockTTL = 7 * 24 * time.Hour
+
+func init() {
+	if ttl := os.Getenv("LOTUS_BAD_BLOCK_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < 0 {
+			log.Errorf("failed to parse 'LOTUS_BAD_BLOCK_TTL' env var %q: must be a non-negative duration", ttl)
+		} else {
+			BadBlockTTL = d
+		}
+	}
+}
+
+var badBlocksPrefix = datastore.NewKey("/chain/badblocks")
+
+func badBlockKey(c cid.Cid) datastore.Key {
+	return badBlocksPrefix.ChildString(c.String())
+}
+
+// BadBlockCache tracks the blocks known to be invalid. The entries are kept
+// in the metadata datastore, so that they survive restarts, and expire after
+// their TTL.
 type BadBlockCache struct {
-	badBlocks *lru.ARCCache
+	ds        datastore.Batching
+	badBlocks *lru.Cache
 }
 
 type BadBlockReason struct {
@@ -19,6 +51,19 @@ type BadBlockReason struct {
 	OriginalReason *BadBlockReason
 }
 
+// BadBlockEntry is a block in the bad block cache.
+type BadBlockEntry struct {
+	Reason BadBlockReason
+	// Added is when the block was marked bad.
+	Added time.Time
+	// Expires is when the block stops being marked bad, zero for never.
+	Expires time.Time
+}
+
+func (e BadBlockEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
 func NewBadBlockReason(cid []cid.Cid, format string, i ...interface{}) BadBlockReason {
 	return BadBlockReason{
 		TipSet: cid,
@@ -42,19 +87,102 @@ func (bbr BadBlockReason) String() string {
 	return res
 }
 
-func NewBadBlockCache() *BadBlockCache {
-	cache, err := lru.NewARC(build.BadBlockCacheSize)
+// NewBadBlockCache returns a bad block cache persisted in ds, loading the
+// entries saved in it. A nil ds keeps the cache in memory.
+func NewBadBlockCache(ds datastore.Batching) *BadBlockCache {
+	bts := &BadBlockCache{ds: ds}
+
+	// the entries evicted, removed or purged are deleted from the datastore
+	cache, err := lru.NewWithEvict(build.BadBlockCacheSize, func(key interface{}, _ interface{}) {
+		bts.deleteEntry(key.(cid.Cid))
+	})
 	if err != nil {
 		panic(err) // ok
 	}
+	bts.badBlocks = cache
 
-	return &BadBlockCache{
-		badBlocks: cache,
+	if ds != nil {
+		if err := bts.load(context.TODO()); err != nil {
+			log.Errorw("loading bad block cache", "error", err)
+		}
 	}
+	return bts
 }
 
+// load adds the unexpired entries of the datastore to the cache, deleting the
+// expired ones.
+func (bts *BadBlockCache) load(ctx context.Context) error {
+	res, err := bts.ds.Query(ctx, query.Query{Prefix: badBlocksPrefix.String()})
+	if err != nil {
+		return err
+	}
+	defer res.Close() //nolint:errcheck
+
+	now := build.Clock.Now()
+	var loaded, expired int
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+
+		c, err := cid.Decode(datastore.RawKey(r.Key).BaseNamespace())
+		if err != nil {
+			log.Warnw("invalid bad block cache key", "key", r.Key, "error", err)
+			continue
+		}
+		var e BadBlockEntry
+		if err := json.Unmarshal(r.Value, &e); err != nil {
+			log.Warnw("invalid bad block cache entry", "cid", c, "error", err)
+			continue
+		}
+
+		if e.expired(now) {
+			bts.deleteEntry(c)
+			expired++
+			continue
+		}
+		bts.badBlocks.Add(c, e)
+		loaded++
+	}
+
+	log.Infow("loaded bad block cache", "blocks", loaded, "expired", expired)
+	return nil
+}
+
+func (bts *BadBlockCache) deleteEntry(c cid.Cid) {
+	if bts.ds == nil {
+		return
+	}
+	if err := bts.ds.Delete(context.TODO(), badBlockKey(c)); err != nil {
+		log.Errorw("deleting bad block cache entry", "cid", c, "error", err)
+	}
+}
+
+// Add marks the block c bad for BadBlockTTL.
 func (bts *BadBlockCache) Add(c cid.Cid, bbr BadBlockReason) {
-	bts.badBlocks.Add(c, bbr)
+	bts.AddWithTTL(c, bbr, BadBlockTTL)
+}
+
+// AddWithTTL marks the block c bad for ttl, or until it is unmarked if ttl is
+// zero.
+func (bts *BadBlockCache) AddWithTTL(c cid.Cid, bbr BadBlockReason, ttl time.Duration) {
+	e := BadBlockEntry{Reason: bbr, Added: build.Clock.Now()}
+	if ttl > 0 {
+		e.Expires = e.Added.Add(ttl)
+	}
+	bts.badBlocks.Add(c, e)
+
+	if bts.ds == nil {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Errorw("marshaling bad block cache entry", "cid", c, "error", err)
+		return
+	}
+	if err := bts.ds.Put(context.TODO(), badBlockKey(c), data); err != nil {
+		log.Errorw("persisting bad block cache entry", "cid", c, "error", err)
+	}
 }
 
 func (bts *BadBlockCache) Remove(c cid.Cid) {
@@ -66,10 +194,37 @@ func (bts *BadBlockCache) Purge() {
 }
 
 func (bts *BadBlockCache) Has(c cid.Cid) (BadBlockReason, bool) {
+	e, ok := bts.Get(c)
+	return e.Reason, ok
+}
+
+// Get returns the entry of the block c, if it is marked bad.
+func (bts *BadBlockCache) Get(c cid.Cid) (BadBlockEntry, bool) {
 	rval, ok := bts.badBlocks.Get(c)
 	if !ok {
-		return BadBlockReason{}, false
+		return BadBlockEntry{}, false
 	}
 
-	return rval.(BadBlockReason), true
+	e := rval.(BadBlockEntry)
+	if e.expired(build.Clock.Now()) {
+		bts.badBlocks.Remove(c)
+		return BadBlockEntry{}, false
+	}
+	return e, true
+}
+
+// List returns the unexpired entries of the cache, by block.
+func (bts *BadBlockCache) List() map[cid.Cid]BadBlockEntry {
+	now := build.Clock.Now()
+	out := make(map[cid.Cid]BadBlockEntry, bts.badBlocks.Len())
+	for _, k := range bts.badBlocks.Keys() {
+		rval, ok := bts.badBlocks.Peek(k)
+		if !ok {
+			continue
+		}
+		if e := rval.(BadBlockEntry); !e.expired(now) {
+			out[k.(cid.Cid)] = e
+		}
+	}
+	return out
 }
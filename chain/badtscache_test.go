@@ -0,0 +1,56 @@
+// stm: #unit
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestBadBlockCachePersistence(t *testing.T) {
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	bad := NewBadBlockCache(ds)
+
+	expiring := mock.MkBlock(genTs, 1, 1).Cid()
+	manual := mock.MkBlock(genTs, 1, 2).Cid()
+	removed := mock.MkBlock(genTs, 1, 3).Cid()
+	bad.AddWithTTL(expiring, NewBadBlockReason(nil, "invalid"), time.Hour)
+	bad.AddWithTTL(manual, NewBadBlockReason(nil, "manually marked bad"), 0)
+	bad.Add(removed, NewBadBlockReason(nil, "invalid"))
+	bad.Remove(removed)
+
+	// the entries are reloaded on restart
+	bad = NewBadBlockCache(ds)
+	require.Len(t, bad.List(), 2)
+	e, ok := bad.Get(expiring)
+	require.True(t, ok)
+	require.Equal(t, "invalid", e.Reason.Reason)
+	require.True(t, mc.Now().Add(time.Hour).Equal(e.Expires))
+	_, ok = bad.Has(removed)
+	require.False(t, ok)
+
+	// until they expire
+	mc.Add(time.Hour)
+	_, ok = bad.Has(expiring)
+	require.False(t, ok)
+	_, ok = bad.Has(manual)
+	require.True(t, ok)
+
+	bad = NewBadBlockCache(ds)
+	require.Len(t, bad.List(), 1)
+
+	bad.Purge()
+	require.Empty(t, NewBadBlockCache(ds).List())
+}
@@ -53,7 +53,14 @@ func NewActorRegistry() *vm.ActorRegistry {
 	return inv
 }
 
-type TipSetExecutor struct{}
+type TipSetExecutor struct {
+	// ParallelWorkers is the number of groups of the messages of a block
+	// executed at once, see applyParallel. Only the legacy VM, which executes
+	// the tipsets before network version 16, executes messages in parallel;
+	// the FVM always executes them one at a time. Parallel execution is
+	// disabled below 2, the default.
+	ParallelWorkers int
+}
 
 func NewTipSetExecutor() *TipSetExecutor {
 	return &TipSetExecutor{}
@@ -89,23 +96,33 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 	}()
 
 	ctx = blockstore.WithHotView(ctx)
-	makeVmWithBaseStateAndEpoch := func(base cid.Cid, e abi.ChainEpoch) (vm.Interface, error) {
-		vmopt := &vm.VMOpts{
+	makeVmOpts := func(base cid.Cid, e abi.ChainEpoch, circ vm.CircSupplyCalculator) *vm.VMOpts {
+		return &vm.VMOpts{
 			StateBase:      base,
 			Epoch:          e,
 			Rand:           r,
 			Bstore:         sm.ChainStore().StateBlockstore(),
 			Actors:         NewActorRegistry(),
 			Syscalls:       sm.Syscalls,
-			CircSupplyCalc: sm.GetVMCirculatingSupply,
+			CircSupplyCalc: circ,
 			NetworkVersion: sm.GetNetworkVersion(ctx, e),
 			BaseFee:        baseFee,
 			LookbackState:  stmgr.LookbackStateGetterForTipset(sm, ts),
 			TipSetGetter:   stmgr.TipSetGetterForTipset(sm.ChainStore(), ts),
 			Tracing:        vmTracing,
 		}
-
-		return sm.VMConstructor()(ctx, vmopt)
+	}
+	vmm, _ := em.(stmgr.VMMonitor)
+	makeVmWithBaseStateAndEpoch := func(base cid.Cid, e abi.ChainEpoch) (vm.Interface, error) {
+		opts := makeVmOpts(base, e, sm.GetVMCirculatingSupply)
+		if vmm != nil {
+			vmm.ConfigureVM(opts)
+		}
+		return sm.VMConstructor()(ctx, opts)
+	}
+	// the VMs of the groups of messages executed in parallel
+	makeGroupVm := func(base cid.Cid, circ vm.CircSupplyCalculator) (*vm.LegacyVM, error) {
+		return vm.NewLegacyVM(ctx, makeVmOpts(base, epoch, circ))
 	}
 
 	runCron := func(vmCron vm.Interface, epoch abi.ChainEpoch) error {
@@ -178,26 +195,44 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 		penalty := types.NewInt(0)
 		gasReward := big.Zero()
 
+		var msgs []types.ChainMsg
 		for _, cm := range append(b.BlsMessages, b.SecpkMessages...) {
 			m := cm.VMMessage()
 			if _, found := processedMsgs[m.Cid()]; found {
 				continue
 			}
-			r, err := vmi.ApplyMessage(ctx, cm)
+			msgs = append(msgs, cm)
+			processedMsgs[m.Cid()] = struct{}{}
+		}
+
+		var rets []*vm.ApplyRet
+		applied := false
+		if lvm, ok := vmi.(*vm.LegacyVM); ok && t.ParallelWorkers > 1 && vmm == nil {
+			rets, applied, err = applyParallel(ctx, lvm, msgs, t.ParallelWorkers, makeGroupVm, sm.GetVMCirculatingSupply)
 			if err != nil {
-				return cid.Undef, cid.Undef, err
+				return cid.Undef, cid.Undef, xerrors.Errorf("applying messages in parallel: %w", err)
+			}
+		}
+		if !applied {
+			rets = make([]*vm.ApplyRet, len(msgs))
+			for i, cm := range msgs {
+				rets[i], err = vmi.ApplyMessage(ctx, cm)
+				if err != nil {
+					return cid.Undef, cid.Undef, err
+				}
 			}
+		}
 
+		for i, r := range rets {
 			receipts = append(receipts, &r.MessageReceipt)
 			gasReward = big.Add(gasReward, r.GasCosts.MinerTip)
 			penalty = big.Add(penalty, r.GasCosts.MinerPenalty)
 
 			if em != nil {
-				if err := em.MessageApplied(ctx, ts, cm.Cid(), m, r, false); err != nil {
+				if err := em.MessageApplied(ctx, ts, msgs[i].Cid(), msgs[i].VMMessage(), r, false); err != nil {
 					return cid.Undef, cid.Undef, err
 				}
 			}
-			processedMsgs[m.Cid()] = struct{}{}
 		}
 
 		params, err := actors.SerializeParams(&reward.AwardBlockRewardParams{
@@ -0,0 +1,302 @@
+package filcns
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// feeActors receive the fees of every message. Messages only ever change
+// their balance, so the changes of the groups add up.
+var feeActors = []address.Address{reward.Address, builtin.BurntFundsActorAddr}
+
+func isFeeActor(a address.Address) bool {
+	for _, fa := range feeActors {
+		if a == fa {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionMessages groups the messages by the actors they are sent from and
+// to, resolved with resolve: the messages touching the same actor, the fee
+// actors aside, are in the same group. Groups are ordered by their first
+// message and keep the order of their messages.
+func partitionMessages(msgs []types.ChainMsg, resolve func(address.Address) address.Address) [][]int {
+	parent := make([]int, len(msgs))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	owner := make(map[address.Address]int)
+	for i, cm := range msgs {
+		m := cm.VMMessage()
+		for _, a := range []address.Address{m.From, m.To} {
+			a = resolve(a)
+			if isFeeActor(a) {
+				continue
+			}
+			j, ok := owner[a]
+			if !ok {
+				owner[a] = i
+				continue
+			}
+			// the first message of a group is its root
+			ri, rj := find(i), find(j)
+			if ri < rj {
+				parent[rj] = ri
+			} else {
+				parent[ri] = rj
+			}
+		}
+	}
+
+	var groups [][]int
+	index := make(map[int]int)
+	for i := range msgs {
+		r := find(i)
+		g, ok := index[r]
+		if !ok {
+			g = len(groups)
+			index[r] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// execGroup is a group of messages executed on a VM of its own.
+type execGroup struct {
+	msgs []int
+
+	vm   *vm.LegacyVM
+	tree *state.StateTree
+	rets []*vm.ApplyRet
+
+	reads  map[address.Address]struct{}
+	writes map[address.Address]struct{}
+	// dynamic is set when a message computed the circulating supply from the
+	// state of the group
+	dynamic bool
+}
+
+func (g *execGroup) execute(ctx context.Context, msgs []types.ChainMsg, base cid.Cid, baseCirc abi.TokenAmount,
+	newVM func(base cid.Cid, circ vm.CircSupplyCalculator) (*vm.LegacyVM, error), circ vm.CircSupplyCalculator) error {
+	constructed := false
+	gvm, err := newVM(base, func(ctx context.Context, e abi.ChainEpoch, st *state.StateTree) (abi.TokenAmount, error) {
+		if !constructed {
+			constructed = true
+			return baseCirc, nil
+		}
+		g.dynamic = true
+		return circ(ctx, e, st)
+	})
+	if err != nil {
+		return xerrors.Errorf("making vm: %w", err)
+	}
+	tree, ok := gvm.StateTree().(*state.StateTree)
+	if !ok {
+		return xerrors.Errorf("unexpected state tree type %T", gvm.StateTree())
+	}
+
+	g.reads, g.writes = make(map[address.Address]struct{}), make(map[address.Address]struct{})
+	tree.TrackAccess(func(a address.Address, write bool) {
+		if write {
+			g.writes[a] = struct{}{}
+		} else {
+			g.reads[a] = struct{}{}
+		}
+	})
+	for _, i := range g.msgs {
+		r, err := gvm.ApplyMessage(ctx, msgs[i])
+		if err != nil {
+			return err
+		}
+		g.rets = append(g.rets, r)
+	}
+	tree.TrackAccess(nil)
+
+	g.vm, g.tree = gvm, tree
+	return nil
+}
+
+// independent reports whether no group read or wrote an actor written by
+// another one, the fee actors aside.
+func independent(groups []*execGroup) bool {
+	writer := make(map[address.Address]int)
+	for i, g := range groups {
+		for a := range g.writes {
+			if isFeeActor(a) {
+				continue
+			}
+			if j, ok := writer[a]; ok && j != i {
+				return false
+			}
+			writer[a] = i
+		}
+	}
+	for i, g := range groups {
+		for a := range g.reads {
+			if j, ok := writer[a]; ok && j != i {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// applyParallel applies the messages to lvm as if they were applied one after
+// the other, executing the groups of messages of independent actors at once,
+// on VMs of their own built by newVM from the state of lvm. The actors read
+// and written by every group are tracked, and the groups are only merged into
+// lvm when they turn out to be independent, and only changed the balance of
+// the fee actors. Otherwise, as when a message computes the circulating
+// supply, which is computed with circ from the whole state before network
+// version 15, it returns false, leaving the state of lvm as it was, for the
+// messages to be applied serially.
+func applyParallel(ctx context.Context, lvm *vm.LegacyVM, msgs []types.ChainMsg, workers int,
+	newVM func(base cid.Cid, circ vm.CircSupplyCalculator) (*vm.LegacyVM, error), circ vm.CircSupplyCalculator) ([]*vm.ApplyRet, bool, error) {
+	main, ok := lvm.StateTree().(*state.StateTree)
+	if !ok {
+		return nil, false, nil
+	}
+	groups := partitionMessages(msgs, func(a address.Address) address.Address {
+		if id, err := main.LookupID(a); err == nil {
+			return id
+		}
+		return a
+	})
+	if len(groups) < 2 {
+		return nil, false, nil
+	}
+
+	base, err := lvm.Flush(ctx)
+	if err != nil {
+		return nil, false, xerrors.Errorf("flushing vm: %w", err)
+	}
+	baseCirc, err := lvm.GetCircSupply(ctx)
+	if err != nil {
+		return nil, false, xerrors.Errorf("getting circulating supply: %w", err)
+	}
+
+	eg := make([]*execGroup, len(groups))
+	errs := make([]error, len(groups))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, idx := range groups {
+		i, g := i, &execGroup{msgs: idx}
+		eg[i] = g
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = g.execute(ctx, msgs, base, baseCirc, newVM, circ)
+		}()
+	}
+	wg.Wait()
+
+	for i, g := range eg {
+		if errs[i] != nil {
+			log.Debugw("parallel execution failed, applying the messages serially", "error", errs[i])
+			return nil, false, nil
+		}
+		if g.dynamic {
+			return nil, false, nil
+		}
+	}
+	if !independent(eg) {
+		return nil, false, nil
+	}
+
+	balances := make(map[address.Address]abi.TokenAmount, len(feeActors))
+	for _, a := range feeActors {
+		pre, err := main.GetActor(a)
+		if err != nil {
+			return nil, false, xerrors.Errorf("loading fee actor %s: %w", a, err)
+		}
+		balance := pre.Balance
+		for _, g := range eg {
+			if _, ok := g.writes[a]; !ok {
+				continue
+			}
+			post, err := g.tree.GetActor(a)
+			if err != nil || post.Code != pre.Code || post.Head != pre.Head || post.Nonce != pre.Nonce {
+				return nil, false, nil
+			}
+			balance = big.Add(balance, big.Sub(post.Balance, pre.Balance))
+		}
+		balances[a] = balance
+	}
+
+	// merge the groups
+	for _, g := range eg {
+		if _, err := g.vm.Flush(ctx); err != nil {
+			return nil, false, xerrors.Errorf("flushing group vm: %w", err)
+		}
+		for a := range g.writes {
+			if isFeeActor(a) {
+				continue
+			}
+			act, err := g.tree.GetActor(a)
+			if xerrors.Is(err, types.ErrActorNotFound) {
+				// deleted, or created and deleted by the group
+				if _, err := main.GetActor(a); xerrors.Is(err, types.ErrActorNotFound) {
+					continue
+				} else if err != nil {
+					return nil, false, xerrors.Errorf("loading actor %s: %w", a, err)
+				}
+				if err := main.DeleteActor(a); err != nil {
+					return nil, false, xerrors.Errorf("deleting actor %s: %w", a, err)
+				}
+				continue
+			}
+			if err != nil {
+				return nil, false, xerrors.Errorf("loading actor %s of group: %w", a, err)
+			}
+			if err := main.SetActor(a, act); err != nil {
+				return nil, false, xerrors.Errorf("setting actor %s: %w", a, err)
+			}
+		}
+	}
+	for a, balance := range balances {
+		if err := main.MutateActor(a, func(act *types.Actor) error {
+			act.Balance = balance
+			return nil
+		}); err != nil {
+			return nil, false, xerrors.Errorf("setting balance of fee actor %s: %w", a, err)
+		}
+	}
+
+	rets := make([]*vm.ApplyRet, len(msgs))
+	for _, g := range eg {
+		for j, i := range g.msgs {
+			rets[i] = g.rets[j]
+		}
+	}
+	return rets, true, nil
+}
@@ -0,0 +1,123 @@
+// stm: #integration
+package filcns_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/types"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+func init() {
+	policy.SetSupportedProofTypes(abi.RegisteredSealProof_StackedDrg2KiBV1)
+	policy.SetConsensusMinerMinPower(abi.NewStoragePower(2048))
+	policy.SetMinVerifiedDealSize(abi.NewStoragePower(256))
+}
+
+func TestParallelExecution(t *testing.T) {
+	ctx := context.Background()
+
+	// the generated chain stays at the genesis network version, executed by
+	// the legacy VM
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	w := cg.Wallet()
+	nonces := make(map[address.Address]uint64)
+	send := func(from, to address.Address, value abi.TokenAmount) *types.SignedMessage {
+		m := &types.Message{
+			From:       from,
+			To:         to,
+			Value:      value,
+			Nonce:      nonces[from],
+			GasLimit:   types.TestGasLimit,
+			GasFeeCap:  abi.NewTokenAmount(1e9),
+			GasPremium: abi.NewTokenAmount(1e3),
+		}
+		nonces[from]++
+
+		sig, err := w.WalletSign(ctx, from, m.Cid().Bytes(), api.MsgMeta{})
+		require.NoError(t, err)
+		return &types.SignedMessage{Message: *m, Signature: *sig}
+	}
+	mine := func(msgs ...*types.SignedMessage) *types.TipSet {
+		// every block includes the messages, only executed in the first one
+		bmsgs := make([][]*types.SignedMessage, len(cg.Miners))
+		for i := range bmsgs {
+			bmsgs[i] = msgs
+		}
+		fts, err := cg.NextTipSetFromMinersWithMessagesAndNulls(cg.CurTipset.TipSet(), cg.Miners, bmsgs, 0)
+		require.NoError(t, err)
+		return fts.TipSet()
+	}
+	execute := func(ts *types.TipSet, workers int) (cid.Cid, cid.Cid) {
+		exec := &filcns.TipSetExecutor{ParallelWorkers: workers}
+		st, rec, err := exec.ExecuteTipSet(ctx, cg.StateManager(), ts, nil, false)
+		require.NoError(t, err)
+		return st, rec
+	}
+
+	accts := make([]address.Address, 6)
+	for i := range accts {
+		accts[i], err = w.WalletNew(ctx, types.KTSecp256k1)
+		require.NoError(t, err)
+	}
+	a, b, c, d, e, f := accts[0], accts[1], accts[2], accts[3], accts[4], accts[5]
+
+	// the funding of the accounts, all sent by the banker
+	var funding []*types.SignedMessage
+	for _, to := range []address.Address{a, b, c, d} {
+		funding = append(funding, send(cg.Banker(), to, types.FromFil(10)))
+	}
+	mine(funding...)
+
+	// two groups of independent actors, the second one sending back and
+	// forth, merged after their parallel execution
+	independent := mine(
+		send(a, b, types.FromFil(1)),
+		send(c, d, types.FromFil(1)),
+		send(d, c, types.FromFil(2)),
+		send(a, b, types.FromFil(1)),
+	)
+	// two groups sharing senders and receivers within them, which both
+	// create an account, writing the init actor, and are applied serially
+	conflicting := mine(
+		send(a, e, types.FromFil(1)),
+		send(c, f, types.FromFil(1)),
+		send(b, a, types.FromFil(1)),
+		send(d, c, types.FromFil(1)),
+		send(a, b, types.FromFil(1)),
+	)
+
+	for name, ts := range map[string]*types.TipSet{"independent": independent, "conflicting": conflicting} {
+		ts := ts
+		t.Run(name, func(t *testing.T) {
+			st, rec := execute(ts, 1)
+			pst, prec := execute(ts, 4)
+			require.Equal(t, st, pst, "state root")
+			require.Equal(t, rec, prec, "receipts root")
+		})
+	}
+
+	// the messages were executed, creating the new accounts
+	st, _ := execute(conflicting, 4)
+	tree, err := cg.StateManager().StateTree(st)
+	require.NoError(t, err)
+	for _, addr := range []address.Address{e, f} {
+		act, err := tree.GetActor(addr)
+		require.NoError(t, err)
+		require.Equal(t, types.FromFil(1), act.Balance)
+	}
+}
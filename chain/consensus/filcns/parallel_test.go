@@ -0,0 +1,45 @@
+// stm: #unit
+package filcns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestPartitionMessages(t *testing.T) {
+	// 200 is a robust address of 101
+	alias := mock.Address(200)
+	resolve := func(a address.Address) address.Address {
+		if a == alias {
+			return mock.Address(101)
+		}
+		return a
+	}
+	msg := func(from, to address.Address) types.ChainMsg {
+		return &types.Message{From: from, To: to}
+	}
+
+	msgs := []types.ChainMsg{
+		msg(mock.Address(100), mock.Address(101)),
+		msg(mock.Address(102), mock.Address(103)),
+		msg(mock.Address(104), builtin.BurntFundsActorAddr),
+		msg(mock.Address(105), alias),
+		msg(mock.Address(106), reward.Address),
+		msg(mock.Address(103), mock.Address(107)),
+	}
+	require.Equal(t, [][]int{{0, 3}, {1, 5}, {2}, {4}}, partitionMessages(msgs, resolve))
+
+	// joining two groups
+	msgs = append(msgs, msg(mock.Address(107), mock.Address(100)))
+	require.Equal(t, [][]int{{0, 1, 3, 5, 6}, {2}, {4}}, partitionMessages(msgs, resolve))
+
+	require.Empty(t, partitionMessages(nil, resolve))
+}
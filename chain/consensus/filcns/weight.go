@@ -9,6 +9,7 @@ import (
 
 	big2 "github.com/filecoin-project/go-state-types/big"
 
+	"github.com/filecoin-project/lotus/api"
 	bstore "github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
@@ -23,9 +24,22 @@ func Weight(ctx context.Context, stateBs bstore.Blockstore, ts *types.TipSet) (t
 	if ts == nil {
 		return types.NewInt(0), nil
 	}
+
+	wc, err := WeightComponents(ctx, stateBs, ts)
+	if err != nil {
+		return types.EmptyInt, err
+	}
+	return wc.Weight, nil
+}
+
+// WeightComponents computes the weight of ts along with the terms it adds up.
+func WeightComponents(ctx context.Context, stateBs bstore.Blockstore, ts *types.TipSet) (api.TipSetWeightComponents, error) {
+	var wc api.TipSetWeightComponents
+
 	// >>> w[r] <<< + wFunction(totalPowerAtTipset(ts)) * 2^8 + (wFunction(totalPowerAtTipset(ts)) * sum(ts.blocks[].ElectionProof.WinCount) * wRatio_num * 2^8) / (e * wRatio_den)
 
 	var out = new(big.Int).Set(ts.ParentWeight().Int)
+	wc.ParentWeight = ts.ParentWeight()
 
 	// >>> wFunction(totalPowerAtTipset(ts)) * 2^8 <<< + (wFunction(totalPowerAtTipset(ts)) * sum(ts.blocks[].ElectionProof.WinCount) * wRatio_num * 2^8) / (e * wRatio_den)
 
@@ -34,36 +48,40 @@ func Weight(ctx context.Context, stateBs bstore.Blockstore, ts *types.TipSet) (t
 		cst := cbor.NewCborStore(stateBs)
 		state, err := state.LoadStateTree(cst, ts.ParentState())
 		if err != nil {
-			return types.NewInt(0), xerrors.Errorf("load state tree: %w", err)
+			return wc, xerrors.Errorf("load state tree: %w", err)
 		}
 
 		act, err := state.GetActor(power.Address)
 		if err != nil {
-			return types.NewInt(0), xerrors.Errorf("get power actor: %w", err)
+			return wc, xerrors.Errorf("get power actor: %w", err)
 		}
 
 		powState, err := power.Load(store.ActorStore(ctx, stateBs), act)
 		if err != nil {
-			return types.NewInt(0), xerrors.Errorf("failed to load power actor state: %w", err)
+			return wc, xerrors.Errorf("failed to load power actor state: %w", err)
 		}
 
 		claim, err := powState.TotalPower()
 		if err != nil {
-			return types.NewInt(0), xerrors.Errorf("failed to get total power: %w", err)
+			return wc, xerrors.Errorf("failed to get total power: %w", err)
 		}
 
 		tpow = claim.QualityAdjPower // TODO: REVIEW: Is this correct?
 	}
+	wc.TotalPower = tpow
 
 	log2P := int64(0)
 	if tpow.GreaterThan(zero) {
 		log2P = int64(tpow.BitLen() - 1)
 	} else {
 		// Not really expect to be here ...
-		return types.EmptyInt, xerrors.Errorf("All power in the net is gone. You network might be disconnected, or the net is dead!")
+		return wc, xerrors.Errorf("All power in the net is gone. You network might be disconnected, or the net is dead!")
 	}
+	wc.Log2Power = log2P
 
-	out.Add(out, big.NewInt(log2P<<8))
+	powTerm := big.NewInt(log2P << 8)
+	out.Add(out, powTerm)
+	wc.PowerTerm = types.BigInt{Int: powTerm}
 
 	// (wFunction(totalPowerAtTipset(ts)) * sum(ts.blocks[].ElectionProof.WinCount) * wRatio_num * 2^8) / (e * wRatio_den)
 
@@ -71,13 +89,16 @@ func Weight(ctx context.Context, stateBs bstore.Blockstore, ts *types.TipSet) (t
 	for _, b := range ts.Blocks() {
 		totalJ += b.ElectionProof.WinCount
 	}
+	wc.WinCount = totalJ
 
 	eWeight := big.NewInt((log2P * build.WRatioNum))
 	eWeight = eWeight.Lsh(eWeight, 8)
 	eWeight = eWeight.Mul(eWeight, new(big.Int).SetInt64(totalJ))
 	eWeight = eWeight.Div(eWeight, big.NewInt(int64(build.BlocksPerEpoch*build.WRatioDen)))
+	wc.WinCountTerm = types.BigInt{Int: eWeight}
 
 	out = out.Add(out, eWeight)
+	wc.Weight = types.BigInt{Int: out}
 
-	return types.BigInt{Int: out}, nil
+	return wc, nil
 }
@@ -13,6 +13,11 @@ import (
 
 type Consensus interface {
 	ValidateBlock(ctx context.Context, b *types.FullBlock) (err error)
+	// ValidateBlockHeader runs the checks of ValidateBlock that only need the
+	// header of the block and the state of its parent, such as the signatures,
+	// the tickets, the election and the weight. Neither the messages of the
+	// block nor the execution of its parent are checked.
+	ValidateBlockHeader(ctx context.Context, h *types.BlockHeader) (err error)
 	ValidateBlockPubsub(ctx context.Context, self bool, msg *pubsub.Message) (pubsub.ValidationResult, string)
 	IsEpochBeyondCurrMax(epoch abi.ChainEpoch) bool
 
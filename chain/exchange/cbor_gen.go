@@ -272,6 +272,176 @@ func (t *Response) UnmarshalCBOR(r io.Reader) (err error) {
 	return nil
 }
 
+var lengthBufBatchRequest = []byte{129}
+
+func (t *BatchRequest) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write(lengthBufBatchRequest); err != nil {
+		return err
+	}
+
+	// t.Requests ([]*exchange.Request) (slice)
+	if len(t.Requests) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Requests was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Requests))); err != nil {
+		return err
+	}
+	for _, v := range t.Requests {
+		if err := v.MarshalCBOR(cw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *BatchRequest) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = BatchRequest{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Requests ([]*exchange.Request) (slice)
+
+	maj, extra, err = cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Requests: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Requests = make([]*Request, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v Request
+		if err := v.UnmarshalCBOR(cr); err != nil {
+			return err
+		}
+
+		t.Requests[i] = &v
+	}
+
+	return nil
+}
+
+var lengthBufBatchResponse = []byte{129}
+
+func (t *BatchResponse) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write(lengthBufBatchResponse); err != nil {
+		return err
+	}
+
+	// t.Responses ([]*exchange.Response) (slice)
+	if len(t.Responses) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Responses was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Responses))); err != nil {
+		return err
+	}
+	for _, v := range t.Responses {
+		if err := v.MarshalCBOR(cw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *BatchResponse) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = BatchResponse{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Responses ([]*exchange.Response) (slice)
+
+	maj, extra, err = cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Responses: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Responses = make([]*Response, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v Response
+		if err := v.UnmarshalCBOR(cr); err != nil {
+			return err
+		}
+
+		t.Responses[i] = &v
+	}
+
+	return nil
+}
+
 var lengthBufCompactedMessages = []byte{132}
 
 func (t *CompactedMessages) MarshalCBOR(w io.Writer) error {
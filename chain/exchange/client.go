@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"time"
 
@@ -40,9 +41,15 @@ var _ Client = (*client)(nil)
 // NewClient creates a new libp2p-based exchange.Client that uses the libp2p
 // ChainExhange protocol as the fetching mechanism.
 func NewClient(lc fx.Lifecycle, host host.Host, pmgr peermgr.MaybePeerMgr) Client {
+	return NewClientWithScoring(lc, host, pmgr, DefaultScoringConfig())
+}
+
+// NewClientWithScoring is NewClient, choosing the peers to request from with
+// the given scoring.
+func NewClientWithScoring(lc fx.Lifecycle, host host.Host, pmgr peermgr.MaybePeerMgr, scoring ScoringConfig) Client {
 	return &client{
 		host:        host,
-		peerTracker: newPeerTracker(lc, host, pmgr.Mgr),
+		peerTracker: newPeerTracker(lc, host, pmgr.Mgr, scoring),
 	}
 }
 
@@ -72,16 +79,45 @@ func (c *client) doRequest(
 	// so the tipset blocks need to be provided by the caller.
 	tipsets []*types.TipSet,
 ) (*validatedResponse, error) {
-	// Validate request.
-	if req.Length == 0 {
-		return nil, xerrors.Errorf("invalid request of length 0")
+	validRes, err := c.doBatchRequest(ctx, []*Request{req}, singlePeer, [][]*types.TipSet{tipsets})
+	if err != nil {
+		return nil, err
 	}
-	if req.Length > MaxRequestLength {
-		return nil, xerrors.Errorf("request length (%d) above maximum (%d)",
-			req.Length, MaxRequestLength)
+	return validRes[0], nil
+}
+
+// doBatchRequest is doRequest, for several requests sent together: in a
+// single `BatchRequest` to the peers supporting the batched protocol, one
+// after the other to the others. A peer's responses are returned only if
+// they are all valid, `tipsets` holding the tipsets provided for each
+// request.
+func (c *client) doBatchRequest(
+	ctx context.Context,
+	reqs []*Request,
+	singlePeer *peer.ID,
+	tipsets [][]*types.TipSet,
+) ([]*validatedResponse, error) {
+	// Validate requests.
+	if len(reqs) == 0 {
+		return nil, xerrors.Errorf("empty batch of requests")
+	}
+	if len(reqs) > MaxBatchRequests {
+		return nil, xerrors.Errorf("batch of requests (%d) above maximum (%d)",
+			len(reqs), MaxBatchRequests)
+	}
+	var length uint64
+	for _, req := range reqs {
+		if req.Length == 0 {
+			return nil, xerrors.Errorf("invalid request of length 0")
+		}
+		if req.Options == 0 {
+			return nil, xerrors.Errorf("request with no options set")
+		}
+		length += req.Length
 	}
-	if req.Options == 0 {
-		return nil, xerrors.Errorf("request with no options set")
+	if length > MaxRequestLength {
+		return nil, xerrors.Errorf("request length (%d) above maximum (%d)",
+			length, MaxRequestLength)
 	}
 
 	// Generate the list of peers to be queried, either the
@@ -104,6 +140,7 @@ func (c *client) doRequest(
 	globalTime := build.Clock.Now()
 	// Global time used to track what is the expected time we will need to get
 	// a response if a client fails us.
+peers:
 	for _, peer := range peers {
 		select {
 		case <-ctx.Done():
@@ -112,7 +149,7 @@ func (c *client) doRequest(
 		}
 
 		// Send request, read response.
-		res, err := c.sendRequestToPeer(ctx, peer, req)
+		res, err := c.sendRequestToPeer(ctx, peer, reqs)
 		if err != nil {
 			if !xerrors.Is(err, network.ErrNoConn) {
 				log.Warnf("could not send request to peer %s: %s",
@@ -122,11 +159,14 @@ func (c *client) doRequest(
 		}
 
 		// Process and validate response.
-		validRes, err := c.processResponse(req, res, tipsets)
-		if err != nil {
-			log.Warnf("processing peer %s response failed: %s",
-				peer.String(), err)
-			continue
+		validRes := make([]*validatedResponse, len(reqs))
+		for i, req := range reqs {
+			validRes[i], err = c.processResponse(req, res[i], tipsets[i])
+			if err != nil {
+				log.Warnf("processing peer %s response failed: %s",
+					peer.String(), err)
+				continue peers
+			}
 		}
 
 		c.peerTracker.logGlobalSuccess(build.Clock.Since(globalTime))
@@ -151,6 +191,9 @@ func (c *client) doRequest(
 // we can apply the correct penalties depending on the cause of the error.
 // FIXME: Add the `peer` as argument once we implement penalties.
 func (c *client) processResponse(req *Request, res *Response, tipsets []*types.TipSet) (r *validatedResponse, err error) {
+	if res == nil {
+		return nil, xerrors.Errorf("got no response")
+	}
 	err = res.statusToError()
 	if err != nil {
 		return nil, xerrors.Errorf("status error: %s", err)
@@ -372,16 +415,83 @@ func (c *client) GetChainMessages(ctx context.Context, tipsets []*types.TipSet)
 	return validRes.messages, nil
 }
 
-// Send a request to a peer. Write request in the stream and read the
-// response back. We do not do any processing of the request/response
-// here.
-func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Request) (_ *Response, err error) {
+// GetChainMessagesBatch implements Client.GetChainMessagesBatch(). Refer to the
+// godocs there.
+func (c *client) GetChainMessagesBatch(ctx context.Context, ranges [][]*types.TipSet) ([][]*CompactedMessages, error) {
+	ctx, span := trace.StartSpan(ctx, "GetChainMessagesBatch")
+	if span.IsRecordingEvents() {
+		span.AddAttributes(
+			trace.Int64Attribute("ranges", int64(len(ranges))),
+		)
+	}
+	defer span.End()
+
+	reqs := make([]*Request, len(ranges))
+	for i, tipsets := range ranges {
+		if len(tipsets) == 0 {
+			return nil, xerrors.Errorf("empty range of tipsets at %d", i)
+		}
+		reqs[i] = &Request{
+			Head:    tipsets[0].Cids(),
+			Length:  uint64(len(tipsets)),
+			Options: Messages,
+		}
+	}
+
+	validRes, err := c.doBatchRequest(ctx, reqs, nil, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]*CompactedMessages, len(validRes))
+	for i, res := range validRes {
+		out[i] = res.messages
+	}
+	return out, nil
+}
+
+// Send the requests to a peer: in a single batch if the peer supports the
+// batched protocol, one after the other otherwise. We do not do any
+// processing of the requests/responses here.
+func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, reqs []*Request) ([]*Response, error) {
+	supported, err := c.host.Peerstore().SupportsProtocols(peer, ChainExchangeBatchProtocolID, ChainExchangeProtocolID)
+	if err != nil {
+		c.RemovePeer(peer)
+		return nil, xerrors.Errorf("failed to get protocols for peer: %w", err)
+	}
+
+	for _, p := range supported {
+		if p == ChainExchangeBatchProtocolID {
+			return c.sendBatchToPeer(ctx, peer, reqs)
+		}
+	}
+	for _, p := range supported {
+		if p != ChainExchangeProtocolID {
+			continue
+		}
+
+		res := make([]*Response, len(reqs))
+		for i, req := range reqs {
+			if res[i], err = c.sendSingleRequestToPeer(ctx, peer, req); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return nil, xerrors.Errorf("peer %s does not support protocols %s",
+		peer, []string{ChainExchangeBatchProtocolID, ChainExchangeProtocolID})
+}
+
+// Send a batch of requests to a peer over the batched protocol. Write the
+// batch in the stream and read the responses back.
+func (c *client) sendBatchToPeer(ctx context.Context, peer peer.ID, reqs []*Request) (_ []*Response, err error) {
 	// Trace code.
-	ctx, span := trace.StartSpan(ctx, "sendRequestToPeer")
+	ctx, span := trace.StartSpan(ctx, "sendBatchToPeer")
 	defer span.End()
 	if span.IsRecordingEvents() {
 		span.AddAttributes(
 			trace.StringAttribute("peer", peer.Pretty()),
+			trace.Int64Attribute("requests", int64(len(reqs))),
 		)
 	}
 	defer func() {
@@ -396,15 +506,90 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 	}()
 	// -- TRACE --
 
-	supported, err := c.host.Peerstore().SupportsProtocols(peer, ChainExchangeProtocolID)
+	var length uint64
+	for _, req := range reqs {
+		length += req.Length
+	}
+
+	connectionStart := build.Clock.Now()
+
+	// Open stream to peer.
+	stream, err := c.host.NewStream(
+		network.WithNoDial(ctx, "should already have connection"),
+		peer,
+		ChainExchangeBatchProtocolID)
 	if err != nil {
 		c.RemovePeer(peer)
-		return nil, xerrors.Errorf("failed to get protocols for peer: %w", err)
+		return nil, xerrors.Errorf("failed to open stream to peer: %w", err)
 	}
-	if len(supported) == 0 || (supported[0] != ChainExchangeProtocolID) {
-		return nil, xerrors.Errorf("peer %s does not support protocols %s",
-			peer, []string{ChainExchangeProtocolID})
+
+	defer stream.Close() //nolint:errcheck
+
+	// Write requests, the peer reading them until we close for writing.
+	_ = stream.SetWriteDeadline(time.Now().Add(WriteReqDeadline))
+	err = writeCompressedCborRPC(stream, &BatchRequest{Requests: reqs})
+	if err == nil {
+		err = stream.CloseWrite()
+	}
+	if err != nil {
+		_ = stream.SetWriteDeadline(time.Time{})
+		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), length)
+		return nil, err
 	}
+	_ = stream.SetWriteDeadline(time.Time{})
+
+	// Read responses.
+	var bres BatchResponse
+	cr := &countingReader{r: incrt.New(stream, ReadResMinSpeed, ReadResDeadline)}
+	err = readCompressedCborRPC(cr, &bres)
+	if err != nil {
+		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), length)
+		return nil, xerrors.Errorf("failed to read chainxchg batch response: %w", err)
+	}
+	if len(bres.Responses) != len(reqs) {
+		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), length)
+		return nil, xerrors.Errorf("got %d responses to a batch of %d requests", len(bres.Responses), len(reqs))
+	}
+
+	var chainLen int
+	for _, res := range bres.Responses {
+		if res != nil {
+			chainLen += len(res.Chain)
+		}
+	}
+	if span.IsRecordingEvents() {
+		span.AddAttributes(
+			trace.Int64Attribute("chain_len", int64(chainLen)),
+		)
+	}
+
+	c.peerTracker.logSuccess(peer, build.Clock.Since(connectionStart), uint64(chainLen), cr.n)
+	return bres.Responses, nil
+}
+
+// Send a request to a peer. Write request in the stream and read the
+// response back. We do not do any processing of the request/response
+// here.
+func (c *client) sendSingleRequestToPeer(ctx context.Context, peer peer.ID, req *Request) (_ *Response, err error) {
+	// Trace code.
+	ctx, span := trace.StartSpan(ctx, "sendRequestToPeer")
+	defer span.End()
+	if span.IsRecordingEvents() {
+		span.AddAttributes(
+			trace.StringAttribute("peer", peer.Pretty()),
+		)
+	}
+	defer func() {
+		if err != nil {
+			if span.IsRecordingEvents() {
+				span.SetStatus(trace.Status{
+					Code:    5,
+					Message: err.Error(),
+				})
+			}
+		}
+	}()
+	// -- TRACE --
 
 	connectionStart := build.Clock.Now()
 
@@ -433,9 +618,8 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 
 	// Read response.
 	var res Response
-	err = cborutil.ReadCborRPC(
-		bufio.NewReader(incrt.New(stream, ReadResMinSpeed, ReadResDeadline)),
-		&res)
+	cr := &countingReader{r: incrt.New(stream, ReadResMinSpeed, ReadResDeadline)}
+	err = cborutil.ReadCborRPC(bufio.NewReader(cr), &res)
 	if err != nil {
 		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), req.Length)
 		return nil, xerrors.Errorf("failed to read chainxchg response: %w", err)
@@ -451,7 +635,7 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 		)
 	}
 
-	c.peerTracker.logSuccess(peer, build.Clock.Since(connectionStart), uint64(len(res.Chain)))
+	c.peerTracker.logSuccess(peer, build.Clock.Since(connectionStart), uint64(len(res.Chain)), cr.n)
 	// FIXME: We should really log a success only after we validate the response.
 	//  It might be a bit hard to do.
 	return &res, nil
@@ -467,6 +651,23 @@ func (c *client) RemovePeer(p peer.ID) {
 	c.peerTracker.removePeer(p)
 }
 
+// PeerScores implements Client.PeerScores(). Refer to the godocs there.
+func (c *client) PeerScores() []PeerScore {
+	return c.peerTracker.scores()
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += uint64(n)
+	return n, err
+}
+
 // getShuffledPeers returns a preference-sorted set of peers (by latency
 // and failure counting), shuffling the first few peers so we don't always
 // pick the same peer.
@@ -0,0 +1,99 @@
+// stm: #unit
+package exchange
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/lib/peermgr"
+)
+
+func mockChain(ctx context.Context, t *testing.T, cs *store.ChainStore, height int) []*types.TipSet {
+	gen := mock.MkBlock(nil, 1, 1)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+
+	chain := []*types.TipSet{mock.TipSet(gen)}
+	for i := 0; i < height; i++ {
+		blk := mock.MkBlock(chain[len(chain)-1], 1, 1)
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		chain = append(chain, mock.TipSet(blk))
+	}
+	return chain
+}
+
+func TestClientBatchedProtocol(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+	chain := mockChain(ctx, t, cs, 20)
+	srv := NewServer(cs)
+
+	mn := mocknet.New()
+	defer mn.Close() //nolint:errcheck
+	newPeer := func(protocols ...protocol.ID) host.Host {
+		h, err := mn.GenPeer()
+		require.NoError(t, err)
+		for _, p := range protocols {
+			switch p {
+			case ChainExchangeProtocolID:
+				h.SetStreamHandler(p, srv.HandleStream)
+			case ChainExchangeBatchProtocolID:
+				h.SetStreamHandler(p, srv.HandleBatchStream)
+			}
+		}
+		return h
+	}
+	batched := newPeer(ChainExchangeBatchProtocolID)
+	legacy := newPeer(ChainExchangeProtocolID)
+	ch := newPeer()
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+	require.NoError(t, ch.Peerstore().AddProtocols(batched.ID(), ChainExchangeBatchProtocolID, ChainExchangeProtocolID))
+	require.NoError(t, ch.Peerstore().AddProtocols(legacy.ID(), ChainExchangeProtocolID))
+
+	reqs := []*Request{
+		{Head: chain[20].Cids(), Length: 5, Options: Headers},
+		{Head: chain[10].Cids(), Length: 3, Options: Headers},
+		// partial, from the genesis
+		{Head: chain[1].Cids(), Length: 5, Options: Headers},
+	}
+
+	for _, p := range []host.Host{batched, legacy} {
+		c := NewClient(fxtest.NewLifecycle(t), ch, peermgr.MaybePeerMgr{}).(*client)
+		c.AddPeer(p.ID())
+
+		res, err := c.doBatchRequest(ctx, reqs, nil, make([][]*types.TipSet, len(reqs)))
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		require.Equal(t, []*types.TipSet{chain[20], chain[19], chain[18], chain[17], chain[16]}, res[0].tipsets)
+		require.Equal(t, []*types.TipSet{chain[10], chain[9], chain[8]}, res[1].tipsets)
+		require.Equal(t, []*types.TipSet{chain[1], chain[0]}, res[2].tipsets)
+
+		tss, err := c.GetBlocks(ctx, chain[5].Key(), 2)
+		require.NoError(t, err)
+		require.Equal(t, []*types.TipSet{chain[5], chain[4]}, tss)
+	}
+
+	// the batches are limited in size by the server
+	bresp, err := srv.(*server).processBatchRequest(ctx, &BatchRequest{Requests: []*Request{
+		{Head: chain[20].Cids(), Length: MaxRequestLength, Options: Headers},
+		{Head: chain[10].Cids(), Length: 1, Options: Headers},
+	}})
+	require.NoError(t, err)
+	require.Len(t, bresp.Responses, 2)
+	require.Equal(t, status(BadRequest), bresp.Responses[0].Status)
+}
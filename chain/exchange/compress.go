@@ -0,0 +1,33 @@
+package exchange
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/DataDog/zstd"
+
+	cborutil "github.com/filecoin-project/go-cbor-util"
+)
+
+// writeCompressedCborRPC writes obj to w, zstd compressed.
+func writeCompressedCborRPC(w io.Writer, obj interface{}) error {
+	buffered := bufio.NewWriter(w)
+	zw := zstd.NewWriter(buffered)
+	if err := cborutil.WriteCborRPC(zw, obj); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	// closing the compressor ends the frame
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// readCompressedCborRPC reads obj from r, zstd compressed. The decompressor
+// reads ahead, so r must end after obj.
+func readCompressedCborRPC(r io.Reader, obj interface{}) error {
+	zr := zstd.NewReader(r)
+	defer zr.Close() //nolint:errcheck
+	return cborutil.ReadCborRPC(bufio.NewReader(zr), obj)
+}
@@ -16,4 +16,9 @@
 // The response will include a status code, an optional message, and the
 // response payload in case of success. The payload is a slice of serialized
 // tipsets.
+//
+// Lotus also supports a batched flavor of the protocol, with a distinct
+// protocol ID, carrying several requests and the responses to them in a
+// single zstd compressed exchange. Clients use it with the peers supporting
+// it, and fall back to one request per stream with the others.
 package exchange
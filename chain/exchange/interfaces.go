@@ -21,6 +21,13 @@ type Server interface {
 	// server will read a single Request, and will respond with a single
 	// Response. It will dispose of the stream straight after.
 	HandleStream(stream network.Stream)
+
+	// HandleBatchStream is the protocol handler of the batched ChainExchange
+	// protocol, to be registered on a libp2p protocol router.
+	//
+	// Streams are single-use as well. The server will read a single
+	// BatchRequest, and will respond with a single BatchResponse.
+	HandleBatchStream(stream network.Stream)
 }
 
 // Client is the requesting side of the ChainExchange protocol. It acts as
@@ -36,6 +43,12 @@ type Client interface {
 	// and returning messages from as many tipsets as requested or less.
 	GetChainMessages(ctx context.Context, tipsets []*types.TipSet) ([]*CompactedMessages, error)
 
+	// GetChainMessagesBatch fetches the messages of several ranges of tipsets
+	// in a single request, each range starting from its first tipset, and
+	// returns the messages of as many tipsets of each range as requested, or
+	// less.
+	GetChainMessagesBatch(ctx context.Context, ranges [][]*types.TipSet) ([][]*CompactedMessages, error)
+
 	// GetFullTipSet fetches a full tipset from a given peer. If successful,
 	// the fetched object contains block headers and all messages in full form.
 	GetFullTipSet(ctx context.Context, peer peer.ID, tsk types.TipSetKey) (*store.FullTipSet, error)
@@ -47,4 +60,8 @@ type Client interface {
 	// RemovePeer removes a peer from the pool of peers that the Client
 	// requests data from.
 	RemovePeer(peer peer.ID)
+
+	// PeerScores returns the scores of the peers in the pool, the peers
+	// requested first coming first.
+	PeerScores() []PeerScore
 }
@@ -16,11 +16,53 @@ import (
 	"github.com/filecoin-project/lotus/lib/peermgr"
 )
 
+// ScoringConfig weighs the statistics kept for the peers into the expected
+// cost of a request to them, the peers with the lowest cost being requested
+// first. Every term is a duration per item requested, so that the weights are
+// comparable.
+type ScoringConfig struct {
+	// LatencyWeight weighs the average time per item of the requests to the
+	// peer.
+	LatencyWeight float64
+	// BandwidthWeight weighs the time to transfer the average item at the
+	// average bandwidth of the peer.
+	BandwidthWeight float64
+	// FailureWeight weighs the failure rate of the peer, a failure costing the
+	// average time it takes to get a response from another peer.
+	FailureWeight float64
+}
+
+// DefaultScoringConfig weighs all the statistics equally.
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		LatencyWeight:   1,
+		BandwidthWeight: 1,
+		FailureWeight:   1,
+	}
+}
+
+// PeerScore are the statistics kept for a peer, and its resulting cost.
+type PeerScore struct {
+	Peer      peer.ID
+	FirstSeen time.Time
+	Successes int
+	Failures  int
+	// Latency is the average time per item of the requests to the peer.
+	Latency time.Duration
+	// Bandwidth is the average bandwidth of the responses of the peer, in
+	// bytes per second.
+	Bandwidth float64
+	// Cost is the expected time per item of a request to the peer.
+	Cost time.Duration
+}
+
 type peerStats struct {
 	successes   int
 	failures    int
 	firstSeen   time.Time
 	averageTime time.Duration
+	// averageBandwidth is in bytes per second
+	averageBandwidth float64
 }
 
 type bsPeerTracker struct {
@@ -28,14 +70,19 @@ type bsPeerTracker struct {
 
 	peers         map[peer.ID]*peerStats
 	avgGlobalTime time.Duration
+	// avgItemSize is the average size of the items in responses, in bytes
+	avgItemSize float64
+
+	scoring ScoringConfig
 
 	pmgr *peermgr.PeerMgr
 }
 
-func newPeerTracker(lc fx.Lifecycle, h host.Host, pmgr *peermgr.PeerMgr) *bsPeerTracker {
+func newPeerTracker(lc fx.Lifecycle, h host.Host, pmgr *peermgr.PeerMgr, scoring ScoringConfig) *bsPeerTracker {
 	bsPt := &bsPeerTracker{
-		peers: make(map[peer.ID]*peerStats),
-		pmgr:  pmgr,
+		peers:   make(map[peer.ID]*peerStats),
+		scoring: scoring,
+		pmgr:    pmgr,
 	}
 
 	evtSub, err := h.EventBus().Subscribe(new(peermgr.FilPeerEvt))
@@ -87,39 +134,56 @@ func (bpt *bsPeerTracker) prefSortedPeers() []peer.ID {
 	bpt.lk.Lock()
 	defer bpt.lk.Unlock()
 	out := make([]peer.ID, 0, len(bpt.peers))
-	for p := range bpt.peers {
+	costs := make(map[peer.ID]float64, len(bpt.peers))
+	for p, pi := range bpt.peers {
 		out = append(out, p)
+		costs[p] = bpt.cost(pi)
 	}
 
 	// sort by 'expected cost' of requesting data from that peer
-	// additionally handle edge cases where not enough data is available
 	sort.Slice(out, func(i, j int) bool {
-		pi := bpt.peers[out[i]]
-		pj := bpt.peers[out[j]]
+		return costs[out[i]] < costs[out[j]]
+	})
 
-		var costI, costJ float64
+	return out
+}
 
-		getPeerInitLat := func(p peer.ID) float64 {
-			return float64(bpt.avgGlobalTime) * newPeerMul
-		}
+// cost is the expected time per item of a request to the peer pi,
+// additionally handling edge cases where not enough data is available.
+func (bpt *bsPeerTracker) cost(pi *peerStats) float64 {
+	if pi.successes+pi.failures == 0 {
+		return float64(bpt.avgGlobalTime) * newPeerMul
+	}
 
-		if pi.successes+pi.failures > 0 {
-			failRateI := float64(pi.failures) / float64(pi.failures+pi.successes)
-			costI = float64(pi.averageTime) + failRateI*float64(bpt.avgGlobalTime)
-		} else {
-			costI = getPeerInitLat(out[i])
-		}
+	failRate := float64(pi.failures) / float64(pi.failures+pi.successes)
+	cost := bpt.scoring.LatencyWeight*float64(pi.averageTime) +
+		bpt.scoring.FailureWeight*failRate*float64(bpt.avgGlobalTime)
+	if pi.averageBandwidth > 0 {
+		cost += bpt.scoring.BandwidthWeight * bpt.avgItemSize / pi.averageBandwidth * float64(time.Second)
+	}
+	return cost
+}
 
-		if pj.successes+pj.failures > 0 {
-			failRateJ := float64(pj.failures) / float64(pj.failures+pj.successes)
-			costJ = float64(pj.averageTime) + failRateJ*float64(bpt.avgGlobalTime)
-		} else {
-			costJ = getPeerInitLat(out[j])
-		}
+// scores returns the scores of the peers, lowest cost first.
+func (bpt *bsPeerTracker) scores() []PeerScore {
+	bpt.lk.Lock()
+	defer bpt.lk.Unlock()
 
-		return costI < costJ
+	out := make([]PeerScore, 0, len(bpt.peers))
+	for p, pi := range bpt.peers {
+		out = append(out, PeerScore{
+			Peer:      p,
+			FirstSeen: pi.firstSeen,
+			Successes: pi.successes,
+			Failures:  pi.failures,
+			Latency:   pi.averageTime,
+			Bandwidth: pi.averageBandwidth,
+			Cost:      time.Duration(bpt.cost(pi)),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Cost < out[j].Cost
 	})
-
 	return out
 }
 
@@ -152,7 +216,17 @@ func logTime(pi *peerStats, dur time.Duration) {
 
 }
 
-func (bpt *bsPeerTracker) logSuccess(p peer.ID, dur time.Duration, reqSize uint64) {
+func logBandwidth(pi *peerStats, bw float64) {
+	if pi.averageBandwidth == 0 {
+		pi.averageBandwidth = bw
+		return
+	}
+	pi.averageBandwidth += (bw - pi.averageBandwidth) / localInvAlpha
+}
+
+// logSuccess records a response of resSize bytes and reqSize items from the
+// peer p, taking dur.
+func (bpt *bsPeerTracker) logSuccess(p peer.ID, dur time.Duration, reqSize uint64, resSize uint64) {
 	bpt.lk.Lock()
 	defer bpt.lk.Unlock()
 
@@ -168,6 +242,17 @@ func (bpt *bsPeerTracker) logSuccess(p peer.ID, dur time.Duration, reqSize uint6
 		reqSize = 1
 	}
 	logTime(pi, dur/time.Duration(reqSize))
+
+	if dur > 0 && resSize > 0 {
+		logBandwidth(pi, float64(resSize)/dur.Seconds())
+
+		itemSize := float64(resSize) / float64(reqSize)
+		if bpt.avgItemSize == 0 {
+			bpt.avgItemSize = itemSize
+		} else {
+			bpt.avgItemSize += (itemSize - bpt.avgItemSize) / globalInvAlpha
+		}
+	}
 }
 
 func (bpt *bsPeerTracker) logFailure(p peer.ID, dur time.Duration, reqSize uint64) {
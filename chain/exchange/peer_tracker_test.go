@@ -0,0 +1,42 @@
+// stm: #unit
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerTrackerScoring(t *testing.T) {
+	newTracker := func(scoring ScoringConfig) *bsPeerTracker {
+		bpt := &bsPeerTracker{peers: map[peer.ID]*peerStats{}, scoring: scoring}
+		for _, p := range []peer.ID{"fast", "slow", "narrow", "failing", "new"} {
+			bpt.addPeer(p)
+		}
+
+		// 10 items of 100KiB, in 100ms for 10MiB/s
+		bpt.logSuccess("fast", 100*time.Millisecond, 10, 10*100<<10)
+		bpt.logSuccess("slow", time.Second, 10, 10*100<<10)
+		bpt.logSuccess("narrow", 200*time.Millisecond, 10, 10*100<<10)
+		bpt.peers["narrow"].averageBandwidth = 10 << 10
+		bpt.logSuccess("failing", 100*time.Millisecond, 10, 10*100<<10)
+		bpt.logFailure("failing", 100*time.Millisecond, 10)
+		bpt.logGlobalSuccess(time.Second)
+		return bpt
+	}
+
+	bpt := newTracker(DefaultScoringConfig())
+	require.Equal(t, []peer.ID{"fast", "slow", "failing", "new", "narrow"}, bpt.prefSortedPeers())
+
+	scores := bpt.scores()
+	require.Len(t, scores, 5)
+	require.Equal(t, peer.ID("fast"), scores[0].Peer)
+	require.Equal(t, 1, scores[0].Successes)
+	require.Equal(t, 10*time.Millisecond, scores[0].Latency)
+
+	// ignoring the bandwidth
+	bpt = newTracker(ScoringConfig{LatencyWeight: 1, FailureWeight: 1})
+	require.Equal(t, []peer.ID{"fast", "narrow"}, bpt.prefSortedPeers()[:2])
+}
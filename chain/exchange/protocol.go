@@ -18,6 +18,12 @@ const (
 	// ChainExchangeProtocolID is the protocol ID of the chain exchange
 	// protocol.
 	ChainExchangeProtocolID = "/fil/chain/xchg/0.0.1"
+	// ChainExchangeBatchProtocolID is the protocol ID of the batched chain
+	// exchange protocol. Its streams carry a BatchRequest, and the
+	// BatchResponse to it, zstd compressed; each side closes the stream for
+	// writing once it wrote its message. Clients prefer it to the chain
+	// exchange protocol, with the peers supporting it.
+	ChainExchangeBatchProtocolID = "/fil/chain/xchg/batch/0.0.1+zstd"
 )
 
 // FIXME: Bumped from original 800 to this to accommodate `syncFork()`
@@ -29,6 +35,10 @@ const (
 //	 qualifier to avoid "const initializer [...] is not a constant" error.)
 var MaxRequestLength = uint64(build.ForkLengthThreshold)
 
+// MaxBatchRequests is the maximum number of requests in a BatchRequest. The
+// sum of their lengths is at most MaxRequestLength.
+var MaxBatchRequests = 16
+
 const (
 	// Extracted constants from the code.
 	// FIXME: Should be reviewed and confirmed.
@@ -55,6 +65,12 @@ type Request struct {
 	Options uint64
 }
 
+// BatchRequest is a batch of requests, for as many ranges of tipsets, sent
+// over the batched chain exchange protocol.
+type BatchRequest struct {
+	Requests []*Request
+}
+
 // `Request` processed and validated to query the tipsets needed.
 type validatedRequest struct {
 	head    types.TipSetKey
@@ -98,6 +114,12 @@ type Response struct {
 	Chain []*BSTipSet
 }
 
+// BatchResponse holds the responses to the requests of a BatchRequest, in
+// order.
+type BatchResponse struct {
+	Responses []*Response
+}
+
 type status uint64
 
 const (
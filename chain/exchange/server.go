@@ -68,6 +68,94 @@ func (s *server) HandleStream(stream inet.Stream) {
 	_ = stream.SetDeadline(time.Time{})
 }
 
+// HandleBatchStream implements Server.HandleBatchStream. Refer to the godocs
+// there.
+func (s *server) HandleBatchStream(stream inet.Stream) {
+	ctx, span := trace.StartSpan(context.Background(), "chainxchg.HandleBatchStream")
+	defer span.End()
+
+	defer stream.Close() //nolint:errcheck
+
+	var breq BatchRequest
+	if err := readCompressedCborRPC(stream, &breq); err != nil {
+		log.Warnf("failed to read block sync batch request: %s", err)
+		return
+	}
+	log.Debugw("block sync batch request", "requests", len(breq.Requests))
+
+	bresp, err := s.processBatchRequest(ctx, &breq)
+	if err != nil {
+		log.Warn("failed to process batch request: ", err)
+		return
+	}
+
+	_ = stream.SetDeadline(time.Now().Add(WriteResDeadline))
+	if err := writeCompressedCborRPC(stream, bresp); err != nil {
+		_ = stream.SetDeadline(time.Time{})
+		log.Warnw("failed to write back response for handle batch stream",
+			"err", err, "peer", stream.Conn().RemotePeer())
+		return
+	}
+	_ = stream.SetDeadline(time.Time{})
+}
+
+// Validate and service the requests of the batch, in order. An invalid batch
+// gets the same error response for each of its requests.
+func (s *server) processBatchRequest(ctx context.Context, breq *BatchRequest) (*BatchResponse, error) {
+	bresp := &BatchResponse{Responses: make([]*Response, 0, len(breq.Requests))}
+
+	if errResponse := validateBatchRequest(breq); errResponse != nil {
+		for range breq.Requests {
+			bresp.Responses = append(bresp.Responses, errResponse)
+		}
+		return bresp, nil
+	}
+
+	for _, req := range breq.Requests {
+		if req == nil {
+			bresp.Responses = append(bresp.Responses, &Response{
+				Status:       BadRequest,
+				ErrorMessage: "nil request in batch",
+			})
+			continue
+		}
+
+		resp, err := s.processRequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		bresp.Responses = append(bresp.Responses, resp)
+	}
+	return bresp, nil
+}
+
+// Validate the size of a batch, the requests in it being validated one by
+// one when serviced.
+func validateBatchRequest(breq *BatchRequest) *Response {
+	if len(breq.Requests) > MaxBatchRequests {
+		return &Response{
+			Status: BadRequest,
+			ErrorMessage: fmt.Sprintf("batch over maximum allowed number of requests (%d)",
+				MaxBatchRequests),
+		}
+	}
+
+	var length uint64
+	for _, req := range breq.Requests {
+		if req != nil {
+			length += req.Length
+		}
+	}
+	if length > MaxRequestLength {
+		return &Response{
+			Status: BadRequest,
+			ErrorMessage: fmt.Sprintf("batch length over maximum allowed (%d)",
+				MaxRequestLength),
+		}
+	}
+	return nil
+}
+
 // Validate and service the request. We return either a protocol
 // response or an internal error.
 func (s *server) processRequest(ctx context.Context, req *Request) (*Response, error) {
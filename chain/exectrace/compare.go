@@ -0,0 +1,141 @@
+package exectrace
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+)
+
+// Divergence is the first difference of an actual trace from an expected one.
+type Divergence struct {
+	// Path locates the field differing, as in Messages[2].Call.Subcalls[0].ExitCode
+	Path     string
+	Expected interface{}
+	Actual   interface{}
+}
+
+func (d *Divergence) Error() string {
+	return fmt.Sprintf("execution diverged at %s: expected %v, got %v", d.Path, d.Expected, d.Actual)
+}
+
+// Compare returns a *Divergence error at the first difference of actual from
+// expected, the messages compared in the order of execution.
+func Compare(expected, actual *TipSetTrace) error {
+	if d := compareTipSet(expected, actual); d != nil {
+		return d
+	}
+	return nil
+}
+
+func compareTipSet(e, a *TipSetTrace) *Divergence {
+	if e.Version != a.Version {
+		return &Divergence{Path: "Version", Expected: e.Version, Actual: a.Version}
+	}
+	if e.Height != a.Height {
+		return &Divergence{Path: "Height", Expected: e.Height, Actual: a.Height}
+	}
+	if len(e.Blocks) != len(a.Blocks) {
+		return &Divergence{Path: "Blocks", Expected: e.Blocks, Actual: a.Blocks}
+	}
+	for i := range e.Blocks {
+		if e.Blocks[i] != a.Blocks[i] {
+			return &Divergence{Path: fmt.Sprintf("Blocks[%d]", i), Expected: e.Blocks[i], Actual: a.Blocks[i]}
+		}
+	}
+	if e.ParentState != a.ParentState {
+		return &Divergence{Path: "ParentState", Expected: e.ParentState, Actual: a.ParentState}
+	}
+
+	// the messages before the state, which only tells that something differs
+	for i := 0; i < len(e.Messages) && i < len(a.Messages); i++ {
+		if d := compareMessage(fmt.Sprintf("Messages[%d]", i), &e.Messages[i], &a.Messages[i]); d != nil {
+			return d
+		}
+	}
+	if len(e.Messages) != len(a.Messages) {
+		return &Divergence{Path: "len(Messages)", Expected: len(e.Messages), Actual: len(a.Messages)}
+	}
+	if e.State != a.State {
+		return &Divergence{Path: "State", Expected: e.State, Actual: a.State}
+	}
+	return nil
+}
+
+func compareMessage(path string, e, a *MessageTrace) *Divergence {
+	if e.Cid != a.Cid {
+		return &Divergence{Path: path + ".Cid", Expected: e.Cid, Actual: a.Cid}
+	}
+	if e.Implicit != a.Implicit {
+		return &Divergence{Path: path + ".Implicit", Expected: e.Implicit, Actual: a.Implicit}
+	}
+	if d := compareCall(path+".Call", &e.Call, &a.Call); d != nil {
+		return d
+	}
+
+	path += ".GasCost"
+	for _, f := range []struct {
+		name string
+		e, a abi.TokenAmount
+	}{
+		{"BaseFeeBurn", e.GasCost.BaseFeeBurn, a.GasCost.BaseFeeBurn},
+		{"OverEstimationBurn", e.GasCost.OverEstimationBurn, a.GasCost.OverEstimationBurn},
+		{"MinerPenalty", e.GasCost.MinerPenalty, a.GasCost.MinerPenalty},
+		{"MinerTip", e.GasCost.MinerTip, a.GasCost.MinerTip},
+		{"Refund", e.GasCost.Refund, a.GasCost.Refund},
+	} {
+		if !tokensEqual(f.e, f.a) {
+			return &Divergence{Path: path + "." + f.name, Expected: f.e, Actual: f.a}
+		}
+	}
+	if e.GasCost.GasRefund != a.GasCost.GasRefund {
+		return &Divergence{Path: path + ".GasRefund", Expected: e.GasCost.GasRefund, Actual: a.GasCost.GasRefund}
+	}
+	if e.GasCost.GasBurned != a.GasCost.GasBurned {
+		return &Divergence{Path: path + ".GasBurned", Expected: e.GasCost.GasBurned, Actual: a.GasCost.GasBurned}
+	}
+	return nil
+}
+
+func compareCall(path string, e, a *Call) *Divergence {
+	switch {
+	case e.From != a.From:
+		return &Divergence{Path: path + ".From", Expected: e.From, Actual: a.From}
+	case e.To != a.To:
+		return &Divergence{Path: path + ".To", Expected: e.To, Actual: a.To}
+	case !tokensEqual(e.Value, a.Value):
+		return &Divergence{Path: path + ".Value", Expected: e.Value, Actual: a.Value}
+	case e.Method != a.Method:
+		return &Divergence{Path: path + ".Method", Expected: e.Method, Actual: a.Method}
+	case !bytes.Equal(e.Params, a.Params):
+		return &Divergence{Path: path + ".Params", Expected: e.Params, Actual: a.Params}
+	}
+
+	// the calls made decide of the result
+	for i := 0; i < len(e.Subcalls) && i < len(a.Subcalls); i++ {
+		if d := compareCall(fmt.Sprintf("%s.Subcalls[%d]", path, i), &e.Subcalls[i], &a.Subcalls[i]); d != nil {
+			return d
+		}
+	}
+	if len(e.Subcalls) != len(a.Subcalls) {
+		return &Divergence{Path: path + ".len(Subcalls)", Expected: len(e.Subcalls), Actual: len(a.Subcalls)}
+	}
+
+	switch {
+	case e.ExitCode != a.ExitCode:
+		return &Divergence{Path: path + ".ExitCode", Expected: e.ExitCode, Actual: a.ExitCode}
+	case !bytes.Equal(e.Return, a.Return):
+		return &Divergence{Path: path + ".Return", Expected: e.Return, Actual: a.Return}
+	case e.GasUsed != a.GasUsed:
+		return &Divergence{Path: path + ".GasUsed", Expected: e.GasUsed, Actual: a.GasUsed}
+	}
+	return nil
+}
+
+func tokensEqual(a, b abi.TokenAmount) bool {
+	if a.Int == nil || b.Int == nil {
+		return a.Int == nil && b.Int == nil
+	}
+	return big.Cmp(a, b) == 0
+}
@@ -0,0 +1,49 @@
+package exectrace
+
+import (
+	"encoding/json"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// Format is an encoding of the traces.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCBOR Format = "cbor"
+)
+
+// Encode writes tr to w in the format f. JSON traces are indented, one field
+// per line, for the traces of implementations to be diffed line by line.
+func Encode(w io.Writer, tr *TipSetTrace, f Format) error {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tr)
+	case FormatCBOR:
+		return tr.MarshalCBOR(w)
+	default:
+		return xerrors.Errorf("unknown trace format %q", f)
+	}
+}
+
+// Decode reads a trace in the format f from r.
+func Decode(r io.Reader, f Format) (*TipSetTrace, error) {
+	var tr TipSetTrace
+	switch f {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&tr); err != nil {
+			return nil, xerrors.Errorf("decoding json trace: %w", err)
+		}
+	case FormatCBOR:
+		if err := tr.UnmarshalCBOR(r); err != nil {
+			return nil, xerrors.Errorf("decoding cbor trace: %w", err)
+		}
+	default:
+		return nil, xerrors.Errorf("unknown trace format %q", f)
+	}
+	return &tr, nil
+}
@@ -0,0 +1,311 @@
+package exectrace
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// Recorder is an ExecMonitor recording the trace of the execution of a
+// tipset, and the inputs of the VMs executing it.
+type Recorder struct {
+	trace *TipSetTrace
+}
+
+var _ stmgr.VMMonitor = (*Recorder)(nil)
+
+func NewRecorder(ts *types.TipSet) *Recorder {
+	return &Recorder{trace: &TipSetTrace{
+		Version:     Version,
+		Height:      ts.Height(),
+		Blocks:      ts.Cids(),
+		ParentState: ts.ParentState(),
+	}}
+}
+
+func (r *Recorder) ConfigureVM(opts *vm.VMOpts) {
+	i := len(r.trace.VMs)
+	r.trace.VMs = append(r.trace.VMs, VMTrace{
+		Epoch:          opts.Epoch,
+		StateBase:      opts.StateBase,
+		NetworkVersion: uint64(opts.NetworkVersion),
+		BaseFee:        opts.BaseFee,
+	})
+
+	opts.Rand = &recordingRand{rand: opts.Rand, record: func(rnd Randomness) {
+		r.trace.VMs[i].Randomness = append(r.trace.VMs[i].Randomness, rnd)
+	}}
+	circ := opts.CircSupplyCalc
+	opts.CircSupplyCalc = func(ctx context.Context, e abi.ChainEpoch, st *state.StateTree) (abi.TokenAmount, error) {
+		cs, err := circ(ctx, e, st)
+		if err == nil {
+			r.trace.VMs[i].CircSupply = append(r.trace.VMs[i].CircSupply, cs)
+		}
+		return cs, err
+	}
+}
+
+func (r *Recorder) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	mt := MessageTrace{
+		Cid:        mcid,
+		Implicit:   implicit,
+		Nonce:      msg.Nonce,
+		GasLimit:   msg.GasLimit,
+		GasFeeCap:  msg.GasFeeCap,
+		GasPremium: msg.GasPremium,
+		GasCost:    makeGasCost(ret.GasCosts),
+		Call:       makeCall(ret.ExecutionTrace),
+	}
+	// the top level call is the message, whatever the trace recorded
+	mt.Call.From, mt.Call.To, mt.Call.Value = msg.From, msg.To, msg.Value
+	mt.Call.Method, mt.Call.Params = msg.Method, msg.Params
+	mt.Call.ExitCode, mt.Call.Return, mt.Call.GasUsed = ret.ExitCode, ret.Return, ret.GasUsed
+
+	r.trace.Messages = append(r.trace.Messages, mt)
+	if n := len(r.trace.VMs); n > 0 {
+		r.trace.VMs[n-1].Messages++
+	}
+	return nil
+}
+
+// Trace returns the trace recorded.
+func (r *Recorder) Trace() *TipSetTrace {
+	return r.trace
+}
+
+// message returns the message traced, signed when the trace has its
+// signature.
+func (mt *MessageTrace) message() types.ChainMsg {
+	msg := &types.Message{
+		To:         mt.Call.To,
+		From:       mt.Call.From,
+		Nonce:      mt.Nonce,
+		Value:      mt.Call.Value,
+		GasLimit:   mt.GasLimit,
+		GasFeeCap:  mt.GasFeeCap,
+		GasPremium: mt.GasPremium,
+		Method:     mt.Call.Method,
+		Params:     mt.Call.Params,
+	}
+	if mt.Signature != nil {
+		return &types.SignedMessage{Message: *msg, Signature: *mt.Signature}
+	}
+	return msg
+}
+
+// recordingRand records the randomness drawn from rand.
+type recordingRand struct {
+	rand   vm.Rand
+	record func(Randomness)
+}
+
+func (r *recordingRand) GetChainRandomness(ctx context.Context, pers crypto.DomainSeparationTag, round abi.ChainEpoch, entropy []byte) ([]byte, error) {
+	v, err := r.rand.GetChainRandomness(ctx, pers, round, entropy)
+	if err == nil {
+		r.record(Randomness{Personalization: pers, Round: round, Entropy: entropy, Value: v})
+	}
+	return v, err
+}
+
+func (r *recordingRand) GetBeaconRandomness(ctx context.Context, pers crypto.DomainSeparationTag, round abi.ChainEpoch, entropy []byte) ([]byte, error) {
+	v, err := r.rand.GetBeaconRandomness(ctx, pers, round, entropy)
+	if err == nil {
+		r.record(Randomness{Beacon: true, Personalization: pers, Round: round, Entropy: entropy, Value: v})
+	}
+	return v, err
+}
+
+func makeGasCost(gc *vm.GasOutputs) GasCost {
+	if gc == nil {
+		// implicit messages pay no gas
+		return GasCost{
+			BaseFeeBurn:        big.Zero(),
+			OverEstimationBurn: big.Zero(),
+			MinerPenalty:       big.Zero(),
+			MinerTip:           big.Zero(),
+			Refund:             big.Zero(),
+		}
+	}
+	return GasCost{
+		BaseFeeBurn:        gc.BaseFeeBurn,
+		OverEstimationBurn: gc.OverEstimationBurn,
+		MinerPenalty:       gc.MinerPenalty,
+		MinerTip:           gc.MinerTip,
+		Refund:             gc.Refund,
+		GasRefund:          gc.GasRefund,
+		GasBurned:          gc.GasBurned,
+	}
+}
+
+func makeCall(et types.ExecutionTrace) Call {
+	c := Call{Value: big.Zero()}
+	if et.Msg != nil {
+		c.From, c.To, c.Value = et.Msg.From, et.Msg.To, et.Msg.Value
+		c.Method, c.Params = et.Msg.Method, et.Msg.Params
+	}
+	if et.MsgRct != nil {
+		c.ExitCode, c.Return, c.GasUsed = et.MsgRct.ExitCode, et.MsgRct.Return, et.MsgRct.GasUsed
+	}
+	for _, sub := range et.Subcalls {
+		c.Subcalls = append(c.Subcalls, makeCall(sub))
+	}
+	return c
+}
+
+// Record executes ts against its parent state and returns the trace of the
+// execution. Nothing computed is persisted as the state of ts.
+func Record(ctx context.Context, sm *stmgr.StateManager, ts *types.TipSet) (*TipSetTrace, error) {
+	rec := NewRecorder(ts)
+	st, err := sm.ExecutionTraceWithMonitor(ctx, ts, rec)
+	if err != nil {
+		return nil, xerrors.Errorf("executing tipset %s: %w", ts.Key(), err)
+	}
+	if len(rec.trace.VMs) == 0 {
+		return nil, xerrors.Errorf("the executor of the state manager doesn't report its VMs")
+	}
+	rec.trace.State = st
+
+	// the signatures of the secp256k1 messages
+	for i := range rec.trace.Messages {
+		mt := &rec.trace.Messages[i]
+		if mt.Implicit || mt.message().Cid() == mt.Cid {
+			continue
+		}
+		smsg, err := sm.ChainStore().GetSignedMessage(ctx, mt.Cid)
+		if err != nil {
+			return nil, xerrors.Errorf("loading signed message %s: %w", mt.Cid, err)
+		}
+		mt.Signature = &smsg.Signature
+	}
+	return rec.trace, nil
+}
+
+// Replay executes the messages of tr again, on VMs built from the inputs in
+// the trace, and returns a *Divergence error when the execution does not match
+// the trace. The chain isn't needed: bs only has to hold the state read by the
+// execution, from the parent state of the tipset. The executions looking back
+// at the chain, as to verify consensus faults, fail.
+func Replay(ctx context.Context, bs blockstore.Blockstore, syscalls vm.SyscallBuilder, tr *TipSetTrace) error {
+	if tr.Version != Version {
+		return xerrors.Errorf("unsupported trace version %d, expected %d", tr.Version, Version)
+	}
+
+	rec := &Recorder{trace: &TipSetTrace{
+		Version:     tr.Version,
+		Height:      tr.Height,
+		Blocks:      tr.Blocks,
+		ParentState: tr.ParentState,
+	}}
+	st := tr.ParentState
+	msgs := tr.Messages
+	for i, vt := range tr.VMs {
+		if vt.Messages > uint64(len(msgs)) {
+			return xerrors.Errorf("VMs[%d] applies %d messages, the trace has %d left", i, vt.Messages, len(msgs))
+		}
+
+		opts := &vm.VMOpts{
+			StateBase:      vt.StateBase,
+			Epoch:          vt.Epoch,
+			Rand:           &replayedRand{recorded: vt.Randomness},
+			Bstore:         bs,
+			Actors:         filcns.NewActorRegistry(),
+			Syscalls:       syscalls,
+			CircSupplyCalc: replayedCircSupply(vt.CircSupply),
+			NetworkVersion: network.Version(vt.NetworkVersion),
+			BaseFee:        vt.BaseFee,
+			LookbackState: func(context.Context, abi.ChainEpoch) (*state.StateTree, error) {
+				return nil, xerrors.Errorf("looking back at the chain is not supported by replays")
+			},
+			TipSetGetter: func(context.Context, abi.ChainEpoch) (types.TipSetKey, error) {
+				return types.EmptyTSK, xerrors.Errorf("looking back at the chain is not supported by replays")
+			},
+			Tracing: true,
+		}
+		rec.ConfigureVM(opts)
+		vmi, err := vm.NewVM(ctx, opts)
+		if err != nil {
+			return xerrors.Errorf("making vm: %w", err)
+		}
+
+		for _, mt := range msgs[:vt.Messages] {
+			msg := mt.message()
+			if msg.Cid() != mt.Cid {
+				return xerrors.Errorf("message %s of the trace does not match its CID %s", msg.Cid(), mt.Cid)
+			}
+
+			var ret *vm.ApplyRet
+			if mt.Implicit {
+				ret, err = vmi.ApplyImplicitMessage(ctx, msg.VMMessage())
+			} else {
+				ret, err = vmi.ApplyMessage(ctx, msg)
+			}
+			if err != nil {
+				return xerrors.Errorf("applying message %s: %w", mt.Cid, err)
+			}
+			if err := rec.MessageApplied(ctx, nil, mt.Cid, msg.VMMessage(), ret, mt.Implicit); err != nil {
+				return err
+			}
+			rec.trace.Messages[len(rec.trace.Messages)-1].Signature = mt.Signature
+		}
+		msgs = msgs[vt.Messages:]
+
+		if st, err = vmi.Flush(ctx); err != nil {
+			return xerrors.Errorf("flushing vm: %w", err)
+		}
+	}
+	rec.trace.State = st
+
+	return Compare(tr, rec.trace)
+}
+
+// replayedRand serves the randomness recorded, in order, as long as it is
+// drawn with the same parameters.
+type replayedRand struct {
+	recorded []Randomness
+}
+
+func (r *replayedRand) next(want Randomness) ([]byte, error) {
+	if len(r.recorded) == 0 {
+		return nil, xerrors.Errorf("drawing randomness not in the trace (beacon: %t, round %d)", want.Beacon, want.Round)
+	}
+	got := r.recorded[0]
+	if got.Beacon != want.Beacon || got.Personalization != want.Personalization || got.Round != want.Round || !bytes.Equal(got.Entropy, want.Entropy) {
+		return nil, xerrors.Errorf("drawing randomness (beacon: %t, round %d) differing from the trace (beacon: %t, round %d)", want.Beacon, want.Round, got.Beacon, got.Round)
+	}
+	r.recorded = r.recorded[1:]
+	return got.Value, nil
+}
+
+func (r *replayedRand) GetChainRandomness(_ context.Context, pers crypto.DomainSeparationTag, round abi.ChainEpoch, entropy []byte) ([]byte, error) {
+	return r.next(Randomness{Personalization: pers, Round: round, Entropy: entropy})
+}
+
+func (r *replayedRand) GetBeaconRandomness(_ context.Context, pers crypto.DomainSeparationTag, round abi.ChainEpoch, entropy []byte) ([]byte, error) {
+	return r.next(Randomness{Beacon: true, Personalization: pers, Round: round, Entropy: entropy})
+}
+
+// replayedCircSupply serves the circulating supplies recorded, in order.
+func replayedCircSupply(recorded []abi.TokenAmount) vm.CircSupplyCalculator {
+	return func(context.Context, abi.ChainEpoch, *state.StateTree) (abi.TokenAmount, error) {
+		if len(recorded) == 0 {
+			return abi.TokenAmount{}, xerrors.Errorf("computing a circulating supply not in the trace")
+		}
+		cs := recorded[0]
+		recorded = recorded[1:]
+		return cs, nil
+	}
+}
@@ -0,0 +1,103 @@
+// Package exectrace defines a stable, versioned format of the traces of the
+// execution of tipsets, holding only what the consensus rules determine: the
+// calls made by the messages, their results and the gas they used, but no
+// durations, gas charge breakdowns or error messages specific to an
+// implementation. Traces also hold the inputs of the execution, the messages,
+// the randomness drawn and the inputs of the VMs, so that they are replayed on
+// a blockstore holding the state they read, without the chain, to check that
+// the execution is deterministic. Traces are encoded as JSON or CBOR, and can
+// be compared with the traces of other implementations.
+package exectrace
+
+import (
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/exitcode"
+)
+
+// Version is the version of the format of the traces, bumped on any change of
+// their content or encoding.
+const Version = 2
+
+// TipSetTrace is the trace of the execution of the messages of a tipset
+// against its parent state, the implicit reward and cron messages included.
+type TipSetTrace struct {
+	Version     uint64
+	Height      abi.ChainEpoch
+	Blocks      []cid.Cid
+	ParentState cid.Cid
+	// State is the state root resulting from the execution
+	State    cid.Cid
+	Messages []MessageTrace `cborgen:"maxlen=1000000000"`
+	// VMs are the VMs that applied the messages, in order: one running the
+	// cron of each null round before the tipset, then the one applying the
+	// messages of the tipset
+	VMs []VMTrace
+}
+
+// MessageTrace is the trace of a message, in the order of execution.
+type MessageTrace struct {
+	Cid cid.Cid
+	// Implicit messages are those sent by the system actor
+	Implicit bool
+	// the fields of the message not in its Call
+	Nonce      uint64
+	GasLimit   int64
+	GasFeeCap  abi.TokenAmount
+	GasPremium abi.TokenAmount
+	// Signature is the signature of the secp256k1 messages, part of their CID
+	// and of the size they pay gas for
+	Signature *crypto.Signature
+	GasCost   GasCost
+	Call      Call
+}
+
+// VMTrace holds the inputs of a VM, which applied the next Messages messages
+// of the trace.
+type VMTrace struct {
+	Epoch          abi.ChainEpoch
+	StateBase      cid.Cid
+	NetworkVersion uint64
+	BaseFee        abi.TokenAmount
+	Messages       uint64
+	// CircSupply are the circulating supplies computed by the VM, in order
+	CircSupply []abi.TokenAmount `cborgen:"maxlen=1000000000"`
+	// Randomness is the randomness drawn by the VM, in order
+	Randomness []Randomness `cborgen:"maxlen=1000000000"`
+}
+
+// Randomness is randomness drawn from the chain, or from the beacon.
+type Randomness struct {
+	Beacon          bool
+	Personalization crypto.DomainSeparationTag
+	Round           abi.ChainEpoch
+	Entropy         []byte
+	Value           []byte
+}
+
+// GasCost is the split of the gas fees of a message.
+type GasCost struct {
+	BaseFeeBurn        abi.TokenAmount
+	OverEstimationBurn abi.TokenAmount
+	MinerPenalty       abi.TokenAmount
+	MinerTip           abi.TokenAmount
+	Refund             abi.TokenAmount
+	GasRefund          int64
+	GasBurned          int64
+}
+
+// Call is a call to an actor method and the calls it made in turn.
+type Call struct {
+	From     address.Address
+	To       address.Address
+	Value    abi.TokenAmount
+	Method   abi.MethodNum
+	Params   []byte
+	ExitCode exitcode.ExitCode
+	Return   []byte
+	GasUsed  int64
+	Subcalls []Call `cborgen:"maxlen=1000000000"`
+}
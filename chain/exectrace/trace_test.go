@@ -0,0 +1,162 @@
+// stm: #unit
+package exectrace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+func TestTraceFormat(t *testing.T) {
+	ctx := context.Background()
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	from, to, sub := mock.Address(100), mock.Address(101), mock.Address(102)
+	msg := mock.UnsignedMessage(from, to, 0)
+	msg.Method, msg.Params = 2, []byte{1, 2}
+
+	rec := NewRecorder(ts)
+	opts := &vm.VMOpts{
+		Epoch:          ts.Height(),
+		StateBase:      ts.ParentState(),
+		Rand:           fixedRand{},
+		CircSupplyCalc: fixedCircSupply,
+		NetworkVersion: network.Version16,
+		BaseFee:        abi.NewTokenAmount(100),
+	}
+	rec.ConfigureVM(opts)
+	_, err := opts.Rand.GetBeaconRandomness(ctx, crypto.DomainSeparationTag_ElectionProofProduction, 5, []byte{4})
+	require.NoError(t, err)
+	_, err = opts.CircSupplyCalc(ctx, ts.Height(), nil)
+	require.NoError(t, err)
+	require.NoError(t, rec.MessageApplied(ctx, ts, msg.Cid(), msg, &vm.ApplyRet{
+		MessageReceipt: types.MessageReceipt{ExitCode: exitcode.Ok, Return: []byte{3}, GasUsed: 1000},
+		ExecutionTrace: types.ExecutionTrace{
+			Msg:    msg,
+			MsgRct: &types.MessageReceipt{ExitCode: exitcode.Ok, Return: []byte{3}, GasUsed: 1000},
+			Subcalls: []types.ExecutionTrace{{
+				Msg:    &types.Message{From: to, To: sub, Value: abi.NewTokenAmount(1), Method: 3},
+				MsgRct: &types.MessageReceipt{ExitCode: exitcode.ErrForbidden, GasUsed: 100},
+			}},
+		},
+		GasCosts: &vm.GasOutputs{
+			BaseFeeBurn:        abi.NewTokenAmount(10),
+			OverEstimationBurn: big.Zero(),
+			MinerPenalty:       big.Zero(),
+			MinerTip:           abi.NewTokenAmount(5),
+			Refund:             abi.NewTokenAmount(1),
+			GasRefund:          3,
+		},
+	}, false))
+	tr := rec.Trace()
+	tr.State = ts.ParentState()
+
+	require.Len(t, tr.Messages, 1)
+	require.Equal(t, Call{
+		From:     to,
+		To:       sub,
+		Value:    abi.NewTokenAmount(1),
+		Method:   3,
+		ExitCode: exitcode.ErrForbidden,
+		GasUsed:  100,
+	}, tr.Messages[0].Call.Subcalls[0])
+
+	require.Equal(t, []VMTrace{{
+		Epoch:          ts.Height(),
+		StateBase:      ts.ParentState(),
+		NetworkVersion: uint64(network.Version16),
+		BaseFee:        abi.NewTokenAmount(100),
+		Messages:       1,
+		CircSupply:     []abi.TokenAmount{abi.NewTokenAmount(1000)},
+		Randomness: []Randomness{{
+			Beacon:          true,
+			Personalization: crypto.DomainSeparationTag_ElectionProofProduction,
+			Round:           5,
+			Entropy:         []byte{4},
+			Value:           []byte{5, 4},
+		}},
+	}}, tr.VMs)
+	require.Equal(t, msg.Cid(), tr.Messages[0].message().Cid())
+
+	for _, f := range []Format{FormatJSON, FormatCBOR} {
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, tr, f))
+		decoded, err := Decode(&buf, f)
+		require.NoError(t, err)
+		require.NoError(t, Compare(tr, decoded), "format %s", f)
+		require.Equal(t, tr.VMs, decoded.VMs, "format %s", f)
+	}
+
+	// the first divergence is reported
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, tr, FormatCBOR))
+	diverged, err := Decode(&buf, FormatCBOR)
+	require.NoError(t, err)
+	diverged.Messages[0].Call.Subcalls[0].ExitCode = exitcode.Ok
+	diverged.Messages[0].Call.GasUsed = 900
+
+	err = Compare(tr, diverged)
+	var d *Divergence
+	require.ErrorAs(t, err, &d)
+	require.Equal(t, "Messages[0].Call.Subcalls[0].ExitCode", d.Path)
+	require.Equal(t, exitcode.ErrForbidden, d.Expected)
+
+	diverged.Messages = nil
+	require.ErrorAs(t, Compare(tr, diverged), &d)
+	require.Equal(t, "len(Messages)", d.Path)
+}
+
+func TestReplayedInputs(t *testing.T) {
+	ctx := context.Background()
+
+	r := &replayedRand{recorded: []Randomness{
+		{Personalization: crypto.DomainSeparationTag_TicketProduction, Round: 3, Entropy: []byte{1}, Value: []byte{3, 1}},
+		{Beacon: true, Personalization: crypto.DomainSeparationTag_TicketProduction, Round: 4, Value: []byte{4}},
+	}}
+	v, err := r.GetChainRandomness(ctx, crypto.DomainSeparationTag_TicketProduction, 3, []byte{1})
+	require.NoError(t, err)
+	require.Equal(t, []byte{3, 1}, v)
+	// randomness drawn differently than recorded
+	_, err = r.GetChainRandomness(ctx, crypto.DomainSeparationTag_TicketProduction, 4, nil)
+	require.Error(t, err)
+	v, err = r.GetBeaconRandomness(ctx, crypto.DomainSeparationTag_TicketProduction, 4, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{4}, v)
+	_, err = r.GetBeaconRandomness(ctx, crypto.DomainSeparationTag_TicketProduction, 4, nil)
+	require.Error(t, err)
+
+	circ := replayedCircSupply([]abi.TokenAmount{abi.NewTokenAmount(1)})
+	cs, err := circ(ctx, 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, abi.NewTokenAmount(1), cs)
+	_, err = circ(ctx, 1, nil)
+	require.Error(t, err)
+}
+
+// fixedRand is randomness derived from the round and the entropy.
+type fixedRand struct{}
+
+func (fixedRand) GetChainRandomness(_ context.Context, _ crypto.DomainSeparationTag, round abi.ChainEpoch, entropy []byte) ([]byte, error) {
+	return append([]byte{byte(round)}, entropy...), nil
+}
+
+func (fixedRand) GetBeaconRandomness(_ context.Context, _ crypto.DomainSeparationTag, round abi.ChainEpoch, entropy []byte) ([]byte, error) {
+	return append([]byte{byte(round)}, entropy...), nil
+}
+
+func fixedCircSupply(context.Context, abi.ChainEpoch, *state.StateTree) (abi.TokenAmount, error) {
+	return abi.NewTokenAmount(1000), nil
+}
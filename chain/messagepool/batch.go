@@ -0,0 +1,223 @@
+package messagepool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var (
+	ErrBatchRejected = errors.New("message batch rejected")
+	ErrBatchNonce    = errors.New("non consecutive nonce in message batch")
+)
+
+// batchAdded is a message of a batch added to the pool, with the message it
+// replaced if any, to roll its addition back.
+type batchAdded struct {
+	msg      *types.SignedMessage
+	replaced *types.SignedMessage
+	reps     replacements
+}
+
+// PushBatch adds the local messages msgs to the pool all or nothing: when one
+// of them is invalid none is added. The messages of a sender must have
+// consecutive nonces, in the order of the batch, the first one not leaving a
+// gap after the pending messages of the sender.
+//
+// The returned errors are those of the messages, in the order of msgs, nil for
+// the valid ones; the error returned along wraps ErrBatchRejected when some
+// message is invalid.
+func (mp *MessagePool) PushBatch(ctx context.Context, msgs []*types.SignedMessage, publish bool) ([]error, error) {
+	if len(msgs) == 0 {
+		return nil, xerrors.Errorf("empty message batch")
+	}
+
+	errs := make([]error, len(msgs))
+	failed := false
+	for i, m := range msgs {
+		if err := mp.checkMessage(ctx, m); err != nil {
+			errs[i] = err
+			failed = true
+		}
+	}
+	if failed {
+		return errs, xerrors.Errorf("invalid messages: %w", ErrBatchRejected)
+	}
+
+	prior := make(map[address.Address][]*types.SignedMessage)
+	for i, m := range msgs {
+		if err := mp.simulate(ctx, m, prior[m.Message.From]); err != nil {
+			errs[i] = err
+			failed = true
+		}
+		prior[m.Message.From] = append(prior[m.Message.From], m)
+	}
+	if failed {
+		return errs, xerrors.Errorf("messages would abort: %w", ErrBatchRejected)
+	}
+
+	// serialize push access to reduce lock contention
+	mp.addSema <- struct{}{}
+	defer func() {
+		<-mp.addSema
+	}()
+
+	mp.curTsLk.Lock()
+	toPublish, err := mp.addBatch(ctx, msgs, errs)
+	mp.curTsLk.Unlock()
+	if err != nil {
+		return errs, err
+	}
+
+	if !publish {
+		return errs, nil
+	}
+	for _, m := range toPublish {
+		msgb, err := m.Serialize()
+		if err != nil {
+			return errs, xerrors.Errorf("error serializing message: %w", err)
+		}
+
+		if err := mp.api.PubSubPublish(build.MessagesTopic(mp.netName), msgb); err != nil {
+			return errs, xerrors.Errorf("error publishing message %s: %w", m.Cid(), err)
+		}
+	}
+
+	return errs, nil
+}
+
+// addBatch adds the batch msgs, setting the errors of the invalid messages in
+// errs, and returns the messages to publish. The caller holds curTsLk.
+func (mp *MessagePool) addBatch(ctx context.Context, msgs []*types.SignedMessage, errs []error) ([]*types.SignedMessage, error) {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	curTs := mp.curTs
+	seen := make(map[address.Address]uint64, len(msgs))
+	added := make([]batchAdded, 0, len(msgs))
+	var toPublish []*types.SignedMessage
+	failed := false
+
+	for i, m := range msgs {
+		publish, a, err := mp.addBatchMsg(ctx, m, curTs, seen)
+		if err != nil {
+			errs[i] = err
+			failed = true
+			continue
+		}
+		added = append(added, a)
+		if publish {
+			toPublish = append(toPublish, m)
+		}
+	}
+
+	if failed {
+		mp.rollbackBatch(ctx, added)
+		return nil, xerrors.Errorf("invalid messages: %w", ErrBatchRejected)
+	}
+
+	for i, a := range added {
+		if err := mp.addLocal(ctx, a.msg); err != nil {
+			for _, p := range added[:i] {
+				if derr := mp.localMsgs.Delete(ctx, datastore.NewKey(string(p.msg.Cid().Bytes()))); derr != nil {
+					log.Errorf("deleting local message %s of a rejected batch: %s", p.msg.Cid(), derr)
+				}
+			}
+			mp.rollbackBatch(ctx, added)
+			return nil, xerrors.Errorf("error persisting local message: %w", err)
+		}
+	}
+
+	for _, a := range added {
+		mp.localJournal.pushed(ctx, a.msg)
+	}
+	for from := range seen {
+		mp.promoteParked(ctx, from)
+	}
+
+	return toPublish, nil
+}
+
+// addBatchMsg checks and adds a message of a batch; seen holds the next nonce
+// in the batch of the senders already seen.
+func (mp *MessagePool) addBatchMsg(ctx context.Context, m *types.SignedMessage, curTs *types.TipSet, seen map[address.Address]uint64) (bool, batchAdded, error) {
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		return false, batchAdded{}, xerrors.Errorf("failed to resolve sender: %s: %w", err, ErrSoftValidationFailure)
+	}
+
+	if next, ok := seen[from]; ok {
+		if m.Message.Nonce != next {
+			return false, batchAdded{}, xerrors.Errorf("expected nonce %d in the batch, got %d: %w", next, m.Message.Nonce, ErrBatchNonce)
+		}
+	} else {
+		snonce, err := mp.getStateNonce(ctx, m.Message.From, curTs)
+		if err != nil {
+			return false, batchAdded{}, xerrors.Errorf("failed to look up actor state nonce: %s: %w", err, ErrSoftValidationFailure)
+		}
+		if snonce > m.Message.Nonce {
+			return false, batchAdded{}, xerrors.Errorf("minimum expected nonce is %d: %w", snonce, ErrNonceTooLow)
+		}
+
+		next, err := mp.getNonceLocked(ctx, m.Message.From, curTs)
+		if err != nil {
+			return false, batchAdded{}, xerrors.Errorf("failed to get the next nonce: %s: %w", err, ErrSoftValidationFailure)
+		}
+		if m.Message.Nonce > next {
+			return false, batchAdded{}, xerrors.Errorf("batch of %s starts at nonce %d, its next nonce is %d: %w", from, m.Message.Nonce, next, ErrNonceGap)
+		}
+	}
+	seen[from] = m.Message.Nonce + 1
+
+	publish, err := mp.verifyMsgBeforeAdd(ctx, m, curTs, true)
+	if err != nil {
+		return false, batchAdded{}, err
+	}
+
+	if err := mp.checkBalance(ctx, m, curTs); err != nil {
+		return false, batchAdded{}, err
+	}
+
+	a := batchAdded{msg: m}
+	mset, ok, err := mp.getPendingMset(ctx, from)
+	if err != nil {
+		return false, batchAdded{}, err
+	}
+	if ok {
+		a.replaced = mset.msgs[m.Message.Nonce]
+		a.reps = mset.replaced[m.Message.Nonce]
+	}
+
+	if err := mp.addLocked(ctx, m, false, false); err != nil {
+		return false, batchAdded{}, err
+	}
+
+	return publish, a, nil
+}
+
+// rollbackBatch removes the messages of a batch added to the pool, in the
+// reverse order, putting back the messages they replaced.
+func (mp *MessagePool) rollbackBatch(ctx context.Context, added []batchAdded) {
+	for i := len(added) - 1; i >= 0; i-- {
+		a := added[i]
+		mp.remove(ctx, a.msg.Message.From, a.msg.Message.Nonce, false)
+		if a.replaced == nil {
+			continue
+		}
+
+		if err := mp.addLocked(ctx, a.replaced, false, false); err != nil {
+			log.Errorf("restoring message %s replaced in a rejected batch: %s", a.replaced.Cid(), err)
+			continue
+		}
+		if mset, ok, err := mp.getPendingMset(ctx, a.replaced.Message.From); err == nil && ok {
+			mset.replaced[a.replaced.Message.Nonce] = a.reps
+		}
+	}
+}
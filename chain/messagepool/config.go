@@ -8,6 +8,10 @@ import (
 
 	"github.com/ipfs/go-datastore"
 
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
@@ -18,6 +22,7 @@ var (
 	MemPoolSizeLimitLoDefault = 20000
 	PruneCooldownDefault      = time.Minute
 	GasLimitOverestimation    = 1.25
+	ParkTTLDefault            = time.Duration(10*build.BlockDelaySecs) * time.Second
 
 	ConfigKey = datastore.NewKey("/mpool/config")
 )
@@ -67,9 +72,30 @@ func validateConfg(cfg *types.MpoolConfig) error {
 	if cfg.GasLimitOverestimation < 1 {
 		return fmt.Errorf("'GasLimitOverestimation' cannot be less than 1")
 	}
+	if cfg.MaxReplacementsPerEpoch < 0 {
+		return fmt.Errorf("'MaxReplacementsPerEpoch' cannot be negative")
+	}
+	if cfg.ParkNonceGap > 0 && cfg.ParkTTL <= 0 {
+		return fmt.Errorf("'ParkTTL' must be positive when parking is enabled")
+	}
+	if cfg.SenderMsgsPerEpoch < 0 || cfg.SenderBytesPerEpoch < 0 {
+		return fmt.Errorf("'SenderMsgsPerEpoch' and 'SenderBytesPerEpoch' cannot be negative")
+	}
+	if cfg.SpamScoreThreshold < 0 {
+		return fmt.Errorf("'SpamScoreThreshold' cannot be negative")
+	}
 	return nil
 }
 
+// RBFPolicy returns the replace by fee policy in effect.
+func (mp *MessagePool) RBFPolicy() api.MpoolRBFPolicy {
+	cfg := mp.getConfig()
+	return api.MpoolRBFPolicy{
+		MinPremiumBumpPercent:   float64(rbfNum(cfg.ReplaceByFeeRatio)) * 100 / RbfDenom,
+		MaxReplacementsPerEpoch: cfg.MaxReplacementsPerEpoch,
+	}
+}
+
 func (mp *MessagePool) SetConfig(ctx context.Context, cfg *types.MpoolConfig) error {
 	if err := validateConfg(cfg); err != nil {
 		return err
@@ -87,6 +113,42 @@ func (mp *MessagePool) SetConfig(ctx context.Context, cfg *types.MpoolConfig) er
 	return nil
 }
 
+// SetPriorityAddrs sets the priority addresses of the node config, used along
+// with those of the mpool config. The messages of the priority addresses are
+// never pruned, are selected first, and aren't limited when received from the
+// network.
+func (mp *MessagePool) SetPriorityAddrs(addrs []address.Address) {
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+	mp.nodePriorityAddrs = append([]address.Address(nil), addrs...)
+}
+
+// priorityAddrs returns the priority addresses of the mpool and node configs.
+func (mp *MessagePool) priorityAddrs() []address.Address {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+	return append(append([]address.Address(nil), mp.cfg.PriorityAddrs...), mp.nodePriorityAddrs...)
+}
+
+// isPriority returns whether from is a priority address. The caller holds lk.
+func (mp *MessagePool) isPriority(ctx context.Context, from address.Address) bool {
+	key, err := mp.resolveToKey(ctx, from)
+	if err != nil {
+		return false
+	}
+	for _, actor := range mp.priorityAddrs() {
+		pk, err := mp.resolveToKey(ctx, actor)
+		if err != nil {
+			log.Debugf("failed to resolve priority address %s: %s", actor, err)
+			continue
+		}
+		if pk == key {
+			return true
+		}
+	}
+	return false
+}
+
 func DefaultConfig() *types.MpoolConfig {
 	return &types.MpoolConfig{
 		SizeLimitHigh:          MemPoolSizeLimitHiDefault,
@@ -94,5 +156,6 @@ func DefaultConfig() *types.MpoolConfig {
 		ReplaceByFeeRatio:      ReplaceByFeeRatioDefault,
 		PruneCooldown:          PruneCooldownDefault,
 		GasLimitOverestimation: GasLimitOverestimation,
+		ParkTTL:                ParkTTLDefault,
 	}
 }
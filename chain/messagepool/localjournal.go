@@ -0,0 +1,372 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const localJournalDs = "/mpool/journal"
+
+// LocalJournalRetention is how long the local message journal keeps the
+// messages that landed, were replaced or expired.
+var LocalJournalRetention = 7 * 24 * time.Hour
+
+// journalRecord is a record of the local message journal: a local message
+// pushed, with its Message, or a change of the status of one.
+type journalRecord struct {
+	Cid        cid.Cid
+	Status     api.MpoolMessageStatus
+	Time       time.Time
+	Epoch      abi.ChainEpoch       `json:",omitempty"`
+	ReplacedBy *cid.Cid             `json:",omitempty"`
+	Message    *types.SignedMessage `json:",omitempty"`
+}
+
+type journalEntry struct {
+	api.MpoolJournalEntry
+
+	// keys are the keys of the records of the entry
+	keys []datastore.Key
+}
+
+// localJournal is the append-only journal of the local messages, tracking
+// their status from their push until they land, are replaced or expire.
+// Records are only ever appended, except when the entries past the retention
+// are compacted away on load.
+type localJournal struct {
+	lk      sync.Mutex
+	ds      datastore.Datastore
+	seq     uint64
+	entries map[cid.Cid]*journalEntry
+}
+
+func newLocalJournal(ctx context.Context, ds datastore.Datastore) (*localJournal, error) {
+	j := &localJournal{
+		ds:      ds,
+		entries: make(map[cid.Cid]*journalEntry),
+	}
+
+	res, err := ds.Query(ctx, query.Query{Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		return nil, xerrors.Errorf("querying local message journal: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading local message journal: %w", r.Error)
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimPrefix(r.Key, "/"), 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing local message journal key %s: %w", r.Key, err)
+		}
+		if seq > j.seq {
+			j.seq = seq
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal(r.Value, &rec); err != nil {
+			return nil, xerrors.Errorf("parsing local message journal record %s: %w", r.Key, err)
+		}
+		j.replay(datastore.NewKey(r.Key), &rec)
+	}
+
+	j.compact(ctx)
+
+	return j, nil
+}
+
+// replay applies rec, at key, to the entries.
+func (j *localJournal) replay(key datastore.Key, rec *journalRecord) {
+	e, ok := j.entries[rec.Cid]
+	if !ok {
+		if rec.Message == nil {
+			log.Warnf("local message journal record %s is for unknown message %s", key, rec.Cid)
+			return
+		}
+		e = &journalEntry{MpoolJournalEntry: api.MpoolJournalEntry{
+			Message: rec.Message,
+			Cid:     rec.Cid,
+			Added:   rec.Time,
+		}}
+		j.entries[rec.Cid] = e
+	}
+
+	e.Status = rec.Status
+	e.Updated = rec.Time
+	e.Epoch = rec.Epoch
+	e.ReplacedBy = rec.ReplacedBy
+	e.keys = append(e.keys, key)
+}
+
+// compact deletes the records of the entries past the retention.
+func (j *localJournal) compact(ctx context.Context) {
+	cutoff := build.Clock.Now().Add(-LocalJournalRetention)
+	for c, e := range j.entries {
+		if e.Status == api.MpoolMessagePending || e.Updated.After(cutoff) {
+			continue
+		}
+
+		for _, k := range e.keys {
+			if err := j.ds.Delete(ctx, k); err != nil {
+				log.Warnf("deleting local message journal record %s: %s", k, err)
+			}
+		}
+		delete(j.entries, c)
+	}
+}
+
+// append appends rec to the journal, and applies it.
+// Must be called with the lock held.
+func (j *localJournal) append(ctx context.Context, rec journalRecord) {
+	rec.Time = build.Clock.Now()
+
+	b, err := json.Marshal(&rec)
+	if err != nil {
+		log.Errorf("marshaling local message journal record: %s", err)
+		return
+	}
+
+	j.seq++
+	key := datastore.NewKey(fmt.Sprintf("%020d", j.seq))
+	if err := j.ds.Put(ctx, key, b); err != nil {
+		log.Errorf("writing local message journal record: %s", err)
+		return
+	}
+
+	j.replay(key, &rec)
+}
+
+// pushed records the local message m, marking the other pending messages with
+// its sender and nonce as replaced by it.
+func (j *localJournal) pushed(ctx context.Context, m *types.SignedMessage) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	c := m.Cid()
+	if e, ok := j.entries[c]; ok && e.Status == api.MpoolMessagePending {
+		return
+	}
+
+	for _, e := range j.entries {
+		if e.Status == api.MpoolMessagePending && e.Message.Message.From == m.Message.From && e.Message.Message.Nonce == m.Message.Nonce {
+			j.append(ctx, journalRecord{Cid: e.Cid, Status: api.MpoolMessageReplaced, ReplacedBy: &c})
+		}
+	}
+
+	j.append(ctx, journalRecord{Cid: c, Status: api.MpoolMessagePending, Message: m})
+}
+
+// landed marks the pending message c as landed at epoch.
+func (j *localJournal) landed(ctx context.Context, c cid.Cid, epoch abi.ChainEpoch) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	if e, ok := j.entries[c]; ok && e.Status != api.MpoolMessageLanded {
+		j.append(ctx, journalRecord{Cid: c, Status: api.MpoolMessageLanded, Epoch: epoch})
+	}
+}
+
+// reverted marks the message c, which landed in a reverted tipset, as pending
+// again.
+func (j *localJournal) reverted(ctx context.Context, c cid.Cid) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	if e, ok := j.entries[c]; ok && e.Status == api.MpoolMessageLanded {
+		j.append(ctx, journalRecord{Cid: c, Status: api.MpoolMessagePending})
+	}
+}
+
+// settle marks the pending messages with a nonce below the state nonce of
+// their sender as replaced, another message with their nonce having landed.
+func (j *localJournal) settle(ctx context.Context, stateNonce func(address.Address) (uint64, error)) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	nonces := make(map[address.Address]uint64)
+	for _, e := range j.entries {
+		if e.Status != api.MpoolMessagePending {
+			continue
+		}
+
+		from := e.Message.Message.From
+		nonce, ok := nonces[from]
+		if !ok {
+			var err error
+			nonce, err = stateNonce(from)
+			if err != nil {
+				log.Debugf("getting state nonce of local message sender %s: %s", from, err)
+				continue
+			}
+			nonces[from] = nonce
+		}
+
+		if e.Message.Message.Nonce < nonce {
+			j.append(ctx, journalRecord{Cid: e.Cid, Status: api.MpoolMessageReplaced})
+		}
+	}
+}
+
+// cleared marks the pending messages as expired, all the local messages having
+// been cleared from the mpool.
+func (j *localJournal) cleared(ctx context.Context) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	for _, e := range j.entries {
+		if e.Status == api.MpoolMessagePending {
+			j.append(ctx, journalRecord{Cid: e.Cid, Status: api.MpoolMessageExpired})
+		}
+	}
+}
+
+// list returns the entries with one of the statuses, all of them if none are
+// given, in the order they were pushed.
+func (j *localJournal) list(statuses ...api.MpoolMessageStatus) []api.MpoolJournalEntry {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	out := make([]api.MpoolJournalEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		if len(statuses) > 0 && !hasStatus(statuses, e.Status) {
+			continue
+		}
+		out = append(out, e.MpoolJournalEntry)
+	}
+
+	sort.Slice(out, func(i, k int) bool {
+		return out[i].Added.Before(out[k].Added)
+	})
+	return out
+}
+
+func (j *localJournal) get(c cid.Cid) (api.MpoolJournalEntry, bool) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	e, ok := j.entries[c]
+	if !ok {
+		return api.MpoolJournalEntry{}, false
+	}
+	return e.MpoolJournalEntry, true
+}
+
+func hasStatus(statuses []api.MpoolMessageStatus, s api.MpoolMessageStatus) bool {
+	for _, st := range statuses {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalJournal returns the local messages of the journal with one of the
+// statuses, all of them if none are given.
+func (mp *MessagePool) LocalJournal(statuses ...api.MpoolMessageStatus) []api.MpoolJournalEntry {
+	return mp.localJournal.list(statuses...)
+}
+
+// Rebroadcast publishes again the pending local messages cids, all of them if
+// none are given, adding them back to the mpool if they aren't in it anymore.
+// It returns the messages published.
+func (mp *MessagePool) Rebroadcast(ctx context.Context, cids []cid.Cid) ([]cid.Cid, error) {
+	var msgs []*types.SignedMessage
+	if len(cids) == 0 {
+		for _, e := range mp.localJournal.list(api.MpoolMessagePending) {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	for _, c := range cids {
+		e, ok := mp.localJournal.get(c)
+		if !ok {
+			return nil, xerrors.Errorf("message %s is not in the local message journal", c)
+		}
+		if e.Status != api.MpoolMessagePending {
+			return nil, xerrors.Errorf("message %s is %s, not pending", c, e.Status)
+		}
+		msgs = append(msgs, e.Message)
+	}
+
+	var out []cid.Cid
+	for _, m := range msgs {
+		if !mp.hasPending(ctx, m) {
+			if _, err := mp.Push(ctx, m, false); err != nil {
+				return out, xerrors.Errorf("adding message %s back to the mpool: %w", m.Cid(), err)
+			}
+		}
+
+		msgb, err := m.Serialize()
+		if err != nil {
+			return out, xerrors.Errorf("error serializing message: %w", err)
+		}
+		if err := mp.api.PubSubPublish(build.MessagesTopic(mp.netName), msgb); err != nil {
+			return out, xerrors.Errorf("error publishing message: %w", err)
+		}
+		out = append(out, m.Cid())
+	}
+
+	return out, nil
+}
+
+// hasPending returns whether m is pending in the mpool.
+func (mp *MessagePool) hasPending(ctx context.Context, m *types.SignedMessage) bool {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	return mp.hasPendingLocked(ctx, m)
+}
+
+func (mp *MessagePool) hasPendingLocked(ctx context.Context, m *types.SignedMessage) bool {
+	mset, ok, err := mp.getPendingMset(ctx, m.Message.From)
+	if err != nil || !ok {
+		return false
+	}
+	pm, ok := mset.msgs[m.Message.Nonce]
+	return ok && pm.Cid() == m.Cid()
+}
+
+// loadJournal adds back the pending local messages of the journal missing from
+// the mpool.
+// Must be called with the lock held.
+func (mp *MessagePool) loadJournal(ctx context.Context) error {
+	for _, e := range mp.localJournal.list(api.MpoolMessagePending) {
+		if mp.hasPendingLocked(ctx, e.Message) {
+			continue
+		}
+
+		if err := mp.addLoaded(ctx, e.Message); err != nil {
+			if xerrors.Is(err, ErrNonceTooLow) {
+				continue // settled on the next head change
+			}
+
+			log.Errorf("adding journaled local message %s: %+v", e.Cid, err)
+			continue
+		}
+
+		if err := mp.addLocal(ctx, e.Message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// stm: #unit
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/wallet"
+)
+
+func TestLocalJournal(t *testing.T) {
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(ctx, tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	require.NoError(t, err)
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	from, err := w.WalletNew(ctx, types.KTBLS)
+	require.NoError(t, err)
+	tma.setBalance(from, 1000e9)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+
+	statuses := func(mp *MessagePool) map[cid.Cid]api.MpoolMessageStatus {
+		out := make(map[cid.Cid]api.MpoolMessageStatus)
+		for _, e := range mp.LocalJournal() {
+			out[e.Cid] = e.Status
+		}
+		return out
+	}
+	push := func(m *types.SignedMessage) {
+		_, err := mp.Push(ctx, m, true)
+		require.NoError(t, err)
+	}
+
+	m0 := makeTestMessage(w, from, to, 0, 50_000_000, gasPrice)
+	m1 := makeTestMessage(w, from, to, 1, 50_000_000, gasPrice)
+	push(m0)
+	push(m1)
+
+	// replaced by fee
+	m0r := makeTestMessage(w, from, to, 0, 50_000_000, 2*gasPrice)
+	push(m0r)
+	require.Equal(t, map[cid.Cid]api.MpoolMessageStatus{
+		m0.Cid():  api.MpoolMessageReplaced,
+		m0r.Cid(): api.MpoolMessagePending,
+		m1.Cid():  api.MpoolMessagePending,
+	}, statuses(mp))
+	e, ok := mp.localJournal.get(m0.Cid())
+	require.True(t, ok)
+	require.Equal(t, m0r.Cid(), *e.ReplacedBy)
+
+	// landed, reverted, and landed again
+	a := tma.nextBlock()
+	tma.setBlockMessages(a, m0r)
+	tma.applyBlock(t, a)
+	require.Equal(t, api.MpoolMessageLanded, statuses(mp)[m0r.Cid()])
+	tma.revertBlock(t, a)
+	require.Equal(t, api.MpoolMessagePending, statuses(mp)[m0r.Cid()])
+	tma.applyBlock(t, a)
+	require.Equal(t, api.MpoolMessageLanded, statuses(mp)[m0r.Cid()])
+	e, ok = mp.localJournal.get(m0r.Cid())
+	require.True(t, ok)
+	require.Equal(t, a.Height, e.Epoch)
+
+	// another message with the nonce of m1 landed
+	m1o := makeTestMessage(w, from, to, 1, 50_000_000, 3*gasPrice)
+	b := tma.nextBlock()
+	tma.setBlockMessages(b, m1o)
+	tma.setStateNonce(from, 1)
+	tma.applyBlock(t, b)
+	require.Equal(t, api.MpoolMessageReplaced, statuses(mp)[m1.Cid()])
+
+	require.Len(t, mp.LocalJournal(api.MpoolMessagePending), 0)
+
+	// a pending message dropped from the local messages comes back from the
+	// journal on restart
+	m2 := makeTestMessage(w, from, to, 2, 50_000_000, gasPrice)
+	push(m2)
+	require.NoError(t, mp.localMsgs.Delete(ctx, datastore.NewKey(string(m2.Cid().Bytes()))))
+	require.NoError(t, mp.Close())
+
+	mp, err = New(ctx, tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	require.NoError(t, err)
+
+	pending, _ := mp.Pending(ctx)
+	require.Len(t, pending, 1)
+	require.Equal(t, m2.Cid(), pending[0].Cid())
+	require.Len(t, mp.LocalJournal(), 4)
+
+	published := tma.published
+	rebroadcast, err := mp.Rebroadcast(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m2.Cid()}, rebroadcast)
+	require.Equal(t, published+1, tma.published)
+
+	_, err = mp.Rebroadcast(ctx, []cid.Cid{m0.Cid()})
+	require.Error(t, err)
+
+	mp.Clear(ctx, true)
+	require.Equal(t, api.MpoolMessageExpired, statuses(mp)[m2.Cid()])
+}
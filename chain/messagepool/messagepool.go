@@ -47,7 +47,6 @@ var log = logging.Logger("messagepool")
 
 var futureDebug = false
 
-var rbfNumBig = types.NewInt(uint64((ReplaceByFeeRatioDefault - 1) * RbfDenom))
 var rbfDenomBig = types.NewInt(RbfDenom)
 
 const RbfDenom = 256
@@ -83,6 +82,7 @@ var (
 	ErrTooManyPendingMessages = errors.New("too many pending messages for actor")
 	ErrNonceGap               = errors.New("unfulfilled nonce gap")
 	ErrExistingNonce          = errors.New("message with nonce already exists")
+	ErrTooManyReplacements    = errors.New("too many replacements of message in epoch")
 )
 
 const (
@@ -141,11 +141,22 @@ type MessagePool struct {
 
 	keyCache map[address.Address]address.Address
 
+	// parked holds the messages with too big a nonce gap to be added, by
+	// sender, see park
+	parked      map[address.Address]map[uint64]*parkedMsg
+	parkedCount int
+
+	// senderRates counts the messages of the rate limited senders in the
+	// current epoch
+	senderRates map[address.Address]senderRate
+
 	curTsLk sync.Mutex // DO NOT LOCK INSIDE lk
 	curTs   *types.TipSet
 
 	cfgLk sync.RWMutex
 	cfg   *types.MpoolConfig
+	// nodePriorityAddrs are the priority addresses of the node config
+	nodePriorityAddrs []address.Address
 
 	api Provider
 
@@ -167,6 +178,8 @@ type MessagePool struct {
 
 	localMsgs datastore.Datastore
 
+	localJournal *localJournal
+
 	netName dtypes.NetworkName
 
 	sigValCache *lru.TwoQueueCache
@@ -186,6 +199,17 @@ type msgSet struct {
 	msgs          map[uint64]*types.SignedMessage
 	nextNonce     uint64
 	requiredFunds *stdbig.Int
+
+	// replaced counts the replacements of the messages by nonce, in the epoch
+	// of the last one
+	replaced map[uint64]replacements
+	// added holds the times the messages were added, by nonce
+	added map[uint64]time.Time
+}
+
+type replacements struct {
+	epoch abi.ChainEpoch
+	count int
 }
 
 func newMsgSet(nonce uint64) *msgSet {
@@ -193,14 +217,31 @@ func newMsgSet(nonce uint64) *msgSet {
 		msgs:          make(map[uint64]*types.SignedMessage),
 		nextNonce:     nonce,
 		requiredFunds: stdbig.NewInt(0),
+		replaced:      make(map[uint64]replacements),
+		added:         make(map[uint64]time.Time),
 	}
 }
 
+// ComputeMinRBF returns the minimum premium of a message replacing one of
+// premium curPrem, with the default replace by fee ratio.
 func ComputeMinRBF(curPrem abi.TokenAmount) abi.TokenAmount {
+	return ComputeRBF(curPrem, ReplaceByFeeRatioDefault)
+}
+
+// ComputeRBF returns the minimum premium of a message replacing one of premium
+// curPrem, with the replace by fee ratio replaceByFeeRatio.
+func ComputeRBF(curPrem abi.TokenAmount, replaceByFeeRatio float64) abi.TokenAmount {
+	rbfNumBig := types.NewInt(rbfNum(replaceByFeeRatio))
 	minPrice := types.BigAdd(curPrem, types.BigDiv(types.BigMul(curPrem, rbfNumBig), rbfDenomBig))
 	return types.BigAdd(minPrice, types.NewInt(1))
 }
 
+// rbfNum returns the minimum premium increase, in RbfDenom of the premium, of
+// the replace by fee ratio replaceByFeeRatio.
+func rbfNum(replaceByFeeRatio float64) uint64 {
+	return uint64((replaceByFeeRatio - 1) * RbfDenom)
+}
+
 func CapGasFee(mff dtypes.DefaultMaxFeeFunc, msg *types.Message, sendSpec *api.MessageSendSpec) {
 	var maxFee abi.TokenAmount
 	if sendSpec != nil {
@@ -261,8 +302,25 @@ func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted
 		}
 
 		if m.Cid() != exms.Cid() {
+			cfg := mp.getConfig()
+
+			// check the replacements of the nonce in this epoch, remote messages
+			// only, as for the other limits
+			var epoch abi.ChainEpoch
+			if mp.curTs != nil {
+				epoch = mp.curTs.Height()
+			}
+			rep := ms.replaced[m.Message.Nonce]
+			if rep.epoch != epoch {
+				rep = replacements{epoch: epoch}
+			}
+			if strict && cfg.MaxReplacementsPerEpoch > 0 && rep.count >= cfg.MaxReplacementsPerEpoch {
+				return false, xerrors.Errorf("message from %s with nonce %d already replaced %d times at epoch %d: %w",
+					m.Message.From, m.Message.Nonce, rep.count, epoch, ErrTooManyReplacements)
+			}
+
 			// check if RBF passes
-			minPrice := ComputeMinRBF(exms.Message.GasPremium)
+			minPrice := ComputeRBF(exms.Message.GasPremium, cfg.ReplaceByFeeRatio)
 			if types.BigCmp(m.Message.GasPremium, minPrice) >= 0 {
 				log.Debugw("add with RBF", "oldpremium", exms.Message.GasPremium,
 					"newpremium", m.Message.GasPremium, "addr", m.Message.From, "nonce", m.Message.Nonce)
@@ -276,6 +334,9 @@ func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted
 					m.Message.From, m.Message.Nonce, minPrice, m.Message.GasPremium,
 					ErrRBFTooLowPremium)
 			}
+
+			rep.count++
+			ms.replaced[m.Message.Nonce] = rep
 		} else {
 			return false, xerrors.Errorf("message from %s with nonce %d already in mpool: %w",
 				m.Message.From, m.Message.Nonce, ErrExistingNonce)
@@ -297,6 +358,7 @@ func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted
 
 	ms.nextNonce = nextNonce
 	ms.msgs[m.Message.Nonce] = m
+	ms.added[m.Message.Nonce] = build.Clock.Now()
 	ms.requiredFunds.Add(ms.requiredFunds, m.Message.RequiredFunds().Int)
 	//ms.requiredFunds.Add(ms.requiredFunds, m.Message.Value.Int)
 
@@ -320,6 +382,8 @@ func (ms *msgSet) rm(nonce uint64, applied bool) {
 	ms.requiredFunds.Sub(ms.requiredFunds, m.Message.RequiredFunds().Int)
 	//ms.requiredFunds.Sub(ms.requiredFunds, m.Message.Value.Int)
 	delete(ms.msgs, nonce)
+	delete(ms.replaced, nonce)
+	delete(ms.added, nonce)
 
 	// adjust next nonce
 	if applied {
@@ -378,6 +442,11 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		j = journal.NilJournal()
 	}
 
+	lj, err := newLocalJournal(ctx, namespace.Wrap(ds, datastore.NewKey(localJournalDs)))
+	if err != nil {
+		return nil, xerrors.Errorf("error loading local message journal: %w", err)
+	}
+
 	mp := &MessagePool{
 		ds:             ds,
 		addSema:        make(chan struct{}, 1),
@@ -387,6 +456,7 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		localAddrs:     make(map[address.Address]struct{}),
 		pending:        make(map[address.Address]*msgSet),
 		keyCache:       make(map[address.Address]address.Address),
+		senderRates:    make(map[address.Address]senderRate),
 		minGasPrice:    types.NewInt(0),
 		getNtwkVersion: us.GetNtwkVersion,
 		pruneTrigger:   make(chan struct{}, 1),
@@ -396,6 +466,7 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		nonceCache:     noncecache,
 		changes:        lps.New(50),
 		localMsgs:      namespace.Wrap(ds, datastore.NewKey(localMsgsDs)),
+		localJournal:   lj,
 		api:            api,
 		netName:        netName,
 		cfg:            cfg,
@@ -427,6 +498,9 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 	go func() {
 		defer cancel()
 		err := mp.loadLocal(ctx)
+		if err == nil {
+			err = mp.loadJournal(ctx)
+		}
 
 		mp.lk.Unlock()
 		mp.curTsLk.Unlock()
@@ -558,6 +632,19 @@ func (mp *MessagePool) forEachLocal(ctx context.Context, f func(context.Context,
 	}
 }
 
+// LocalAddresses returns the key addresses of the senders of the local
+// messages.
+func (mp *MessagePool) LocalAddresses(ctx context.Context) []address.Address {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	var out []address.Address
+	mp.forEachLocal(ctx, func(_ context.Context, la address.Address) {
+		out = append(out, la)
+	})
+	return out
+}
+
 func (mp *MessagePool) Close() error {
 	close(mp.closer)
 	return nil
@@ -680,6 +767,10 @@ func (mp *MessagePool) Push(ctx context.Context, m *types.SignedMessage, publish
 		return cid.Undef, err
 	}
 
+	if err := mp.simulate(ctx, m, nil); err != nil {
+		return cid.Undef, err
+	}
+
 	// serialize push access to reduce lock contention
 	mp.addSema <- struct{}{}
 	defer func() {
@@ -858,16 +949,46 @@ func (mp *MessagePool) addTs(ctx context.Context, m *types.SignedMessage, curTs
 		return false, err
 	}
 
-	err = mp.addLocked(ctx, m, !local, untrusted)
+	// only the messages from the network, and the untrusted ones, are rate
+	// limited; the messages of the priority senders from the network are
+	// accepted as the local ones
+	priority := !local && mp.isPriority(ctx, m.Message.From)
+	rateLimited := (!local || untrusted) && !priority
+	if rateLimited {
+		if err := mp.checkSenderRate(ctx, m); err != nil {
+			return false, err
+		}
+	}
+
+	err = mp.addLocked(ctx, m, !local && !priority, untrusted)
 	if err != nil {
+		if !local && xerrors.Is(err, ErrNonceGap) {
+			parked, perr := mp.park(ctx, m)
+			if perr != nil {
+				return false, xerrors.Errorf("parking message: %w", perr)
+			}
+			if parked {
+				mp.recordSenderRate(ctx, m)
+				return false, ErrMessageParked
+			}
+		}
 		return false, err
 	}
 
+	if rateLimited {
+		mp.recordSenderRate(ctx, m)
+	}
+
 	if local {
 		err = mp.addLocal(ctx, m)
 		if err != nil {
 			return false, xerrors.Errorf("error persisting local message: %w", err)
 		}
+		mp.localJournal.pushed(ctx, m)
+	}
+
+	if from, err := mp.resolveToKey(ctx, m.Message.From); err == nil {
+		mp.promoteParked(ctx, from)
 	}
 
 	return publish, nil
@@ -1069,6 +1190,10 @@ func (mp *MessagePool) PushUntrusted(ctx context.Context, m *types.SignedMessage
 		return cid.Undef, err
 	}
 
+	if err := mp.simulate(ctx, m, nil); err != nil {
+		return cid.Undef, err
+	}
+
 	// serialize push access to reduce lock contention
 	mp.addSema <- struct{}{}
 	defer func() {
@@ -1163,6 +1288,21 @@ func (mp *MessagePool) allPending(ctx context.Context) ([]*types.SignedMessage,
 	return out, mp.curTs
 }
 
+// PendingTimes returns the times the pending messages were added to the pool.
+func (mp *MessagePool) PendingTimes() []api.MpoolPendingTime {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	out := make([]api.MpoolPendingTime, 0)
+	mp.forEachPending(func(a address.Address, mset *msgSet) {
+		for nonce, m := range mset.msgs {
+			out = append(out, api.MpoolPendingTime{Message: m.Cid(), Added: mset.added[nonce]})
+		}
+	})
+
+	return out
+}
+
 func (mp *MessagePool) PendingFor(ctx context.Context, a address.Address) ([]*types.SignedMessage, *types.TipSet) {
 	mp.curTsLk.Lock()
 	defer mp.curTsLk.Unlock()
@@ -1247,6 +1387,7 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 
 		for _, msg := range msgs {
 			add(msg)
+			mp.localJournal.reverted(ctx, msg.Cid())
 		}
 	}
 
@@ -1265,11 +1406,13 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 			for _, msg := range smsgs {
 				rm(msg.Message.From, msg.Message.Nonce)
 				maybeRepub(msg.Cid())
+				mp.localJournal.landed(ctx, msg.Cid(), ts.Height())
 			}
 
 			for _, msg := range bmsgs {
 				rm(msg.From, msg.Nonce)
 				maybeRepub(msg.Cid())
+				mp.localJournal.landed(ctx, msg.Cid(), ts.Height())
 			}
 		}
 	}
@@ -1289,6 +1432,15 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 		}
 	}
 
+	mp.lk.Lock()
+	mp.promoteAllParked(ctx)
+	mp.pruneSenderRates()
+	mp.lk.Unlock()
+
+	mp.localJournal.settle(ctx, func(addr address.Address) (uint64, error) {
+		return mp.getStateNonce(ctx, addr, mp.curTs)
+	})
+
 	if len(revert) > 0 && futureDebug {
 		mp.lk.Lock()
 		msgs, ts := mp.allPending(ctx)
@@ -1470,6 +1622,12 @@ func (mp *MessagePool) RecoverSig(msg *types.Message) *types.SignedMessage {
 }
 
 func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, error) {
+	return mp.UpdatesFiltered(ctx, nil)
+}
+
+// UpdatesFiltered returns the mpool updates for which keep returns true, all of
+// them if keep is nil.
+func (mp *MessagePool) UpdatesFiltered(ctx context.Context, keep func(api.MpoolUpdate) bool) (<-chan api.MpoolUpdate, error) {
 	out := make(chan api.MpoolUpdate, 20)
 	sub := mp.changes.Sub(localUpdates)
 
@@ -1480,6 +1638,9 @@ func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, err
 		for {
 			select {
 			case u := <-sub:
+				if keep != nil && !keep(u.(api.MpoolUpdate)) {
+					continue
+				}
 				select {
 				case out <- u.(api.MpoolUpdate):
 				case <-ctx.Done():
@@ -1514,6 +1675,15 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 			return xerrors.Errorf("unmarshaling local message: %w", err)
 		}
 
+		// the messages the journal knows landed, were replaced or expired are
+		// dropped, the journal keeping them
+		if e, ok := mp.localJournal.get(sm.Cid()); ok && e.Status != api.MpoolMessagePending {
+			if err := mp.localMsgs.Delete(ctx, datastore.NewKey(r.Key)); err != nil {
+				log.Warnf("error deleting local message: %s", err)
+			}
+			continue
+		}
+
 		if err := mp.addLoaded(ctx, &sm); err != nil {
 			if xerrors.Is(err, ErrNonceTooLow) {
 				continue // todo: drop the message from local cache (if above certain confidence threshold)
@@ -1531,6 +1701,51 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 	return nil
 }
 
+// CompactLocal deletes the local messages persisted which aren't pending
+// anymore, having landed or been replaced, so that they aren't loaded again on
+// restart, and returns how many it deleted.
+func (mp *MessagePool) CompactLocal(ctx context.Context) (int, error) {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	res, err := mp.localMsgs.Query(ctx, query.Query{})
+	if err != nil {
+		return 0, xerrors.Errorf("query local messages: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var stale []string
+	for r := range res.Next() {
+		if r.Error != nil {
+			return 0, xerrors.Errorf("r.Error: %w", r.Error)
+		}
+
+		var sm types.SignedMessage
+		if err := sm.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+			return 0, xerrors.Errorf("unmarshaling local message: %w", err)
+		}
+
+		mset, ok, err := mp.getPendingMset(ctx, sm.Message.From)
+		if err != nil {
+			return 0, xerrors.Errorf("getting pending mset: %w", err)
+		}
+		if ok {
+			if pm, has := mset.msgs[sm.Message.Nonce]; has && pm.Cid() == sm.Cid() {
+				continue
+			}
+		}
+		stale = append(stale, r.Key)
+	}
+
+	for _, k := range stale {
+		if err := mp.localMsgs.Delete(ctx, datastore.NewKey(k)); err != nil {
+			return 0, xerrors.Errorf("deleting local message: %w", err)
+		}
+	}
+
+	return len(stale), nil
+}
+
 func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 	mp.lk.Lock()
 	defer mp.lk.Unlock()
@@ -1556,7 +1771,9 @@ func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 		})
 
 		mp.clearPending()
+		mp.clearParked()
 		mp.republished = nil
+		mp.localJournal.cleared(ctx)
 
 		return
 	}
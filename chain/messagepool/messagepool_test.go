@@ -6,15 +6,18 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	"github.com/filecoin-project/go-state-types/network"
 	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
 
@@ -45,6 +48,9 @@ type testMpoolAPI struct {
 	published int
 
 	baseFee types.BigInt
+
+	// call simulates the messages, successfully if nil
+	call func(msg *types.Message, priorMsgs []types.ChainMsg) *api.InvocResult
 }
 
 func newTestMpoolAPI() *testMpoolAPI {
@@ -111,6 +117,13 @@ func (tma *testMpoolAPI) PutMessage(ctx context.Context, m types.ChainMsg) (cid.
 	return cid.Undef, nil
 }
 
+func (tma *testMpoolAPI) CallWithGas(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet) (*api.InvocResult, error) {
+	if tma.call != nil {
+		return tma.call(msg, priorMsgs), nil
+	}
+	return &api.InvocResult{Msg: msg, MsgRct: &types.MessageReceipt{}}, nil
+}
+
 func (tma *testMpoolAPI) IsLite() bool {
 	return false
 }
@@ -1048,6 +1061,415 @@ func TestAddMessageTwiceCidDiffReplaced(t *testing.T) {
 	}
 }
 
+func TestAddMessageReplacedPolicy(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	cfg := DefaultConfig()
+	cfg.ReplaceByFeeRatio = 2
+	cfg.MaxReplacementsPerEpoch = 1
+	assert.NoError(t, mp.SetConfig(context.Background(), cfg))
+	assert.Equal(t, api.MpoolRBFPolicy{MinPremiumBumpPercent: 100, MaxReplacementsPerEpoch: 1}, mp.RBFPolicy())
+
+	to := mock.Address(1001)
+	premium := minimumBaseFee.Uint64()
+
+	{
+		sm := makeTestMessage(w, from, to, 0, 50_000_000, premium)
+		mustAdd(t, mp, sm)
+
+		// enough for the default ratio, but not the configured one
+		sm2 := makeTestMessage(w, from, to, 0, 50_000_000, premium*3/2)
+		err = mp.Add(context.TODO(), sm2)
+		assert.ErrorIs(t, err, ErrRBFTooLowPremium)
+
+		sm2 = makeTestMessage(w, from, to, 0, 50_000_000, premium*3)
+		mustAdd(t, mp, sm2)
+
+		// a single replacement per epoch
+		sm3 := makeTestMessage(w, from, to, 0, 50_000_000, premium*10)
+		err = mp.Add(context.TODO(), sm3)
+		assert.ErrorIs(t, err, ErrTooManyReplacements)
+	}
+}
+
+func TestAddMessageParked(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+	from2, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+	tma.setBalance(from2, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+	pending := func(addr address.Address) []uint64 {
+		msgs, _ := mp.PendingFor(context.TODO(), addr)
+		var nonces []uint64
+		for _, m := range msgs {
+			nonces = append(nonces, m.Message.Nonce)
+		}
+		return nonces
+	}
+
+	// parking disabled by default
+	err = mp.Add(context.TODO(), makeTestMessage(w, from, to, MaxNonceGap+2, 50_000_000, gasPrice))
+	assert.ErrorIs(t, err, ErrNonceGap)
+
+	cfg := DefaultConfig()
+	cfg.ParkNonceGap = 10
+	assert.NoError(t, mp.SetConfig(context.Background(), cfg))
+
+	{
+		err = mp.Add(context.TODO(), makeTestMessage(w, from, to, MaxNonceGap+2, 50_000_000, gasPrice))
+		assert.ErrorIs(t, err, ErrMessageParked)
+		assert.Empty(t, pending(from))
+
+		// beyond the parking gap
+		err = mp.Add(context.TODO(), makeTestMessage(w, from, to, 11, 50_000_000, gasPrice))
+		assert.ErrorIs(t, err, ErrNonceGap)
+
+		// promoted once the gap is small enough
+		mustAdd(t, mp, makeTestMessage(w, from, to, 0, 50_000_000, gasPrice))
+		assert.Equal(t, []uint64{0}, pending(from))
+		mustAdd(t, mp, makeTestMessage(w, from, to, 1, 50_000_000, gasPrice))
+		assert.Equal(t, []uint64{0, 1, MaxNonceGap + 2}, pending(from))
+	}
+
+	cfg.ParkTTL = time.Nanosecond
+	assert.NoError(t, mp.SetConfig(context.Background(), cfg))
+
+	{
+		// expired before the gap is filled
+		err = mp.Add(context.TODO(), makeTestMessage(w, from2, to, MaxNonceGap+2, 50_000_000, gasPrice))
+		assert.ErrorIs(t, err, ErrMessageParked)
+		mustAdd(t, mp, makeTestMessage(w, from2, to, 0, 50_000_000, gasPrice))
+		mustAdd(t, mp, makeTestMessage(w, from2, to, 1, 50_000_000, gasPrice))
+		assert.Equal(t, []uint64{0, 1}, pending(from2))
+	}
+}
+
+func TestAddMessageRateLimited(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+
+	cfg := DefaultConfig()
+	cfg.SenderMsgsPerEpoch = 3
+	cfg.SpamScoreThreshold = 0.5
+	cfg.SpamPremiumFloor = 2 * gasPrice
+	assert.NoError(t, mp.SetConfig(context.Background(), cfg))
+
+	{
+		mustAdd(t, mp, makeTestMessage(w, from, to, 0, 50_000_000, gasPrice))
+
+		// over the spam threshold, the premium must be over the floor
+		err = mp.Add(context.TODO(), makeTestMessage(w, from, to, 1, 50_000_000, gasPrice))
+		assert.ErrorIs(t, err, ErrSpamPremiumTooLow)
+		mustAdd(t, mp, makeTestMessage(w, from, to, 1, 50_000_000, 2*gasPrice))
+		mustAdd(t, mp, makeTestMessage(w, from, to, 2, 50_000_000, 2*gasPrice))
+
+		// over the limit
+		err = mp.Add(context.TODO(), makeTestMessage(w, from, to, 3, 50_000_000, 2*gasPrice))
+		assert.ErrorIs(t, err, ErrSenderRateLimited)
+
+		// local messages aren't limited
+		_, err = mp.Push(context.TODO(), makeTestMessage(w, from, to, 3, 50_000_000, gasPrice), false)
+		assert.NoError(t, err)
+	}
+}
+
+func TestAddMessagePriority(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+	other, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+	tma.setBalance(other, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+
+	cfg := DefaultConfig()
+	cfg.SenderMsgsPerEpoch = 1
+	assert.NoError(t, mp.SetConfig(context.Background(), cfg))
+	mp.SetPriorityAddrs([]address.Address{from})
+
+	{
+		// the other senders are limited
+		mustAdd(t, mp, makeTestMessage(w, other, to, 0, 50_000_000, gasPrice))
+		err = mp.Add(context.TODO(), makeTestMessage(w, other, to, 1, 50_000_000, gasPrice))
+		assert.ErrorIs(t, err, ErrSenderRateLimited)
+	}
+
+	{
+		// the priority ones aren't, and may have nonce gaps
+		mustAdd(t, mp, makeTestMessage(w, from, to, 0, 50_000_000, gasPrice))
+		mustAdd(t, mp, makeTestMessage(w, from, to, 1, 50_000_000, gasPrice))
+		mustAdd(t, mp, makeTestMessage(w, from, to, MaxNonceGap+3, 50_000_000, gasPrice))
+		assert.Len(t, mp.pendingFor(context.TODO(), from), 3)
+	}
+
+	{
+		// and their messages are selected first
+		mp.curTsLk.Lock()
+		ts := mp.curTs
+		mp.curTsLk.Unlock()
+
+		msgs, err := mp.SelectMessages(context.TODO(), ts, 1.0)
+		assert.NoError(t, err)
+		assert.Len(t, msgs, 3)
+		assert.Equal(t, from, msgs[0].Message.From)
+		assert.Equal(t, from, msgs[1].Message.From)
+		assert.Equal(t, other, msgs[2].Message.From)
+	}
+}
+
+func TestPushBatch(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from1, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+	from2, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from1, 1000e9)
+	tma.setBalance(from2, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+
+	m0 := makeTestMessage(w, from1, to, 0, 50_000_000, gasPrice)
+	_, err = mp.Push(context.TODO(), m0, false)
+	assert.NoError(t, err)
+
+	{
+		// the batch has a nonce gap, none is added, and the replaced message
+		// is kept
+		rbf := makeTestMessage(w, from1, to, 0, 50_000_000, 2*gasPrice)
+		batch := []*types.SignedMessage{
+			rbf,
+			makeTestMessage(w, from1, to, 1, 50_000_000, gasPrice),
+			makeTestMessage(w, from1, to, 3, 50_000_000, gasPrice),
+			makeTestMessage(w, from2, to, 0, 50_000_000, gasPrice),
+		}
+		errs, err := mp.PushBatch(context.TODO(), batch, false)
+		assert.ErrorIs(t, err, ErrBatchRejected)
+		assert.Len(t, errs, 4)
+		assert.NoError(t, errs[0])
+		assert.NoError(t, errs[1])
+		assert.ErrorIs(t, errs[2], ErrBatchNonce)
+		assert.NoError(t, errs[3])
+
+		pending := mp.pendingFor(context.TODO(), from1)
+		assert.Len(t, pending, 1)
+		assert.Equal(t, m0.Cid(), pending[0].Cid())
+		assert.Len(t, mp.pendingFor(context.TODO(), from2), 0)
+
+		nonce, err := mp.GetNonce(context.TODO(), from1, types.EmptyTSK)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), nonce)
+	}
+
+	{
+		// the batch leaves a gap after the pending messages
+		errs, err := mp.PushBatch(context.TODO(), []*types.SignedMessage{
+			makeTestMessage(w, from2, to, 1, 50_000_000, gasPrice),
+		}, false)
+		assert.ErrorIs(t, err, ErrBatchRejected)
+		assert.ErrorIs(t, errs[0], ErrNonceGap)
+	}
+
+	{
+		batch := []*types.SignedMessage{
+			makeTestMessage(w, from1, to, 1, 50_000_000, gasPrice),
+			makeTestMessage(w, from2, to, 0, 50_000_000, gasPrice),
+			makeTestMessage(w, from1, to, 2, 50_000_000, gasPrice),
+		}
+		errs, err := mp.PushBatch(context.TODO(), batch, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []error{nil, nil, nil}, errs)
+
+		assert.Len(t, mp.pendingFor(context.TODO(), from1), 3)
+		assert.Len(t, mp.pendingFor(context.TODO(), from2), 1)
+		assert.Len(t, mp.LocalJournal(api.MpoolMessagePending), 4)
+	}
+}
+
+func TestPushSimulated(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+
+	var priors []int
+	tma.call = func(msg *types.Message, priorMsgs []types.ChainMsg) *api.InvocResult {
+		priors = append(priors, len(priorMsgs))
+		rct := &types.MessageReceipt{}
+		if msg.Method == 42 {
+			rct.ExitCode = exitcode.SysErrInvalidMethod
+		}
+		return &api.InvocResult{Msg: msg, MsgRct: rct, Error: "invalid method"}
+	}
+
+	bad := makeTestMessage(w, from, to, 0, 50_000_000, gasPrice)
+	bad.Message.Method = 42
+	sig, err := w.WalletSign(context.TODO(), from, bad.Message.Cid().Bytes(), api.MsgMeta{})
+	assert.NoError(t, err)
+	bad.Signature = *sig
+
+	// messages aren't simulated by default
+	{
+		_, err = mp.Push(context.TODO(), bad, false)
+		assert.NoError(t, err)
+		mp.Remove(context.TODO(), from, 0, false)
+		assert.Empty(t, priors)
+	}
+
+	cfg := DefaultConfig()
+	cfg.SimulatePushes = true
+	assert.NoError(t, mp.SetConfig(context.Background(), cfg))
+
+	{
+		_, err = mp.Push(context.TODO(), bad, false)
+		var abort *api.ErrMessageWouldAbort
+		assert.ErrorAs(t, err, &abort)
+		assert.Equal(t, exitcode.SysErrInvalidMethod, abort.ExitCode)
+		assert.Len(t, mp.pendingFor(context.TODO(), from), 0)
+	}
+
+	{
+		// the pending messages of the sender are applied first
+		_, err = mp.Push(context.TODO(), makeTestMessage(w, from, to, 0, 50_000_000, gasPrice), false)
+		assert.NoError(t, err)
+		_, err = mp.Push(context.TODO(), makeTestMessage(w, from, to, 1, 50_000_000, gasPrice), false)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 0, 1}, priors)
+	}
+}
+
+func TestCompactLocal(t *testing.T) {
+	//stm: @CHAIN_MEMPOOL_PUSH_001
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+	gasPrice := minimumBaseFee.Uint64()
+
+	for i := uint64(0); i < 3; i++ {
+		_, err = mp.Push(context.TODO(), makeTestMessage(w, from, to, i, 50_000_000, gasPrice), false)
+		assert.NoError(t, err)
+	}
+
+	// replace two messages
+	for i := uint64(1); i < 3; i++ {
+		_, err = mp.Push(context.TODO(), makeTestMessage(w, from, to, i, 50_000_000, 2*gasPrice), false)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []address.Address{from}, mp.LocalAddresses(context.TODO()))
+
+	n, err := mp.CompactLocal(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = mp.CompactLocal(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	ids, err := mp.localMsgs.Query(context.TODO(), query.Query{KeysOnly: true})
+	assert.NoError(t, err)
+	keys, err := ids.Rest()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+}
+
 func TestRemoveMessage(t *testing.T) {
 	//stm: @CHAIN_MEMPOOL_PUSH_001
 	tma := newTestMpoolAPI()
@@ -1082,6 +1504,44 @@ func TestRemoveMessage(t *testing.T) {
 	}
 }
 
+func TestPendingTimes(t *testing.T) {
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	assert.NoError(t, err)
+
+	from, err := w.WalletNew(context.Background(), types.KTBLS)
+	assert.NoError(t, err)
+
+	tma.setBalance(from, 1000e9)
+
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	assert.NoError(t, err)
+
+	to := mock.Address(1001)
+
+	before := build.Clock.Now()
+	sm0 := makeTestMessage(w, from, to, 0, 50_000_000, minimumBaseFee.Uint64())
+	sm1 := makeTestMessage(w, from, to, 1, 50_000_000, minimumBaseFee.Uint64())
+	mustAdd(t, mp, sm0)
+	mustAdd(t, mp, sm1)
+
+	times := mp.PendingTimes()
+	assert.Len(t, times, 2)
+	for _, pt := range times {
+		assert.Contains(t, []cid.Cid{sm0.Cid(), sm1.Cid()}, pt.Message)
+		assert.False(t, pt.Added.Before(before))
+	}
+
+	// removed messages are forgotten
+	mp.Remove(context.TODO(), from, sm0.Message.Nonce, true)
+	times = mp.PendingTimes()
+	assert.Len(t, times, 1)
+	assert.Equal(t, sm1.Cid(), times[0].Message)
+}
+
 func TestCapGasFee(t *testing.T) {
 	t.Run("use default maxfee", func(t *testing.T) {
 		msg := &types.Message{
@@ -0,0 +1,167 @@
+package messagepool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// MaxParkedMessages is the number of messages that may be parked at once.
+var MaxParkedMessages = 1000
+
+// ErrMessageParked is returned when adding a message from the network that is
+// parked rather than added. The message isn't relayed to the peers until the
+// gap in the nonces of its sender is filled.
+var ErrMessageParked = errors.New("message parked until its nonce gap is filled")
+
+// parkedMsg is a message from the network with a nonce too far ahead of the
+// next nonce of its sender to be added, waiting for the gap to fill.
+type parkedMsg struct {
+	msg     *types.SignedMessage
+	expires time.Time
+}
+
+// park parks the message m, rejected because of its nonce gap, if its nonce is
+// at most ParkNonceGap ahead of the next nonce of the sender. It returns
+// whether the message was parked.
+// Must be called with the lock held.
+func (mp *MessagePool) park(ctx context.Context, m *types.SignedMessage) (bool, error) {
+	cfg := mp.getConfig()
+	if cfg.ParkNonceGap == 0 {
+		return false, nil
+	}
+
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		return false, err
+	}
+
+	mset, ok, err := mp.getPendingMset(ctx, from)
+	if err != nil {
+		return false, err
+	}
+	var nextNonce uint64
+	if ok {
+		if _, has := mset.msgs[m.Message.Nonce]; has {
+			// replace by fee with a gap
+			return false, nil
+		}
+		nextNonce = mset.nextNonce
+	} else {
+		nextNonce, err = mp.getStateNonce(ctx, from, mp.curTs)
+		if err != nil {
+			return false, err
+		}
+	}
+	if m.Message.Nonce > nextNonce+cfg.ParkNonceGap {
+		return false, nil
+	}
+
+	if mp.parked == nil {
+		mp.parked = make(map[address.Address]map[uint64]*parkedMsg)
+	}
+	msgs, ok := mp.parked[from]
+	if !ok {
+		msgs = make(map[uint64]*parkedMsg)
+		mp.parked[from] = msgs
+	}
+
+	if p, has := msgs[m.Message.Nonce]; has {
+		if p.msg.Cid() == m.Cid() {
+			return false, ErrExistingNonce
+		}
+		if m.Message.GasPremium.LessThan(ComputeRBF(p.msg.Message.GasPremium, cfg.ReplaceByFeeRatio)) {
+			return false, ErrRBFTooLowPremium
+		}
+	} else if mp.parkedCount >= MaxParkedMessages {
+		return false, nil
+	} else {
+		mp.parkedCount++
+	}
+
+	log.Debugw("parking message with nonce gap", "from", from, "nonce", m.Message.Nonce, "nextNonce", nextNonce)
+	msgs[m.Message.Nonce] = &parkedMsg{
+		msg:     m,
+		expires: build.Clock.Now().Add(cfg.ParkTTL),
+	}
+	return true, nil
+}
+
+// promoteParked adds the parked messages of from which nonce gap was filled,
+// and drops the ones that expired or can't be added anymore.
+// Must be called with the lock held.
+func (mp *MessagePool) promoteParked(ctx context.Context, from address.Address) {
+	msgs, ok := mp.parked[from]
+	if !ok {
+		return
+	}
+
+	snonce, err := mp.getStateNonce(ctx, from, mp.curTs)
+	if err != nil {
+		log.Debugf("failed to get state nonce of parked messages: %s", err)
+		return
+	}
+
+	nonces := make([]uint64, 0, len(msgs))
+	for n := range msgs {
+		nonces = append(nonces, n)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+	now := build.Clock.Now()
+	gapped := false
+	for _, n := range nonces {
+		p := msgs[n]
+		if n >= snonce && now.Before(p.expires) {
+			if gapped {
+				continue
+			}
+
+			_, err := mp.verifyMsgBeforeAdd(ctx, p.msg, mp.curTs, false)
+			if err == nil {
+				err = mp.checkBalance(ctx, p.msg, mp.curTs)
+			}
+			if err == nil {
+				err = mp.addLocked(ctx, p.msg, true, false)
+			}
+			if errors.Is(err, ErrNonceGap) || errors.Is(err, ErrTooManyPendingMessages) {
+				// the next nonces wait for this one
+				gapped = true
+				continue
+			}
+			if err != nil {
+				log.Debugw("dropping parked message", "from", from, "nonce", n, "error", err)
+			} else {
+				log.Debugw("promoted parked message", "from", from, "nonce", n)
+			}
+		}
+
+		delete(msgs, n)
+		mp.parkedCount--
+	}
+
+	if len(msgs) == 0 {
+		delete(mp.parked, from)
+	}
+}
+
+// promoteAllParked promotes the parked messages of every sender.
+// Must be called with the lock held.
+func (mp *MessagePool) promoteAllParked(ctx context.Context) {
+	for from := range mp.parked {
+		mp.promoteParked(ctx, from)
+	}
+}
+
+// clearParked drops all parked messages.
+// Must be called with the lock held.
+func (mp *MessagePool) clearParked() {
+	mp.parked = nil
+	mp.parkedCount = 0
+}
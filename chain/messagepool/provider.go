@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/network"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -34,6 +35,7 @@ type Provider interface {
 	MessagesForTipset(context.Context, *types.TipSet) ([]types.ChainMsg, error)
 	LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error)
 	ChainComputeBaseFee(ctx context.Context, ts *types.TipSet) (types.BigInt, error)
+	CallWithGas(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet) (*api.InvocResult, error)
 	IsLite() bool
 }
 
@@ -129,3 +131,10 @@ func (mpp *mpoolProvider) ChainComputeBaseFee(ctx context.Context, ts *types.Tip
 	}
 	return baseFee, nil
 }
+
+func (mpp *mpoolProvider) CallWithGas(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet) (*api.InvocResult, error) {
+	if mpp.IsLite() {
+		return nil, xerrors.Errorf("message simulation isn't supported by lite nodes")
+	}
+	return mpp.sm.CallWithGas(ctx, msg, priorMsgs, ts)
+}
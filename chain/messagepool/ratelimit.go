@@ -0,0 +1,118 @@
+package messagepool
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var (
+	ErrSenderRateLimited = errors.New("sender exceeded its message rate limit")
+	ErrSpamPremiumTooLow = errors.New("gas premium under the spam floor")
+)
+
+// senderRate counts the messages of a sender added in an epoch.
+type senderRate struct {
+	epoch abi.ChainEpoch
+	msgs  int
+	bytes int
+}
+
+// spamScore returns the spam score of a sender with msgs messages making bytes
+// bytes in the epoch: the largest fraction of the limits of cfg they use.
+func spamScore(cfg *types.MpoolConfig, msgs, bytes int) float64 {
+	var score float64
+	if cfg.SenderMsgsPerEpoch > 0 {
+		score = float64(msgs) / float64(cfg.SenderMsgsPerEpoch)
+	}
+	if cfg.SenderBytesPerEpoch > 0 {
+		if s := float64(bytes) / float64(cfg.SenderBytesPerEpoch); s > score {
+			score = s
+		}
+	}
+	return score
+}
+
+// checkSenderRate checks that adding m doesn't make its sender exceed the rate
+// limits, and that m pays the spam floor premium if the spam score of the
+// sender goes over the threshold.
+// Must be called with the lock held.
+func (mp *MessagePool) checkSenderRate(ctx context.Context, m *types.SignedMessage) error {
+	cfg := mp.getConfig()
+	if cfg.SenderMsgsPerEpoch == 0 && cfg.SenderBytesPerEpoch == 0 {
+		return nil
+	}
+
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		return err
+	}
+
+	r := mp.senderRate(from)
+	msgs, bytes := r.msgs+1, r.bytes+m.ChainLength()
+
+	if cfg.SenderMsgsPerEpoch > 0 && msgs > cfg.SenderMsgsPerEpoch {
+		return xerrors.Errorf("sender %s added %d messages in epoch %d: %w", from, r.msgs, r.epoch, ErrSenderRateLimited)
+	}
+	if cfg.SenderBytesPerEpoch > 0 && bytes > cfg.SenderBytesPerEpoch {
+		return xerrors.Errorf("sender %s added %d bytes of messages in epoch %d: %w", from, r.bytes, r.epoch, ErrSenderRateLimited)
+	}
+
+	if cfg.SpamScoreThreshold > 0 && cfg.SpamPremiumFloor > 0 {
+		floor := types.NewInt(cfg.SpamPremiumFloor)
+		if score := spamScore(cfg, msgs, bytes); score > cfg.SpamScoreThreshold && m.Message.GasPremium.LessThan(floor) {
+			return xerrors.Errorf("sender %s has spam score %.2f, gas premium %s is under %s: %w",
+				from, score, m.Message.GasPremium, floor, ErrSpamPremiumTooLow)
+		}
+	}
+
+	return nil
+}
+
+// recordSenderRate counts m, added, in the rate of its sender.
+// Must be called with the lock held.
+func (mp *MessagePool) recordSenderRate(ctx context.Context, m *types.SignedMessage) {
+	cfg := mp.getConfig()
+	if cfg.SenderMsgsPerEpoch == 0 && cfg.SenderBytesPerEpoch == 0 {
+		return
+	}
+
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		log.Debugf("failed to resolve sender to record its rate: %s", err)
+		return
+	}
+
+	r := mp.senderRate(from)
+	r.msgs++
+	r.bytes += m.ChainLength()
+	mp.senderRates[from] = r
+}
+
+// senderRate returns the rate of from in the current epoch.
+// Must be called with the lock held.
+func (mp *MessagePool) senderRate(from address.Address) senderRate {
+	epoch := mp.curTs.Height()
+	r, ok := mp.senderRates[from]
+	if !ok || r.epoch != epoch {
+		return senderRate{epoch: epoch}
+	}
+	return r
+}
+
+// pruneSenderRates drops the rates of the past epochs.
+// Must be called with the lock held.
+func (mp *MessagePool) pruneSenderRates() {
+	epoch := mp.curTs.Height()
+	for from, r := range mp.senderRates {
+		if r.epoch != epoch {
+			delete(mp.senderRates, from)
+		}
+	}
+}
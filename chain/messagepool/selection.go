@@ -598,12 +598,12 @@ func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[a
 
 	// 1. Get priority actor chains
 	var chains []*msgChain
-	priority := mpCfg.PriorityAddrs
+	priority := mp.priorityAddrs()
 	for _, actor := range priority {
 		pk, err := mp.resolveToKey(ctx, actor)
 		if err != nil {
 			log.Debugf("mpooladdlocal failed to resolve sender: %s", err)
-			return result
+			continue
 		}
 
 		mset, ok := pending[pk]
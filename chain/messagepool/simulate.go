@@ -0,0 +1,69 @@
+package messagepool
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// definiteAborts are the exit codes of the simulated messages rejected: those
+// of the messages which can't succeed whatever the messages included before
+// them, unlike running out of gas or most actor errors.
+var definiteAborts = map[exitcode.ExitCode]struct{}{
+	exitcode.SysErrSenderInvalid:      {},
+	exitcode.SysErrSenderStateInvalid: {},
+	exitcode.SysErrInvalidMethod:      {},
+	exitcode.SysErrInvalidReceiver:    {},
+	exitcode.SysErrInsufficientFunds:  {},
+	exitcode.ErrUnhandledMessage:      {},
+}
+
+// simulate dry-runs the local message m on the head state, after the pending
+// messages of its sender with lower nonces, or lower than the first of prior
+// and then prior, when the pool is configured to simulate pushes. It returns
+// an *api.ErrMessageWouldAbort when the message definitely aborts; failing to
+// simulate it isn't an error.
+func (mp *MessagePool) simulate(ctx context.Context, m *types.SignedMessage, prior []*types.SignedMessage) error {
+	if !mp.getConfig().SimulatePushes || mp.api.IsLite() {
+		return nil
+	}
+
+	before := m.Message.Nonce
+	if len(prior) > 0 {
+		before = prior[0].Message.Nonce
+	}
+
+	mp.curTsLk.Lock()
+	curTs := mp.curTs
+	mp.lk.Lock()
+	pending := mp.pendingFor(ctx, m.Message.From)
+	mp.lk.Unlock()
+	mp.curTsLk.Unlock()
+
+	priorMsgs := make([]types.ChainMsg, 0, len(pending)+len(prior))
+	for _, pm := range pending {
+		if pm.Message.Nonce < before {
+			priorMsgs = append(priorMsgs, pm)
+		}
+	}
+	for _, pm := range prior {
+		priorMsgs = append(priorMsgs, pm)
+	}
+
+	res, err := mp.api.CallWithGas(ctx, &m.Message, priorMsgs, curTs)
+	if err != nil {
+		log.Warnf("simulating message %s: %s", m.Cid(), err)
+		return nil
+	}
+
+	if _, abort := definiteAborts[res.MsgRct.ExitCode]; abort {
+		return &api.ErrMessageWouldAbort{
+			ExitCode: res.MsgRct.ExitCode,
+			Reason:   res.Error,
+		}
+	}
+	return nil
+}
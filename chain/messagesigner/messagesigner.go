@@ -31,6 +31,9 @@ type MsgSigner interface {
 	StoreSignedMessage(ctx context.Context, uuid uuid.UUID, message *types.SignedMessage) error
 	NextNonce(ctx context.Context, addr address.Address) (uint64, error)
 	SaveNonce(ctx context.Context, addr address.Address, nonce uint64) error
+	ReserveNonces(ctx context.Context, addr address.Address, count uint64) (*api.NonceReservation, error)
+	ReleaseNonces(ctx context.Context, id uuid.UUID, used uint64) error
+	NonceReservations(ctx context.Context) ([]api.NonceReservation, error)
 }
 
 // MessageSigner keeps track of nonces per address, and increments the nonce
@@ -118,17 +121,40 @@ func (ms *MessageSigner) StoreSignedMessage(ctx context.Context, uuid uuid.UUID,
 	return ms.ds.Put(ctx, key, serializedMsg)
 }
 
-// NextNonce gets the next nonce for the given address.
+// NextNonce gets the next nonce for the given address: the lowest nonce
+// released from a reservation that is still unused, or the nonce after the
+// last one used or reserved.
 // If there is no nonce in the datastore, gets the nonce from the message pool.
 func (ms *MessageSigner) NextNonce(ctx context.Context, addr address.Address) (uint64, error) {
+	nonce, mpoolNonce, err := ms.nextNonce(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+
+	free, err := ms.freeNonces(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	for _, n := range free {
+		if n >= mpoolNonce && n < nonce {
+			return n, nil
+		}
+	}
+	return nonce, nil
+}
+
+// nextNonce returns the nonce after the last one used or reserved for the
+// given address, along with the nonce from the message pool.
+func (ms *MessageSigner) nextNonce(ctx context.Context, addr address.Address) (uint64, uint64, error) {
 	// Nonces used to be created by the mempool and we need to support nodes
 	// that have mempool nonces, so first check the mempool for a nonce for
 	// this address. Note that the mempool returns the actor state's nonce
 	// by default.
 	nonce, err := ms.mpool.GetNonce(ctx, addr, types.EmptyTSK)
 	if err != nil {
-		return 0, xerrors.Errorf("failed to get nonce from mempool: %w", err)
+		return 0, 0, xerrors.Errorf("failed to get nonce from mempool: %w", err)
 	}
+	mpoolNonce := nonce
 
 	// Get the next nonce for this address from the datastore
 	addrNonceKey := ms.dstoreKey(addr)
@@ -138,19 +164,19 @@ func (ms *MessageSigner) NextNonce(ctx context.Context, addr address.Address) (u
 	case xerrors.Is(err, datastore.ErrNotFound):
 		// If a nonce for this address hasn't yet been created in the
 		// datastore, just use the nonce from the mempool
-		return nonce, nil
+		return nonce, mpoolNonce, nil
 
 	case err != nil:
-		return 0, xerrors.Errorf("failed to get nonce from datastore: %w", err)
+		return 0, 0, xerrors.Errorf("failed to get nonce from datastore: %w", err)
 
 	default:
 		// There is a nonce in the datastore, so unmarshall it
 		maj, dsNonce, err := cbg.CborReadHeader(bytes.NewReader(dsNonceBytes))
 		if err != nil {
-			return 0, xerrors.Errorf("failed to parse nonce from datastore: %w", err)
+			return 0, 0, xerrors.Errorf("failed to parse nonce from datastore: %w", err)
 		}
 		if maj != cbg.MajUnsignedInt {
-			return 0, xerrors.Errorf("bad cbor type parsing nonce from datastore")
+			return 0, 0, xerrors.Errorf("bad cbor type parsing nonce from datastore")
 		}
 
 		// The message pool nonce should be <= than the datastore nonce
@@ -160,16 +186,28 @@ func (ms *MessageSigner) NextNonce(ctx context.Context, addr address.Address) (u
 			log.Warnf("mempool nonce was larger than datastore nonce (%d > %d)", nonce, dsNonce)
 		}
 
-		return nonce, nil
+		return nonce, mpoolNonce, nil
 	}
 }
 
 // SaveNonce increments the nonce for this address and writes it to the
-// datastore
+// datastore. A nonce released from a reservation is only marked used.
 func (ms *MessageSigner) SaveNonce(ctx context.Context, addr address.Address, nonce uint64) error {
-	// Increment the nonce
-	nonce++
+	free, err := ms.freeNonces(ctx, addr)
+	if err != nil {
+		return err
+	}
+	for i, n := range free {
+		if n == nonce {
+			return ms.putFreeNonces(ctx, addr, append(free[:i:i], free[i+1:]...))
+		}
+	}
+
+	return ms.writeNonce(ctx, addr, nonce+1)
+}
 
+// writeNonce writes the next nonce for this address to the datastore.
+func (ms *MessageSigner) writeNonce(ctx context.Context, addr address.Address, nonce uint64) error {
 	// Write the nonce to the datastore
 	addrNonceKey := ms.dstoreKey(addr)
 	buf := bytes.Buffer{}
@@ -203,3 +203,66 @@ func TestMessageSignerSignMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageSignerReserveNonces(t *testing.T) {
+	ctx := context.Background()
+
+	w, _ := wallet.NewWallet(wallet.NewMemKeyStore())
+	from, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	to, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	mpool := newMockMpool()
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	ms := NewMessageSigner(w, mpool, ds)
+
+	sign := func(expNonce uint64) {
+		smsg, err := ms.SignMessage(ctx, &types.Message{To: to, From: from}, nil, func(*types.SignedMessage) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, expNonce, smsg.Message.Nonce)
+	}
+
+	_, err = ms.ReserveNonces(ctx, from, 0)
+	require.Error(t, err)
+
+	r1, err := ms.ReserveNonces(ctx, from, 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, r1.Start)
+	sign(3)
+
+	r2, err := ms.ReserveNonces(ctx, from, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, r2.Start)
+
+	rs, err := ms.NonceReservations(ctx)
+	require.NoError(t, err)
+	require.Len(t, rs, 2)
+
+	// the unused nonces of a reservation are used by the next messages
+	require.Error(t, ms.ReleaseNonces(ctx, r1.ID, 4))
+	require.NoError(t, ms.ReleaseNonces(ctx, r1.ID, 1))
+	require.Error(t, ms.ReleaseNonces(ctx, r1.ID, 1))
+	sign(1)
+	sign(2)
+	sign(6)
+
+	// the free nonces below the mpool nonce are dropped
+	mpool.setNonce(from, 5)
+	require.NoError(t, ms.ReleaseNonces(ctx, r2.ID, 0))
+	sign(5)
+	sign(7)
+
+	// the next nonce goes back when the last nonces reserved are released
+	r3, err := ms.ReserveNonces(ctx, from, 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 8, r3.Start)
+	require.NoError(t, ms.ReleaseNonces(ctx, r3.ID, 1))
+	sign(9)
+
+	rs, err = ms.NonceReservations(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rs)
+}
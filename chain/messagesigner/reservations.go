@@ -0,0 +1,213 @@
+package messagesigner
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+const dsKeyNonceReservation = "NonceReservation"
+const dsKeyFreeNonces = "FreeNonces"
+
+// ReserveNonces reserves count consecutive nonces of addr, after the last one
+// used or reserved. The messages signed by the node don't use them until the
+// reservation is released.
+func (ms *MessageSigner) ReserveNonces(ctx context.Context, addr address.Address, count uint64) (*api.NonceReservation, error) {
+	if count == 0 {
+		return nil, xerrors.Errorf("no nonces to reserve")
+	}
+
+	ms.lk.Lock()
+	defer ms.lk.Unlock()
+
+	start, _, err := ms.nextNonce(ctx, addr)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get next nonce: %w", err)
+	}
+
+	r := &api.NonceReservation{
+		ID:       uuid.New(),
+		Address:  addr,
+		Start:    start,
+		Count:    count,
+		Reserved: time.Now(),
+	}
+
+	// The reservation is written first, so that the nonces are never skipped
+	// without a reservation to release them.
+	if err := ms.putReservation(ctx, r); err != nil {
+		return nil, err
+	}
+	if err := ms.writeNonce(ctx, addr, start+count); err != nil {
+		if derr := ms.ds.Delete(ctx, ms.reservationKey(r.ID)); derr != nil {
+			log.Errorf("failed to delete nonce reservation %s: %s", r.ID, derr)
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ReleaseNonces ends the reservation id, the first used nonces of which were
+// used by messages signed out of band. The other nonces are given back: the
+// next nonce goes back if they are the last ones reserved, otherwise they are
+// used by the next messages signed by the node.
+func (ms *MessageSigner) ReleaseNonces(ctx context.Context, id uuid.UUID, used uint64) error {
+	ms.lk.Lock()
+	defer ms.lk.Unlock()
+
+	r, err := ms.getReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if used > r.Count {
+		return xerrors.Errorf("reservation %s has %d nonces, %d used", id, r.Count, used)
+	}
+
+	if used < r.Count {
+		next, mpoolNonce, err := ms.nextNonce(ctx, r.Address)
+		if err != nil {
+			return xerrors.Errorf("failed to get next nonce: %w", err)
+		}
+
+		if next == r.Start+r.Count {
+			if err := ms.writeNonce(ctx, r.Address, r.Start+used); err != nil {
+				return err
+			}
+		} else {
+			free, err := ms.freeNonces(ctx, r.Address)
+			if err != nil {
+				return err
+			}
+
+			// the nonces below the mpool nonce can't be used anymore
+			kept := free[:0]
+			for _, n := range free {
+				if n >= mpoolNonce {
+					kept = append(kept, n)
+				}
+			}
+			for n := r.Start + used; n < r.Start+r.Count; n++ {
+				if n >= mpoolNonce {
+					kept = append(kept, n)
+				}
+			}
+			sort.Slice(kept, func(i, j int) bool { return kept[i] < kept[j] })
+
+			if err := ms.putFreeNonces(ctx, r.Address, kept); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ms.ds.Delete(ctx, ms.reservationKey(id)); err != nil {
+		return xerrors.Errorf("failed to delete nonce reservation: %w", err)
+	}
+	return nil
+}
+
+// NonceReservations returns the reservations not released yet.
+func (ms *MessageSigner) NonceReservations(ctx context.Context) ([]api.NonceReservation, error) {
+	res, err := ms.ds.Query(ctx, query.Query{Prefix: "/" + dsKeyNonceReservation})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query nonce reservations: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []api.NonceReservation
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("failed to read nonce reservation: %w", e.Error)
+		}
+
+		var r api.NonceReservation
+		if err := json.Unmarshal(e.Value, &r); err != nil {
+			return nil, xerrors.Errorf("failed to parse nonce reservation %s: %w", e.Key, err)
+		}
+		out = append(out, r)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Reserved.Before(out[j].Reserved) })
+	return out, nil
+}
+
+func (ms *MessageSigner) getReservation(ctx context.Context, id uuid.UUID) (*api.NonceReservation, error) {
+	b, err := ms.ds.Get(ctx, ms.reservationKey(id))
+	if xerrors.Is(err, datastore.ErrNotFound) {
+		return nil, xerrors.Errorf("no nonce reservation %s", id)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get nonce reservation: %w", err)
+	}
+
+	var r api.NonceReservation
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, xerrors.Errorf("failed to parse nonce reservation: %w", err)
+	}
+	return &r, nil
+}
+
+func (ms *MessageSigner) putReservation(ctx context.Context, r *api.NonceReservation) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal nonce reservation: %w", err)
+	}
+	if err := ms.ds.Put(ctx, ms.reservationKey(r.ID), b); err != nil {
+		return xerrors.Errorf("failed to write nonce reservation: %w", err)
+	}
+	return nil
+}
+
+// freeNonces returns the sorted nonces of addr given back by reservations and
+// not used yet.
+func (ms *MessageSigner) freeNonces(ctx context.Context, addr address.Address) ([]uint64, error) {
+	b, err := ms.ds.Get(ctx, ms.freeNoncesKey(addr))
+	if xerrors.Is(err, datastore.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get free nonces: %w", err)
+	}
+
+	var free []uint64
+	if err := json.Unmarshal(b, &free); err != nil {
+		return nil, xerrors.Errorf("failed to parse free nonces: %w", err)
+	}
+	return free, nil
+}
+
+func (ms *MessageSigner) putFreeNonces(ctx context.Context, addr address.Address, free []uint64) error {
+	if len(free) == 0 {
+		if err := ms.ds.Delete(ctx, ms.freeNoncesKey(addr)); err != nil {
+			return xerrors.Errorf("failed to delete free nonces: %w", err)
+		}
+		return nil
+	}
+
+	b, err := json.Marshal(free)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal free nonces: %w", err)
+	}
+	if err := ms.ds.Put(ctx, ms.freeNoncesKey(addr), b); err != nil {
+		return xerrors.Errorf("failed to write free nonces: %w", err)
+	}
+	return nil
+}
+
+func (ms *MessageSigner) reservationKey(id uuid.UUID) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{dsKeyNonceReservation, id.String()})
+}
+
+func (ms *MessageSigner) freeNoncesKey(addr address.Address) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{dsKeyFreeNonces, addr.String()})
+}
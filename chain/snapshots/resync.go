@@ -0,0 +1,205 @@
+package snapshots
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/httpreader"
+)
+
+// ResyncConfig configures a Watchdog.
+type ResyncConfig struct {
+	// Source is the http url, or the local path, of the latest trusted
+	// snapshot.
+	Source string
+	// Digest, if set, is the http url or the local path of the sha256sum of
+	// the snapshot, read again for every resync.
+	Digest string
+	// MaxLag is the number of epochs the head may be behind the current time.
+	MaxLag abi.ChainEpoch
+	// Grace is how long the head may stay more than MaxLag behind without
+	// catching up before the node is resynced.
+	Grace time.Duration
+}
+
+// Watchdog resyncs the node from the latest trusted snapshot when its head
+// falls behind and fails to catch up: once the head has been more than
+// Config.MaxLag epochs behind for Config.Grace, without the lag going down,
+// the snapshot is downloaded and imported, and its head taken if it is
+// heavier; the syncer then resumes from it.
+type Watchdog struct {
+	cs  *store.ChainStore
+	cfg ResyncConfig
+
+	lk sync.Mutex
+	// behindSince is when the grace period started, zero while the head isn't
+	// behind, and behindLag the lag then
+	behindSince time.Time
+	behindLag   abi.ChainEpoch
+}
+
+// NewWatchdog returns a watchdog resyncing cs as configured by cfg.
+func NewWatchdog(cs *store.ChainStore, cfg ResyncConfig) (*Watchdog, error) {
+	if cfg.Source == "" {
+		return nil, xerrors.Errorf("resync is enabled but has no snapshot source")
+	}
+	if cfg.MaxLag <= 0 {
+		return nil, xerrors.Errorf("resync max lag must be positive")
+	}
+	if cfg.Grace <= 0 {
+		return nil, xerrors.Errorf("resync grace period must be positive")
+	}
+	return &Watchdog{cs: cs, cfg: cfg}, nil
+}
+
+// Run checks the lag of the head every epoch, resyncing the node when it is
+// due, until ctx is canceled.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := build.Clock.Ticker(time.Duration(build.BlockDelaySecs) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !w.due(w.cs.GetHeaviestTipSet(), build.Clock.Now()) {
+				continue
+			}
+			if _, err := w.Resync(ctx); err != nil && ctx.Err() == nil {
+				log.Errorw("resyncing from snapshot", "source", w.cfg.Source, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// lag returns the number of epochs head is behind the time now.
+func lag(head *types.TipSet, now time.Time) abi.ChainEpoch {
+	behind := now.Unix() - int64(head.MinTimestamp())
+	if behind <= 0 {
+		return 0
+	}
+	return abi.ChainEpoch(behind / int64(build.BlockDelaySecs))
+}
+
+// due returns whether the node is to be resynced, given its head at the time
+// now. The grace period restarts whenever the lag went down over it, and after
+// every resync.
+func (w *Watchdog) due(head *types.TipSet, now time.Time) bool {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	l := lag(head, now)
+	if l <= w.cfg.MaxLag {
+		w.behindSince = time.Time{}
+		return false
+	}
+	if w.behindSince.IsZero() {
+		log.Warnw("head fell behind", "lag", l, "height", head.Height())
+		w.behindSince, w.behindLag = now, l
+		return false
+	}
+	if now.Sub(w.behindSince) < w.cfg.Grace {
+		return false
+	}
+
+	catchingUp := l < w.behindLag
+	w.behindSince, w.behindLag = now, l
+	if catchingUp {
+		log.Infow("head still behind, but catching up", "lag", l, "height", head.Height())
+		return false
+	}
+	return true
+}
+
+// Resync imports the snapshot, taking its head if it is heavier than the
+// current one, and returns the head of the snapshot.
+func (w *Watchdog) Resync(ctx context.Context) (*types.TipSet, error) {
+	log.Warnw("resyncing from snapshot", "source", w.cfg.Source, "height", w.cs.GetHeaviestTipSet().Height())
+
+	var digest []byte
+	if w.cfg.Digest != "" {
+		var err error
+		digest, err = httpreader.LoadSha256Sum(ctx, w.cfg.Digest)
+		if err != nil {
+			return nil, xerrors.Errorf("loading snapshot digest: %w", err)
+		}
+	}
+
+	src, err := openSource(ctx, w.cfg.Source)
+	if err != nil {
+		return nil, xerrors.Errorf("opening snapshot: %w", err)
+	}
+	defer src.Close() //nolint:errcheck
+
+	hr := &hashingReader{r: src, h: sha256.New()}
+	ir, err := store.NewDecompressedReader(hr)
+	if err != nil {
+		return nil, xerrors.Errorf("opening snapshot: %w", err)
+	}
+	defer ir.Close() //nolint:errcheck
+
+	// the blocks already stored are skipped, most of the state being shared
+	ts, err := w.cs.ImportWithOpts(ctx, ir, store.ImportOpts{Dedup: true})
+	if err != nil {
+		return nil, xerrors.Errorf("importing snapshot: %w", err)
+	}
+
+	if digest != nil {
+		if _, err := io.Copy(io.Discard, hr); err != nil {
+			return nil, xerrors.Errorf("reading snapshot: %w", err)
+		}
+		if sum := hr.h.Sum(nil); !bytes.Equal(sum, digest) {
+			return nil, xerrors.Errorf("sha256 of the snapshot is %x, expected %x", sum, digest)
+		}
+	}
+
+	if err := w.cs.MaybeTakeHeavierTipSet(ctx, ts); err != nil {
+		return nil, xerrors.Errorf("taking snapshot head: %w", err)
+	}
+	if head := w.cs.GetHeaviestTipSet(); !head.Equals(ts) {
+		log.Warnw("snapshot head not taken, the head is heavier", "snapshot", ts.Height(), "head", head.Height())
+	} else {
+		log.Infow("resynced from snapshot", "height", ts.Height(), "tipset", ts.Cids())
+	}
+	return ts, nil
+}
+
+func openSource(ctx context.Context, src string) (io.ReadCloser, error) {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return httpreader.NewResumableReader(ctx, src)
+	}
+
+	p, err := homedir.Expand(src)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// hashingReader hashes the data read from r.
+type hashingReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func (hr *hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n]) //nolint:errcheck
+	return n, err
+}
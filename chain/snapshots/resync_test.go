@@ -0,0 +1,58 @@
+// stm: #unit
+package snapshots
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+func TestWatchdogDue(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	_, err := NewWatchdog(cs, ResyncConfig{MaxLag: 10, Grace: time.Hour})
+	require.Error(t, err)
+
+	w, err := NewWatchdog(cs, ResyncConfig{Source: "snapshot.car", MaxLag: 10, Grace: time.Hour})
+	require.NoError(t, err)
+
+	head := mockChain(ctx, t, bs, cs, 10)
+	epoch := time.Duration(build.BlockDelaySecs) * time.Second
+	start := time.Unix(int64(head.MinTimestamp()), 0)
+
+	// not while in sync
+	require.False(t, w.due(head, start.Add(10*epoch)))
+
+	// not until the grace period is over
+	behind := start.Add(20 * epoch)
+	require.False(t, w.due(head, behind))
+	require.False(t, w.due(head, behind.Add(time.Hour-time.Second)))
+
+	// not while catching up, restarting the grace period
+	catchingUp := mockChain(ctx, t, bs, cs, 135)
+	require.False(t, w.due(catchingUp, behind.Add(time.Hour)))
+	require.False(t, w.due(catchingUp, behind.Add(2*time.Hour-time.Second)))
+
+	// due once the head failed to catch up for the grace period
+	require.True(t, w.due(catchingUp, behind.Add(2*time.Hour)))
+	require.False(t, w.due(catchingUp, behind.Add(2*time.Hour+time.Second)))
+
+	// the grace period restarts when the head is back in sync
+	inSync := mockChain(ctx, t, bs, cs, 500)
+	require.False(t, w.due(inSync, behind.Add(2*time.Hour+time.Minute)))
+	require.False(t, w.due(head, behind.Add(3*time.Hour)))
+	require.False(t, w.due(head, behind.Add(4*time.Hour-time.Second)))
+	require.True(t, w.due(head, behind.Add(4*time.Hour)))
+}
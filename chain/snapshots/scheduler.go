@@ -0,0 +1,383 @@
+// Package snapshots exports snapshots of the chain at a regular interval, as
+// the node follows the chain, and resyncs the node from a trusted snapshot when
+// it falls behind for good.
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/s3upload"
+)
+
+var log = logging.Logger("snapshots")
+
+var historyKey = datastore.NewKey("/snapshots/history")
+
+// maxLag is how far, in epochs, the head may be behind the current time for
+// snapshots to be exported. A node that is still syncing doesn't export
+// snapshots of the chain it is catching up with.
+const maxLag = 10
+
+// extensions are the file name extensions of the compressions.
+var extensions = map[string]string{
+	store.CompressionNone: "",
+	store.CompressionZstd: ".zst",
+	store.CompressionGzip: ".gz",
+}
+
+// Config configures the snapshots exported by a Scheduler.
+type Config struct {
+	// Interval is the number of epochs between snapshots. Snapshots are
+	// taken at the tipsets whose height is a multiple of it, once they are
+	// build.MessageConfidence epochs below the head.
+	Interval abi.ChainEpoch
+	// Destination is a directory, or an s3:// or gs:// url prefix.
+	Destination string
+	// Endpoint overrides the object store endpoint, see
+	// s3upload.ConfigFromURL.
+	Endpoint string
+
+	RecentStateRoots abi.ChainEpoch
+	SkipOldMessages  bool
+	// Compression is one of the compressions of store.NewCompressedWriter.
+	Compression string
+	// Retain is the number of snapshots to keep; 0 keeps all of them.
+	Retain int
+}
+
+// Scheduler exports snapshots of the chain every Config.Interval epochs, and
+// deletes the snapshots falling out of the retention window. Every snapshot
+// is written along with its manifest.
+type Scheduler struct {
+	cs          *store.ChainStore
+	ds          datastore.Datastore
+	cfg         Config
+	networkName string
+
+	lk sync.Mutex
+	// target is the height of the last snapshot that was started
+	target  abi.ChainEpoch
+	running *api.SnapshotInfo
+	last    *api.SnapshotInfo
+	// history are the retained snapshots, oldest first
+	history []api.SnapshotInfo
+
+	wg sync.WaitGroup
+}
+
+// New returns a scheduler exporting the chain of cs as configured by cfg. The
+// retained snapshots are tracked in ds.
+func New(ctx context.Context, cs *store.ChainStore, ds datastore.Datastore, networkName string, cfg Config) (*Scheduler, error) {
+	s := &Scheduler{
+		cs:          cs,
+		ds:          ds,
+		cfg:         cfg,
+		networkName: networkName,
+	}
+	if cfg.Interval <= 0 {
+		return s, nil
+	}
+
+	if cfg.Destination == "" {
+		return nil, xerrors.Errorf("snapshots are enabled but have no destination")
+	}
+	if !remote(cfg.Destination) {
+		if err := os.MkdirAll(cfg.Destination, 0755); err != nil {
+			return nil, xerrors.Errorf("creating snapshot directory: %w", err)
+		}
+	}
+	if _, ok := extensions[cfg.Compression]; !ok {
+		return nil, xerrors.Errorf("unknown snapshot compression %q", cfg.Compression)
+	}
+
+	data, err := ds.Get(ctx, historyKey)
+	switch err {
+	case nil:
+		if err := json.Unmarshal(data, &s.history); err != nil {
+			return nil, xerrors.Errorf("parsing snapshot history: %w", err)
+		}
+		if len(s.history) > 0 {
+			s.target = s.history[len(s.history)-1].Height
+		}
+	case datastore.ErrNotFound:
+	default:
+		return nil, xerrors.Errorf("loading snapshot history: %w", err)
+	}
+
+	return s, nil
+}
+
+// Enabled returns whether the scheduler exports snapshots.
+func (s *Scheduler) Enabled() bool {
+	return s.cfg.Interval > 0
+}
+
+// Run exports snapshots as the head of the chain advances, until ctx is
+// canceled. The running export, if any, is aborted before Run returns.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.wg.Wait()
+
+	for changes := range s.cs.SubHeadChanges(ctx) {
+		for _, hc := range changes {
+			if hc.Type != store.HCApply && hc.Type != store.HCCurrent {
+				continue
+			}
+			if target, ok := s.due(hc.Val, build.Clock.Now().Unix()); ok {
+				s.start(ctx, hc.Val, target)
+			}
+		}
+	}
+}
+
+// due returns the height of the snapshot to export, if one is due at head.
+func (s *Scheduler) due(head *types.TipSet, now int64) (abi.ChainEpoch, bool) {
+	if now-int64(head.MinTimestamp()) > maxLag*int64(build.BlockDelaySecs) {
+		return 0, false
+	}
+
+	target := (head.Height() - abi.ChainEpoch(build.MessageConfidence)) / s.cfg.Interval * s.cfg.Interval
+	if target <= 0 {
+		return 0, false
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return target, s.running == nil && target > s.target
+}
+
+func (s *Scheduler) start(ctx context.Context, head *types.TipSet, target abi.ChainEpoch) {
+	ts, err := s.cs.GetTipsetByHeight(ctx, target, head, true)
+	if err != nil {
+		log.Errorw("loading snapshot tipset", "height", target, "error", err)
+		return
+	}
+
+	s.lk.Lock()
+	s.target = target
+	s.running = &api.SnapshotInfo{
+		Tipset:  ts.Key(),
+		Height:  ts.Height(),
+		Started: build.Clock.Now(),
+	}
+	s.lk.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.export(ctx, ts)
+	}()
+}
+
+func (s *Scheduler) export(ctx context.Context, ts *types.TipSet) {
+	log.Infow("exporting snapshot", "height", ts.Height())
+
+	info, err := s.exportTipSet(ctx, ts)
+	if err != nil {
+		log.Errorw("exporting snapshot failed", "height", ts.Height(), "error", err)
+		info.Error = err.Error()
+	} else {
+		log.Infow("exported snapshot", "height", ts.Height(), "location", info.Location, "size", info.Size)
+	}
+
+	s.lk.Lock()
+	s.running = nil
+	s.last = &info
+	if err == nil {
+		s.history = append(s.history, info)
+	}
+	history := s.history
+	s.lk.Unlock()
+	if err != nil {
+		return
+	}
+
+	var removed int
+	for s.cfg.Retain > 0 && len(history)-removed > s.cfg.Retain {
+		old := history[removed]
+		if err := s.remove(ctx, old.Location); err != nil {
+			log.Errorw("deleting old snapshot", "location", old.Location, "error", err)
+			break
+		}
+		log.Infow("deleted old snapshot", "location", old.Location)
+		removed++
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	s.history = s.history[removed:]
+	if err := s.saveHistory(ctx); err != nil {
+		log.Errorw("saving snapshot history", "error", err)
+	}
+}
+
+// exportTipSet exports a snapshot of ts and its manifest to the destination.
+func (s *Scheduler) exportTipSet(ctx context.Context, ts *types.TipSet) (api.SnapshotInfo, error) {
+	info := api.SnapshotInfo{
+		Tipset:  ts.Key(),
+		Height:  ts.Height(),
+		Started: build.Clock.Now(),
+	}
+
+	name := fmt.Sprintf("snapshot_%d_%d.car%s", ts.Height(), ts.MinTimestamp(), extensions[s.cfg.Compression])
+	info.Location = s.location(name)
+
+	sink, err := s.newSink(ctx, info.Location)
+	if err != nil {
+		return info, err
+	}
+	cs, err := store.NewCompressedSink(sink, s.cfg.Compression, 0)
+	if err != nil {
+		_ = sink.Abort(ctx)
+		return info, err
+	}
+	ms := &manifestSink{ManifestWriter: store.NewManifestWriter(cs), sink: cs}
+
+	err = s.cs.ExportToSink(ctx, ts, s.cfg.RecentStateRoots, s.cfg.SkipOldMessages, true, ms)
+	info.Finished = build.Clock.Now()
+	if err != nil {
+		return info, err
+	}
+
+	m := ms.Manifest(ts, s.cfg.RecentStateRoots)
+	m.NetworkName = s.networkName
+	info.Size = m.Size
+	if err := s.writeManifest(ctx, info.Location+store.ManifestSuffix, m); err != nil {
+		return info, xerrors.Errorf("writing manifest: %w", err)
+	}
+
+	return info, nil
+}
+
+// manifestSink records the manifest of the uncompressed export.
+type manifestSink struct {
+	*store.ManifestWriter
+	sink store.ExportSink
+}
+
+func (ms *manifestSink) Commit(ctx context.Context) error { return ms.sink.Commit(ctx) }
+func (ms *manifestSink) Abort(ctx context.Context) error  { return ms.sink.Abort(ctx) }
+
+// Status returns the status of the snapshots.
+func (s *Scheduler) Status() api.SnapshotStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	st := api.SnapshotStatus{
+		Enabled:   s.Enabled(),
+		Interval:  s.cfg.Interval,
+		Snapshots: append([]api.SnapshotInfo{}, s.history...),
+	}
+	if s.running != nil {
+		running := *s.running
+		st.Running = &running
+	}
+	if s.last != nil {
+		last := *s.last
+		st.Last = &last
+	}
+	return st
+}
+
+// Roots returns the tipsets of the retained snapshots, and of the one being
+// exported, which chain pruning keeps.
+func (s *Scheduler) Roots() []types.TipSetKey {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	roots := make([]types.TipSetKey, 0, len(s.history)+1)
+	for _, si := range s.history {
+		roots = append(roots, si.Tipset)
+	}
+	if s.running != nil {
+		roots = append(roots, s.running.Tipset)
+	}
+	return roots
+}
+
+func (s *Scheduler) saveHistory(ctx context.Context) error {
+	data, err := json.Marshal(s.history)
+	if err != nil {
+		return err
+	}
+	return s.ds.Put(ctx, historyKey, data)
+}
+
+func remote(dest string) bool {
+	return strings.Contains(dest, "://")
+}
+
+func (s *Scheduler) location(name string) string {
+	if remote(s.cfg.Destination) {
+		return strings.TrimSuffix(s.cfg.Destination, "/") + "/" + name
+	}
+	return filepath.Join(s.cfg.Destination, name)
+}
+
+func (s *Scheduler) newSink(ctx context.Context, loc string) (store.ExportSink, error) {
+	if !remote(loc) {
+		return store.NewFileSink(loc)
+	}
+
+	cfg, err := s3upload.ConfigFromURL(loc, s.cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return s3upload.New(ctx, cfg)
+}
+
+func (s *Scheduler) writeManifest(ctx context.Context, loc string, m *store.SnapshotManifest) error {
+	if !remote(loc) {
+		return store.WriteManifest(loc, m)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	sink, err := s.newSink(ctx, loc)
+	if err != nil {
+		return err
+	}
+	if _, err := sink.Write(data); err != nil {
+		_ = sink.Abort(ctx)
+		return err
+	}
+	return sink.Commit(ctx)
+}
+
+// remove deletes the snapshot at loc and its manifest.
+func (s *Scheduler) remove(ctx context.Context, loc string) error {
+	for _, l := range []string{loc, loc + store.ManifestSuffix} {
+		if !remote(l) {
+			if err := os.Remove(l); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		cfg, err := s3upload.ConfigFromURL(l, s.cfg.Endpoint)
+		if err != nil {
+			return err
+		}
+		if err := s3upload.Delete(ctx, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
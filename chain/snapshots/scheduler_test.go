@@ -0,0 +1,144 @@
+// stm: #unit
+package snapshots
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func mockChain(ctx context.Context, t *testing.T, bs blockstore.Blockstore, cs *store.ChainStore, height int) *types.TipSet {
+	st, err := cbor.WrapObject(map[string]interface{}{}, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, st))
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = st.Cid()
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+
+	ts := mock.TipSet(gen)
+	for i := 0; i < height; i++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+	}
+	return ts
+}
+
+func TestSchedulerDue(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	s, err := New(ctx, cs, datastore.NewMapDatastore(), "test", Config{Interval: 10, Destination: t.TempDir()})
+	require.NoError(t, err)
+
+	head := mockChain(ctx, t, bs, cs, 24)
+	now := int64(head.MinTimestamp())
+
+	// the last multiple of the interval at least MessageConfidence below the head
+	target, ok := s.due(head, now)
+	require.True(t, ok)
+	require.Equal(t, abi.ChainEpoch(24-build.MessageConfidence)/10*10, target)
+
+	// not while syncing
+	_, ok = s.due(head, now+maxLag*int64(build.BlockDelaySecs)+1)
+	require.False(t, ok)
+
+	// not twice for the same height
+	s.target = target
+	_, ok = s.due(head, now)
+	require.False(t, ok)
+}
+
+func TestSchedulerRetention(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	head := mockChain(ctx, t, bs, cs, 10)
+
+	dir := t.TempDir()
+	ds := datastore.NewMapDatastore()
+	cfg := Config{
+		Interval:        2,
+		Destination:     dir,
+		SkipOldMessages: true,
+		Compression:     store.CompressionZstd,
+		Retain:          2,
+	}
+	s, err := New(ctx, cs, ds, "test", cfg)
+	require.NoError(t, err)
+
+	for h := abi.ChainEpoch(2); h <= 8; h += 2 {
+		ts, err := cs.GetTipsetByHeight(ctx, h, head, true)
+		require.NoError(t, err)
+		s.export(ctx, ts)
+	}
+
+	st := s.Status()
+	require.True(t, st.Enabled)
+	require.Nil(t, st.Running)
+	require.Empty(t, st.Last.Error)
+	require.Equal(t, abi.ChainEpoch(8), st.Last.Height)
+	require.Len(t, st.Snapshots, 2)
+	require.Equal(t, abi.ChainEpoch(6), st.Snapshots[0].Height)
+
+	// only the retained snapshots and their manifests are left
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	sort.Strings(files)
+	var want []string
+	for _, info := range st.Snapshots {
+		want = append(want, info.Location, info.Location+store.ManifestSuffix)
+	}
+	sort.Strings(want)
+	require.Equal(t, want, files)
+
+	m, err := store.ReadManifest(st.Snapshots[1].Location + store.ManifestSuffix)
+	require.NoError(t, err)
+	require.Equal(t, "test", m.NetworkName)
+	require.Equal(t, st.Snapshots[1].Size, m.Size)
+
+	// the history survives restarts
+	s, err = New(ctx, cs, ds, "test", cfg)
+	require.NoError(t, err)
+	require.Len(t, s.Status().Snapshots, 2)
+	for i, info := range s.Status().Snapshots {
+		require.Equal(t, st.Snapshots[i].Location, info.Location)
+		require.Equal(t, st.Snapshots[i].Tipset, info.Tipset)
+	}
+	require.Equal(t, abi.ChainEpoch(8), s.target)
+
+	// failed exports are reported, and leave no files behind
+	require.NoError(t, os.RemoveAll(dir))
+	require.NoError(t, os.Mkdir(dir, 0755))
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	s.export(ctx, head)
+	require.NotEmpty(t, s.Status().Last.Error)
+	require.Len(t, s.Status().Snapshots, 2)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,102 @@
+package state
+
+import (
+	"bytes"
+	"context"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ActorChange is the change of an actor between two state trees. Old is nil
+// for the actors added, and New for the actors removed.
+type ActorChange struct {
+	Address address.Address
+	Old     *types.Actor
+	New     *types.Actor
+}
+
+// DiffActors returns the actors added, modified or removed between the two
+// state trees, unlike Diff which leaves the removed actors out.
+func DiffActors(ctx context.Context, oldTree, newTree *StateTree) ([]ActorChange, error) {
+	d := &actorDiffer{ctx: ctx, oldVersion: oldTree.version, newVersion: newTree.version}
+	if err := adt.DiffAdtMap(oldTree.root, newTree.root, d); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+type actorDiffer struct {
+	ctx        context.Context
+	oldVersion types.StateTreeVersion
+	newVersion types.StateTreeVersion
+	changes    []ActorChange
+}
+
+var _ adt.AdtMapDiff = (*actorDiffer)(nil)
+
+func (d *actorDiffer) AsKey(key string) (abi.Keyer, error) {
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return nil, xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+	return abi.AddrKey(addr), nil
+}
+
+func (d *actorDiffer) Add(key string, val *cbg.Deferred) error {
+	return d.change(key, nil, val)
+}
+
+func (d *actorDiffer) Modify(key string, from, to *cbg.Deferred) error {
+	return d.change(key, from, to)
+}
+
+func (d *actorDiffer) Remove(key string, val *cbg.Deferred) error {
+	return d.change(key, val, nil)
+}
+
+func (d *actorDiffer) change(key string, from, to *cbg.Deferred) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+	change := ActorChange{Address: addr}
+	if from != nil {
+		if change.Old, err = decodeActor(d.oldVersion, from.Raw); err != nil {
+			return xerrors.Errorf("decoding actor %s: %w", addr, err)
+		}
+	}
+	if to != nil {
+		if change.New, err = decodeActor(d.newVersion, to.Raw); err != nil {
+			return xerrors.Errorf("decoding actor %s: %w", addr, err)
+		}
+	}
+	d.changes = append(d.changes, change)
+	return nil
+}
+
+func decodeActor(version types.StateTreeVersion, raw []byte) (*types.Actor, error) {
+	if version <= types.StateTreeVersion4 {
+		var act types.ActorV4
+		if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+			return nil, err
+		}
+		return types.AsActorV5(&act), nil
+	}
+
+	var act types.Actor
+	if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	return &act, nil
+}
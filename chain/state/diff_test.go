@@ -0,0 +1,68 @@
+// stm: #unit
+package state
+
+import (
+	"context"
+	"testing"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestDiffActors(t *testing.T) {
+	ctx := context.Background()
+	cst := cbor.NewMemCborStore()
+
+	actor := func(balance uint64) *types.Actor {
+		return &types.Actor{
+			Code:    builtin2.AccountActorCodeID,
+			Head:    builtin2.AccountActorCodeID,
+			Balance: types.NewInt(balance),
+		}
+	}
+	kept, modified, removed, added := mustIDAddr(t, 100), mustIDAddr(t, 101), mustIDAddr(t, 102), mustIDAddr(t, 103)
+
+	oldTree, err := NewStateTree(cst, types.StateTreeVersion4)
+	require.NoError(t, err)
+	require.NoError(t, oldTree.SetActor(kept, actor(1)))
+	require.NoError(t, oldTree.SetActor(modified, actor(2)))
+	require.NoError(t, oldTree.SetActor(removed, actor(3)))
+	oldRoot, err := oldTree.Flush(ctx)
+	require.NoError(t, err)
+
+	newTree, err := LoadStateTree(cst, oldRoot)
+	require.NoError(t, err)
+	require.NoError(t, newTree.SetActor(modified, actor(5)))
+	require.NoError(t, newTree.DeleteActor(removed))
+	require.NoError(t, newTree.SetActor(added, actor(7)))
+	newRoot, err := newTree.Flush(ctx)
+	require.NoError(t, err)
+
+	oldTree, err = LoadStateTree(cst, oldRoot)
+	require.NoError(t, err)
+	newTree, err = LoadStateTree(cst, newRoot)
+	require.NoError(t, err)
+
+	changes, err := DiffActors(ctx, oldTree, newTree)
+	require.NoError(t, err)
+
+	byAddr := map[address.Address]ActorChange{}
+	for _, c := range changes {
+		byAddr[c.Address] = c
+	}
+	require.Len(t, byAddr, 3)
+	require.Equal(t, ActorChange{Address: modified, Old: actor(2), New: actor(5)}, byAddr[modified])
+	require.Equal(t, ActorChange{Address: removed, Old: actor(3)}, byAddr[removed])
+	require.Equal(t, ActorChange{Address: added, New: actor(7)}, byAddr[added])
+}
+
+func mustIDAddr(t *testing.T, id uint64) address.Address {
+	a, err := address.NewIDAddress(id)
+	require.NoError(t, err)
+	return a
+}
@@ -36,6 +36,7 @@ type StateTree struct {
 	info        cid.Cid
 	Store       cbor.IpldStore
 	lookupIDFun func(address.Address) (address.Address, error)
+	access      func(addr address.Address, write bool)
 
 	snaps *stateSnaps
 }
@@ -314,6 +315,9 @@ func (st *StateTree) SetActor(addr address.Address, act *types.Actor) error {
 	}
 	addr = iaddr
 
+	if st.access != nil {
+		st.access(addr, true)
+	}
 	st.snaps.setActor(addr, act)
 	return nil
 }
@@ -375,6 +379,10 @@ func (st *StateTree) GetActor(addr address.Address) (*types.Actor, error) {
 	}
 	addr = iaddr
 
+	if st.access != nil {
+		st.access(addr, false)
+	}
+
 	snapAct, err := st.snaps.getActor(addr)
 	if err != nil {
 		return nil, err
@@ -426,6 +434,9 @@ func (st *StateTree) DeleteActor(addr address.Address) error {
 		return err
 	}
 
+	if st.access != nil {
+		st.access(addr, true)
+	}
 	st.snaps.deleteActor(addr)
 
 	return nil
@@ -469,6 +480,14 @@ func (st *StateTree) Flush(ctx context.Context) (cid.Cid, error) {
 	return st.Store.Put(ctx, &types.StateRoot{Version: st.version, Actors: root, Info: st.info})
 }
 
+// TrackAccess calls f with the ID address of every actor the tree is asked
+// for, whether it exists or not, and of every actor set or deleted, including
+// the changes reverted later; nil stops the tracking. Address resolutions go
+// through the init actor, which is tracked as read unless they are cached.
+func (st *StateTree) TrackAccess(f func(addr address.Address, write bool)) {
+	st.access = f
+}
+
 func (st *StateTree) Snapshot(ctx context.Context) error {
 	ctx, span := trace.StartSpan(ctx, "stateTree.SnapShot") //nolint:staticcheck
 	defer span.End()
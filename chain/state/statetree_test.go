@@ -276,6 +276,48 @@ func TestSetCache(t *testing.T) {
 	}
 }
 
+func TestTrackAccess(t *testing.T) {
+	cst := cbor.NewMemCborStore()
+	st, err := NewStateTree(cst, types.StateTreeVersion4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := address.NewIDAddress(222)
+	b, _ := address.NewIDAddress(223)
+	act := &types.Actor{Balance: types.NewInt(0), Code: builtin2.AccountActorCodeID, Head: builtin2.AccountActorCodeID}
+
+	var reads, writes []address.Address
+	st.TrackAccess(func(addr address.Address, write bool) {
+		if write {
+			writes = append(writes, addr)
+		} else {
+			reads = append(reads, addr)
+		}
+	})
+
+	if err := st.SetActor(a, act); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.GetActor(b); err == nil {
+		t.Fatal("expected actor not found")
+	}
+	if err := st.DeleteActor(a); err != nil {
+		t.Fatal(err)
+	}
+	st.TrackAccess(nil)
+	if _, err := st.GetActor(b); err == nil {
+		t.Fatal("expected actor not found")
+	}
+
+	if fmt.Sprint(reads) != fmt.Sprint([]address.Address{b, a}) {
+		t.Fatalf("unexpected reads %v", reads)
+	}
+	if fmt.Sprint(writes) != fmt.Sprint([]address.Address{a, a}) {
+		t.Fatalf("unexpected writes %v", writes)
+	}
+}
+
 func TestSnapshots(t *testing.T) {
 	//stm: @CHAIN_STATETREE_SET_ACTOR_001, @CHAIN_STATETREE_GET_ACTOR_001, @CHAIN_STATETREE_VERSION_FOR_NETWORK_001
 	//stm: @CHAIN_STATETREE_FLUSH_001, @CHAIN_STATETREE_SNAPSHOT_REVERT_001, CHAIN_STATETREE_SNAPSHOT_CLEAR_001
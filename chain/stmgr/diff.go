@@ -0,0 +1,191 @@
+package stmgr
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	init_ "github.com/filecoin-project/lotus/chain/actors/builtin/init"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// DiffStates returns the actors changed from the parent state of from to that
+// of to, the changes of the collections of the builtin actors states decoded
+// with decode.
+func (sm *StateManager) DiffStates(ctx context.Context, from, to *types.TipSet, decode bool) (*api.StateDiff, error) {
+	store := sm.cs.ActorStore(ctx)
+	fromTree, err := state.LoadStateTree(store, from.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree of %s: %w", from.Key(), err)
+	}
+	toTree, err := state.LoadStateTree(store, to.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree of %s: %w", to.Key(), err)
+	}
+	changes, err := state.DiffActors(ctx, fromTree, toTree)
+	if err != nil {
+		return nil, xerrors.Errorf("diffing state trees: %w", err)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Address.String() < changes[j].Address.String()
+	})
+
+	out := &api.StateDiff{
+		From:      from.Key(),
+		To:        to.Key(),
+		FromState: from.ParentState(),
+		ToState:   to.ParentState(),
+	}
+	for _, c := range changes {
+		d := &api.ActorDiff{ActorChange: *actorChange(c)}
+		switch {
+		case c.Old == nil:
+			d.NonceDelta = int64(c.New.Nonce)
+			out.Created = append(out.Created, d)
+		case c.New == nil:
+			d.NonceDelta = -int64(c.Old.Nonce)
+			out.Deleted = append(out.Deleted, d)
+		default:
+			d.NonceDelta = int64(c.New.Nonce) - int64(c.Old.Nonce)
+			if decode && c.Old.Code == c.New.Code && c.Old.Head != c.New.Head {
+				d.State, err = diffActorState(store, c)
+				if err != nil {
+					return nil, xerrors.Errorf("diffing the state of %s: %w", c.Address, err)
+				}
+			}
+			out.Modified = append(out.Modified, d)
+		}
+	}
+
+	return out, nil
+}
+
+// actorChange returns the api change of the actor, with its balance delta.
+func actorChange(c state.ActorChange) *api.ActorChange {
+	delta := big.Zero()
+	if c.New != nil {
+		delta = big.Add(delta, c.New.Balance)
+	}
+	if c.Old != nil {
+		delta = big.Sub(delta, c.Old.Balance)
+	}
+	return &api.ActorChange{
+		Address:      c.Address,
+		Old:          c.Old,
+		New:          c.New,
+		BalanceDelta: delta,
+	}
+}
+
+// diffActorState decodes the changes of the collections of the state of the
+// builtin actor modified by c, or returns nil for the other actors.
+func diffActorState(store adt.Store, c state.ActorChange) (*api.ActorStateDiff, error) {
+	switch {
+	case c.Address == init_.Address:
+		pre, cur, err := loadStates(store, c, init_.Load)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := init_.DiffAddressMap(pre, cur)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing address map: %w", err)
+		}
+		return &api.ActorStateDiff{AddressMap: addrs}, nil
+
+	case c.Address == market.Address:
+		pre, cur, err := loadStates(store, c, market.Load)
+		if err != nil {
+			return nil, err
+		}
+		preProps, err := pre.Proposals()
+		if err != nil {
+			return nil, err
+		}
+		curProps, err := cur.Proposals()
+		if err != nil {
+			return nil, err
+		}
+		props, err := market.DiffDealProposals(preProps, curProps)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing deal proposals: %w", err)
+		}
+		preStates, err := pre.States()
+		if err != nil {
+			return nil, err
+		}
+		curStates, err := cur.States()
+		if err != nil {
+			return nil, err
+		}
+		states, err := market.DiffDealStates(preStates, curStates)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing deal states: %w", err)
+		}
+		return &api.ActorStateDiff{DealProposals: props, DealStates: states}, nil
+
+	case c.Address == power.Address:
+		pre, cur, err := loadStates(store, c, power.Load)
+		if err != nil {
+			return nil, err
+		}
+		claims, err := power.DiffClaims(pre, cur)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing claims: %w", err)
+		}
+		return &api.ActorStateDiff{Claims: claims}, nil
+
+	case builtin.IsStorageMinerActor(c.New.Code):
+		pre, cur, err := loadStates(store, c, miner.Load)
+		if err != nil {
+			return nil, err
+		}
+		precommits, err := miner.DiffPreCommits(pre, cur)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing precommits: %w", err)
+		}
+		sectors, err := miner.DiffSectors(pre, cur)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing sectors: %w", err)
+		}
+		return &api.ActorStateDiff{PreCommits: precommits, Sectors: sectors}, nil
+
+	case builtin.IsMultisigActor(c.New.Code):
+		pre, cur, err := loadStates(store, c, multisig.Load)
+		if err != nil {
+			return nil, err
+		}
+		txs, err := multisig.DiffPendingTransactions(pre, cur)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing pending transactions: %w", err)
+		}
+		return &api.ActorStateDiff{PendingTransactions: txs}, nil
+	}
+
+	return nil, nil
+}
+
+// loadStates loads the states of the actor before and after c.
+func loadStates[S any](store adt.Store, c state.ActorChange, load func(adt.Store, *types.Actor) (S, error)) (S, S, error) {
+	pre, err := load(store, c.Old)
+	if err != nil {
+		var zero S
+		return zero, zero, xerrors.Errorf("loading previous state: %w", err)
+	}
+	cur, err := load(store, c.New)
+	if err != nil {
+		var zero S
+		return zero, zero, xerrors.Errorf("loading current state: %w", err)
+	}
+	return pre, cur, nil
+}
@@ -0,0 +1,113 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	init_ "github.com/filecoin-project/lotus/chain/actors/builtin/init"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestDiffStates(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+	adtStore := cs.ActorStore(ctx)
+
+	sm, err := stmgr.NewStateManager(cs, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	initCode, ok := actors.GetActorCodeID(actorstypes.Version7, actors.InitKey)
+	require.True(t, ok)
+	initState, err := init_.MakeState(adtStore, actorstypes.Version7, "test")
+	require.NoError(t, err)
+	initHead, err := adtStore.Put(ctx, initState.GetState())
+	require.NoError(t, err)
+
+	code, err := abi.CidBuilder.Sum([]byte("actor"))
+	require.NoError(t, err)
+	actor := func(head cid.Cid, nonce uint64, balance int64) *types.Actor {
+		return &types.Actor{Code: code, Head: head, Nonce: nonce, Balance: abi.NewTokenAmount(balance)}
+	}
+	created, deleted, modified := mock.Address(100), mock.Address(101), mock.Address(102)
+
+	tree, err := state.NewStateTree(adtStore, types.StateTreeVersion4)
+	require.NoError(t, err)
+	require.NoError(t, tree.SetActor(init_.Address, &types.Actor{Code: initCode, Head: initHead}))
+	require.NoError(t, tree.SetActor(deleted, actor(code, 3, 10)))
+	require.NoError(t, tree.SetActor(modified, actor(code, 1, 10)))
+	fromRoot, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	robust, err := address.NewActorAddress([]byte("new actor"))
+	require.NoError(t, err)
+	id, err := initState.MapAddressToNewID(robust)
+	require.NoError(t, err)
+	newInitHead, err := adtStore.Put(ctx, initState.GetState())
+	require.NoError(t, err)
+
+	require.NoError(t, tree.SetActor(init_.Address, &types.Actor{Code: initCode, Head: newInitHead}))
+	require.NoError(t, tree.SetActor(created, actor(code, 0, 5)))
+	require.NoError(t, tree.DeleteActor(deleted))
+	require.NoError(t, tree.SetActor(modified, actor(initHead, 4, 7)))
+	toRoot, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	mkTipSet := func(root cid.Cid, nonce uint64) *types.TipSet {
+		blk := mock.MkBlock(nil, 1, nonce)
+		blk.ParentStateRoot = root
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		return mock.TipSet(blk)
+	}
+	from, to := mkTipSet(fromRoot, 1), mkTipSet(toRoot, 2)
+
+	diff, err := sm.DiffStates(ctx, from, to, true)
+	require.NoError(t, err)
+	require.Equal(t, fromRoot, diff.FromState)
+	require.Equal(t, toRoot, diff.ToState)
+
+	require.Len(t, diff.Created, 1)
+	require.Equal(t, created, diff.Created[0].Address)
+	require.Equal(t, abi.NewTokenAmount(5), diff.Created[0].BalanceDelta)
+
+	require.Len(t, diff.Deleted, 1)
+	require.Equal(t, deleted, diff.Deleted[0].Address)
+	require.Equal(t, abi.NewTokenAmount(-10), diff.Deleted[0].BalanceDelta)
+	require.Equal(t, int64(-3), diff.Deleted[0].NonceDelta)
+
+	// the init actor sorts first, its address map changes are decoded
+	require.Len(t, diff.Modified, 2)
+	require.Equal(t, init_.Address, diff.Modified[0].Address)
+	require.NotNil(t, diff.Modified[0].State)
+	require.Len(t, diff.Modified[0].State.AddressMap.Added, 1)
+	require.Equal(t, id, diff.Modified[0].State.AddressMap.Added[0].ID)
+	require.Equal(t, robust, diff.Modified[0].State.AddressMap.Added[0].PK)
+
+	require.Equal(t, modified, diff.Modified[1].Address)
+	require.Equal(t, abi.NewTokenAmount(-3), diff.Modified[1].BalanceDelta)
+	require.Equal(t, int64(3), diff.Modified[1].NonceDelta)
+	require.Nil(t, diff.Modified[1].State)
+
+	// undecoded
+	diff, err = sm.DiffStates(ctx, from, to, false)
+	require.NoError(t, err)
+	require.Nil(t, diff.Modified[0].State)
+}
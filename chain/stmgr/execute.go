@@ -7,10 +7,21 @@ import (
 	"github.com/ipfs/go-cid"
 	"go.opencensus.io/trace"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
+// DefaultExecutionCacheLookback is the default number of epochs of execution
+// results kept by the execution cache, see SetExecutionCache.
+const DefaultExecutionCacheLookback = abi.ChainEpoch(build.Finality)
+
+// executionCachePruneInterval is the number of epochs between the prunings of
+// the execution cache, an hour.
+const executionCachePruneInterval = 120
+
 func (sm *StateManager) TipSetState(ctx context.Context, ts *types.TipSet) (st cid.Cid, rec cid.Cid, err error) {
 	ctx, span := trace.StartSpan(ctx, "tipSetState")
 	defer span.End()
@@ -60,14 +71,60 @@ func (sm *StateManager) TipSetState(ctx context.Context, ts *types.TipSet) (st c
 		return ts.Blocks()[0].ParentStateRoot, ts.Blocks()[0].ParentMessageReceipts, nil
 	}
 
+	if sm.execCacheLookback > 0 {
+		res, ok, err := sm.cs.GetExecutionResult(ctx, sm.execCacheVersion, ts)
+		if err != nil {
+			log.Warnw("failed to look up execution result", "height", ts.Height(), "error", err)
+		} else if ok {
+			span.AddAttributes(trace.BoolAttribute("execCache", true))
+			return res.State, res.Receipts, nil
+		}
+	}
+
 	st, rec, err = sm.tsExec.ExecuteTipSet(ctx, sm, ts, sm.tsExecMonitor, false)
 	if err != nil {
 		return cid.Undef, cid.Undef, err
 	}
 
+	if sm.execCacheLookback > 0 {
+		sm.cacheExecutionResult(ctx, ts, st, rec)
+	}
+
 	return st, rec, nil
 }
 
+// cacheExecutionResult records the result of the execution of ts, pruning the
+// results older than the cache lookback every now and then.
+func (sm *StateManager) cacheExecutionResult(ctx context.Context, ts *types.TipSet, st, rec cid.Cid) {
+	if err := sm.cs.PutExecutionResult(ctx, sm.execCacheVersion, ts, st, rec); err != nil {
+		log.Warnw("failed to cache execution result", "height", ts.Height(), "error", err)
+		return
+	}
+	if ts.Height()%executionCachePruneInterval != 0 {
+		return
+	}
+	n, err := sm.cs.PruneExecutionResults(ctx, ts.Height()-sm.execCacheLookback)
+	if err != nil {
+		log.Warnw("failed to prune execution cache", "error", err)
+		return
+	}
+	log.Debugw("pruned execution cache", "height", ts.Height(), "removed", n)
+}
+
+// SetExecutionCache enables the caching of the results of the executions of
+// the tipsets in the metadata store, for the tipsets executed again, as after
+// reorgs or restarts, not to be re-executed. The results of the tipsets more
+// than lookback epochs below the latest executed one are dropped. The cache is
+// disabled when lookback is not positive, the default.
+//
+// The results are tagged with version, the version of the execution code, and
+// only reused by the state managers of the same version: a version fixing the
+// execution of some tipsets executes them again.
+func (sm *StateManager) SetExecutionCache(lookback abi.ChainEpoch, version string) {
+	sm.execCacheLookback = lookback
+	sm.execCacheVersion = version
+}
+
 func (sm *StateManager) ExecutionTraceWithMonitor(ctx context.Context, ts *types.TipSet, em ExecMonitor) (cid.Cid, error) {
 	st, _, err := sm.tsExec.ExecuteTipSet(ctx, sm, ts, em, true)
 	return st, err
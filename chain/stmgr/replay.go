@@ -0,0 +1,98 @@
+package stmgr
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ReplayTipSet re-executes the messages of ts against its parent state and
+// diffs the resulting state with the parent state. The state computed is
+// neither cached nor persisted as the state of ts.
+func (sm *StateManager) ReplayTipSet(ctx context.Context, ts *types.TipSet) (*api.TipSetReplay, error) {
+	if ts.Height() == 0 {
+		return nil, xerrors.Errorf("cannot replay the genesis tipset")
+	}
+
+	var trace []*api.InvocResult
+	st, rec, err := sm.tsExec.ExecuteTipSet(ctx, sm, ts, &InvocationTracer{trace: &trace}, true)
+	if err != nil {
+		return nil, xerrors.Errorf("executing tipset %s: %w", ts.Key(), err)
+	}
+
+	expSt, expRec, err := sm.recordedTipSetState(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("getting the recorded state of tipset %s: %w", ts.Key(), err)
+	}
+
+	out := &api.TipSetReplay{
+		TipSet:           ts.Key(),
+		Height:           ts.Height(),
+		ParentState:      ts.ParentState(),
+		State:            st,
+		Receipts:         rec,
+		ExpectedState:    expSt,
+		ExpectedReceipts: expRec,
+	}
+
+	for _, ir := range trace {
+		mr := &api.MessageReplay{
+			MsgCid:  ir.MsgCid,
+			MsgRct:  ir.MsgRct,
+			GasCost: ir.GasCost,
+			Error:   ir.Error,
+		}
+		if ir.Msg != nil {
+			mr.From, mr.To, mr.Method = ir.Msg.From, ir.Msg.To, ir.Msg.Method
+		}
+		if ir.MsgRct != nil {
+			out.GasUsed += ir.MsgRct.GasUsed
+		}
+		out.Messages = append(out.Messages, mr)
+	}
+
+	store := sm.cs.ActorStore(ctx)
+	parentTree, err := state.LoadStateTree(store, ts.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent state tree: %w", err)
+	}
+	tree, err := state.LoadStateTree(store, st)
+	if err != nil {
+		return nil, xerrors.Errorf("loading replayed state tree: %w", err)
+	}
+	changes, err := state.DiffActors(ctx, parentTree, tree)
+	if err != nil {
+		return nil, xerrors.Errorf("diffing state trees: %w", err)
+	}
+
+	for _, c := range changes {
+		out.Actors = append(out.Actors, actorChange(c))
+	}
+	sort.Slice(out.Actors, func(i, j int) bool {
+		return out.Actors[i].Address.String() < out.Actors[j].Address.String()
+	})
+
+	return out, nil
+}
+
+// recordedTipSetState returns the state roots of ts recorded in the headers of
+// its child on the heaviest chain, or those computed by the node if ts has no
+// child there.
+func (sm *StateManager) recordedTipSetState(ctx context.Context, ts *types.TipSet) (cid.Cid, cid.Cid, error) {
+	if head := sm.cs.GetHeaviestTipSet(); ts.Height() < head.Height() {
+		child, err := sm.cs.GetTipsetByHeight(ctx, ts.Height()+1, head, false)
+		if err != nil {
+			return cid.Undef, cid.Undef, xerrors.Errorf("loading child tipset: %w", err)
+		}
+		if child.Parents() == ts.Key() {
+			return child.ParentState(), child.Blocks()[0].ParentMessageReceipts, nil
+		}
+	}
+	return sm.TipSetState(ctx, ts)
+}
@@ -169,6 +169,12 @@ func (sm *StateManager) searchBackForMsg(ctx context.Context, from *types.TipSet
 	limitHeight := from.Height() - limit
 	noLimit := limit == LookbackNoLimit
 
+	if ts, r, err := sm.searchMsgIndex(ctx, from, m.Cid(), limitHeight, noLimit); err != nil {
+		log.Warnw("message index lookup failed, walking the chain", "message", m.Cid(), "error", err)
+	} else if ts != nil {
+		return ts, r, m.Cid(), nil
+	}
+
 	cur := from
 	curActor, err := sm.LoadActor(ctx, m.VMMessage().From, cur)
 	if err != nil {
@@ -230,6 +236,33 @@ func (sm *StateManager) searchBackForMsg(ctx context.Context, from *types.TipSet
 	}
 }
 
+// searchMsgIndex looks up the tipset executing the message in the message index
+// of the chainstore, and returns it with the receipt if it is in the chain of
+// from, above limitHeight.
+func (sm *StateManager) searchMsgIndex(ctx context.Context, from *types.TipSet, mcid cid.Cid, limitHeight abi.ChainEpoch, noLimit bool) (*types.TipSet, *types.MessageReceipt, error) {
+	ts, idx, ok, err := sm.cs.LookupMsgExecution(ctx, mcid)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	if ts.Height() > from.Height() || !noLimit && ts.Height() <= limitHeight {
+		return nil, nil, nil
+	}
+
+	cur, err := sm.cs.GetTipsetByHeight(ctx, ts.Height(), from, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cur.Key() != ts.Key() {
+		return nil, nil, nil
+	}
+
+	r, err := sm.cs.GetParentReceipt(ctx, ts.Blocks()[0], idx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ts, r, nil
+}
+
 func (sm *StateManager) tipsetExecutedMessage(ctx context.Context, ts *types.TipSet, msg cid.Cid, vmm *types.Message, allowReplaced bool) (*types.MessageReceipt, cid.Cid, error) {
 	// The genesis block did not execute any messages
 	if ts.Height() == 0 {
@@ -0,0 +1,118 @@
+package stmgr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// speculation holds the blocks arriving from the network, to form the tipsets
+// executed speculatively.
+type speculation struct {
+	lk sync.Mutex
+	// height is the height of the latest blocks arrived
+	height abi.ChainEpoch
+	// blocks are the blocks arrived at the height, by the key of their parents
+	blocks map[types.TipSetKey][]*types.BlockHeader
+
+	// next is the candidate tipset to execute next
+	next chan *types.TipSet
+	done chan struct{}
+}
+
+// SetSpeculativeExecution enables the execution of the candidate tipsets formed
+// by the blocks arriving from the network, passed to SpeculateBlock, before the
+// blocks are validated and taken as the head of the chain. The state of the
+// tipset finally taken is then usually computed by the time the blocks mined on
+// it are validated or mined.
+func (sm *StateManager) SetSpeculativeExecution(enabled bool) {
+	if !enabled {
+		sm.speculation = nil
+		return
+	}
+	sm.speculation = &speculation{
+		blocks: make(map[types.TipSetKey][]*types.BlockHeader),
+		next:   make(chan *types.TipSet, 1),
+	}
+}
+
+// SpeculateBlock adds a block arriving from the network to the candidate tipset
+// of the blocks with the same parents and height, which is then executed by the
+// started state manager. The block messages must be in the chain blockstore.
+// The blocks older than the latest ones arrived are ignored, as they won't be
+// part of the next head. It does nothing unless speculative execution is
+// enabled.
+func (sm *StateManager) SpeculateBlock(blk *types.BlockHeader) {
+	spec := sm.speculation
+	if spec == nil {
+		return
+	}
+
+	spec.lk.Lock()
+	defer spec.lk.Unlock()
+
+	switch {
+	case blk.Height < spec.height:
+		return
+	case blk.Height > spec.height:
+		spec.height = blk.Height
+		spec.blocks = make(map[types.TipSetKey][]*types.BlockHeader)
+	}
+
+	parents := types.NewTipSetKey(blk.Parents...)
+	blks := spec.blocks[parents]
+	for _, b := range blks {
+		if b.Cid() == blk.Cid() {
+			return
+		}
+	}
+	blks = append(blks, blk)
+
+	ts, err := types.NewTipSet(blks)
+	if err != nil {
+		// the block can't be in a tipset with the others, one of them is invalid
+		log.Debugw("block not forming a tipset with the blocks arrived", "block", blk.Cid(), "height", blk.Height, "error", err)
+		return
+	}
+	spec.blocks[parents] = blks
+
+	// replace the candidate not executed yet
+	select {
+	case <-spec.next:
+	default:
+	}
+	spec.next <- ts
+}
+
+// speculationWorker executes the candidate tipsets. Only the latest candidate is
+// executed: the candidates replaced while a tipset is executed are skipped.
+func (sm *StateManager) speculationWorker(ctx context.Context) {
+	spec := sm.speculation
+	defer close(spec.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ts := <-spec.next:
+			// the parents of the blocks arrived while syncing aren't known yet
+			if _, err := sm.cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+				log.Debugw("skipping speculative execution of tipset with unknown parents", "height", ts.Height(), "tipset", ts.Key())
+				continue
+			}
+
+			start := build.Clock.Now()
+			if _, _, err := sm.TipSetState(ctx, ts); err != nil {
+				if ctx.Err() == nil {
+					log.Warnw("speculative execution failed", "height", ts.Height(), "tipset", ts.Key(), "error", err)
+				}
+				continue
+			}
+			log.Debugw("speculatively executed tipset", "height", ts.Height(), "tipset", ts.Key(), "took", build.Clock.Since(start))
+		}
+	}
+}
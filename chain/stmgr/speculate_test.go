@@ -0,0 +1,84 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// recordingExecutor records the tipsets executed, computing their parent state.
+type recordingExecutor chan types.TipSetKey
+
+func (e recordingExecutor) NewActorRegistry() *vm.ActorRegistry {
+	return vm.NewActorRegistry()
+}
+
+func (e recordingExecutor) ExecuteTipSet(ctx context.Context, sm *stmgr.StateManager, ts *types.TipSet, em stmgr.ExecMonitor, vmTracing bool) (cid.Cid, cid.Cid, error) {
+	e <- ts.Key()
+	return ts.ParentState(), ts.Blocks()[0].ParentMessageReceipts, nil
+}
+
+func TestSpeculativeExecution(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	gen := mock.MkBlock(nil, 1, 1)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+	head := mock.TipSet(gen)
+	require.NoError(t, cs.SetHead(ctx, head))
+
+	exec := make(recordingExecutor, 10)
+	sm, err := stmgr.NewStateManager(cs, exec, nil, nil, nil)
+	require.NoError(t, err)
+	sm.SetSpeculativeExecution(true)
+	require.NoError(t, sm.Start(ctx))
+	defer sm.Stop(ctx) //nolint:errcheck
+
+	next := func() types.TipSetKey {
+		select {
+		case tsk := <-exec:
+			return tsk
+		case <-time.After(5 * time.Second):
+			t.Fatal("no tipset executed")
+			return types.EmptyTSK
+		}
+	}
+
+	// the blocks mined on the head form the candidate tipset as they arrive
+	b1 := mock.MkBlock(head, 1, 1)
+	b2 := mock.MkBlock(head, 1, 2)
+	sm.SpeculateBlock(b1)
+	require.Equal(t, types.NewTipSetKey(b1.Cid()), next())
+	sm.SpeculateBlock(b2)
+	candidate := mock.TipSet(b1, b2)
+	require.Equal(t, candidate.Key(), next())
+
+	// blocks already arrived, older than the candidate, or on unknown parents
+	// aren't executed
+	sm.SpeculateBlock(b1)
+	sm.SpeculateBlock(mock.MkBlock(nil, 1, 3))
+	sm.SpeculateBlock(mock.MkBlock(candidate, 1, 1))
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, exec)
+
+	// the state of the candidate taken as the head is computed already
+	_, _, err = sm.TipSetState(ctx, candidate)
+	require.NoError(t, err)
+	require.Empty(t, exec)
+}
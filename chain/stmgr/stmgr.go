@@ -5,7 +5,6 @@ import (
 	"sync"
 
 	"github.com/ipfs/go-cid"
-	cbor "github.com/ipfs/go-ipld-cbor"
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
 
@@ -95,6 +94,11 @@ type StateManager struct {
 	tsExec        Executor
 	tsExecMonitor ExecMonitor
 	beacon        beacon.Schedule
+
+	execCacheLookback abi.ChainEpoch
+	execCacheVersion  string
+
+	speculation *speculation
 }
 
 // Caches a single state tree
@@ -173,7 +177,8 @@ func cidsToKey(cids []cid.Cid) string {
 }
 
 // Start starts the state manager's optional background processes. At the moment, this schedules
-// pre-migration functions to run ahead of network upgrades.
+// pre-migration functions to run ahead of network upgrades, and executes the candidate tipsets
+// when speculative execution is enabled.
 //
 // This method is not safe to invoke from multiple threads or concurrently with Stop.
 func (sm *StateManager) Start(context.Context) error {
@@ -181,6 +186,10 @@ func (sm *StateManager) Start(context.Context) error {
 	ctx, sm.cancel = context.WithCancel(context.Background())
 	sm.shutdown = make(chan struct{})
 	go sm.preMigrationWorker(ctx)
+	if sm.speculation != nil {
+		sm.speculation.done = make(chan struct{})
+		go sm.speculationWorker(ctx)
+	}
 	return nil
 }
 
@@ -195,6 +204,13 @@ func (sm *StateManager) Stop(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		}
+		if sm.speculation != nil {
+			select {
+			case <-sm.speculation.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
 	}
 	return nil
 }
@@ -222,7 +238,7 @@ func (sm *StateManager) ResolveToKeyAddress(ctx context.Context, addr address.Ad
 		ts = sm.cs.GetHeaviestTipSet()
 	}
 
-	cst := cbor.NewCborStore(sm.cs.StateBlockstore())
+	cst := sm.cs.StateCborStore(ctx)
 
 	// First try to resolve the actor in the parent state, so we don't have to compute anything.
 	tree, err := state.LoadStateTree(cst, ts.ParentState())
@@ -272,7 +288,7 @@ func (sm *StateManager) ResolveToKeyAddressAtFinality(ctx context.Context, addr
 		}
 	}
 
-	cst := cbor.NewCborStore(sm.cs.StateBlockstore())
+	cst := sm.cs.StateCborStore(ctx)
 	tree := sm.tCache.tree
 
 	if tree == nil || sm.tCache.root != ts.ParentState() {
@@ -309,7 +325,7 @@ func (sm *StateManager) GetBlsPublicKey(ctx context.Context, addr address.Addres
 }
 
 func (sm *StateManager) LookupID(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
-	cst := cbor.NewCborStore(sm.cs.StateBlockstore())
+	cst := sm.cs.StateCborStore(ctx)
 	state, err := state.LoadStateTree(cst, sm.parentState(ts))
 	if err != nil {
 		return address.Undef, xerrors.Errorf("load state tree: %w", err)
@@ -323,7 +339,7 @@ func (sm *StateManager) LookupRobustAddress(ctx context.Context, idAddr address.
 		return address.Undef, xerrors.Errorf("failed to decode provided address as id addr: %w", err)
 	}
 
-	cst := cbor.NewCborStore(sm.cs.StateBlockstore())
+	cst := sm.cs.StateCborStore(ctx)
 	wrapStore := adt.WrapStore(ctx, cst)
 
 	stateTree, err := state.LoadStateTree(cst, sm.parentState(ts))
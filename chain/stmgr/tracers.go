@@ -15,6 +15,16 @@ type ExecMonitor interface {
 	MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error
 }
 
+// VMMonitor is an ExecMonitor also monitoring the VMs built to execute a
+// tipset, e.g. to record their inputs. ConfigureVM is called with the options
+// of each VM before it is built, and may wrap its randomness and circulating
+// supply calculator. The messages of monitored executions are applied
+// serially.
+type VMMonitor interface {
+	ExecMonitor
+	ConfigureVM(opts *vm.VMOpts)
+}
+
 var _ ExecMonitor = (*InvocationTracer)(nil)
 
 type InvocationTracer struct {
@@ -22,6 +32,21 @@ type InvocationTracer struct {
 }
 
 func (i *InvocationTracer) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	*i.trace = append(*i.trace, makeInvocResult(mcid, msg, ret))
+	return nil
+}
+
+var _ ExecMonitor = (InvocationFunc)(nil)
+
+// InvocationFunc is an ExecMonitor calling the function with the invocation
+// result of every message applied. Returning an error halts the execution.
+type InvocationFunc func(context.Context, *api.InvocResult) error
+
+func (f InvocationFunc) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	return f(ctx, makeInvocResult(mcid, msg, ret))
+}
+
+func makeInvocResult(mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet) *api.InvocResult {
 	ir := &api.InvocResult{
 		MsgCid:         mcid,
 		Msg:            msg,
@@ -35,8 +60,7 @@ func (i *InvocationTracer) MessageApplied(ctx context.Context, ts *types.TipSet,
 	if ret.GasCosts != nil {
 		ir.GasCost = MakeMsgGasCost(msg, ret)
 	}
-	*i.trace = append(*i.trace, ir)
-	return nil
+	return ir
 }
 
 var _ ExecMonitor = (*messageFinder)(nil)
@@ -1,6 +1,7 @@
 package stmgr
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"reflect"
@@ -49,6 +50,51 @@ func GetParamType(ar *vm.ActorRegistry, actCode cid.Cid, method abi.MethodNum) (
 	return reflect.New(m.Params.Elem()).Interface().(cbg.CBORUnmarshaler), nil
 }
 
+// DecodeParams decodes the params of the method of the actors of code, with the
+// actor registry ar or the actor decoder registered for code.
+func DecodeParams(ar *vm.ActorRegistry, code cid.Cid, method abi.MethodNum, params []byte) (interface{}, error) {
+	if d, ok := vm.GetActorDecoder(code); ok {
+		return d.DecodeParams(method, params)
+	}
+
+	p, err := GetParamType(ar, code, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DecodeReturn decodes the return value of the method of the actors of code,
+// with the actor registry ar or the actor decoder registered for code.
+func DecodeReturn(ar *vm.ActorRegistry, code cid.Cid, method abi.MethodNum, ret []byte) (interface{}, error) {
+	if d, ok := vm.GetActorDecoder(code); ok {
+		return d.DecodeReturn(method, ret)
+	}
+
+	m, found := ar.Methods[code][method]
+	if !found {
+		return nil, fmt.Errorf("unknown method %d for actor %s", method, code)
+	}
+	r := reflect.New(m.Ret.Elem()).Interface().(cbg.CBORUnmarshaler)
+	if err := r.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DecodeMessageReturn decodes the return value of the method of the actor to
+// at ts.
+func DecodeMessageReturn(ctx context.Context, sm *StateManager, to address.Address, method abi.MethodNum, ret []byte, ts *types.TipSet) (interface{}, error) {
+	act, err := sm.LoadActor(ctx, to, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load actor: %w", err)
+	}
+	return DecodeReturn(sm.tsExec.NewActorRegistry(), act.Code, method, ret)
+}
+
 func GetNetworkName(ctx context.Context, sm *StateManager, st cid.Cid) (dtypes.NetworkName, error) {
 	act, err := sm.LoadActorRaw(ctx, init_.Address, st)
 	if err != nil {
@@ -63,20 +109,32 @@ func GetNetworkName(ctx context.Context, sm *StateManager, st cid.Cid) (dtypes.N
 }
 
 func ComputeState(ctx context.Context, sm *StateManager, height abi.ChainEpoch, msgs []*types.Message, ts *types.TipSet) (cid.Cid, []*api.InvocResult, error) {
+	var trace []*api.InvocResult
+	root, err := ComputeStateWithMonitor(ctx, sm, height, msgs, ts, &InvocationTracer{trace: &trace})
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	return root, trace, nil
+}
+
+// ComputeStateWithMonitor is ComputeState reporting the messages applied,
+// those of ts and of the state migrations up to height, to em as they are,
+// rather than returning their trace.
+func ComputeStateWithMonitor(ctx context.Context, sm *StateManager, height abi.ChainEpoch, msgs []*types.Message, ts *types.TipSet, em ExecMonitor) (cid.Cid, error) {
 	if ts == nil {
 		ts = sm.cs.GetHeaviestTipSet()
 	}
 
-	base, trace, err := sm.ExecutionTrace(ctx, ts)
+	base, err := sm.ExecutionTraceWithMonitor(ctx, ts, em)
 	if err != nil {
-		return cid.Undef, nil, err
+		return cid.Undef, err
 	}
 
 	for i := ts.Height(); i < height; i++ {
 		// Technically, the tipset we're passing in here should be ts+1, but that may not exist.
-		base, err = sm.HandleStateForks(ctx, base, i, &InvocationTracer{trace: &trace}, ts)
+		base, err = sm.HandleStateForks(ctx, base, i, em, ts)
 		if err != nil {
-			return cid.Undef, nil, xerrors.Errorf("error handling state forks: %w", err)
+			return cid.Undef, xerrors.Errorf("error handling state forks: %w", err)
 		}
 
 		// We intentionally don't run cron here, as we may be trying to look into the
@@ -100,26 +158,21 @@ func ComputeState(ctx context.Context, sm *StateManager, height abi.ChainEpoch,
 	}
 	vmi, err := sm.newVM(ctx, vmopt)
 	if err != nil {
-		return cid.Undef, nil, err
+		return cid.Undef, err
 	}
 
 	for i, msg := range msgs {
 		// TODO: Use the signed message length for secp messages
 		ret, err := vmi.ApplyMessage(ctx, msg)
 		if err != nil {
-			return cid.Undef, nil, xerrors.Errorf("applying message %s: %w", msg.Cid(), err)
+			return cid.Undef, xerrors.Errorf("applying message %s: %w", msg.Cid(), err)
 		}
 		if ret.ExitCode != 0 {
 			log.Infof("compute state apply message %d failed (exit: %d): %s", i, ret.ExitCode, ret.ActorErr)
 		}
 	}
 
-	root, err := vmi.Flush(ctx)
-	if err != nil {
-		return cid.Undef, nil, err
-	}
-
-	return root, trace, nil
+	return vmi.Flush(ctx)
 }
 
 func LookbackStateGetterForTipset(sm *StateManager, ts *types.TipSet) vm.LookbackStateGetter {
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BlockDAG returns the graph of the blocks known to the chainstore in the last
+// epochs ending at the head: the blocks of the heaviest chain, of the chains
+// of the fork tips, and the other blocks added to the tipset tracker. At most
+// finality epochs are returned, the tracker keeping no more.
+func (cs *ChainStore) BlockDAG(ctx context.Context, epochs abi.ChainEpoch) (*api.BlockDAG, error) {
+	if epochs <= 0 || epochs > build.Finality {
+		return nil, xerrors.Errorf("epochs must be between 1 and %d", build.Finality)
+	}
+
+	head := cs.GetHeaviestTipSet()
+	if head == nil {
+		return nil, xerrors.Errorf("no head")
+	}
+	from := head.Height() - epochs + 1
+	if from < 0 {
+		from = 0
+	}
+
+	blocks := make(map[cid.Cid]*api.DAGBlock)
+	add := func(b *types.BlockHeader) (*api.DAGBlock, bool) {
+		if db, ok := blocks[b.Cid()]; ok {
+			return db, false
+		}
+		db := &api.DAGBlock{
+			Cid:          b.Cid(),
+			Height:       b.Height,
+			Miner:        b.Miner,
+			Parents:      b.Parents,
+			ParentWeight: b.ParentWeight,
+			Timestamp:    b.Timestamp,
+		}
+		if b.ElectionProof != nil {
+			db.WinCount = b.ElectionProof.WinCount
+		}
+		blocks[b.Cid()] = db
+		return db, true
+	}
+
+	// walk adds the blocks of the chain of ts down to from, stopping at the
+	// first tipset whose blocks are all known, where it joins a chain walked
+	walk := func(ts *types.TipSet, heaviest bool) error {
+		for ts.Height() >= from {
+			known := true
+			for _, b := range ts.Blocks() {
+				db, added := add(b)
+				known = known && !added
+				db.Heaviest = db.Heaviest || heaviest
+			}
+			if (known && !heaviest) || ts.Height() == 0 {
+				return nil
+			}
+
+			var err error
+			if ts, err = cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+				return xerrors.Errorf("loading parent tipset: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if err := walk(head, true); err != nil {
+		return nil, err
+	}
+	tips, err := cs.ForkTips(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting fork tips: %w", err)
+	}
+	for _, tip := range tips {
+		if tip.Height < from {
+			continue
+		}
+		ts, err := cs.LoadTipSet(ctx, tip.Key)
+		if err != nil {
+			return nil, xerrors.Errorf("loading fork tip: %w", err)
+		}
+		if err := walk(ts, tip.Heaviest); err != nil {
+			return nil, err
+		}
+		for _, c := range ts.Cids() {
+			blocks[c].Tip = true
+		}
+	}
+
+	// the blocks which are part of no chain walked
+	var tracked []cid.Cid
+	cs.tstLk.Lock()
+	for h, cids := range cs.tipsets {
+		if h >= from && h <= head.Height() {
+			tracked = append(tracked, cids...)
+		}
+	}
+	cs.tstLk.Unlock()
+	for _, c := range tracked {
+		if _, ok := blocks[c]; ok {
+			continue
+		}
+		b, err := cs.GetBlock(ctx, c)
+		if err != nil {
+			return nil, xerrors.Errorf("loading tracked block %s: %w", c, err)
+		}
+		add(b)
+	}
+
+	out := &api.BlockDAG{
+		Head:   head.Key(),
+		From:   from,
+		To:     head.Height(),
+		Blocks: make([]*api.DAGBlock, 0, len(blocks)),
+	}
+	for _, db := range blocks {
+		out.Blocks = append(out.Blocks, db)
+	}
+	sort.Slice(out.Blocks, func(i, j int) bool {
+		if out.Blocks[i].Height != out.Blocks[j].Height {
+			return out.Blocks[i].Height < out.Blocks[j].Height
+		}
+		return out.Blocks[i].Cid.String() < out.Blocks[j].Cid.String()
+	})
+	return out, nil
+}
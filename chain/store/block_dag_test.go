@@ -0,0 +1,106 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestBlockDAG(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	extend := func(ts *types.TipSet, n int, nonce uint64) *types.TipSet {
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, 1, nonce)
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			require.NoError(t, cs.AddToTipSetTracker(ctx, blk))
+			ts = mock.TipSet(blk)
+		}
+		require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, ts))
+		return ts
+	}
+
+	gen := mock.MkBlock(nil, 1, 1)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+	head := extend(mock.TipSet(gen), 10, 1)
+	fork, err := cs.GetTipsetByHeight(ctx, 6, head, false)
+	require.NoError(t, err)
+	tip := extend(fork, 2, 2)
+
+	// a block received, but part of no tipset offered as a head
+	parent, err := cs.GetTipsetByHeight(ctx, 7, head, false)
+	require.NoError(t, err)
+	orphan := mock.MkBlock(parent, 1, 3)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, orphan))
+	require.NoError(t, cs.AddToTipSetTracker(ctx, orphan))
+
+	_, err = cs.BlockDAG(ctx, 0)
+	require.Error(t, err)
+
+	dag, err := cs.BlockDAG(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, head.Key(), dag.Head)
+	require.Equal(t, abi.ChainEpoch(6), dag.From)
+	require.Equal(t, abi.ChainEpoch(10), dag.To)
+
+	type block struct {
+		height   abi.ChainEpoch
+		heaviest bool
+		tip      bool
+	}
+	blocks := map[cid.Cid]block{}
+	for i, db := range dag.Blocks {
+		if i > 0 {
+			require.LessOrEqual(t, dag.Blocks[i-1].Height, db.Height)
+		}
+		blocks[db.Cid] = block{db.Height, db.Heaviest, db.Tip}
+	}
+
+	expected := map[cid.Cid]block{
+		tip.Cids()[0]:           {8, false, true},
+		tip.Parents().Cids()[0]: {7, false, false},
+		orphan.Cid():            {8, false, false},
+		head.Cids()[0]:          {10, true, true},
+	}
+	for ts := head; ts.Height() >= 6; ts = mustLoad(t, cs, ts.Parents()) {
+		if ts.Height() < 10 {
+			expected[ts.Cids()[0]] = block{ts.Height(), true, false}
+		}
+	}
+	require.Equal(t, expected, blocks)
+	require.Equal(t, orphan.Parents, dagBlock(dag, orphan.Cid()).Parents)
+	require.Equal(t, orphan.ParentWeight, dagBlock(dag, orphan.Cid()).ParentWeight)
+}
+
+func dagBlock(dag *api.BlockDAG, c cid.Cid) *api.DAGBlock {
+	for _, db := range dag.Blocks {
+		if db.Cid == c {
+			return db
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/index"
+	"github.com/multiformats/go-multicodec"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// carV2Writer writes an indexed CARv2 file in a single pass. The CARv1 payload
+// is streamed right after space reserved for the CARv2 pragma and header, the
+// index is built from the section offsets as blocks are written, and the header
+// is filled in on finalize once the payload size is known.
+type carV2Writer struct {
+	w  io.WriteSeeker
+	bw *bufio.Writer
+
+	dataSize uint64
+	records  []index.Record
+}
+
+func newCARv2Writer(w io.WriteSeeker, roots []cid.Cid) (*carV2Writer, error) {
+	if _, err := w.Seek(carv2.PragmaSize+carv2.HeaderSize, io.SeekStart); err != nil {
+		return nil, xerrors.Errorf("seeking to carv2 data offset: %w", err)
+	}
+
+	cw := &carV2Writer{
+		w:  w,
+		bw: bufio.NewWriterSize(w, 1<<20),
+	}
+
+	h := &car.CarHeader{
+		Roots:   roots,
+		Version: 1,
+	}
+
+	hn, err := car.HeaderSize(h)
+	if err != nil {
+		return nil, xerrors.Errorf("sizing car header: %w", err)
+	}
+
+	if err := car.WriteHeader(h, cw.bw); err != nil {
+		return nil, xerrors.Errorf("failed to write car header: %w", err)
+	}
+	cw.dataSize = hn
+
+	return cw, nil
+}
+
+func (cw *carV2Writer) writeBlock(c cid.Cid, data []byte) error {
+	cw.records = append(cw.records, index.Record{Cid: c, Offset: cw.dataSize})
+
+	if err := carutil.LdWrite(cw.bw, c.Bytes(), data); err != nil {
+		return xerrors.Errorf("failed to write block to car output: %w", err)
+	}
+	cw.dataSize += carutil.LdSize(c.Bytes(), data)
+
+	return nil
+}
+
+func (cw *carV2Writer) finalize() error {
+	idx, err := index.New(multicodec.CarMultihashIndexSorted)
+	if err != nil {
+		return err
+	}
+	if err := idx.Load(cw.records); err != nil {
+		return xerrors.Errorf("loading carv2 index: %w", err)
+	}
+	cw.records = nil
+
+	if _, err := index.WriteTo(idx, cw.bw); err != nil {
+		return xerrors.Errorf("writing carv2 index: %w", err)
+	}
+	if err := cw.bw.Flush(); err != nil {
+		return xerrors.Errorf("flushing car output: %w", err)
+	}
+
+	if _, err := cw.w.Seek(0, io.SeekStart); err != nil {
+		return xerrors.Errorf("seeking to carv2 header: %w", err)
+	}
+	if _, err := cw.w.Write(carv2.Pragma); err != nil {
+		return xerrors.Errorf("writing carv2 pragma: %w", err)
+	}
+	if _, err := carv2.NewHeader(cw.dataSize).WriteTo(cw.w); err != nil {
+		return xerrors.Errorf("writing carv2 header: %w", err)
+	}
+
+	return nil
+}
+
+// ExportCARv2 is like Export, but writes a CARv2 file with an embedded index
+// so that the snapshot supports random access without re-indexing. The writer
+// must be seekable, as the CARv2 header can only be written once the size of
+// the payload is known.
+func (cs *ChainStore) ExportCARv2(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, w io.WriteSeeker) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	inclRecentRoots, err := CheckRecentRoots(ts, inclRecentRoots, ExportRecentRootsPolicy)
+	if err != nil {
+		return err
+	}
+
+	et := cs.trackExport(ts)
+	err = cs.exportCARv2(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, w, et)
+	et.finish(err)
+	return err
+}
+
+func (cs *ChainStore) exportCARv2(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, w io.WriteSeeker, et *exportTracker) error {
+	cw, err := newCARv2Writer(w, ts.Cids())
+	if err != nil {
+		return err
+	}
+
+	unionBs := cs.UnionStore()
+	err = cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, et, nil, func(c cid.Cid) error {
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := cw.writeBlock(c, blk.RawData()); err != nil {
+			return err
+		}
+		et.wrote(carutil.LdSize(c.Bytes(), blk.RawData()))
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return cw.finalize()
+}
+
+// WriteCARv2 reads a CARv1 stream, such as the one returned by the ChainExport
+// API, and writes it out as an indexed CARv2 file. The index is built while the
+// blocks are copied, so the payload is only read once.
+func WriteCARv2(r io.Reader, w io.WriteSeeker) error {
+	br, err := carv2.NewBlockReader(r)
+	if err != nil {
+		return xerrors.Errorf("reading car header: %w", err)
+	}
+
+	cw, err := newCARv2Writer(w, br.Roots)
+	if err != nil {
+		return err
+	}
+
+	for {
+		blk, err := br.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return xerrors.Errorf("reading car block: %w", err)
+		}
+
+		if err := cw.writeBlock(blk.Cid(), blk.RawData()); err != nil {
+			return err
+		}
+	}
+
+	return cw.finalize()
+}
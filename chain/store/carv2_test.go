@@ -0,0 +1,63 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	carv2 "github.com/ipld/go-car/v2"
+	carbs "github.com/ipld/go-car/v2/blockstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+func TestWriteCARv2(t *testing.T) {
+	var blks []blocks.Block
+	for i := 0; i < 100; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	var v1 bytes.Buffer
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{blks[0].Cid()}, Version: 1}, &v1))
+	for _, b := range blks {
+		require.NoError(t, carutil.LdWrite(&v1, b.Cid().Bytes(), b.RawData()))
+	}
+	v1Size := v1.Len()
+
+	path := filepath.Join(t.TempDir(), "out.car")
+	fi, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, store.WriteCARv2(&v1, fi))
+	require.NoError(t, fi.Close())
+
+	cr, err := carv2.OpenReader(path)
+	require.NoError(t, err)
+	defer cr.Close() //nolint:errcheck
+
+	require.Equal(t, uint64(2), cr.Version)
+	require.True(t, cr.Header.HasIndex())
+	require.Equal(t, uint64(v1Size), cr.Header.DataSize)
+
+	bs, err := carbs.OpenReadOnly(path)
+	require.NoError(t, err)
+	defer bs.Close() //nolint:errcheck
+
+	roots, err := bs.Roots()
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{blks[0].Cid()}, roots)
+
+	for _, b := range blks {
+		got, err := bs.Get(context.Background(), b.Cid())
+		require.NoError(t, err)
+		require.Equal(t, b.RawData(), got.RawData())
+	}
+}
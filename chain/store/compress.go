@@ -0,0 +1,77 @@
+package store
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+
+	"github.com/DataDog/zstd"
+	"golang.org/x/xerrors"
+)
+
+// Compression formats supported for snapshot exports.
+const (
+	CompressionNone = ""
+	CompressionZstd = "zstd"
+	CompressionGzip = "gzip"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewCompressedWriter wraps w so that data written to the returned writer is
+// compressed with the given algorithm. A level of 0 selects the default level
+// of the compressor. The returned writer must be closed to flush any buffered
+// output; closing it does not close w.
+func NewCompressedWriter(w io.Writer, compression string, level int) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionZstd:
+		if level == 0 {
+			level = zstd.DefaultCompression
+		}
+		if level < zstd.BestSpeed || level > zstd.BestCompression {
+			return nil, xerrors.Errorf("invalid zstd compression level %d, must be between %d and %d", level, zstd.BestSpeed, zstd.BestCompression)
+		}
+		return zstd.NewWriterLevel(w, level), nil
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		zw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, xerrors.Errorf("creating gzip writer: %w", err)
+		}
+		return zw, nil
+	default:
+		return nil, xerrors.Errorf("unknown compression %q", compression)
+	}
+}
+
+// NewDecompressedReader returns a reader of the snapshot read from r, which is
+// decompressed if it is compressed with zstd or gzip. Closing the returned
+// reader does not close r.
+func NewDecompressedReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	header, err := br.Peek(4)
+	if err != nil {
+		return nil, xerrors.Errorf("peek header: %w", err)
+	}
+
+	switch {
+	case string(header[1:]) == "\xB5\x2F\xFD": // zstd
+		return zstd.NewReader(br), nil
+	case string(header[:2]) == "\x1F\x8B": // gzip
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, xerrors.Errorf("opening gzip reader: %w", err)
+		}
+		return zr, nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
@@ -0,0 +1,66 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/DataDog/zstd"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+func TestCompressedWriter(t *testing.T) {
+	data := bytes.Repeat([]byte("snapshot data "), 10000)
+
+	readers := map[string]func(io.Reader) (io.Reader, error){
+		store.CompressionNone: func(r io.Reader) (io.Reader, error) { return r, nil },
+		store.CompressionZstd: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r), nil },
+		store.CompressionGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+
+	for compression, newReader := range readers {
+		for _, level := range []int{0, 1, 9} {
+			var buf bytes.Buffer
+			cw, err := store.NewCompressedWriter(&buf, compression, level)
+			require.NoError(t, err)
+			_, err = cw.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, cw.Close())
+
+			r, err := newReader(&buf)
+			require.NoError(t, err)
+			out, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data, out, "compression %q level %d", compression, level)
+		}
+	}
+
+	_, err := store.NewCompressedWriter(io.Discard, "lz4", 0)
+	require.Error(t, err)
+	_, err = store.NewCompressedWriter(io.Discard, store.CompressionZstd, 100)
+	require.Error(t, err)
+}
+
+func TestDecompressedReader(t *testing.T) {
+	data := bytes.Repeat([]byte("snapshot data "), 10000)
+
+	for _, compression := range []string{store.CompressionNone, store.CompressionZstd, store.CompressionGzip} {
+		var buf bytes.Buffer
+		cw, err := store.NewCompressedWriter(&buf, compression, 0)
+		require.NoError(t, err)
+		_, err = cw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, cw.Close())
+
+		r, err := store.NewDecompressedReader(&buf)
+		require.NoError(t, err)
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, data, out, "compression %q", compression)
+	}
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var executionCacheKeyPrefix = dstore.NewKey("/chain/exec")
+
+// ExecutionResult is the state root and receipts root of the execution of a
+// tipset at Height.
+type ExecutionResult struct {
+	Height   abi.ChainEpoch
+	State    cid.Cid
+	Receipts cid.Cid
+}
+
+// executionKey keys the execution of ts by the version of the code executing
+// it, and by its parent state and blocks, which commit to the messages, the
+// miners rewarded and the randomness.
+func executionKey(version string, ts *types.TipSet) dstore.Key {
+	h := sha256.New()
+	h.Write([]byte(version))
+	h.Write([]byte{0})
+	h.Write(ts.ParentState().Bytes())
+	for _, c := range ts.Cids() {
+		h.Write(c.Bytes())
+	}
+	return executionCacheKeyPrefix.ChildString(hex.EncodeToString(h.Sum(nil)))
+}
+
+// GetExecutionResult returns the result of a previous execution of ts by the
+// given version of the execution code, if any whose state and receipts are
+// still in the state store.
+func (cs *ChainStore) GetExecutionResult(ctx context.Context, version string, ts *types.TipSet) (ExecutionResult, bool, error) {
+	b, err := cs.metadataDs.Get(ctx, executionKey(version, ts))
+	if xerrors.Is(err, dstore.ErrNotFound) {
+		return ExecutionResult{}, false, nil
+	}
+	if err != nil {
+		return ExecutionResult{}, false, xerrors.Errorf("getting execution result: %w", err)
+	}
+	res, err := decodeExecutionResult(b)
+	if err != nil {
+		return ExecutionResult{}, false, xerrors.Errorf("decoding execution result: %w", err)
+	}
+
+	for _, c := range []cid.Cid{res.State, res.Receipts} {
+		has, err := cs.stateBlockstore.Has(ctx, c)
+		if err != nil {
+			return ExecutionResult{}, false, xerrors.Errorf("checking execution result: %w", err)
+		}
+		if !has {
+			// collected since
+			return ExecutionResult{}, false, nil
+		}
+	}
+	return res, true, nil
+}
+
+// PutExecutionResult records the result of the execution of ts by the given
+// version of the execution code.
+func (cs *ChainStore) PutExecutionResult(ctx context.Context, version string, ts *types.TipSet, st, rec cid.Cid) error {
+	b := encodeExecutionResult(ExecutionResult{Height: ts.Height(), State: st, Receipts: rec})
+	if err := cs.metadataDs.Put(ctx, executionKey(version, ts), b); err != nil {
+		return xerrors.Errorf("caching execution result: %w", err)
+	}
+	return nil
+}
+
+// PruneExecutionResults removes the results of the executions of the tipsets
+// below the given height, returning how many were removed.
+func (cs *ChainStore) PruneExecutionResults(ctx context.Context, below abi.ChainEpoch) (int, error) {
+	res, err := cs.metadataDs.Query(ctx, query.Query{Prefix: executionCacheKeyPrefix.String()})
+	if err != nil {
+		return 0, xerrors.Errorf("listing execution results: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var stale []dstore.Key
+	for r := range res.Next() {
+		if r.Error != nil {
+			return 0, xerrors.Errorf("listing execution results: %w", r.Error)
+		}
+		er, err := decodeExecutionResult(r.Value)
+		if err != nil || er.Height < below {
+			stale = append(stale, dstore.RawKey(r.Key))
+		}
+	}
+
+	batch, err := cs.metadataDs.Batch(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to open a DS batch: %w", err)
+	}
+	for _, k := range stale {
+		if err := batch.Delete(ctx, k); err != nil {
+			return 0, xerrors.Errorf("removing execution result: %w", err)
+		}
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return 0, xerrors.Errorf("failed to commit the DS batch: %w", err)
+	}
+	return len(stale), nil
+}
+
+func encodeExecutionResult(res ExecutionResult) []byte {
+	var b []byte
+	b = binary.AppendVarint(b, int64(res.Height))
+	b = append(b, res.State.Bytes()...)
+	return append(b, res.Receipts.Bytes()...)
+}
+
+func decodeExecutionResult(b []byte) (ExecutionResult, error) {
+	var res ExecutionResult
+	h, n := binary.Varint(b)
+	if n <= 0 {
+		return res, xerrors.Errorf("invalid height")
+	}
+	res.Height = abi.ChainEpoch(h)
+	b = b[n:]
+
+	n, st, err := cid.CidFromBytes(b)
+	if err != nil {
+		return res, xerrors.Errorf("invalid state root: %w", err)
+	}
+	res.State = st
+	b = b[n:]
+
+	n, rec, err := cid.CidFromBytes(b)
+	if err != nil {
+		return res, xerrors.Errorf("invalid receipts root: %w", err)
+	}
+	if n != len(b) {
+		return res, xerrors.Errorf("%d trailing bytes", len(b)-n)
+	}
+	res.Receipts = rec
+	return res, nil
+}
@@ -0,0 +1,78 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExecutionCache(t *testing.T) {
+	ctx := context.Background()
+	const version = "1.19.0+mainnet+git.1234567"
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v int64) *cbg.CborInt {
+		i := cbg.CborInt(v)
+		return &i
+	}
+	st, err := cs.ActorStore(ctx).Put(ctx, put(1))
+	require.NoError(t, err)
+	rec, err := cs.ActorStore(ctx).Put(ctx, put(2))
+	require.NoError(t, err)
+
+	parent := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	ts := mock.TipSet(mock.MkBlock(parent, 1, 1))
+	// another tipset on the same parent, mined by another miner
+	fork := mock.TipSet(mock.MkBlock(parent, 2, 1))
+
+	_, ok, err := cs.GetExecutionResult(ctx, version, ts)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, cs.PutExecutionResult(ctx, version, ts, st, rec))
+	res, ok, err := cs.GetExecutionResult(ctx, version, ts)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, store.ExecutionResult{Height: ts.Height(), State: st, Receipts: rec}, res)
+
+	_, ok, err = cs.GetExecutionResult(ctx, version, fork)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// the results of other versions, which may execute the tipsets
+	// differently, aren't reused
+	_, ok, err = cs.GetExecutionResult(ctx, "1.19.1+mainnet+git.89abcde", ts)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// results whose state is no longer in the store are ignored
+	missing := mock.MkBlock(nil, 3, 3).ParentStateRoot
+	require.NoError(t, cs.PutExecutionResult(ctx, version, fork, missing, rec))
+	_, ok, err = cs.GetExecutionResult(ctx, version, fork)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	n, err := cs.PruneExecutionResults(ctx, ts.Height())
+	require.NoError(t, err)
+	require.Zero(t, n)
+	n, err = cs.PruneExecutionResults(ctx, ts.Height()+abi.ChainEpoch(1))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	_, ok, err = cs.GetExecutionResult(ctx, version, ts)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// RecentRootsPolicy is what an export does when asked for fewer recent state
+// roots than build.Finality. Nodes can't sync from such snapshots, as they
+// need the state of the last finality worth of tipsets to follow the chain.
+type RecentRootsPolicy int
+
+const (
+	// RecentRootsReject fails the export.
+	RecentRootsReject RecentRootsPolicy = iota
+	// RecentRootsBump exports build.Finality state roots instead.
+	RecentRootsBump
+	// RecentRootsAllow exports the requested number of state roots.
+	RecentRootsAllow
+)
+
+// ExportRecentRootsPolicy is the policy applied by Export and its variants.
+var ExportRecentRootsPolicy = RecentRootsReject
+
+// ParseRecentRootsPolicy parses "reject", "bump" or "allow".
+func ParseRecentRootsPolicy(s string) (RecentRootsPolicy, error) {
+	switch s {
+	case "reject":
+		return RecentRootsReject, nil
+	case "bump":
+		return RecentRootsBump, nil
+	case "allow":
+		return RecentRootsAllow, nil
+	default:
+		return 0, xerrors.Errorf("unknown recent state roots policy %q", s)
+	}
+}
+
+// CheckRecentRoots applies policy to the number of recent state roots of an
+// export of ts, returning the number to export. Exports without recent state
+// roots only contain the genesis state and are always allowed, as are exports
+// of the state of every tipset up to ts.
+func CheckRecentRoots(ts *types.TipSet, inclRecentRoots abi.ChainEpoch, policy RecentRootsPolicy) (abi.ChainEpoch, error) {
+	if inclRecentRoots <= 0 || inclRecentRoots >= build.Finality || inclRecentRoots > ts.Height() {
+		return inclRecentRoots, nil
+	}
+
+	switch policy {
+	case RecentRootsBump:
+		log.Warnw("raising the number of recent state roots of the export to finality", "requested", inclRecentRoots, "finality", build.Finality)
+		return build.Finality, nil
+	case RecentRootsAllow:
+		return inclRecentRoots, nil
+	default:
+		return 0, xerrors.Errorf("exporting %d recent state roots, less than finality (%d); nodes can't sync from such a snapshot", inclRecentRoots, build.Finality)
+	}
+}
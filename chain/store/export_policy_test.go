@@ -0,0 +1,48 @@
+// stm: #unit
+package store_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestCheckRecentRoots(t *testing.T) {
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.Height = 2 * build.Finality
+	ts := mock.TipSet(blk)
+
+	_, err := store.CheckRecentRoots(ts, 10, store.RecentRootsReject)
+	require.Error(t, err)
+
+	n, err := store.CheckRecentRoots(ts, 10, store.RecentRootsBump)
+	require.NoError(t, err)
+	require.Equal(t, build.Finality, n)
+
+	n, err = store.CheckRecentRoots(ts, 10, store.RecentRootsAllow)
+	require.NoError(t, err)
+	require.Equal(t, abi.ChainEpoch(10), n)
+
+	// no recent roots, finality, and the whole chain are always allowed
+	for _, n := range []abi.ChainEpoch{0, build.Finality, ts.Height() + 1} {
+		got, err := store.CheckRecentRoots(ts, n, store.RecentRootsReject)
+		require.NoError(t, err)
+		require.Equal(t, n, got)
+	}
+
+	// chains shorter than finality can be exported with all their state
+	blk.Height = 10
+	ts = mock.TipSet(blk)
+	n, err = store.CheckRecentRoots(ts, 11, store.RecentRootsReject)
+	require.NoError(t, err)
+	require.Equal(t, abi.ChainEpoch(11), n)
+
+	_, err = store.ParseRecentRootsPolicy("bogus")
+	require.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ExportProgressInterval is how often progress is published for running
+// exports.
+var ExportProgressInterval = time.Second
+
+// exportTracker counts what an export has written and periodically publishes
+// it to the export progress subscribers.
+type exportTracker struct {
+	cs *ChainStore
+
+	id     uint64
+	tsk    types.TipSetKey
+	height abi.ChainEpoch
+	// bottom is the epoch the walk ends at, 0 unless a chain segment is
+	// exported
+	bottom abi.ChainEpoch
+	start  time.Time
+
+	blocks uint64
+	bytes  uint64
+	epoch  int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (cs *ChainStore) trackExport(ts *types.TipSet) *exportTracker {
+	et := &exportTracker{
+		cs:     cs,
+		id:     atomic.AddUint64(&cs.exportSeq, 1),
+		tsk:    ts.Key(),
+		height: ts.Height(),
+		start:  build.Clock.Now(),
+		epoch:  int64(ts.Height()),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(et.done)
+
+		tick := build.Clock.Ticker(ExportProgressInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				cs.exportProgress.Pub(et.progress(), "progress")
+			case <-et.stop:
+				return
+			}
+		}
+	}()
+
+	return et
+}
+
+func (et *exportTracker) wrote(n uint64) {
+	if et == nil {
+		return
+	}
+	atomic.AddUint64(&et.blocks, 1)
+	atomic.AddUint64(&et.bytes, n)
+}
+
+func (et *exportTracker) walked(h abi.ChainEpoch) {
+	if et == nil {
+		return
+	}
+	atomic.StoreInt64(&et.epoch, int64(h))
+}
+
+func (et *exportTracker) progress() api.ExportProgress {
+	epoch := abi.ChainEpoch(atomic.LoadInt64(&et.epoch))
+	elapsed := build.Clock.Since(et.start)
+
+	var eta time.Duration
+	if walked := et.height - epoch; walked > 0 {
+		eta = time.Duration(float64(elapsed) * float64(epoch-et.bottom) / float64(walked))
+	}
+
+	return api.ExportProgress{
+		ID:      et.id,
+		Tipset:  et.tsk,
+		Height:  et.height,
+		Epoch:   epoch,
+		Blocks:  atomic.LoadUint64(&et.blocks),
+		Bytes:   atomic.LoadUint64(&et.bytes),
+		Elapsed: elapsed,
+		ETA:     eta,
+	}
+}
+
+// finish stops the periodic reports and publishes the final one.
+func (et *exportTracker) finish(err error) {
+	close(et.stop)
+	<-et.done
+
+	p := et.progress()
+	p.Done = true
+	p.ETA = 0
+	if err != nil {
+		p.Error = err.Error()
+	}
+	et.cs.exportProgress.Pub(p, "progress")
+}
+
+// SubExportProgress returns a channel of progress reports for all exports
+// run by this ChainStore. Reports are dropped rather than buffered when the
+// reader falls behind.
+func (cs *ChainStore) SubExportProgress(ctx context.Context) chan api.ExportProgress {
+	subch := cs.exportProgress.Sub("progress")
+
+	out := make(chan api.ExportProgress, 16)
+	go func() {
+		defer func() {
+			close(out)
+
+			cs.exportProgress.Unsub(subch)
+			for range subch {
+			}
+		}()
+
+		for {
+			select {
+			case val, ok := <-subch:
+				if !ok {
+					return
+				}
+				select {
+				case out <- val.(api.ExportProgress):
+				default:
+					log.Warnw("dropping export progress report for slow reader", "export", val.(api.ExportProgress).ID)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
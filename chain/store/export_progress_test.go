@@ -0,0 +1,93 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// mockExportChain persists a chain of mock headers of the given height, whose
+// genesis state is a single empty object, and returns its head.
+func mockExportChain(ctx context.Context, t *testing.T, bs blockstore.Blockstore, cs *store.ChainStore, height int) *types.TipSet {
+	st, err := cbor.WrapObject(map[string]interface{}{}, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, st))
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = st.Cid()
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+
+	ts := mock.TipSet(gen)
+	for i := 0; i < height; i++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+	}
+
+	return ts
+}
+
+func TestExportProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	progress := cs.SubExportProgress(ctx)
+
+	// only the headers and the genesis state are exported
+	require.NoError(t, cs.Export(ctx, ts, 0, true, true, io.Discard))
+
+	waitDone := func() api.ExportProgress {
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case p := <-progress:
+				if p.Done {
+					return p
+				}
+			case <-timeout:
+				t.Fatal("timed out waiting for the final progress report")
+			}
+		}
+	}
+
+	last := waitDone()
+
+	require.Equal(t, ts.Key(), last.Tipset)
+	require.Equal(t, abi.ChainEpoch(10), last.Height)
+	require.Equal(t, abi.ChainEpoch(0), last.Epoch)
+	require.Equal(t, uint64(12), last.Blocks)
+	require.Greater(t, last.Bytes, uint64(0))
+	require.Empty(t, last.Error)
+
+	// failed exports report the error
+	missing := mock.TipSet(mock.MkBlock(ts, 1, 2))
+	require.Error(t, cs.Export(ctx, missing, 0, true, true, io.Discard))
+
+	last = waitDone()
+
+	require.Equal(t, missing.Key(), last.Tipset)
+	require.NotEmpty(t, last.Error)
+	require.Equal(t, uint64(0), last.Blocks)
+}
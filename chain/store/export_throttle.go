@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ExportThrottle limits the resources an export takes from a node that is
+// also syncing the chain. The zero value does not throttle.
+type ExportThrottle struct {
+	// BlocksPerSec limits the rate at which blocks are exported.
+	BlocksPerSec uint64
+	// BytesPerSec limits the rate at which block data is exported.
+	BytesPerSec uint64
+	// PauseBehind pauses the export while the head of the chain is more than
+	// this many epochs behind the current time, until sync catches up.
+	PauseBehind abi.ChainEpoch
+}
+
+type exportThrottle struct {
+	cs          *ChainStore
+	blocks      *rate.Limiter
+	bytes       *rate.Limiter
+	pauseBehind abi.ChainEpoch
+}
+
+func (cs *ChainStore) newExportThrottle(t ExportThrottle) *exportThrottle {
+	if t == (ExportThrottle{}) {
+		return nil
+	}
+
+	th := &exportThrottle{cs: cs, pauseBehind: t.PauseBehind}
+	if t.BlocksPerSec > 0 {
+		th.blocks = rate.NewLimiter(rate.Limit(t.BlocksPerSec), 1)
+	}
+	if t.BytesPerSec > 0 {
+		th.bytes = rate.NewLimiter(rate.Limit(t.BytesPerSec), 1<<20)
+	}
+	return th
+}
+
+// wait blocks until a block of the given size may be exported.
+func (th *exportThrottle) wait(ctx context.Context, size int) error {
+	if th == nil {
+		return nil
+	}
+
+	if th.pauseBehind > 0 && th.behind() {
+		if err := th.waitForSync(ctx); err != nil {
+			return err
+		}
+	}
+
+	if th.blocks != nil {
+		if err := th.blocks.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	for th.bytes != nil && size > 0 {
+		n := size
+		if b := th.bytes.Burst(); n > b {
+			n = b
+		}
+		if err := th.bytes.WaitN(ctx, n); err != nil {
+			return err
+		}
+		size -= n
+	}
+
+	return nil
+}
+
+func (th *exportThrottle) behind() bool {
+	return th.behindAt(th.cs.GetHeaviestTipSet())
+}
+
+func (th *exportThrottle) behindAt(head *types.TipSet) bool {
+	if head == nil {
+		return false
+	}
+	lag := build.Clock.Now().Unix() - int64(head.MinTimestamp())
+	return lag > int64(th.pauseBehind)*int64(build.BlockDelaySecs)
+}
+
+func (th *exportThrottle) waitForSync(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the first notification is the current head, so a head change between
+	// the check above and subscribing isn't missed
+	changes := th.cs.SubHeadChanges(ctx)
+
+	log.Warnw("pausing export until the node catches up with the chain", "head", th.cs.GetHeaviestTipSet().Height())
+	for {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return xerrors.Errorf("head change subscription closed while export was paused")
+			}
+			if head := th.cs.GetHeaviestTipSet(); !th.behindAt(head) {
+				log.Infow("resuming export", "head", head.Height())
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ExportThrottled is like Export, but limits the rate of the export and
+// pauses it while the node is behind the chain, as configured by throttle.
+func (cs *ChainStore) ExportThrottled(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, throttle ExportThrottle, w io.Writer) error {
+	return cs.ExportFiltered(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, throttle, w)
+}
+
+// ExportFiltered is like ExportThrottled, but only writes the blocks filter
+// selects, all of them when it is nil.
+func (cs *ChainStore) ExportFiltered(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, filter SnapshotFilter, throttle ExportThrottle, w io.Writer) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	inclRecentRoots, err := CheckRecentRoots(ts, inclRecentRoots, ExportRecentRootsPolicy)
+	if err != nil {
+		return err
+	}
+
+	et := cs.trackExport(ts)
+	err = cs.export(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, filter, w, et, cs.newExportThrottle(throttle), nil)
+	et.finish(err)
+	return err
+}
@@ -0,0 +1,59 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExportThrottled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	// 12 blocks at 50 per second
+	start := time.Now()
+	require.NoError(t, cs.ExportThrottled(ctx, ts, 0, true, true, store.ExportThrottle{BlocksPerSec: 50}, io.Discard))
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	// the mock chain is far in the past, so the export waits for sync
+	require.NoError(t, cs.SetHead(ctx, ts))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cs.ExportThrottled(ctx, ts, 0, true, true, store.ExportThrottle{PauseBehind: 5}, io.Discard)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("export did not pause: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	blk := mock.MkBlock(ts, 1, 1)
+	blk.Timestamp = uint64(time.Now().Unix())
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	require.NoError(t, cs.SetHead(ctx, mock.TipSet(blk)))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("export did not resume after sync caught up")
+	}
+}
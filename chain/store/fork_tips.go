@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// forkTips tracks the tips of the forks offered to the chainstore as heads: the
+// tipsets that no other offered tipset extends. Tips more than the fork length
+// threshold below the head are dropped, they can't be taken anymore.
+type forkTips struct {
+	lk   sync.Mutex
+	tips map[types.TipSetKey]*forkTip
+}
+
+type forkTip struct {
+	ts     *types.TipSet
+	weight types.BigInt
+	seen   time.Time
+}
+
+func newForkTips() *forkTips {
+	return &forkTips{tips: make(map[types.TipSetKey]*forkTip)}
+}
+
+// trackForkTip records ts, of weight w, as a tip, with heaviestLk held. The tips
+// it extends are dropped.
+func (cs *ChainStore) trackForkTip(ctx context.Context, ts *types.TipSet, w types.BigInt) {
+	ft := cs.forkTips
+	ft.lk.Lock()
+	defer ft.lk.Unlock()
+
+	if _, ok := ft.tips[ts.Key()]; ok {
+		return
+	}
+
+	onChainOf := func(a, b *types.TipSet) bool {
+		anc, err := cs.GetTipsetByHeight(ctx, a.Height(), b, false)
+		if err != nil {
+			log.Warnw("tracking fork tip", "tipset", ts.Cids(), "error", err)
+			return false
+		}
+		return anc.Equals(a)
+	}
+
+	var minHeight = ts.Height() - build.ForkLengthThreshold
+	if cs.heaviest != nil {
+		minHeight = cs.heaviest.Height() - build.ForkLengthThreshold
+	}
+	for k, tip := range ft.tips {
+		switch {
+		case tip.ts.Height() < minHeight:
+			delete(ft.tips, k)
+		case tip.ts.Height() < ts.Height() && onChainOf(tip.ts, ts):
+			delete(ft.tips, k)
+		case tip.ts.Height() > ts.Height() && onChainOf(ts, tip.ts):
+			// an ancestor of a known tip, offered late
+			return
+		}
+	}
+
+	ft.tips[ts.Key()] = &forkTip{ts: ts, weight: w, seen: time.Now()}
+}
+
+// ForkTips returns the tips of the forks known to the chainstore, heaviest first.
+// They are the tipsets offered as heads that no other offered tipset extends,
+// and the head itself. Each is reported with the height at which it forks off
+// the heaviest chain.
+func (cs *ChainStore) ForkTips(ctx context.Context) ([]api.ForkTip, error) {
+	head := cs.GetHeaviestTipSet()
+	if head == nil {
+		return nil, nil
+	}
+
+	ft := cs.forkTips
+	ft.lk.Lock()
+	tips := make([]forkTip, 0, len(ft.tips)+1)
+	for _, tip := range ft.tips {
+		tips = append(tips, *tip)
+	}
+	ft.lk.Unlock()
+
+	headTracked := false
+	out := make([]api.ForkTip, 0, len(tips)+1)
+	for _, tip := range tips {
+		ts := tip.ts
+		if ts.Equals(head) {
+			headTracked = true
+		}
+		// a tip the head moved past without it being offered, e.g. with
+		// SetHead
+		if ts.Height() < head.Height() {
+			anc, err := cs.GetTipsetByHeight(ctx, ts.Height(), head, false)
+			if err != nil {
+				return nil, xerrors.Errorf("looking up fork tip in the heaviest chain: %w", err)
+			}
+			if anc.Equals(ts) {
+				continue
+			}
+		}
+
+		revert, _, err := cs.ReorgOps(ctx, head, ts)
+		if err != nil {
+			return nil, xerrors.Errorf("finding where %s forks off: %w", ts.Key(), err)
+		}
+		forkHeight := head.Height()
+		if len(revert) > 0 {
+			anc, err := cs.LoadTipSet(ctx, revert[len(revert)-1].Parents())
+			if err != nil {
+				return nil, xerrors.Errorf("loading common ancestor: %w", err)
+			}
+			forkHeight = anc.Height()
+		}
+
+		out = append(out, api.ForkTip{
+			Key:        ts.Key(),
+			Height:     ts.Height(),
+			Weight:     tip.weight,
+			Blocks:     len(ts.Blocks()),
+			Heaviest:   ts.Equals(head),
+			ForkHeight: forkHeight,
+			FirstSeen:  tip.seen,
+		})
+	}
+
+	if !headTracked {
+		w, err := cs.weight(ctx, cs.StateBlockstore(), head)
+		if err != nil {
+			return nil, xerrors.Errorf("computing weight of the head: %w", err)
+		}
+		out = append(out, api.ForkTip{
+			Key:        head.Key(),
+			Height:     head.Height(),
+			Weight:     w,
+			Blocks:     len(head.Blocks()),
+			Heaviest:   true,
+			ForkHeight: head.Height(),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Heaviest != out[j].Heaviest {
+			return out[i].Heaviest
+		}
+		if c := types.BigCmp(out[i].Weight, out[j].Weight); c != 0 {
+			return c > 0
+		}
+		return out[i].Height > out[j].Height
+	})
+	return out, nil
+}
@@ -0,0 +1,105 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestForkTips(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	// extend grows ts by n tipsets of weight w, and offers the last one to cs
+	extend := func(ts *types.TipSet, n int, w, nonce uint64) *types.TipSet {
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, w, nonce)
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+		}
+		require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, ts))
+		return ts
+	}
+
+	type tip struct {
+		key        types.TipSetKey
+		heaviest   bool
+		forkHeight abi.ChainEpoch
+	}
+	tips := func() []tip {
+		fts, err := cs.ForkTips(ctx)
+		require.NoError(t, err)
+		var out []tip
+		for _, ft := range fts {
+			w, err := weight(ctx, nil, mustLoad(t, cs, ft.Key))
+			require.NoError(t, err)
+			require.Equal(t, w, ft.Weight)
+			require.Equal(t, 1, ft.Blocks)
+			out = append(out, tip{ft.Key, ft.Heaviest, ft.ForkHeight})
+		}
+		return out
+	}
+
+	gen := mock.MkBlock(nil, 1, 1)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+	a := extend(mock.TipSet(gen), 5, 1, 1)
+	a = extend(a, 5, 1, 1)
+	require.Equal(t, []tip{{a.Key(), true, a.Height()}}, tips())
+
+	// competing forks are reported, heaviest first, until they are extended
+	fork, err := cs.GetTipsetByHeight(ctx, 6, a, false)
+	require.NoError(t, err)
+	b := extend(fork, 2, 1, 2)
+	c := extend(fork, 1, 3, 3)
+	require.Equal(t, []tip{
+		{a.Key(), true, a.Height()},
+		{c.Key(), false, 6},
+		{b.Key(), false, 6},
+	}, tips())
+
+	// a fork taking over becomes the head, the previous head a fork
+	b = extend(b, 3, 1, 2)
+	require.Equal(t, b, cs.GetHeaviestTipSet())
+	require.Equal(t, []tip{
+		{b.Key(), true, b.Height()},
+		{a.Key(), false, 6},
+		{c.Key(), false, 6},
+	}, tips())
+
+	// the head is reported when it was set without being offered
+	d := mock.MkBlock(c, 1, 4)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, d))
+	require.NoError(t, cs.SetHead(ctx, mock.TipSet(d)))
+	require.Equal(t, []tip{
+		{mock.TipSet(d).Key(), true, d.Height},
+		{b.Key(), false, 6},
+		{a.Key(), false, 6},
+	}, tips())
+}
+
+func mustLoad(t *testing.T, cs *store.ChainStore, tsk types.TipSetKey) *types.TipSet {
+	ts, err := cs.LoadTipSet(context.Background(), tsk)
+	require.NoError(t, err)
+	return ts
+}
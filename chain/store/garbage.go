@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+)
+
+// StateGarbage walks the chain from the head and reports the objects of the
+// hot state blockstore unreachable from the state trees of the latest
+// opts.Keep epochs, see api.StateGarbage. The block headers, messages and
+// receipts of the chain are live. The walk of the older state roots stops at
+// the first one missing from the store, below which the states were pruned.
+//
+// Only raw and dag-cbor blocks are considered, as in snapshots.
+func (cs *ChainStore) StateGarbage(ctx context.Context, opts api.StateGarbageOpts) (api.StateGarbage, error) {
+	head := cs.GetHeaviestTipSet()
+	if head == nil {
+		return api.StateGarbage{}, xerrors.Errorf("no head to walk from")
+	}
+	if opts.Keep < 1 {
+		return api.StateGarbage{}, xerrors.Errorf("at least the state of the head must be kept")
+	}
+	if opts.Bucket <= 0 {
+		opts.Bucket = builtin.EpochsInDay
+	}
+	res := api.StateGarbage{Head: head.Key(), Height: head.Height(), Keep: opts.Keep, Lowest: head.Height()}
+
+	// walked are the blocks reached from the chain and the state roots, older
+	// those only reached from the state roots below the kept ones
+	walked, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return res, err
+	}
+	defer walked.Close() //nolint:errcheck
+	older, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return res, err
+	}
+	defer older.Close() //nolint:errcheck
+
+	msgWalker := newLinkWalker(ctx, cs.chainBlockstore, walked, ExportWorkers)
+	stateWalker := newLinkWalker(ctx, cs.stateBlockstore, walked, ExportWorkers)
+
+	// walk walks the blocks reachable from root not walked yet, if root is in
+	// bs, returning them
+	walk := func(lw *linkWalker, bs bstore.Blockstore, root cid.Cid) ([]cid.Cid, bool, error) {
+		has, err := bs.Has(ctx, root)
+		if err != nil || !has {
+			return nil, has, err
+		}
+		visit, err := walked.Visit(root)
+		if err != nil || !visit {
+			return nil, true, err
+		}
+		cids, err := lw.recurse(root, []cid.Cid{root})
+		return cids, true, err
+	}
+
+	// the chain and the kept state trees, collecting the older state roots
+	type stateRoot struct {
+		height abi.ChainEpoch
+		root   cid.Cid
+	}
+	var roots []stateRoot
+	oldRoots := cid.NewSet()
+	keptBelow := head.Height() - opts.Keep
+	seen := cid.NewSet()
+	queue := head.Cids()
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if !seen.Visit(c) {
+			continue
+		}
+		b, err := cs.GetBlock(ctx, c)
+		if ipld.IsNotFound(err) {
+			// pruned history
+			continue
+		}
+		if err != nil {
+			return res, xerrors.Errorf("loading block: %w", err)
+		}
+		if _, err := walked.Visit(c); err != nil {
+			return res, err
+		}
+		if b.Height > 0 {
+			queue = append(queue, b.Parents...)
+		}
+
+		if _, _, err := walk(msgWalker, cs.chainBlockstore, b.Messages); err != nil {
+			return res, xerrors.Errorf("walking messages of block %d: %w", b.Height, err)
+		}
+		if _, _, err := walk(stateWalker, cs.stateBlockstore, b.ParentMessageReceipts); err != nil {
+			return res, xerrors.Errorf("walking receipts of block %d: %w", b.Height, err)
+		}
+
+		switch {
+		case b.Height > keptBelow:
+			_, has, err := walk(stateWalker, cs.stateBlockstore, b.ParentStateRoot)
+			if err != nil {
+				return res, xerrors.Errorf("walking state of block %d: %w", b.Height, err)
+			}
+			if !has {
+				return res, xerrors.Errorf("kept state %s of block %d is missing", b.ParentStateRoot, b.Height)
+			}
+		case opts.Lookback <= 0 || b.Height > keptBelow-opts.Lookback:
+			if oldRoots.Visit(b.ParentStateRoot) {
+				roots = append(roots, stateRoot{height: b.Height, root: b.ParentStateRoot})
+			}
+		}
+	}
+
+	// the older state roots, from the newest
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].height > roots[j].height
+	})
+	for _, r := range roots {
+		cids, has, err := walk(stateWalker, cs.stateBlockstore, r.root)
+		if err != nil {
+			return res, xerrors.Errorf("walking state at %d: %w", r.height, err)
+		}
+		if !has {
+			break
+		}
+		res.Lowest = r.height
+
+		i := int((keptBelow - r.height) / opts.Bucket)
+		for len(res.Buckets) <= i {
+			to := keptBelow - abi.ChainEpoch(len(res.Buckets))*opts.Bucket
+			res.Buckets = append(res.Buckets, api.StateGarbageBucket{From: to - opts.Bucket + 1, To: to})
+		}
+		bucket := &res.Buckets[i]
+		bucket.Roots++
+		for _, c := range cids {
+			if !exportable(c) {
+				continue
+			}
+			if _, err := older.Visit(c); err != nil {
+				return res, err
+			}
+			size, err := cs.stateBlockstore.GetSize(ctx, c)
+			if err != nil {
+				return res, xerrors.Errorf("getting size of %s: %w", c, err)
+			}
+			bucket.Objects.Add(size)
+		}
+	}
+
+	// sort out the objects of the store
+	_, hotState := cs.hotBlockstores()
+	err = forEachBlockstoreKey(ctx, hotState, func(c cid.Cid) error {
+		if !exportable(c) {
+			return nil
+		}
+		size, err := hotState.GetSize(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("getting size of %s: %w", c, err)
+		}
+		res.Total.Add(size)
+
+		reached, err := walked.Has(c)
+		if err != nil {
+			return err
+		}
+		if !reached {
+			res.Dangling.Add(size)
+			return nil
+		}
+		old, err := older.Has(c)
+		if err != nil {
+			return err
+		}
+		if !old {
+			res.Live.Add(size)
+		}
+		return nil
+	})
+	if err != nil {
+		return res, xerrors.Errorf("scanning state blockstore: %w", err)
+	}
+
+	return res, nil
+}
+
+// forEachBlockstoreKey calls f with the keys of bs.
+func forEachBlockstoreKey(ctx context.Context, bs bstore.Blockstore, f func(cid.Cid) error) error {
+	if iter, ok := bs.(bstore.BlockstoreIterator); ok {
+		return iter.ForEachKey(f)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return err
+	}
+	for c := range ch {
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
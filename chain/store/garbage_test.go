@@ -0,0 +1,87 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestStateGarbage(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+	adtStore := cs.ActorStore(ctx)
+
+	code, err := cid.V1Builder{Codec: cid.Raw, MhType: multihash.IDENTITY}.Sum([]byte("fil/test/garbage"))
+	require.NoError(t, err)
+
+	// a state tree of an actor whose state is v, three objects with the
+	// state root and actors HAMT
+	stateRoot := func(v int64) cid.Cid {
+		st := cbg.CborInt(v)
+		head, err := adtStore.Put(ctx, &st)
+		require.NoError(t, err)
+		tree, err := state.NewStateTree(adtStore, types.StateTreeVersion4)
+		require.NoError(t, err)
+		require.NoError(t, tree.SetActor(mock.Address(100), &types.Actor{Code: code, Head: head, Balance: types.NewInt(0)}))
+		root, err := tree.Flush(ctx)
+		require.NoError(t, err)
+		return root
+	}
+	oldRoot, newRoot := stateRoot(1), stateRoot(2)
+	stateRoot(3) // left over by a reorg
+
+	var ts *types.TipSet
+	for h := 0; h < 10; h++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		blk.ParentStateRoot = oldRoot
+		if h >= 5 {
+			blk.ParentStateRoot = newRoot
+		}
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+	}
+	require.NoError(t, cs.SetHead(ctx, ts))
+
+	// the states of heights 7 to 9 are kept, the new state is also that of
+	// heights 5 and 6, and the old one is first reached at height 4
+	g, err := cs.StateGarbage(ctx, api.StateGarbageOpts{Keep: 3, Bucket: 2})
+	require.NoError(t, err)
+	require.Equal(t, ts.Key(), g.Head)
+	require.Equal(t, []api.StateGarbageBucket{
+		{From: 5, To: 6, Roots: 1},
+		{From: 3, To: 4, Roots: 1, Objects: api.BlockstoreObjects{Count: 3, Bytes: g.Buckets[1].Objects.Bytes}},
+	}, g.Buckets)
+	require.Equal(t, uint64(3), g.Dangling.Count)
+	require.Equal(t, g.Total.Count-6, g.Live.Count)
+	require.Equal(t, g.Total.Bytes, g.Live.Bytes+g.Dangling.Bytes+g.Buckets[1].Objects.Bytes)
+	require.Equal(t, abi.ChainEpoch(4), g.Lowest)
+
+	// the old state is not walked within the lookback
+	g, err = cs.StateGarbage(ctx, api.StateGarbageOpts{Keep: 3, Bucket: 2, Lookback: 2})
+	require.NoError(t, err)
+	require.Len(t, g.Buckets, 1)
+	require.Equal(t, uint64(6), g.Dangling.Count)
+	require.Equal(t, abi.ChainEpoch(6), g.Lowest)
+
+	_, err = cs.StateGarbage(ctx, api.StateGarbageOpts{})
+	require.Error(t, err)
+}
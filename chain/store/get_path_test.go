@@ -0,0 +1,89 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestGetPath(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	// extend grows ts by n tipsets, with some null rounds
+	r := rand.New(rand.NewSource(1))
+	extend := func(ts *types.TipSet, n int, nonce uint64) *types.TipSet {
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, 1, nonce)
+			if r.Intn(4) == 0 {
+				blk.Height += 1 + abi.ChainEpoch(r.Intn(3))
+			}
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+		}
+		return ts
+	}
+
+	gen := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen.Blocks()...))
+	require.NoError(t, cs.SetGenesis(ctx, gen.Blocks()[0]))
+
+	main := extend(gen, 300, 1)
+	require.NoError(t, cs.SetHead(ctx, main))
+	heads := []*types.TipSet{gen, main}
+	for i, at := range []abi.ChainEpoch{0, 1, 5, 100, 250, 299} {
+		base, err := cs.GetTipsetByHeight(ctx, at, main, true)
+		require.NoError(t, err)
+		heads = append(heads, base, extend(base, 1+i*17, uint64(i+2)))
+	}
+
+	for _, from := range heads {
+		for _, to := range heads {
+			revert, apply, err := cs.ReorgOps(ctx, from, to)
+			require.NoError(t, err)
+			var want []*api.HeadChange
+			for _, ts := range revert {
+				want = append(want, &api.HeadChange{Type: store.HCRevert, Val: ts})
+			}
+			for i := len(apply) - 1; i >= 0; i-- {
+				want = append(want, &api.HeadChange{Type: store.HCApply, Val: apply[i]})
+			}
+
+			path, err := cs.GetPath(ctx, from.Key(), to.Key())
+			require.NoError(t, err)
+			require.Len(t, path, len(want), "from %d to %d", from.Height(), to.Height())
+			for i := range want {
+				require.Equal(t, want[i], path[i])
+			}
+		}
+	}
+
+	// chains without a common genesis have no path between them
+	otherGen := mock.MkBlock(nil, 1, 9)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, otherGen))
+	other := extend(mock.TipSet(otherGen), 3, 9)
+	_, err := cs.GetPath(ctx, main.Key(), other.Key())
+	require.Error(t, err)
+}
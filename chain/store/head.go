@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// The head is persisted twice, in the primary record and a backup written
+// after it, so that a record corrupted by a torn write leaves the other one
+// usable. The primary record is the JSON list of the CIDs of the head, as read
+// by older versions; the backup is the key of the head tipset prefixed with its
+// CRC-32C.
+
+var headCRC = crc32.MakeTable(crc32.Castagnoli)
+
+func encodeHeadRecord(tsk types.TipSetKey) []byte {
+	b := tsk.Bytes()
+	out := make([]byte, 4, 4+len(b))
+	binary.BigEndian.PutUint32(out, crc32.Checksum(b, headCRC))
+	return append(out, b...)
+}
+
+func decodeHeadRecord(data []byte) (types.TipSetKey, error) {
+	if len(data) > 0 && data[0] == '[' {
+		var tscids []cid.Cid
+		if err := json.Unmarshal(data, &tscids); err != nil {
+			return types.EmptyTSK, xerrors.Errorf("failed to unmarshal stored chain head: %w", err)
+		}
+		return types.NewTipSetKey(tscids...), nil
+	}
+
+	if len(data) < 4 {
+		return types.EmptyTSK, xerrors.Errorf("stored chain head is truncated")
+	}
+	if sum := crc32.Checksum(data[4:], headCRC); sum != binary.BigEndian.Uint32(data) {
+		return types.EmptyTSK, xerrors.Errorf("stored chain head checksum mismatch")
+	}
+	tsk, err := types.TipSetKeyFromBytes(data[4:])
+	if err != nil {
+		return types.EmptyTSK, xerrors.Errorf("failed to decode stored chain head: %w", err)
+	}
+	return tsk, nil
+}
+
+func (cs *ChainStore) loadHeadRecord(ctx context.Context, k dstore.Key) (*types.TipSet, error) {
+	data, err := cs.metadataDs.Get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	tsk, err := decodeHeadRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := cs.LoadTipSet(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset: %w", err)
+	}
+	return ts, nil
+}
+
+func (cs *ChainStore) loadHead(ctx context.Context) error {
+	ts, err := cs.loadHeadRecord(ctx, chainHeadKey)
+	if err == nil {
+		cs.heaviest = ts
+		return nil
+	}
+
+	bts, berr := cs.loadHeadRecord(ctx, chainHeadBackupKey)
+	if err == dstore.ErrNotFound && berr == dstore.ErrNotFound {
+		log.Warn("no previous chain state found")
+		return nil
+	}
+	if berr == nil {
+		log.Errorw("chain head is unusable, using its backup", "error", err, "head", bts.Cids(), "height", bts.Height())
+		ts = bts
+	} else {
+		log.Errorw("chain head and its backup are unusable, recovering the heaviest known tipset", "error", err, "backupError", berr)
+		if ts, err = cs.recoverHead(ctx); err != nil {
+			return xerrors.Errorf("failed to load chain state from datastore: %w", err)
+		}
+		log.Warnw("recovered chain head", "head", ts.Cids(), "height", ts.Height())
+	}
+
+	cs.heaviest = ts
+	return cs.writeHead(ctx, ts)
+}
+
+// recoverHead returns the heaviest tipset known to the head journal and the
+// height index, for when both records of the head are unusable. Without them,
+// it returns the heaviest tipset of the block headers in the chain blockstore.
+func (cs *ChainStore) recoverHead(ctx context.Context) (*types.TipSet, error) {
+	var candidates []types.TipSetKey
+
+	// the last head change of the journal
+	if data, err := cs.metadataDs.Get(ctx, headJournalNextKey); err == nil {
+		if next, n := binary.Uvarint(data); n > 0 && next > 0 {
+			data, err := cs.metadataDs.Get(ctx, headJournalKey(next-1))
+			if err == nil {
+				var ent *api.HeadJournalEntry
+				if ent, err = decodeHeadJournalEntry(data); err == nil {
+					candidates = append(candidates, ent.To)
+				}
+			}
+			if err != nil {
+				log.Warnw("reading last head journal entry", "error", err)
+			}
+		}
+	} else if err != dstore.ErrNotFound {
+		log.Warnw("reading head journal", "error", err)
+	}
+
+	// the top of the height index
+	if tsk, ok, err := cs.heightIndexTop(ctx); err != nil {
+		log.Warnw("reading height index", "error", err)
+	} else if ok {
+		candidates = append(candidates, tsk)
+	}
+
+	if best := cs.heaviestOf(ctx, candidates); best != nil {
+		return best, nil
+	}
+
+	log.Warn("no usable chain head found in the head journal or the height index, scanning the block headers")
+	leaves, err := cs.headerLeaves(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("scanning block headers: %w", err)
+	}
+	if best := cs.heaviestOf(ctx, leaves); best != nil {
+		return best, nil
+	}
+	return nil, xerrors.Errorf("no usable chain head found in the head journal, the height index or the block headers")
+}
+
+// heaviestOf returns the heaviest of the tipsets that can be loaded and
+// weighed, or nil.
+func (cs *ChainStore) heaviestOf(ctx context.Context, tsks []types.TipSetKey) *types.TipSet {
+	var best *types.TipSet
+	var bestWeight types.BigInt
+	for _, tsk := range tsks {
+		ts, err := cs.LoadTipSet(ctx, tsk)
+		if err != nil {
+			log.Warnw("loading head candidate", "tipset", tsk, "error", err)
+			continue
+		}
+		w := types.NewInt(uint64(ts.Height()))
+		if cs.weight != nil {
+			if w, err = cs.weight(ctx, cs.StateBlockstore(), ts); err != nil {
+				log.Warnw("computing weight of head candidate", "tipset", tsk, "error", err)
+				continue
+			}
+		}
+		if best == nil || w.GreaterThan(bestWeight) {
+			best, bestWeight = ts, w
+		}
+	}
+	return best
+}
+
+// headerLeaves returns the keys of the tipsets formed by the block headers of
+// the chain blockstore that no other header has as parents. The other tipsets
+// weigh no more than the parent weight of their children, so the heaviest
+// tipset is one of them. Only the headers within finality of the highest one
+// are kept while the blockstore is scanned, the leaves of the forks further
+// behind not being candidates for the head.
+func (cs *ChainStore) headerLeaves(ctx context.Context) ([]types.TipSetKey, error) {
+	keys, err := cs.chainBlockstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type tipsetID struct {
+		parents types.TipSetKey
+		height  abi.ChainEpoch
+	}
+	tipsets := make(map[tipsetID][]*types.BlockHeader)
+	// the keys of the tipsets with children, with the height of the highest
+	// child, above theirs
+	parents := make(map[types.TipSetKey]abi.ChainEpoch)
+	top, cutoff := abi.ChainEpoch(-1), abi.ChainEpoch(-1)
+	for c := range keys {
+		if c.Prefix().Codec != cid.DagCBOR {
+			continue
+		}
+		blk, err := cs.chainBlockstore.Get(ctx, c)
+		if err != nil {
+			return nil, xerrors.Errorf("reading block %s: %w", c, err)
+		}
+		// block headers are CBOR arrays of 16 fields; messages and state
+		// objects are skipped without decoding them
+		if raw := blk.RawData(); len(raw) == 0 || raw[0] != 0x90 {
+			continue
+		}
+		bh, err := types.DecodeBlock(blk.RawData())
+		if err != nil || bh.Height < cutoff {
+			continue
+		}
+
+		if bh.Height > top {
+			top = bh.Height
+			if top-build.Finality > cutoff {
+				cutoff = top - build.Finality
+				for id := range tipsets {
+					if id.height < cutoff {
+						delete(tipsets, id)
+					}
+				}
+				for tsk, h := range parents {
+					if h < cutoff {
+						delete(parents, tsk)
+					}
+				}
+			}
+		}
+
+		id := tipsetID{parents: types.NewTipSetKey(bh.Parents...), height: bh.Height}
+		tipsets[id] = append(tipsets[id], bh)
+		if h, ok := parents[id.parents]; !ok || bh.Height > h {
+			parents[id.parents] = bh.Height
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var leaves []types.TipSetKey
+	for _, blks := range tipsets {
+		ts, err := types.NewTipSet(blks)
+		if err != nil {
+			log.Warnw("block headers not forming a tipset", "height", blks[0].Height, "error", err)
+			continue
+		}
+		if _, ok := parents[ts.Key()]; !ok {
+			leaves = append(leaves, ts.Key())
+		}
+	}
+	return leaves, nil
+}
+
+// heightIndexTop returns the tipset at the highest epoch of the persisted height
+// index.
+func (cs *ChainStore) heightIndexTop(ctx context.Context) (types.TipSetKey, bool, error) {
+	res, err := cs.metadataDs.Query(ctx, query.Query{
+		Prefix:   heightIndexPrefix.String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return types.EmptyTSK, false, err
+	}
+	ents, err := res.Rest()
+	if err != nil {
+		return types.EmptyTSK, false, err
+	}
+
+	top := int64(-1)
+	for _, e := range ents {
+		h, err := strconv.ParseInt(dstore.RawKey(e.Key).BaseNamespace(), 10, 64)
+		if err != nil {
+			continue
+		}
+		if h > top {
+			top = h
+		}
+	}
+	if top < 0 {
+		return types.EmptyTSK, false, nil
+	}
+
+	data, err := cs.metadataDs.Get(ctx, heightIndexKey(abi.ChainEpoch(top)))
+	if err != nil {
+		return types.EmptyTSK, false, err
+	}
+	tsk, err := types.TipSetKeyFromBytes(data)
+	if err != nil {
+		return types.EmptyTSK, false, err
+	}
+	return tsk, true, nil
+}
+
+func (cs *ChainStore) writeHead(ctx context.Context, ts *types.TipSet) error {
+	// when the state writes are batched, the state computed up to the head must
+	// be in the blockstore before the head is
+	_, sbs := cs.hotBlockstores()
+	if f, ok := sbs.(bstore.Flusher); ok {
+		if err := f.Flush(ctx); err != nil {
+			return xerrors.Errorf("flushing state writes: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(ts.Cids())
+	if err != nil {
+		return xerrors.Errorf("failed to marshal tipset: %w", err)
+	}
+
+	// the backup is written last, one of the records is intact if either write
+	// is torn
+	if err := cs.metadataDs.Put(ctx, chainHeadKey, data); err != nil {
+		return xerrors.Errorf("failed to write chain head to datastore: %w", err)
+	}
+	if err := cs.metadataDs.Put(ctx, chainHeadBackupKey, encodeHeadRecord(ts.Key())); err != nil {
+		return xerrors.Errorf("failed to write chain head backup to datastore: %w", err)
+	}
+
+	return nil
+}
+
+// ClearHead removes the persisted chain head from the metadata datastore ds,
+// e.g. to start over from genesis with already synced blocks.
+func ClearHead(ctx context.Context, ds dstore.Datastore) error {
+	for _, k := range []dstore.Key{chainHeadKey, chainHeadBackupKey} {
+		if err := ds.Delete(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// HeadJournal enables the persistent journal of the head changes of the
+// chainstore.
+var HeadJournal = true
+
+// HeadJournalRetain is the number of head changes kept in the head journal,
+// older ones are dropped as new ones are written.
+var HeadJournalRetain uint64 = 100_000
+
+var (
+	headJournalPrefix  = dstore.NewKey("/chain/headjournal")
+	headJournalNextKey = dstore.NewKey("/chain/headjournalnext")
+)
+
+// headJournal records every head change of the chainstore, with the tipsets it
+// reverts and applies, in the metadata datastore. Entries are written before
+// the head is updated and the change is delivered to the ReorgNotifees, so the
+// journal also covers the changes a crash or a stuck notifee cut short.
+type headJournal struct {
+	ds dstore.Batching
+
+	lk sync.Mutex
+	// first and next are the sequence numbers of the oldest entry kept, and of
+	// the next entry written
+	first uint64
+	next  uint64
+}
+
+func headJournalKey(seq uint64) dstore.Key {
+	// fixed width, so that the keys sort in sequence order
+	return headJournalPrefix.ChildString(fmt.Sprintf("%016x", seq))
+}
+
+func parseHeadJournalKey(k string) (uint64, error) {
+	return strconv.ParseUint(dstore.RawKey(k).BaseNamespace(), 16, 64)
+}
+
+// load finds the bounds of the journal written before.
+func (hj *headJournal) load(ctx context.Context) error {
+	hj.lk.Lock()
+	defer hj.lk.Unlock()
+
+	data, err := hj.ds.Get(ctx, headJournalNextKey)
+	if err == dstore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	next, n := binary.Uvarint(data)
+	if n <= 0 {
+		return xerrors.Errorf("invalid head journal sequence number")
+	}
+
+	res, err := hj.ds.Query(ctx, query.Query{
+		Prefix:   headJournalPrefix.String(),
+		Orders:   []query.Order{query.OrderByKey{}},
+		Limit:    1,
+		KeysOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	ents, err := res.Rest()
+	if err != nil {
+		return err
+	}
+	first := next
+	if len(ents) > 0 {
+		if first, err = parseHeadJournalKey(ents[0].Key); err != nil {
+			return xerrors.Errorf("invalid head journal key %q: %w", ents[0].Key, err)
+		}
+	}
+
+	hj.first, hj.next = first, next
+	return nil
+}
+
+// record writes the head change from old to new, reverting and applying the
+// given tipsets, and drops the entries past the retention.
+func (hj *headJournal) record(ctx context.Context, old, new *types.TipSet, revert, apply []*types.TipSet) error {
+	hj.lk.Lock()
+	defer hj.lk.Unlock()
+
+	ent := api.HeadJournalEntry{
+		Seq:        hj.next,
+		Time:       build.Clock.Now(),
+		From:       old.Key(),
+		FromHeight: old.Height(),
+		To:         new.Key(),
+		ToHeight:   new.Height(),
+	}
+	for _, ts := range revert {
+		ent.Revert = append(ent.Revert, ts.Key())
+	}
+	for _, ts := range apply {
+		ent.Apply = append(ent.Apply, ts.Key())
+	}
+
+	b, err := hj.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(ctx, headJournalKey(ent.Seq), encodeHeadJournalEntry(&ent)); err != nil {
+		return err
+	}
+	nb := make([]byte, binary.MaxVarintLen64)
+	if err := b.Put(ctx, headJournalNextKey, nb[:binary.PutUvarint(nb, ent.Seq+1)]); err != nil {
+		return err
+	}
+	first := hj.first
+	for ; HeadJournalRetain > 0 && ent.Seq-first >= HeadJournalRetain; first++ {
+		if err := b.Delete(ctx, headJournalKey(first)); err != nil {
+			return err
+		}
+	}
+	if err := b.Commit(ctx); err != nil {
+		return err
+	}
+
+	hj.first = first
+	hj.next++
+	return nil
+}
+
+// query returns the entries matching q, in sequence order, or newest first
+// with q.Reverse.
+func (hj *headJournal) query(ctx context.Context, q api.HeadJournalQuery) ([]api.HeadJournalEntry, error) {
+	since := q.Since
+	if q.Reverse && q.Limit > 0 {
+		// the datastore may not iterate keys backwards, start from the
+		// oldest of the newest entries instead
+		hj.lk.Lock()
+		if hj.next > uint64(q.Limit) && hj.next-uint64(q.Limit) > since {
+			since = hj.next - uint64(q.Limit)
+		}
+		hj.lk.Unlock()
+	}
+
+	dq := query.Query{
+		Prefix: headJournalPrefix.String(),
+		Orders: []query.Order{query.OrderByKey{}},
+		Limit:  q.Limit,
+	}
+	if since > 0 {
+		dq.Filters = []query.Filter{query.FilterKeyCompare{
+			Op:  query.GreaterThanOrEqual,
+			Key: headJournalKey(since).String(),
+		}}
+	}
+
+	res, err := hj.ds.Query(ctx, dq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []api.HeadJournalEntry
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		seq, err := parseHeadJournalKey(r.Key)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid head journal key %q: %w", r.Key, err)
+		}
+		ent, err := decodeHeadJournalEntry(r.Value)
+		if err != nil {
+			return nil, xerrors.Errorf("decoding head journal entry %d: %w", seq, err)
+		}
+		ent.Seq = seq
+		out = append(out, *ent)
+	}
+	if q.Reverse {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return out, nil
+}
+
+// Entries are encoded as the timestamp, the heights and keys of the old and new
+// heads, then the counts and keys of the reverted and applied tipsets. The
+// sequence number is the key of the entry.
+func encodeHeadJournalEntry(ent *api.HeadJournalEntry) []byte {
+	var buf []byte
+	tmp := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(tmp, v)
+		buf = append(buf, tmp[:n]...)
+	}
+	putKey := func(tsk types.TipSetKey) {
+		b := tsk.Bytes()
+		putUvarint(uint64(len(b)))
+		buf = append(buf, b...)
+	}
+
+	n := binary.PutVarint(tmp, ent.Time.UnixNano())
+	buf = append(buf, tmp[:n]...)
+	putUvarint(uint64(ent.FromHeight))
+	putKey(ent.From)
+	putUvarint(uint64(ent.ToHeight))
+	putKey(ent.To)
+	for _, tsks := range [][]types.TipSetKey{ent.Revert, ent.Apply} {
+		putUvarint(uint64(len(tsks)))
+		for _, tsk := range tsks {
+			putKey(tsk)
+		}
+	}
+	return buf
+}
+
+func decodeHeadJournalEntry(data []byte) (*api.HeadJournalEntry, error) {
+	errInvalid := xerrors.Errorf("invalid head journal entry")
+	getUvarint := func() (uint64, error) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, errInvalid
+		}
+		data = data[n:]
+		return v, nil
+	}
+	getKey := func() (types.TipSetKey, error) {
+		l, err := getUvarint()
+		if err != nil {
+			return types.EmptyTSK, err
+		}
+		if uint64(len(data)) < l {
+			return types.EmptyTSK, errInvalid
+		}
+		tsk, err := types.TipSetKeyFromBytes(data[:l])
+		if err != nil {
+			return types.EmptyTSK, xerrors.Errorf("invalid head journal entry: %w", err)
+		}
+		data = data[l:]
+		return tsk, nil
+	}
+	getHead := func() (abi.ChainEpoch, types.TipSetKey, error) {
+		h, err := getUvarint()
+		if err != nil {
+			return 0, types.EmptyTSK, err
+		}
+		tsk, err := getKey()
+		return abi.ChainEpoch(h), tsk, err
+	}
+
+	t, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, errInvalid
+	}
+	data = data[n:]
+
+	var err error
+	ent := &api.HeadJournalEntry{Time: time.Unix(0, t)}
+	if ent.FromHeight, ent.From, err = getHead(); err != nil {
+		return nil, err
+	}
+	if ent.ToHeight, ent.To, err = getHead(); err != nil {
+		return nil, err
+	}
+	for _, tsks := range []*[]types.TipSetKey{&ent.Revert, &ent.Apply} {
+		cnt, err := getUvarint()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < cnt; i++ {
+			tsk, err := getKey()
+			if err != nil {
+				return nil, err
+			}
+			*tsks = append(*tsks, tsk)
+		}
+	}
+	if len(data) != 0 {
+		return nil, errInvalid
+	}
+	return ent, nil
+}
+
+// HeadJournal returns the head changes recorded in the head journal matching q.
+// Entries list the reverted tipsets from the old head down, and the applied
+// tipsets up to the new head, the order head change notifications deliver them
+// in.
+func (cs *ChainStore) HeadJournal(ctx context.Context, q api.HeadJournalQuery) ([]api.HeadJournalEntry, error) {
+	if cs.hjournal == nil {
+		return nil, xerrors.Errorf("the head journal is disabled")
+	}
+	return cs.hjournal.query(ctx, q)
+}
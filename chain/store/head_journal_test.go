@@ -0,0 +1,107 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestHeadJournal(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+
+	bs := blockstore.NewMemorySync()
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	cs := store.NewChainStore(bs, bs, ds, weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	extend := func(ts *types.TipSet, n int, w, nonce uint64) []*types.TipSet {
+		var out []*types.TipSet
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, w, nonce)
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+			out = append(out, ts)
+		}
+		return out
+	}
+	keys := func(tss ...*types.TipSet) []types.TipSetKey {
+		var out []types.TipSetKey
+		for _, ts := range tss {
+			out = append(out, ts.Key())
+		}
+		return out
+	}
+	type change struct {
+		seq           uint64
+		from, to      *types.TipSet
+		revert, apply []types.TipSetKey
+	}
+	changes := func(q api.HeadJournalQuery) []change {
+		ents, err := cs.HeadJournal(ctx, q)
+		require.NoError(t, err)
+		var out []change
+		for _, ent := range ents {
+			require.False(t, ent.Time.IsZero())
+			from, to := mustLoad(t, cs, ent.From), mustLoad(t, cs, ent.To)
+			require.Equal(t, from.Height(), ent.FromHeight)
+			require.Equal(t, to.Height(), ent.ToHeight)
+			out = append(out, change{ent.Seq, from, to, ent.Revert, ent.Apply})
+		}
+		return out
+	}
+
+	gen := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen.Blocks()...))
+	require.NoError(t, cs.SetHead(ctx, gen))
+
+	a := extend(gen, 3, 1, 1)
+	require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, a[0]))
+	require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, a[2]))
+	b := extend(a[0], 3, 2, 2)
+	require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, b[2]))
+
+	// the reverts of a reorg are listed from the old head down, the applies up
+	// to the new head
+	all := []change{
+		{0, gen, a[0], nil, keys(a[0])},
+		{1, a[0], a[2], nil, keys(a[1], a[2])},
+		{2, a[2], b[2], keys(a[2], a[1]), keys(b...)},
+	}
+	require.Equal(t, all, changes(api.HeadJournalQuery{}))
+	require.Equal(t, all[1:], changes(api.HeadJournalQuery{Since: 1}))
+	require.Equal(t, all[1:2], changes(api.HeadJournalQuery{Since: 1, Limit: 1}))
+	require.Equal(t, []change{all[2], all[1]}, changes(api.HeadJournalQuery{Limit: 2, Reverse: true}))
+
+	// the sequence continues after a restart, and old entries are dropped
+	defer func(r uint64) { store.HeadJournalRetain = r }(store.HeadJournalRetain)
+	store.HeadJournalRetain = 2
+
+	require.NoError(t, cs.Close())
+	cs = store.NewChainStore(bs, bs, ds, weight, nil)
+	require.NoError(t, cs.Load(ctx))
+
+	c := extend(b[2], 1, 1, 3)
+	require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, c[0]))
+	require.Equal(t, cs.GetHeaviestTipSet(), c[0])
+	require.Equal(t, []change{
+		all[2],
+		{3, b[2], c[0], nil, keys(c[0])},
+	}, changes(api.HeadJournalQuery{}))
+}
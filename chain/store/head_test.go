@@ -0,0 +1,120 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestHeadRecovery(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+
+	bs := blockstore.NewMemorySync()
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	headKey, backupKey := datastore.NewKey("head"), datastore.NewKey("/chain/headbackup")
+
+	cs := store.NewChainStore(bs, bs, ds, weight, nil)
+	var ts *types.TipSet
+	var chain []*types.TipSet
+	for i := 0; i < 5; i++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+		chain = append(chain, ts)
+		require.NoError(t, cs.SetHead(ctx, ts))
+	}
+	require.NoError(t, cs.Close())
+
+	load := func() (*types.TipSet, error) {
+		cs := store.NewChainStore(bs, bs, ds, weight, nil)
+		defer cs.Close() //nolint:errcheck
+		if err := cs.Load(ctx); err != nil {
+			return nil, err
+		}
+		return cs.GetHeaviestTipSet(), nil
+	}
+	corrupt := func(k datastore.Key) {
+		data, err := ds.Get(ctx, k)
+		require.NoError(t, err)
+		data = append([]byte{}, data...)
+		data[len(data)-1] ^= 0xff
+		require.NoError(t, ds.Put(ctx, k, data))
+	}
+	requireHead := func() {
+		head, err := load()
+		require.NoError(t, err)
+		require.Equal(t, ts, head)
+	}
+
+	requireHead()
+
+	// a corrupted head falls back to its backup, and is rewritten
+	corrupt(headKey)
+	requireHead()
+
+	// with both records corrupted the head is recovered from the indexes
+	corrupt(headKey)
+	corrupt(backupKey)
+	requireHead()
+	require.NoError(t, ds.Delete(ctx, backupKey))
+	requireHead()
+
+	// the head is still the JSON list of its CIDs read by older versions
+	data, err := ds.Get(ctx, headKey)
+	require.NoError(t, err)
+	var cids []cid.Cid
+	require.NoError(t, json.Unmarshal(data, &cids))
+	require.Equal(t, ts.Cids(), cids)
+
+	// without the indexes the head is recovered from the block headers,
+	// picking the heaviest of the forks
+	fork := mock.MkBlock(chain[2], 1, 2)
+	cs = store.NewChainStore(bs, bs, ds, weight, nil)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, fork))
+	require.NoError(t, cs.Close())
+	require.NoError(t, ds.Delete(ctx, datastore.NewKey("/chain/headjournalnext")))
+	for _, prefix := range []string{"/chain/headjournal", "/chain/height"} {
+		res, err := ds.Query(ctx, query.Query{Prefix: prefix, KeysOnly: true})
+		require.NoError(t, err)
+		ents, err := res.Rest()
+		require.NoError(t, err)
+		for _, e := range ents {
+			require.NoError(t, ds.Delete(ctx, datastore.NewKey(e.Key)))
+		}
+	}
+	corrupt(headKey)
+	require.NoError(t, ds.Delete(ctx, backupKey))
+	requireHead()
+
+	// recovery fails without any block header
+	bs = blockstore.NewMemorySync()
+	corrupt(headKey)
+	corrupt(backupKey)
+	_, err = load()
+	require.Error(t, err)
+
+	// a cleared head starts over
+	require.NoError(t, store.ClearHead(ctx, ds))
+	head, err := load()
+	require.NoError(t, err)
+	require.Nil(t, head)
+}
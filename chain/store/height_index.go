@@ -0,0 +1,412 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	dstore "github.com/ipfs/go-datastore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// HeightIndex enables the persistent index of the heaviest chain by height,
+// which answers GetTipsetByHeight without walking the chain.
+var HeightIndex = true
+
+// HeightIndexBackfillBatch is the number of tipsets the height index is
+// backfilled with at a time.
+var HeightIndexBackfillBatch = 2000
+
+var (
+	heightIndexPrefix  = dstore.NewKey("/chain/height")
+	heightIndexBaseKey = dstore.NewKey("/chain/heightbase")
+)
+
+// heightIndex maps every epoch in [base, top] of the heaviest chain to the key
+// of the tipset at that epoch, null rounds map to the tipset above them. It is
+// kept in the metadata datastore and updated as the head changes. Epochs below
+// base are backfilled in the background, down to genesis.
+//
+// The index is only consulted for tipsets it holds, lookups from anywhere else
+// fall back to the ChainIndex.
+type heightIndex struct {
+	ds         dstore.Batching
+	loadTipSet loadTipSetFunc
+
+	lk    sync.RWMutex
+	ready bool
+	base  abi.ChainEpoch
+	top   abi.ChainEpoch
+
+	wake chan struct{}
+}
+
+func newHeightIndex(ds dstore.Batching, lts loadTipSetFunc) *heightIndex {
+	return &heightIndex{
+		ds:         ds,
+		loadTipSet: lts,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func heightIndexKey(h abi.ChainEpoch) dstore.Key {
+	return heightIndexPrefix.ChildString(strconv.FormatInt(int64(h), 10))
+}
+
+func (hi *heightIndex) get(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, bool, error) {
+	data, err := hi.ds.Get(ctx, heightIndexKey(h))
+	if err == dstore.ErrNotFound {
+		return types.EmptyTSK, false, nil
+	}
+	if err != nil {
+		return types.EmptyTSK, false, err
+	}
+	tsk, err := types.TipSetKeyFromBytes(data)
+	if err != nil {
+		return types.EmptyTSK, false, err
+	}
+	return tsk, true, nil
+}
+
+// holds returns whether the index holds ts, with hi.lk held.
+func (hi *heightIndex) holds(ctx context.Context, ts *types.TipSet) (bool, error) {
+	if !hi.ready || ts.Height() < hi.base || ts.Height() > hi.top {
+		return false, nil
+	}
+	tsk, ok, err := hi.get(ctx, ts.Height())
+	if err != nil || !ok {
+		return false, err
+	}
+	return tsk == ts.Key(), nil
+}
+
+// lookup returns the tipset at height h in the chain of from, or the first
+// tipset above it if h is a null round. ok is false when the index can't tell.
+func (hi *heightIndex) lookup(ctx context.Context, from *types.TipSet, h abi.ChainEpoch) (_ *types.TipSet, ok bool, err error) {
+	hi.lk.RLock()
+	defer hi.lk.RUnlock()
+
+	if h < hi.base {
+		return nil, false, nil
+	}
+	if ok, err := hi.holds(ctx, from); err != nil || !ok {
+		return nil, false, err
+	}
+
+	tsk, ok, err := hi.get(ctx, h)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	ts, err := hi.loadTipSet(ctx, tsk)
+	if err != nil {
+		return nil, false, err
+	}
+	return ts, true, nil
+}
+
+// parentHeight returns the height of the parent of ts, -1 for genesis.
+func (hi *heightIndex) parentHeight(ctx context.Context, ts *types.TipSet) (abi.ChainEpoch, error) {
+	if ts.Height() == 0 {
+		return -1, nil
+	}
+	pts, err := hi.loadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return 0, xerrors.Errorf("loading parent of %s: %w", ts.Key(), err)
+	}
+	return pts.Height(), nil
+}
+
+// put maps the epochs from the parent of ts, exclusive, up to ts to ts.
+func (hi *heightIndex) put(ctx context.Context, b dstore.Batch, ts *types.TipSet, parentHeight abi.ChainEpoch) error {
+	for h := parentHeight + 1; h <= ts.Height(); h++ {
+		if err := b.Put(ctx, heightIndexKey(h), ts.Key().Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (hi *heightIndex) putBase(ctx context.Context, b dstore.Batch, base abi.ChainEpoch) error {
+	return b.Put(ctx, heightIndexBaseKey, []byte(strconv.FormatInt(int64(base), 10)))
+}
+
+// load picks up the index of the chain of head, as it was persisted. The
+// index is reset to head if it doesn't hold it.
+func (hi *heightIndex) load(ctx context.Context, head *types.TipSet) error {
+	hi.lk.Lock()
+	defer hi.lk.Unlock()
+
+	data, err := hi.ds.Get(ctx, heightIndexBaseKey)
+	switch err {
+	case nil:
+		base, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing height index base: %w", err)
+		}
+		hi.ready, hi.base, hi.top = true, abi.ChainEpoch(base), head.Height()
+		if ok, err := hi.holds(ctx, head); err != nil || !ok {
+			hi.ready = false
+			if err != nil {
+				return err
+			}
+		}
+	case dstore.ErrNotFound:
+	default:
+		return xerrors.Errorf("loading height index base: %w", err)
+	}
+
+	if !hi.ready {
+		log.Infow("resetting height index", "head", head.Height())
+		return hi.reset(ctx, head)
+	}
+
+	// a head that was reverted before the index above it was
+	b, err := hi.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := hi.clearAbove(ctx, b, head.Height()); err != nil {
+		return err
+	}
+	if err := b.Commit(ctx); err != nil {
+		return err
+	}
+	hi.start()
+	return nil
+}
+
+// clearAbove removes the contiguous epochs above h from the index.
+func (hi *heightIndex) clearAbove(ctx context.Context, b dstore.Batch, h abi.ChainEpoch) error {
+	for h := h + 1; ; h++ {
+		has, err := hi.ds.Has(ctx, heightIndexKey(h))
+		if err != nil {
+			return err
+		}
+		if !has {
+			return nil
+		}
+		if err := b.Delete(ctx, heightIndexKey(h)); err != nil {
+			return err
+		}
+	}
+}
+
+// reset restarts the index from ts, with hi.lk held. The epochs below it are
+// backfilled anew.
+func (hi *heightIndex) reset(ctx context.Context, ts *types.TipSet) error {
+	ph, err := hi.parentHeight(ctx, ts)
+	if err != nil {
+		return err
+	}
+
+	b, err := hi.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := hi.clearAbove(ctx, b, ts.Height()); err != nil {
+		return err
+	}
+	if err := hi.put(ctx, b, ts, ph); err != nil {
+		return err
+	}
+	if err := hi.putBase(ctx, b, ph+1); err != nil {
+		return err
+	}
+	if err := b.Commit(ctx); err != nil {
+		return xerrors.Errorf("writing height index: %w", err)
+	}
+
+	hi.ready, hi.base, hi.top = true, ph+1, ts.Height()
+	hi.start()
+	return nil
+}
+
+// headChange is the ReorgNotifee of the index.
+func (hi *heightIndex) headChange(rev, app []*types.TipSet) error {
+	ctx := context.TODO()
+
+	hi.lk.Lock()
+	defer hi.lk.Unlock()
+
+	if err := hi.update(ctx, rev, app); err != nil {
+		// the index is only trusted for the tipsets it holds, a reset
+		// brings it back in line with the head
+		log.Errorw("updating height index, resetting it", "error", err)
+		if len(app) > 0 {
+			if err := hi.reset(ctx, app[len(app)-1]); err != nil {
+				log.Errorw("resetting height index", "error", err)
+				hi.ready = false
+			}
+		} else {
+			hi.ready = false
+		}
+	}
+	return nil
+}
+
+func (hi *heightIndex) update(ctx context.Context, rev, app []*types.TipSet) error {
+	if !hi.ready {
+		if len(app) == 0 {
+			return nil
+		}
+		return hi.reset(ctx, app[len(app)-1])
+	}
+
+	b, err := hi.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+
+	top := hi.top
+	for _, ts := range rev {
+		if ts.Height() != top {
+			continue
+		}
+		ph, err := hi.parentHeight(ctx, ts)
+		if err != nil {
+			return err
+		}
+		for h := ph + 1; h <= ts.Height(); h++ {
+			if err := b.Delete(ctx, heightIndexKey(h)); err != nil {
+				return err
+			}
+		}
+		top = ph
+	}
+
+	for _, ts := range app {
+		pts, err := hi.loadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent of %s: %w", ts.Key(), err)
+		}
+		// the head jumped, e.g. it was forced, or the index fell behind
+		if pts.Height() != top {
+			return hi.reset(ctx, app[len(app)-1])
+		}
+		if pts.Height() >= hi.base {
+			tsk, ok, err := hi.get(ctx, pts.Height())
+			if err != nil {
+				return err
+			}
+			if !ok || tsk != pts.Key() {
+				return hi.reset(ctx, app[len(app)-1])
+			}
+		}
+		if err := hi.put(ctx, b, ts, pts.Height()); err != nil {
+			return err
+		}
+		top = ts.Height()
+	}
+
+	if err := b.Commit(ctx); err != nil {
+		return xerrors.Errorf("writing height index: %w", err)
+	}
+	hi.top = top
+	return nil
+}
+
+func (hi *heightIndex) start() {
+	select {
+	case hi.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run backfills the index down to genesis, until ctx is done.
+func (hi *heightIndex) run(ctx context.Context) {
+	for {
+		select {
+		case <-hi.wake:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			done, err := hi.backfill(ctx)
+			if err != nil {
+				log.Errorw("backfilling height index", "error", err)
+				break
+			}
+			if done || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// backfill extends the index below base by a batch of tipsets, and returns
+// whether it reached genesis, or the pruned history. The tipsets are loaded without holding hi.lk, the
+// batch is dropped if the index was reset meanwhile.
+func (hi *heightIndex) backfill(ctx context.Context) (bool, error) {
+	hi.lk.RLock()
+	ready, base := hi.ready, hi.base
+	tsk, ok, err := hi.get(ctx, base)
+	hi.lk.RUnlock()
+
+	if !ready || base == 0 {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, xerrors.Errorf("height index has no tipset at its base %d", base)
+	}
+	ts, err := hi.loadTipSet(ctx, tsk)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := hi.ds.Batch(ctx)
+	if err != nil {
+		return false, err
+	}
+	newBase := base
+	pruned := false
+	for i := 0; i < HeightIndexBackfillBatch && newBase > 0; i++ {
+		ph, err := hi.parentHeight(ctx, ts)
+		if ipld.IsNotFound(err) {
+			// the chain below was pruned
+			pruned = true
+			break
+		}
+		if err != nil {
+			return false, xerrors.Errorf("backfilling height index below %d: %w", newBase, err)
+		}
+		if err := hi.put(ctx, b, ts, ph); err != nil {
+			return false, err
+		}
+		newBase = ph + 1
+		if newBase > 0 {
+			if ts, err = hi.loadTipSet(ctx, ts.Parents()); err != nil {
+				return false, err
+			}
+		}
+	}
+	if err := hi.putBase(ctx, b, newBase); err != nil {
+		return false, err
+	}
+
+	hi.lk.Lock()
+	defer hi.lk.Unlock()
+	if !hi.ready || hi.base != base {
+		return false, nil
+	}
+	if err := b.Commit(ctx); err != nil {
+		return false, xerrors.Errorf("writing height index: %w", err)
+	}
+
+	hi.base = newBase
+	switch {
+	case newBase == 0:
+		log.Infow("height index backfilled to genesis")
+	case pruned:
+		log.Infow("height index backfilled to the pruned history", "base", newBase)
+	}
+	return newBase == 0 || pruned, nil
+}
@@ -0,0 +1,121 @@
+// stm: #unit
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestHeightIndex(t *testing.T) {
+	ctx := context.Background()
+
+	batch := HeightIndexBackfillBatch
+	HeightIndexBackfillBatch = 7
+	defer func() { HeightIndexBackfillBatch = batch }()
+
+	bs := blockstore.NewMemorySync()
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	open := func() *ChainStore {
+		cs := NewChainStore(bs, bs, ds, nil, nil)
+		require.NoError(t, cs.Load(ctx))
+		return cs
+	}
+	cs := open()
+
+	// extend grows ts by n tipsets, with a null round every 7 epochs
+	extend := func(ts *types.TipSet, n int, nonce uint64) *types.TipSet {
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, 1, nonce)
+			if blk.Height%7 == 0 {
+				blk.Height++
+			}
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+		}
+		return ts
+	}
+
+	gen := mock.MkBlock(nil, 1, 1)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+	a := extend(mock.TipSet(gen), 50, 1)
+	require.NoError(t, cs.SetHead(ctx, a))
+
+	indexed := func(head *types.TipSet) func() bool {
+		return func() bool {
+			cs.hindex.lk.RLock()
+			defer cs.hindex.lk.RUnlock()
+			ok, err := cs.hindex.holds(ctx, head)
+			require.NoError(t, err)
+			return ok && cs.hindex.base == 0
+		}
+	}
+
+	// every lookup from head is answered by the index, like by the walk
+	check := func(head *types.TipSet) {
+		for h := abi.ChainEpoch(0); h <= head.Height(); h++ {
+			_, ok, err := cs.hindex.lookup(ctx, head, h)
+			require.NoError(t, err)
+			require.True(t, ok, "height %d", h)
+
+			for _, prev := range []bool{false, true} {
+				ts, err := cs.GetTipsetByHeight(ctx, h, head, prev)
+				require.NoError(t, err)
+
+				want, err := cs.cindex.GetTipsetByHeightWithoutCache(ctx, head, h)
+				require.NoError(t, err)
+				if want.Height() != h && prev {
+					want, err = cs.LoadTipSet(ctx, want.Parents())
+					require.NoError(t, err)
+				}
+				require.Equal(t, want.Key(), ts.Key(), "height %d prev %t", h, prev)
+			}
+		}
+	}
+
+	// the index is started and backfilled on load
+	require.NoError(t, cs.Close())
+	cs = open()
+	require.Eventually(t, indexed(a), 5*time.Second, 10*time.Millisecond)
+	check(a)
+
+	// it follows reorgs, lookups from the reverted chain use the walk
+	fork, err := cs.GetTipsetByHeight(ctx, 40, a, true)
+	require.NoError(t, err)
+	b := extend(fork, 20, 2)
+	require.NoError(t, cs.SetHead(ctx, b))
+	require.Eventually(t, indexed(b), 5*time.Second, 10*time.Millisecond)
+	check(b)
+
+	_, ok, err := cs.hindex.lookup(ctx, a, 10)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ts, err := cs.GetTipsetByHeight(ctx, 45, a, false)
+	require.NoError(t, err)
+	want, err := cs.cindex.GetTipsetByHeightWithoutCache(ctx, a, 45)
+	require.NoError(t, err)
+	require.Equal(t, want.Key(), ts.Key())
+
+	// it survives restarts without a reset
+	require.NoError(t, cs.Close())
+	cs = open()
+	require.True(t, indexed(b)())
+
+	// and is reset when the head jumps
+	require.NoError(t, cs.ForceHeadSilent(ctx, a))
+	a = extend(a, 3, 1)
+	require.NoError(t, cs.SetHead(ctx, a))
+	require.Eventually(t, indexed(a), 5*time.Second, 10*time.Millisecond)
+	check(a)
+	require.NoError(t, cs.Close())
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ipfs/bbloom"
+	blocks "github.com/ipfs/go-block-format"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// ImportDedupFPRate is the false positive rate of the bloom filter of existing
+// blocks used by deduplicating imports. Lower rates save blockstore lookups
+// at the cost of memory.
+var ImportDedupFPRate = 0.01
+
+// importDedup filters out the blocks of an import that are already in the
+// blockstore. A bloom filter of the existing keys lets blocks that are
+// certainly missing skip the blockstore lookup.
+type importDedup struct {
+	bs      bstore.Blockstore
+	bloom   *bbloom.Bloom
+	skipped uint64
+}
+
+// newImportDedup indexes the keys in bs. It returns nil if bs is empty, as
+// there is nothing to deduplicate against.
+func newImportDedup(ctx context.Context, bs bstore.Blockstore) (*importDedup, error) {
+	log.Infow("indexing existing blocks to deduplicate the import")
+
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing existing blocks: %w", err)
+	}
+	var n uint64
+	for range keys {
+		n++
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	bloom, err := bbloom.New(float64(n), ImportDedupFPRate)
+	if err != nil {
+		return nil, xerrors.Errorf("creating bloom filter: %w", err)
+	}
+
+	keys, err = bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing existing blocks: %w", err)
+	}
+	for c := range keys {
+		bloom.Add(c.Hash())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Infow("indexed existing blocks", "blocks", n)
+	return &importDedup{bs: bs, bloom: bloom}, nil
+}
+
+// missing returns the blocks of blks that are not in the blockstore yet.
+func (d *importDedup) missing(ctx context.Context, blks []blocks.Block) ([]blocks.Block, error) {
+	if d == nil {
+		return blks, nil
+	}
+
+	out := make([]blocks.Block, 0, len(blks))
+	for _, b := range blks {
+		if d.bloom.Has(b.Cid().Hash()) {
+			has, err := d.bs.Has(ctx, b.Cid())
+			if err != nil {
+				return nil, xerrors.Errorf("checking for existing block: %w", err)
+			}
+			if has {
+				continue
+			}
+		}
+		out = append(out, b)
+	}
+
+	atomic.AddUint64(&d.skipped, uint64(len(blks)-len(out)))
+	return out, nil
+}
@@ -0,0 +1,305 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	carutil "github.com/ipld/go-car/util"
+	carv2 "github.com/ipld/go-car/v2"
+	"go.opencensus.io/stats"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// ImportWorkers is the default number of goroutines that verify and write the
+// blocks of an import.
+var ImportWorkers = 5
+
+// ImportBufferBytes is the default cap on the block data an import reads
+// ahead of the blockstore writes.
+var ImportBufferBytes int64 = 512 << 20
+
+// ImportProgressInterval is how often an import logs its throughput.
+var ImportProgressInterval = 10 * time.Second
+
+// importBatchBlocks is the number of blocks written with a single PutMany.
+const importBatchBlocks = 1000
+
+// importSection is a block read from a CAR. Blocks of CARv1 streams are read
+// raw and verified by the import workers; the car reader already verified the
+// blocks of other CARs.
+type importSection struct {
+	c        cid.Cid
+	data     []byte
+	verified bool
+}
+
+type importBatch struct {
+	secs []importSection
+	// end is the number of blocks of the CAR up to and including this batch.
+	end  uint64
+	size int64
+	done chan error
+}
+
+// importReader reads the blocks of a CAR one section at a time.
+type importReader struct {
+	br   *carv2.BlockReader
+	bufr *bufio.Reader
+}
+
+func newImportReader(r io.Reader) (*importReader, error) {
+	bufr := bufio.NewReaderSize(r, 1<<20)
+	br, err := carv2.NewBlockReader(bufr)
+	if err != nil {
+		return nil, err
+	}
+
+	ir := &importReader{br: br}
+	if br.Version == 1 {
+		// the block reader reads nothing past the header of a CARv1, so the
+		// sections can be read raw from the buffered reader
+		ir.bufr = bufr
+	}
+	return ir, nil
+}
+
+func (ir *importReader) next() (importSection, error) {
+	if ir.bufr == nil {
+		blk, err := ir.br.Next()
+		if err != nil {
+			return importSection{}, err
+		}
+		return importSection{c: blk.Cid(), data: blk.RawData(), verified: true}, nil
+	}
+
+	c, data, err := carutil.ReadNode(ir.bufr)
+	if err != nil {
+		return importSection{}, err
+	}
+	return importSection{c: c, data: data}, nil
+}
+
+// importPipeline writes the blocks of a CAR to a blockstore. A single reader
+// assembles batches of blocks, a pool of workers verifies and writes them
+// concurrently, and a committer checkpoints the batches in CAR order once they
+// and all the batches before them are written. The block data held by the
+// pipeline is capped; reading stalls until written batches free up space.
+type importPipeline struct {
+	cs      *ChainStore
+	bs      bstore.Blockstore
+	roots   []cid.Cid
+	dedup   *importDedup
+	workers int
+	mem     *semaphore.Weighted
+	memCap  int64
+
+	blocks, bytes uint64
+	start         time.Time
+}
+
+func (cs *ChainStore) newImportPipeline(bs bstore.Blockstore, roots []cid.Cid, dedup *importDedup, opts ImportOpts) *importPipeline {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = ImportWorkers
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	memCap := opts.BufferBytes
+	if memCap <= 0 {
+		memCap = ImportBufferBytes
+	}
+	if memCap < 2 {
+		memCap = 2
+	}
+
+	return &importPipeline{
+		cs:      cs,
+		bs:      bs,
+		roots:   roots,
+		dedup:   dedup,
+		workers: workers,
+		mem:     semaphore.NewWeighted(memCap),
+		memCap:  memCap,
+	}
+}
+
+// run imports the remaining blocks of ir, read is the number of blocks
+// already read from it.
+func (ip *importPipeline) run(ctx context.Context, ir *importReader, read uint64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ip.start = build.Clock.Now()
+
+	work := make(chan *importBatch)
+	var wg sync.WaitGroup
+	for i := 0; i < ip.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range work {
+				b.done <- ip.write(ctx, b)
+			}
+		}()
+	}
+
+	ordered := make(chan *importBatch, ip.workers)
+	cpErr := make(chan error, 1)
+	go func() {
+		cpErr <- ip.commit(ctx, ordered, cancel)
+	}()
+
+	// Batches are flushed at a fraction of the memory cap, and a single
+	// section never takes more than half of it, so the reader can always make
+	// progress once the batches in flight are written.
+	batchBytes := ip.memCap / int64(2*ip.workers)
+	if batchBytes < 1 {
+		batchBytes = 1
+	}
+
+	flush := func(b *importBatch) {
+		ordered <- b // blocks while the committer is behind
+		work <- b    // blocks while all workers are busy
+	}
+
+	b := &importBatch{}
+	var readErr error
+	for ctx.Err() == nil {
+		sec, err := ir.next()
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
+		}
+
+		n := int64(len(sec.data))
+		if n > ip.memCap/2 {
+			n = ip.memCap / 2
+		}
+		if err := ip.mem.Acquire(ctx, n); err != nil {
+			break
+		}
+
+		read++
+		b.secs = append(b.secs, sec)
+		b.size += n
+		if len(b.secs) >= importBatchBlocks || b.size >= batchBytes {
+			b.end, b.done = read, make(chan error, 1)
+			flush(b)
+			b = &importBatch{}
+		}
+	}
+	if len(b.secs) > 0 && readErr == nil && ctx.Err() == nil {
+		b.end, b.done = read, make(chan error, 1)
+		flush(b)
+	}
+
+	// wait for all batches to be written and checkpointed
+	close(work)
+	close(ordered)
+	err := <-cpErr
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	elapsed := build.Clock.Since(ip.start)
+	log.Infow("import finished", "blocks", ip.blocks, "bytes", ip.bytes, "duration", elapsed.Seconds(),
+		"blocksPerSec", float64(ip.blocks)/elapsed.Seconds(), "bytesPerSec", float64(ip.bytes)/elapsed.Seconds())
+
+	return nil
+}
+
+// write verifies the blocks of b and writes them to the blockstore.
+func (ip *importPipeline) write(ctx context.Context, b *importBatch) error {
+	blks := make([]blocks.Block, 0, len(b.secs))
+	for _, sec := range b.secs {
+		if !sec.verified {
+			hashed, err := sec.c.Prefix().Sum(sec.data)
+			if err != nil {
+				return err
+			}
+			if !hashed.Equals(sec.c) {
+				return xerrors.Errorf("mismatch in content integrity, expected: %s, got: %s", sec.c, hashed)
+			}
+		}
+
+		blk, err := blocks.NewBlockWithCid(sec.data, sec.c)
+		if err != nil {
+			return err
+		}
+		blks = append(blks, blk)
+	}
+
+	blks, err := ip.dedup.missing(ctx, blks)
+	if err != nil {
+		return err
+	}
+	if len(blks) == 0 {
+		return nil
+	}
+	return ip.bs.PutMany(ctx, blks)
+}
+
+// commit checkpoints the batches in CAR order as they are written, releasing
+// their memory and recording throughput.
+func (ip *importPipeline) commit(ctx context.Context, ordered chan *importBatch, cancel func()) error {
+	var err error
+	lastLog := build.Clock.Now()
+	for b := range ordered {
+		berr := <-b.done
+		ip.mem.Release(b.size)
+		if berr != nil && err == nil {
+			err = berr
+			cancel()
+		}
+		if err != nil {
+			continue
+		}
+
+		err = ip.cs.writeImportCheckpoint(ctx, &importCheckpoint{
+			Roots:  ip.roots,
+			Blocks: b.end,
+			Last:   b.secs[len(b.secs)-1].c,
+		})
+		if err != nil {
+			cancel()
+			continue
+		}
+
+		var size uint64
+		for _, sec := range b.secs {
+			size += uint64(len(sec.data))
+		}
+		ip.blocks += uint64(len(b.secs))
+		ip.bytes += size
+		stats.Record(ctx, metrics.ChainImportBlocks.M(int64(len(b.secs))), metrics.ChainImportBytes.M(int64(size)))
+
+		if now := build.Clock.Now(); now.Sub(lastLog) >= ImportProgressInterval {
+			elapsed := now.Sub(ip.start).Seconds()
+			log.Infow("import progress", "blocks", ip.blocks, "bytes", ip.bytes,
+				"blocksPerSec", float64(ip.blocks)/elapsed, "bytesPerSec", float64(ip.bytes)/elapsed)
+			lastLog = now
+		}
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestLoadTipSets(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	var chain []*types.TipSet
+	var ts *types.TipSet
+	for i := 0; i < 10; i++ {
+		var blks []*types.BlockHeader
+		for j := 0; j <= i%3; j++ {
+			blks = append(blks, mock.MkBlock(ts, 1, uint64(j)))
+		}
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blks...))
+		ts = mock.TipSet(blks...)
+		chain = append(chain, ts)
+	}
+
+	// cached tipsets are mixed with the ones read from the blockstore
+	_, err := cs.LoadTipSet(ctx, chain[3].Key())
+	require.NoError(t, err)
+
+	keys := []types.TipSetKey{chain[7].Key(), chain[3].Key(), chain[0].Key(), chain[8].Key(), chain[7].Key()}
+	tss, err := cs.LoadTipSets(ctx, keys)
+	require.NoError(t, err)
+	require.Len(t, tss, len(keys))
+	for i, ts := range tss {
+		require.Equal(t, keys[i], ts.Key())
+	}
+	require.Equal(t, chain[8], tss[3])
+
+	tss, err = cs.LoadTipSets(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, tss)
+
+	_, err = cs.LoadTipSets(ctx, []types.TipSetKey{chain[1].Key(), mock.TipSet(mock.MkBlock(ts, 1, 9)).Key()})
+	require.Error(t, err)
+}
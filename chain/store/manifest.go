@@ -0,0 +1,167 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ManifestSuffix is appended to the path of a snapshot to get the path of its
+// manifest.
+const ManifestSuffix = ".manifest.json"
+
+// SnapshotManifest describes a CAR snapshot, and is written next to it so the
+// snapshot can be checked before and while it is imported.
+type SnapshotManifest struct {
+	Roots []cid.Cid
+	// HeadEpoch is the height of the root tipset.
+	HeadEpoch abi.ChainEpoch
+	// OldestStateEpoch is the height of the oldest tipset, besides genesis,
+	// whose parent state is included. The genesis state is always included;
+	// this is 0 if it is the only one.
+	OldestStateEpoch abi.ChainEpoch
+
+	NetworkName    string          `json:",omitempty"`
+	NetworkVersion network.Version `json:",omitempty"`
+
+	// Size and SHA256 describe the uncompressed CAR data.
+	Size   uint64
+	SHA256 string
+
+	// Shards lists the parts of a sharded snapshot, see ExportShards.
+	Shards []SnapshotShard `json:",omitempty"`
+}
+
+// ManifestWriter passes writes through to an underlying writer, recording the
+// size and digest of the data for a SnapshotManifest.
+type ManifestWriter struct {
+	w io.Writer
+	h hash.Hash
+	n uint64
+}
+
+func NewManifestWriter(w io.Writer) *ManifestWriter {
+	return &ManifestWriter{w: w, h: sha256.New()}
+}
+
+func (mw *ManifestWriter) Write(p []byte) (int, error) {
+	n, err := mw.w.Write(p)
+	mw.h.Write(p[:n]) //nolint:errcheck // hash writes don't fail
+	mw.n += uint64(n)
+	return n, err
+}
+
+// Manifest returns the manifest of a snapshot rooted at ts with
+// inclRecentRoots state roots, made of the data written so far.
+func (mw *ManifestWriter) Manifest(ts *types.TipSet, inclRecentRoots abi.ChainEpoch) *SnapshotManifest {
+	var oldest abi.ChainEpoch
+	if inclRecentRoots > 0 && ts.Height()-inclRecentRoots+1 > 0 {
+		oldest = ts.Height() - inclRecentRoots + 1
+	}
+
+	return &SnapshotManifest{
+		Roots:            ts.Cids(),
+		HeadEpoch:        ts.Height(),
+		OldestStateEpoch: oldest,
+		Size:             mw.n,
+		SHA256:           hex.EncodeToString(mw.h.Sum(nil)),
+	}
+}
+
+// WriteManifest writes m as JSON to path.
+func WriteManifest(path string, m *SnapshotManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("marshaling manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return xerrors.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest reads a manifest written by WriteManifest.
+func ReadManifest(path string) (*SnapshotManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading manifest: %w", err)
+	}
+
+	var m SnapshotManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, xerrors.Errorf("parsing manifest %s: %w", path, err)
+	}
+	if len(m.Roots) == 0 {
+		return nil, xerrors.Errorf("manifest %s has no roots", path)
+	}
+	if _, err := hex.DecodeString(m.SHA256); err != nil || len(m.SHA256) != 2*sha256.Size {
+		return nil, xerrors.Errorf("manifest %s has an invalid sha256 %q", path, m.SHA256)
+	}
+	for _, s := range m.Shards {
+		if s.Name == "" || filepath.Base(s.Name) != s.Name {
+			return nil, xerrors.Errorf("manifest %s has an invalid shard name %q", path, s.Name)
+		}
+		if _, err := hex.DecodeString(s.SHA256); err != nil || len(s.SHA256) != 2*sha256.Size {
+			return nil, xerrors.Errorf("manifest %s has an invalid sha256 %q for shard %s", path, s.SHA256, s.Name)
+		}
+	}
+
+	return &m, nil
+}
+
+// manifestReader records the size and digest of the data read through it, to
+// be checked against a manifest once all of it was read.
+type manifestReader struct {
+	r io.Reader
+	m *SnapshotManifest
+	h hash.Hash
+	n uint64
+}
+
+func newManifestReader(r io.Reader, m *SnapshotManifest) *manifestReader {
+	return &manifestReader{r: r, m: m, h: sha256.New()}
+}
+
+func (mr *manifestReader) Read(p []byte) (int, error) {
+	n, err := mr.r.Read(p)
+	mr.h.Write(p[:n]) //nolint:errcheck // hash writes don't fail
+	mr.n += uint64(n)
+	if mr.n > mr.m.Size {
+		// no need to read the rest of a snapshot that can't match
+		return n, xerrors.Errorf("snapshot is larger than the %d bytes in its manifest", mr.m.Size)
+	}
+	return n, err
+}
+
+func (mr *manifestReader) check() error {
+	if mr.n != mr.m.Size {
+		return xerrors.Errorf("snapshot has %d bytes, manifest expects %d", mr.n, mr.m.Size)
+	}
+	want, _ := hex.DecodeString(mr.m.SHA256)
+	if sum := mr.h.Sum(nil); !bytes.Equal(sum, want) {
+		return xerrors.Errorf("sha256 of snapshot is %x, manifest expects %s", sum, mr.m.SHA256)
+	}
+	return nil
+}
+
+// checkRoot checks that the root tipset of an imported snapshot matches the
+// manifest.
+func (m *SnapshotManifest) checkRoot(root *types.TipSet) error {
+	if root.Height() != m.HeadEpoch {
+		return xerrors.Errorf("root tipset is at height %d, manifest expects %d", root.Height(), m.HeadEpoch)
+	}
+	return nil
+}
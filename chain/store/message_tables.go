@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var (
+	messageTableHeader = []string{"epoch", "block", "cid", "from", "to", "nonce", "value", "method", "gas_limit", "gas_fee_cap", "gas_premium"}
+	receiptTableHeader = []string{"epoch", "message", "index", "exit_code", "gas_used", "executed_epoch"}
+)
+
+// MessageTables writes the messages and the receipts of a chain export as CSV
+// tables keyed by epoch, for analytics pipelines. They are filled from the walk
+// of the export as it visits the messages and receipts of every block, so they
+// hold the same messages and receipts as the CAR.
+//
+// The messages table has a row per message of every block, with the epoch and
+// the block including it. The receipts table has a row per receipt, with the
+// epoch of the tipset whose messages it is for, the message, its index among
+// the deduplicated messages of that tipset, and the epoch it was executed at.
+type MessageTables struct {
+	cs *ChainStore
+
+	msgs, rcpts *csv.Writer
+	wroteHeader bool
+
+	// cur is the last block header visited, the messages and receipts visited
+	// next are its own
+	cur *types.BlockHeader
+}
+
+// NewMessageTables returns tables writing the messages to msgs and the receipts
+// to rcpts. Either may be nil to skip its table.
+func (cs *ChainStore) NewMessageTables(msgs, rcpts io.Writer) *MessageTables {
+	t := &MessageTables{cs: cs}
+	if msgs != nil {
+		t.msgs = csv.NewWriter(msgs)
+	}
+	if rcpts != nil {
+		t.rcpts = csv.NewWriter(rcpts)
+	}
+	return t
+}
+
+func (t *MessageTables) visit(ctx context.Context, c cid.Cid, bt SnapshotBlockType) error {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		if err := t.write(t.msgs, messageTableHeader); err != nil {
+			return err
+		}
+		if err := t.write(t.rcpts, receiptTableHeader); err != nil {
+			return err
+		}
+	}
+
+	switch bt {
+	case SnapshotHeader:
+		b, err := t.cs.GetBlock(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("loading block %s: %w", c, err)
+		}
+		t.cur = b
+	case SnapshotMessages:
+		if t.msgs != nil && t.cur != nil && c == t.cur.Messages {
+			return t.writeMessages(ctx, t.cur)
+		}
+	case SnapshotReceipts:
+		if t.rcpts != nil && t.cur != nil && c == t.cur.ParentMessageReceipts && t.cur.Height > 0 {
+			return t.writeReceipts(ctx, t.cur)
+		}
+	}
+	return nil
+}
+
+func (t *MessageTables) writeMessages(ctx context.Context, b *types.BlockHeader) error {
+	bmsgs, smsgs, err := t.cs.MessagesForBlock(ctx, b)
+	if err != nil {
+		return xerrors.Errorf("loading messages of block %s: %w", b.Cid(), err)
+	}
+
+	epoch, blk := strconv.FormatInt(int64(b.Height), 10), b.Cid().String()
+	row := func(c cid.Cid, m *types.Message) error {
+		return t.write(t.msgs, []string{
+			epoch,
+			blk,
+			c.String(),
+			m.From.String(),
+			m.To.String(),
+			strconv.FormatUint(m.Nonce, 10),
+			m.Value.String(),
+			strconv.FormatUint(uint64(m.Method), 10),
+			strconv.FormatInt(m.GasLimit, 10),
+			m.GasFeeCap.String(),
+			m.GasPremium.String(),
+		})
+	}
+	for _, m := range bmsgs {
+		if err := row(m.Cid(), m); err != nil {
+			return err
+		}
+	}
+	for _, sm := range smsgs {
+		if err := row(sm.Cid(), &sm.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeReceipts writes the receipts of b, those of the messages of its parent
+// tipset.
+func (t *MessageTables) writeReceipts(ctx context.Context, b *types.BlockHeader) error {
+	pts, err := t.cs.LoadTipSet(ctx, types.NewTipSetKey(b.Parents...))
+	if err != nil {
+		return xerrors.Errorf("loading parents of block %s: %w", b.Cid(), err)
+	}
+	msgs, err := t.cs.MessagesForTipset(ctx, pts)
+	if err != nil {
+		return xerrors.Errorf("loading messages of tipset at height %d: %w", pts.Height(), err)
+	}
+
+	// block headers use adt0, for now.
+	a, err := blockadt.AsArray(t.cs.ActorStore(ctx), b.ParentMessageReceipts)
+	if err != nil {
+		return xerrors.Errorf("amt load: %w", err)
+	}
+
+	epoch, executed := strconv.FormatInt(int64(pts.Height()), 10), strconv.FormatInt(int64(b.Height), 10)
+	var r types.MessageReceipt
+	return a.ForEach(&r, func(i int64) error {
+		if i >= int64(len(msgs)) {
+			return xerrors.Errorf("receipt %d of block %s has no message", i, b.Cid())
+		}
+		return t.write(t.rcpts, []string{
+			epoch,
+			msgs[i].Cid().String(),
+			strconv.FormatInt(i, 10),
+			strconv.FormatInt(int64(r.ExitCode), 10),
+			strconv.FormatInt(r.GasUsed, 10),
+			executed,
+		})
+	})
+}
+
+func (t *MessageTables) write(w *csv.Writer, row []string) error {
+	if w == nil {
+		return nil
+	}
+	if err := w.Write(row); err != nil {
+		return xerrors.Errorf("writing table row: %w", err)
+	}
+	return nil
+}
+
+// Flush writes the buffered rows of the tables out.
+func (t *MessageTables) Flush() error {
+	for _, w := range []*csv.Writer{t.msgs, t.rcpts} {
+		if w == nil {
+			continue
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return xerrors.Errorf("flushing table: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExportWithMessageTables writes a CARv1 snapshot of the chain from ts to w like
+// Export, filling tables with the messages and receipts of the snapshot from
+// the same walk of the chain. The tables are flushed once the export is done.
+func (cs *ChainStore) ExportWithMessageTables(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, w io.Writer, tables *MessageTables) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	inclRecentRoots, err := CheckRecentRoots(ts, inclRecentRoots, ExportRecentRootsPolicy)
+	if err != nil {
+		return err
+	}
+
+	et := cs.trackExport(ts)
+	err = cs.export(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, w, et, nil, tables)
+	if err == nil {
+		err = tables.Flush()
+	}
+	et.finish(err)
+	return err
+}
@@ -0,0 +1,115 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExportWithMessageTables(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	amt := func(vals ...cbg.CBORMarshaler) cid.Cid {
+		arr := blockadt.MakeEmptyArray(cs.ActorStore(ctx))
+		for i, v := range vals {
+			require.NoError(t, arr.Set(uint64(i), v))
+		}
+		root, err := arr.Root()
+		require.NoError(t, err)
+		return root
+	}
+	meta := func(msgs ...*types.Message) cid.Cid {
+		var vals []cbg.CBORMarshaler
+		for _, m := range msgs {
+			c, err := cs.PutMessage(ctx, m)
+			require.NoError(t, err)
+			cc := cbg.CborCid(c)
+			vals = append(vals, &cc)
+		}
+		c, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: amt(vals...), SecpkMessages: amt()})
+		require.NoError(t, err)
+		return c
+	}
+
+	from, to := mock.Address(100), mock.Address(101)
+	m0, m1 := mock.UnsignedMessage(from, to, 0), mock.UnsignedMessage(from, to, 1)
+	tree, err := state.NewStateTree(cbor.NewCborStore(bs), types.StateTreeVersion4)
+	require.NoError(t, err)
+	st, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	var ts *types.TipSet
+	for h, set := range []func(*types.BlockHeader){
+		nil,
+		func(b *types.BlockHeader) { b.Messages = meta(m0, m1) },
+		func(b *types.BlockHeader) {
+			b.ParentMessageReceipts = amt(
+				&types.MessageReceipt{ExitCode: 0, GasUsed: 10},
+				&types.MessageReceipt{ExitCode: 16, GasUsed: 20},
+			)
+		},
+	} {
+		blk := mock.MkBlock(ts, 1, 1)
+		blk.ParentStateRoot, blk.Messages, blk.ParentMessageReceipts = st, meta(), amt()
+		if set != nil {
+			set(blk)
+		}
+		require.Equal(t, h, int(blk.Height))
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+	}
+	b1 := ts.Blocks()[0].Parents[0]
+
+	var car, msgs, rcpts bytes.Buffer
+	tables := cs.NewMessageTables(&msgs, &rcpts)
+	require.NoError(t, cs.ExportWithMessageTables(ctx, ts, 0, false, false, &car, tables))
+
+	// the CAR is the same as without the tables
+	var plain bytes.Buffer
+	require.NoError(t, cs.Export(ctx, ts, 0, false, false, &plain))
+	require.Equal(t, plain.Bytes(), car.Bytes())
+
+	read := func(buf *bytes.Buffer) [][]string {
+		rows, err := csv.NewReader(buf).ReadAll()
+		require.NoError(t, err)
+		return rows
+	}
+	require.Equal(t, [][]string{
+		{"epoch", "block", "cid", "from", "to", "nonce", "value", "method", "gas_limit", "gas_fee_cap", "gas_premium"},
+		{"1", b1.String(), m0.Cid().String(), from.String(), to.String(), "0", m0.Value.String(), "0", "1000000", m0.GasFeeCap.String(), m0.GasPremium.String()},
+		{"1", b1.String(), m1.Cid().String(), from.String(), to.String(), "1", m1.Value.String(), "0", "1000000", m1.GasFeeCap.String(), m1.GasPremium.String()},
+	}, read(&msgs))
+	require.Equal(t, [][]string{
+		{"epoch", "message", "index", "exit_code", "gas_used", "executed_epoch"},
+		{"1", m0.Cid().String(), "0", "0", "10", "2"},
+		{"1", m1.Cid().String(), "1", "16", "20", "2"},
+	}, read(&rcpts))
+
+	// receipts left out of the export are left out of the tables
+	msgs.Reset()
+	rcpts.Reset()
+	car.Reset()
+	require.NoError(t, cs.ExportWithMessageTables(ctx, ts, 0, false, true, &car, cs.NewMessageTables(nil, &rcpts)))
+	require.Len(t, read(&rcpts), 1)
+}
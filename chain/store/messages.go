@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	block "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
@@ -182,6 +183,8 @@ func (cs *ChainStore) BlockMsgsForTipset(ctx context.Context, ts *types.TipSet)
 }
 
 func (cs *ChainStore) MessagesForTipset(ctx context.Context, ts *types.TipSet) ([]types.ChainMsg, error) {
+	defer recordOp(ctx, opMessagesForTipset, time.Now())
+
 	bmsgs, err := cs.BlockMsgsForTipset(ctx, ts)
 	if err != nil {
 		return nil, err
@@ -208,6 +211,7 @@ type mmCids struct {
 
 func (cs *ChainStore) ReadMsgMetaCids(ctx context.Context, mmc cid.Cid) ([]cid.Cid, []cid.Cid, error) {
 	o, ok := cs.mmCache.Get(mmc)
+	recordCacheLookup(ctx, cacheMsgMeta, ok)
 	if ok {
 		mmcids := o.(*mmCids)
 		return mmcids.bls, mmcids.secpk, nil
@@ -238,6 +242,8 @@ func (cs *ChainStore) ReadMsgMetaCids(ctx context.Context, mmc cid.Cid) ([]cid.C
 }
 
 func (cs *ChainStore) MessagesForBlock(ctx context.Context, b *types.BlockHeader) ([]*types.Message, []*types.SignedMessage, error) {
+	defer recordOp(ctx, opMessagesForBlock, time.Now())
+
 	blscids, secpkcids, err := cs.ReadMsgMetaCids(ctx, b.Messages)
 	if err != nil {
 		return nil, nil, err
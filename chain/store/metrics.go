@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// Operations timed in metrics.ChainStoreOpDuration.
+const (
+	opGetBlock          = "get_block"
+	opLoadTipSet        = "load_tipset"
+	opLoadTipSets       = "load_tipsets"
+	opMessagesForBlock  = "messages_for_block"
+	opMessagesForTipset = "messages_for_tipset"
+	opTakeHead          = "take_head"
+	opNotifyHeadChange  = "notify_head_change"
+)
+
+// Caches whose lookups are counted in metrics.ChainStoreCacheLookup.
+const (
+	cacheTipSet  = "tipset"
+	cacheMsgMeta = "msgmeta"
+)
+
+// recordOp records the duration of the chainstore operation op started at
+// start.
+func recordOp(ctx context.Context, op string, start time.Time) {
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.ChainStoreOp, op)},
+		metrics.ChainStoreOpDuration.M(metrics.SinceInMilliseconds(start)))
+}
+
+func recordCacheLookup(ctx context.Context, cache string, hit bool) {
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.CacheName, cache), tag.Upsert(metrics.CacheHit, strconv.FormatBool(hit))},
+		metrics.ChainStoreCacheLookup.M(1))
+}
@@ -0,0 +1,396 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	ipld "github.com/ipfs/go-ipld-format"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// MsgIndex enables the persistent index of the messages of the heaviest chain,
+// which lets message searches skip the chain walk for the history indexed
+// since it was enabled.
+var MsgIndex = false
+
+var (
+	msgIndexPrefix   = dstore.NewKey("/chain/msgs")
+	addrIndexPrefix  = dstore.NewKey("/chain/addrmsgs")
+	msgIndexSinceKey = dstore.NewKey("/chain/msgs-since")
+)
+
+// msgIndex maps the CIDs of the messages included in the heaviest chain to the
+// tipset executing them, and their index in the messages of its parent, which
+// is also the index of their receipt. It is kept in the metadata datastore and
+// updated as the head changes.
+//
+// The messages are also indexed by their sender and recipient, in the order
+// they are included in the chain, along with the height of the first tipset
+// whose messages were indexed.
+//
+// Entries of reverted tipsets may linger when updates fail, lookups check the
+// tipset against the chain they search.
+type msgIndex struct {
+	ds dstore.Batching
+	cs *ChainStore
+
+	sinceLk sync.Mutex
+	// since is the lowest height of the tipsets whose messages are indexed,
+	// -1 until some are
+	since       abi.ChainEpoch
+	sinceLoaded bool
+}
+
+// IndexedMsg is a message of the address index.
+type IndexedMsg struct {
+	Cid cid.Cid
+	// Height is the height of the tipset including the message
+	Height abi.ChainEpoch
+	// Index is the index of the message in the messages of the tipset
+	// including it, and of its receipt in the tipset executing it
+	Index int
+	// Exec is the tipset executing the message
+	Exec types.TipSetKey
+}
+
+func msgIndexKey(c cid.Cid) dstore.Key {
+	return msgIndexPrefix.ChildString(c.String())
+}
+
+func addrIndexKey(addr address.Address, h abi.ChainEpoch, idx int) dstore.Key {
+	return addrIndexPrefix.ChildString(addr.String()).ChildString(fmt.Sprintf("%020d/%06d", h, idx))
+}
+
+func parseAddrIndexKey(key string) (abi.ChainEpoch, int, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) < 2 {
+		return 0, 0, xerrors.Errorf("invalid address index key %s", key)
+	}
+	h, err := strconv.ParseInt(parts[len(parts)-2], 10, 64)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("invalid address index key %s: %w", key, err)
+	}
+	idx, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, 0, xerrors.Errorf("invalid address index key %s: %w", key, err)
+	}
+	return abi.ChainEpoch(h), idx, nil
+}
+
+func encodeAddrIndexEntry(c cid.Cid, exec types.TipSetKey) []byte {
+	cb := c.Bytes()
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(cb)))
+	return append(append(buf[:n], cb...), exec.Bytes()...)
+}
+
+func decodeAddrIndexEntry(data []byte) (cid.Cid, types.TipSetKey, error) {
+	l, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < l {
+		return cid.Undef, types.EmptyTSK, xerrors.Errorf("invalid address index entry")
+	}
+	c, err := cid.Cast(data[n : n+int(l)])
+	if err != nil {
+		return cid.Undef, types.EmptyTSK, xerrors.Errorf("invalid address index entry: %w", err)
+	}
+	exec, err := types.TipSetKeyFromBytes(data[n+int(l):])
+	if err != nil {
+		return cid.Undef, types.EmptyTSK, xerrors.Errorf("invalid address index entry: %w", err)
+	}
+	return c, exec, nil
+}
+
+// msgAddrs returns the addresses m is indexed by.
+func msgAddrs(m types.ChainMsg) []address.Address {
+	vm := m.VMMessage()
+	if vm.From == vm.To {
+		return []address.Address{vm.From}
+	}
+	return []address.Address{vm.From, vm.To}
+}
+
+func encodeMsgIndexEntry(exec types.TipSetKey, idx int) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(idx))
+	return append(buf[:n], exec.Bytes()...)
+}
+
+func decodeMsgIndexEntry(data []byte) (types.TipSetKey, int, error) {
+	idx, n := binary.Uvarint(data)
+	if n <= 0 {
+		return types.EmptyTSK, 0, xerrors.Errorf("invalid message index entry")
+	}
+	exec, err := types.TipSetKeyFromBytes(data[n:])
+	if err != nil {
+		return types.EmptyTSK, 0, xerrors.Errorf("invalid message index entry: %w", err)
+	}
+	return exec, int(idx), nil
+}
+
+// headChange is the ReorgNotifee of the index.
+func (mi *msgIndex) headChange(rev, app []*types.TipSet) error {
+	ctx := context.TODO()
+	if err := mi.update(ctx, rev, app); err != nil {
+		log.Errorw("updating message index", "error", err)
+	}
+	return nil
+}
+
+func (mi *msgIndex) update(ctx context.Context, rev, app []*types.TipSet) error {
+	b, err := mi.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ts := range rev {
+		err := mi.forExecuted(ctx, ts, func(pts *types.TipSet, m types.ChainMsg, i int) error {
+			for _, addr := range msgAddrs(m) {
+				key := addrIndexKey(addr, pts.Height(), i)
+				data, err := mi.ds.Get(ctx, key)
+				if err == dstore.ErrNotFound {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if _, exec, err := decodeAddrIndexEntry(data); err == nil && exec != ts.Key() {
+					continue
+				}
+				if err := b.Delete(ctx, key); err != nil {
+					return err
+				}
+			}
+
+			c := m.Cid()
+			data, err := mi.ds.Get(ctx, msgIndexKey(c))
+			if err == dstore.ErrNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			// the message was included again, in a tipset applied before
+			// this one was reverted
+			if exec, _, err := decodeMsgIndexEntry(data); err == nil && exec != ts.Key() {
+				return nil
+			}
+			return b.Delete(ctx, msgIndexKey(c))
+		})
+		if err != nil {
+			return xerrors.Errorf("unindexing messages executed by %s: %w", ts.Key(), err)
+		}
+	}
+
+	since, err := mi.indexedSince(ctx)
+	if err != nil {
+		return err
+	}
+	lowest := since
+	for _, ts := range app {
+		// the tipsets between the parent of ts and ts being null rounds, the
+		// messages included from its height are indexed
+		if h := ts.Height() - 1; h >= 0 && (lowest < 0 || h < lowest) {
+			lowest = h
+		}
+
+		err := mi.forExecuted(ctx, ts, func(pts *types.TipSet, m types.ChainMsg, i int) error {
+			c := m.Cid()
+			for _, addr := range msgAddrs(m) {
+				if err := b.Put(ctx, addrIndexKey(addr, pts.Height(), i), encodeAddrIndexEntry(c, ts.Key())); err != nil {
+					return err
+				}
+			}
+			return b.Put(ctx, msgIndexKey(c), encodeMsgIndexEntry(ts.Key(), i))
+		})
+		if err != nil {
+			return xerrors.Errorf("indexing messages executed by %s: %w", ts.Key(), err)
+		}
+	}
+
+	if since < 0 && lowest >= 0 {
+		if err := b.Put(ctx, msgIndexSinceKey, []byte(strconv.FormatInt(int64(lowest), 10))); err != nil {
+			return err
+		}
+	}
+	if err := b.Commit(ctx); err != nil {
+		return err
+	}
+
+	if since < 0 {
+		mi.sinceLk.Lock()
+		mi.since = lowest
+		mi.sinceLk.Unlock()
+	}
+	return nil
+}
+
+// indexedSince returns the lowest height of the tipsets whose messages are
+// indexed, or -1.
+func (mi *msgIndex) indexedSince(ctx context.Context) (abi.ChainEpoch, error) {
+	mi.sinceLk.Lock()
+	defer mi.sinceLk.Unlock()
+
+	if mi.sinceLoaded {
+		return mi.since, nil
+	}
+
+	since := abi.ChainEpoch(-1)
+	data, err := mi.ds.Get(ctx, msgIndexSinceKey)
+	switch err {
+	case nil:
+		h, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return -1, xerrors.Errorf("parsing message index start: %w", err)
+		}
+		since = abi.ChainEpoch(h)
+	case dstore.ErrNotFound:
+	default:
+		return -1, xerrors.Errorf("loading message index start: %w", err)
+	}
+
+	mi.since, mi.sinceLoaded = since, true
+	return since, nil
+}
+
+// forExecuted calls cb with the messages executed by ts, the parent of ts
+// including them, and their index.
+func (mi *msgIndex) forExecuted(ctx context.Context, ts *types.TipSet, cb func(*types.TipSet, types.ChainMsg, int) error) error {
+	if ts.Height() == 0 {
+		return nil
+	}
+	pts, err := mi.cs.LoadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return err
+	}
+	msgs, err := mi.cs.MessagesForTipset(ctx, pts)
+	if err != nil {
+		return err
+	}
+	for i, m := range msgs {
+		if err := cb(pts, m, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LookupMsgExecution returns the tipset that executed the message, and the
+// index of its receipt in that tipset, if the message index has it. The
+// tipset may not be in the chain of the caller, which must check it is.
+func (cs *ChainStore) LookupMsgExecution(ctx context.Context, mcid cid.Cid) (*types.TipSet, int, bool, error) {
+	if cs.mindex == nil {
+		return nil, 0, false, nil
+	}
+
+	data, err := cs.metadataDs.Get(ctx, msgIndexKey(mcid))
+	if err == dstore.ErrNotFound {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	exec, idx, err := decodeMsgIndexEntry(data)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	ts, err := cs.LoadTipSet(ctx, exec)
+	if ipld.IsNotFound(err) {
+		// pruned
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, xerrors.Errorf("loading indexed tipset: %w", err)
+	}
+	return ts, idx, true, nil
+}
+
+// MsgIndexSince returns the lowest height of the tipsets whose messages are in
+// the message index, or false if the index is disabled or empty. Messages
+// included at or above that height are in the address index.
+func (cs *ChainStore) MsgIndexSince(ctx context.Context) (abi.ChainEpoch, bool, error) {
+	if cs.mindex == nil {
+		return 0, false, nil
+	}
+	since, err := cs.mindex.indexedSince(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	return since, since >= 0, nil
+}
+
+// ForEachIndexedMsg calls cb with the indexed messages sent or received by
+// addr, included at heights from hi down to lo, in the order of decreasing
+// heights and, for a height, of the messages of the tipset including them. The
+// tipsets executing the messages may not be in the chain of the caller, which
+// must check they are. It stops when cb returns false.
+func (cs *ChainStore) ForEachIndexedMsg(ctx context.Context, addr address.Address, hi, lo abi.ChainEpoch, cb func(IndexedMsg) (bool, error)) error {
+	if cs.mindex == nil {
+		return xerrors.Errorf("message index disabled")
+	}
+
+	res, err := cs.metadataDs.Query(ctx, query.Query{
+		Prefix: addrIndexPrefix.ChildString(addr.String()).String(),
+		Orders: []query.Order{query.OrderByKeyDescending{}},
+	})
+	if err != nil {
+		return xerrors.Errorf("querying address index: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	// the entries of a height are collected, to call cb with them in
+	// increasing order
+	var group []IndexedMsg
+	flush := func() (bool, error) {
+		for i := len(group) - 1; i >= 0; i-- {
+			if ok, err := cb(group[i]); err != nil || !ok {
+				return false, err
+			}
+		}
+		group = group[:0]
+		return true, nil
+	}
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("querying address index: %w", r.Error)
+		}
+
+		h, idx, err := parseAddrIndexKey(r.Key)
+		if err != nil {
+			return err
+		}
+		if h > hi {
+			continue
+		}
+		if h < lo {
+			break
+		}
+		if len(group) > 0 && group[0].Height != h {
+			if ok, err := flush(); err != nil || !ok {
+				return err
+			}
+		}
+
+		c, exec, err := decodeAddrIndexEntry(r.Value)
+		if err != nil {
+			return err
+		}
+		group = append(group, IndexedMsg{Cid: c, Height: h, Index: idx, Exec: exec})
+	}
+
+	_, err = flush()
+	return err
+}
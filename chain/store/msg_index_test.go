@@ -0,0 +1,158 @@
+// stm: #unit
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestMsgIndex(t *testing.T) {
+	ctx := context.Background()
+
+	defer func(enabled bool) { MsgIndex = enabled }(MsgIndex)
+	MsgIndex = true
+
+	bs := blockstore.NewMemorySync()
+	cs := NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	amt := func(msgs ...*types.Message) cid.Cid {
+		arr := blockadt.MakeEmptyArray(cs.ActorStore(ctx))
+		for i, m := range msgs {
+			c, err := cs.PutMessage(ctx, m)
+			require.NoError(t, err)
+			cc := cbg.CborCid(c)
+			require.NoError(t, arr.Set(uint64(i), &cc))
+		}
+		root, err := arr.Root()
+		require.NoError(t, err)
+		return root
+	}
+
+	from, to := mock.Address(100), mock.Address(101)
+	m0, m1 := mock.UnsignedMessage(from, to, 0), mock.UnsignedMessage(from, to, 1)
+	// the messages of a tipset are selected against its parent state
+	st, err := state.NewStateTree(cbor.NewCborStore(bs), types.StateTreeVersion4)
+	require.NoError(t, err)
+	root, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	empty, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: amt(), SecpkMessages: amt()})
+	require.NoError(t, err)
+	withMsgs, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: amt(m0, m1), SecpkMessages: amt()})
+	require.NoError(t, err)
+
+	// extend grows ts by n tipsets, the one at height with holds the messages
+	extend := func(ts *types.TipSet, n int, nonce uint64, with int64) *types.TipSet {
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, 1, nonce)
+			blk.ParentStateRoot, blk.Messages = root, empty
+			if int64(blk.Height) == with {
+				blk.Messages = withMsgs
+			}
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+		}
+		return ts
+	}
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot, gen.Messages = root, empty
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+	require.NoError(t, cs.SetHead(ctx, mock.TipSet(gen)))
+
+	a := extend(mock.TipSet(gen), 5, 1, 2)
+	require.NoError(t, cs.SetHead(ctx, a))
+
+	lookup := func(m *types.Message) (*types.TipSet, int, bool) {
+		ts, idx, ok, err := cs.LookupMsgExecution(ctx, m.Cid())
+		require.NoError(t, err)
+		return ts, idx, ok
+	}
+
+	indexed := func(addr address.Address, hi, lo abi.ChainEpoch) []IndexedMsg {
+		var out []IndexedMsg
+		require.NoError(t, cs.ForEachIndexedMsg(ctx, addr, hi, lo, func(im IndexedMsg) (bool, error) {
+			out = append(out, im)
+			return true, nil
+		}))
+		return out
+	}
+
+	// the messages included at height 2 are executed at height 3
+	require.Eventually(t, func() bool {
+		_, _, ok := lookup(m1)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	since, ok, err := cs.MsgIndexSince(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, abi.ChainEpoch(0), since)
+
+	// the messages are indexed by sender and recipient, in inclusion order
+	for _, addr := range []address.Address{from, to} {
+		ims := indexed(addr, 10, 0)
+		require.Len(t, ims, 2)
+		for i, m := range []*types.Message{m0, m1} {
+			require.Equal(t, m.Cid(), ims[i].Cid)
+			require.Equal(t, abi.ChainEpoch(2), ims[i].Height)
+			require.Equal(t, i, ims[i].Index)
+		}
+	}
+	require.Empty(t, indexed(from, 1, 0))
+	require.Empty(t, indexed(mock.Address(102), 10, 0))
+	for i, m := range []*types.Message{m0, m1} {
+		ts, idx, ok := lookup(m)
+		require.True(t, ok)
+		require.Equal(t, i, idx)
+		exec, err := cs.GetTipsetByHeight(ctx, 3, a, false)
+		require.NoError(t, err)
+		require.Equal(t, exec.Key(), ts.Key())
+	}
+
+	// they are unindexed when their execution is reverted, and indexed again
+	// when they are executed by another chain
+	fork, err := cs.GetTipsetByHeight(ctx, 1, a, false)
+	require.NoError(t, err)
+	b := extend(fork, 8, 2, -1)
+	require.NoError(t, cs.SetHead(ctx, b))
+	require.Eventually(t, func() bool {
+		_, _, ok := lookup(m0)
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, indexed(from, 10, 0))
+
+	c := extend(fork, 10, 3, 4)
+	require.NoError(t, cs.SetHead(ctx, c))
+	require.Eventually(t, func() bool {
+		ts, _, ok := lookup(m0)
+		return ok && ts.Height() == 5
+	}, 5*time.Second, 10*time.Millisecond)
+	ts, _, _ := lookup(m1)
+	exec, err := cs.GetTipsetByHeight(ctx, 5, c, false)
+	require.NoError(t, err)
+	require.Equal(t, exec.Key(), ts.Key())
+
+	ims := indexed(to, 10, 0)
+	require.Len(t, ims, 2)
+	require.Equal(t, abi.ChainEpoch(4), ims[0].Height)
+	require.Equal(t, exec.Key(), ims[0].Exec)
+}
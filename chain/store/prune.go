@@ -0,0 +1,287 @@
+package store
+
+import (
+	"bytes"
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// PruneBatch is the number of blocks deleted at a time when pruning the chain.
+var PruneBatch = 8192
+
+// PruneOpts configures PruneChain.
+type PruneOpts struct {
+	// Horizon is the height below which the heaviest chain is pruned. It may
+	// not be within finality of the head.
+	Horizon abi.ChainEpoch
+	// Protect are tipsets kept along with their messages and receipts, e.g.
+	// the roots of snapshots. The genesis and the checkpoint are always kept.
+	Protect []types.TipSetKey
+	// DryRun only reports what would be pruned.
+	DryRun bool
+	// Offload moves the pruned blocks to the cold store set with SetColdStore
+	// instead of deleting them.
+	Offload bool
+}
+
+// PruneChain deletes the block headers, messages and receipts of the heaviest
+// chain below opts.Horizon, leaving state alone. Blocks still referenced by
+// the rest of the chain or the protected tipsets are kept, which takes walking
+// the messages and receipts of the chain above the horizon.
+//
+// Pruning again below the same horizon is a noop: the walk of the pruned
+// history stops at the first header missing from the hot blockstores.
+func (cs *ChainStore) PruneChain(ctx context.Context, opts PruneOpts) (api.PruneHistoryResult, error) {
+	res := api.PruneHistoryResult{Horizon: opts.Horizon, DryRun: opts.DryRun, Offload: opts.Offload}
+
+	head := cs.GetHeaviestTipSet()
+	if head == nil {
+		return res, xerrors.Errorf("no head to prune below")
+	}
+	if opts.Offload && cs.coldBlockstore == nil {
+		return res, xerrors.Errorf("no cold store to offload the chain history to")
+	}
+	if opts.Horizon <= 0 {
+		return res, nil
+	}
+	if opts.Horizon > head.Height()-build.Finality {
+		return res, xerrors.Errorf("pruning horizon %d is within finality of the head at %d", opts.Horizon, head.Height())
+	}
+
+	marked, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return res, err
+	}
+	defer marked.Close() //nolint:errcheck
+
+	msgWalker := newLinkWalker(ctx, cs.chainBlockstore, marked, ExportWorkers)
+	rctWalker := newLinkWalker(ctx, cs.stateBlockstore, marked, ExportWorkers)
+
+	// walkMsgs calls cb with the blocks of the messages and receipts of b that
+	// aren't marked yet, and marks them
+	walkMsgs := func(b *types.BlockHeader, cb func(c cid.Cid, receipt bool) error) error {
+		for _, root := range []struct {
+			c       cid.Cid
+			lw      *linkWalker
+			bs      bstore.Blockstore
+			receipt bool
+		}{
+			{b.Messages, msgWalker, cs.chainBlockstore, false},
+			{b.ParentMessageReceipts, rctWalker, cs.stateBlockstore, true},
+		} {
+			// the messages of old tipsets are often not in snapshots
+			has, err := root.bs.Has(ctx, root.c)
+			if err != nil {
+				return err
+			}
+			if !has {
+				continue
+			}
+			visit, err := marked.Visit(root.c)
+			if err != nil {
+				return err
+			}
+			if !visit {
+				continue
+			}
+			cids, err := root.lw.recurse(root.c, []cid.Cid{root.c})
+			if err != nil {
+				return xerrors.Errorf("walking messages of block %d: %w", b.Height, err)
+			}
+			for _, c := range cids {
+				if err := cb(c, root.receipt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	mark := func(cid.Cid, bool) error { return nil }
+
+	// mark the kept tipsets, then the chain down to the horizon
+	protect := append([]types.TipSetKey{}, opts.Protect...)
+	gen, err := cs.GetGenesis(ctx)
+	if err != nil {
+		return res, xerrors.Errorf("loading genesis: %w", err)
+	}
+	protect = append(protect, types.NewTipSetKey(gen.Cid()))
+	if cp := cs.GetCheckpoint(); cp != nil {
+		protect = append(protect, cp.Key())
+	}
+	for _, tsk := range protect {
+		for _, c := range tsk.Cids() {
+			if visit, err := marked.Visit(c); err != nil || !visit {
+				if err != nil {
+					return res, err
+				}
+				continue
+			}
+			b, err := cs.GetBlock(ctx, c)
+			if ipld.IsNotFound(err) {
+				log.Warnw("protected block is missing", "block", c)
+				continue
+			}
+			if err != nil {
+				return res, xerrors.Errorf("loading protected block: %w", err)
+			}
+			if err := walkMsgs(b, mark); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	var frontier []cid.Cid
+	seen := cid.NewSet()
+	queue := head.Cids()
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if !seen.Visit(c) {
+			continue
+		}
+		b, err := cs.GetBlock(ctx, c)
+		if ipld.IsNotFound(err) {
+			// the top of the history pruned before
+			continue
+		}
+		if err != nil {
+			return res, xerrors.Errorf("loading block: %w", err)
+		}
+		if b.Height < opts.Horizon {
+			frontier = append(frontier, c)
+			continue
+		}
+		if _, err := marked.Visit(c); err != nil {
+			return res, err
+		}
+		if err := walkMsgs(b, mark); err != nil {
+			return res, err
+		}
+		if b.Height > 0 {
+			queue = append(queue, b.Parents...)
+		}
+	}
+
+	// sweep the chain below the horizon, out of the hot blockstores
+	hotChain, hotState := cs.hotBlockstores()
+	var chainDel, stateDel []cid.Cid
+	flush := func(force bool) error {
+		for _, d := range []struct {
+			bs   bstore.Blockstore
+			cids *[]cid.Cid
+		}{{hotChain, &chainDel}, {hotState, &stateDel}} {
+			if len(*d.cids) == 0 || !force && len(*d.cids) < PruneBatch {
+				continue
+			}
+			var moved []blocks.Block
+			for _, c := range *d.cids {
+				if opts.Offload && !opts.DryRun {
+					blk, err := d.bs.Get(ctx, c)
+					if err != nil {
+						return xerrors.Errorf("getting block %s: %w", c, err)
+					}
+					moved = append(moved, blk)
+					res.Bytes += uint64(len(blk.RawData()))
+					continue
+				}
+				size, err := d.bs.GetSize(ctx, c)
+				if err != nil {
+					return xerrors.Errorf("getting size of %s: %w", c, err)
+				}
+				res.Bytes += uint64(size)
+			}
+			if len(moved) > 0 {
+				if err := cs.coldBlockstore.PutMany(ctx, moved); err != nil {
+					return xerrors.Errorf("moving blocks to the cold store: %w", err)
+				}
+			}
+			if !opts.DryRun {
+				if err := d.bs.DeleteMany(ctx, *d.cids); err != nil {
+					return xerrors.Errorf("deleting blocks: %w", err)
+				}
+			}
+			*d.cids = (*d.cids)[:0]
+		}
+		return nil
+	}
+	sweep := func(c cid.Cid, receipt bool) error {
+		if receipt {
+			res.Receipts++
+			stateDel = append(stateDel, c)
+		} else {
+			res.Messages++
+			chainDel = append(chainDel, c)
+		}
+		return nil
+	}
+
+	log.Infow("pruning chain", "horizon", opts.Horizon, "dryRun", opts.DryRun, "offload", opts.Offload)
+
+	swept := cid.NewSet()
+	lastHeight := abi.ChainEpoch(-1)
+	queue = frontier
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if !swept.Visit(c) {
+			continue
+		}
+
+		var b *types.BlockHeader
+		err := hotChain.View(ctx, c, func(data []byte) error {
+			b = new(types.BlockHeader)
+			return b.UnmarshalCBOR(bytes.NewReader(data))
+		})
+		if err != nil {
+			if ipld.IsNotFound(err) {
+				// pruned before
+				continue
+			}
+			return res, xerrors.Errorf("loading block: %w", err)
+		}
+		if b.Height > 0 {
+			queue = append(queue, b.Parents...)
+		}
+
+		if visit, err := marked.Visit(c); err != nil {
+			return res, err
+		} else if visit {
+			if b.Height != lastHeight {
+				if b.Height%builtin.EpochsInDay == 0 {
+					log.Infow("pruning chain", "height", b.Height)
+				}
+				lastHeight = b.Height
+				res.Tipsets++
+			}
+			res.Headers++
+			chainDel = append(chainDel, c)
+		}
+		if err := walkMsgs(b, sweep); err != nil {
+			return res, err
+		}
+		if err := flush(false); err != nil {
+			return res, err
+		}
+	}
+	if err := flush(true); err != nil {
+		return res, err
+	}
+
+	if !opts.DryRun {
+		cs.tsCache.Purge()
+	}
+	log.Infow("pruned chain", "horizon", opts.Horizon, "dryRun", opts.DryRun, "offload", opts.Offload, "tipsets", res.Tipsets, "headers", res.Headers, "messages", res.Messages, "receipts", res.Receipts, "bytes", res.Bytes)
+	return res, nil
+}
@@ -0,0 +1,221 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestPruneChain(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return types.NewInt(uint64(ts.Height()) + 1), nil
+	}
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	amt := func(vals ...cbg.CBORMarshaler) cid.Cid {
+		arr := blockadt.MakeEmptyArray(cs.ActorStore(ctx))
+		for i, v := range vals {
+			require.NoError(t, arr.Set(uint64(i), v))
+		}
+		root, err := arr.Root()
+		require.NoError(t, err)
+		return root
+	}
+	meta := func(msgs ...*types.Message) cid.Cid {
+		var vals []cbg.CBORMarshaler
+		for _, m := range msgs {
+			c, err := cs.PutMessage(ctx, m)
+			require.NoError(t, err)
+			cc := cbg.CborCid(c)
+			vals = append(vals, &cc)
+		}
+		c, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: amt(vals...), SecpkMessages: amt()})
+		require.NoError(t, err)
+		return c
+	}
+
+	// most blocks share the empty messages and receipts, which are kept
+	from, to := mock.Address(100), mock.Address(101)
+	empty, emptyMeta := amt(), meta()
+	oldMeta, oldMsg := meta(mock.UnsignedMessage(from, to, 0)), mock.UnsignedMessage(from, to, 0).Cid()
+	oldRcts := amt(&types.MessageReceipt{ExitCode: 0, GasUsed: 10})
+	recentMeta := meta(mock.UnsignedMessage(from, to, 1))
+
+	var chain []*types.TipSet
+	var ts *types.TipSet
+	for h := 0; h <= 1000; h++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		blk.Messages, blk.ParentMessageReceipts = emptyMeta, empty
+		switch h {
+		case 5:
+			blk.Messages = oldMeta
+		case 6:
+			blk.ParentMessageReceipts = oldRcts
+		case 950:
+			blk.Messages = recentMeta
+		}
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+		chain = append(chain, ts)
+	}
+	require.NoError(t, cs.SetGenesis(ctx, chain[0].Blocks()[0]))
+	require.NoError(t, cs.SetHead(ctx, ts))
+
+	opts := store.PruneOpts{
+		Horizon: 50,
+		Protect: []types.TipSetKey{chain[10].Key()},
+		DryRun:  true,
+	}
+	want := api.PruneHistoryResult{
+		Horizon:  50,
+		DryRun:   true,
+		Tipsets:  48,
+		Headers:  48,
+		Messages: 3, // the meta, the bls AMT and the message
+		Receipts: 1,
+	}
+
+	has := func(c cid.Cid) bool {
+		has, err := bs.Has(ctx, c)
+		require.NoError(t, err)
+		return has
+	}
+
+	res, err := cs.PruneChain(ctx, opts)
+	require.NoError(t, err)
+	require.NotZero(t, res.Bytes)
+	want.Bytes = res.Bytes
+	require.Equal(t, want, res)
+	require.True(t, has(chain[5].Cids()[0]))
+	require.True(t, has(oldMsg))
+
+	opts.DryRun, want.DryRun = false, false
+	res, err = cs.PruneChain(ctx, opts)
+	require.NoError(t, err)
+	require.Equal(t, want, res)
+
+	for h, ts := range chain {
+		kept := h == 0 || h == 10 || h >= 50
+		require.Equal(t, kept, has(ts.Cids()[0]), "height %d", h)
+	}
+	for _, c := range []cid.Cid{oldMeta, oldMsg, oldRcts} {
+		require.False(t, has(c))
+	}
+	for _, c := range []cid.Cid{empty, emptyMeta, recentMeta} {
+		require.True(t, has(c))
+	}
+
+	// the history above the horizon is left as it is
+	pts, err := cs.GetTipsetByHeight(ctx, 50, ts, false)
+	require.NoError(t, err)
+	require.Equal(t, chain[50], pts)
+
+	// pruning again only walks the pruned history
+	res, err = cs.PruneChain(ctx, opts)
+	require.NoError(t, err)
+	require.Equal(t, api.PruneHistoryResult{Horizon: 50}, res)
+
+	_, err = cs.PruneChain(ctx, store.PruneOpts{Horizon: ts.Height() - build.Finality + 1})
+	require.Error(t, err)
+}
+
+func TestPruneChainOffload(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return types.NewInt(uint64(ts.Height()) + 1), nil
+	}
+	hot, cold := blockstore.NewMemorySync(), blockstore.NewMemorySync()
+	cs := store.NewChainStore(hot, hot, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	_, err := cs.PruneChain(ctx, store.PruneOpts{Offload: true})
+	require.Error(t, err)
+	cs.SetColdStore(cold)
+
+	from, to := mock.Address(100), mock.Address(101)
+	msg := mock.UnsignedMessage(from, to, 0)
+	msgCid, err := cs.PutMessage(ctx, msg)
+	require.NoError(t, err)
+	msgs := blockadt.MakeEmptyArray(cs.ActorStore(ctx))
+	cc := cbg.CborCid(msgCid)
+	require.NoError(t, msgs.Set(0, &cc))
+	msgsRoot, err := msgs.Root()
+	require.NoError(t, err)
+	empty, err := blockadt.MakeEmptyArray(cs.ActorStore(ctx)).Root()
+	require.NoError(t, err)
+	oldMeta, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: msgsRoot, SecpkMessages: empty})
+	require.NoError(t, err)
+
+	var chain []*types.TipSet
+	var ts *types.TipSet
+	for h := 0; h <= 960; h++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		if h == 5 {
+			blk.Messages = oldMeta
+		}
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+		chain = append(chain, ts)
+	}
+	require.NoError(t, cs.SetGenesis(ctx, chain[0].Blocks()[0]))
+	require.NoError(t, cs.SetHead(ctx, ts))
+
+	res, err := cs.PruneChain(ctx, store.PruneOpts{Horizon: 50, Offload: true})
+	require.NoError(t, err)
+	require.True(t, res.Offload)
+	require.Equal(t, uint64(49), res.Headers)
+	require.NotZero(t, res.Bytes)
+
+	// the history is moved to the cold store
+	for h, ts := range chain[:60] {
+		c := ts.Cids()[0]
+		inHot, err := hot.Has(ctx, c)
+		require.NoError(t, err)
+		inCold, err := cold.Has(ctx, c)
+		require.NoError(t, err)
+		require.Equal(t, h == 0 || h >= 50, inHot, "height %d", h)
+		require.Equal(t, h > 0 && h < 50, inCold, "height %d", h)
+	}
+
+	// and still read from there
+	old := mustLoad(t, cs, chain[5].Key())
+	require.Equal(t, chain[5], old)
+	bmsgs, _, err := cs.MessagesForBlock(ctx, old.Blocks()[0])
+	require.NoError(t, err)
+	require.Len(t, bmsgs, 1)
+	require.Equal(t, msg.Cid(), bmsgs[0].Cid())
+	pts, err := cs.GetTipsetByHeight(ctx, 10, ts, false)
+	require.NoError(t, err)
+	require.Equal(t, chain[10], pts)
+
+	// offloading again only walks the hot history
+	res, err = cs.PruneChain(ctx, store.PruneOpts{Horizon: 50, Offload: true})
+	require.NoError(t, err)
+	require.Equal(t, api.PruneHistoryResult{Horizon: 50, Offload: true}, res)
+}
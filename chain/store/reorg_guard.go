@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+const (
+	ReorgHeld     = "held"
+	ReorgAccepted = "accepted"
+	ReorgRejected = "rejected"
+)
+
+// DeepReorgEvt is recorded in the journal when a reorg deeper than the
+// confirmation depth is held, and when it is accepted or rejected.
+type DeepReorgEvt struct {
+	Action string
+	api.PendingReorg
+}
+
+// reorgGuard holds back the reorgs of the heaviest chain that revert more than
+// depth epochs until an operator accepts or rejects them. Its state is guarded
+// by heaviestLk.
+type reorgGuard struct {
+	depth abi.ChainEpoch
+
+	alerts *alerting.Alerting
+	alert  alerting.AlertType
+
+	// pending is the head of the heaviest held fork, and weight its weight.
+	pending *types.TipSet
+	weight  types.BigInt
+	info    api.PendingReorg
+
+	// rejected are the heads of the rejected forks, the tipsets extending
+	// them are ignored.
+	rejected []*types.TipSet
+}
+
+// SetReorgConfirmDepth makes the chainstore hold back the reorgs reverting more
+// than depth epochs of its heaviest chain, until they are accepted or rejected
+// with ResolveReorg. Held reorgs raise an alert if al is set. A depth of 0
+// disables the protection.
+func (cs *ChainStore) SetReorgConfirmDepth(depth abi.ChainEpoch, al *alerting.Alerting) {
+	cs.heaviestLk.Lock()
+	defer cs.heaviestLk.Unlock()
+
+	if depth <= 0 {
+		cs.reorgGuard = nil
+		return
+	}
+
+	rg := &reorgGuard{depth: depth, alerts: al}
+	if al != nil {
+		rg.alert = al.AddAlertType("chainstore", "deep-reorg")
+	}
+	cs.reorgGuard = rg
+}
+
+// holdReorg returns whether taking ts, of weight w, as the head is held back by
+// the reorg guard, with heaviestLk held.
+func (cs *ChainStore) holdReorg(ctx context.Context, ts *types.TipSet, w types.BigInt) (bool, error) {
+	rg := cs.reorgGuard
+	if rg == nil || cs.heaviest == nil {
+		return false, nil
+	}
+
+	// forks this far below the head are past the fork length threshold, and
+	// won't come back
+	live := rg.rejected[:0]
+	for _, r := range rg.rejected {
+		if r.Height()+build.ForkLengthThreshold > cs.heaviest.Height() {
+			live = append(live, r)
+		}
+	}
+	rg.rejected = live
+	for _, r := range rg.rejected {
+		on := r.Equals(ts)
+		if !on {
+			var err error
+			if on, err = cs.IsAncestorOf(ctx, r, ts); err != nil {
+				return false, xerrors.Errorf("checking for rejected fork: %w", err)
+			}
+		}
+		if on {
+			log.Debugw("ignoring tipset of a rejected fork", "tipset", ts.Cids(), "height", ts.Height())
+			return true, nil
+		}
+	}
+
+	revert, _, err := cs.ReorgOps(ctx, cs.heaviest, ts)
+	if err != nil {
+		return false, xerrors.Errorf("computing reorg: %w", err)
+	}
+	if len(revert) == 0 {
+		return false, nil
+	}
+	anc, err := cs.LoadTipSet(ctx, revert[len(revert)-1].Parents())
+	if err != nil {
+		return false, xerrors.Errorf("loading common ancestor: %w", err)
+	}
+	depth := cs.heaviest.Height() - anc.Height()
+	if depth <= rg.depth {
+		return false, nil
+	}
+
+	if rg.pending != nil && !w.GreaterThan(rg.weight) {
+		return true, nil
+	}
+	rg.pending, rg.weight = ts, w
+	rg.info = api.PendingReorg{
+		From:           cs.heaviest.Key(),
+		FromHeight:     cs.heaviest.Height(),
+		To:             ts.Key(),
+		ToHeight:       ts.Height(),
+		Ancestor:       anc.Key(),
+		AncestorHeight: anc.Height(),
+		Depth:          depth,
+		Seen:           time.Now(),
+	}
+
+	log.Warnw("holding back deep reorg until it is accepted or rejected", "depth", depth, "threshold", rg.depth, "to", ts.Cids(), "height", ts.Height())
+	cs.recordDeepReorg(ReorgHeld)
+	if rg.alerts != nil {
+		rg.alerts.Raise(rg.alert, rg.info)
+	}
+	return true, nil
+}
+
+func (cs *ChainStore) recordDeepReorg(action string) {
+	info := cs.reorgGuard.info
+	cs.journal.RecordEvent(cs.evtTypes[evtTypeDeepReorg], func() interface{} {
+		return DeepReorgEvt{
+			Action:       action,
+			PendingReorg: info,
+		}
+	})
+}
+
+// PendingReorg returns the reorg held back for confirmation, if any.
+func (cs *ChainStore) PendingReorg() *api.PendingReorg {
+	cs.heaviestLk.RLock()
+	defer cs.heaviestLk.RUnlock()
+
+	if cs.reorgGuard == nil || cs.reorgGuard.pending == nil {
+		return nil
+	}
+	info := cs.reorgGuard.info
+	return &info
+}
+
+// ResolveReorg accepts or rejects the pending reorg to the tipset to. Accepting
+// it takes to as the head. Rejecting it ignores to and the tipsets extending
+// it from then on.
+func (cs *ChainStore) ResolveReorg(ctx context.Context, to types.TipSetKey, accept bool) error {
+	cs.heaviestLk.Lock()
+	defer cs.heaviestLk.Unlock()
+
+	rg := cs.reorgGuard
+	if rg == nil || rg.pending == nil {
+		return xerrors.Errorf("no reorg is pending")
+	}
+	if rg.pending.Key() != to {
+		return xerrors.Errorf("pending reorg is to %s, not %s", rg.pending.Key(), to)
+	}
+
+	action := ReorgRejected
+	if accept {
+		action = ReorgAccepted
+	}
+	log.Warnw("deep reorg resolved", "action", action, "depth", rg.info.Depth, "to", rg.pending.Cids(), "height", rg.pending.Height())
+
+	if accept {
+		if err := cs.takeHeaviestTipSet(ctx, rg.pending); err != nil {
+			return xerrors.Errorf("taking the head of the reorg: %w", err)
+		}
+	} else {
+		rg.rejected = append(rg.rejected, rg.pending)
+	}
+
+	cs.recordDeepReorg(action)
+	if rg.alerts != nil {
+		rg.alerts.Resolve(rg.alert, map[string]interface{}{
+			"action": action,
+			"to":     to,
+		})
+	}
+	rg.pending, rg.weight = nil, types.EmptyInt
+	return nil
+}
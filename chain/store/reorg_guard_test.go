@@ -0,0 +1,94 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+func TestReorgGuard(t *testing.T) {
+	ctx := context.Background()
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	cs.SetReorgConfirmDepth(3, al)
+
+	// extend grows ts by n tipsets of weight w, offering each to cs
+	extend := func(ts *types.TipSet, n int, w, nonce uint64) *types.TipSet {
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, w, nonce)
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+			require.NoError(t, cs.MaybeTakeHeavierTipSet(ctx, ts))
+		}
+		return ts
+	}
+
+	gen := mock.MkBlock(nil, 1, 1)
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+	a := extend(mock.TipSet(gen), 10, 1, 1)
+	require.Equal(t, a, cs.GetHeaviestTipSet())
+
+	// reorgs up to the depth go through
+	fork, err := cs.GetTipsetByHeight(ctx, 8, a, true)
+	require.NoError(t, err)
+	b := extend(fork, 3, 2, 2)
+	require.Equal(t, b, cs.GetHeaviestTipSet())
+	require.Nil(t, cs.PendingReorg())
+
+	// deeper ones are held
+	fork, err = cs.GetTipsetByHeight(ctx, 4, b, true)
+	require.NoError(t, err)
+	c := extend(fork, 4, 6, 3)
+	require.Equal(t, b, cs.GetHeaviestTipSet())
+	pr := cs.PendingReorg()
+	require.NotNil(t, pr)
+	require.Equal(t, c.Key(), pr.To)
+	require.Equal(t, b.Key(), pr.From)
+	require.Equal(t, fork.Key(), pr.Ancestor)
+	require.Equal(t, b.Height()-fork.Height(), pr.Depth)
+	require.True(t, al.IsRaised(alerting.AlertType{System: "chainstore", Subsystem: "deep-reorg"}))
+
+	// the fork keeps growing while it is pending
+	c = extend(c, 1, 6, 3)
+	require.Equal(t, c.Key(), cs.PendingReorg().To)
+	require.Error(t, cs.ResolveReorg(ctx, b.Key(), true))
+
+	// it is ignored once rejected, while the chain of the head goes on
+	require.NoError(t, cs.ResolveReorg(ctx, c.Key(), false))
+	require.False(t, al.IsRaised(alerting.AlertType{System: "chainstore", Subsystem: "deep-reorg"}))
+	extend(c, 2, 6, 3)
+	require.Nil(t, cs.PendingReorg())
+	b = extend(b, 1, 1, 2)
+	require.Equal(t, b, cs.GetHeaviestTipSet())
+
+	// and taken as the head once accepted
+	d := extend(fork, 6, 6, 4)
+	require.Equal(t, b, cs.GetHeaviestTipSet())
+	require.Equal(t, d.Key(), cs.PendingReorg().To)
+	require.NoError(t, cs.ResolveReorg(ctx, d.Key(), true))
+	require.Equal(t, d, cs.GetHeaviestTipSet())
+	require.Nil(t, cs.PendingReorg())
+	require.Error(t, cs.ResolveReorg(ctx, d.Key(), true))
+}
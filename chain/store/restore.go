@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"io"
+
+	dstore "github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// RestoreSnapshot imports the snapshot read from r into standby while cs keeps
+// serving from its blockstores, then promotes standby in their place and sets
+// the root of the snapshot as the head. The blockstores of cs must serve from
+// standby once it is promoted, e.g. by being backed by a SwapStore. On failure,
+// standby is discarded and cs is left untouched.
+//
+// The snapshot is checked to be complete and of the chain of cs before standby
+// is promoted. The head changes from the previous head to the root, computed
+// before the promotion while both chains can be read, are then notified as for
+// any other head change, even though the reverted tipsets may no longer be in
+// the blockstores. Chain reads that race with the promotion may fail while the
+// head still is a tipset that is not in the snapshot.
+func (cs *ChainStore) RestoreSnapshot(ctx context.Context, r io.Reader, standby bstore.StandbyBlockstore, opts ImportOpts) (_ *types.TipSet, err error) {
+	defer func() {
+		if err != nil {
+			if derr := standby.Discard(); derr != nil {
+				log.Errorw("discarding standby blockstore", "error", derr)
+			}
+		}
+	}()
+
+	gen, err := cs.GetGenesis(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("loading genesis: %w", err)
+	}
+
+	// the import only writes progress to the metadata datastore, which
+	// doesn't outlive the standby blockstore
+	scs := NewChainStore(standby, standby, syncds.MutexWrap(dstore.NewMapDatastore()), nil, nil)
+	defer scs.Close() //nolint:errcheck
+
+	opts.Verify = true
+	ts, err := scs.ImportWithOpts(ctx, r, opts)
+	if err != nil {
+		return nil, xerrors.Errorf("importing snapshot: %w", err)
+	}
+
+	has, err := standby.Has(ctx, gen.Cid())
+	if err != nil {
+		return nil, xerrors.Errorf("checking for genesis: %w", err)
+	}
+	if !has {
+		return nil, xerrors.Errorf("snapshot is not of this chain: genesis %s is missing", gen.Cid())
+	}
+
+	// the head doesn't change until the root is taken, the reorg ops from the
+	// previous head staying valid
+	cs.heaviestLk.Lock()
+	defer cs.heaviestLk.Unlock()
+
+	head := reorg{new: ts, ops: true}
+	if cs.heaviest != nil {
+		lts := func(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+			if ts, err := scs.LoadTipSet(ctx, tsk); err == nil {
+				return ts, nil
+			}
+			return cs.LoadTipSet(ctx, tsk)
+		}
+		if head.revert, head.apply, err = notifiedReorgOps(ctx, lts, cs.heaviest, ts); err != nil {
+			return nil, xerrors.Errorf("computing head changes to the snapshot: %w", err)
+		}
+	}
+
+	if err := standby.Promote(); err != nil {
+		return nil, xerrors.Errorf("promoting standby blockstore: %w", err)
+	}
+
+	log.Infow("restored snapshot, accepting its root as the new head", "height", ts.Height(), "tipset", ts.Cids())
+	if err := cs.removeCheckpoint(ctx); err != nil {
+		return nil, xerrors.Errorf("removing checkpoint: %w", err)
+	}
+	if err := cs.takeReorg(ctx, head); err != nil {
+		return nil, xerrors.Errorf("setting restored head: %w", err)
+	}
+	return ts, nil
+}
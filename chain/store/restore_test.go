@@ -0,0 +1,146 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// testStandby is a standby of a SwapStore.
+type testStandby struct {
+	blockstore.Blockstore
+	swap      *blockstore.SwapStore
+	discarded bool
+}
+
+func (s *testStandby) Promote() error {
+	s.swap.Swap(s.Blockstore)
+	return nil
+}
+
+func (s *testStandby) Discard() error {
+	s.discarded = true
+	return nil
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	st, err := cbor.WrapObject(map[string]interface{}{}, mh.SHA2_256, -1)
+	require.NoError(t, err)
+
+	// mkChain extends gen by height blocks, all with the same state in bs
+	mkChain := func(bs blockstore.Blockstore, cs *store.ChainStore, gen *types.BlockHeader, height int, nonce uint64) *types.TipSet {
+		require.NoError(t, bs.Put(ctx, st))
+		require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+		ts := mock.TipSet(gen)
+		for i := 0; i < height; i++ {
+			blk := mock.MkBlock(ts, 1, nonce)
+			blk.ParentStateRoot = st.Cid()
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+		}
+		return ts
+	}
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = st.Cid()
+
+	// the snapshot is of a longer chain with the same genesis
+	srcBs := blockstore.NewMemorySync()
+	src := store.NewChainStore(srcBs, srcBs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer src.Close() //nolint:errcheck
+	root := mkChain(srcBs, src, gen, 6, 2)
+
+	var snap bytes.Buffer
+	require.NoError(t, src.Export(ctx, root, 0, true, true, &snap))
+
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return types.NewInt(uint64(ts.Height()) + 1), nil
+	}
+	bs := blockstore.NewSwapStore(blockstore.NewMemorySync())
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), weight, nil)
+	defer cs.Close() //nolint:errcheck
+	head := mkChain(bs, cs, gen, 2, 1)
+	require.NoError(t, cs.SetGenesis(ctx, gen))
+	require.NoError(t, cs.SetHead(ctx, head))
+
+	// a snapshot of another chain is not switched to
+	otherBs := blockstore.NewMemorySync()
+	other := store.NewChainStore(otherBs, otherBs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer other.Close() //nolint:errcheck
+	otherGen := mock.MkBlock(nil, 1, 3)
+	otherGen.ParentStateRoot = st.Cid()
+	otherRoot := mkChain(otherBs, other, otherGen, 3, 3)
+	var otherSnap bytes.Buffer
+	require.NoError(t, other.Export(ctx, otherRoot, 0, true, true, &otherSnap))
+
+	standby := &testStandby{Blockstore: blockstore.NewMemorySync(), swap: bs}
+	_, err = cs.RestoreSnapshot(ctx, &otherSnap, standby, store.ImportOpts{})
+	require.ErrorContains(t, err, "not of this chain")
+	require.True(t, standby.discarded)
+	require.Equal(t, head.Key(), cs.GetHeaviestTipSet().Key())
+	has, err := bs.Has(ctx, head.Cids()[0])
+	require.NoError(t, err)
+	require.True(t, has)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changes := cs.SubHeadChanges(subCtx)
+	require.Equal(t, []*api.HeadChange{{Type: store.HCCurrent, Val: head}}, <-changes)
+
+	type headChange struct{ revert, apply []*types.TipSet }
+	notified := make(chan headChange, 16)
+	cs.SubscribeHeadChanges(func(rev, app []*types.TipSet) error {
+		notified <- headChange{rev, app}
+		return nil
+	})
+
+	// the node serves from the snapshot once it is restored
+	standby = &testStandby{Blockstore: blockstore.NewMemorySync(), swap: bs}
+	ts, err := cs.RestoreSnapshot(ctx, &snap, standby, store.ImportOpts{})
+	require.NoError(t, err)
+	require.False(t, standby.discarded)
+	require.Equal(t, root.Key(), ts.Key())
+	require.Equal(t, root.Key(), cs.GetHeaviestTipSet().Key())
+
+	// the notifees and the subscribers are sent the reorg from the previous
+	// head, after the changes to head still in flight
+	var hc headChange
+	for len(hc.apply) == 0 || !hc.apply[len(hc.apply)-1].Equals(root) {
+		hc = <-notified
+	}
+	require.Len(t, hc.revert, 2)
+	require.Equal(t, head.Key(), hc.revert[0].Key())
+	require.Len(t, hc.apply, 6)
+
+	var hcs []*api.HeadChange
+	for len(hcs) == 0 || !hcs[len(hcs)-1].Val.Equals(root) {
+		hcs = <-changes
+	}
+	require.Len(t, hcs, 8)
+	require.Equal(t, store.HCRevert, hcs[0].Type)
+	require.Equal(t, store.HCApply, hcs[7].Type)
+
+	has, err = bs.Has(ctx, head.Cids()[0])
+	require.NoError(t, err)
+	require.False(t, has)
+	_, err = cs.GetTipsetByHeight(ctx, 1, cs.GetHeaviestTipSet(), false)
+	require.NoError(t, err)
+}
@@ -0,0 +1,356 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SnapshotShard is a part of a sharded snapshot.
+type SnapshotShard struct {
+	// Name is the file name of the shard, in the directory of the manifest.
+	Name   string
+	Size   uint64
+	SHA256 string
+}
+
+// shardWriter splits a CARv1 into shards of at most max bytes. Every shard is a
+// CARv1 with the header of the snapshot followed by whole blocks, a block that
+// doesn't fit into an empty shard gets a shard of its own.
+type shardWriter struct {
+	base   string
+	max    uint64
+	header []byte
+
+	// mw records the manifest of the snapshot the shards stitch back to
+	mw *ManifestWriter
+
+	shards []SnapshotShard
+	f      *os.File
+	bw     *bufio.Writer
+	h      hash.Hash
+	n      uint64
+}
+
+func newShardWriter(base string, max uint64, roots []cid.Cid) (*shardWriter, error) {
+	sw := &shardWriter{
+		base: base,
+		max:  max,
+		mw:   NewManifestWriter(io.Discard),
+	}
+
+	var hb bytes.Buffer
+	if err := car.WriteHeader(&car.CarHeader{Roots: roots, Version: 1}, &hb); err != nil {
+		return nil, xerrors.Errorf("failed to write car header: %s", err)
+	}
+	sw.header = hb.Bytes()
+	if _, err := sw.mw.Write(sw.header); err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+func (sw *shardWriter) shardName(i int) string {
+	return fmt.Sprintf("%s.%04d", filepath.Base(sw.base), i)
+}
+
+func (sw *shardWriter) open() error {
+	path := filepath.Join(filepath.Dir(sw.base), sw.shardName(len(sw.shards)))
+	f, err := os.Create(path)
+	if err != nil {
+		return xerrors.Errorf("creating shard: %w", err)
+	}
+
+	sw.f, sw.h, sw.n = f, sha256.New(), 0
+	sw.bw = bufio.NewWriterSize(io.MultiWriter(f, sw.h), 1<<20)
+	if _, err := sw.bw.Write(sw.header); err != nil {
+		return xerrors.Errorf("writing shard header: %w", err)
+	}
+	sw.n = uint64(len(sw.header))
+	return nil
+}
+
+func (sw *shardWriter) close() error {
+	if sw.f == nil {
+		return nil
+	}
+	if err := sw.bw.Flush(); err != nil {
+		return xerrors.Errorf("writing shard: %w", err)
+	}
+	if err := sw.f.Close(); err != nil {
+		return xerrors.Errorf("closing shard: %w", err)
+	}
+
+	sw.shards = append(sw.shards, SnapshotShard{
+		Name:   sw.shardName(len(sw.shards)),
+		Size:   sw.n,
+		SHA256: hex.EncodeToString(sw.h.Sum(nil)),
+	})
+	sw.f = nil
+	return nil
+}
+
+func (sw *shardWriter) writeBlock(c cid.Cid, data []byte) error {
+	size := carutil.LdSize(c.Bytes(), data)
+	if sw.f != nil && sw.n > uint64(len(sw.header)) && sw.n+size > sw.max {
+		if err := sw.close(); err != nil {
+			return err
+		}
+	}
+	if sw.f == nil {
+		if err := sw.open(); err != nil {
+			return err
+		}
+	}
+
+	if err := carutil.LdWrite(io.MultiWriter(sw.bw, sw.mw), c.Bytes(), data); err != nil {
+		return xerrors.Errorf("failed to write block to shard: %w", err)
+	}
+	sw.n += size
+	return nil
+}
+
+// abort removes the shards written so far.
+func (sw *shardWriter) abort() {
+	if sw.f != nil {
+		_ = sw.f.Close()
+		_ = os.Remove(sw.f.Name())
+	}
+	for _, s := range sw.shards {
+		_ = os.Remove(filepath.Join(filepath.Dir(sw.base), s.Name))
+	}
+}
+
+// ExportShards is like Export, but splits the snapshot into shards of at most
+// maxSize bytes, written next to base as base.0000, base.0001 and so on. Each
+// shard is a CARv1 with the roots of the snapshot. The returned manifest lists
+// the shards, its size and digest describe the CARv1 the shards stitch back
+// to; it must be written to base+ManifestSuffix for OpenShards to find them.
+func (cs *ChainStore) ExportShards(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, base string, maxSize uint64) (*SnapshotManifest, error) {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	inclRecentRoots, err := CheckRecentRoots(ts, inclRecentRoots, ExportRecentRootsPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	sw, err := newShardWriter(base, maxSize, ts.Cids())
+	if err != nil {
+		return nil, err
+	}
+
+	et := cs.trackExport(ts)
+	unionBs := cs.UnionStore()
+	err = cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, et, nil, func(c cid.Cid) error {
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := sw.writeBlock(c, blk.RawData()); err != nil {
+			return err
+		}
+		et.wrote(carutil.LdSize(c.Bytes(), blk.RawData()))
+
+		return nil
+	})
+	if err == nil {
+		err = sw.close()
+	}
+	et.finish(err)
+	if err != nil {
+		sw.abort()
+		return nil, err
+	}
+
+	m := sw.mw.Manifest(ts, inclRecentRoots)
+	m.Shards = sw.shards
+	return m, nil
+}
+
+// WriteShards reads a CARv1 stream, such as the one returned by the
+// ChainExport API, and splits it into shards of at most maxSize bytes written
+// next to base, like ExportShards. The stream only holds the roots of the
+// snapshot, the epochs of the returned manifest are left to the caller.
+func WriteShards(r io.Reader, base string, maxSize uint64) (*SnapshotManifest, error) {
+	cr, err := car.NewCarReader(r)
+	if err != nil {
+		return nil, xerrors.Errorf("reading car header: %w", err)
+	}
+
+	sw, err := newShardWriter(base, maxSize, cr.Header.Roots)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		blk, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			sw.abort()
+			return nil, xerrors.Errorf("reading car block: %w", err)
+		}
+
+		if err := sw.writeBlock(blk.Cid(), blk.RawData()); err != nil {
+			sw.abort()
+			return nil, err
+		}
+	}
+	if err := sw.close(); err != nil {
+		sw.abort()
+		return nil, err
+	}
+
+	return &SnapshotManifest{
+		Roots:  cr.Header.Roots,
+		Size:   sw.mw.n,
+		SHA256: hex.EncodeToString(sw.mw.h.Sum(nil)),
+		Shards: sw.shards,
+	}, nil
+}
+
+// ShardReader reads the shards of a sharded snapshot as a single CARv1, the
+// header of every shard after the first one is skipped. The size and digest of
+// every shard are checked against the manifest as it is read.
+type ShardReader struct {
+	dir string
+	m   *SnapshotManifest
+
+	next int
+	cur  *os.File
+	r    *bufio.Reader
+	mr   *manifestReader
+}
+
+// OpenShards opens the sharded snapshot described by the manifest at path.
+func OpenShards(path string) (*ShardReader, error) {
+	m, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Shards) == 0 {
+		return nil, xerrors.Errorf("manifest %s does not describe a sharded snapshot", path)
+	}
+
+	sr := &ShardReader{dir: filepath.Dir(path), m: m}
+	for _, s := range m.Shards {
+		if _, err := os.Stat(filepath.Join(sr.dir, s.Name)); err != nil {
+			return nil, xerrors.Errorf("opening shard: %w", err)
+		}
+	}
+	return sr, nil
+}
+
+// Manifest returns the manifest of the snapshot.
+func (sr *ShardReader) Manifest() *SnapshotManifest {
+	return sr.m
+}
+
+// Size returns the size of the stitched snapshot.
+func (sr *ShardReader) Size() int64 {
+	return int64(sr.m.Size)
+}
+
+func (sr *ShardReader) Read(p []byte) (int, error) {
+	for {
+		if sr.cur == nil {
+			if sr.next == len(sr.m.Shards) {
+				return 0, io.EOF
+			}
+			if err := sr.openShard(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := sr.r.Read(p)
+		if err == io.EOF {
+			err = sr.closeShard()
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (sr *ShardReader) openShard() error {
+	s := sr.m.Shards[sr.next]
+	f, err := os.Open(filepath.Join(sr.dir, s.Name))
+	if err != nil {
+		return xerrors.Errorf("opening shard: %w", err)
+	}
+
+	sr.cur = f
+	sr.mr = newManifestReader(f, &SnapshotManifest{Size: s.Size, SHA256: s.SHA256})
+	sr.r = bufio.NewReaderSize(sr.mr, 1<<20)
+	if sr.next > 0 {
+		h, err := car.ReadHeader(sr.r)
+		if err != nil {
+			return xerrors.Errorf("reading header of shard %s: %w", s.Name, err)
+		}
+		if !types.CidArrsEqual(h.Roots, sr.m.Roots) {
+			return xerrors.Errorf("shard %s has roots %v, manifest expects %v", s.Name, h.Roots, sr.m.Roots)
+		}
+	}
+	return nil
+}
+
+func (sr *ShardReader) closeShard() error {
+	s := sr.m.Shards[sr.next]
+	sr.next++
+
+	err := sr.cur.Close()
+	sr.cur = nil
+	if err != nil {
+		return err
+	}
+
+	if err := sr.mr.check(); err != nil {
+		return xerrors.Errorf("checking shard %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+func (sr *ShardReader) Close() error {
+	if sr.cur == nil {
+		return nil
+	}
+	err := sr.cur.Close()
+	sr.cur = nil
+	return err
+}
+
+// ImportShards imports the sharded snapshot described by the manifest at path,
+// see OpenShards. The stitched snapshot is checked against the manifest.
+func (cs *ChainStore) ImportShards(ctx context.Context, path string, opts ImportOpts) (*types.TipSet, error) {
+	sr, err := OpenShards(path)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close() //nolint:errcheck
+
+	opts.Manifest = sr.Manifest()
+	return cs.ImportWithOpts(ctx, sr, opts)
+}
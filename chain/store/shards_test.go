@@ -0,0 +1,125 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+func TestExportShards(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	var snap bytes.Buffer
+	require.NoError(t, cs.Export(ctx, ts, 0, true, true, &snap))
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "snapshot.car")
+	m, err := cs.ExportShards(ctx, ts, 0, true, true, base, 1024)
+	require.NoError(t, err)
+	require.NoError(t, store.WriteManifest(base+store.ManifestSuffix, m))
+
+	// the shards stitch back to the unsharded export
+	require.Greater(t, len(m.Shards), 2)
+	require.Equal(t, uint64(snap.Len()), m.Size)
+
+	var blocks int
+	for _, s := range m.Shards {
+		fi, err := os.Open(filepath.Join(dir, s.Name))
+		require.NoError(t, err)
+		cr, err := car.NewCarReader(fi)
+		require.NoError(t, err)
+		require.Equal(t, ts.Cids(), cr.Header.Roots)
+		for {
+			_, err := cr.Next()
+			if err != nil {
+				break
+			}
+			blocks++
+		}
+		require.NoError(t, fi.Close())
+
+		st, err := os.Stat(filepath.Join(dir, s.Name))
+		require.NoError(t, err)
+		require.Equal(t, uint64(st.Size()), s.Size)
+		require.LessOrEqual(t, s.Size, uint64(1024))
+	}
+	require.Equal(t, 12, blocks)
+
+	importShards := func() error {
+		bs := blockstore.NewMemorySync()
+		cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+		defer cs.Close() //nolint:errcheck
+		root, err := cs.ImportShards(ctx, base+store.ManifestSuffix, store.ImportOpts{})
+		if err != nil {
+			return err
+		}
+		require.Equal(t, ts.Key(), root.Key())
+		return nil
+	}
+
+	require.NoError(t, importShards())
+
+	// a corrupt shard is caught before the import completes
+	last := filepath.Join(dir, m.Shards[len(m.Shards)-1].Name)
+	data, err := os.ReadFile(last)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(last, data[:len(data)-1], 0644))
+	require.ErrorContains(t, importShards(), m.Shards[len(m.Shards)-1].Name)
+
+	require.NoError(t, os.Remove(last))
+	require.ErrorContains(t, importShards(), "opening shard")
+}
+
+func TestWriteShards(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	dir := t.TempDir()
+	exported, err := cs.ExportShards(ctx, ts, 0, true, true, filepath.Join(dir, "exported.car"), 1024)
+	require.NoError(t, err)
+
+	// the shards of an export stream are those of the export
+	var snap bytes.Buffer
+	require.NoError(t, cs.Export(ctx, ts, 0, true, true, &snap))
+	m, err := store.WriteShards(&snap, filepath.Join(dir, "written.car"), 1024)
+	require.NoError(t, err)
+
+	require.Equal(t, ts.Cids(), m.Roots)
+	require.Equal(t, exported.Size, m.Size)
+	require.Equal(t, exported.SHA256, m.SHA256)
+	require.Len(t, m.Shards, len(exported.Shards))
+	for i, s := range m.Shards {
+		require.Equal(t, "written.car"+exported.Shards[i].Name[len("exported.car"):], s.Name)
+		require.Equal(t, exported.Shards[i].SHA256, s.SHA256)
+	}
+
+	// nothing is left behind on a truncated stream
+	require.NoError(t, cs.Export(ctx, ts, 0, true, true, &snap))
+	_, err = store.WriteShards(bytes.NewReader(snap.Bytes()[:snap.Len()-10]), filepath.Join(dir, "truncated.car"), 1024)
+	require.Error(t, err)
+	matches, err := filepath.Glob(filepath.Join(dir, "truncated.car*"))
+	require.NoError(t, err)
+	require.Empty(t, matches)
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ExportSink is a destination for a snapshot export, such as an object storage
+// upload. The export is written to the sink sequentially; Commit is called
+// once it has been fully written, and Abort if the export failed, so that the
+// sink can complete or discard any pending upload.
+type ExportSink interface {
+	io.Writer
+
+	Commit(ctx context.Context) error
+	Abort(ctx context.Context) error
+}
+
+// ExportToSink is like Export, but writes the snapshot to sink and commits it
+// once the export succeeds. The sink is aborted if the export fails.
+func (cs *ChainStore) ExportToSink(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, sink ExportSink) error {
+	bw := bufio.NewWriterSize(sink, 1<<20)
+
+	err := cs.Export(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		if aerr := sink.Abort(ctx); aerr != nil {
+			log.Errorw("aborting export sink", "error", aerr)
+		}
+		return err
+	}
+
+	if err := sink.Commit(ctx); err != nil {
+		return xerrors.Errorf("committing export: %w", err)
+	}
+
+	return nil
+}
+
+type writerAtSink struct {
+	w   io.WriterAt
+	off int64
+}
+
+// NewWriterAtSink returns an ExportSink that writes the export sequentially to
+// w, starting at offset 0.
+func NewWriterAtSink(w io.WriterAt) ExportSink {
+	return &writerAtSink{w: w}
+}
+
+func (s *writerAtSink) Write(p []byte) (int, error) {
+	n, err := s.w.WriteAt(p, s.off)
+	s.off += int64(n)
+	return n, err
+}
+
+func (s *writerAtSink) Commit(context.Context) error { return nil }
+func (s *writerAtSink) Abort(context.Context) error  { return nil }
+
+type fileSink struct {
+	*os.File
+	path string
+}
+
+// NewFileSink returns an ExportSink that writes the export to a temporary file
+// next to path, which is renamed to path once the export is committed.
+func NewFileSink(path string) (ExportSink, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return nil, xerrors.Errorf("creating export file: %w", err)
+	}
+	return &fileSink{File: f, path: path}, nil
+}
+
+func (s *fileSink) Commit(context.Context) error {
+	if err := s.File.Close(); err != nil {
+		return xerrors.Errorf("closing export file: %w", err)
+	}
+	if err := os.Rename(s.File.Name(), s.path); err != nil {
+		return xerrors.Errorf("moving export file into place: %w", err)
+	}
+	return nil
+}
+
+func (s *fileSink) Abort(context.Context) error {
+	_ = s.File.Close()
+	return os.Remove(s.File.Name())
+}
+
+type compressedSink struct {
+	io.WriteCloser
+	sink ExportSink
+}
+
+// NewCompressedSink returns an ExportSink that compresses the export before
+// writing it to sink. See NewCompressedWriter for the supported compressions.
+func NewCompressedSink(sink ExportSink, compression string, level int) (ExportSink, error) {
+	cw, err := NewCompressedWriter(sink, compression, level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &compressedSink{WriteCloser: cw, sink: sink}, nil
+}
+
+func (s *compressedSink) Commit(ctx context.Context) error {
+	if err := s.WriteCloser.Close(); err != nil {
+		return xerrors.Errorf("closing compressed writer: %w", err)
+	}
+	return s.sink.Commit(ctx)
+}
+
+func (s *compressedSink) Abort(ctx context.Context) error {
+	return s.sink.Abort(ctx)
+}
@@ -0,0 +1,80 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DataDog/zstd"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type recordingSink struct {
+	bytes.Buffer
+	committed, aborted bool
+}
+
+func (s *recordingSink) Commit(context.Context) error {
+	s.committed = true
+	return nil
+}
+
+func (s *recordingSink) Abort(context.Context) error {
+	s.aborted = true
+	return nil
+}
+
+func TestExportToSink(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	rs := &recordingSink{}
+	sink, err := store.NewCompressedSink(rs, store.CompressionZstd, 0)
+	require.NoError(t, err)
+	require.NoError(t, cs.ExportToSink(ctx, ts, 0, true, true, sink))
+	require.True(t, rs.committed)
+	require.False(t, rs.aborted)
+
+	cr, err := car.NewCarReader(zstd.NewReader(&rs.Buffer))
+	require.NoError(t, err)
+	require.Equal(t, ts.Cids(), cr.Header.Roots)
+
+	// failed exports abort the sink
+	rs = &recordingSink{}
+	err = cs.ExportToSink(ctx, mock.TipSet(mock.MkBlock(ts, 1, 2)), 0, true, true, rs)
+	require.Error(t, err)
+	require.False(t, rs.committed)
+	require.True(t, rs.aborted)
+}
+
+type writerAt []byte
+
+func (w writerAt) WriteAt(p []byte, off int64) (int, error) {
+	return copy(w[off:], p), nil
+}
+
+func TestWriterAtSink(t *testing.T) {
+	buf := make(writerAt, 8)
+	sink := store.NewWriterAtSink(buf)
+
+	_, err := sink.Write([]byte("abc"))
+	require.NoError(t, err)
+	_, err = sink.Write([]byte("defgh"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Commit(context.Background()))
+
+	require.Equal(t, "abcdefgh", string(buf))
+}
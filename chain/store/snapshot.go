@@ -3,22 +3,24 @@ package store
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"sync/atomic"
 
-	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
 	"github.com/ipld/go-car"
 	carutil "github.com/ipld/go-car/util"
-	carv2 "github.com/ipld/go-car/v2"
 	mh "github.com/multiformats/go-multihash"
+	"github.com/multiformats/go-varint"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api"
 	bstore "github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
-	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -26,7 +28,27 @@ func (cs *ChainStore) UnionStore() bstore.Blockstore {
 	return bstore.Union(cs.stateBlockstore, cs.chainBlockstore)
 }
 
-func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error {
+// Export writes a CARv1 snapshot of the chain from ts to w. Message receipts
+// are included along with the messages unless skipMsgReceipts is set. Progress
+// of the export is published to SubExportProgress subscribers. The number of
+// recent state roots is checked against ExportRecentRootsPolicy.
+func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, w io.Writer) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	inclRecentRoots, err := CheckRecentRoots(ts, inclRecentRoots, ExportRecentRootsPolicy)
+	if err != nil {
+		return err
+	}
+
+	et := cs.trackExport(ts)
+	err = cs.export(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, w, et, nil, nil)
+	et.finish(err)
+	return err
+}
+
+func (cs *ChainStore) export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, filter SnapshotFilter, w io.Writer, et *exportTracker, th *exportThrottle, tables *MessageTables) error {
 	h := &car.CarHeader{
 		Roots:   ts.Cids(),
 		Version: 1,
@@ -37,234 +59,438 @@ func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRo
 	}
 
 	unionBs := cs.UnionStore()
-	return cs.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, func(c cid.Cid) error {
+	return cs.walkSnapshotBlocks(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, et, filter, func(c cid.Cid, bt SnapshotBlockType) error {
+		if tables != nil {
+			if err := tables.visit(ctx, c, bt); err != nil {
+				return err
+			}
+		}
+
 		blk, err := unionBs.Get(ctx, c)
 		if err != nil {
 			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
 		}
 
+		if err := th.wait(ctx, len(blk.RawData())); err != nil {
+			return err
+		}
+
 		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
 			return xerrors.Errorf("failed to write block to car output: %w", err)
 		}
+		et.wrote(carutil.LdSize(c.Bytes(), blk.RawData()))
+
+		return nil
+	})
+}
+
+// EstimateExportSize returns the number of blocks and the size of the CARv1
+// file Export would write for the same parameters. The export DAG is walked as
+// usual, but the data of blocks that cannot contain links is never loaded.
+func (cs *ChainStore) EstimateExportSize(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool) (api.ExportSizeEstimate, error) {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	inclRecentRoots, err := CheckRecentRoots(ts, inclRecentRoots, ExportRecentRootsPolicy)
+	if err != nil {
+		return api.ExportSizeEstimate{}, err
+	}
+
+	hn, err := car.HeaderSize(&car.CarHeader{
+		Roots:   ts.Cids(),
+		Version: 1,
+	})
+	if err != nil {
+		return api.ExportSizeEstimate{}, xerrors.Errorf("sizing car header: %w", err)
+	}
+
+	est := api.ExportSizeEstimate{Bytes: hn}
+	unionBs := cs.UnionStore()
+	err = cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, nil, func(c cid.Cid) error {
+		sz, err := unionBs.GetSize(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("getting size of %s: %w", c, err)
+		}
 
+		cb := c.Bytes()
+		est.Blocks++
+		est.Bytes += uint64(varint.UvarintSize(uint64(len(cb)+sz)) + len(cb) + sz)
 		return nil
 	})
+	if err != nil {
+		return api.ExportSizeEstimate{}, err
+	}
+
+	return est, nil
+}
+
+// importCheckpoint records how far a snapshot import got, so that an
+// interrupted import of the same snapshot can resume instead of restarting.
+type importCheckpoint struct {
+	// Roots identifies the snapshot being imported.
+	Roots []cid.Cid
+	// Blocks is the number of blocks, in CAR order, that are known to be
+	// persisted to the blockstore.
+	Blocks uint64
+	// Last is the CID of the last persisted block, used to check that the
+	// resumed stream matches the one the checkpoint was taken from.
+	Last cid.Cid
+}
+
+func (cs *ChainStore) loadImportCheckpoint(ctx context.Context) (*importCheckpoint, error) {
+	data, err := cs.metadataDs.Get(ctx, importCheckpointKey)
+	if err == dstore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load import checkpoint from datastore: %w", err)
+	}
+
+	var cp importCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal import checkpoint: %w", err)
+	}
+
+	return &cp, nil
+}
+
+func (cs *ChainStore) writeImportCheckpoint(ctx context.Context, cp *importCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal import checkpoint: %w", err)
+	}
+
+	if err := cs.metadataDs.Put(ctx, importCheckpointKey, data); err != nil {
+		return xerrors.Errorf("failed to write import checkpoint to datastore: %w", err)
+	}
+
+	return nil
 }
 
+// Import loads a CAR snapshot into the blockstore and returns its root tipset.
+//
+// Progress is checkpointed in the metadata datastore as blocks are persisted.
+// If a previous import of the same snapshot was interrupted, the blocks that
+// were already written are skipped over and the import picks up where it left
+// off. The checkpoint is removed once the import completes.
 func (cs *ChainStore) Import(ctx context.Context, r io.Reader) (*types.TipSet, error) {
+	return cs.ImportWithOpts(ctx, r, ImportOpts{})
+}
+
+// ImportVerified is like Import, but does not trust the snapshot to be
+// complete. Once all blocks are written, the header chain must link back from
+// the root tipset to genesis and the state tree of the root tipset must be
+// fully loadable from the blockstore.
+//
+// Block data is always checked against its CID before it is written, in both
+// modes.
+func (cs *ChainStore) ImportVerified(ctx context.Context, r io.Reader) (*types.TipSet, error) {
+	return cs.ImportWithOpts(ctx, r, ImportOpts{Verify: true})
+}
+
+// ImportOpts configures the checks ImportWithOpts makes on a snapshot.
+type ImportOpts struct {
+	// Verify checks that the snapshot is complete, see ImportVerified.
+	Verify bool
+	// Manifest, if set, must describe the snapshot. Its roots are checked
+	// before anything is written, its size and digest once all blocks were
+	// read.
+	Manifest *SnapshotManifest
+	// Dedup skips writing blocks that are already in the blockstore, so that
+	// refreshing a node from a newer snapshot only writes what changed.
+	Dedup bool
+	// Workers is the number of goroutines verifying and writing blocks; 0
+	// uses ImportWorkers.
+	Workers int
+	// BufferBytes caps the block data read ahead of the blockstore writes; 0
+	// uses ImportBufferBytes.
+	BufferBytes int64
+}
+
+// ImportWithOpts is like Import, checking the snapshot as configured by opts.
+func (cs *ChainStore) ImportWithOpts(ctx context.Context, r io.Reader, opts ImportOpts) (*types.TipSet, error) {
 	// TODO: writing only to the state blockstore is incorrect.
 	//  At this time, both the state and chain blockstores are backed by the
 	//  universal store. When we physically segregate the stores, we will need
 	//  to route state objects to the state blockstore, and chain objects to
 	//  the chain blockstore.
 
-	br, err := carv2.NewBlockReader(r)
+	var mr *manifestReader
+	if opts.Manifest != nil {
+		mr = newManifestReader(r, opts.Manifest)
+		r = mr
+	}
+
+	ir, err := newImportReader(r)
 	if err != nil {
 		return nil, xerrors.Errorf("loadcar failed: %w", err)
 	}
+	roots := ir.br.Roots
 
-	s := cs.StateBlockstore()
+	if opts.Manifest != nil && !types.CidArrsEqual(roots, opts.Manifest.Roots) {
+		return nil, xerrors.Errorf("snapshot roots %v do not match manifest roots %v", roots, opts.Manifest.Roots)
+	}
 
-	parallelPuts := 5
-	putThrottle := make(chan error, parallelPuts)
-	for i := 0; i < parallelPuts; i++ {
-		putThrottle <- nil
+	cp, err := cs.loadImportCheckpoint(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var buf []blocks.Block
-	for {
-		blk, err := br.Next()
-		if err != nil {
-			if err == io.EOF {
-				if len(buf) > 0 {
-					if err := s.PutMany(ctx, buf); err != nil {
-						return nil, err
-					}
-				}
-
-				break
+	var read uint64
+	if cp != nil && types.CidArrsEqual(cp.Roots, roots) {
+		log.Infow("resuming snapshot import from checkpoint", "blocks", cp.Blocks)
+
+		var last cid.Cid
+		for read < cp.Blocks {
+			sec, err := ir.next()
+			if err != nil {
+				return nil, xerrors.Errorf("skipping blocks covered by import checkpoint: %w", err)
 			}
-			return nil, err
+			last = sec.c
+			read++
 		}
 
-		buf = append(buf, blk)
+		if read > 0 && last != cp.Last {
+			return nil, xerrors.Errorf("snapshot does not match import checkpoint: block %d is %s, expected %s", read, last, cp.Last)
+		}
+	} else if cp != nil {
+		log.Warnw("ignoring import checkpoint for a different snapshot", "roots", cp.Roots)
+	}
 
-		if len(buf) > 1000 {
-			if lastErr := <-putThrottle; lastErr != nil { // consume one error to have the right to add one
-				return nil, lastErr
-			}
+	s := cs.StateBlockstore()
 
-			go func(buf []blocks.Block) {
-				putThrottle <- s.PutMany(ctx, buf)
-			}(buf)
-			buf = nil
+	var dedup *importDedup
+	if opts.Dedup {
+		if dedup, err = newImportDedup(ctx, s); err != nil {
+			return nil, err
 		}
 	}
 
-	// check errors
-	for i := 0; i < parallelPuts; i++ {
-		if lastErr := <-putThrottle; lastErr != nil {
-			return nil, lastErr
+	if err := cs.newImportPipeline(s, roots, dedup, opts).run(ctx, ir, read); err != nil {
+		return nil, err
+	}
+	if dedup != nil {
+		log.Infow("skipped blocks already in the blockstore", "blocks", atomic.LoadUint64(&dedup.skipped))
+	}
+	if mr != nil {
+		if err := mr.check(); err != nil {
+			return nil, xerrors.Errorf("checking snapshot against manifest: %w", err)
 		}
 	}
 
-	root, err := cs.LoadTipSet(ctx, types.NewTipSetKey(br.Roots...))
+	root, err := cs.LoadTipSet(ctx, types.NewTipSetKey(roots...))
 	if err != nil {
 		return nil, xerrors.Errorf("failed to load root tipset from chainfile: %w", err)
 	}
 
+	if opts.Manifest != nil {
+		if err := opts.Manifest.checkRoot(root); err != nil {
+			return nil, xerrors.Errorf("checking snapshot against manifest: %w", err)
+		}
+	}
+
+	if opts.Verify {
+		if err := cs.verifyImport(ctx, root); err != nil {
+			return nil, xerrors.Errorf("verifying imported snapshot: %w", err)
+		}
+	}
+
+	if err := cs.metadataDs.Delete(ctx, importCheckpointKey); err != nil {
+		return nil, xerrors.Errorf("failed to remove import checkpoint: %w", err)
+	}
+
 	return root, nil
 }
 
+// verifyImport checks that the headers of an imported snapshot link back from
+// root to genesis, and that the state tree of root can be fully loaded.
+func (cs *ChainStore) verifyImport(ctx context.Context, root *types.TipSet) error {
+	log.Infow("verifying imported header chain", "height", root.Height())
+	for ts := root; ts.Height() > 0; {
+		pts, err := cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parents of tipset at height %d: %w", ts.Height(), err)
+		}
+		if pts.Height() >= ts.Height() {
+			return xerrors.Errorf("parent tipset at height %d is not below its child at height %d", pts.Height(), ts.Height())
+		}
+		ts = pts
+	}
+
+	log.Infow("verifying imported state", "root", root.ParentState())
+	sw := newLinkWalker(ctx, cs.stateBlockstore, newMemVisitedSet(), ExportWorkers)
+	if _, err := sw.recurse(root.ParentState(), nil); err != nil {
+		return xerrors.Errorf("loading state tree %s: %w", root.ParentState(), err)
+	}
+
+	return nil
+}
+
+// WalkSnapshot calls cb with the blocks of the snapshot of the chain from ts,
+// see ChainWalker for walks with more options.
 func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, cb func(cid.Cid) error) error {
 	if ts == nil {
 		ts = cs.GetHeaviestTipSet()
 	}
 
-	seen := cid.NewSet()
-	walked := cid.NewSet()
-
-	blocksToWalk := ts.Cids()
-	currentMinHeight := ts.Height()
+	return cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, nil, cb)
+}
 
-	walkChain := func(blk cid.Cid) error {
-		if !seen.Visit(blk) {
-			return nil
-		}
+func (cs *ChainStore) walkSnapshot(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, et *exportTracker, filter SnapshotFilter, cb func(cid.Cid) error) error {
+	return cs.walkSnapshotBlocks(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, et, filter, func(c cid.Cid, _ SnapshotBlockType) error {
+		return cb(c)
+	})
+}
 
-		if err := cb(blk); err != nil {
-			return err
-		}
+// walkSnapshotBlocks is walkSnapshot calling cb with the part of the chain
+// each block belongs to.
+func (cs *ChainStore) walkSnapshotBlocks(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, et *exportTracker, filter SnapshotFilter, cb func(cid.Cid, SnapshotBlockType) error) error {
+	w := cs.NewChainWalker(ChainWalkerOpts{
+		StateDepth:      inclRecentRoots,
+		SkipOldMessages: skipOldMsgs,
+		SkipReceipts:    skipMsgReceipts,
+		Filter:          filter,
+	})
+	w.et = et
 
-		data, err := cs.chainBlockstore.Get(ctx, blk)
-		if err != nil {
-			return xerrors.Errorf("getting block: %w", err)
-		}
+	log.Infow("export started")
+	exportStart := build.Clock.Now()
 
-		var b types.BlockHeader
-		if err := b.UnmarshalCBOR(bytes.NewBuffer(data.RawData())); err != nil {
-			return xerrors.Errorf("unmarshaling block header (cid=%s): %w", blk, err)
-		}
+	if err := w.Walk(ctx, ts, ChainVisitorFunc(cb)); err != nil {
+		return err
+	}
 
-		if currentMinHeight > b.Height {
-			currentMinHeight = b.Height
-			if currentMinHeight%builtin.EpochsInDay == 0 {
-				log.Infow("export", "height", currentMinHeight)
-			}
-		}
+	log.Infow("export finished", "duration", build.Clock.Now().Sub(exportStart).Seconds())
 
-		var cids []cid.Cid
-		if !skipOldMsgs || b.Height > ts.Height()-inclRecentRoots {
-			if walked.Visit(b.Messages) {
-				mcids, err := recurseLinks(ctx, cs.chainBlockstore, walked, b.Messages, []cid.Cid{b.Messages})
-				if err != nil {
-					return xerrors.Errorf("recursing messages failed: %w", err)
-				}
-				cids = mcids
-			}
-		}
+	return nil
+}
 
-		if b.Height > 0 {
-			for _, p := range b.Parents {
-				blocksToWalk = append(blocksToWalk, p)
-			}
-		} else {
-			// include the genesis block
-			cids = append(cids, b.Parents...)
-		}
+// exportable reports whether the block c is written to snapshots.
+func exportable(c cid.Cid) bool {
+	prefix := c.Prefix()
 
-		out := cids
+	// Don't include identity CIDs.
+	if prefix.MhType == mh.IDENTITY {
+		return false
+	}
 
-		if b.Height == 0 || b.Height > ts.Height()-inclRecentRoots {
-			if walked.Visit(b.ParentStateRoot) {
-				cids, err := recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentStateRoot, []cid.Cid{b.ParentStateRoot})
-				if err != nil {
-					return xerrors.Errorf("recursing genesis state failed: %w", err)
-				}
+	// We only include raw and dagcbor, for now.
+	// Raw for "code" CIDs.
+	switch prefix.Codec {
+	case cid.Raw, cid.DagCBOR:
+		return true
+	default:
+		return false
+	}
+}
 
-				out = append(out, cids...)
-			}
+// linkScan is the result of fetching a block and scanning it for links. It
+// is filled in by a worker goroutine and done is closed once it is ready.
+type linkScan struct {
+	links []cid.Cid
+	err   error
+	done  chan struct{}
+}
 
-			if !skipMsgReceipts && walked.Visit(b.ParentMessageReceipts) {
-				out = append(out, b.ParentMessageReceipts)
-			}
-		}
+// linkWalker walks DAGs depth first, in the same order as a plain recursive
+// walk, while a pool of workers fetches and scans the children of the blocks
+// on the current path ahead of the walk.
+type linkWalker struct {
+	ctx    context.Context
+	bs     bstore.Blockstore
+	walked visitedSet
+	sem    chan struct{}
+}
 
-		for _, c := range out {
-			if seen.Visit(c) {
-				prefix := c.Prefix()
+func newLinkWalker(ctx context.Context, bs bstore.Blockstore, walked visitedSet, workers int) *linkWalker {
+	if workers < 1 {
+		workers = 1
+	}
 
-				// Don't include identity CIDs.
-				if prefix.MhType == mh.IDENTITY {
-					continue
-				}
+	return &linkWalker{
+		ctx:    ctx,
+		bs:     bs,
+		walked: walked,
+		sem:    make(chan struct{}, workers),
+	}
+}
 
-				// We only include raw and dagcbor, for now.
-				// Raw for "code" CIDs.
-				switch prefix.Codec {
-				case cid.Raw, cid.DagCBOR:
-				default:
-					continue
-				}
+func (lw *linkWalker) scan(c cid.Cid) *linkScan {
+	ls := &linkScan{done: make(chan struct{})}
+	if c.Prefix().Codec != cid.DagCBOR {
+		close(ls.done)
+		return ls
+	}
 
-				if err := cb(c); err != nil {
-					return err
-				}
+	lw.sem <- struct{}{}
+	go func() {
+		defer func() {
+			<-lw.sem
+			close(ls.done)
+		}()
 
-			}
+		data, err := lw.bs.Get(lw.ctx, c)
+		if err != nil {
+			ls.err = xerrors.Errorf("recurse links get (%s) failed: %w", c, err)
+			return
 		}
 
-		return nil
-	}
-
-	log.Infow("export started")
-	exportStart := build.Clock.Now()
-
-	for len(blocksToWalk) > 0 {
-		next := blocksToWalk[0]
-		blocksToWalk = blocksToWalk[1:]
-		if err := walkChain(next); err != nil {
-			return xerrors.Errorf("walk chain failed: %w", err)
+		err = cbg.ScanForLinks(bytes.NewReader(data.RawData()), func(l cid.Cid) {
+			ls.links = append(ls.links, l)
+		})
+		if err != nil {
+			ls.err = xerrors.Errorf("scanning for links failed: %w", err)
 		}
-	}
+	}()
 
-	log.Infow("export finished", "duration", build.Clock.Now().Sub(exportStart).Seconds())
+	return ls
+}
 
-	return nil
+// recurse appends all blocks reachable from root that were not walked yet to
+// in, in depth-first pre-order.
+func (lw *linkWalker) recurse(root cid.Cid, in []cid.Cid) ([]cid.Cid, error) {
+	return lw.walk(lw.scan(root), in)
 }
 
-func recurseLinks(ctx context.Context, bs bstore.Blockstore, walked *cid.Set, root cid.Cid, in []cid.Cid) ([]cid.Cid, error) {
-	if root.Prefix().Codec != cid.DagCBOR {
-		return in, nil
+func (lw *linkWalker) walk(ls *linkScan, in []cid.Cid) ([]cid.Cid, error) {
+	select {
+	case <-ls.done:
+	case <-lw.ctx.Done():
+		return nil, lw.ctx.Err()
 	}
-
-	data, err := bs.Get(ctx, root)
-	if err != nil {
-		return nil, xerrors.Errorf("recurse links get (%s) failed: %w", root, err)
+	if ls.err != nil {
+		return nil, ls.err
 	}
 
-	var rerr error
-	err = cbg.ScanForLinks(bytes.NewReader(data.RawData()), func(c cid.Cid) {
-		if rerr != nil {
-			// No error return on ScanForLinks :(
-			return
+	// start fetching the children we are going to descend into
+	children := make([]*linkScan, len(ls.links))
+	for i, c := range ls.links {
+		has, err := lw.walked.Has(c)
+		if err != nil {
+			return nil, err
 		}
+		if !has {
+			children[i] = lw.scan(c)
+		}
+	}
 
+	for i, c := range ls.links {
 		// traversed this already...
-		if !walked.Visit(c) {
-			return
+		visit, err := lw.walked.Visit(c)
+		if err != nil {
+			return nil, err
+		}
+		if !visit {
+			continue
 		}
 
 		in = append(in, c)
-		var err error
-		in, err = recurseLinks(ctx, bs, walked, c, in)
+		in, err = lw.walk(children[i], in)
 		if err != nil {
-			rerr = err
+			return nil, err
 		}
-	})
-	if err != nil {
-		return nil, xerrors.Errorf("scanning for links failed: %w", err)
 	}
 
-	return in, rerr
+	return in, nil
 }
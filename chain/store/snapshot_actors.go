@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"io"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// tracingStore is a read-only ipld store that records the blocks it loads, in
+// the order they were first loaded.
+type tracingStore struct {
+	bs     bstore.Blockstore
+	loaded []cid.Cid
+}
+
+func (ts *tracingStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := ts.bs.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	ts.loaded = append(ts.loaded, c)
+	return blk, nil
+}
+
+func (ts *tracingStore) Put(context.Context, blocks.Block) error {
+	return xerrors.Errorf("tracing store is read-only")
+}
+
+// WalkActorSnapshot walks a snapshot of the parent state of ts that only
+// contains the given actors. It includes the headers of ts, the parts of the
+// state tree needed to look up the actors, and the complete state of each
+// actor, so that the actors can be loaded from the resulting snapshot as if it
+// contained the whole state tree.
+func (cs *ChainStore) WalkActorSnapshot(ctx context.Context, ts *types.TipSet, actors []address.Address, cb func(cid.Cid) error) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	seen := cid.NewSet()
+	visit := func(c cid.Cid) error {
+		if !seen.Visit(c) || !exportable(c) {
+			return nil
+		}
+
+		return cb(c)
+	}
+
+	for _, c := range ts.Cids() {
+		if err := visit(c); err != nil {
+			return err
+		}
+	}
+
+	tracer := &tracingStore{bs: cs.stateBlockstore}
+	st, err := state.LoadStateTree(cbor.NewCborStore(tracer), ts.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	var acts []*types.Actor
+	for _, addr := range actors {
+		act, err := st.GetActor(addr)
+		if err != nil {
+			return xerrors.Errorf("loading actor %s: %w", addr, err)
+		}
+		acts = append(acts, act)
+	}
+
+	// the state root and the path through the actors HAMT to each actor
+	for _, c := range tracer.loaded {
+		if err := visit(c); err != nil {
+			return err
+		}
+	}
+
+	walked := newMemVisitedSet()
+	sw := newLinkWalker(ctx, cs.stateBlockstore, walked, ExportWorkers)
+	for i, act := range acts {
+		has, err := cs.stateBlockstore.Has(ctx, act.Code)
+		if err != nil {
+			return xerrors.Errorf("checking for actor code: %w", err)
+		}
+		if has {
+			if err := visit(act.Code); err != nil {
+				return err
+			}
+		}
+
+		if visit, _ := walked.Visit(act.Head); !visit {
+			continue
+		}
+		cids, err := sw.recurse(act.Head, []cid.Cid{act.Head})
+		if err != nil {
+			return xerrors.Errorf("recursing state of actor %s failed: %w", actors[i], err)
+		}
+		for _, c := range cids {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExportActors writes a CARv1 snapshot rooted at ts that contains only the
+// state of the given actors, see WalkActorSnapshot.
+func (cs *ChainStore) ExportActors(ctx context.Context, ts *types.TipSet, actors []address.Address, w io.Writer) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	h := &car.CarHeader{
+		Roots:   ts.Cids(),
+		Version: 1,
+	}
+
+	if err := car.WriteHeader(h, w); err != nil {
+		return xerrors.Errorf("failed to write car header: %s", err)
+	}
+
+	unionBs := cs.UnionStore()
+	return cs.WalkActorSnapshot(ctx, ts, actors, func(c cid.Cid) error {
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("failed to write block to car output: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExportActors(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cst := cbor.NewCborStore(bs)
+
+	st, err := state.NewStateTree(cst, types.StateTreeVersion4)
+	require.NoError(t, err)
+
+	heads := map[address.Address]cid.Cid{}
+	children := map[address.Address]cid.Cid{}
+	for i := uint64(0); i < 1000; i++ {
+		addr, err := address.NewIDAddress(1000 + i)
+		require.NoError(t, err)
+
+		child, err := cst.Put(ctx, map[string]uint64{"child": i})
+		require.NoError(t, err)
+		head, err := cst.Put(ctx, map[string]interface{}{"id": i, "child": child})
+		require.NoError(t, err)
+
+		require.NoError(t, st.SetActor(addr, &types.Actor{
+			Code:    builtin2.AccountActorCodeID,
+			Head:    head,
+			Balance: types.NewInt(i),
+		}))
+		heads[addr] = head
+		children[addr] = child
+	}
+
+	root, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.ParentStateRoot = root
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	ts := mock.TipSet(blk)
+
+	target, err := address.NewIDAddress(1042)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cs.ExportActors(ctx, ts, []address.Address{target}, &buf))
+
+	ebs := blockstore.NewMemorySync()
+	hdr, err := car.LoadCar(ctx, ebs, &buf)
+	require.NoError(t, err)
+	require.Equal(t, ts.Cids(), hdr.Roots)
+
+	// the exported actor can be loaded from the snapshot with its full state
+	est, err := state.LoadStateTree(cbor.NewCborStore(ebs), root)
+	require.NoError(t, err)
+	act, err := est.GetActor(target)
+	require.NoError(t, err)
+	require.Equal(t, heads[target], act.Head)
+
+	for _, c := range []cid.Cid{blk.Cid(), heads[target], children[target]} {
+		has, err := ebs.Has(ctx, c)
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	// while the state of other actors is left out
+	for addr, head := range heads {
+		if addr == target {
+			continue
+		}
+		has, err := ebs.Has(ctx, head)
+		require.NoError(t, err)
+		require.False(t, has)
+	}
+}
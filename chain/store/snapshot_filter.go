@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SnapshotBlockType is the part of the chain a block of a snapshot walk
+// belongs to.
+type SnapshotBlockType int
+
+const (
+	// SnapshotHeader is a block header, including the genesis block.
+	SnapshotHeader SnapshotBlockType = iota
+	// SnapshotMessages is part of the messages of a block.
+	SnapshotMessages
+	// SnapshotReceipts is part of the message receipts of a tipset.
+	SnapshotReceipts
+	// SnapshotState is part of a state tree, including actor code and state.
+	SnapshotState
+)
+
+func (bt SnapshotBlockType) String() string {
+	switch bt {
+	case SnapshotHeader:
+		return "header"
+	case SnapshotMessages:
+		return "messages"
+	case SnapshotReceipts:
+		return "receipts"
+	case SnapshotState:
+		return "state"
+	default:
+		return "unknown"
+	}
+}
+
+// SnapshotFilter selects the blocks of a snapshot walk that are handed to the
+// walk callback. Filtered out blocks are still traversed, so the blocks they
+// link to are walked as usual.
+type SnapshotFilter func(c cid.Cid, codec uint64, bt SnapshotBlockType) bool
+
+func includeAll(cid.Cid, uint64, SnapshotBlockType) bool {
+	return true
+}
+
+// SnapshotTypes returns a filter that only includes blocks of the given types,
+// e.g. SnapshotTypes(SnapshotHeader) for a headers-only walk.
+func SnapshotTypes(bts ...SnapshotBlockType) SnapshotFilter {
+	return func(_ cid.Cid, _ uint64, bt SnapshotBlockType) bool {
+		for _, t := range bts {
+			if bt == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WalkSnapshotFiltered is like WalkSnapshot, but only calls cb for the blocks
+// filter selects.
+func (cs *ChainStore) WalkSnapshotFiltered(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, filter SnapshotFilter, cb func(cid.Cid) error) error {
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	return cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, filter, cb)
+}
+
+// SnapshotCodecs returns a filter that only includes blocks of the given
+// codecs, e.g. SnapshotCodecs(cid.Raw) for the code of the actors.
+func SnapshotCodecs(codecs ...uint64) SnapshotFilter {
+	return func(_ cid.Cid, codec uint64, _ SnapshotBlockType) bool {
+		for _, c := range codecs {
+			if codec == c {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,79 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestWalkSnapshotFiltered(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(v, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	sleaf := put(map[string]interface{}{"state": 0})
+	st := put(map[string]interface{}{"root": sleaf})
+	mleaf := put(map[string]interface{}{"msg": 0})
+	msgs := put(map[string]interface{}{"msgs": []cid.Cid{mleaf}})
+	rcpts := put(map[string]interface{}{"receipts": true})
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = st
+	gen.Messages = msgs
+	gen.ParentMessageReceipts = rcpts
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+
+	blk := mock.MkBlock(mock.TipSet(gen), 1, 1)
+	blk.ParentStateRoot = st
+	blk.Messages = msgs
+	blk.ParentMessageReceipts = rcpts
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	ts := mock.TipSet(blk)
+
+	walk := func(filter store.SnapshotFilter) []cid.Cid {
+		var out []cid.Cid
+		require.NoError(t, cs.WalkSnapshotFiltered(ctx, ts, 0, false, false, filter, func(c cid.Cid) error {
+			out = append(out, c)
+			return nil
+		}))
+		return out
+	}
+
+	require.ElementsMatch(t, []cid.Cid{blk.Cid(), gen.Cid()}, walk(store.SnapshotTypes(store.SnapshotHeader)))
+	require.ElementsMatch(t, []cid.Cid{msgs, mleaf}, walk(store.SnapshotTypes(store.SnapshotMessages)))
+	require.ElementsMatch(t, []cid.Cid{rcpts}, walk(store.SnapshotTypes(store.SnapshotReceipts)))
+	require.ElementsMatch(t, []cid.Cid{st, sleaf}, walk(store.SnapshotTypes(store.SnapshotState)))
+
+	// filters see the codec of each block
+	require.Empty(t, walk(func(_ cid.Cid, codec uint64, _ store.SnapshotBlockType) bool {
+		return codec == cid.Raw
+	}))
+
+	// a filter that includes everything matches the unfiltered walk
+	var all []cid.Cid
+	require.NoError(t, cs.WalkSnapshot(ctx, ts, 0, false, false, func(c cid.Cid) error {
+		all = append(all, c)
+		return nil
+	}))
+	require.Equal(t, all, walk(store.SnapshotTypes(store.SnapshotHeader, store.SnapshotMessages, store.SnapshotReceipts, store.SnapshotState)))
+}
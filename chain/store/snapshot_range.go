@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// rangeTipSets returns the tipsets of the chain ending in head with heights in
+// [start, end], from the newest to the oldest.
+func (cs *ChainStore) rangeTipSets(ctx context.Context, head *types.TipSet, start, end abi.ChainEpoch) ([]*types.TipSet, error) {
+	if start < 0 || start > end {
+		return nil, xerrors.Errorf("invalid epoch range [%d, %d]", start, end)
+	}
+	if end > head.Height() {
+		return nil, xerrors.Errorf("end of range %d is above the head at %d", end, head.Height())
+	}
+
+	ts, err := cs.GetTipsetByHeight(ctx, end, head, true)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at end of range: %w", err)
+	}
+
+	var tss []*types.TipSet
+	for ts.Height() >= start {
+		tss = append(tss, ts)
+		if ts.Height() == 0 {
+			break
+		}
+		if ts, err = cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+			return nil, xerrors.Errorf("loading parents of tipset at height %d: %w", tss[len(tss)-1].Height(), err)
+		}
+	}
+	if len(tss) == 0 {
+		return nil, xerrors.Errorf("no tipsets in range [%d, %d]", start, end)
+	}
+
+	return tss, nil
+}
+
+// WalkSnapshotRange walks a chain segment: the headers and messages of every
+// tipset of the chain ending in head with a height in [start, end], and the
+// parent state of the oldest of them, so that the segment can be replayed
+// without the rest of the chain. Message receipts are included unless
+// skipMsgReceipts is set.
+func (cs *ChainStore) WalkSnapshotRange(ctx context.Context, head *types.TipSet, start, end abi.ChainEpoch, skipMsgReceipts bool, cb func(cid.Cid) error) error {
+	if head == nil {
+		head = cs.GetHeaviestTipSet()
+	}
+
+	tss, err := cs.rangeTipSets(ctx, head, start, end)
+	if err != nil {
+		return err
+	}
+
+	return cs.walkRange(ctx, tss, skipMsgReceipts, nil, func(c cid.Cid, _ SnapshotBlockType) error {
+		return cb(c)
+	})
+}
+
+// walkRange walks the segment of tss, calling cb with the part of the chain
+// each block belongs to.
+func (cs *ChainStore) walkRange(ctx context.Context, tss []*types.TipSet, skipMsgReceipts bool, et *exportTracker, cb func(cid.Cid, SnapshotBlockType) error) error {
+	seen, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return err
+	}
+	defer seen.Close() //nolint:errcheck
+	walked, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return err
+	}
+	defer walked.Close() //nolint:errcheck
+
+	msgWalker := newLinkWalker(ctx, cs.chainBlockstore, walked, ExportWorkers)
+	stateWalker := newLinkWalker(ctx, cs.stateBlockstore, walked, ExportWorkers)
+
+	emit := func(cids []cid.Cid, bt SnapshotBlockType) error {
+		for _, c := range cids {
+			visit, err := seen.Visit(c)
+			if err != nil {
+				return err
+			}
+			if !visit || !exportable(c) {
+				continue
+			}
+			if err := cb(c, bt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	walk := func(lw *linkWalker, root cid.Cid, bt SnapshotBlockType) error {
+		visit, err := walked.Visit(root)
+		if !visit || err != nil {
+			return err
+		}
+		cids, err := lw.recurse(root, []cid.Cid{root})
+		if err != nil {
+			return err
+		}
+		return emit(cids, bt)
+	}
+
+	for _, ts := range tss {
+		et.walked(ts.Height())
+		if err := emit(ts.Cids(), SnapshotHeader); err != nil {
+			return err
+		}
+
+		for _, b := range ts.Blocks() {
+			if err := walk(msgWalker, b.Messages, SnapshotMessages); err != nil {
+				return xerrors.Errorf("recursing messages failed: %w", err)
+			}
+			if !skipMsgReceipts {
+				if err := walk(stateWalker, b.ParentMessageReceipts, SnapshotReceipts); err != nil {
+					return xerrors.Errorf("recursing message receipts failed: %w", err)
+				}
+			}
+		}
+	}
+
+	oldest := tss[len(tss)-1]
+	if err := walk(stateWalker, oldest.ParentState(), SnapshotState); err != nil {
+		return xerrors.Errorf("recursing parent state of tipset at height %d failed: %w", oldest.Height(), err)
+	}
+
+	return nil
+}
+
+// ExportRange writes a CARv1 chain segment, see WalkSnapshotRange. The roots of
+// the CAR are the blocks of every tipset in the range, newest first. Progress
+// of the export is published to SubExportProgress subscribers.
+func (cs *ChainStore) ExportRange(ctx context.Context, head *types.TipSet, start, end abi.ChainEpoch, skipMsgReceipts bool, w io.Writer) error {
+	return cs.ExportRangeFiltered(ctx, head, start, end, skipMsgReceipts, nil, ExportThrottle{}, w)
+}
+
+// ExportRangeFiltered is like ExportRange, but only writes the blocks filter
+// selects, all of them when it is nil, and throttles the export as configured
+// by throttle.
+func (cs *ChainStore) ExportRangeFiltered(ctx context.Context, head *types.TipSet, start, end abi.ChainEpoch, skipMsgReceipts bool, filter SnapshotFilter, throttle ExportThrottle, w io.Writer) error {
+	if head == nil {
+		head = cs.GetHeaviestTipSet()
+	}
+
+	tss, err := cs.rangeTipSets(ctx, head, start, end)
+	if err != nil {
+		return err
+	}
+
+	var roots []cid.Cid
+	for _, ts := range tss {
+		roots = append(roots, ts.Cids()...)
+	}
+
+	h := &car.CarHeader{
+		Roots:   roots,
+		Version: 1,
+	}
+
+	if err := car.WriteHeader(h, w); err != nil {
+		return xerrors.Errorf("failed to write car header: %s", err)
+	}
+
+	et := cs.trackExport(head)
+	et.bottom = start
+	th := cs.newExportThrottle(throttle)
+	unionBs := cs.UnionStore()
+	err = cs.walkRange(ctx, tss, skipMsgReceipts, et, func(c cid.Cid, bt SnapshotBlockType) error {
+		if filter != nil && !filter(c, c.Prefix().Codec, bt) {
+			return nil
+		}
+
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := th.wait(ctx, len(blk.RawData())); err != nil {
+			return err
+		}
+
+		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("failed to write block to car output: %w", err)
+		}
+		et.wrote(carutil.LdSize(c.Bytes(), blk.RawData()))
+
+		return nil
+	})
+	et.finish(err)
+	return err
+}
@@ -0,0 +1,117 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExportRange(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(v, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	// every block has its own messages, receipts and state; height 5 is a
+	// null round
+	states := map[abi.ChainEpoch]cid.Cid{}
+	msgs := map[abi.ChainEpoch]cid.Cid{}
+	rcpts := map[abi.ChainEpoch]cid.Cid{}
+	hdrs := map[abi.ChainEpoch]cid.Cid{}
+
+	var ts *types.TipSet
+	for h := abi.ChainEpoch(0); h <= 8; h++ {
+		if h == 5 {
+			continue
+		}
+		blk := mock.MkBlock(ts, 1, 1)
+		blk.Height = h
+		blk.ParentStateRoot = put(map[string]interface{}{"state": int64(h)})
+		blk.Messages = put(map[string]interface{}{"msgs": int64(h)})
+		blk.ParentMessageReceipts = put(map[string]interface{}{"receipts": int64(h)})
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+
+		states[h], msgs[h], rcpts[h], hdrs[h] = blk.ParentStateRoot, blk.Messages, blk.ParentMessageReceipts, blk.Cid()
+		ts = mock.TipSet(blk)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cs.ExportRange(ctx, ts, 4, 6, false, &buf))
+
+	br, err := car.NewCarReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{hdrs[6], hdrs[4]}, br.Header.Roots)
+
+	got := cid.NewSet()
+	for {
+		b, err := br.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got.Add(b.Cid())
+	}
+
+	for h := abi.ChainEpoch(0); h <= 8; h++ {
+		if h == 5 {
+			continue
+		}
+		in := h == 4 || h == 6
+		require.Equal(t, in, got.Has(hdrs[h]), "header %d", h)
+		require.Equal(t, in, got.Has(msgs[h]), "messages %d", h)
+		require.Equal(t, in, got.Has(rcpts[h]), "receipts %d", h)
+		// only the state the segment starts from
+		require.Equal(t, h == 4, got.Has(states[h]), "state %d", h)
+	}
+
+	require.Error(t, cs.ExportRange(ctx, ts, 6, 4, false, io.Discard))
+	require.Error(t, cs.ExportRange(ctx, ts, 4, 9, false, io.Discard))
+
+	// a filtered segment only holds the blocks selected
+	buf.Reset()
+	require.NoError(t, cs.ExportRangeFiltered(ctx, ts, 4, 6, false, store.SnapshotTypes(store.SnapshotReceipts), store.ExportThrottle{}, &buf))
+	br, err = car.NewCarReader(&buf)
+	require.NoError(t, err)
+	var filtered []cid.Cid
+	for {
+		b, err := br.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		filtered = append(filtered, b.Cid())
+	}
+	require.ElementsMatch(t, []cid.Cid{rcpts[6], rcpts[4]}, filtered)
+
+	buf.Reset()
+	require.NoError(t, cs.ExportRangeFiltered(ctx, ts, 4, 6, false, store.SnapshotCodecs(cid.Raw), store.ExportThrottle{}, &buf))
+	br, err = car.NewCarReader(&buf)
+	require.NoError(t, err)
+	_, err = br.Next()
+	require.Equal(t, io.EOF, err)
+}
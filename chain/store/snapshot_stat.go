@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	carbs "github.com/ipld/go-car/v2/blockstore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SnapshotStat describes the contents of a snapshot CAR.
+type SnapshotStat struct {
+	Roots []cid.Cid
+
+	// Head is the highest tipset of the roots of the CAR, the tipsets below it are
+	// followed through their parents for as long as their headers are in the
+	// CAR.
+	Head        types.TipSetKey
+	HeadEpoch   abi.ChainEpoch
+	OldestEpoch abi.ChainEpoch
+	TipSets     int
+
+	// StateRoots is the number of tipsets whose parent state root is in the
+	// CAR, OldestStateEpoch the height of the oldest of them.
+	StateRoots       int
+	OldestStateEpoch abi.ChainEpoch
+
+	// Messages is the number of distinct messages included in the tipsets
+	// whose messages are in the CAR.
+	Messages int
+
+	Blocks uint64
+	Bytes  uint64
+	// Codecs breaks Blocks and Bytes down by the codec of the blocks.
+	Codecs map[uint64]SnapshotCodecStat
+}
+
+type SnapshotCodecStat struct {
+	Blocks uint64
+	Bytes  uint64
+}
+
+// StatSnapshot analyzes the snapshot CAR at path without importing it. The CAR
+// may be a CARv1 or a CARv2 file, but not compressed, as the chain is read
+// from it in place.
+func StatSnapshot(ctx context.Context, path string) (*SnapshotStat, error) {
+	return statSnapshot(ctx, path, nil)
+}
+
+// statSnapshot is StatSnapshot, calling visit with every block of the CAR as
+// they are counted, when it is set.
+func statSnapshot(ctx context.Context, path string, visit func(c cid.Cid, data []byte)) (*SnapshotStat, error) {
+	st := &SnapshotStat{Codecs: make(map[uint64]SnapshotCodecStat)}
+	if err := st.countBlocks(path, visit); err != nil {
+		return nil, err
+	}
+
+	cbs, err := carbs.OpenReadOnly(path, carbs.UseWholeCIDs(true))
+	if err != nil {
+		return nil, xerrors.Errorf("opening car: %w", err)
+	}
+	defer cbs.Close() //nolint:errcheck
+
+	if st.Roots, err = cbs.Roots(); err != nil {
+		return nil, xerrors.Errorf("reading car roots: %w", err)
+	}
+
+	bs := bstore.Adapt(cbs)
+	cs := NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts, err := snapshotHead(ctx, cs, st.Roots)
+	if err != nil {
+		return nil, xerrors.Errorf("loading head tipset from car roots: %w", err)
+	}
+	st.Head, st.HeadEpoch = ts.Key(), ts.Height()
+
+	msgs := cid.NewSet()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		st.TipSets++
+		st.OldestEpoch = ts.Height()
+
+		has, err := bs.Has(ctx, ts.ParentState())
+		if err != nil {
+			return nil, err
+		}
+		if has {
+			st.StateRoots++
+			st.OldestStateEpoch = ts.Height()
+		}
+
+		for _, b := range ts.Blocks() {
+			has, err := bs.Has(ctx, b.Messages)
+			if err != nil {
+				return nil, err
+			}
+			if !has {
+				continue
+			}
+			bls, secpk, err := cs.ReadMsgMetaCids(ctx, b.Messages)
+			if err != nil {
+				return nil, xerrors.Errorf("reading messages of block %s: %w", b.Cid(), err)
+			}
+			for _, c := range bls {
+				msgs.Add(c)
+			}
+			for _, c := range secpk {
+				msgs.Add(c)
+			}
+		}
+
+		if ts.Height() == 0 {
+			break
+		}
+		has, err = allBlocksIn(ctx, bs, ts.Parents())
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			break
+		}
+		if ts, err = cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+			return nil, xerrors.Errorf("loading parents of tipset at height %d: %w", st.OldestEpoch, err)
+		}
+	}
+	st.Messages = msgs.Len()
+
+	return st, nil
+}
+
+func (st *SnapshotStat) countBlocks(path string, visit func(c cid.Cid, data []byte)) error {
+	fi, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fi.Close() //nolint:errcheck
+
+	ir, err := newImportReader(fi)
+	if err != nil {
+		return xerrors.Errorf("reading car header: %w", err)
+	}
+
+	for {
+		sec, err := ir.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("reading car: %w", err)
+		}
+
+		if visit != nil {
+			visit(sec.c, sec.data)
+		}
+
+		codec := sec.c.Prefix().Codec
+		cst := st.Codecs[codec]
+		cst.Blocks++
+		cst.Bytes += uint64(len(sec.data))
+		st.Codecs[codec] = cst
+		st.Blocks++
+		st.Bytes += uint64(len(sec.data))
+	}
+}
+
+// snapshotHead returns the highest tipset among roots. Chain segments have the
+// blocks of several tipsets as their roots.
+func snapshotHead(ctx context.Context, cs *ChainStore, roots []cid.Cid) (*types.TipSet, error) {
+	var head []cid.Cid
+	var height abi.ChainEpoch
+	for _, c := range roots {
+		b, err := cs.GetBlock(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(head) == 0 || b.Height > height:
+			head, height = []cid.Cid{c}, b.Height
+		case b.Height == height:
+			head = append(head, c)
+		}
+	}
+	if len(head) == 0 {
+		return nil, xerrors.Errorf("car has no roots")
+	}
+	return cs.LoadTipSet(ctx, types.NewTipSetKey(head...))
+}
+
+func allBlocksIn(ctx context.Context, bs bstore.Blockstore, tsk types.TipSetKey) (bool, error) {
+	for _, c := range tsk.Cids() {
+		has, err := bs.Has(ctx, c)
+		if err != nil || !has {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,122 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestStatSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(v, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	amt := func(msgs ...*types.Message) cid.Cid {
+		arr := blockadt.MakeEmptyArray(cs.ActorStore(ctx))
+		for i, m := range msgs {
+			c, err := cs.PutMessage(ctx, m)
+			require.NoError(t, err)
+			cc := cbg.CborCid(c)
+			require.NoError(t, arr.Set(uint64(i), &cc))
+		}
+		root, err := arr.Root()
+		require.NoError(t, err)
+		return root
+	}
+
+	meta := func(bls, secpk cid.Cid) cid.Cid {
+		c, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: bls, SecpkMessages: secpk})
+		require.NoError(t, err)
+		return c
+	}
+
+	from, to := mock.Address(100), mock.Address(101)
+	m0, m1, m2 := mock.UnsignedMessage(from, to, 0), mock.UnsignedMessage(from, to, 1), mock.UnsignedMessage(from, to, 2)
+
+	// the state of heights 2 and up is in the car, messages are in heights 3
+	// and 4, with m1 in both
+	var ts *types.TipSet
+	for h := abi.ChainEpoch(0); h <= 4; h++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		if h >= 2 {
+			blk.ParentStateRoot = put(map[string]interface{}{"state": int64(h)})
+		}
+		switch h {
+		case 3:
+			blk.Messages = meta(amt(m0, m1), amt())
+		case 4:
+			blk.Messages = meta(amt(), amt(m1, m2))
+		}
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+	}
+
+	// the genesis header is missing from the car, so the walk stops at height 1
+	gen, err := cs.GetTipsetByHeight(ctx, 0, ts, false)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "snapshot.car")
+	fi, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: ts.Cids(), Version: 1}, fi))
+
+	keys, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	var blocks, size uint64
+	for c := range keys {
+		if c == gen.Cids()[0] {
+			continue
+		}
+		blk, err := bs.Get(ctx, c)
+		require.NoError(t, err)
+		require.NoError(t, carutil.LdWrite(fi, c.Bytes(), blk.RawData()))
+		blocks++
+		size += uint64(len(blk.RawData()))
+	}
+	require.NoError(t, fi.Close())
+
+	st, err := store.StatSnapshot(ctx, path)
+	require.NoError(t, err)
+
+	require.Equal(t, ts.Key(), st.Head)
+	require.Equal(t, abi.ChainEpoch(4), st.HeadEpoch)
+	require.Equal(t, abi.ChainEpoch(1), st.OldestEpoch)
+	require.Equal(t, 4, st.TipSets)
+	require.Equal(t, 3, st.StateRoots)
+	require.Equal(t, abi.ChainEpoch(2), st.OldestStateEpoch)
+	require.Equal(t, 3, st.Messages)
+
+	require.Equal(t, blocks, st.Blocks)
+	require.Equal(t, size, st.Bytes)
+	require.Len(t, st.Codecs, 1)
+	require.Equal(t, blocks, st.Codecs[cid.DagCBOR].Blocks)
+}
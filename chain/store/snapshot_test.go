@@ -0,0 +1,367 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type countingBlockstore struct {
+	blockstore.Blockstore
+	puts int64
+}
+
+func (cb *countingBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	atomic.AddInt64(&cb.puts, int64(len(blks)))
+	return cb.Blockstore.PutMany(ctx, blks)
+}
+
+type failingReader struct {
+	r     io.Reader
+	limit int
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+	if fr.limit <= 0 {
+		return 0, errors.New("connection reset")
+	}
+	if len(p) > fr.limit {
+		p = p[:fr.limit]
+	}
+	n, err := fr.r.Read(p)
+	fr.limit -= n
+	return n, err
+}
+
+func TestImportResume(t *testing.T) {
+	ctx := context.Background()
+
+	hdr := mock.MkBlock(nil, 1, 1)
+	hblk, err := hdr.ToStorageBlock()
+	require.NoError(t, err)
+
+	blks := []blocks.Block{hblk}
+	for i := 0; i < 5000; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	var snap bytes.Buffer
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{hdr.Cid()}, Version: 1}, &snap))
+	for _, b := range blks {
+		require.NoError(t, carutil.LdWrite(&snap, b.Cid().Bytes(), b.RawData()))
+	}
+
+	bs := &countingBlockstore{Blockstore: blockstore.NewMemorySync()}
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	cs := store.NewChainStore(bs, bs, ds, nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	// interrupt the import part way through the snapshot
+	_, err = cs.Import(ctx, &failingReader{r: bytes.NewReader(snap.Bytes()), limit: snap.Len() / 2})
+	require.Error(t, err)
+	require.Greater(t, atomic.LoadInt64(&bs.puts), int64(0))
+
+	ts, err := cs.Import(ctx, bytes.NewReader(snap.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, hdr.Cid(), ts.Cids()[0])
+
+	// the second import only wrote the blocks the first one did not persist
+	require.Equal(t, int64(len(blks)), atomic.LoadInt64(&bs.puts))
+
+	for _, b := range blks {
+		has, err := bs.Has(ctx, b.Cid())
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	// the checkpoint is cleared once the import completes
+	atomic.StoreInt64(&bs.puts, 0)
+	_, err = cs.Import(ctx, bytes.NewReader(snap.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blks)), atomic.LoadInt64(&bs.puts))
+}
+
+func TestImportVerified(t *testing.T) {
+	ctx := context.Background()
+
+	leaf, err := cbor.WrapObject(map[string]interface{}{"leaf": true}, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	st, err := cbor.WrapObject(map[string]interface{}{"root": leaf.Cid()}, mh.SHA2_256, -1)
+	require.NoError(t, err)
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = st.Cid()
+	hdrs := []*types.BlockHeader{gen}
+	ts := mock.TipSet(gen)
+	for i := 0; i < 5; i++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		hdrs = append(hdrs, blk)
+		ts = mock.TipSet(blk)
+	}
+
+	var blks []blocks.Block
+	for i := len(hdrs) - 1; i >= 0; i-- {
+		hblk, err := hdrs[i].ToStorageBlock()
+		require.NoError(t, err)
+		blks = append(blks, hblk)
+	}
+
+	mkCar := func(blks ...blocks.Block) []byte {
+		var snap bytes.Buffer
+		require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: ts.Cids(), Version: 1}, &snap))
+		for _, b := range blks {
+			require.NoError(t, carutil.LdWrite(&snap, b.Cid().Bytes(), b.RawData()))
+		}
+		return snap.Bytes()
+	}
+
+	importCar := func(snap []byte) (*types.TipSet, error) {
+		bs := blockstore.NewMemorySync()
+		cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+		defer cs.Close() //nolint:errcheck
+		return cs.ImportVerified(ctx, bytes.NewReader(snap))
+	}
+
+	root, err := importCar(mkCar(append(blks, st, leaf)...))
+	require.NoError(t, err)
+	require.Equal(t, ts.Key(), root.Key())
+
+	// block data that does not hash to its CID
+	bad, err := blocks.NewBlockWithCid([]byte("not the leaf"), leaf.Cid())
+	require.NoError(t, err)
+	_, err = importCar(mkCar(append(blks, st, bad)...))
+	require.Error(t, err)
+
+	// incomplete state tree
+	_, err = importCar(mkCar(append(blks, st)...))
+	require.ErrorContains(t, err, "loading state tree")
+
+	// broken header chain
+	_, err = importCar(mkCar(append(append([]blocks.Block{}, blks[:3]...), st, leaf)...))
+	require.ErrorContains(t, err, "loading parents")
+}
+
+func TestEstimateExportSize(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	est, err := cs.EstimateExportSize(ctx, ts, 0, true, true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cs.Export(ctx, ts, 0, true, true, &buf))
+
+	require.Equal(t, uint64(12), est.Blocks)
+	require.Equal(t, uint64(buf.Len()), est.Bytes)
+}
+
+func TestExportReceipts(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(v, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	st := put(map[string]interface{}{})
+	msgs := put(map[string]interface{}{"msgs": true})
+	rleaf := put(map[string]interface{}{"receipt": 0})
+	rcpts := put(map[string]interface{}{"receipts": []cid.Cid{rleaf}})
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = st
+	gen.Messages = msgs
+	gen.ParentMessageReceipts = rcpts
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+
+	blk := mock.MkBlock(mock.TipSet(gen), 1, 1)
+	blk.Messages = msgs
+	blk.ParentMessageReceipts = rcpts
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	ts := mock.TipSet(blk)
+
+	exported := func(skipMsgReceipts bool) *cid.Set {
+		var buf bytes.Buffer
+		require.NoError(t, cs.Export(ctx, ts, 0, false, skipMsgReceipts, &buf))
+
+		br, err := car.NewCarReader(&buf)
+		require.NoError(t, err)
+
+		set := cid.NewSet()
+		for {
+			b, err := br.Next()
+			if err == io.EOF {
+				return set
+			}
+			require.NoError(t, err)
+			set.Add(b.Cid())
+		}
+	}
+
+	with := exported(false)
+	require.True(t, with.Has(msgs))
+	require.True(t, with.Has(rcpts))
+	require.True(t, with.Has(rleaf))
+
+	without := exported(true)
+	require.True(t, without.Has(msgs))
+	require.False(t, without.Has(rcpts))
+	require.False(t, without.Has(rleaf))
+}
+
+func TestImportManifest(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts := mockExportChain(ctx, t, bs, cs, 10)
+
+	var buf bytes.Buffer
+	mw := store.NewManifestWriter(&buf)
+	require.NoError(t, cs.Export(ctx, ts, 0, true, true, mw))
+	m := mw.Manifest(ts, 0)
+
+	require.Equal(t, ts.Cids(), m.Roots)
+	require.Equal(t, ts.Height(), m.HeadEpoch)
+	require.Equal(t, abi.ChainEpoch(0), m.OldestStateEpoch)
+	require.Equal(t, ts.Height()-3, mw.Manifest(ts, 4).OldestStateEpoch)
+	require.Equal(t, uint64(buf.Len()), m.Size)
+
+	path := filepath.Join(t.TempDir(), "snapshot.car"+store.ManifestSuffix)
+	require.NoError(t, store.WriteManifest(path, m))
+	m, err := store.ReadManifest(path)
+	require.NoError(t, err)
+
+	importCar := func(m *store.SnapshotManifest) error {
+		bs := blockstore.NewMemorySync()
+		cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+		defer cs.Close() //nolint:errcheck
+		_, err := cs.ImportWithOpts(ctx, bytes.NewReader(buf.Bytes()), store.ImportOpts{Manifest: m})
+		return err
+	}
+
+	require.NoError(t, importCar(m))
+
+	bad := *m
+	bad.SHA256 = fmt.Sprintf("%064x", 0)
+	require.ErrorContains(t, importCar(&bad), "sha256")
+
+	bad = *m
+	bad.Size--
+	require.ErrorContains(t, importCar(&bad), "larger")
+
+	bad = *m
+	bad.Roots = ts.Parents().Cids()
+	require.ErrorContains(t, importCar(&bad), "roots")
+}
+
+func TestImportDedup(t *testing.T) {
+	ctx := context.Background()
+
+	hdr := mock.MkBlock(nil, 1, 1)
+	hblk, err := hdr.ToStorageBlock()
+	require.NoError(t, err)
+
+	blks := []blocks.Block{hblk}
+	for i := 0; i < 3000; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	var snap bytes.Buffer
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{hdr.Cid()}, Version: 1}, &snap))
+	for _, b := range blks {
+		require.NoError(t, carutil.LdWrite(&snap, b.Cid().Bytes(), b.RawData()))
+	}
+
+	// the node already has every other block of the snapshot
+	bs := &countingBlockstore{Blockstore: blockstore.NewMemorySync()}
+	for i := 0; i < len(blks); i += 2 {
+		require.NoError(t, bs.Blockstore.Put(ctx, blks[i]))
+	}
+
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	ts, err := cs.ImportWithOpts(ctx, bytes.NewReader(snap.Bytes()), store.ImportOpts{Dedup: true})
+	require.NoError(t, err)
+	require.Equal(t, hdr.Cid(), ts.Cids()[0])
+
+	require.Equal(t, int64(len(blks)/2), atomic.LoadInt64(&bs.puts))
+	for _, b := range blks {
+		has, err := bs.Has(ctx, b.Cid())
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+}
+
+func TestImportPipelineLimits(t *testing.T) {
+	ctx := context.Background()
+
+	hdr := mock.MkBlock(nil, 1, 1)
+	hblk, err := hdr.ToStorageBlock()
+	require.NoError(t, err)
+
+	blks := []blocks.Block{hblk, blocks.NewBlock(bytes.Repeat([]byte("large"), 1000))}
+	for i := 0; i < 3000; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	var snap bytes.Buffer
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{hdr.Cid()}, Version: 1}, &snap))
+	for _, b := range blks {
+		require.NoError(t, carutil.LdWrite(&snap, b.Cid().Bytes(), b.RawData()))
+	}
+
+	for _, opts := range []store.ImportOpts{
+		{Workers: 1, BufferBytes: 1},
+		// smaller than the large block
+		{Workers: 8, BufferBytes: 1000},
+		{Workers: 3},
+	} {
+		bs := &countingBlockstore{Blockstore: blockstore.NewMemorySync()}
+		cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+
+		ts, err := cs.ImportWithOpts(ctx, bytes.NewReader(snap.Bytes()), opts)
+		require.NoError(t, err)
+		require.Equal(t, hdr.Cid(), ts.Cids()[0])
+		require.Equal(t, int64(len(blks)), atomic.LoadInt64(&bs.puts))
+
+		require.NoError(t, cs.Close())
+	}
+}
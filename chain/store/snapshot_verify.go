@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	carbs "github.com/ipld/go-car/v2/blockstore"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// verifyMaxCorrupt is the number of corrupt blocks listed by VerifySnapshot.
+const verifyMaxCorrupt = 20
+
+// SnapshotVerification is the result of the verification of a snapshot CAR.
+type SnapshotVerification struct {
+	Stat *SnapshotStat
+
+	// Corrupt is the number of blocks whose data does not hash to their CID,
+	// CorruptBlocks the first of them.
+	Corrupt       uint64
+	CorruptBlocks []cid.Cid
+	// StateBlocks is the number of blocks of the state tree of the head.
+	StateBlocks uint64
+
+	// Problems lists what is wrong with the snapshot, nothing when it is valid.
+	Problems []string
+}
+
+// Valid reports whether no problem was found with the snapshot.
+func (v *SnapshotVerification) Valid() bool {
+	return len(v.Problems) == 0
+}
+
+func (v *SnapshotVerification) problem(format string, args ...interface{}) {
+	v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+}
+
+// VerifySnapshot checks the snapshot CAR at path without importing it: the
+// data of every block must hash to its CID, the headers must link the head
+// back to genesis with decreasing heights, and the state tree of the head
+// must be complete. Like StatSnapshot, it reads uncompressed CARv1 and CARv2
+// files in place. An error is only returned when the CAR can't be read; what
+// is wrong with a snapshot that can be is listed in the Problems of the result.
+func VerifySnapshot(ctx context.Context, path string) (*SnapshotVerification, error) {
+	v := &SnapshotVerification{}
+
+	st, err := statSnapshot(ctx, path, func(c cid.Cid, data []byte) {
+		hashed, err := c.Prefix().Sum(data)
+		if err == nil && hashed.Equals(c) {
+			return
+		}
+		v.Corrupt++
+		if len(v.CorruptBlocks) < verifyMaxCorrupt {
+			v.CorruptBlocks = append(v.CorruptBlocks, c)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	v.Stat = st
+	if v.Corrupt > 0 {
+		v.problem("%d blocks do not match their CID", v.Corrupt)
+	}
+
+	cbs, err := carbs.OpenReadOnly(path, carbs.UseWholeCIDs(true))
+	if err != nil {
+		return nil, xerrors.Errorf("opening car: %w", err)
+	}
+	defer cbs.Close() //nolint:errcheck
+
+	bs := bstore.Adapt(cbs)
+	cs := NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	head, err := cs.LoadTipSet(ctx, st.Head)
+	if err != nil {
+		return nil, xerrors.Errorf("loading head tipset: %w", err)
+	}
+
+	for ts := head; ts.Height() > 0; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		has, err := allBlocksIn(ctx, bs, ts.Parents())
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			v.problem("the headers of the parents of the tipset at height %d are missing", ts.Height())
+			break
+		}
+		pts, err := cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			v.problem("loading the parents of the tipset at height %d: %s", ts.Height(), err)
+			break
+		}
+		if pts.Height() >= ts.Height() {
+			v.problem("the parents of the tipset at height %d are at height %d, not below it", ts.Height(), pts.Height())
+			break
+		}
+		ts = pts
+	}
+
+	sw := newLinkWalker(ctx, bs, newMemVisitedSet(), ExportWorkers)
+	cids, err := sw.recurse(head.ParentState(), []cid.Cid{head.ParentState()})
+	if err != nil {
+		v.problem("the state tree %s of the head is incomplete: %s", head.ParentState(), err)
+	}
+	v.StateBlocks = uint64(len(cids))
+
+	return v, nil
+}
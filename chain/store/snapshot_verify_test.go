@@ -0,0 +1,103 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestVerifySnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(v, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	// the state of the head links to a leaf
+	leaf := put(map[string]interface{}{"leaf": true})
+	state := put(map[string]interface{}{"leaf": leaf})
+
+	var ts *types.TipSet
+	var gen cid.Cid
+	for h := 0; h <= 3; h++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		blk.ParentStateRoot = state
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		if h == 0 {
+			gen = blk.Cid()
+		}
+		ts = mock.TipSet(blk)
+	}
+
+	// writeCar writes the blocks of bs to a car rooted at ts, without skip and
+	// with the data of corrupt altered
+	writeCar := func(skip, corrupt cid.Cid) string {
+		path := filepath.Join(t.TempDir(), "snapshot.car")
+		fi, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: ts.Cids(), Version: 1}, fi))
+
+		keys, err := bs.AllKeysChan(ctx)
+		require.NoError(t, err)
+		for c := range keys {
+			if c == skip {
+				continue
+			}
+			blk, err := bs.Get(ctx, c)
+			require.NoError(t, err)
+			data := blk.RawData()
+			if c == corrupt {
+				data = append([]byte{}, data...)
+				data[len(data)-1]++
+			}
+			require.NoError(t, carutil.LdWrite(fi, c.Bytes(), data))
+		}
+		require.NoError(t, fi.Close())
+		return path
+	}
+
+	v, err := store.VerifySnapshot(ctx, writeCar(cid.Undef, cid.Undef))
+	require.NoError(t, err)
+	require.True(t, v.Valid(), v.Problems)
+	require.Equal(t, ts.Key(), v.Stat.Head)
+	require.Equal(t, uint64(2), v.StateBlocks)
+
+	v, err = store.VerifySnapshot(ctx, writeCar(cid.Undef, leaf))
+	require.NoError(t, err)
+	require.False(t, v.Valid())
+	require.Equal(t, uint64(1), v.Corrupt)
+	require.Equal(t, []cid.Cid{leaf}, v.CorruptBlocks)
+
+	v, err = store.VerifySnapshot(ctx, writeCar(leaf, cid.Undef))
+	require.NoError(t, err)
+	require.Len(t, v.Problems, 1)
+	require.Contains(t, v.Problems[0], "state tree")
+
+	v, err = store.VerifySnapshot(ctx, writeCar(gen, cid.Undef))
+	require.NoError(t, err)
+	require.Len(t, v.Problems, 1)
+	require.Contains(t, v.Problems[0], "height 1 are missing")
+}
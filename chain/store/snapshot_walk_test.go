@@ -0,0 +1,59 @@
+// stm: #unit
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+func TestLinkWalkerOrder(t *testing.T) {
+	ctx := context.Background()
+	bs := blockstore.NewMemorySync()
+
+	// build a DAG with shared subtrees so that the walk order depends on
+	// which parent reaches a node first
+	var mk func(depth, idx int) cid.Cid
+	mk = func(depth, idx int) cid.Cid {
+		var links []cid.Cid
+		if depth > 0 {
+			for i := 0; i < 4; i++ {
+				links = append(links, mk(depth-1, (idx+i)%6))
+			}
+		}
+		nd, err := cbor.WrapObject(map[string]interface{}{
+			"name":  fmt.Sprintf("%d-%d", depth, idx),
+			"links": links,
+		}, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+	root := mk(4, 0)
+
+	walk := func(workers int) []cid.Cid {
+		lw := newLinkWalker(ctx, bs, newMemVisitedSet(), workers)
+		out, err := lw.recurse(root, []cid.Cid{root})
+		require.NoError(t, err)
+		return out
+	}
+
+	serial := walk(1)
+	require.Greater(t, len(serial), 20)
+	for _, workers := range []int{2, 8, 32} {
+		require.Equal(t, serial, walk(workers))
+	}
+
+	// a missing block surfaces as an error
+	require.NoError(t, bs.DeleteBlock(ctx, serial[len(serial)/2]))
+	lw := newLinkWalker(ctx, bs, newMemVisitedSet(), 8)
+	_, err := lw.recurse(root, []cid.Cid{root})
+	require.Error(t, err)
+}
@@ -40,13 +40,36 @@ var log = logging.Logger("chainstore")
 
 var (
 	chainHeadKey                  = dstore.NewKey("head")
+	chainHeadBackupKey            = dstore.NewKey("/chain/headbackup")
 	checkpointKey                 = dstore.NewKey("/chain/checks")
+	importCheckpointKey           = dstore.NewKey("/chain/import/checkpoint")
 	blockValidationCacheKeyPrefix = dstore.NewKey("blockValidation")
 )
 
 var DefaultTipSetCacheSize = 8192
+
+// DefaultTipSetCacheRecentEpochs is the number of epochs below the highest
+// cached tipset whose tipsets are all kept by the tipset cache, in addition to
+// the DefaultTipSetCacheSize historical tipsets.
+var DefaultTipSetCacheRecentEpochs = abi.ChainEpoch(build.Finality)
 var DefaultMsgMetaCacheSize = 2048
 
+// ExportWorkers is the number of goroutines used to fetch and scan blocks
+// ahead of the snapshot walk. The walk order does not depend on it.
+var ExportWorkers = 8
+
+// ExportSpillDir, if set, is a directory in which snapshot walks keep the sets
+// of blocks they have visited on disk instead of in memory. This bounds the
+// memory used by exports of long chains, at the cost of speed.
+var ExportSpillDir = ""
+
+// ZeroCopyStateReads makes the state of the chainstore read with views of the
+// state blockstore, decoding the objects in place instead of copying them into
+// heap buffers first; see blockstore.NewCborStore. This lowers the GC pressure
+// of state heavy workloads such as StateCompute, at the cost of holding the
+// read transaction of the blockstore while each object is decoded.
+var ZeroCopyStateReads = false
+
 var ErrNotifeeDone = errors.New("notifee is done and should be removed")
 
 func init() {
@@ -58,6 +81,11 @@ func init() {
 		DefaultTipSetCacheSize = tscs
 	}
 
+	parseEnv("LOTUS_CHAIN_TIPSET_CACHE_RECENT", &DefaultTipSetCacheRecentEpochs, func(s string) (abi.ChainEpoch, error) {
+		r, err := strconv.ParseInt(s, 10, 64)
+		return abi.ChainEpoch(r), err
+	})
+
 	if s := os.Getenv("LOTUS_CHAIN_MSGMETA_CACHE"); s != "" {
 		mmcs, err := strconv.Atoi(s)
 		if err != nil {
@@ -65,6 +93,47 @@ func init() {
 		}
 		DefaultMsgMetaCacheSize = mmcs
 	}
+
+	parseEnv("LOTUS_CHAIN_EXPORT_WORKERS", &ExportWorkers, strconv.Atoi)
+
+	parseEnv("LOTUS_CHAIN_IMPORT_WORKERS", &ImportWorkers, strconv.Atoi)
+
+	parseEnv("LOTUS_CHAIN_IMPORT_BUFFER_BYTES", &ImportBufferBytes, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+
+	parseEnv("LOTUS_CHAIN_EXPORT_SPILL_DIR", &ExportSpillDir, func(s string) (string, error) {
+		return s, nil
+	})
+
+	parseEnv("LOTUS_CHAIN_ZERO_COPY_STATE_READS", &ZeroCopyStateReads, strconv.ParseBool)
+
+	parseEnv("LOTUS_CHAIN_HEIGHT_INDEX", &HeightIndex, strconv.ParseBool)
+
+	parseEnv("LOTUS_CHAIN_MSG_INDEX", &MsgIndex, strconv.ParseBool)
+
+	parseEnv("LOTUS_CHAIN_HEAD_JOURNAL", &HeadJournal, strconv.ParseBool)
+
+	parseEnv("LOTUS_CHAIN_HEAD_JOURNAL_RETAIN", &HeadJournalRetain, func(s string) (uint64, error) {
+		return strconv.ParseUint(s, 10, 64)
+	})
+
+	parseEnv("LOTUS_CHAIN_EXPORT_ROOTS_POLICY", &ExportRecentRootsPolicy, ParseRecentRootsPolicy)
+}
+
+// parseEnv sets v to the value of the env var key parsed with parse, when the
+// env var is set. Values failing to parse are logged, leaving v as it was.
+func parseEnv[T any](key string, v *T, parse func(string) (T, error)) {
+	s := os.Getenv(key)
+	if s == "" {
+		return
+	}
+	p, err := parse(s)
+	if err != nil {
+		log.Errorf("failed to parse '%s' env var: %s", key, err)
+		return
+	}
+	*v = p
 }
 
 // ReorgNotifee represents a callback that gets called upon reorgs.
@@ -73,6 +142,7 @@ type ReorgNotifee = func(rev, app []*types.TipSet) error
 // Journal event types.
 const (
 	evtTypeHeadChange = iota
+	evtTypeDeepReorg
 )
 
 type HeadChangeEvt struct {
@@ -92,7 +162,7 @@ type WeightFunc func(ctx context.Context, stateBs bstore.Blockstore, ts *types.T
 // latest head tipset references) being tracked in the Datastore (key-value
 // store).
 //
-// To alleviate disk access, the ChainStore has two ARC caches:
+// To alleviate disk access, the ChainStore has two caches:
 //  1. a tipset cache
 //  2. a block => messages references cache.
 type ChainStore struct {
@@ -104,6 +174,13 @@ type ChainStore struct {
 
 	chainLocalBlockstore bstore.Blockstore
 
+	// coldBlockstore is the tier the old chain history is moved to, if any. The
+	// blockstores above then read through to it, with the hot tiers kept in
+	// hotChainBlockstore and hotStateBlockstore.
+	coldBlockstore     bstore.Blockstore
+	hotChainBlockstore bstore.Blockstore
+	hotStateBlockstore bstore.Blockstore
+
 	heaviestLk sync.RWMutex
 	heaviest   *types.TipSet
 	checkpoint *types.TipSet
@@ -111,18 +188,28 @@ type ChainStore struct {
 	bestTips *pubsub.PubSub
 	pubLk    sync.Mutex
 
+	exportProgress *pubsub.PubSub
+	exportSeq      uint64
+
 	tstLk   sync.Mutex
 	tipsets map[abi.ChainEpoch][]cid.Cid
 
 	cindex *ChainIndex
+	hindex *heightIndex
+	mindex *msgIndex
+
+	hjournal *headJournal
 
 	reorgCh        chan<- reorg
 	reorgNotifeeCh chan ReorgNotifee
 
 	mmCache *lru.ARCCache // msg meta cache (mh.Messages -> secp, bls []cid)
-	tsCache *lru.ARCCache
+	tsCache *tipSetCache
+
+	reorgGuard *reorgGuard
+	forkTips   *forkTips
 
-	evtTypes [1]journal.EventType
+	evtTypes [2]journal.EventType
 	journal  journal.Journal
 
 	cancelFn context.CancelFunc
@@ -131,7 +218,7 @@ type ChainStore struct {
 
 func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dstore.Batching, weight WeightFunc, j journal.Journal) *ChainStore {
 	c, _ := lru.NewARC(DefaultMsgMetaCacheSize)
-	tsc, _ := lru.NewARC(DefaultTipSetCacheSize)
+	tsc := newTipSetCache(DefaultTipSetCacheSize, DefaultTipSetCacheRecentEpochs)
 	if j == nil {
 		j = journal.NilJournal()
 	}
@@ -147,20 +234,32 @@ func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dsto
 		weight:               weight,
 		metadataDs:           ds,
 		bestTips:             pubsub.New(64),
+		exportProgress:       pubsub.New(16),
 		tipsets:              make(map[abi.ChainEpoch][]cid.Cid),
+		forkTips:             newForkTips(),
 		mmCache:              c,
 		tsCache:              tsc,
 		cancelFn:             cancel,
 		journal:              j,
 	}
 
-	cs.evtTypes = [1]journal.EventType{
+	cs.evtTypes = [2]journal.EventType{
 		evtTypeHeadChange: j.RegisterEventType("sync", "head_change"),
+		evtTypeDeepReorg:  j.RegisterEventType("sync", "deep_reorg"),
 	}
 
 	ci := NewChainIndex(cs.LoadTipSet)
 
 	cs.cindex = ci
+	if HeightIndex {
+		cs.hindex = newHeightIndex(ds, cs.LoadTipSet)
+	}
+	if MsgIndex {
+		cs.mindex = &msgIndex{ds: ds, cs: cs}
+	}
+	if HeadJournal {
+		cs.hjournal = &headJournal{ds: ds}
+	}
 
 	hcnf := func(rev, app []*types.TipSet) error {
 		cs.pubLk.Lock()
@@ -193,7 +292,20 @@ func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dsto
 	}
 
 	cs.reorgNotifeeCh = make(chan ReorgNotifee)
-	cs.reorgCh = cs.reorgWorker(ctx, []ReorgNotifee{hcnf, hcmetric})
+	notifees := []ReorgNotifee{hcnf, hcmetric}
+	if cs.hindex != nil {
+		notifees = append(notifees, cs.hindex.headChange)
+
+		cs.wg.Add(1)
+		go func() {
+			defer cs.wg.Done()
+			cs.hindex.run(ctx)
+		}()
+	}
+	if cs.mindex != nil {
+		notifees = append(notifees, cs.mindex.headChange)
+	}
+	cs.reorgCh = cs.reorgWorker(ctx, notifees)
 
 	return cs
 }
@@ -211,33 +323,18 @@ func (cs *ChainStore) Load(ctx context.Context) error {
 	if err := cs.loadCheckpoint(ctx); err != nil {
 		return err
 	}
-	return nil
-}
-func (cs *ChainStore) loadHead(ctx context.Context) error {
-	head, err := cs.metadataDs.Get(ctx, chainHeadKey)
-	if err == dstore.ErrNotFound {
-		log.Warn("no previous chain state found")
-		return nil
-	}
-	if err != nil {
-		return xerrors.Errorf("failed to load chain state from datastore: %w", err)
-	}
-
-	var tscids []cid.Cid
-	if err := json.Unmarshal(head, &tscids); err != nil {
-		return xerrors.Errorf("failed to unmarshal stored chain head: %w", err)
+	if cs.hindex != nil && cs.heaviest != nil {
+		if err := cs.hindex.load(ctx, cs.heaviest); err != nil {
+			return xerrors.Errorf("loading height index: %w", err)
+		}
 	}
-
-	ts, err := cs.LoadTipSet(ctx, types.NewTipSetKey(tscids...))
-	if err != nil {
-		return xerrors.Errorf("loading tipset: %w", err)
+	if cs.hjournal != nil {
+		if err := cs.hjournal.load(ctx); err != nil {
+			return xerrors.Errorf("loading head journal: %w", err)
+		}
 	}
-
-	cs.heaviest = ts
-
 	return nil
 }
-
 func (cs *ChainStore) loadCheckpoint(ctx context.Context) error {
 	tskBytes, err := cs.metadataDs.Get(ctx, checkpointKey)
 	if err == dstore.ErrNotFound {
@@ -263,19 +360,6 @@ func (cs *ChainStore) loadCheckpoint(ctx context.Context) error {
 	return nil
 }
 
-func (cs *ChainStore) writeHead(ctx context.Context, ts *types.TipSet) error {
-	data, err := json.Marshal(ts.Cids())
-	if err != nil {
-		return xerrors.Errorf("failed to marshal tipset: %w", err)
-	}
-
-	if err := cs.metadataDs.Put(ctx, chainHeadKey, data); err != nil {
-		return xerrors.Errorf("failed to write chain head to datastore: %w", err)
-	}
-
-	return nil
-}
-
 const (
 	HCRevert  = "revert"
 	HCApply   = "apply"
@@ -395,7 +479,8 @@ func (cs *ChainStore) PutTipSet(ctx context.Context, ts *types.TipSet) error {
 
 // MaybeTakeHeavierTipSet evaluates the incoming tipset and locks it in our
 // internal state as our new head, if and only if it is heavier than the current
-// head and does not exceed the maximum fork length.
+// head and does not exceed the maximum fork length. Reorgs deeper than the
+// confirmation depth, if one is set, are held until they are resolved.
 func (cs *ChainStore) MaybeTakeHeavierTipSet(ctx context.Context, ts *types.TipSet) error {
 	for {
 		cs.heaviestLk.Lock()
@@ -416,6 +501,8 @@ func (cs *ChainStore) MaybeTakeHeavierTipSet(ctx context.Context, ts *types.TipS
 	if err != nil {
 		return err
 	}
+	cs.trackForkTip(ctx, ts, w)
+
 	heaviestW, err := cs.weight(ctx, cs.StateBlockstore(), cs.heaviest)
 	if err != nil {
 		return err
@@ -440,6 +527,14 @@ func (cs *ChainStore) MaybeTakeHeavierTipSet(ctx context.Context, ts *types.TipS
 			return nil
 		}
 
+		held, err := cs.holdReorg(ctx, ts, w)
+		if err != nil {
+			return err
+		}
+		if held {
+			return nil
+		}
+
 		return cs.takeHeaviestTipSet(ctx, ts)
 	}
 
@@ -539,6 +634,11 @@ func (cs *ChainStore) ForceHeadSilent(ctx context.Context, ts *types.TipSet) err
 type reorg struct {
 	old *types.TipSet
 	new *types.TipSet
+
+	// revert and apply are the reorg ops when they were computed already, in
+	// notification order
+	ops           bool
+	revert, apply []*types.TipSet
 }
 
 const reorgChBuf = 32
@@ -559,10 +659,14 @@ func (cs *ChainStore) reorgWorker(ctx context.Context, initialNotifees []ReorgNo
 				notifees = append(notifees, n)
 
 			case r := <-out:
-				revert, apply, err := cs.ReorgOps(ctx, r.old, r.new)
-				if err != nil {
-					log.Error("computing reorg ops failed: ", err)
-					continue
+				revert, apply := r.revert, r.apply
+				if !r.ops {
+					var err error
+					revert, apply, err = cs.reorgOps(ctx, r.old, r.new)
+					if err != nil {
+						log.Error("computing reorg ops failed: ", err)
+						continue
+					}
 				}
 
 				cs.journal.RecordEvent(cs.evtTypes[evtTypeHeadChange], func() interface{} {
@@ -576,12 +680,7 @@ func (cs *ChainStore) reorgWorker(ctx context.Context, initialNotifees []ReorgNo
 					}
 				})
 
-				// reverse the apply array
-				for i := len(apply)/2 - 1; i >= 0; i-- {
-					opp := len(apply) - 1 - i
-					apply[i], apply[opp] = apply[opp], apply[i]
-				}
-
+				start := time.Now()
 				var toremove map[int]struct{}
 				for i, hcf := range notifees {
 					err := hcf(revert, apply)
@@ -611,6 +710,7 @@ func (cs *ChainStore) reorgWorker(ctx context.Context, initialNotifees []ReorgNo
 					}
 					notifees = newNotifees
 				}
+				recordOp(ctx, opNotifyHeadChange, start)
 
 			case <-ctx.Done():
 				return
@@ -624,17 +724,38 @@ func (cs *ChainStore) reorgWorker(ctx context.Context, initialNotifees []ReorgNo
 // memory and in the ChainStore. It also sends a notification to deliver to
 // ReorgNotifees.
 func (cs *ChainStore) takeHeaviestTipSet(ctx context.Context, ts *types.TipSet) error {
+	return cs.takeReorg(ctx, reorg{new: ts})
+}
+
+// takeReorg takes r.new as the head, from the current head, like
+// takeHeaviestTipSet. The reorg ops are computed unless r already holds them.
+func (cs *ChainStore) takeReorg(ctx context.Context, r reorg) error {
+	ts := r.new
 	_, span := trace.StartSpan(ctx, "takeHeaviestTipSet")
 	defer span.End()
+	defer recordOp(ctx, opTakeHead, time.Now())
 
 	if cs.heaviest != nil { // buf
+		r.old = cs.heaviest
+		if !r.ops && cs.hjournal != nil {
+			var err error
+			if r.revert, r.apply, err = cs.reorgOps(ctx, r.old, r.new); err != nil {
+				log.Errorw("computing reorg ops for the head journal", "error", err)
+			} else {
+				r.ops = true
+			}
+		}
+		if r.ops && cs.hjournal != nil {
+			if err := cs.hjournal.record(ctx, r.old, r.new, r.revert, r.apply); err != nil {
+				log.Errorw("writing head journal", "error", err)
+			}
+		}
+
+		stats.Record(ctx, metrics.ChainStoreReorgQueue.M(int64(len(cs.reorgCh))))
 		if len(cs.reorgCh) > 0 {
 			log.Warnf("Reorg channel running behind, %d reorgs buffered", len(cs.reorgCh))
 		}
-		cs.reorgCh <- reorg{
-			old: cs.heaviest,
-			new: ts,
-		}
+		cs.reorgCh <- r
 	} else {
 		log.Warnf("no heaviest tipset found, using %s", ts.Cids())
 	}
@@ -796,6 +917,8 @@ func (cs *ChainStore) Contains(ctx context.Context, ts *types.TipSet) (bool, err
 // GetBlock fetches a BlockHeader with the supplied CID. It returns
 // blockstore.ErrNotFound if the block was not found in the BlockStore.
 func (cs *ChainStore) GetBlock(ctx context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	defer recordOp(ctx, opGetBlock, time.Now())
+
 	var blk *types.BlockHeader
 	err := cs.chainLocalBlockstore.View(ctx, c, func(b []byte) (err error) {
 		blk, err = types.DecodeBlock(b)
@@ -805,10 +928,12 @@ func (cs *ChainStore) GetBlock(ctx context.Context, c cid.Cid) (*types.BlockHead
 }
 
 func (cs *ChainStore) LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
-	v, ok := cs.tsCache.Get(tsk)
+	ts, ok := cs.tsCache.Get(tsk)
+	recordCacheLookup(ctx, cacheTipSet, ok)
 	if ok {
-		return v.(*types.TipSet), nil
+		return ts, nil
 	}
+	defer recordOp(ctx, opLoadTipSet, time.Now())
 
 	// Fetch tipset block headers from blockstore in parallel
 	var eg errgroup.Group
@@ -831,7 +956,7 @@ func (cs *ChainStore) LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*typ
 		return nil, err
 	}
 
-	ts, err := types.NewTipSet(blks)
+	ts, err = types.NewTipSet(blks)
 	if err != nil {
 		return nil, err
 	}
@@ -841,6 +966,52 @@ func (cs *ChainStore) LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*typ
 	return ts, nil
 }
 
+// LoadTipSets loads the tipsets of keys, in order. The block headers of the
+// tipsets that aren't cached are read from the blockstore in a single batch,
+// which saves the round-trips of loading them one by one.
+func (cs *ChainStore) LoadTipSets(ctx context.Context, keys []types.TipSetKey) ([]*types.TipSet, error) {
+	out := make([]*types.TipSet, len(keys))
+	var missing []int
+	var cids []cid.Cid
+	for i, tsk := range keys {
+		ts, ok := cs.tsCache.Get(tsk)
+		recordCacheLookup(ctx, cacheTipSet, ok)
+		if ok {
+			out[i] = ts
+			continue
+		}
+		missing = append(missing, i)
+		cids = append(cids, tsk.Cids()...)
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+	defer recordOp(ctx, opLoadTipSets, time.Now())
+
+	blks := make([]*types.BlockHeader, len(cids))
+	err := bstore.ViewMany(ctx, cs.chainLocalBlockstore, cids, func(i int, b []byte) (err error) {
+		blks[i], err = types.DecodeBlock(b)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("get blocks: %w", err)
+	}
+
+	for _, i := range missing {
+		n := len(keys[i].Cids())
+		ts, err := types.NewTipSet(blks[:n])
+		if err != nil {
+			return nil, xerrors.Errorf("tipset %s: %w", keys[i], err)
+		}
+		blks = blks[n:]
+
+		cs.tsCache.Add(keys[i], ts)
+		out[i] = ts
+	}
+
+	return out, nil
+}
+
 // IsAncestorOf returns true if 'a' is an ancestor of 'b'
 func (cs *ChainStore) IsAncestorOf(ctx context.Context, a, b *types.TipSet) (bool, error) {
 	if b.Height() <= a.Height() {
@@ -881,6 +1052,27 @@ func (cs *ChainStore) ReorgOps(ctx context.Context, a, b *types.TipSet) ([]*type
 	return ReorgOps(ctx, cs.LoadTipSet, a, b)
 }
 
+// reorgOps returns the reorg ops from a to b in the order they are delivered to
+// the ReorgNotifees: the reverted tipsets from a down, then the applied ones up
+// to b.
+func (cs *ChainStore) reorgOps(ctx context.Context, a, b *types.TipSet) ([]*types.TipSet, []*types.TipSet, error) {
+	return notifiedReorgOps(ctx, cs.LoadTipSet, a, b)
+}
+
+// notifiedReorgOps is reorgOps with the tipsets loaded by lts.
+func notifiedReorgOps(ctx context.Context, lts func(ctx context.Context, _ types.TipSetKey) (*types.TipSet, error), a, b *types.TipSet) ([]*types.TipSet, []*types.TipSet, error) {
+	revert, apply, err := ReorgOps(ctx, lts, a, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	// reverse the apply array
+	for i := len(apply)/2 - 1; i >= 0; i-- {
+		opp := len(apply) - 1 - i
+		apply[i], apply[opp] = apply[opp], apply[i]
+	}
+	return revert, apply, nil
+}
+
 func ReorgOps(ctx context.Context, lts func(ctx context.Context, _ types.TipSetKey) (*types.TipSet, error), a, b *types.TipSet) ([]*types.TipSet, []*types.TipSet, error) {
 	left := a
 	right := b
@@ -1060,6 +1252,9 @@ func (cs *ChainStore) GetGenesis(ctx context.Context) (*types.BlockHeader, error
 // GetPath returns the sequence of atomic head change operations that
 // need to be applied in order to switch the head of the chain from the `from`
 // tipset to the `to` tipset.
+//
+// The common ancestor of the tipsets is searched for with height lookups, so
+// that only the tipsets on the path are loaded, however deep the fork.
 func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to types.TipSetKey) ([]*api.HeadChange, error) {
 	fts, err := cs.LoadTipSet(ctx, from)
 	if err != nil {
@@ -1069,11 +1264,31 @@ func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to type
 	if err != nil {
 		return nil, xerrors.Errorf("loading to tipset %s: %w", to, err)
 	}
-	revert, apply, err := cs.ReorgOps(ctx, fts, tts)
+	anc, err := cs.commonAncestor(ctx, fts, tts)
 	if err != nil {
 		return nil, xerrors.Errorf("error getting tipset branches: %w", err)
 	}
 
+	// branch returns the tipsets from ts down to anc, exclusive
+	branch := func(ts *types.TipSet) ([]*types.TipSet, error) {
+		var out []*types.TipSet
+		for ts.Height() > anc.Height() {
+			out = append(out, ts)
+			if ts, err = cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+				return nil, xerrors.Errorf("error getting tipset branches: %w", err)
+			}
+		}
+		return out, nil
+	}
+	revert, err := branch(fts)
+	if err != nil {
+		return nil, err
+	}
+	apply, err := branch(tts)
+	if err != nil {
+		return nil, err
+	}
+
 	path := make([]*api.HeadChange, len(revert)+len(apply))
 	for i, r := range revert {
 		path[i] = &api.HeadChange{Type: HCRevert, Val: r}
@@ -1084,6 +1299,72 @@ func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to type
 	return path, nil
 }
 
+// commonAncestor returns the highest tipset on the chains of both a and b. The
+// chains agree on the last tipset at or below every height up to the ancestor,
+// and disagree above it: the search steps down from the lower of a and b with
+// doubling steps until they agree, then bisects.
+func (cs *ChainStore) commonAncestor(ctx context.Context, a, b *types.TipSet) (*types.TipSet, error) {
+	// same returns the last tipset at or below h on the chain of a, and
+	// whether the chain of b holds it too
+	same := func(h abi.ChainEpoch) (*types.TipSet, bool, error) {
+		ats, err := cs.GetTipsetByHeight(ctx, h, a, true)
+		if err != nil {
+			return nil, false, err
+		}
+		bts, err := cs.GetTipsetByHeight(ctx, h, b, true)
+		if err != nil {
+			return nil, false, err
+		}
+		return ats, ats.Equals(bts), nil
+	}
+
+	hi := a.Height()
+	if b.Height() < hi {
+		hi = b.Height()
+	}
+	ts, ok, err := same(hi)
+	if err != nil || ok {
+		return ts, err
+	}
+
+	// same(lo) holds, same(hi) doesn't
+	var lo abi.ChainEpoch
+	for step := abi.ChainEpoch(1); ; step *= 2 {
+		lo = hi - step
+		if lo <= 0 {
+			lo = 0
+			if _, ok, err = same(lo); err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, xerrors.Errorf("%s and %s have no common ancestor", a.Key(), b.Key())
+			}
+			break
+		}
+		if _, ok, err = same(lo); err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		hi = lo
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if _, ok, err = same(mid); err != nil {
+			return nil, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	ts, _, err = same(lo)
+	return ts, err
+}
+
 // ChainBlockstore returns the chain blockstore. Currently the chain and state
 // // stores are both backed by the same physical store, albeit with different
 // // caching policies, but in the future they will segregate.
@@ -1103,7 +1384,16 @@ func ActorStore(ctx context.Context, bs bstore.Blockstore) adt.Store {
 }
 
 func (cs *ChainStore) ActorStore(ctx context.Context) adt.Store {
-	return ActorStore(ctx, cs.stateBlockstore)
+	return adt.WrapStore(ctx, cs.StateCborStore(ctx))
+}
+
+// StateCborStore returns a cbor store over the state blockstore, decoding the
+// objects in place when ZeroCopyStateReads is set.
+func (cs *ChainStore) StateCborStore(ctx context.Context) *cbor.BasicIpldStore {
+	if ZeroCopyStateReads {
+		return bstore.NewCborStore(ctx, cs.stateBlockstore)
+	}
+	return cbor.NewCborStore(cs.stateBlockstore)
 }
 
 func (cs *ChainStore) TryFillTipSet(ctx context.Context, ts *types.TipSet) (*FullTipSet, error) {
@@ -1145,9 +1435,23 @@ func (cs *ChainStore) GetTipsetByHeight(ctx context.Context, h abi.ChainEpoch, t
 		return ts, nil
 	}
 
-	lbts, err := cs.cindex.GetTipsetByHeight(ctx, ts, h)
-	if err != nil {
-		return nil, err
+	var lbts *types.TipSet
+	if cs.hindex != nil {
+		its, ok, err := cs.hindex.lookup(ctx, ts, h)
+		if err != nil {
+			log.Warnw("height index lookup failed, walking the chain", "height", h, "error", err)
+		}
+		if ok {
+			lbts = its
+		}
+	}
+
+	var err error
+	if lbts == nil {
+		lbts, err = cs.cindex.GetTipsetByHeight(ctx, ts, h)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if lbts.Height() < h {
@@ -106,7 +106,7 @@ func TestChainExportImport(t *testing.T) {
 	}
 
 	buf := new(bytes.Buffer)
-	if err := cg.ChainStore().Export(context.TODO(), last, 0, false, buf); err != nil {
+	if err := cg.ChainStore().Export(context.TODO(), last, 0, false, true, buf); err != nil {
 		t.Fatal(err)
 	}
 
@@ -144,7 +144,7 @@ func TestChainExportImportFull(t *testing.T) {
 	}
 
 	buf := new(bytes.Buffer)
-	if err := cg.ChainStore().Export(context.TODO(), last, last.Height(), false, buf); err != nil {
+	if err := cg.ChainStore().Export(context.TODO(), last, last.Height(), false, true, buf); err != nil {
 		t.Fatal(err)
 	}
 
@@ -0,0 +1,31 @@
+package store
+
+import (
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// SetColdStore sets the tier the chain history is moved to by PruneChain with
+// Offload set, usually on cheaper and slower storage than the chainstore's
+// blockstores. Reads of the chainstore fall back to it for the blocks missing
+// from them. It must be set once, before the chainstore is used.
+func (cs *ChainStore) SetColdStore(cold bstore.Blockstore) {
+	cs.coldBlockstore = cold
+	cs.hotChainBlockstore, cs.hotStateBlockstore = cs.chainBlockstore, cs.stateBlockstore
+	cs.chainBlockstore = bstore.NewTiered(cs.chainBlockstore, cold)
+	cs.stateBlockstore = bstore.NewTiered(cs.stateBlockstore, cold)
+	cs.chainLocalBlockstore = bstore.NewTiered(cs.chainLocalBlockstore, cold)
+}
+
+// ColdStore returns the tier the chain history is moved to, nil if there is
+// none.
+func (cs *ChainStore) ColdStore() bstore.Blockstore {
+	return cs.coldBlockstore
+}
+
+// hotBlockstores returns the chain and state blockstores without the cold tier.
+func (cs *ChainStore) hotBlockstores() (chain, state bstore.Blockstore) {
+	if cs.coldBlockstore != nil {
+		return cs.hotChainBlockstore, cs.hotStateBlockstore
+	}
+	return cs.chainBlockstore, cs.stateBlockstore
+}
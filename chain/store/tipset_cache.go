@@ -0,0 +1,141 @@
+package store
+
+import (
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// tipSetCache caches loaded tipsets. The tipsets of the last recentEpochs epochs
+// below the highest tipset cached are all kept, as they are what the node reads
+// the most. Older tipsets share an ARC cache of size entries, which adapts to
+// keep both the recently and the frequently requested ones.
+type tipSetCache struct {
+	lk sync.Mutex
+
+	size         int
+	recentEpochs abi.ChainEpoch
+
+	// top is the height of the highest tipset cached
+	top      abi.ChainEpoch
+	recent   map[types.TipSetKey]*types.TipSet
+	byHeight map[abi.ChainEpoch][]types.TipSetKey
+	hist     *lru.ARCCache
+
+	hits, misses uint64
+}
+
+func newTipSetCache(size int, recentEpochs abi.ChainEpoch) *tipSetCache {
+	c := &tipSetCache{}
+	c.reset(size, recentEpochs)
+	return c
+}
+
+func (c *tipSetCache) reset(size int, recentEpochs abi.ChainEpoch) {
+	if size <= 0 {
+		size = 1
+	}
+	if recentEpochs < 0 {
+		recentEpochs = 0
+	}
+	hist, _ := lru.NewARC(size)
+
+	c.size, c.recentEpochs = size, recentEpochs
+	c.top = 0
+	c.recent = make(map[types.TipSetKey]*types.TipSet)
+	c.byHeight = make(map[abi.ChainEpoch][]types.TipSetKey)
+	c.hist = hist
+}
+
+// Resize drops the cached tipsets and sets the size and the recent window of the
+// cache.
+func (c *tipSetCache) Resize(size int, recentEpochs abi.ChainEpoch) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.reset(size, recentEpochs)
+}
+
+func (c *tipSetCache) Get(tsk types.TipSetKey) (*types.TipSet, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	ts, ok := c.recent[tsk]
+	if !ok {
+		var v interface{}
+		if v, ok = c.hist.Get(tsk); ok {
+			ts = v.(*types.TipSet)
+		}
+	}
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return ts, ok
+}
+
+func (c *tipSetCache) Add(tsk types.TipSetKey, ts *types.TipSet) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if _, ok := c.recent[tsk]; ok {
+		return
+	}
+
+	if h := ts.Height(); h > c.top {
+		// the tipsets falling out of the window move to the ARC cache
+		for e := c.top - c.recentEpochs + 1; e <= h-c.recentEpochs && e <= c.top; e++ {
+			for _, k := range c.byHeight[e] {
+				c.hist.Add(k, c.recent[k])
+				delete(c.recent, k)
+			}
+			delete(c.byHeight, e)
+		}
+		c.top = h
+	}
+
+	if ts.Height() > c.top-c.recentEpochs {
+		c.hist.Remove(tsk)
+		c.recent[tsk] = ts
+		c.byHeight[ts.Height()] = append(c.byHeight[ts.Height()], tsk)
+		return
+	}
+	c.hist.Add(tsk, ts)
+}
+
+func (c *tipSetCache) Purge() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.reset(c.size, c.recentEpochs)
+}
+
+func (c *tipSetCache) Stats() api.TipSetCacheStats {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	return api.TipSetCacheStats{
+		Size:         c.size,
+		RecentEpochs: c.recentEpochs,
+		Recent:       len(c.recent),
+		Historical:   c.hist.Len(),
+		Hits:         c.hits,
+		Misses:       c.misses,
+	}
+}
+
+// SetTipSetCache sets the number of historical tipsets cached by the chainstore,
+// and the number of epochs below the highest cached tipset whose tipsets are
+// all kept. The tipsets already cached are dropped.
+func (cs *ChainStore) SetTipSetCache(size int, recentEpochs abi.ChainEpoch) {
+	cs.tsCache.Resize(size, recentEpochs)
+}
+
+// TipSetCacheStats returns the occupancy and the hit rate of the tipset cache.
+func (cs *ChainStore) TipSetCacheStats() api.TipSetCacheStats {
+	return cs.tsCache.Stats()
+}
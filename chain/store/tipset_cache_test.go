@@ -0,0 +1,72 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestTipSetCache(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	var chain []*types.TipSet
+	var ts *types.TipSet
+	for i := 0; i < 50; i++ {
+		blk := mock.MkBlock(ts, 1, 1)
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+		chain = append(chain, ts)
+	}
+
+	cs.SetTipSetCache(4, 10)
+	for _, ts := range chain {
+		mustLoad(t, cs, ts.Key())
+	}
+	st := cs.TipSetCacheStats()
+	require.Equal(t, 4, st.Size)
+	require.Equal(t, 10, st.Recent)
+	require.Equal(t, 4, st.Historical)
+	require.Equal(t, uint64(0), st.Hits)
+	require.Equal(t, uint64(50), st.Misses)
+
+	// the recent window is always cached
+	for _, ts := range chain[40:] {
+		require.Equal(t, ts, mustLoad(t, cs, ts.Key()))
+	}
+	require.Equal(t, uint64(10), cs.TipSetCacheStats().Hits)
+
+	// frequently requested historical tipsets outlive the recently loaded ones
+	for i := 0; i < 3; i++ {
+		mustLoad(t, cs, chain[0].Key())
+	}
+	for _, ts := range chain[1:30] {
+		mustLoad(t, cs, ts.Key())
+	}
+	hits := cs.TipSetCacheStats().Hits
+	mustLoad(t, cs, chain[0].Key())
+	require.Equal(t, hits+1, cs.TipSetCacheStats().Hits)
+
+	// resizing drops the cached tipsets
+	cs.SetTipSetCache(8, 0)
+	st = cs.TipSetCacheStats()
+	require.Equal(t, 0, st.Recent+st.Historical)
+	for _, ts := range chain {
+		mustLoad(t, cs, ts.Key())
+	}
+	st = cs.TipSetCacheStats()
+	require.Equal(t, 0, st.Recent)
+	require.Equal(t, 8, st.Historical)
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// StartFromTrustedTipSet bootstraps the chain from the tipset tsk, trusted to
+// be in the canonical chain with the parent state stateRoot, instead of from
+// genesis or a snapshot. If the head is below it, the tipset becomes the head
+// and the checkpoint of the chain: the syncer then only fetches and validates
+// the headers above it, and the chain below it, such as the state tree, is
+// read on demand through the blockstores, which must fall back to the network
+// for the blocks they miss. Nothing below the tipset is verified, not even that
+// it descends from the genesis of cs.
+//
+// It returns whether the head was moved to the tipset; a head already at or
+// above it is left alone, but must be on its chain.
+func (cs *ChainStore) StartFromTrustedTipSet(ctx context.Context, tsk types.TipSetKey, stateRoot cid.Cid) (bool, error) {
+	ts, err := cs.LoadTipSet(ctx, tsk)
+	if err != nil {
+		return false, xerrors.Errorf("loading trusted tipset: %w", err)
+	}
+	if ts.ParentState() != stateRoot {
+		return false, xerrors.Errorf("trusted tipset %s has the parent state %s, not the trusted state root %s", tsk, ts.ParentState(), stateRoot)
+	}
+
+	if head := cs.GetHeaviestTipSet(); head != nil && head.Height() >= ts.Height() {
+		anc, err := cs.GetTipsetByHeight(ctx, ts.Height(), head, false)
+		if err != nil {
+			return false, xerrors.Errorf("checking trusted tipset is in the chain of the head: %w", err)
+		}
+		if !anc.Equals(ts) {
+			return false, xerrors.Errorf("head %s at height %d is not on the chain of the trusted tipset %s", head.Key(), head.Height(), tsk)
+		}
+		return false, nil
+	}
+
+	tskBytes, err := json.Marshal(ts.Key())
+	if err != nil {
+		return false, err
+	}
+
+	log.Warnw("starting the chain from the trusted tipset", "height", ts.Height(), "tipset", ts.Cids(), "stateRoot", stateRoot)
+
+	cs.heaviestLk.Lock()
+	defer cs.heaviestLk.Unlock()
+
+	cs.heaviest = ts
+	if err := cs.writeHead(ctx, ts); err != nil {
+		return false, xerrors.Errorf("writing trusted head: %w", err)
+	}
+	if err := cs.metadataDs.Put(ctx, checkpointKey, tskBytes); err != nil {
+		return false, xerrors.Errorf("writing trusted checkpoint: %w", err)
+	}
+	cs.checkpoint = ts
+	return true, nil
+}
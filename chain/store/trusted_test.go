@@ -0,0 +1,75 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestStartFromTrustedTipSet(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		if ts == nil {
+			return types.NewInt(0), nil
+		}
+		return ts.ParentWeight(), nil
+	}
+	cs := store.NewChainStore(bs, bs, ds, weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	mkChain := func(base *types.TipSet, n int, ticket uint64) []*types.TipSet {
+		var chain []*types.TipSet
+		ts := base
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, 1, ticket)
+			blk.ParentStateRoot = blocks.NewBlock([]byte(fmt.Sprintf("state %d/%d", blk.Height, ticket))).Cid()
+			require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+			ts = mock.TipSet(blk)
+			chain = append(chain, ts)
+		}
+		return chain
+	}
+
+	chain := mkChain(nil, 6, 1)
+	require.NoError(t, cs.ForceHeadSilent(ctx, chain[0]))
+
+	// the trusted state root must be the parent state of the tipset
+	trusted := chain[3]
+	_, err := cs.StartFromTrustedTipSet(ctx, trusted.Key(), chain[2].ParentState())
+	require.Error(t, err)
+
+	// a head below the tipset moves to it, checkpointed
+	moved, err := cs.StartFromTrustedTipSet(ctx, trusted.Key(), trusted.ParentState())
+	require.NoError(t, err)
+	require.True(t, moved)
+	require.True(t, cs.GetHeaviestTipSet().Equals(trusted))
+	require.True(t, cs.GetCheckpoint().Equals(trusted))
+
+	// a head at or above it is left alone
+	require.NoError(t, cs.RemoveCheckpoint(ctx))
+	require.NoError(t, cs.SetHead(ctx, chain[5]))
+	moved, err = cs.StartFromTrustedTipSet(ctx, trusted.Key(), trusted.ParentState())
+	require.NoError(t, err)
+	require.False(t, moved)
+	require.True(t, cs.GetHeaviestTipSet().Equals(chain[5]))
+
+	// unless it is on a fork of the tipset
+	fork := mkChain(chain[1], 4, 2)
+	require.NoError(t, cs.ForceHeadSilent(ctx, fork[3]))
+	_, err = cs.StartFromTrustedTipSet(ctx, trusted.Key(), trusted.ParentState())
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"os"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/dgraph-io/badger/v2/options"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// visitedSet tracks the blocks a snapshot walk has already visited.
+type visitedSet interface {
+	// Visit adds c to the set, returning false if it was already in it.
+	Visit(cid.Cid) (bool, error)
+	Has(cid.Cid) (bool, error)
+	Close() error
+}
+
+// newVisitedSet returns an in-memory visited set, or one backed by a badger
+// database in a temporary directory below dir if dir is set.
+func newVisitedSet(dir string) (visitedSet, error) {
+	if dir == "" {
+		return newMemVisitedSet(), nil
+	}
+	return newDiskVisitedSet(dir)
+}
+
+type memVisitedSet struct {
+	set *cid.Set
+}
+
+func newMemVisitedSet() *memVisitedSet {
+	return &memVisitedSet{set: cid.NewSet()}
+}
+
+func (s *memVisitedSet) Visit(c cid.Cid) (bool, error) {
+	return s.set.Visit(c), nil
+}
+
+func (s *memVisitedSet) Has(c cid.Cid) (bool, error) {
+	return s.set.Has(c), nil
+}
+
+func (s *memVisitedSet) Close() error {
+	return nil
+}
+
+// diskVisitedBatch is the number of visited blocks buffered in memory before
+// they are written to the database.
+var diskVisitedBatch = 16384
+
+// diskVisitedSet keeps the visited set in a badger database, so that its
+// memory use does not grow with the size of the walk.
+type diskVisitedSet struct {
+	db      *badger.DB
+	dir     string
+	pending map[string]struct{}
+}
+
+func newDiskVisitedSet(dir string) (*diskVisitedSet, error) {
+	path, err := os.MkdirTemp(dir, "export-visited-")
+	if err != nil {
+		return nil, xerrors.Errorf("creating visited set directory: %w", err)
+	}
+
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil
+	opts.SyncWrites = false
+	// keys are cids without values; there is nothing to compress
+	opts.Compression = options.None
+	// avoid mapping the tables into memory, which is the point of this set
+	opts.TableLoadingMode = options.FileIO
+	opts.ValueLogLoadingMode = options.FileIO
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		_ = os.RemoveAll(path)
+		return nil, xerrors.Errorf("opening visited set database: %w", err)
+	}
+
+	return &diskVisitedSet{
+		db:      db,
+		dir:     path,
+		pending: make(map[string]struct{}),
+	}, nil
+}
+
+func (s *diskVisitedSet) Visit(c cid.Cid) (bool, error) {
+	has, err := s.Has(c)
+	if has || err != nil {
+		return false, err
+	}
+
+	s.pending[string(c.Bytes())] = struct{}{}
+	if len(s.pending) >= diskVisitedBatch {
+		if err := s.flush(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *diskVisitedSet) Has(c cid.Cid) (bool, error) {
+	key := c.Bytes()
+	if _, ok := s.pending[string(key)]; ok {
+		return true, nil
+	}
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	switch err {
+	case nil:
+		return true, nil
+	case badger.ErrKeyNotFound:
+		return false, nil
+	default:
+		return false, xerrors.Errorf("reading visited set: %w", err)
+	}
+}
+
+func (s *diskVisitedSet) flush() error {
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for k := range s.pending {
+		if err := wb.Set([]byte(k), nil); err != nil {
+			return xerrors.Errorf("writing visited set: %w", err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return xerrors.Errorf("writing visited set: %w", err)
+	}
+
+	s.pending = make(map[string]struct{})
+	return nil
+}
+
+func (s *diskVisitedSet) Close() error {
+	err := s.db.Close()
+	if rerr := os.RemoveAll(s.dir); rerr != nil && err == nil {
+		err = rerr
+	}
+	if err != nil {
+		return xerrors.Errorf("closing visited set: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// stm: #unit
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+func TestDiskVisitedSet(t *testing.T) {
+	defer func(b int) { diskVisitedBatch = b }(diskVisitedBatch)
+	diskVisitedBatch = 10
+
+	dir := t.TempDir()
+	s, err := newDiskVisitedSet(dir)
+	require.NoError(t, err)
+
+	var cids []cid.Cid
+	for i := 0; i < 25; i++ {
+		nd, err := cbor.WrapObject(map[string]int{"i": i}, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		cids = append(cids, nd.Cid())
+	}
+
+	for _, c := range cids {
+		visit, err := s.Visit(c)
+		require.NoError(t, err)
+		require.True(t, visit)
+	}
+
+	// both flushed and pending entries are remembered
+	for _, c := range cids {
+		has, err := s.Has(c)
+		require.NoError(t, err)
+		require.True(t, has)
+
+		visit, err := s.Visit(c)
+		require.NoError(t, err)
+		require.False(t, visit)
+	}
+
+	// the same hash with another codec is a different block
+	has, err := s.Has(cid.NewCidV1(cid.Raw, cids[0].Hash()))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, s.Close())
+	ents, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, ents)
+}
+
+func TestLinkWalkerDiskVisitedSet(t *testing.T) {
+	ctx := context.Background()
+	bs := blockstore.NewMemorySync()
+
+	var links []cid.Cid
+	for i := 0; i < 100; i++ {
+		leaf, err := cbor.WrapObject(map[string]int{"leaf": i % 40}, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, leaf))
+		links = append(links, leaf.Cid())
+	}
+	root, err := cbor.WrapObject(map[string]interface{}{"links": links}, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, root))
+
+	mem, err := newLinkWalker(ctx, bs, newMemVisitedSet(), 4).recurse(root.Cid(), nil)
+	require.NoError(t, err)
+	require.Len(t, mem, 40)
+
+	s, err := newVisitedSet(t.TempDir())
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	disk, err := newLinkWalker(ctx, bs, s, 4).recurse(root.Cid(), nil)
+	require.NoError(t, err)
+	require.Equal(t, mem, disk)
+}
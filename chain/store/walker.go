@@ -0,0 +1,232 @@
+package store
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ChainWalkerOpts configures a ChainWalker.
+type ChainWalkerOpts struct {
+	// StateDepth is the number of epochs below the walked tipset for which
+	// state trees are walked. The genesis state is always walked.
+	StateDepth abi.ChainEpoch
+	// SkipOldMessages only walks the messages of the tipsets within
+	// StateDepth.
+	SkipOldMessages bool
+	// SkipReceipts skips the message receipts.
+	SkipReceipts bool
+
+	// Codecs are the codecs of the blocks handed to the visitor, raw and
+	// dag-cbor when empty. Identity blocks are never visited.
+	Codecs []uint64
+	// Filter selects the blocks handed to the visitor, all of them when nil.
+	Filter SnapshotFilter
+
+	// Workers is the number of blocks fetched ahead of the walk, ExportWorkers
+	// when 0.
+	Workers int
+}
+
+// ChainVisitor is handed the blocks of a chain walk.
+type ChainVisitor interface {
+	// VisitBlock is called once for every block of the walk, with the part of
+	// the chain it belongs to. Returning an error aborts the walk.
+	VisitBlock(c cid.Cid, bt SnapshotBlockType) error
+}
+
+// ChainVisitorFunc is a ChainVisitor calling itself.
+type ChainVisitorFunc func(c cid.Cid, bt SnapshotBlockType) error
+
+func (f ChainVisitorFunc) VisitBlock(c cid.Cid, bt SnapshotBlockType) error {
+	return f(c, bt)
+}
+
+// ChainWalker walks the DAG of the chain the way snapshots are exported: the
+// block headers down to genesis, their messages and receipts, and the state
+// trees of the recent tipsets and of genesis. Blocks unselected by the codecs
+// or the filter are still traversed, so the blocks they link to are visited
+// as usual.
+type ChainWalker struct {
+	cs   *ChainStore
+	opts ChainWalkerOpts
+
+	et *exportTracker
+}
+
+// NewChainWalker returns a walker of the chain of cs.
+func (cs *ChainStore) NewChainWalker(opts ChainWalkerOpts) *ChainWalker {
+	if len(opts.Codecs) == 0 {
+		opts.Codecs = []uint64{cid.Raw, cid.DagCBOR}
+	}
+	if opts.Filter == nil {
+		opts.Filter = includeAll
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = ExportWorkers
+	}
+	return &ChainWalker{cs: cs, opts: opts}
+}
+
+func (w *ChainWalker) visitable(c cid.Cid, bt SnapshotBlockType) bool {
+	prefix := c.Prefix()
+	if prefix.MhType == mh.IDENTITY {
+		return false
+	}
+	for _, codec := range w.opts.Codecs {
+		if prefix.Codec == codec {
+			return w.opts.Filter(c, prefix.Codec, bt)
+		}
+	}
+	return false
+}
+
+// Walk walks the chain from ts, the head when nil, handing every block to v
+// once.
+func (w *ChainWalker) Walk(ctx context.Context, ts *types.TipSet, v ChainVisitor) error {
+	cs := w.cs
+	if ts == nil {
+		ts = cs.GetHeaviestTipSet()
+	}
+
+	seen, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return err
+	}
+	defer seen.Close() //nolint:errcheck
+	walked, err := newVisitedSet(ExportSpillDir)
+	if err != nil {
+		return err
+	}
+	defer walked.Close() //nolint:errcheck
+
+	msgWalker := newLinkWalker(ctx, cs.chainBlockstore, walked, w.opts.Workers)
+	stateWalker := newLinkWalker(ctx, cs.stateBlockstore, walked, w.opts.Workers)
+
+	blocksToWalk := ts.Cids()
+	currentMinHeight := ts.Height()
+
+	emit := func(cids []cid.Cid, bt SnapshotBlockType) error {
+		for _, c := range cids {
+			visit, err := seen.Visit(c)
+			if err != nil {
+				return err
+			}
+			if visit && w.visitable(c, bt) {
+				if err := v.VisitBlock(c, bt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	walkChain := func(blk cid.Cid) error {
+		if visit, err := seen.Visit(blk); !visit || err != nil {
+			return err
+		}
+
+		if w.visitable(blk, SnapshotHeader) {
+			if err := v.VisitBlock(blk, SnapshotHeader); err != nil {
+				return err
+			}
+		}
+
+		data, err := cs.chainBlockstore.Get(ctx, blk)
+		if err != nil {
+			return xerrors.Errorf("getting block: %w", err)
+		}
+
+		var b types.BlockHeader
+		if err := b.UnmarshalCBOR(bytes.NewBuffer(data.RawData())); err != nil {
+			return xerrors.Errorf("unmarshaling block header (cid=%s): %w", blk, err)
+		}
+
+		if currentMinHeight > b.Height {
+			currentMinHeight = b.Height
+			w.et.walked(currentMinHeight)
+			if currentMinHeight%builtin.EpochsInDay == 0 {
+				log.Infow("walking chain", "height", currentMinHeight)
+			}
+		}
+
+		if !w.opts.SkipOldMessages || b.Height > ts.Height()-w.opts.StateDepth {
+			visit, err := walked.Visit(b.Messages)
+			if err != nil {
+				return err
+			}
+			if visit {
+				mcids, err := msgWalker.recurse(b.Messages, []cid.Cid{b.Messages})
+				if err != nil {
+					return xerrors.Errorf("recursing messages failed: %w", err)
+				}
+				if err := emit(mcids, SnapshotMessages); err != nil {
+					return err
+				}
+			}
+
+			// receipts of the parent messages, kept alongside the messages
+			visit = false
+			if !w.opts.SkipReceipts {
+				if visit, err = walked.Visit(b.ParentMessageReceipts); err != nil {
+					return err
+				}
+			}
+			if visit {
+				rcids, err := stateWalker.recurse(b.ParentMessageReceipts, []cid.Cid{b.ParentMessageReceipts})
+				if err != nil {
+					return xerrors.Errorf("recursing message receipts failed: %w", err)
+				}
+				if err := emit(rcids, SnapshotReceipts); err != nil {
+					return err
+				}
+			}
+		}
+
+		if b.Height > 0 {
+			blocksToWalk = append(blocksToWalk, b.Parents...)
+		} else {
+			// include the genesis block
+			if err := emit(b.Parents, SnapshotHeader); err != nil {
+				return err
+			}
+		}
+
+		if b.Height == 0 || b.Height > ts.Height()-w.opts.StateDepth {
+			visit, err := walked.Visit(b.ParentStateRoot)
+			if err != nil {
+				return err
+			}
+			if visit {
+				cids, err := stateWalker.recurse(b.ParentStateRoot, []cid.Cid{b.ParentStateRoot})
+				if err != nil {
+					return xerrors.Errorf("recursing genesis state failed: %w", err)
+				}
+
+				if err := emit(cids, SnapshotState); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for len(blocksToWalk) > 0 {
+		next := blocksToWalk[0]
+		blocksToWalk = blocksToWalk[1:]
+		if err := walkChain(next); err != nil {
+			return xerrors.Errorf("walk chain failed: %w", err)
+		}
+	}
+
+	return nil
+}
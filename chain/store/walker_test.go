@@ -0,0 +1,118 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestChainWalker(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	put := func(v interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(v, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, bs.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	code, err := cid.Prefix{Version: 1, Codec: cid.Raw, MhType: mh.SHA2_256, MhLength: -1}.Sum([]byte("code"))
+	require.NoError(t, err)
+	rawBlk, err := blocks.NewBlockWithCid([]byte("code"), code)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, rawBlk))
+
+	genSt := put(map[string]interface{}{"code": code})
+	st := put(map[string]interface{}{"state": 1})
+	oldMsgs := put(map[string]interface{}{"msgs": 0})
+	msgs := put(map[string]interface{}{"msgs": 1})
+	rcpts := put(map[string]interface{}{"receipts": true})
+
+	gen := mock.MkBlock(nil, 1, 1)
+	gen.ParentStateRoot = genSt
+	gen.Messages = oldMsgs
+	gen.ParentMessageReceipts = rcpts
+	require.NoError(t, cs.PersistBlockHeaders(ctx, gen))
+
+	blk := mock.MkBlock(mock.TipSet(gen), 1, 1)
+	blk.ParentStateRoot = st
+	blk.Messages = msgs
+	blk.ParentMessageReceipts = rcpts
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	ts := mock.TipSet(blk)
+
+	type visit struct {
+		c  cid.Cid
+		bt store.SnapshotBlockType
+	}
+	walk := func(opts store.ChainWalkerOpts) []visit {
+		var out []visit
+		require.NoError(t, cs.NewChainWalker(opts).Walk(ctx, ts, store.ChainVisitorFunc(func(c cid.Cid, bt store.SnapshotBlockType) error {
+			out = append(out, visit{c, bt})
+			return nil
+		})))
+		return out
+	}
+
+	// blocks are visited once, with the part of the chain they belong to
+	require.Equal(t, []visit{
+		{blk.Cid(), store.SnapshotHeader},
+		{msgs, store.SnapshotMessages},
+		{rcpts, store.SnapshotReceipts},
+		{st, store.SnapshotState},
+		{gen.Cid(), store.SnapshotHeader},
+		{oldMsgs, store.SnapshotMessages},
+		{genSt, store.SnapshotState},
+		{code, store.SnapshotState},
+	}, walk(store.ChainWalkerOpts{StateDepth: 1}))
+
+	// the default options walk like snapshots are exported
+	var snap []cid.Cid
+	require.NoError(t, cs.WalkSnapshot(ctx, ts, 1, true, true, func(c cid.Cid) error {
+		snap = append(snap, c)
+		return nil
+	}))
+	var walked []cid.Cid
+	for _, v := range walk(store.ChainWalkerOpts{StateDepth: 1, SkipOldMessages: true, SkipReceipts: true, Workers: 4}) {
+		walked = append(walked, v.c)
+	}
+	require.Equal(t, snap, walked)
+	require.NotContains(t, walked, oldMsgs)
+	require.NotContains(t, walked, rcpts)
+
+	// without state depth only the genesis state is walked
+	require.Equal(t, []visit{
+		{genSt, store.SnapshotState},
+		{code, store.SnapshotState},
+	}, walk(store.ChainWalkerOpts{Filter: store.SnapshotTypes(store.SnapshotState)}))
+
+	// codecs select the blocks visited, the others are still traversed
+	require.Equal(t, []visit{{code, store.SnapshotState}}, walk(store.ChainWalkerOpts{Codecs: []uint64{cid.Raw}}))
+
+	// visitor errors abort the walk
+	errStop := xerrors.New("stop")
+	var n int
+	err = cs.NewChainWalker(store.ChainWalkerOpts{}).Walk(ctx, ts, store.ChainVisitorFunc(func(cid.Cid, store.SnapshotBlockType) error {
+		n++
+		return errStop
+	}))
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 1, n)
+}
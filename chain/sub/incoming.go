@@ -351,20 +351,7 @@ func (mv *MessageValidator) Validate(ctx context.Context, pid peer.ID, msg *pubs
 			tag.Upsert(metrics.Local, "false"),
 		)
 		recordFailure(ctx, metrics.MessageValidationFailure, "add")
-		switch {
-		case xerrors.Is(err, messagepool.ErrSoftValidationFailure):
-			fallthrough
-		case xerrors.Is(err, messagepool.ErrRBFTooLowPremium):
-			fallthrough
-		case xerrors.Is(err, messagepool.ErrTooManyPendingMessages):
-			fallthrough
-		case xerrors.Is(err, messagepool.ErrNonceGap):
-			fallthrough
-		case xerrors.Is(err, messagepool.ErrNonceTooLow):
-			return pubsub.ValidationIgnore
-		default:
-			return pubsub.ValidationReject
-		}
+		return addFailureResult(err)
 	}
 
 	ctx, _ = tag.New(
@@ -376,6 +363,35 @@ func (mv *MessageValidator) Validate(ctx context.Context, pid peer.ID, msg *pubs
 	return pubsub.ValidationAccept
 }
 
+// addFailureResult is the validation result of a message from the network the
+// message pool failed to add. The messages failing for reasons specific to the
+// state of the pool, including the parked ones, are ignored rather than
+// rejected, but never relayed.
+func addFailureResult(err error) pubsub.ValidationResult {
+	switch {
+	case xerrors.Is(err, messagepool.ErrSoftValidationFailure):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrRBFTooLowPremium):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrTooManyPendingMessages):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrTooManyReplacements):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrNonceGap):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrMessageParked):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrSenderRateLimited):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrSpamPremiumTooLow):
+		fallthrough
+	case xerrors.Is(err, messagepool.ErrNonceTooLow):
+		return pubsub.ValidationIgnore
+	default:
+		return pubsub.ValidationReject
+	}
+}
+
 func (mv *MessageValidator) validateLocalMessage(ctx context.Context, msg *pubsub.Message) pubsub.ValidationResult {
 	ctx, _ = tag.New(
 		ctx,
@@ -12,11 +12,14 @@ import (
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	pb "github.com/libp2p/go-libp2p-pubsub/pb"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-legs/dtsync"
 
 	"github.com/filecoin-project/lotus/api/mocks"
+	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -134,3 +137,10 @@ func TestIndexerMessageValidator_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestAddFailureResult(t *testing.T) {
+	// the parked messages are not relayed
+	require.Equal(t, pubsub.ValidationIgnore, addFailureResult(xerrors.Errorf("adding: %w", messagepool.ErrMessageParked)))
+	require.Equal(t, pubsub.ValidationIgnore, addFailureResult(messagepool.ErrNonceGap))
+	require.Equal(t, pubsub.ValidationReject, addFailureResult(messagepool.ErrInvalidToAddr))
+}
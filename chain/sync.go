@@ -54,6 +54,9 @@ var (
 
 	concurrentSyncRequests = exchange.ShufflePeersPrefix
 	syncRequestBatchSize   = 8
+	// syncRequestBatchRanges is the number of windows of syncRequestBatchSize
+	// tipsets whose messages are fetched in a single batched request.
+	syncRequestBatchRanges = 4
 	syncRequestRetries     = 5
 )
 
@@ -114,6 +117,17 @@ type Syncer struct {
 	tickerCtxCancel context.CancelFunc
 
 	ds dtypes.MetadataDS
+
+	// HeadersOnly makes the syncer validate and store only the headers of the
+	// tipsets it syncs, neither fetching their messages nor executing them.
+	// The state the validation of the headers reads, such as the power table,
+	// must be fetched on demand by the blockstores. It is set before Start.
+	HeadersOnly bool
+
+	// PipelineDepth is the number of consecutive tipsets validated at once
+	// while syncing, see validationPipeline. A depth of 1 or less validates
+	// the tipsets one at a time. It is set before Start.
+	PipelineDepth int
 }
 
 type SyncManagerCtor func(syncFn SyncFunc) SyncManager
@@ -143,7 +157,7 @@ func NewSyncer(ds dtypes.MetadataDS,
 	s := &Syncer{
 		ds:             ds,
 		beacon:         beacon,
-		bad:            NewBadBlockCache(),
+		bad:            NewBadBlockCache(ds),
 		Genesis:        gent,
 		consensus:      consensus,
 		Exchange:       exchange,
@@ -578,7 +592,8 @@ func (syncer *Syncer) ValidateTipSet(ctx context.Context, fts *store.FullTipSet,
 
 		futures = append(futures, async.Err(func() error {
 			if err := syncer.ValidateBlock(ctx, b, useCache); err != nil {
-				if isPermanent(err) {
+				// a canceled validation says nothing about the block
+				if isPermanent(err) && ctx.Err() == nil {
 					syncer.bad.Add(b.Cid(), NewBadBlockReason([]cid.Cid{b.Cid()}, err.Error()))
 				}
 				return xerrors.Errorf("validating block %s: %w", b.Cid(), err)
@@ -943,6 +958,17 @@ func (syncer *Syncer) syncMessagesAndCheckState(ctx context.Context, headers []*
 	ss := extractSyncState(ctx)
 	ss.SetHeight(headers[len(headers)-1].Height())
 
+	if syncer.PipelineDepth > 1 {
+		p := newValidationPipeline(ctx, syncer, syncer.PipelineDepth)
+		if err := syncer.iterFullTipsets(ctx, headers, func(_ context.Context, fts *store.FullTipSet) error {
+			return p.push(fts)
+		}); err != nil {
+			p.abort()
+			return err
+		}
+		return p.wait()
+	}
+
 	return syncer.iterFullTipsets(ctx, headers, func(ctx context.Context, fts *store.FullTipSet) error {
 		log.Debugw("validating tipset", "height", fts.TipSet().Height(), "size", len(fts.TipSet().Cids()))
 		if err := syncer.ValidateTipSet(ctx, fts, true); err != nil {
@@ -957,6 +983,62 @@ func (syncer *Syncer) syncMessagesAndCheckState(ctx context.Context, headers []*
 	})
 }
 
+// validateHeaders validates the headers of the given tipsets, lowest first,
+// without their messages and the execution of their parents, for the Syncer in
+// HeadersOnly mode.
+func (syncer *Syncer) validateHeaders(ctx context.Context, headers []*types.TipSet) error {
+	ss := extractSyncState(ctx)
+	ss.SetHeight(headers[len(headers)-1].Height())
+
+	for i := len(headers) - 1; i >= 0; i-- {
+		ts := headers[i]
+		if err := syncer.validateTipSetHeaders(ctx, ts); err != nil {
+			log.Errorf("failed to validate tipset headers: %+v", err)
+			return xerrors.Errorf("header validation failed: %w", err)
+		}
+
+		stats.Record(ctx, metrics.ChainNodeWorkerHeight.M(int64(ts.Height())))
+		ss.SetHeight(ts.Height())
+	}
+	return nil
+}
+
+func (syncer *Syncer) validateTipSetHeaders(ctx context.Context, ts *types.TipSet) error {
+	ctx, span := trace.StartSpan(ctx, "validateTipSetHeaders")
+	defer span.End()
+
+	span.AddAttributes(trace.Int64Attribute("height", int64(ts.Height())))
+
+	if ts.Equals(syncer.Genesis) {
+		return nil
+	}
+
+	var futures []async.ErrorFuture
+	for _, b := range ts.Blocks() {
+		b := b // rebind to a scoped variable
+
+		futures = append(futures, async.Err(func() error {
+			if err := syncer.consensus.ValidateBlockHeader(ctx, b); err != nil {
+				if isPermanent(err) && ctx.Err() == nil {
+					syncer.bad.Add(b.Cid(), NewBadBlockReason([]cid.Cid{b.Cid()}, err.Error()))
+				}
+				return xerrors.Errorf("validating block header %s: %w", b.Cid(), err)
+			}
+
+			if err := syncer.sm.ChainStore().AddToTipSetTracker(ctx, b); err != nil {
+				return xerrors.Errorf("failed to add validated header to tipset tracker: %w", err)
+			}
+			return nil
+		}))
+	}
+	for _, f := range futures {
+		if err := f.AwaitContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // fills out each of the given tipsets with messages and calls the callback with it
 func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipSet, cb func(context.Context, *store.FullTipSet) error) error {
 	ss := extractSyncState(ctx)
@@ -1065,62 +1147,94 @@ func (syncer *Syncer) fetchMessages(ctx context.Context, headers []*types.TipSet
 
 	start := build.Clock.Now()
 
+	// the windows of syncRequestBatchSize tipsets, the messages of
+	// syncRequestBatchRanges of them being fetched together
+	var windows [][2]int
 	for j := 0; j < batchSize; j += syncRequestBatchSize {
+		end := j + syncRequestBatchSize
+		if end > batchSize {
+			end = batchSize
+		}
+		windows = append(windows, [2]int{j, end})
+	}
+
+	for w := 0; w < len(windows); w += syncRequestBatchRanges {
+		group := windows[w:]
+		if len(group) > syncRequestBatchRanges {
+			group = group[:syncRequestBatchRanges]
+		}
+
 		wg.Add(1)
-		go func(j int) {
+		go func(group [][2]int) {
 			defer wg.Done()
 
-			nreq := syncRequestBatchSize
-			if j+nreq > batchSize {
-				nreq = batchSize - j
-			}
-
-			failed := false
-			for offset := 0; !failed && offset < nreq; {
-				nextI := j + offset
-				lastI := j + nreq
-
-				var requestErr error
-				var requestResult []*exchange.CompactedMessages
-				for retry := 0; requestResult == nil && retry < syncRequestRetries; retry++ {
-					if retry > 0 {
-						log.Infof("fetching messages at %d (retry %d)", startOffset+nextI, retry)
-					} else {
-						log.Infof("fetching messages at %d", startOffset+nextI)
+			// fetched is the number of tipsets of each window fetched so far,
+			// the responses possibly covering the start of the windows only
+			fetched := make([]int, len(group))
+
+			var requestErr error
+			for retry := 0; retry < syncRequestRetries; {
+				var pending []int
+				var ranges [][]*types.TipSet
+				for k, win := range group {
+					if win[0]+fetched[k] < win[1] {
+						pending = append(pending, k)
+						ranges = append(ranges, headers[win[0]+fetched[k]:win[1]])
 					}
+				}
+				if len(pending) == 0 {
+					return
+				}
 
-					result, err := syncer.Exchange.GetChainMessages(ctx, headers[nextI:lastI])
-					if err != nil {
-						requestErr = multierror.Append(requestErr, err)
-					} else {
-						isGood := true
-						for index, ts := range headers[nextI:lastI] {
-							cm := result[index]
-							if err := checkMsgMeta(ts, cm.Bls, cm.Secpk, cm.BlsIncludes, cm.SecpkIncludes); err != nil {
-								log.Errorf("fetched messages not as expected: %s", err)
-								isGood = false
-								break
-							}
-						}
+				nextI := group[pending[0]][0] + fetched[pending[0]]
+				if retry > 0 {
+					log.Infof("fetching messages at %d (retry %d)", startOffset+nextI, retry)
+				} else {
+					log.Infof("fetching messages at %d", startOffset+nextI)
+				}
 
-						if isGood {
-							requestResult = result
+				result, err := syncer.Exchange.GetChainMessagesBatch(ctx, ranges)
+				if err != nil {
+					requestErr = multierror.Append(requestErr, err)
+					retry++
+					continue
+				}
+
+				progress := false
+				for r, k := range pending {
+					isGood := true
+					for index, cm := range result[r] {
+						if err := checkMsgMeta(ranges[r][index], cm.Bls, cm.Secpk, cm.BlsIncludes, cm.SecpkIncludes); err != nil {
+							log.Errorf("fetched messages not as expected: %s", err)
+							isGood = false
+							break
 						}
 					}
-				}
+					if !isGood || len(result[r]) == 0 {
+						continue
+					}
 
-				mx.Lock()
-				if requestResult != nil {
-					copy(batch[j+offset:], requestResult)
-					offset += len(requestResult)
+					mx.Lock()
+					copy(batch[group[k][0]+fetched[k]:], result[r])
+					mx.Unlock()
+					fetched[k] += len(result[r])
+					progress = true
+				}
+				if progress {
+					// the retries count the requests failing in a row
+					retry = 0
+					requestErr = nil
 				} else {
-					log.Errorf("error fetching messages at %d: %s", nextI, requestErr)
-					batchErr = multierror.Append(batchErr, requestErr)
-					failed = true
+					requestErr = multierror.Append(requestErr, xerrors.Errorf("fetched messages not as expected"))
+					retry++
 				}
-				mx.Unlock()
 			}
-		}(j)
+
+			mx.Lock()
+			log.Errorf("error fetching messages at %d: %s", startOffset+group[0][0], requestErr)
+			batchErr = multierror.Append(batchErr, requestErr)
+			mx.Unlock()
+		}(group)
 	}
 	wg.Wait()
 
@@ -1209,12 +1323,22 @@ func (syncer *Syncer) collectChain(ctx context.Context, ts *types.TipSet, hts *t
 	}
 	toPersist = nil
 
-	ss.SetStage(api.StageMessages)
+	if syncer.HeadersOnly {
+		ss.SetStage(api.StageHeaderValidation)
 
-	if err := syncer.syncMessagesAndCheckState(ctx, headers); err != nil {
-		err = xerrors.Errorf("collectChain syncMessages: %w", err)
-		ss.Error(err)
-		return err
+		if err := syncer.validateHeaders(ctx, headers); err != nil {
+			err = xerrors.Errorf("collectChain validateHeaders: %w", err)
+			ss.Error(err)
+			return err
+		}
+	} else {
+		ss.SetStage(api.StageMessages)
+
+		if err := syncer.syncMessagesAndCheckState(ctx, headers); err != nil {
+			err = xerrors.Errorf("collectChain syncMessages: %w", err)
+			ss.Error(err)
+			return err
+		}
 	}
 
 	ss.SetStage(api.StageSyncComplete)
@@ -1227,9 +1351,9 @@ func (syncer *Syncer) State() []SyncerStateSnapshot {
 	return syncer.syncmgr.State()
 }
 
-// MarkBad manually adds a block to the "bad blocks" cache.
+// MarkBad manually adds a block to the "bad blocks" cache, without expiry.
 func (syncer *Syncer) MarkBad(blk cid.Cid) {
-	syncer.bad.Add(blk, NewBadBlockReason([]cid.Cid{blk}, "manually marked bad"))
+	syncer.bad.AddWithTTL(blk, NewBadBlockReason([]cid.Cid{blk}, "manually marked bad"), 0)
 }
 
 // UnmarkBad manually adds a block to the "bad blocks" cache.
@@ -1245,3 +1369,14 @@ func (syncer *Syncer) CheckBadBlockCache(blk cid.Cid) (string, bool) {
 	bbr, ok := syncer.bad.Has(blk)
 	return bbr.String(), ok
 }
+
+// BadBlock returns the entry of the block blk in the "bad blocks" cache, if
+// it is marked bad.
+func (syncer *Syncer) BadBlock(blk cid.Cid) (BadBlockEntry, bool) {
+	return syncer.bad.Get(blk)
+}
+
+// BadBlocks returns the entries of the "bad blocks" cache.
+func (syncer *Syncer) BadBlocks() map[cid.Cid]BadBlockEntry {
+	return syncer.bad.List()
+}
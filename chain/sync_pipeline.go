@@ -0,0 +1,140 @@
+package chain
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// validationPipeline validates the tipsets of a chain being synced, pushed in
+// chain order, up to depth tipsets at once.
+//
+// Validating a tipset executes its parent, starting from the parent state in
+// the header of the parent, so the executions have to run in chain order: the
+// validation of each tipset first executes its parent once the execution of
+// the grandparent is done, then checks the tipset while the next validation
+// executes it. What overlaps is the execution of a tipset with the message
+// and signature checks of the tipsets around it, and with the persistence of
+// the messages of the next ones. The results are collected in chain order,
+// and the first failure cancels the validations after it, so the head only
+// ever moves to validated tipsets.
+type validationPipeline struct {
+	syncer *Syncer
+	ss     *SyncerState
+	depth  int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// the tipsets being validated, in chain order
+	inflight []pendingValidation
+	// closed once the parent of the last tipset pushed is executed
+	executed chan struct{}
+}
+
+type pendingValidation struct {
+	ts   *types.TipSet
+	done chan error
+}
+
+func newValidationPipeline(ctx context.Context, syncer *Syncer, depth int) *validationPipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &validationPipeline{
+		syncer: syncer,
+		ss:     extractSyncState(ctx),
+		depth:  depth,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// push starts the validation of fts, once fewer than depth tipsets are being
+// validated. It returns the first failure of the validations before it.
+func (p *validationPipeline) push(fts *store.FullTipSet) error {
+	for len(p.inflight) >= p.depth {
+		if err := p.collect(); err != nil {
+			return err
+		}
+	}
+
+	v := pendingValidation{ts: fts.TipSet(), done: make(chan error, 1)}
+	prev, executed := p.executed, make(chan struct{})
+	p.executed = executed
+	go func() {
+		p.executeParent(v.ts, prev, executed)
+
+		log.Debugw("validating tipset", "height", v.ts.Height(), "size", len(v.ts.Cids()))
+		v.done <- p.syncer.ValidateTipSet(p.ctx, fts, true)
+	}()
+	p.inflight = append(p.inflight, v)
+	return nil
+}
+
+// executeParent executes the parent of ts, once the execution of its
+// grandparent is done, caching its state for the validation of ts. Failures
+// are left to the validation to report.
+func (p *validationPipeline) executeParent(ts *types.TipSet, prev <-chan struct{}, executed chan<- struct{}) {
+	defer close(executed)
+
+	if prev != nil {
+		select {
+		case <-prev:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+	if ts.Height() == 0 {
+		return
+	}
+
+	parent, err := p.syncer.store.LoadTipSet(p.ctx, ts.Parents())
+	if err != nil {
+		return
+	}
+	if _, _, err := p.syncer.sm.TipSetState(p.ctx, parent); err != nil {
+		log.Debugw("executing parent tipset", "height", parent.Height(), "error", err)
+	}
+}
+
+// collect waits for the validation of the oldest tipset being validated. On
+// failure, the validations after it are canceled.
+func (p *validationPipeline) collect() error {
+	v := p.inflight[0]
+	p.inflight = p.inflight[1:]
+
+	if err := <-v.done; err != nil {
+		log.Errorf("failed to validate tipset: %+v", err)
+		p.abort()
+		return xerrors.Errorf("message processing failed: %w", err)
+	}
+
+	stats.Record(p.ctx, metrics.ChainNodeWorkerHeight.M(int64(v.ts.Height())))
+	p.ss.SetHeight(v.ts.Height())
+	return nil
+}
+
+// wait waits for all the validations, returning the first failure.
+func (p *validationPipeline) wait() error {
+	defer p.cancel()
+
+	for len(p.inflight) > 0 {
+		if err := p.collect(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abort cancels the running validations and waits for them.
+func (p *validationPipeline) abort() {
+	p.cancel()
+	for _, v := range p.inflight {
+		<-v.done
+	}
+	p.inflight = nil
+}
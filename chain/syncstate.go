@@ -20,11 +20,25 @@ type SyncerStateSnapshot struct {
 	Message  string
 	Start    time.Time
 	End      time.Time
+
+	// Stages is the progress through the stages entered so far, in the order
+	// they were first entered.
+	Stages []api.SyncStageProgress
+	// EpochsRemaining is the number of epochs left to validate.
+	EpochsRemaining abi.ChainEpoch
+	// ETA is the estimated completion time of the sync, zero until the
+	// validation of the tipsets has made progress.
+	ETA time.Time
 }
 
 type SyncerState struct {
 	lk   sync.Mutex
 	data SyncerStateSnapshot
+
+	// stageStart is when the current stage was entered
+	stageStart time.Time
+	// validateStart is when the messages were first fetched or validated
+	validateStart time.Time
 }
 
 func (ss *SyncerState) SetStage(v api.SyncStateStage) {
@@ -34,12 +48,43 @@ func (ss *SyncerState) SetStage(v api.SyncStateStage) {
 
 	ss.lk.Lock()
 	defer ss.lk.Unlock()
+	now := build.Clock.Now()
+	ss.endStage(now)
 	ss.data.Stage = v
 	if v == api.StageSyncComplete {
-		ss.data.End = build.Clock.Now()
+		ss.data.End = now
+		return
+	}
+
+	ss.stage(v)
+	ss.stageStart = now
+	if isValidationStage(v) && ss.validateStart.IsZero() {
+		ss.validateStart = now
 	}
 }
 
+// stage returns the progress through the stage v, adding it if it wasn't
+// entered yet.
+func (ss *SyncerState) stage(v api.SyncStateStage) *api.SyncStageProgress {
+	for i := range ss.data.Stages {
+		if ss.data.Stages[i].Stage == v {
+			return &ss.data.Stages[i]
+		}
+	}
+	ss.data.Stages = append(ss.data.Stages, api.SyncStageProgress{Stage: v})
+	return &ss.data.Stages[len(ss.data.Stages)-1]
+}
+
+// endStage accounts the time spent in the current stage, if any.
+func (ss *SyncerState) endStage(now time.Time) {
+	if ss.stageStart.IsZero() {
+		return
+	}
+	st := ss.stage(ss.data.Stage)
+	st.Time += now.Sub(ss.stageStart)
+	ss.stageStart = time.Time{}
+}
+
 func (ss *SyncerState) Init(base, target *types.TipSet) {
 	if ss == nil {
 		return
@@ -54,6 +99,10 @@ func (ss *SyncerState) Init(base, target *types.TipSet) {
 	ss.data.Message = ""
 	ss.data.Start = build.Clock.Now()
 	ss.data.End = time.Time{}
+	ss.data.Stages = nil
+	ss.stageStart = ss.data.Start
+	ss.validateStart = time.Time{}
+	ss.stage(api.StageHeaders)
 }
 
 func (ss *SyncerState) SetHeight(h abi.ChainEpoch) {
@@ -64,6 +113,25 @@ func (ss *SyncerState) SetHeight(h abi.ChainEpoch) {
 	ss.lk.Lock()
 	defer ss.lk.Unlock()
 	ss.data.Height = h
+
+	// the headers are fetched down from the target, and the tipsets validated
+	// up from the base
+	switch ss.data.Stage {
+	case api.StageHeaders:
+		if ss.data.Target != nil && h <= ss.data.Target.Height() {
+			ss.stage(api.StageHeaders).Epochs = ss.data.Target.Height() - h
+		}
+	case api.StageMessages, api.StageHeaderValidation:
+		if ss.data.Base != nil && h >= ss.data.Base.Height() {
+			ss.stage(ss.data.Stage).Epochs = h - ss.data.Base.Height()
+		}
+	}
+}
+
+// isValidationStage returns whether the stage v is part of the validation of
+// the tipsets synced.
+func isValidationStage(v api.SyncStateStage) bool {
+	return v == api.StageMessages || v == api.StageFetchingMessages || v == api.StageHeaderValidation
 }
 
 func (ss *SyncerState) Error(err error) {
@@ -74,6 +142,7 @@ func (ss *SyncerState) Error(err error) {
 	ss.lk.Lock()
 	defer ss.lk.Unlock()
 	ss.data.Message = err.Error()
+	ss.endStage(build.Clock.Now())
 	ss.data.Stage = api.StageSyncErrored
 	ss.data.End = build.Clock.Now()
 }
@@ -81,5 +150,38 @@ func (ss *SyncerState) Error(err error) {
 func (ss *SyncerState) Snapshot() SyncerStateSnapshot {
 	ss.lk.Lock()
 	defer ss.lk.Unlock()
-	return ss.data
+
+	now := build.Clock.Now()
+	snap := ss.data
+	snap.Stages = make([]api.SyncStageProgress, len(ss.data.Stages))
+	var validated abi.ChainEpoch
+	for i, st := range ss.data.Stages {
+		if st.Stage == ss.data.Stage && !ss.stageStart.IsZero() {
+			st.Time += now.Sub(ss.stageStart)
+		}
+		if st.Time > 0 {
+			st.Rate = float64(st.Epochs) / st.Time.Seconds()
+		}
+		if st.Stage == api.StageMessages || st.Stage == api.StageHeaderValidation {
+			validated = st.Epochs
+		}
+		snap.Stages[i] = st
+	}
+
+	if ss.data.Base == nil || ss.data.Target == nil {
+		return snap
+	}
+	switch ss.data.Stage {
+	case api.StageSyncComplete:
+	case api.StageMessages, api.StageFetchingMessages, api.StageHeaderValidation:
+		snap.EpochsRemaining = ss.data.Target.Height() - ss.data.Base.Height() - validated
+		// the rate of validation includes the time spent fetching messages
+		if elapsed := now.Sub(ss.validateStart); validated > 0 && elapsed > 0 {
+			rate := float64(validated) / elapsed.Seconds()
+			snap.ETA = now.Add(time.Duration(float64(snap.EpochsRemaining) / rate * float64(time.Second)))
+		}
+	default:
+		snap.EpochsRemaining = ss.data.Target.Height() - ss.data.Base.Height()
+	}
+	return snap
 }
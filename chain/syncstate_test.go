@@ -0,0 +1,67 @@
+// stm: #unit
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestSyncerStateProgress(t *testing.T) {
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	blk := mock.MkBlock(genTs, 1, 1)
+	blk.Height = 100
+	target := mock.TipSet(blk)
+
+	var ss SyncerState
+	ss.Init(genTs, target)
+
+	// 99 headers in 20s
+	mc.Add(10 * time.Second)
+	ss.SetHeight(50)
+	mc.Add(10 * time.Second)
+	ss.SetHeight(1)
+	ss.SetStage(api.StagePersistHeaders)
+	mc.Add(5 * time.Second)
+	ss.SetStage(api.StageMessages)
+
+	snap := ss.Snapshot()
+	require.EqualValues(t, 100, snap.EpochsRemaining)
+	require.True(t, snap.ETA.IsZero())
+	require.Len(t, snap.Stages, 3)
+	require.Equal(t, api.StageHeaders, snap.Stages[0].Stage)
+	require.Equal(t, 20*time.Second, snap.Stages[0].Time)
+	require.EqualValues(t, 99, snap.Stages[0].Epochs)
+
+	// 20 tipsets validated in 10s, 5s of them fetching messages
+	ss.SetStage(api.StageFetchingMessages)
+	mc.Add(5 * time.Second)
+	ss.SetStage(api.StageMessages)
+	mc.Add(5 * time.Second)
+	ss.SetHeight(20)
+
+	snap = ss.Snapshot()
+	require.Len(t, snap.Stages, 4)
+	msgs := snap.Stages[2]
+	require.Equal(t, api.StageMessages, msgs.Stage)
+	require.Equal(t, 5*time.Second, msgs.Time)
+	require.Equal(t, float64(4), msgs.Rate)
+	require.EqualValues(t, 80, snap.EpochsRemaining)
+	require.Equal(t, mc.Now().Add(40*time.Second), snap.ETA)
+
+	ss.SetHeight(100)
+	ss.SetStage(api.StageSyncComplete)
+	snap = ss.Snapshot()
+	require.Zero(t, snap.EpochsRemaining)
+	require.Equal(t, mc.Now(), snap.End)
+}
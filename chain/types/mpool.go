@@ -13,6 +13,29 @@ type MpoolConfig struct {
 	ReplaceByFeeRatio      float64
 	PruneCooldown          time.Duration
 	GasLimitOverestimation float64
+	// MaxReplacementsPerEpoch is the number of times a message received from
+	// the network may be replaced by fee per nonce and epoch, 0 for no limit.
+	MaxReplacementsPerEpoch int
+	// ParkNonceGap is how far ahead of the next nonce of its sender a message
+	// received from the network may be, too far to be added, to be parked
+	// until the gap fills. 0 disables parking.
+	ParkNonceGap uint64
+	// ParkTTL is how long a message stays parked before being dropped.
+	ParkTTL time.Duration
+	// SenderMsgsPerEpoch and SenderBytesPerEpoch limit the number and the size
+	// of the messages a sender may add per epoch, from the network or pushed
+	// untrusted, 0 for no limit.
+	SenderMsgsPerEpoch  int
+	SenderBytesPerEpoch int
+	// SpamScoreThreshold is the spam score, the largest fraction of its limits
+	// a sender used in the epoch, over which the messages of the sender must
+	// pay a gas premium of at least SpamPremiumFloor, in attoFIL per unit of
+	// gas. 0 disables the floor.
+	SpamScoreThreshold float64
+	SpamPremiumFloor   uint64
+	// SimulatePushes makes the pool dry-run the messages pushed locally on the
+	// head state, rejecting those that would definitely abort.
+	SimulatePushes bool
 }
 
 func (mc *MpoolConfig) Clone() *MpoolConfig {
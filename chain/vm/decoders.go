@@ -0,0 +1,95 @@
+package vm
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+)
+
+// ActorDecoder decodes the CBOR encoded state, method parameters and return
+// values of the actors of a non builtin code, for the state inspection APIs
+// to render them.
+type ActorDecoder interface {
+	DecodeState(b []byte) (interface{}, error)
+	DecodeParams(method abi.MethodNum, b []byte) (interface{}, error)
+	DecodeReturn(method abi.MethodNum, b []byte) (interface{}, error)
+}
+
+// CBORDecoder is an ActorDecoder unmarshaling the state, parameters and
+// return values into new values of cbor-gen types.
+type CBORDecoder struct {
+	State   func() cbg.CBORUnmarshaler
+	Params  map[abi.MethodNum]func() cbg.CBORUnmarshaler
+	Returns map[abi.MethodNum]func() cbg.CBORUnmarshaler
+}
+
+var _ ActorDecoder = CBORDecoder{}
+
+func (d CBORDecoder) DecodeState(b []byte) (interface{}, error) {
+	if d.State == nil {
+		return nil, xerrors.Errorf("no state type")
+	}
+	return unmarshalNew(d.State, b)
+}
+
+func (d CBORDecoder) DecodeParams(method abi.MethodNum, b []byte) (interface{}, error) {
+	newParams, ok := d.Params[method]
+	if !ok {
+		return nil, xerrors.Errorf("no params type for method %d", method)
+	}
+	return unmarshalNew(newParams, b)
+}
+
+func (d CBORDecoder) DecodeReturn(method abi.MethodNum, b []byte) (interface{}, error) {
+	newRet, ok := d.Returns[method]
+	if !ok {
+		return nil, xerrors.Errorf("no return type for method %d", method)
+	}
+	return unmarshalNew(newRet, b)
+}
+
+func unmarshalNew(newValue func() cbg.CBORUnmarshaler, b []byte) (interface{}, error) {
+	v := newValue()
+	if err := v.UnmarshalCBOR(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+var (
+	decodersLk sync.RWMutex
+	decoders   = make(map[cid.Cid]ActorDecoder)
+)
+
+// RegisterActorDecoder registers the decoder of the actors of code, which
+// must not be a builtin actor code, nor already have a decoder.
+func RegisterActorDecoder(code cid.Cid, d ActorDecoder) error {
+	if builtin.IsBuiltinActor(code) {
+		return xerrors.Errorf("actor code %s is builtin", code)
+	}
+
+	decodersLk.Lock()
+	defer decodersLk.Unlock()
+
+	if _, ok := decoders[code]; ok {
+		return xerrors.Errorf("actor code %s already has a decoder", code)
+	}
+	decoders[code] = d
+	return nil
+}
+
+// GetActorDecoder returns the decoder registered for the actors of code.
+func GetActorDecoder(code cid.Cid) (ActorDecoder, bool) {
+	decodersLk.RLock()
+	defer decodersLk.RUnlock()
+
+	d, ok := decoders[code]
+	return d, ok
+}
@@ -0,0 +1,54 @@
+// stm: #unit
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin0 "github.com/filecoin-project/specs-actors/actors/builtin"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestActorDecoders(t *testing.T) {
+	code, err := abi.CidBuilder.Sum([]byte("user actor"))
+	require.NoError(t, err)
+
+	d := CBORDecoder{
+		State:   func() cbg.CBORUnmarshaler { return new(cbg.CborInt) },
+		Params:  map[abi.MethodNum]func() cbg.CBORUnmarshaler{2: func() cbg.CBORUnmarshaler { return new(cbg.CborCid) }},
+		Returns: map[abi.MethodNum]func() cbg.CBORUnmarshaler{2: func() cbg.CBORUnmarshaler { return new(cbg.CborInt) }},
+	}
+	require.NoError(t, RegisterActorDecoder(code, d))
+	require.Error(t, RegisterActorDecoder(code, d))
+	require.Error(t, RegisterActorDecoder(builtin0.AccountActorCodeID, d))
+
+	got, ok := GetActorDecoder(code)
+	require.True(t, ok)
+	_, ok = GetActorDecoder(builtin0.AccountActorCodeID)
+	require.False(t, ok)
+
+	// the state of the actors without a builtin state type is decoded
+	var buf bytes.Buffer
+	st := cbg.CborInt(42)
+	require.NoError(t, st.MarshalCBOR(&buf))
+	dumped, err := DumpActorState(NewActorRegistry(), &types.Actor{Code: code}, buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, &st, dumped)
+
+	buf.Reset()
+	param := cbg.CborCid(code)
+	require.NoError(t, param.MarshalCBOR(&buf))
+	decoded, err := got.DecodeParams(2, buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, &param, decoded)
+
+	_, err = got.DecodeParams(3, buf.Bytes())
+	require.Error(t, err)
+	_, err = got.DecodeReturn(2, buf.Bytes())
+	require.Error(t, err)
+}
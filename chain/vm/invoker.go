@@ -290,6 +290,13 @@ func DumpActorState(i *ActorRegistry, act *types.Actor, b []byte) (interface{},
 
 	actInfo, ok := i.actors[act.Code]
 	if !ok {
+		if d, ok := GetActorDecoder(act.Code); ok {
+			st, err := d.DecodeState(b)
+			if err != nil {
+				return nil, xerrors.Errorf("decoding actor state: %w", err)
+			}
+			return st, nil
+		}
 		return nil, xerrors.Errorf("state type for actor %s not found", act.Code)
 	}
 
@@ -11,15 +11,20 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multicodec"
 	"github.com/urfave/cli/v2"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
+	"gopkg.in/cheggaaa/pb.v1"
 
 	"github.com/filecoin-project/go-address"
 	cborutil "github.com/filecoin-project/go-cbor-util"
@@ -38,6 +43,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -50,12 +56,18 @@ var ChainCmd = &cli.Command{
 		ChainReadObjCmd,
 		ChainDeleteObjCmd,
 		ChainStatObjCmd,
+		ChainStatCarCmd,
+		ChainVerifySnapshotCmd,
 		ChainGetMsgCmd,
 		ChainSetHeadCmd,
 		ChainListCmd,
+		ChainForkTipsCmd,
+		ChainHeadJournalCmd,
 		ChainGetCmd,
 		ChainBisectCmd,
 		ChainExportCmd,
+		ChainRestoreCmd,
+		ChainReorgCmd,
 		SlashConsensusFault,
 		ChainGasPriceCmd,
 		ChainInspectUsage,
@@ -63,12 +75,20 @@ var ChainCmd = &cli.Command{
 		ChainEncodeCmd,
 		ChainDisputeSetCmd,
 		ChainPruneCmd,
+		ChainPruneHistoryCmd,
+		ChainBlockstoreGCCmd,
+		ChainBlockstoreUsageCmd,
+		ChainStateGarbageCmd,
+		ChainReplCmd,
 	},
 }
 
 var ChainHeadCmd = &cli.Command{
 	Name:  "head",
 	Usage: "Print chain head",
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
@@ -84,6 +104,10 @@ var ChainHeadCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(head)
+		}
+
 		for _, c := range head.Cids() {
 			afmt.Println(c)
 		}
@@ -266,6 +290,7 @@ var ChainStatObjCmd = &cli.Command{
 			Name:  "base",
 			Usage: "ignore links found in this obj",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
@@ -294,12 +319,136 @@ var ChainStatObjCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(stats)
+		}
+
 		afmt.Printf("Links: %d\n", stats.Links)
 		afmt.Printf("Size: %s (%d)\n", types.SizeStr(types.NewInt(stats.Size)), stats.Size)
 		return nil
 	},
 }
 
+var ChainStatCarCmd = &cli.Command{
+	Name:      "stat-car",
+	Usage:     "Analyze a snapshot CAR file without importing it",
+	ArgsUsage: "[file]",
+	Description: `Report the head tipset, the epochs covered, the number of state roots and
+   messages, and the size of the blocks of each codec of an uncompressed
+   snapshot CAR. The file is read in place, no node is needed.
+`,
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+		if !cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("must specify the car file to analyze"))
+		}
+
+		st, err := store.StatSnapshot(ReqContext(cctx), cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(st, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(b))
+			return nil
+		}
+
+		printSnapshotStat(afmt, st)
+		return nil
+	},
+}
+
+var ChainVerifySnapshotCmd = &cli.Command{
+	Name:      "verify-snapshot",
+	Usage:     "Check the integrity of a snapshot CAR file without importing it",
+	ArgsUsage: "[file]",
+	Description: `Check that the data of every block of an uncompressed snapshot CAR matches
+   its CID, that the headers link from the head down to genesis, and that the
+   state tree of the head is complete. The file is read in place, no node is
+   needed. The command fails when the snapshot is invalid.
+`,
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+		if !cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("must specify the car file to verify"))
+		}
+
+		v, err := store.VerifySnapshot(ReqContext(cctx), cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(b))
+		} else {
+			printSnapshotStat(afmt, v.Stat)
+			afmt.Printf("State blocks of the head: %d\n", v.StateBlocks)
+			afmt.Printf("Corrupt blocks: %d\n", v.Corrupt)
+			for _, c := range v.CorruptBlocks {
+				afmt.Printf("  %s\n", c)
+			}
+			if len(v.Problems) > 0 {
+				afmt.Println("Problems:")
+				for _, p := range v.Problems {
+					afmt.Printf("  %s\n", p)
+				}
+			}
+		}
+
+		if !v.Valid() {
+			if !cctx.Bool("json") {
+				afmt.Println("Snapshot is INVALID")
+			}
+			return xerrors.Errorf("snapshot is invalid: %d problems", len(v.Problems))
+		}
+		if !cctx.Bool("json") {
+			afmt.Println("Snapshot is valid")
+		}
+		return nil
+	},
+}
+
+func printSnapshotStat(afmt *AppFmt, st *store.SnapshotStat) {
+	afmt.Printf("Head: %s\n", st.Head)
+	afmt.Printf("Epochs: %d - %d (%d tipsets)\n", st.OldestEpoch, st.HeadEpoch, st.TipSets)
+	if st.StateRoots > 0 {
+		afmt.Printf("State roots: %d (oldest at %d)\n", st.StateRoots, st.OldestStateEpoch)
+	} else {
+		afmt.Printf("State roots: 0\n")
+	}
+	afmt.Printf("Messages: %d\n", st.Messages)
+	afmt.Printf("Blocks: %d\n", st.Blocks)
+	afmt.Printf("Size: %s (%d)\n", types.SizeStr(types.NewInt(st.Bytes)), st.Bytes)
+
+	codecs := make([]uint64, 0, len(st.Codecs))
+	for c := range st.Codecs {
+		codecs = append(codecs, c)
+	}
+	sort.Slice(codecs, func(i, j int) bool {
+		return st.Codecs[codecs[i]].Bytes > st.Codecs[codecs[j]].Bytes
+	})
+
+	afmt.Println("Codecs:")
+	for _, c := range codecs {
+		cst := st.Codecs[c]
+		afmt.Printf("  %s: %d blocks, %s\n", multicodec.Code(c), cst.Blocks, types.SizeStr(types.NewInt(cst.Bytes)))
+	}
+}
+
 var ChainGetMsgCmd = &cli.Command{
 	Name:      "getmessage",
 	Aliases:   []string{"get-message", "get-msg"},
@@ -547,6 +696,132 @@ var ChainInspectUsage = &cli.Command{
 	},
 }
 
+var ChainForkTipsCmd = &cli.Command{
+	Name:  "tips",
+	Usage: "List the tips of the competing forks known to the node",
+	Description: `The tips are the tipsets offered to the node as heads that no other offered
+   tipset extends, and the current head, which is marked with a '*'. FORK is the
+   height at which a tip forks off the chain of the head.`,
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		tips, err := api.ChainGetForkTips(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(tips, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(b))
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "\tHEIGHT\tFORK\tWEIGHT\tBLOCKS\tTIPSET")
+		for _, tip := range tips {
+			head := ""
+			if tip.Heaviest {
+				head = "*"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\t%s\n", head, tip.Height, tip.ForkHeight, tip.Weight, tip.Blocks, tip.Key)
+		}
+		return tw.Flush()
+	},
+}
+
+var ChainHeadJournalCmd = &cli.Command{
+	Name:  "head-journal",
+	Usage: "List the head changes recorded by the node",
+	Description: `Lists the last head changes recorded in the head journal of the node, oldest
+   first, or the ones from the sequence number given with --since. With --replay,
+   the tipsets each change reverted and applied are listed too, in the order head
+   change notifications delivered them.`,
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "since",
+			Usage: "list the head changes from this sequence number",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "maximum number of head changes listed, 0 for all",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "replay",
+			Usage: "list the tipsets reverted and applied by each head change",
+		},
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		q := lapi.HeadJournalQuery{
+			Since: cctx.Uint64("since"),
+			Limit: cctx.Int("limit"),
+		}
+		// without --since, list the last entries
+		q.Reverse = !cctx.IsSet("since")
+		ents, err := api.ChainGetHeadJournal(ctx, q)
+		if err != nil {
+			return err
+		}
+		if q.Reverse {
+			for i, j := 0, len(ents)-1; i < j; i, j = i+1, j-1 {
+				ents[i], ents[j] = ents[j], ents[i]
+			}
+		}
+
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(ents, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(b))
+			return nil
+		}
+
+		if cctx.Bool("replay") {
+			for _, ent := range ents {
+				afmt.Printf("%d %s: %d -> %d\n", ent.Seq, ent.Time.Format(time.RFC3339), ent.FromHeight, ent.ToHeight)
+				for _, tsk := range ent.Revert {
+					afmt.Printf("  revert %s\n", tsk)
+				}
+				for _, tsk := range ent.Apply {
+					afmt.Printf("  apply  %s\n", tsk)
+				}
+			}
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "SEQ\tTIME\tFROM\tTO\tREVERT\tAPPLY\tHEAD")
+		for _, ent := range ents {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%s\n", ent.Seq, ent.Time.Format(time.RFC3339), ent.FromHeight, ent.ToHeight, len(ent.Revert), len(ent.Apply), ent.To)
+		}
+		return tw.Flush()
+	},
+}
+
 var ChainListCmd = &cli.Command{
 	Name:    "list",
 	Aliases: []string{"love"},
@@ -563,6 +838,7 @@ var ChainListCmd = &cli.Command{
 			Name:  "gas-stats",
 			Usage: "view gas statistics for the chain",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
@@ -605,6 +881,14 @@ var ChainListCmd = &cli.Command{
 			tss = append(tss, head)
 		}
 
+		if cctx.Bool("json") {
+			otss := make([]*types.TipSet, 0, len(tss))
+			for i := len(tss) - 1; i >= 0; i-- {
+				otss = append(otss, tss[i])
+			}
+			return afmt.PrintJSON(otss)
+		}
+
 		if cctx.Bool("gas-stats") {
 			otss := make([]*types.TipSet, 0, len(tss))
 			for i := len(tss) - 1; i >= 0; i-- {
@@ -936,7 +1220,24 @@ var ChainBisectCmd = &cli.Command{
     - lotus chain bisect 1 32000 '@Ha:t03/1' jq -e '.[2] > 100000'
 
    For special path elements see 'chain get' help
+
+   With --predicate, tests a comparison of fields of the decoded state of actors
+   instead, joined by '&&', and takes only the min and max heights:
+
+   lotus chain bisect --predicate 'power.TotalQualityAdjPower > 1000000' [min height] [max height]
+
+   The actors are addresses or the names of the singleton actors: system, init,
+   reward, cron, power, market, verifreg, datacap and burnt. The fields are the
+   ones of 'state read-state', array elements by index. Numbers compare as
+   numbers, values ending in FIL as attoFIL, and other values, quoted or not,
+   compare as strings with == and != only.
 `,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "predicate",
+			Usage: "bisect for the first height where this state predicate is true, like 'power.TotalQualityAdjPower > 1000000'",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
@@ -947,6 +1248,37 @@ var ChainBisectCmd = &cli.Command{
 		defer closer()
 		ctx := ReqContext(cctx)
 
+		if cctx.IsSet("predicate") {
+			if cctx.NArg() != 2 {
+				return IncorrectNumArgs(cctx)
+			}
+
+			preds, err := parseStatePredicates(cctx.String("predicate"))
+			if err != nil {
+				return err
+			}
+
+			start, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+			if err != nil {
+				return err
+			}
+
+			end, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+			if err != nil {
+				return err
+			}
+			if start > end {
+				return xerrors.Errorf("min height %d is above the max height %d", start, end)
+			}
+
+			h, err := bisectStatePredicates(ctx, afmt, api, abi.ChainEpoch(start), abi.ChainEpoch(end), preds)
+			if err != nil {
+				return err
+			}
+			afmt.Println(h)
+			return nil
+		}
+
 		if cctx.NArg() < 4 {
 			return IncorrectNumArgs(cctx)
 		}
@@ -1059,50 +1391,249 @@ var ChainExportCmd = &cli.Command{
 			Name:  "recent-stateroots",
 			Usage: "specify the number of recent state roots to include in the export",
 		},
+		&cli.BoolFlag{
+			Name:  "bump-recent-stateroots",
+			Usage: "export finality worth of state roots when fewer are requested, instead of failing",
+		},
 		&cli.BoolFlag{
 			Name: "skip-old-msgs",
 		},
+		&cli.BoolFlag{
+			Name:  "include-receipts",
+			Usage: "include the message receipts of every tipset whose messages are exported",
+		},
+		&cli.Int64Flag{
+			Name:  "start-epoch",
+			Usage: "with --end-epoch, export the chain segment of the tipsets from --start-epoch to --end-epoch instead of a snapshot",
+		},
+		&cli.Int64Flag{
+			Name:  "end-epoch",
+			Usage: "with --start-epoch, export the chain segment of the tipsets from --start-epoch to --end-epoch instead of a snapshot",
+		},
+		&cli.StringSliceFlag{
+			Name:  "codec",
+			Usage: "only write the blocks of these codecs, names or numbers, e.g. raw or dag-cbor",
+		},
+		&cli.BoolFlag{
+			Name:  "carv2",
+			Usage: "write an indexed CARv2 file instead of a plain CARv1 stream",
+		},
+		&cli.StringFlag{
+			Name:  "compress",
+			Usage: "compress the export on the node; one of 'zstd' or 'gzip'",
+		},
+		&cli.IntFlag{
+			Name:  "compression-level",
+			Usage: "compression level to use with --compress; 0 uses the compressor default",
+		},
+		&cli.StringFlag{
+			Name:  "shard-size",
+			Usage: "split the export into shards of at most this size, e.g. 4GiB, written as <outputPath>.0000 and so on, with a manifest",
+		},
+		&cli.BoolFlag{
+			Name:  "progress",
+			Usage: "show a progress bar while the node walks the chain",
+		},
+		&cli.BoolFlag{
+			Name:  "estimate",
+			Usage: "only print the number of blocks and uncompressed size of the export",
+		},
+		&cli.Uint64Flag{
+			Name:  "max-blocks-rate",
+			Usage: "limit the number of blocks the node exports per second",
+		},
+		&cli.StringFlag{
+			Name:  "max-bandwidth",
+			Usage: "limit the rate at which the node exports block data, e.g. 50MiB (per second)",
+		},
+		&cli.Int64Flag{
+			Name:  "pause-behind",
+			Usage: "pause the export while the node's head is more than this many epochs behind, so it can catch up",
+		},
+		&cli.BoolFlag{
+			Name:  "manifest",
+			Usage: "write a manifest with the roots, epochs and sha256 of the export to <outputPath>" + store.ManifestSuffix,
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "resume an interrupted export to <outputPath>, with the tipset and options it was started with",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
 		defer closer()
 		ctx := ReqContext(cctx)
 
-		if !cctx.Args().Present() {
+		if !cctx.Args().Present() && !cctx.Bool("estimate") {
 			return fmt.Errorf("must specify filename to export chain to")
 		}
+		path := cctx.Args().First()
 
-		rsrs := abi.ChainEpoch(cctx.Int64("recent-stateroots"))
-		if cctx.IsSet("recent-stateroots") && rsrs < build.Finality {
-			return fmt.Errorf("\"recent-stateroots\" has to be greater than %d", build.Finality)
+		var ts *types.TipSet
+		var p exportParams
+		if cctx.Bool("resume") {
+			if cctx.Bool("estimate") || cctx.Bool("carv2") || cctx.IsSet("shard-size") {
+				return xerrors.Errorf("--resume cannot be combined with --estimate, --carv2 or --shard-size")
+			}
+			if p, err = readExportParams(path + exportParamsSuffix); err != nil {
+				return err
+			}
+			if ts, err = api.ChainGetTipSet(ctx, p.Tipset); err != nil {
+				return xerrors.Errorf("loading the tipset of the export: %w", err)
+			}
+		} else {
+			if ts, err = LoadTipSet(ctx, cctx, &v0api.WrapperV1Full{FullNode: api}); err != nil {
+				return err
+			}
+			if p, err = exportParamsFromFlags(cctx, ts); err != nil {
+				return err
+			}
 		}
+		opts := p.Opts
+		ranged := opts.RangeEnd != 0
 
-		fi, err := createExportFile(cctx.App, cctx.Args().First())
-		if err != nil {
-			return err
-		}
-		defer func() {
-			err := fi.Close()
+		if cctx.Bool("estimate") {
+			if ranged || len(opts.Codecs) > 0 {
+				return xerrors.Errorf("--estimate cannot be combined with a range of epochs or --codec")
+			}
+			est, err := api.ChainExportEstimate(ctx, p.RecentRoots, p.SkipOldMessages, ts.Key(), lapi.ChainExportOpts{
+				IncludeReceipts: opts.IncludeReceipts,
+			})
 			if err != nil {
-				fmt.Printf("error closing output file: %+v", err)
+				return err
 			}
-		}()
 
-		ts, err := LoadTipSet(ctx, cctx, api)
-		if err != nil {
-			return err
+			afmt := NewAppFmt(cctx.App)
+			afmt.Printf("Blocks: %d\n", est.Blocks)
+			afmt.Printf("Size: %s (%d bytes)\n", types.SizeStr(types.NewInt(est.Bytes)), est.Bytes)
+			return nil
 		}
 
-		skipold := cctx.Bool("skip-old-msgs")
-
-		if rsrs == 0 && skipold {
+		if p.RecentRoots == 0 && p.SkipOldMessages {
 			return fmt.Errorf("must pass recent stateroots along with skip-old-msgs")
 		}
 
-		stream, err := api.ChainExport(ctx, rsrs, skipold, ts.Key())
+		var shardSize uint64
+		if cctx.IsSet("shard-size") {
+			if shardSize, err = humanize.ParseBytes(cctx.String("shard-size")); err != nil {
+				return xerrors.Errorf("parse --shard-size: %w", err)
+			}
+			if opts.Compression != "" || cctx.Bool("carv2") || p.Manifest {
+				return xerrors.Errorf("--shard-size cannot be combined with --compress, --carv2 or --manifest, shards have a manifest")
+			}
+		}
+		if (p.Manifest || shardSize > 0) && ranged {
+			return xerrors.Errorf("--manifest and --shard-size cannot be combined with a range of epochs")
+		}
+
+		var out io.Writer
+		var pw *io.PipeWriter
+		var finished chan error
+		var sharded *store.SnapshotManifest
+		var paramsPath string
+		if shardSize > 0 {
+			var pr *io.PipeReader
+			pr, pw = io.Pipe()
+			finished = make(chan error, 1)
+			go func() {
+				m, err := store.WriteShards(pr, path, shardSize)
+				pr.CloseWithError(err) //nolint:errcheck // it is a pipe
+				sharded = m
+				finished <- err
+			}()
+
+			out = pw
+			defer pw.Close() //nolint:errcheck
+		} else {
+			var fi io.WriteCloser
+			if cctx.Bool("resume") {
+				f, err := os.OpenFile(path, os.O_RDWR, 0)
+				if err != nil {
+					return xerrors.Errorf("opening the output of the export: %w", err)
+				}
+				st, err := f.Stat()
+				if err != nil {
+					_ = f.Close()
+					return err
+				}
+				_, _ = fmt.Fprintf(cctx.App.ErrWriter, "Resuming the export of %s, %s already written\n", ts.Key(), types.SizeStr(types.NewInt(uint64(st.Size()))))
+				fi = &resumeWriter{f: f, left: st.Size()}
+			} else if fi, err = createExportFile(cctx.App, path); err != nil {
+				return err
+			}
+			defer func() {
+				err := fi.Close()
+				if err != nil {
+					fmt.Printf("error closing output file: %+v", err)
+				}
+			}()
+			out = fi
+
+			// exports of uncompressed CARv1 files to disk can be resumed, the
+			// node writes the same stream for the same tipset and options
+			_, isFile := fi.(*os.File)
+			if _, resumed := fi.(*resumeWriter); (isFile || resumed) && opts.Compression == "" && !cctx.Bool("carv2") {
+				paramsPath = path + exportParamsSuffix
+				if err := writeExportParams(paramsPath, p); err != nil {
+					return err
+				}
+			}
+		}
+
+		var mw *store.ManifestWriter
+		if p.Manifest {
+			if opts.Compression != "" || cctx.Bool("carv2") {
+				return xerrors.Errorf("--manifest cannot be combined with --compress or --carv2")
+			}
+			mw = store.NewManifestWriter(out)
+			out = mw
+		}
+
+		if cctx.Bool("carv2") {
+			if opts.Compression != "" {
+				return xerrors.Errorf("--carv2 cannot be combined with --compress")
+			}
+
+			ws, ok := out.(io.WriteSeeker)
+			if !ok {
+				return xerrors.Errorf("carv2 export requires a seekable output file")
+			}
+
+			var pr *io.PipeReader
+			pr, pw = io.Pipe()
+			finished = make(chan error, 1)
+			go func() {
+				err := store.WriteCARv2(pr, ws)
+				pr.CloseWithError(err) //nolint:errcheck // it is a pipe
+				finished <- err
+			}()
+
+			out = pw
+			defer pw.Close() //nolint:errcheck
+		}
+
+		if cctx.Bool("progress") {
+			// subscribe before starting the export so the first reports aren't missed
+			pctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			progress, err := api.ChainExportProgress(pctx)
+			if err != nil {
+				return xerrors.Errorf("subscribing to export progress: %w", err)
+			}
+
+			done := make(chan struct{})
+			go showExportProgress(cctx.App.ErrWriter, ts, opts.RangeStart, progress, done)
+			defer func() {
+				cancel()
+				<-done
+			}()
+		}
+
+		stream, err := api.ChainExport(ctx, p.RecentRoots, p.SkipOldMessages, ts.Key(), opts)
 		if err != nil {
 			return err
 		}
@@ -1111,7 +1642,7 @@ var ChainExportCmd = &cli.Command{
 		for b := range stream {
 			last = len(b) == 0
 
-			_, err := fi.Write(b)
+			_, err := out.Write(b)
 			if err != nil {
 				return err
 			}
@@ -1121,8 +1652,340 @@ var ChainExportCmd = &cli.Command{
 			return xerrors.Errorf("incomplete export (remote connection lost?)")
 		}
 
-		return nil
-	},
+		if pw != nil {
+			if err := pw.Close(); err != nil {
+				return err
+			}
+			if err := <-finished; err != nil {
+				return xerrors.Errorf("writing export: %w", err)
+			}
+		}
+
+		var m *store.SnapshotManifest
+		switch {
+		case mw != nil:
+			m = mw.Manifest(ts, p.RecentRoots)
+		case sharded != nil:
+			m = sharded
+			em := store.NewManifestWriter(io.Discard).Manifest(ts, p.RecentRoots)
+			m.HeadEpoch, m.OldestStateEpoch = em.HeadEpoch, em.OldestStateEpoch
+		}
+		if m != nil {
+			nn, err := api.StateNetworkName(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting network name: %w", err)
+			}
+			m.NetworkName = string(nn)
+			if m.NetworkVersion, err = api.StateNetworkVersion(ctx, ts.Key()); err != nil {
+				return xerrors.Errorf("getting network version: %w", err)
+			}
+			if err := store.WriteManifest(path+store.ManifestSuffix, m); err != nil {
+				return err
+			}
+		}
+
+		if paramsPath != "" {
+			if err := os.Remove(paramsPath); err != nil {
+				return xerrors.Errorf("removing the parameters of the export: %w", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// exportParamsSuffix is appended to the path of an export to get the path of
+// the parameters saved while it runs.
+const exportParamsSuffix = ".export.json"
+
+// exportParams are the parameters of a chain export, saved next to its output
+// while it runs so that it can be resumed with --resume if interrupted.
+type exportParams struct {
+	Tipset          types.TipSetKey
+	RecentRoots     abi.ChainEpoch
+	SkipOldMessages bool
+	Opts            lapi.ChainExportOpts
+	Manifest        bool
+}
+
+func exportParamsFromFlags(cctx *cli.Context, ts *types.TipSet) (exportParams, error) {
+	p := exportParams{
+		Tipset:          ts.Key(),
+		SkipOldMessages: cctx.Bool("skip-old-msgs"),
+		Manifest:        cctx.Bool("manifest"),
+		Opts: lapi.ChainExportOpts{
+			Compression:      cctx.String("compress"),
+			CompressionLevel: cctx.Int("compression-level"),
+			IncludeReceipts:  cctx.Bool("include-receipts"),
+			MaxBlocksPerSec:  cctx.Uint64("max-blocks-rate"),
+			PauseBehind:      abi.ChainEpoch(cctx.Int64("pause-behind")),
+		},
+	}
+
+	if cctx.IsSet("start-epoch") || cctx.IsSet("end-epoch") {
+		if !cctx.IsSet("end-epoch") || cctx.Int64("end-epoch") <= 0 {
+			return p, xerrors.Errorf("a range of epochs needs an --end-epoch above 0")
+		}
+		if cctx.IsSet("recent-stateroots") || p.SkipOldMessages {
+			return p, xerrors.Errorf("--recent-stateroots and --skip-old-msgs don't apply to a range of epochs")
+		}
+		p.Opts.RangeStart = abi.ChainEpoch(cctx.Int64("start-epoch"))
+		p.Opts.RangeEnd = abi.ChainEpoch(cctx.Int64("end-epoch"))
+	} else {
+		policy := store.RecentRootsReject
+		if cctx.Bool("bump-recent-stateroots") {
+			policy = store.RecentRootsBump
+		}
+		rsrs, err := store.CheckRecentRoots(ts, abi.ChainEpoch(cctx.Int64("recent-stateroots")), policy)
+		if err != nil {
+			return p, err
+		}
+		p.RecentRoots = rsrs
+	}
+
+	for _, name := range cctx.StringSlice("codec") {
+		var c multicodec.Code
+		if err := c.Set(name); err != nil {
+			return p, xerrors.Errorf("parse --codec: %w", err)
+		}
+		p.Opts.Codecs = append(p.Opts.Codecs, uint64(c))
+	}
+
+	if cctx.IsSet("max-bandwidth") {
+		bw, err := humanize.ParseBytes(cctx.String("max-bandwidth"))
+		if err != nil {
+			return p, xerrors.Errorf("parse --max-bandwidth: %w", err)
+		}
+		p.Opts.MaxBytesPerSec = bw
+	}
+
+	return p, nil
+}
+
+func writeExportParams(path string, p exportParams) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return xerrors.Errorf("writing the parameters of the export: %w", err)
+	}
+	return nil
+}
+
+func readExportParams(path string) (exportParams, error) {
+	var p exportParams
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, xerrors.Errorf("no interrupted export to resume: %s not found", path)
+		}
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, xerrors.Errorf("parsing %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// resumeWriter writes the stream of an export resumed over the partial output
+// of the interrupted one: the part of the stream the file already holds is
+// compared with it, the rest is appended.
+type resumeWriter struct {
+	f *os.File
+	// left is the size of the part of the file not compared yet
+	left int64
+	buf  []byte
+}
+
+func (rw *resumeWriter) Write(p []byte) (int, error) {
+	var n int
+	for rw.left > 0 && len(p) > 0 {
+		k := len(p)
+		if int64(k) > rw.left {
+			k = int(rw.left)
+		}
+		if cap(rw.buf) < k {
+			rw.buf = make([]byte, k)
+		}
+		if _, err := io.ReadFull(rw.f, rw.buf[:k]); err != nil {
+			return n, xerrors.Errorf("reading the partial export: %w", err)
+		}
+		if !bytes.Equal(rw.buf[:k], p[:k]) {
+			return n, xerrors.Errorf("the export differs from the partial export it resumes, remove it to start over")
+		}
+		rw.left -= int64(k)
+		n += k
+		p = p[k:]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	m, err := rw.f.Write(p)
+	return n + m, err
+}
+
+func (rw *resumeWriter) Close() error {
+	return rw.f.Close()
+}
+
+// showExportProgress renders a progress bar for the export of ts, walking the
+// chain down to bottom, using the first export of that tipset that reports
+// progress.
+func showExportProgress(w io.Writer, ts *types.TipSet, bottom abi.ChainEpoch, progress <-chan lapi.ExportProgress, done chan struct{}) {
+	defer close(done)
+
+	bar := pb.New64(int64(ts.Height() - bottom))
+	bar.Output = w
+	bar.ShowTimeLeft = true
+	bar.ShowPercent = true
+	bar.Start()
+	defer bar.Finish()
+
+	var id uint64
+	for p := range progress {
+		if id == 0 && p.Tipset == ts.Key() {
+			id = p.ID
+		}
+		if p.ID != id {
+			continue
+		}
+
+		bar.Set64(int64(p.Height - p.Epoch))
+		bar.Postfix(fmt.Sprintf(" %d blocks, %s", p.Blocks, types.SizeStr(types.NewInt(p.Bytes))))
+		if p.Done {
+			return
+		}
+	}
+}
+
+var ChainRestoreCmd = &cli.Command{
+	Name:  "restore",
+	Usage: "Replace the chain of a running node with a snapshot",
+	Description: `The snapshot is imported into a standby blockstore while the node keeps
+   serving from its current one. Once it is imported and verified, the node
+   switches over to it and takes the root of the snapshot as its head, the
+   current blockstore is removed.
+
+   The snapshot is read by the node: the path is on the filesystem of the node.
+   It may be a CAR, a compressed CAR, or the manifest of a sharded snapshot.`,
+	ArgsUsage: "[snapshot]",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must specify the snapshot to restore"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		path, err := filepath.Abs(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		afmt.Printf("Restoring %s, this takes as long as importing it...\n", path)
+		ts, err := api.ChainRestoreSnapshot(ctx, path)
+		if err != nil {
+			return err
+		}
+
+		afmt.Printf("Restored, new head at height %d: %s\n", ts.Height(), ts.Cids())
+		return nil
+	},
+}
+
+var ChainReorgCmd = &cli.Command{
+	Name:  "reorg",
+	Usage: "Inspect and resolve the reorg held back for confirmation",
+	Description: `Reorgs reverting more epochs of the chain than the Chainstore.ReorgConfirmDepth
+   of the config are held back, and an alert raised, until they are accepted or
+   rejected. Only the heaviest held fork is pending at a time.`,
+	Subcommands: []*cli.Command{
+		ChainReorgStatusCmd,
+		ChainReorgAcceptCmd,
+		ChainReorgRejectCmd,
+	},
+}
+
+var ChainReorgStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Print the pending reorg",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		pr, err := api.ChainGetPendingReorg(ctx)
+		if err != nil {
+			return err
+		}
+		if pr == nil {
+			afmt.Println("No reorg is pending")
+			return nil
+		}
+
+		afmt.Printf("Pending reorg of depth %d, seen %s\n", pr.Depth, pr.Seen.Format(time.RFC3339))
+		afmt.Printf("From:     %d: %s\n", pr.FromHeight, pr.From)
+		afmt.Printf("To:       %d: %s\n", pr.ToHeight, pr.To)
+		afmt.Printf("Ancestor: %d: %s\n", pr.AncestorHeight, pr.Ancestor)
+		return nil
+	},
+}
+
+var ChainReorgAcceptCmd = &cli.Command{
+	Name:  "accept",
+	Usage: "Accept the pending reorg, taking the head of its fork as the head",
+	Action: func(cctx *cli.Context) error {
+		return resolveReorg(cctx, true)
+	},
+}
+
+var ChainReorgRejectCmd = &cli.Command{
+	Name:  "reject",
+	Usage: "Reject the pending reorg, ignoring its fork from then on",
+	Action: func(cctx *cli.Context) error {
+		return resolveReorg(cctx, false)
+	},
+}
+
+func resolveReorg(cctx *cli.Context, accept bool) error {
+	afmt := NewAppFmt(cctx.App)
+
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := ReqContext(cctx)
+
+	pr, err := api.ChainGetPendingReorg(ctx)
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		return fmt.Errorf("no reorg is pending")
+	}
+
+	if err := api.ChainResolveReorg(ctx, pr.To, accept); err != nil {
+		return err
+	}
+	if accept {
+		afmt.Printf("Accepted reorg of depth %d, new head at height %d: %s\n", pr.Depth, pr.ToHeight, pr.To)
+	} else {
+		afmt.Printf("Rejected reorg of depth %d to height %d: %s\n", pr.Depth, pr.ToHeight, pr.To)
+	}
+	return nil
 }
 
 var SlashConsensusFault = &cli.Command{
@@ -1255,9 +2118,24 @@ var SlashConsensusFault = &cli.Command{
 var ChainGasPriceCmd = &cli.Command{
 	Name:  "gas-price",
 	Usage: "Estimate gas prices",
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "history",
+			Usage: "suggest fees from the percentiles of the premiums paid in the given number of recent tipsets",
+		},
+		&cli.Float64SliceFlag{
+			Name:  "percentile",
+			Usage: "percentiles of the premiums paid to suggest fees at, with --history (default: 25, 50, 90)",
+		},
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
+		if cctx.IsSet("history") {
+			return chainGasPricePercentiles(cctx, afmt)
+		}
+
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
 			return err
@@ -1265,7 +2143,13 @@ var ChainGasPriceCmd = &cli.Command{
 		defer closer()
 		ctx := ReqContext(cctx)
 
+		type premiumEstimate struct {
+			Blocks     int
+			GasPremium abi.TokenAmount
+		}
+
 		nb := []int{1, 2, 3, 5, 10, 20, 50, 100, 300}
+		ests := make([]premiumEstimate, 0, len(nb))
 		for _, nblocks := range nb {
 			addr := builtin.SystemActorAddr // TODO: make real when used in GasEstimateGasPremium
 
@@ -1274,13 +2158,46 @@ var ChainGasPriceCmd = &cli.Command{
 				return err
 			}
 
-			afmt.Printf("%d blocks: %s (%s)\n", nblocks, est, types.FIL(est))
+			ests = append(ests, premiumEstimate{Blocks: nblocks, GasPremium: est})
+		}
+
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(ests)
+		}
+
+		for _, est := range ests {
+			afmt.Printf("%d blocks: %s (%s)\n", est.Blocks, est.GasPremium, types.FIL(est.GasPremium))
 		}
 
 		return nil
 	},
 }
 
+func chainGasPricePercentiles(cctx *cli.Context, afmt *AppFmt) error {
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := ReqContext(cctx)
+
+	fees, err := api.GasEstimateFeeCapPercentile(ctx, cctx.Uint64("history"), cctx.Float64Slice("percentile"), types.EmptyTSK)
+	if err != nil {
+		return err
+	}
+
+	if cctx.Bool("json") {
+		return afmt.PrintJSON(fees)
+	}
+
+	afmt.Printf("base fee: %s, from %d messages in %d tipsets\n", types.FIL(fees.BaseFee), fees.Messages, fees.Tipsets)
+	for _, s := range fees.Suggestions {
+		afmt.Printf("%gth percentile: premium %s (%s), fee cap %s (%s)\n", s.Percentile, s.GasPremium, types.FIL(s.GasPremium), s.GasFeeCap, types.FIL(s.GasFeeCap))
+	}
+
+	return nil
+}
+
 var ChainDecodeCmd = &cli.Command{
 	Name:  "decode",
 	Usage: "decode various types",
@@ -1509,3 +2426,270 @@ var ChainPruneCmd = &cli.Command{
 		return api.ChainPrune(ctx, opts)
 	},
 }
+
+var ChainPruneHistoryCmd = &cli.Command{
+	Name:  "prune-history",
+	Usage: "Delete the block headers, messages and receipts older than a retention",
+	Description: `The history kept below the head is given in epochs, it is at least finality.
+   The genesis, the checkpoint and the roots of the snapshots exported by the node
+   are kept. The state is left alone, see 'lotus chain prune' for the splitstore.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:     "retention",
+			Usage:    "number of epochs of history to keep",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only report what would be pruned",
+		},
+		&cli.BoolFlag{
+			Name:  "offload",
+			Usage: "move the history to the cold store of the node instead of deleting it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		res, err := api.ChainPruneHistory(ctx, lapi.PruneHistoryOpts{
+			Retention: abi.ChainEpoch(cctx.Int64("retention")),
+			DryRun:    cctx.Bool("dry-run"),
+			Offload:   cctx.Bool("offload"),
+		})
+		if err != nil {
+			return err
+		}
+
+		verb := "Pruned"
+		if res.Offload {
+			verb = "Offloaded"
+		}
+		if res.DryRun {
+			verb = "Would prune"
+			if res.Offload {
+				verb = "Would offload"
+			}
+		}
+		afmt.Printf("%s %d tipsets below height %d: %d headers, %d message blocks, %d receipt blocks, %s\n",
+			verb, res.Tipsets, res.Horizon, res.Headers, res.Messages, res.Receipts, types.SizeStr(types.NewInt(res.Bytes)))
+		return nil
+	},
+}
+
+var ChainBlockstoreGCCmd = &cli.Command{
+	Name:  "blockstore-gc",
+	Usage: "Manage the online garbage collection of the blockstore",
+	Description: `GC cycles run every Chainstore.BlockstoreGC.Interval of the config, within its
+   windows, and wait while the node is syncing or the system is loaded. The
+   splitstore collects its own garbage, see 'lotus chain prune'.`,
+	Subcommands: []*cli.Command{
+		ChainBlockstoreGCStatusCmd,
+		ChainBlockstoreGCStartCmd,
+		ChainBlockstoreGCAbortCmd,
+	},
+}
+
+var ChainBlockstoreGCStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Print the running and the last GC cycles",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainBlockstoreGCStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if st.Interval > 0 {
+			afmt.Printf("Scheduled every %s\n", st.Interval)
+		} else {
+			afmt.Println("Not scheduled")
+		}
+		printCycle := func(name string, c *lapi.BlockstoreGCCycle) {
+			if c == nil {
+				afmt.Printf("%s: none\n", name)
+				return
+			}
+			afmt.Printf("%s: started %s, %d rounds, throttled for %s", name, c.Started.Format(time.RFC3339), c.Rounds, c.Throttled.Round(time.Second))
+			switch {
+			case c.Throttling:
+				afmt.Print(", waiting for the node to be less busy")
+			case c.Aborted:
+				afmt.Print(", aborted")
+			}
+			if !c.Finished.IsZero() {
+				afmt.Printf(", took %s", c.Finished.Sub(c.Started).Round(time.Second))
+			}
+			if c.Error != "" {
+				afmt.Printf(", failed: %s", c.Error)
+			}
+			afmt.Println()
+		}
+		printCycle("Running", st.Running)
+		printCycle("Last", st.Last)
+		return nil
+	},
+}
+
+var ChainBlockstoreGCStartCmd = &cli.Command{
+	Name:  "start",
+	Usage: "Start a GC cycle now, outside of the GC windows",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.ChainBlockstoreGC(ReqContext(cctx))
+	},
+}
+
+var ChainBlockstoreGCAbortCmd = &cli.Command{
+	Name:  "abort",
+	Usage: "Abort the running GC cycle",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.ChainBlockstoreGCAbort(ReqContext(cctx))
+	},
+}
+
+var ChainBlockstoreUsageCmd = &cli.Command{
+	Name:  "blockstore-usage",
+	Usage: "Report the space used by the blockstore, per tier and codec",
+	Description: `Scans every block of the hot, cold and chain history stores, reporting the
+   blocks stored in more than one of them, the bytes used per codec and the
+   largest objects. The scan reads the whole blockstore and can take hours on
+   a full node; while offline, see 'lotus-shed blockstore-usage'.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of largest objects to report",
+			Value: 20,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		u, err := api.ChainBlockstoreUsage(ReqContext(cctx), cctx.Int("top"))
+		if err != nil {
+			return err
+		}
+
+		PrintBlockstoreUsage(NewAppFmt(cctx.App), u)
+		return nil
+	},
+}
+
+var ChainStateGarbageCmd = &cli.Command{
+	Name:  "state-garbage",
+	Usage: "Estimate the state objects unreachable from the latest state roots",
+	Description: `Walks the chain and the state trees of the latest epochs, then the older state
+   roots, and scans the state blockstore, reporting the objects only reachable
+   from the older roots per bucket of epochs, and those reachable from none of
+   them. This guides how many epochs of state the splitstore should retain.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "keep",
+			Usage: "number of epochs of state trees kept below the head",
+			Value: int64(build.Finality),
+		},
+		&cli.Int64Flag{
+			Name:  "bucket",
+			Usage: "number of epochs of the buckets of older state roots",
+			Value: int64(builtin.EpochsInDay),
+		},
+		&cli.Int64Flag{
+			Name:  "lookback",
+			Usage: "number of epochs of older state roots walked, all of them when 0",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		g, err := api.ChainStateGarbage(ReqContext(cctx), lapi.StateGarbageOpts{
+			Keep:     abi.ChainEpoch(cctx.Int64("keep")),
+			Bucket:   abi.ChainEpoch(cctx.Int64("bucket")),
+			Lookback: abi.ChainEpoch(cctx.Int64("lookback")),
+		})
+		if err != nil {
+			return err
+		}
+
+		size := func(o lapi.BlockstoreObjects) string {
+			return fmt.Sprintf("%d objects, %s", o.Count, types.SizeStr(types.NewInt(o.Bytes)))
+		}
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Head: %d, keeping the states above %d\n", g.Height, g.Height-g.Keep)
+		afmt.Printf("Total: %s\n", size(g.Total))
+		afmt.Printf("Live: %s\n", size(g.Live))
+		afmt.Printf("Dangling: %s\n", size(g.Dangling))
+		afmt.Printf("\nOnly reachable from older state roots, down to %d:\n", g.Lowest)
+		for _, b := range g.Buckets {
+			afmt.Printf("  %d-%d (%d roots): %s\n", b.From, b.To, b.Roots, size(b.Objects))
+		}
+		return nil
+	},
+}
+
+// PrintBlockstoreUsage prints a blockstore usage report.
+func PrintBlockstoreUsage(afmt *AppFmt, u lapi.BlockstoreUsage) {
+	size := func(o lapi.BlockstoreObjects) string {
+		return fmt.Sprintf("%d objects, %s", o.Count, types.SizeStr(types.NewInt(o.Bytes)))
+	}
+
+	afmt.Println("Tiers:")
+	for _, t := range u.Tiers {
+		afmt.Printf("  %s: %s", t.Name, size(t.Objects))
+		if t.Duplicates.Count > 0 {
+			afmt.Printf(" (%s also in an earlier tier)", size(t.Duplicates))
+		}
+		afmt.Println()
+	}
+	afmt.Printf("Total: %s\n", size(u.Total))
+	afmt.Printf("Duplicates: %s\n", size(u.Duplicates))
+
+	codecs := make([]string, 0, len(u.Codecs))
+	for c := range u.Codecs {
+		codecs = append(codecs, c)
+	}
+	sort.Slice(codecs, func(i, j int) bool {
+		return u.Codecs[codecs[i]].Bytes > u.Codecs[codecs[j]].Bytes
+	})
+	afmt.Println("\nCodecs:")
+	for _, c := range codecs {
+		afmt.Printf("  %s: %s\n", c, size(u.Codecs[c]))
+	}
+
+	afmt.Println("\nLargest objects:")
+	for _, o := range u.Largest {
+		afmt.Printf("  %s %s, %s in %s\n", o.Cid, o.Codec, types.SizeStr(types.NewInt(o.Size)), o.Tier)
+	}
+}
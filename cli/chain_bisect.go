@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// predicateActors are the names of the singleton actors in state predicates.
+var predicateActors = map[string]address.Address{
+	"system":   builtintypes.SystemActorAddr,
+	"init":     builtintypes.InitActorAddr,
+	"reward":   builtintypes.RewardActorAddr,
+	"cron":     builtintypes.CronActorAddr,
+	"power":    builtintypes.StoragePowerActorAddr,
+	"market":   builtintypes.StorageMarketActorAddr,
+	"verifreg": builtintypes.VerifiedRegistryActorAddr,
+	"datacap":  builtintypes.DatacapActorAddr,
+	"burnt":    builtintypes.BurntFundsActorAddr,
+}
+
+// predicateOps are the comparisons of state predicates, the ones that are a
+// prefix of others last.
+var predicateOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// statePredicate compares a field of the decoded state of an actor with a
+// value, like 'power.TotalQualityAdjPower > 1000'.
+type statePredicate struct {
+	Actor address.Address
+	// Path is the path of the field in the state, array elements by index
+	Path  []string
+	Op    string
+	Value string
+}
+
+// parseStatePredicates parses the predicates of expr joined by '&&'.
+func parseStatePredicates(expr string) ([]statePredicate, error) {
+	var preds []statePredicate
+	for _, s := range strings.Split(expr, "&&") {
+		p, err := parseStatePredicate(s)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing '%s': %w", strings.TrimSpace(s), err)
+		}
+		preds = append(preds, p)
+	}
+	return preds, nil
+}
+
+func parseStatePredicate(s string) (statePredicate, error) {
+	for _, op := range predicateOps {
+		i := strings.Index(s, op)
+		if i < 0 {
+			continue
+		}
+
+		lhs, rhs := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(op):])
+		path := strings.Split(lhs, ".")
+		if len(path) < 2 {
+			return statePredicate{}, xerrors.Errorf("expected actor.field, got '%s'", lhs)
+		}
+
+		actor, ok := predicateActors[path[0]]
+		if !ok {
+			var err error
+			if actor, err = address.NewFromString(path[0]); err != nil {
+				return statePredicate{}, xerrors.Errorf("'%s' is neither an actor name nor an address", path[0])
+			}
+		}
+
+		value, err := parsePredicateValue(rhs)
+		if err != nil {
+			return statePredicate{}, err
+		}
+
+		return statePredicate{Actor: actor, Path: path[1:], Op: op, Value: value}, nil
+	}
+
+	return statePredicate{}, xerrors.Errorf("no comparison, expected one of %s", strings.Join(predicateOps, " "))
+}
+
+// parsePredicateValue unquotes quoted values and converts FIL amounts to
+// attoFIL.
+func parsePredicateValue(v string) (string, error) {
+	switch {
+	case v == "":
+		return "", xerrors.Errorf("missing value")
+	case strings.HasPrefix(v, `"`):
+		return strconv.Unquote(v)
+	case strings.HasSuffix(strings.ToLower(v), "fil"):
+		f, err := types.ParseFIL(v)
+		if err != nil {
+			return "", err
+		}
+		return big.Int(f).String(), nil
+	}
+	return v, nil
+}
+
+// eval evaluates p against the state of its actor, as returned by
+// StateReadState.
+func (p statePredicate) eval(state interface{}) (bool, error) {
+	// decode the state again so that big numbers don't lose precision
+	b, err := json.Marshal(state)
+	if err != nil {
+		return false, err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return false, err
+	}
+
+	for i, f := range p.Path {
+		switch n := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = n[f]; !ok {
+				return false, xerrors.Errorf("state has no field %s", strings.Join(p.Path[:i+1], "."))
+			}
+		case []interface{}:
+			idx, err := strconv.Atoi(f)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return false, xerrors.Errorf("%s is not an index of %s", f, strings.Join(p.Path[:i], "."))
+			}
+			v = n[idx]
+		default:
+			return false, xerrors.Errorf("%s has no fields", strings.Join(p.Path[:i], "."))
+		}
+	}
+
+	var got string
+	switch v := v.(type) {
+	case string:
+		got = v
+	case json.Number:
+		got = v.String()
+	case bool:
+		got = strconv.FormatBool(v)
+	case nil:
+		got = "null"
+	default:
+		return false, xerrors.Errorf("%s is not a value", strings.Join(p.Path, "."))
+	}
+
+	gotNum, gerr := big.FromString(got)
+	wantNum, werr := big.FromString(p.Value)
+	if gerr == nil && werr == nil {
+		cmp := big.Cmp(gotNum, wantNum)
+		switch p.Op {
+		case "==":
+			return cmp == 0, nil
+		case "!=":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		case ">=":
+			return cmp >= 0, nil
+		}
+	}
+
+	switch p.Op {
+	case "==":
+		return got == p.Value, nil
+	case "!=":
+		return got != p.Value, nil
+	}
+	return false, xerrors.Errorf("%s needs numbers, got '%s' and '%s'", p.Op, got, p.Value)
+}
+
+// evalStatePredicates evaluates preds in the parent state of ts.
+func evalStatePredicates(ctx context.Context, api v0api.FullNode, ts *types.TipSet, preds []statePredicate) (bool, error) {
+	states := map[address.Address]interface{}{}
+	for _, p := range preds {
+		st, ok := states[p.Actor]
+		if !ok {
+			as, err := api.StateReadState(ctx, p.Actor, ts.Key())
+			if err != nil {
+				return false, xerrors.Errorf("reading state of %s at %d: %w", p.Actor, ts.Height(), err)
+			}
+			st = as.State
+			states[p.Actor] = st
+		}
+
+		ok, err := p.eval(st)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// bisectStatePredicates returns the first epoch between start and end in whose
+// parent state preds are true, assuming they stay true after it.
+func bisectStatePredicates(ctx context.Context, afmt *AppFmt, api v0api.FullNode, start, end abi.ChainEpoch, preds []statePredicate) (abi.ChainEpoch, error) {
+	highest, err := api.ChainGetTipSetByHeight(ctx, end, types.EmptyTSK)
+	if err != nil {
+		return 0, xerrors.Errorf("getting end tipset: %w", err)
+	}
+
+	test := func(h abi.ChainEpoch) (*types.TipSet, bool, error) {
+		ts, err := api.ChainGetTipSetByHeight(ctx, h, highest.Key())
+		if err != nil {
+			return nil, false, err
+		}
+		afmt.Printf("* Testing %d (%d - %d): ", ts.Height(), start, end)
+		ok, err := evalStatePredicates(ctx, api, ts, preds)
+		if err != nil {
+			afmt.Println("error")
+			return nil, false, err
+		}
+		afmt.Println(ok)
+		return ts, ok, nil
+	}
+
+	ts, ok, err := test(end)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, xerrors.Errorf("predicate is false at the max height %d", end)
+	}
+	first := ts.Height()
+
+	if _, ok, err := test(start); err != nil {
+		return 0, err
+	} else if ok {
+		return start, nil
+	}
+
+	// the predicate is false at start and true at end
+	for end-start > 1 {
+		mid := start + (end-start)/2
+		ts, ok, err := test(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			end = mid
+			first = ts.Height()
+		} else {
+			start = mid
+		}
+	}
+	return first, nil
+}
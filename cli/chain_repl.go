@@ -0,0 +1,448 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var ChainReplCmd = &cli.Command{
+	Name:  "repl",
+	Usage: "Start an interactive prompt to inspect the chain",
+	Description: `The prompt keeps a connection to the node and a current tipset, the head at
+   start, that the commands run against. The tipsets, actors and CIDs met
+   during the session are offered for tab completion. Type 'help' for the list
+   of commands.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "history-file",
+			Usage: "file to keep the history of the commands in",
+			Value: filepath.Join(os.TempDir(), "lotus-chain-repl.tmp"),
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx, cancel := context.WithCancel(ReqContext(cctx))
+		defer cancel()
+
+		r := newChainRepl(api, afmt)
+		if err := r.exec(ctx, "head"); err != nil {
+			return err
+		}
+
+		cs := readline.NewCancelableStdin(afmt.Stdin)
+		go func() {
+			<-ctx.Done()
+			cs.Close() // nolint:errcheck
+		}()
+
+		rl, err := readline.NewEx(&readline.Config{
+			Stdin:             cs,
+			Stdout:            cctx.App.Writer,
+			HistoryFile:       cctx.String("history-file"),
+			Prompt:            "chain> ",
+			EOFPrompt:         "exit",
+			HistorySearchFold: true,
+			AutoComplete:      r,
+		})
+		if err != nil {
+			return err
+		}
+		defer rl.Close() // nolint:errcheck
+
+		for {
+			line, err := rl.Readline()
+			if err == readline.ErrInterrupt {
+				if len(line) == 0 {
+					return nil
+				}
+				continue
+			} else if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			line = strings.TrimSpace(line)
+			if line == "exit" || line == "quit" {
+				return nil
+			}
+			if err := r.exec(ctx, line); err != nil {
+				afmt.Printf("%s %s\n", color.RedString("Error:"), err)
+			}
+		}
+	},
+}
+
+// replArg is the kind of an argument of a repl command, deciding what it is
+// completed with.
+type replArg int
+
+const (
+	replArgNone replArg = iota
+	replArgTipSet
+	replArgActor
+	replArgCid
+)
+
+type replCommand struct {
+	name  string
+	args  []replArg
+	usage string
+	run   func(ctx context.Context, r *chainRepl, args []string) error
+}
+
+var replCommands []replCommand
+
+func init() {
+	// set in init, as the help command refers to the list
+	replCommands = []replCommand{
+		{"help", nil, "help: list the commands", replHelp},
+		{"head", nil, "head: move to the head of the chain", replHead},
+		{"tipset", []replArg{replArgTipSet}, "tipset [@height|@head|cid,...]: print the current tipset, or move to the given one", replTipSet},
+		{"parent", nil, "parent: move to the parent of the current tipset", replParent},
+		{"block", []replArg{replArgCid}, "block <cid>: print a block header", replBlock},
+		{"msgs", nil, "msgs: list the messages of the current tipset", replMsgs},
+		{"msg", []replArg{replArgCid}, "msg <cid>: print a message and its decoded params", replMsg},
+		{"actor", []replArg{replArgActor}, "actor <address>: print an actor in the parent state of the current tipset", replActor},
+		{"decode", []replArg{replArgActor, replArgNone, replArgNone}, "decode <address> <method> <params>: decode base64 params, or hex ones with a 0x prefix", replDecode},
+		{"exit", nil, "exit: leave the prompt", nil},
+	}
+}
+
+// chainRepl holds the state of a chain repl session.
+type chainRepl struct {
+	api  v1api.FullNode
+	afmt *AppFmt
+
+	ts *types.TipSet
+
+	// the values met during the session, offered for completion
+	tipsets map[string]struct{}
+	actors  map[string]struct{}
+	cids    map[string]struct{}
+}
+
+var _ readline.AutoCompleter = (*chainRepl)(nil)
+
+func newChainRepl(api v1api.FullNode, afmt *AppFmt) *chainRepl {
+	r := &chainRepl{
+		api:     api,
+		afmt:    afmt,
+		tipsets: map[string]struct{}{"@head": {}},
+		actors:  map[string]struct{}{},
+		cids:    map[string]struct{}{},
+	}
+	for _, a := range []address.Address{
+		builtintypes.SystemActorAddr,
+		builtintypes.InitActorAddr,
+		builtintypes.RewardActorAddr,
+		builtintypes.CronActorAddr,
+		builtintypes.StoragePowerActorAddr,
+		builtintypes.StorageMarketActorAddr,
+		builtintypes.VerifiedRegistryActorAddr,
+		builtintypes.DatacapActorAddr,
+		builtintypes.BurntFundsActorAddr,
+	} {
+		r.actors[a.String()] = struct{}{}
+	}
+	return r
+}
+
+// exec runs the command on line.
+func (r *chainRepl) exec(ctx context.Context, line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, c := range replCommands {
+		if c.name != fields[0] || c.run == nil {
+			continue
+		}
+		return c.run(ctx, r, fields[1:])
+	}
+	return xerrors.Errorf("unknown command %q, type 'help' for the list of commands", fields[0])
+}
+
+// Do completes the word under the cursor: a command name for the first word,
+// and for the arguments, the values of their kind met during the session.
+func (r *chainRepl) Do(line []rune, pos int) ([][]rune, int) {
+	fields := strings.Fields(string(line[:pos]))
+	if len(fields) == 0 || pos > 0 && line[pos-1] == ' ' {
+		fields = append(fields, "")
+	}
+	word := fields[len(fields)-1]
+
+	var candidates []string
+	if len(fields) == 1 {
+		for _, c := range replCommands {
+			candidates = append(candidates, c.name)
+		}
+	} else {
+		for _, c := range replCommands {
+			if c.name != fields[0] || len(fields)-2 >= len(c.args) {
+				continue
+			}
+			switch c.args[len(fields)-2] {
+			case replArgTipSet:
+				candidates = sortedKeys(r.tipsets)
+			case replArgActor:
+				candidates = sortedKeys(r.actors)
+			case replArgCid:
+				candidates = sortedKeys(r.cids)
+			}
+		}
+	}
+
+	var out [][]rune
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			out = append(out, []rune(c[len(word):]+" "))
+		}
+	}
+	return out, len([]rune(word))
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setTipSet makes ts the current tipset, and remembers its blocks.
+func (r *chainRepl) setTipSet(ts *types.TipSet) {
+	r.ts = ts
+	r.tipsets["@"+strconv.FormatInt(int64(ts.Height()), 10)] = struct{}{}
+	for _, b := range ts.Blocks() {
+		r.cids[b.Cid().String()] = struct{}{}
+		r.actors[b.Miner.String()] = struct{}{}
+	}
+}
+
+func (r *chainRepl) printTipSet() {
+	r.afmt.Printf("Tipset @%d: %s\n", r.ts.Height(), r.ts.Key())
+	r.afmt.Printf("Parent state: %s\n", r.ts.ParentState())
+	for _, b := range r.ts.Blocks() {
+		r.afmt.Printf("  %s: miner %s\n", b.Cid(), b.Miner)
+	}
+}
+
+func (r *chainRepl) printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.afmt.Println(string(b))
+	return nil
+}
+
+func replParseArgs(args []string, n int) error {
+	if len(args) != n {
+		return xerrors.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+	return nil
+}
+
+func replHelp(ctx context.Context, r *chainRepl, args []string) error {
+	for _, c := range replCommands {
+		r.afmt.Println("  " + c.usage)
+	}
+	return nil
+}
+
+func replHead(ctx context.Context, r *chainRepl, args []string) error {
+	ts, err := r.api.ChainHead(ctx)
+	if err != nil {
+		return err
+	}
+	r.setTipSet(ts)
+	r.printTipSet()
+	return nil
+}
+
+func replTipSet(ctx context.Context, r *chainRepl, args []string) error {
+	if len(args) > 1 {
+		return xerrors.Errorf("expected at most 1 argument, got %d", len(args))
+	}
+	if len(args) == 1 {
+		ts, err := ParseTipSetRef(ctx, &v0api.WrapperV1Full{FullNode: r.api}, args[0])
+		if err != nil {
+			return err
+		}
+		r.setTipSet(ts)
+	}
+	r.printTipSet()
+	return nil
+}
+
+func replParent(ctx context.Context, r *chainRepl, args []string) error {
+	if r.ts.Height() == 0 {
+		return xerrors.Errorf("the current tipset is the genesis")
+	}
+	ts, err := r.api.ChainGetTipSet(ctx, r.ts.Parents())
+	if err != nil {
+		return err
+	}
+	r.setTipSet(ts)
+	r.printTipSet()
+	return nil
+}
+
+func replBlock(ctx context.Context, r *chainRepl, args []string) error {
+	if err := replParseArgs(args, 1); err != nil {
+		return err
+	}
+	c, err := cid.Parse(args[0])
+	if err != nil {
+		return xerrors.Errorf("parsing block cid: %w", err)
+	}
+	blk, err := r.api.ChainGetBlock(ctx, c)
+	if err != nil {
+		return err
+	}
+	r.actors[blk.Miner.String()] = struct{}{}
+	for _, p := range blk.Parents {
+		r.cids[p.String()] = struct{}{}
+	}
+	return r.printJSON(blk)
+}
+
+func replMsgs(ctx context.Context, r *chainRepl, args []string) error {
+	msgs, err := r.api.ChainGetMessagesInTipset(ctx, r.ts.Key())
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		r.cids[m.Cid.String()] = struct{}{}
+		r.actors[m.Message.From.String()] = struct{}{}
+		r.actors[m.Message.To.String()] = struct{}{}
+		r.afmt.Printf("%s: %s -> %s, method %d, value %s\n", m.Cid, m.Message.From, m.Message.To, m.Message.Method, types.FIL(m.Message.Value))
+	}
+	r.afmt.Printf("%d messages\n", len(msgs))
+	return nil
+}
+
+func replMsg(ctx context.Context, r *chainRepl, args []string) error {
+	if err := replParseArgs(args, 1); err != nil {
+		return err
+	}
+	c, err := cid.Parse(args[0])
+	if err != nil {
+		return xerrors.Errorf("parsing message cid: %w", err)
+	}
+	msg, err := r.api.ChainGetMessage(ctx, c)
+	if err != nil {
+		return err
+	}
+	r.actors[msg.From.String()] = struct{}{}
+	r.actors[msg.To.String()] = struct{}{}
+	if err := r.printJSON(msg); err != nil {
+		return err
+	}
+	if msg.Method == 0 || len(msg.Params) == 0 {
+		return nil
+	}
+
+	act, err := r.api.StateGetActor(ctx, msg.To, r.ts.Key())
+	if err != nil {
+		return xerrors.Errorf("getting receiver actor: %w", err)
+	}
+	pstr, err := JsonParams(act.Code, msg.Method, msg.Params)
+	if err != nil {
+		return err
+	}
+	r.afmt.Println("Params:", pstr)
+	return nil
+}
+
+func replActor(ctx context.Context, r *chainRepl, args []string) error {
+	if err := replParseArgs(args, 1); err != nil {
+		return err
+	}
+	addr, err := address.NewFromString(args[0])
+	if err != nil {
+		return xerrors.Errorf("parsing address: %w", err)
+	}
+	act, err := r.api.StateGetActor(ctx, addr, r.ts.Key())
+	if err != nil {
+		return err
+	}
+	r.actors[addr.String()] = struct{}{}
+	r.cids[act.Head.String()] = struct{}{}
+
+	r.afmt.Printf("Address:\t%s\n", addr)
+	r.afmt.Printf("Balance:\t%s\n", types.FIL(act.Balance))
+	r.afmt.Printf("Nonce:\t\t%d\n", act.Nonce)
+	r.afmt.Printf("Code:\t\t%s (%s)\n", act.Code, builtin.ActorNameByCode(act.Code))
+	r.afmt.Printf("Head:\t\t%s\n", act.Head)
+	return nil
+}
+
+func replDecode(ctx context.Context, r *chainRepl, args []string) error {
+	if err := replParseArgs(args, 3); err != nil {
+		return err
+	}
+	to, err := address.NewFromString(args[0])
+	if err != nil {
+		return xerrors.Errorf("parsing address: %w", err)
+	}
+	method, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return xerrors.Errorf("parsing method number: %w", err)
+	}
+
+	var params []byte
+	if strings.HasPrefix(args[2], "0x") {
+		params, err = hex.DecodeString(args[2][2:])
+		if err != nil {
+			return xerrors.Errorf("decoding hex params: %w", err)
+		}
+	} else {
+		params, err = base64.StdEncoding.DecodeString(args[2])
+		if err != nil {
+			return xerrors.Errorf("decoding base64 params: %w", err)
+		}
+	}
+
+	act, err := r.api.StateGetActor(ctx, to, r.ts.Key())
+	if err != nil {
+		return xerrors.Errorf("getting actor: %w", err)
+	}
+	pstr, err := JsonParams(act.Code, abi.MethodNum(method), params)
+	if err != nil {
+		return err
+	}
+	r.afmt.Println(pstr)
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -14,6 +16,7 @@ import (
 	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/specs-actors/v7/actors/builtin"
@@ -40,6 +43,16 @@ func TestChainHead(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.Regexp(t, regexp.MustCompile(ts.Cids()[0].String()), buf.String())
+
+	buf.Reset()
+	mockApi.EXPECT().ChainHead(ctx).Return(ts, nil)
+
+	err = app.Run([]string{"chain", "head", "--json"})
+	assert.NoError(t, err)
+
+	var out types.TipSet
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, ts.Key(), out.Key())
 }
 
 func TestGetBlock(t *testing.T) {
@@ -491,6 +504,84 @@ func TestChainBisect(t *testing.T) {
 	assert.Contains(t, out, path)
 }
 
+func TestChainBisectPredicate(t *testing.T) {
+	tipsets := map[abi.ChainEpoch]*types.TipSet{}
+	for h := abi.ChainEpoch(0); h <= 10; h++ {
+		blk := mock.MkBlock(nil, 0, uint64(h))
+		blk.Height = h
+		tipsets[h] = mock.TipSet(blk)
+	}
+
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainBisectCmd))
+	defer done()
+
+	mockApi.EXPECT().ChainGetTipSetByHeight(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, h abi.ChainEpoch, _ types.TipSetKey) (*types.TipSet, error) {
+			return tipsets[h], nil
+		}).AnyTimes()
+	mockApi.EXPECT().StateReadState(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ address.Address, tsk types.TipSetKey) (*api.ActorState, error) {
+			for h, ts := range tipsets {
+				if ts.Key() == tsk {
+					// the power passes 55 at height 6
+					return &api.ActorState{State: map[string]interface{}{
+						"TotalQualityAdjPower": big.NewInt(int64(h) * 10).String(),
+						"MinerCount":           h,
+					}}, nil
+				}
+			}
+			return nil, fmt.Errorf("unknown tipset")
+		}).AnyTimes()
+
+	//stm: @CLI_CHAIN_BISECT_001
+	err := app.Run([]string{"chain", "bisect", "--predicate", "power.TotalQualityAdjPower >= 55 && power.MinerCount >= 1", "0", "10"})
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "6", lines[len(lines)-1])
+
+	err = app.Run([]string{"chain", "bisect", "--predicate", "power.TotalQualityAdjPower > 1000", "0", "10"})
+	assert.ErrorContains(t, err, "false at the max height")
+}
+
+func TestStatePredicate(t *testing.T) {
+	state := map[string]interface{}{
+		"Power":  "123456789012345678901234567890",
+		"Count":  3,
+		"Name":   "foo",
+		"Nested": map[string]interface{}{"List": []interface{}{1, 2}},
+	}
+
+	for expr, want := range map[string]bool{
+		"power.Power > 123456789012345678901234567889": true,
+		"power.Power <= 100":                           false,
+		"power.Count == 3":                             true,
+		"power.Name == foo":                            true,
+		`power.Name != "foo"`:                          false,
+		"power.Nested.List.1 >= 2":                     true,
+		"power.Count < 1 FIL":                          true,
+	} {
+		preds, err := parseStatePredicates(expr)
+		assert.NoError(t, err, expr)
+		assert.Len(t, preds, 1)
+		got, err := preds[0].eval(state)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, want, got, expr)
+	}
+
+	for _, expr := range []string{"power.Name < foo", "power.Missing == 1", "power.Nested > 1"} {
+		preds, err := parseStatePredicates(expr)
+		assert.NoError(t, err, expr)
+		_, err = preds[0].eval(state)
+		assert.Error(t, err, expr)
+	}
+
+	for _, expr := range []string{"power.Count", "nope.Count == 1", "power == 1"} {
+		_, err := parseStatePredicates(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
 func TestChainExport(t *testing.T) {
 	app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainExportCmd))
 	defer done()
@@ -513,7 +604,7 @@ func TestChainExport(t *testing.T) {
 
 	gomock.InOrder(
 		mockApi.EXPECT().ChainHead(ctx).Return(ts, nil),
-		mockApi.EXPECT().ChainExport(ctx, abi.ChainEpoch(0), false, ts.Key()).Return(export, nil),
+		mockApi.EXPECT().ChainExport(ctx, abi.ChainEpoch(0), false, ts.Key(), api.ChainExportOpts{}).Return(export, nil),
 	)
 
 	//stm: @CLI_CHAIN_EXPORT_001
@@ -523,6 +614,105 @@ func TestChainExport(t *testing.T) {
 	assert.Equal(t, expBytes, mockFile.Bytes())
 }
 
+func TestChainExportEstimate(t *testing.T) {
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainExportCmd))
+	defer done()
+
+	blk := mock.MkBlock(nil, 0, 0)
+	ts := mock.TipSet(blk)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(ctx).Return(ts, nil),
+		mockApi.EXPECT().ChainExportEstimate(ctx, abi.ChainEpoch(0), false, ts.Key(), api.ChainExportOpts{}).Return(api.ExportSizeEstimate{Blocks: 12, Bytes: 2048}, nil),
+	)
+
+	err := app.Run([]string{"chain", "export", "--estimate"})
+	assert.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "Blocks: 12")
+	assert.Contains(t, buf.String(), "(2048 bytes)")
+}
+
+func TestChainExportRange(t *testing.T) {
+	app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainExportCmd))
+	defer done()
+
+	mockFile := mockExportFile{new(bytes.Buffer)}
+	app.Metadata["export-file"] = mockFile
+
+	ts := mock.TipSet(mock.MkBlock(nil, 0, 0))
+
+	export := make(chan []byte, 2)
+	export <- []byte("segment")
+	export <- []byte{}
+	close(export)
+
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(gomock.Any()).Return(ts, nil),
+		mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key(), api.ChainExportOpts{
+			Codecs:     []uint64{cid.Raw, cid.DagCBOR},
+			RangeStart: 4,
+			RangeEnd:   6,
+		}).Return(export, nil),
+	)
+
+	err := app.Run([]string{"chain", "export", "--start-epoch", "4", "--end-epoch", "6", "--codec", "raw", "--codec", "0x71", "segment.car"})
+	assert.NoError(t, err)
+	assert.Equal(t, "segment", mockFile.String())
+
+	// the state roots only apply to snapshots
+	mockApi.EXPECT().ChainHead(gomock.Any()).Return(ts, nil)
+	err = app.Run([]string{"chain", "export", "--end-epoch", "6", "--recent-stateroots", "900", "segment.car"})
+	assert.Error(t, err)
+}
+
+func TestChainExportResume(t *testing.T) {
+	app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainExportCmd))
+	defer done()
+
+	path := filepath.Join(t.TempDir(), "chain.car")
+	ts := mock.TipSet(mock.MkBlock(nil, 0, 0))
+
+	stream := func(bs ...[]byte) <-chan []byte {
+		ch := make(chan []byte, len(bs))
+		for _, b := range bs {
+			ch <- b
+		}
+		close(ch)
+		return ch
+	}
+
+	// the connection is lost midway, the export is left to resume
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(gomock.Any()).Return(ts, nil),
+		mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key(), api.ChainExportOpts{IncludeReceipts: true}).Return(stream([]byte("part1")), nil),
+	)
+	err := app.Run([]string{"chain", "export", "--include-receipts", path})
+	assert.ErrorContains(t, err, "incomplete export")
+	assert.FileExists(t, path+exportParamsSuffix)
+
+	// it is resumed with the tipset and options it was started with
+	mockApi.EXPECT().ChainGetTipSet(gomock.Any(), ts.Key()).Return(ts, nil).Times(2)
+	mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key(), api.ChainExportOpts{IncludeReceipts: true}).Return(stream([]byte("paXt1part2"), []byte{}), nil)
+	err = app.Run([]string{"chain", "export", "--resume", path})
+	assert.ErrorContains(t, err, "differs from the partial export")
+
+	mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key(), api.ChainExportOpts{IncludeReceipts: true}).Return(stream([]byte("par"), []byte("t1part2"), []byte{}), nil)
+	err = app.Run([]string{"chain", "export", "--resume", path})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "part1part2", string(data))
+	assert.NoFileExists(t, path+exportParamsSuffix)
+
+	err = app.Run([]string{"chain", "export", "--resume", path})
+	assert.ErrorContains(t, err, "no interrupted export")
+}
+
 func TestChainGasPrice(t *testing.T) {
 	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainGasPriceCmd))
 	defer done()
@@ -557,3 +747,53 @@ type mockExportFile struct {
 func (mef mockExportFile) Close() error {
 	return nil
 }
+
+func TestChainRepl(t *testing.T) {
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainReplCmd))
+	defer done()
+
+	gen := mock.MkBlock(nil, 0, 0)
+	head := mock.TipSet(mock.MkBlock(mock.TipSet(gen), 1, 1))
+	addr := mock.Address(1000)
+
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(gomock.Any()).Return(head, nil),
+		mockApi.EXPECT().StateGetActor(gomock.Any(), addr, head.Key()).Return(&types.Actor{
+			Code:    builtin.AccountActorCodeID,
+			Head:    gen.Cid(),
+			Balance: big.NewInt(1),
+		}, nil),
+		mockApi.EXPECT().ChainGetTipSet(gomock.Any(), head.Parents()).Return(mock.TipSet(gen), nil),
+	)
+
+	app.Metadata["stdin"] = strings.NewReader("actor f01000\nbogus\nparent\nparent\nexit\n")
+	err := app.Run([]string{"chain", "repl", "--history-file", filepath.Join(t.TempDir(), "history")})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "Tipset @1: "+head.Key().String())
+	assert.Contains(t, out, "/account)")
+	assert.Contains(t, out, `unknown command "bogus"`)
+	assert.Contains(t, out, "Tipset @0: "+mock.TipSet(gen).Key().String())
+	assert.Contains(t, out, "the current tipset is the genesis")
+
+	// the commands, then the values met in the session, are completed
+	r := newChainRepl(mockApi, NewAppFmt(app))
+	r.setTipSet(head)
+	r.actors[addr.String()] = struct{}{}
+
+	complete := func(line string) []string {
+		got, n := r.Do([]rune(line), len(line))
+		var words []string
+		for _, g := range got {
+			words = append(words, line[len(line)-n:]+strings.TrimSpace(string(g)))
+		}
+		return words
+	}
+	assert.Equal(t, []string{"parent"}, complete("pa"))
+	assert.Equal(t, []string{"msgs", "msg"}, complete("ms"))
+	assert.Equal(t, []string{"@1", "@head"}, complete("tipset @"))
+	assert.Equal(t, []string{"f01000"}, complete("decode f010"))
+	assert.Equal(t, []string{head.Cids()[0].String()}, complete("block "))
+	assert.Empty(t, complete("decode f01000 "))
+}
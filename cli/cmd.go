@@ -64,10 +64,12 @@ var CommonCommands = []*cli.Command{
 	FetchParamCmd,
 	PprofCmd,
 	VersionCmd,
+	CompletionCmd,
 }
 
 var Commands = []*cli.Command{
 	WithCategory("basic", sendCmd),
+	WithCategory("basic", msgCmd),
 	WithCategory("basic", walletCmd),
 	WithCategory("basic", infoCmd),
 	WithCategory("basic", clientCmd),
@@ -86,6 +88,7 @@ var Commands = []*cli.Command{
 	WithCategory("status", StatusCmd),
 	PprofCmd,
 	VersionCmd,
+	CompletionCmd,
 }
 
 func WithCategory(cat string, cmd *cli.Command) *cli.Command {
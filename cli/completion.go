@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// completionTimeout bounds the queries to the node completing an argument, so
+// that completion doesn't hang when the node is busy or not running.
+const completionTimeout = 5 * time.Second
+
+// completionTipSets is the number of recent tipsets whose keys are completed.
+const completionTipSets = 10
+
+// ArgCompleter returns the values an argument or the value of a flag can take,
+// querying the node. It returns nothing when the node can't be queried.
+type ArgCompleter func(ctx context.Context, cctx *cli.Context) []string
+
+// flagCompleters complete the values of the flags of every command.
+var flagCompleters = map[string]ArgCompleter{
+	"tipset": CompleteTipSetKeys,
+	"from":   CompleteWalletAddresses,
+	"miner":  CompleteMinerIDs,
+}
+
+func init() {
+	var walk func(cmds []*cli.Command)
+	walk = func(cmds []*cli.Command) {
+		for _, cmd := range cmds {
+			walk(cmd.Subcommands)
+			if cmd.BashComplete != nil {
+				continue
+			}
+			for _, f := range cmd.Flags {
+				if _, ok := flagCompleters[f.Names()[0]]; ok {
+					cmd.BashComplete = completeArgs()
+					break
+				}
+			}
+		}
+	}
+	walk(Commands)
+}
+
+// completeArgs completes the nth argument of a command with the nth of args,
+// and the values of its flags with flagCompleters. Other words complete like
+// they do without it.
+func completeArgs(args ...ArgCompleter) cli.BashCompleteFunc {
+	return func(cctx *cli.Context) {
+		complete := func(c ArgCompleter) {
+			ctx, cancel := context.WithTimeout(ReqContext(cctx), completionTimeout)
+			defer cancel()
+			for _, v := range c(ctx, cctx) {
+				fmt.Fprintln(cctx.App.Writer, v)
+			}
+		}
+
+		// commands with subcommands run as apps of their own
+		flags, completeDefault := cctx.Command.Flags, cli.DefaultCompleteWithFlags(cctx.Command)
+		if cctx.Command.Name == "" {
+			flags, completeDefault = cctx.App.Flags, cli.DefaultAppComplete
+		}
+
+		// the word before the one being completed, or the flag being typed
+		if len(os.Args) > 2 {
+			if last := os.Args[len(os.Args)-2]; strings.HasPrefix(last, "-") {
+				for _, f := range flags {
+					for _, name := range f.Names() {
+						if c, ok := flagCompleters[name]; ok && strings.TrimLeft(last, "-") == name {
+							complete(c)
+							return
+						}
+					}
+				}
+			}
+		}
+
+		if n := cctx.NArg(); n < len(args) && args[n] != nil {
+			complete(args[n])
+			return
+		}
+		completeDefault(cctx)
+	}
+}
+
+// CompleteWalletAddresses completes the addresses of the wallet of the node.
+func CompleteWalletAddresses(ctx context.Context, cctx *cli.Context) []string {
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return nil
+	}
+	defer closer()
+
+	addrs, err := api.WalletList(ctx)
+	if err != nil {
+		return nil
+	}
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		out[i] = a.String()
+	}
+	return out
+}
+
+// CompleteMinerIDs completes the addresses of the miners in the state at the
+// head.
+func CompleteMinerIDs(ctx context.Context, cctx *cli.Context) []string {
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return nil
+	}
+	defer closer()
+
+	miners, err := api.StateListMiners(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil
+	}
+	out := make([]string, len(miners))
+	for i, m := range miners {
+		out[i] = m.String()
+	}
+	return out
+}
+
+// CompleteTipSetKeys completes '@head' and the keys and heights of the recent
+// tipsets.
+func CompleteTipSetKeys(ctx context.Context, cctx *cli.Context) []string {
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return nil
+	}
+	defer closer()
+
+	ts, err := api.ChainHead(ctx)
+	if err != nil {
+		return nil
+	}
+
+	out := []string{"@head"}
+	for i := 0; i < completionTipSets && ts != nil; i++ {
+		cids := make([]string, len(ts.Cids()))
+		for j, c := range ts.Cids() {
+			cids[j] = c.String()
+		}
+		out = append(out, strings.Join(cids, ","), fmt.Sprintf("@%d", ts.Height()))
+
+		if ts.Height() == 0 {
+			break
+		}
+		if ts, err = api.ChainGetTipSet(ctx, ts.Parents()); err != nil {
+			break
+		}
+	}
+	return out
+}
+
+const bashCompletion = `#!/usr/bin/env bash
+
+_cli_bash_autocomplete() {
+  if [[ "${COMP_WORDS[0]}" != "source" ]]; then
+    local cur opts base
+    COMPREPLY=()
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    if [[ "$cur" == "-"* ]]; then
+      opts=$( ${COMP_WORDS[@]:0:$COMP_CWORD} ${cur} --generate-bash-completion )
+    else
+      opts=$( ${COMP_WORDS[@]:0:$COMP_CWORD} --generate-bash-completion )
+    fi
+    COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
+    return 0
+  fi
+}
+
+complete -o bashdefault -o default -o nospace -F _cli_bash_autocomplete %s
+`
+
+const zshCompletion = `#compdef %[1]s
+
+_cli_zsh_autocomplete() {
+
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(_CLI_ZSH_AUTOCOMPLETE_HACK=1 ${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion)}")
+  else
+    opts=("${(@f)$(_CLI_ZSH_AUTOCOMPLETE_HACK=1 ${words[@]:0:#words[@]-1} --generate-bash-completion)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+
+  return
+}
+
+compdef _cli_zsh_autocomplete %[1]s
+`
+
+const fishCompletion = `function __lotus_cli_complete
+  set -l args (commandline -opc)
+  set -l cur (commandline -ct)
+  if string match -q -- '-*' $cur
+    $args $cur --generate-bash-completion 2>/dev/null
+  else
+    $args --generate-bash-completion 2>/dev/null
+  end
+end
+
+for cmd in %s
+  complete -c $cmd -f -a '(__lotus_cli_complete)'
+end
+`
+
+var CompletionCmd = &cli.Command{
+	Name:      "completion",
+	Usage:     "Print the shell completion script",
+	ArgsUsage: "[bash|zsh|fish]",
+	Description: `Prints the script completing the commands and flags of this binary in the
+   given shell. Wallet addresses, miner addresses and the keys of the recent
+   tipsets are completed by querying the running node. For example:
+
+     source <(lotus completion bash)
+     lotus completion fish > ~/.config/fish/completions/lotus.fish`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		var script string
+		switch cctx.Args().First() {
+		case "bash":
+			script = bashCompletion
+		case "zsh":
+			script = zshCompletion
+		case "fish":
+			script = fishCompletion
+		default:
+			return ShowHelp(cctx, fmt.Errorf("unsupported shell '%s', expected bash, zsh or fish", cctx.Args().First()))
+		}
+
+		_, err := fmt.Fprintf(cctx.App.Writer, script, cctx.App.Name)
+		return err
+	},
+}
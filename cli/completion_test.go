@@ -0,0 +1,111 @@
+// stm: #unit
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestCompletion(t *testing.T) {
+	runCompletion := func(t *testing.T, app interface{ Run([]string) error }, args ...string) {
+		// completion reads the word before the completed one from os.Args
+		args = append(args, "--generate-bash-completion")
+		oldArgs := os.Args
+		os.Args = args
+		defer func() { os.Args = oldArgs }()
+
+		require.NoError(t, app.Run(args))
+	}
+
+	addr, err := address.NewIDAddress(1234)
+	require.NoError(t, err)
+
+	t.Run("wallet-addresses", func(t *testing.T) {
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("wallet", walletBalance))
+		defer done()
+		app.EnableBashCompletion = true
+
+		mockApi.EXPECT().WalletList(gomock.Any()).Return([]address.Address{addr}, nil)
+
+		runCompletion(t, app, "wallet", "balance")
+		require.Equal(t, addr.String()+"\n", buf.String())
+	})
+
+	t.Run("only-the-first-argument", func(t *testing.T) {
+		app, _, buf, done := NewMockAppWithFullAPI(t, WithCategory("wallet", walletBalance))
+		defer done()
+		app.EnableBashCompletion = true
+
+		runCompletion(t, app, "wallet", "balance", addr.String())
+		require.Empty(t, buf.String())
+	})
+
+	t.Run("tipset-flag", func(t *testing.T) {
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainGetCmd))
+		defer done()
+		app.EnableBashCompletion = true
+
+		genesis := mock.TipSet(mock.MkBlock(nil, 0, 0))
+		head := mock.TipSet(mock.MkBlock(genesis, 0, 0))
+		gomock.InOrder(
+			mockApi.EXPECT().ChainHead(gomock.Any()).Return(head, nil),
+			mockApi.EXPECT().ChainGetTipSet(gomock.Any(), head.Parents()).Return(genesis, nil),
+		)
+
+		runCompletion(t, app, "chain", "get", "--tipset")
+		require.Equal(t, []string{
+			"@head",
+			head.Cids()[0].String(), "@1",
+			genesis.Cids()[0].String(), "@0",
+		}, strings.Fields(buf.String()))
+	})
+
+	t.Run("parent-tipset-flag", func(t *testing.T) {
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, StateCmd)
+		defer done()
+		app.EnableBashCompletion = true
+
+		head := mock.TipSet(mock.MkBlock(nil, 0, 0))
+		mockApi.EXPECT().ChainHead(gomock.Any()).Return(head, nil)
+
+		runCompletion(t, app, "lotus", "state", "--tipset")
+		require.Equal(t, []string{"@head", head.Cids()[0].String(), "@0"}, strings.Fields(buf.String()))
+	})
+
+	t.Run("subcommands", func(t *testing.T) {
+		app, _, buf, done := NewMockAppWithFullAPI(t, StateCmd)
+		defer done()
+		app.EnableBashCompletion = true
+
+		runCompletion(t, app, "lotus", "state")
+		require.Contains(t, strings.Fields(buf.String()), "get-actor")
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		app, _, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainGetCmd))
+		defer done()
+		app.EnableBashCompletion = true
+
+		runCompletion(t, app, "chain", "get", "--ti")
+		require.Contains(t, buf.String(), "--tipset")
+	})
+
+	t.Run("script", func(t *testing.T) {
+		app, _, buf, done := NewMockAppWithFullAPI(t, CompletionCmd)
+		defer done()
+		app.Name = "lotus"
+
+		require.NoError(t, app.Run([]string{"lotus", "completion", "fish"}))
+		require.Contains(t, buf.String(), "for cmd in lotus\n")
+
+		require.Error(t, app.Run([]string{"lotus", "completion", "tcsh"}))
+	})
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -27,6 +28,20 @@ func (e *PrintHelpErr) Is(o error) bool {
 	return ok
 }
 
+// ExitCodeErr is an error making RunApp exit with Code instead of 1.
+type ExitCodeErr struct {
+	Err  error
+	Code int
+}
+
+func (e *ExitCodeErr) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeErr) Unwrap() error {
+	return e.Err
+}
+
 func ShowHelp(cctx *ufcli.Context, err error) error {
 	return &PrintHelpErr{Err: err, Ctx: cctx}
 }
@@ -46,10 +61,21 @@ func RunApp(app *ufcli.App) {
 		if xerrors.As(err, &phe) {
 			_ = ufcli.ShowCommandHelp(phe.Ctx, phe.Ctx.Command.Name)
 		}
+		var ece *ExitCodeErr
+		if xerrors.As(err, &ece) {
+			os.Exit(ece.Code)
+		}
 		os.Exit(1)
 	}
 }
 
+// JSONFlag selects the machine-readable output of the chain, state and mpool
+// commands, printed with AppFmt.PrintJSON in place of their tables.
+var JSONFlag = ufcli.BoolFlag{
+	Name:  "json",
+	Usage: "print the output as json",
+}
+
 type AppFmt struct {
 	app   *ufcli.App
 	Stdin io.Reader
@@ -78,6 +104,16 @@ func (a *AppFmt) Printf(fmtstr string, args ...interface{}) {
 	fmt.Fprintf(a.app.Writer, fmtstr, args...)
 }
 
+// PrintJSON prints v as indented json, followed by a newline.
+func (a *AppFmt) PrintJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	a.Println(string(b))
+	return nil
+}
+
 func (a *AppFmt) Scan(args ...interface{}) (int, error) {
 	return fmt.Fscan(a.Stdin, args...)
 }
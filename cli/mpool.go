@@ -31,10 +31,13 @@ var MpoolCmd = &cli.Command{
 		MpoolSub,
 		MpoolStat,
 		MpoolReplaceCmd,
+		MpoolRepriceCmd,
 		MpoolFindCmd,
 		MpoolConfig,
 		MpoolGasPerfCmd,
+		MpoolInspectCmd,
 		mpoolManage,
+		MpoolJournalCmd,
 	},
 }
 
@@ -58,6 +61,7 @@ var MpoolPending = &cli.Command{
 			Name:  "from",
 			Usage: "return messages from a given address",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
@@ -106,6 +110,7 @@ var MpoolPending = &cli.Command{
 			return err
 		}
 
+		var out []*types.SignedMessage
 		for _, msg := range msgs {
 			if filter != nil {
 				if _, has := filter[msg.Message.From]; !has {
@@ -120,7 +125,9 @@ var MpoolPending = &cli.Command{
 				continue
 			}
 
-			if cctx.Bool("cids") {
+			if cctx.Bool("json") {
+				out = append(out, msg)
+			} else if cctx.Bool("cids") {
 				afmt.Println(msg.Cid())
 			} else {
 				out, err := json.MarshalIndent(msg, "", "  ")
@@ -131,6 +138,17 @@ var MpoolPending = &cli.Command{
 			}
 		}
 
+		if cctx.Bool("json") {
+			if cctx.Bool("cids") {
+				cids := make([]cid.Cid, len(out))
+				for i, msg := range out {
+					cids[i] = msg.Cid()
+				}
+				return afmt.PrintJSON(cids)
+			}
+			return afmt.PrintJSON(out)
+		}
+
 		return nil
 	},
 }
@@ -174,8 +192,26 @@ var MpoolClear = &cli.Command{
 var MpoolSub = &cli.Command{
 	Name:  "sub",
 	Usage: "Subscribe to mpool changes",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "from",
+			Usage: "only print the messages from the given addresses",
+		},
+		&cli.StringSliceFlag{
+			Name:  "to",
+			Usage: "only print the messages to the given addresses",
+		},
+		&cli.Uint64SliceFlag{
+			Name:  "method",
+			Usage: "only print the messages calling the given method numbers",
+		},
+		&cli.StringSliceFlag{
+			Name:  "actor-code",
+			Usage: "only print the messages to actors of the given code CIDs",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -183,7 +219,33 @@ var MpoolSub = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		sub, err := api.MpoolSub(ctx)
+		var filter lapi.MpoolSubFilter
+		for _, s := range cctx.StringSlice("from") {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing from address %q: %w", s, err)
+			}
+			filter.From = append(filter.From, a)
+		}
+		for _, s := range cctx.StringSlice("to") {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing to address %q: %w", s, err)
+			}
+			filter.To = append(filter.To, a)
+		}
+		for _, m := range cctx.Uint64Slice("method") {
+			filter.Methods = append(filter.Methods, abi.MethodNum(m))
+		}
+		for _, s := range cctx.StringSlice("actor-code") {
+			c, err := cid.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("parsing actor code %q: %w", s, err)
+			}
+			filter.ActorCodes = append(filter.ActorCodes, c)
+		}
+
+		sub, err := api.MpoolSubFiltered(ctx, filter)
 		if err != nil {
 			return err
 		}
@@ -216,6 +278,7 @@ var MpoolStat = &cli.Command{
 			Usage: "number of blocks to look back for minimum basefee",
 			Value: 60,
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
@@ -271,10 +334,10 @@ var MpoolStat = &cli.Command{
 			msgs map[uint64]*types.SignedMessage
 		}
 		type mpStat struct {
-			addr                 string
-			past, cur, future    uint64
-			belowCurr, belowPast uint64
-			gasLimit             big.Int
+			Addr                 string `json:",omitempty"`
+			Past, Cur, Future    uint64
+			BelowCurr, BelowPast uint64
+			GasLimit             big.Int
 		}
 
 		buckets := map[address.Address]*statBucket{}
@@ -301,7 +364,9 @@ var MpoolStat = &cli.Command{
 		for a, bkt := range buckets {
 			act, err := api.StateGetActor(ctx, a, ts.Key())
 			if err != nil {
-				afmt.Printf("%s, err: %s\n", a, err)
+				if !cctx.Bool("json") {
+					afmt.Printf("%s, err: %s\n", a, err)
+				}
 				continue
 			}
 
@@ -315,51 +380,61 @@ var MpoolStat = &cli.Command{
 			}
 
 			var s mpStat
-			s.addr = a.String()
-			s.gasLimit = big.Zero()
+			s.Addr = a.String()
+			s.GasLimit = big.Zero()
 
 			for _, m := range bkt.msgs {
 				if m.Message.Nonce < act.Nonce {
-					s.past++
+					s.Past++
 				} else if m.Message.Nonce > cur {
-					s.future++
+					s.Future++
 				} else {
-					s.cur++
+					s.Cur++
 				}
 
 				if m.Message.GasFeeCap.LessThan(currBF) {
-					s.belowCurr++
+					s.BelowCurr++
 				}
 				if m.Message.GasFeeCap.LessThan(minBF) {
-					s.belowPast++
+					s.BelowPast++
 				}
 
-				s.gasLimit = big.Add(s.gasLimit, types.NewInt(uint64(m.Message.GasLimit)))
+				s.GasLimit = big.Add(s.GasLimit, types.NewInt(uint64(m.Message.GasLimit)))
 			}
 
 			out = append(out, s)
 		}
 
 		sort.Slice(out, func(i, j int) bool {
-			return out[i].addr < out[j].addr
+			return out[i].Addr < out[j].Addr
 		})
 
 		var total mpStat
-		total.gasLimit = big.Zero()
+		total.GasLimit = big.Zero()
 
 		for _, stat := range out {
-			total.past += stat.past
-			total.cur += stat.cur
-			total.future += stat.future
-			total.belowCurr += stat.belowCurr
-			total.belowPast += stat.belowPast
-			total.gasLimit = big.Add(total.gasLimit, stat.gasLimit)
+			total.Past += stat.Past
+			total.Cur += stat.Cur
+			total.Future += stat.Future
+			total.BelowCurr += stat.BelowCurr
+			total.BelowPast += stat.BelowPast
+			total.GasLimit = big.Add(total.GasLimit, stat.GasLimit)
+		}
 
-			afmt.Printf("%s: Nonce past: %d, cur: %d, future: %d; FeeCap cur: %d, min-%d: %d, gasLimit: %s\n", stat.addr, stat.past, stat.cur, stat.future, stat.belowCurr, cctx.Int("basefee-lookback"), stat.belowPast, stat.gasLimit)
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(struct {
+				BaseFeeLookback int
+				Addresses       []mpStat
+				Total           mpStat
+			}{cctx.Int("basefee-lookback"), out, total})
+		}
+
+		for _, stat := range out {
+			afmt.Printf("%s: Nonce past: %d, cur: %d, future: %d; FeeCap cur: %d, min-%d: %d, gasLimit: %s\n", stat.Addr, stat.Past, stat.Cur, stat.Future, stat.BelowCurr, cctx.Int("basefee-lookback"), stat.BelowPast, stat.GasLimit)
 		}
 
 		afmt.Println("-----")
-		afmt.Printf("total: Nonce past: %d, cur: %d, future: %d; FeeCap cur: %d, min-%d: %d, gasLimit: %s\n", total.past, total.cur, total.future, total.belowCurr, cctx.Int("basefee-lookback"), total.belowPast, total.gasLimit)
+		afmt.Printf("total: Nonce past: %d, cur: %d, future: %d; FeeCap cur: %d, min-%d: %d, gasLimit: %s\n", total.Past, total.Cur, total.Future, total.BelowCurr, cctx.Int("basefee-lookback"), total.BelowPast, total.GasLimit)
 
 		return nil
 	},
@@ -520,6 +595,70 @@ var MpoolReplaceCmd = &cli.Command{
 	},
 }
 
+var MpoolRepriceCmd = &cli.Command{
+	Name:  "reprice",
+	Usage: "replace all the local pending messages of addresses with messages of estimated gas parameters",
+	Description: `Replaces the local pending messages of the given addresses, of all the
+   addresses with local messages if none is given, keeping their nonces, to
+   recover the messages stranded by a fee spike. The gas parameters are estimated
+   again and bumped by at least the replace by fee ratio of the mpool.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "fee-limit",
+			Usage: "spend up to X FIL per message",
+		},
+	},
+	ArgsUsage: "[address...]",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		var addrs []address.Address
+		for _, s := range cctx.Args().Slice() {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing address %s: %w", s, err)
+			}
+			addrs = append(addrs, a)
+		}
+
+		var mss *lapi.MessageSendSpec
+		if cctx.IsSet("fee-limit") {
+			maxFee, err := types.ParseFIL(cctx.String("fee-limit"))
+			if err != nil {
+				return xerrors.Errorf("parsing fee-limit: %w", err)
+			}
+			mss = &lapi.MessageSendSpec{
+				MaxFee: abi.TokenAmount(maxFee),
+			}
+		}
+
+		res, err := api.MpoolRepricePending(ctx, addrs, mss)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, m := range res.Messages {
+			if m.Error != "" {
+				failed++
+				afmt.Printf("%s %d: %s: %s\n", m.From, m.Nonce, m.Old, m.Error)
+				continue
+			}
+			afmt.Printf("%s %d: %s -> %s (premium %s, feecap %s)\n", m.From, m.Nonce, m.Old, m.New, m.GasPremium, m.GasFeeCap)
+		}
+		afmt.Printf("repriced %d messages, %d failed, %d stale local messages removed\n", len(res.Messages)-failed, failed, res.Compacted)
+		return nil
+	},
+}
+
 var MpoolFindCmd = &cli.Command{
 	Name:  "find",
 	Usage: "find a message in the mempool",
@@ -660,6 +799,7 @@ var MpoolGasPerfCmd = &cli.Command{
 			Name:  "all",
 			Usage: "print gas performance for all mempool messages (default only prints for local)",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
@@ -726,11 +866,27 @@ var MpoolGasPerfCmd = &cli.Command{
 			return r
 		}
 
+		type msgGasPerf struct {
+			From      address.Address
+			Nonce     uint64
+			GasReward big.Int
+			GasPerf   float64
+		}
+
+		perfs := make([]msgGasPerf, 0, len(msgs))
 		for _, m := range msgs {
 			gasReward := getGasReward(m)
 			gasPerf := getGasPerf(gasReward, m.Message.GasLimit)
 
-			afmt.Printf("%s\t%d\t%s\t%f\n", m.Message.From, m.Message.Nonce, gasReward, gasPerf)
+			perfs = append(perfs, msgGasPerf{From: m.Message.From, Nonce: m.Message.Nonce, GasReward: gasReward, GasPerf: gasPerf})
+		}
+
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(perfs)
+		}
+
+		for _, p := range perfs {
+			afmt.Printf("%s\t%d\t%s\t%f\n", p.From, p.Nonce, p.GasReward, p.GasPerf)
 		}
 
 		return nil
@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"sort"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+// mpoolInspectPercentiles are the percentiles of the fee caps and premiums of
+// the pending messages reported.
+var mpoolInspectPercentiles = []float64{10, 25, 50, 75, 90, 99}
+
+// mpoolInspectAges are the upper bounds of the buckets of the ages of the
+// pending messages, the last bucket holding the older messages.
+var mpoolInspectAges = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 12 * time.Hour}
+
+type mpoolInspection struct {
+	Messages int
+	Bytes    int
+	BaseFee  abi.TokenAmount
+	Senders  []mpoolSenderStat
+	FeeCaps  []mpoolPercentile
+	Premiums []mpoolPercentile
+	Ages     []mpoolAgeBucket
+	// Inclusion is the estimate for the fee given with --premium
+	Inclusion *mpoolInclusionEstimate `json:",omitempty"`
+}
+
+type mpoolSenderStat struct {
+	Sender   address.Address
+	Messages int
+	Bytes    int
+}
+
+type mpoolPercentile struct {
+	Percentile float64
+	Value      abi.TokenAmount
+}
+
+// mpoolAgeBucket counts the messages added less than Below ago, and at least
+// the Below of the previous bucket. The last bucket has no bound.
+type mpoolAgeBucket struct {
+	Below    time.Duration `json:",omitempty"`
+	Messages int
+}
+
+// mpoolInclusionEstimate estimates the epochs a message paying the given fee
+// waits for, from the gas of the pending messages paying more.
+type mpoolInclusionEstimate struct {
+	GasPremium abi.TokenAmount
+	GasFeeCap  abi.TokenAmount
+	// GasAhead is the gas limit of the pending messages paying more
+	GasAhead int64
+	// Epochs is 0 when the fee cap is below the base fee
+	Epochs int64
+}
+
+var MpoolInspectCmd = &cli.Command{
+	Name:  "inspect",
+	Usage: "Summarize the pending messages by sender, fee and age",
+	Description: `Prints the number and size of the pending messages of the top senders, the
+   percentiles of the fee caps and premiums, and how long ago the node added the
+   messages to its mpool.
+
+   With --premium, estimates the epochs before a message paying that premium is
+   included, assuming the tipsets are filled up to the gas target with the
+   pending messages paying more, and that no other messages arrive.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of senders printed, by size of their pending messages",
+			Value: 20,
+		},
+		&cli.StringFlag{
+			Name:  "premium",
+			Usage: "estimate the epochs to inclusion of a message paying this gas premium (attoFIL/GasUnit)",
+		},
+		&cli.StringFlag{
+			Name:  "fee-cap",
+			Usage: "fee cap of the message of --premium (attoFIL/GasUnit), twice the base fee plus the premium by default",
+		},
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := api.ChainHead(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting chain head: %w", err)
+		}
+
+		msgs, err := api.MpoolPending(ctx, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		times, err := api.MpoolPendingTimes(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting the times the messages were added: %w", err)
+		}
+
+		var inclusion *mpoolInclusionEstimate
+		if cctx.IsSet("premium") {
+			inclusion = &mpoolInclusionEstimate{}
+			if inclusion.GasPremium, err = types.BigFromString(cctx.String("premium")); err != nil {
+				return xerrors.Errorf("parsing premium: %w", err)
+			}
+			if cctx.IsSet("fee-cap") {
+				if inclusion.GasFeeCap, err = types.BigFromString(cctx.String("fee-cap")); err != nil {
+					return xerrors.Errorf("parsing fee-cap: %w", err)
+				}
+			}
+		}
+
+		in := inspectMpool(msgs, times, ts.Blocks()[0].ParentBaseFee, build.Clock.Now(), inclusion)
+
+		if cctx.Bool("json") {
+			if top := cctx.Int("top"); len(in.Senders) > top {
+				in.Senders = in.Senders[:top]
+			}
+			return afmt.PrintJSON(in)
+		}
+
+		afmt.Printf("Messages: %d, %s\n", in.Messages, types.SizeStr(types.NewInt(uint64(in.Bytes))))
+		afmt.Printf("Base fee: %s\n", types.FIL(in.BaseFee))
+
+		afmt.Println()
+		tw := tablewriter.New(
+			tablewriter.Col("Sender"),
+			tablewriter.Col("Messages"),
+			tablewriter.Col("Size"),
+		)
+		for i, s := range in.Senders {
+			if i == cctx.Int("top") {
+				break
+			}
+			tw.Write(map[string]interface{}{
+				"Sender":   s.Sender,
+				"Messages": s.Messages,
+				"Size":     types.SizeStr(types.NewInt(uint64(s.Bytes))),
+			})
+		}
+		if err := tw.Flush(cctx.App.Writer); err != nil {
+			return err
+		}
+
+		afmt.Println()
+		tw = tablewriter.New(
+			tablewriter.Col("Percentile"),
+			tablewriter.Col("FeeCap"),
+			tablewriter.Col("Premium"),
+		)
+		for i := range in.FeeCaps {
+			tw.Write(map[string]interface{}{
+				"Percentile": in.FeeCaps[i].Percentile,
+				"FeeCap":     in.FeeCaps[i].Value,
+				"Premium":    in.Premiums[i].Value,
+			})
+		}
+		if err := tw.Flush(cctx.App.Writer); err != nil {
+			return err
+		}
+
+		afmt.Println()
+		afmt.Println("Added:")
+		var above time.Duration
+		for _, b := range in.Ages {
+			switch {
+			case b.Below == 0:
+				afmt.Printf("  over %s ago: %d\n", above, b.Messages)
+			case above == 0:
+				afmt.Printf("  under %s ago: %d\n", b.Below, b.Messages)
+			default:
+				afmt.Printf("  %s to %s ago: %d\n", above, b.Below, b.Messages)
+			}
+			above = b.Below
+		}
+
+		if in.Inclusion != nil {
+			afmt.Println()
+			if in.Inclusion.Epochs == 0 {
+				afmt.Printf("A message with fee cap %s is not included before the base fee drops below it\n", in.Inclusion.GasFeeCap)
+			} else {
+				afmt.Printf("A message with premium %s and fee cap %s is included in about %d epochs, behind %d gas\n",
+					in.Inclusion.GasPremium, in.Inclusion.GasFeeCap, in.Inclusion.Epochs, in.Inclusion.GasAhead)
+			}
+		}
+
+		return nil
+	},
+}
+
+// inspectMpool summarizes the pending messages msgs added at times, at base fee
+// baseFee and time now. The inclusion estimate is filled in when not nil, its
+// fee cap defaulting to twice the base fee plus the premium.
+func inspectMpool(msgs []*types.SignedMessage, times []lapi.MpoolPendingTime, baseFee abi.TokenAmount, now time.Time, inclusion *mpoolInclusionEstimate) *mpoolInspection {
+	in := &mpoolInspection{
+		Messages: len(msgs),
+		BaseFee:  baseFee,
+		Ages:     make([]mpoolAgeBucket, len(mpoolInspectAges)+1),
+	}
+
+	senders := map[address.Address]*mpoolSenderStat{}
+	feeCaps := make([]abi.TokenAmount, 0, len(msgs))
+	premiums := make([]abi.TokenAmount, 0, len(msgs))
+	for _, m := range msgs {
+		size := m.ChainLength()
+		in.Bytes += size
+
+		s, ok := senders[m.Message.From]
+		if !ok {
+			s = &mpoolSenderStat{Sender: m.Message.From}
+			senders[m.Message.From] = s
+		}
+		s.Messages++
+		s.Bytes += size
+
+		feeCaps = append(feeCaps, m.Message.GasFeeCap)
+		premiums = append(premiums, m.Message.GasPremium)
+	}
+
+	for _, s := range senders {
+		in.Senders = append(in.Senders, *s)
+	}
+	sort.Slice(in.Senders, func(i, j int) bool {
+		if in.Senders[i].Bytes != in.Senders[j].Bytes {
+			return in.Senders[i].Bytes > in.Senders[j].Bytes
+		}
+		return in.Senders[i].Sender.String() < in.Senders[j].Sender.String()
+	})
+
+	in.FeeCaps = mpoolPercentiles(feeCaps, mpoolInspectPercentiles)
+	in.Premiums = mpoolPercentiles(premiums, mpoolInspectPercentiles)
+
+	for i, b := range mpoolInspectAges {
+		in.Ages[i].Below = b
+	}
+	for _, t := range times {
+		age := now.Sub(t.Added)
+		i := sort.Search(len(mpoolInspectAges), func(i int) bool {
+			return age < mpoolInspectAges[i]
+		})
+		in.Ages[i].Messages++
+	}
+
+	if inclusion != nil {
+		if inclusion.GasFeeCap.Nil() {
+			inclusion.GasFeeCap = big.Add(big.Mul(baseFee, big.NewInt(2)), inclusion.GasPremium)
+		}
+		in.Inclusion = inclusion
+
+		if inclusion.GasFeeCap.GreaterThanEqual(baseFee) {
+			premium := effectivePremium(&types.Message{GasFeeCap: inclusion.GasFeeCap, GasPremium: inclusion.GasPremium}, baseFee)
+			for _, m := range msgs {
+				if m.Message.GasFeeCap.LessThan(baseFee) {
+					continue
+				}
+				if effectivePremium(&m.Message, baseFee).GreaterThan(premium) {
+					inclusion.GasAhead += m.Message.GasLimit
+				}
+			}
+			perEpoch := build.BlockGasTarget * int64(build.BlocksPerEpoch)
+			inclusion.Epochs = inclusion.GasAhead/perEpoch + 1
+		}
+	}
+
+	return in
+}
+
+// effectivePremium is the premium paid by m at base fee baseFee, capped by its
+// fee cap.
+func effectivePremium(m *types.Message, baseFee abi.TokenAmount) abi.TokenAmount {
+	if maxPremium := big.Sub(m.GasFeeCap, baseFee); maxPremium.LessThan(m.GasPremium) {
+		return maxPremium
+	}
+	return m.GasPremium
+}
+
+// mpoolPercentiles returns the values under which each of ps percent of vals are,
+// sorting vals.
+func mpoolPercentiles(vals []abi.TokenAmount, ps []float64) []mpoolPercentile {
+	sort.Slice(vals, func(i, j int) bool {
+		return vals[i].LessThan(vals[j])
+	})
+
+	out := make([]mpoolPercentile, len(ps))
+	for i, p := range ps {
+		out[i] = mpoolPercentile{Percentile: p, Value: big.Zero()}
+		if len(vals) == 0 {
+			continue
+		}
+		at := int(float64(len(vals))*p/100+0.5) - 1
+		if at < 0 {
+			at = 0
+		}
+		if at >= len(vals) {
+			at = len(vals) - 1
+		}
+		out[i].Value = vals[at]
+	}
+	return out
+}
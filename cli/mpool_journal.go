@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var MpoolJournalCmd = &cli.Command{
+	Name:  "journal",
+	Usage: "Inspect the journal of the messages pushed by the node",
+	Subcommands: []*cli.Command{
+		mpoolJournalListCmd,
+		mpoolJournalRebroadcastCmd,
+	},
+}
+
+var mpoolJournalListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the journaled messages",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "status",
+			Usage: "only list the messages with the given statuses: pending, landed, replaced or expired",
+		},
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var statuses []lapi.MpoolMessageStatus
+		for _, s := range cctx.StringSlice("status") {
+			st := lapi.MpoolMessageStatus(s)
+			switch st {
+			case lapi.MpoolMessagePending, lapi.MpoolMessageLanded, lapi.MpoolMessageReplaced, lapi.MpoolMessageExpired:
+			default:
+				return xerrors.Errorf("unknown message status %q", s)
+			}
+			statuses = append(statuses, st)
+		}
+
+		entries, err := api.MpoolJournalList(ctx, statuses)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cctx.App.Writer, string(out))
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Message"),
+			tablewriter.Col("From"),
+			tablewriter.Col("Nonce"),
+			tablewriter.Col("Status"),
+			tablewriter.Col("Added"),
+			tablewriter.Col("Updated"),
+			tablewriter.NewLineCol("Detail"),
+		)
+		for _, e := range entries {
+			var detail string
+			switch {
+			case e.Status == lapi.MpoolMessageLanded:
+				detail = fmt.Sprintf("at epoch %d", e.Epoch)
+			case e.ReplacedBy != nil:
+				detail = fmt.Sprintf("by %s", *e.ReplacedBy)
+			}
+			tw.Write(map[string]interface{}{
+				"Message": e.Cid,
+				"From":    e.Message.Message.From,
+				"Nonce":   e.Message.Message.Nonce,
+				"Status":  e.Status,
+				"Added":   e.Added.Format("2006-01-02 15:04:05"),
+				"Updated": e.Updated.Format("2006-01-02 15:04:05"),
+				"Detail":  detail,
+			})
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+var mpoolJournalRebroadcastCmd = &cli.Command{
+	Name:      "rebroadcast",
+	Usage:     "Publish the pending journaled messages again",
+	ArgsUsage: "[messageCid ...]",
+	Description: `Publish the given pending messages again, all the pending
+   messages if none are given, adding them back to the mpool if needed.`,
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var cids []cid.Cid
+		for _, s := range cctx.Args().Slice() {
+			c, err := cid.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("parsing message cid %q: %w", s, err)
+			}
+			cids = append(cids, c)
+		}
+
+		published, err := api.MpoolJournalRebroadcast(ctx, cids)
+		for _, c := range published {
+			fmt.Fprintln(cctx.App.Writer, c)
+		}
+		return err
+	},
+}
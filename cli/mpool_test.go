@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -522,6 +523,122 @@ func TestGasPerf(t *testing.T) {
 		assert.Contains(t, buf.String(), sm.Message.From.String())
 		assert.Contains(t, buf.String(), fmt.Sprint(sm.Message.Nonce))
 	})
+
+	t.Run("json", func(t *testing.T) {
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("mpool", MpoolStat))
+		defer done()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first := mock.TipSet(mock.MkBlock(nil, 5, 4))
+		head := mock.TipSet(mock.MkBlock(first, 15, 7))
+
+		w, _ := wallet.NewWallet(wallet.NewMemKeyStore())
+		senderAddr, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		toAddr, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sm := mock.MkMessage(senderAddr, toAddr, 1, w)
+
+		actor := types.Actor{Nonce: 2, Balance: big.NewInt(200000)}
+
+		gomock.InOrder(
+			mockApi.EXPECT().ChainHead(ctx).Return(head, nil),
+			mockApi.EXPECT().ChainGetTipSet(ctx, head.Parents()).Return(first, nil),
+			mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return([]*types.SignedMessage{sm}, nil),
+			mockApi.EXPECT().StateGetActor(ctx, senderAddr, head.Key()).Return(&actor, nil),
+		)
+
+		err = app.Run([]string{"mpool", "stat", "--basefee-lookback", "1", "--json"})
+		assert.NoError(t, err)
+
+		var out struct {
+			Addresses []struct {
+				Addr string
+				Past uint64
+			}
+			Total struct{ Past uint64 }
+		}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		assert.Len(t, out.Addresses, 1)
+		assert.Equal(t, senderAddr.String(), out.Addresses[0].Addr)
+		assert.Equal(t, uint64(1), out.Total.Past)
+	})
+}
+
+func TestInspect(t *testing.T) {
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("mpool", MpoolInspectCmd))
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	head := mock.TipSet(mock.MkBlock(nil, 5, 4))
+
+	w, _ := wallet.NewWallet(wallet.NewMemKeyStore())
+	a1, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := []*types.SignedMessage{
+		mock.MkMessage(a1, a2, 0, w),
+		mock.MkMessage(a1, a2, 1, w),
+		mock.MkMessage(a2, a1, 0, w),
+	}
+	// outbids the premium estimated
+	msgs[2].Message.GasFeeCap = big.NewInt(1000)
+	msgs[2].Message.GasPremium = big.NewInt(50)
+
+	now := time.Now()
+	times := []api.MpoolPendingTime{
+		{Message: msgs[0].Cid(), Added: now},
+		{Message: msgs[1].Cid(), Added: now},
+		{Message: msgs[2].Cid(), Added: now.Add(-3 * time.Hour)},
+	}
+
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(ctx).Return(head, nil),
+		mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return(msgs, nil),
+		mockApi.EXPECT().MpoolPendingTimes(ctx).Return(times, nil),
+	)
+
+	err = app.Run([]string{"mpool", "inspect", "--premium", "10", "--fee-cap", "1000", "--json"})
+	assert.NoError(t, err)
+
+	var out mpoolInspection
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, 3, out.Messages)
+	assert.Len(t, out.Senders, 2)
+	assert.Equal(t, a1, out.Senders[0].Sender)
+	assert.Equal(t, 2, out.Senders[0].Messages)
+	assert.Equal(t, 2, out.Ages[0].Messages)
+	assert.Equal(t, 1, out.Ages[4].Messages)
+	assert.Equal(t, big.NewInt(50), out.Premiums[len(out.Premiums)-1].Value)
+	assert.Equal(t, msgs[2].Message.GasLimit, out.Inclusion.GasAhead)
+	assert.Equal(t, int64(1), out.Inclusion.Epochs)
+
+	buf.Reset()
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(ctx).Return(head, nil),
+		mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return(msgs, nil),
+		mockApi.EXPECT().MpoolPendingTimes(ctx).Return(times, nil),
+	)
+
+	err = app.Run([]string{"mpool", "inspect", "--premium", "10", "--fee-cap", "1000"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Messages: 3")
+	assert.Contains(t, buf.String(), "2h0m0s to 12h0m0s ago: 1")
+	assert.Contains(t, buf.String(), "included in about 1 epochs")
 }
 
 func TestConfig(t *testing.T) {
@@ -0,0 +1,519 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// MessageFileVersion is the version of the message files written by the msg
+// commands. Files of a later version are rejected, as they may hold fields
+// this version would drop.
+const MessageFileVersion = 1
+
+// The kinds of message files.
+const (
+	MessageFileUnsigned = "unsigned-message"
+	MessageFileSigned   = "signed-message"
+)
+
+// MessageFile is a message moved between an online node and an air-gapped
+// signer. Cid is the cid of the message, signed or not, and is checked when
+// the file is read.
+type MessageFile struct {
+	Version int
+	Kind    string
+	// Network is the name of the network of the node that created the message
+	Network string
+	Cid     cid.Cid
+	Message *types.Message       `json:",omitempty"`
+	Signed  *types.SignedMessage `json:",omitempty"`
+}
+
+func newMessageFile(network string, msg *types.Message) *MessageFile {
+	return &MessageFile{
+		Version: MessageFileVersion,
+		Kind:    MessageFileUnsigned,
+		Network: network,
+		Cid:     msg.Cid(),
+		Message: msg,
+	}
+}
+
+// ReadMessageFile reads the message file at path, of the given kind.
+func ReadMessageFile(path, kind string) (*MessageFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading message file: %w", err)
+	}
+
+	var mf MessageFile
+	if err := json.Unmarshal(b, &mf); err != nil {
+		return nil, xerrors.Errorf("parsing message file: %w", err)
+	}
+
+	switch {
+	case mf.Version == 0:
+		return nil, xerrors.Errorf("%s is not a message file", path)
+	case mf.Version > MessageFileVersion:
+		return nil, xerrors.Errorf("message file version %d is newer than the supported version %d", mf.Version, MessageFileVersion)
+	case mf.Kind != kind:
+		return nil, xerrors.Errorf("expected a file of a %s, got a %s", kind, mf.Kind)
+	}
+
+	var c cid.Cid
+	switch mf.Kind {
+	case MessageFileUnsigned:
+		if mf.Message == nil {
+			return nil, xerrors.Errorf("message file has no message")
+		}
+		c = mf.Message.Cid()
+	case MessageFileSigned:
+		if mf.Signed == nil {
+			return nil, xerrors.Errorf("message file has no signed message")
+		}
+		c = mf.Signed.Cid()
+	}
+	if c != mf.Cid {
+		return nil, xerrors.Errorf("message file cid %s doesn't match its message %s", mf.Cid, c)
+	}
+
+	return &mf, nil
+}
+
+// WriteMessageFile writes mf to path, or to the app writer when path is empty.
+func WriteMessageFile(cctx *cli.Context, path string, mf *MessageFile) error {
+	b, err := json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		_, err := fmt.Fprintln(cctx.App.Writer, string(b))
+		return err
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return xerrors.Errorf("writing message file: %w", err)
+	}
+	return nil
+}
+
+var msgCmd = &cli.Command{
+	Name:  "msg",
+	Usage: "Create, sign and submit messages with an air-gapped wallet",
+	Description: `Splits sending a message between an online node and a signer without network
+   access:
+
+     lotus msg create --from <address> --out unsigned.json <to> <amount>
+     lotus msg sign --out signed.json unsigned.json     (on the signer)
+     lotus msg submit signed.json
+
+   The files are JSON, versioned and bound to the network of the node that
+   created the message. 'lotus msg export' prints the bytes to sign for
+   signers other than lotus, whose signature 'lotus msg sign --signature'
+   attaches.`,
+	Subcommands: []*cli.Command{
+		msgCreateCmd,
+		msgExportCmd,
+		msgSignCmd,
+		msgSubmitCmd,
+	},
+}
+
+var msgCreateCmd = &cli.Command{
+	Name:      "create",
+	Usage:     "Create an unsigned message, filling in its nonce and gas",
+	ArgsUsage: "[targetAddress] [amount]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "account to send the message from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "gas-premium",
+			Usage: "specify gas price to use in AttoFIL",
+		},
+		&cli.StringFlag{
+			Name:  "gas-feecap",
+			Usage: "specify gas fee cap to use in AttoFIL",
+		},
+		&cli.Int64Flag{
+			Name:  "gas-limit",
+			Usage: "specify gas limit",
+		},
+		&cli.Uint64Flag{
+			Name:  "nonce",
+			Usage: "specify the nonce to use, the next nonce of the sender by default",
+		},
+		&cli.Uint64Flag{
+			Name:  "method",
+			Usage: "specify method to invoke",
+			Value: uint64(builtin.MethodSend),
+		},
+		&cli.StringFlag{
+			Name:  "params-json",
+			Usage: "specify invocation parameters in json",
+		},
+		&cli.StringFlag{
+			Name:  "params-hex",
+			Usage: "specify invocation parameters in hex",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "write the unsigned message to this file instead of printing it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		srv, err := GetFullNodeServices(cctx)
+		if err != nil {
+			return err
+		}
+		defer srv.Close() //nolint:errcheck
+		fapi := srv.FullNodeAPI()
+
+		ctx := ReqContext(cctx)
+		var params SendParams
+
+		params.To, err = address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return ShowHelp(cctx, fmt.Errorf("failed to parse target address: %w", err))
+		}
+
+		val, err := types.ParseFIL(cctx.Args().Get(1))
+		if err != nil {
+			return ShowHelp(cctx, fmt.Errorf("failed to parse amount: %w", err))
+		}
+		params.Val = abi.TokenAmount(val)
+
+		from, err := address.NewFromString(cctx.String("from"))
+		if err != nil {
+			return ShowHelp(cctx, fmt.Errorf("failed to parse from address: %w", err))
+		}
+		// the signer can't look up the key of an id address
+		params.From, err = fapi.StateAccountKey(ctx, from, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("resolving the key of %s: %w", from, err)
+		}
+
+		if cctx.IsSet("gas-premium") {
+			gp, err := types.BigFromString(cctx.String("gas-premium"))
+			if err != nil {
+				return err
+			}
+			params.GasPremium = &gp
+		}
+
+		if cctx.IsSet("gas-feecap") {
+			gfc, err := types.BigFromString(cctx.String("gas-feecap"))
+			if err != nil {
+				return err
+			}
+			params.GasFeeCap = &gfc
+		}
+
+		if cctx.IsSet("gas-limit") {
+			limit := cctx.Int64("gas-limit")
+			params.GasLimit = &limit
+		}
+
+		params.Method = abi.MethodNum(cctx.Uint64("method"))
+
+		if cctx.IsSet("params-json") {
+			decparams, err := srv.DecodeTypedParamsFromJSON(ctx, params.To, params.Method, cctx.String("params-json"))
+			if err != nil {
+				return fmt.Errorf("failed to decode json params: %w", err)
+			}
+			params.Params = decparams
+		}
+		if cctx.IsSet("params-hex") {
+			if params.Params != nil {
+				return fmt.Errorf("can only specify one of 'params-json' and 'params-hex'")
+			}
+			decparams, err := hex.DecodeString(cctx.String("params-hex"))
+			if err != nil {
+				return fmt.Errorf("failed to decode hex params: %w", err)
+			}
+			params.Params = decparams
+		}
+
+		if cctx.IsSet("nonce") {
+			n := cctx.Uint64("nonce")
+			params.Nonce = &n
+		}
+
+		proto, err := srv.MessageForSend(ctx, params)
+		if err != nil {
+			return xerrors.Errorf("creating message prototype: %w", err)
+		}
+		if !proto.ValidNonce {
+			proto.Message.Nonce, err = fapi.MpoolGetNonce(ctx, proto.Message.From)
+			if err != nil {
+				return xerrors.Errorf("getting nonce: %w", err)
+			}
+		}
+
+		msg, err := fapi.GasEstimateMessageGas(ctx, &proto.Message, nil, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("estimating gas: %w", err)
+		}
+
+		network, err := fapi.StateNetworkName(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting network name: %w", err)
+		}
+
+		return WriteMessageFile(cctx, cctx.String("out"), newMessageFile(string(network), msg))
+	},
+}
+
+var msgExportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "Print the bytes to sign of an unsigned message, for signers other than lotus",
+	ArgsUsage: "[unsigned message file]",
+	Description: `Prints the cid of the message, the bytes a signature is over, which are the
+   bytes of the cid, and the serialized message the cid is of. A signature of
+   the bytes to sign in the format of 'lotus wallet sign' is attached with
+   'lotus msg sign --signature'.`,
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+		afmt := NewAppFmt(cctx.App)
+
+		mf, err := ReadMessageFile(cctx.Args().First(), MessageFileUnsigned)
+		if err != nil {
+			return err
+		}
+
+		ser, err := mf.Message.Serialize()
+		if err != nil {
+			return err
+		}
+
+		out := struct {
+			Cid     cid.Cid
+			ToSign  string
+			Message string
+		}{
+			Cid:     mf.Cid,
+			ToSign:  hex.EncodeToString(mf.Cid.Bytes()),
+			Message: hex.EncodeToString(ser),
+		}
+
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(out)
+		}
+
+		afmt.Printf("Cid: %s\n", out.Cid)
+		afmt.Printf("From: %s\n", mf.Message.From)
+		afmt.Printf("To sign: %s\n", out.ToSign)
+		afmt.Printf("Message: %s\n", out.Message)
+		return nil
+	},
+}
+
+var msgSignCmd = &cli.Command{
+	Name:      "sign",
+	Usage:     "Sign an unsigned message without network access",
+	ArgsUsage: "[unsigned message file]",
+	Description: `Signs the message with the key of its sender, from the file given with
+   --key-file, or else from the keystore of the repo at LOTUS_PATH, with the node
+   stopped. With --signature, attaches a signature made outside of lotus instead.
+
+   Doesn't connect to a node; check the printed message before moving the
+   signed file to the online node.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "key-file",
+			Usage: "file holding the key of the sender, as written by 'lotus wallet export'",
+		},
+		&cli.StringFlag{
+			Name:  "signature",
+			Usage: "signature of the message in hex, as printed by 'lotus wallet sign'",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "write the signed message to this file instead of printing it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+		ctx := ReqContext(cctx)
+
+		mf, err := ReadMessageFile(cctx.Args().First(), MessageFileUnsigned)
+		if err != nil {
+			return err
+		}
+		msg := mf.Message
+
+		var sig *crypto.Signature
+		switch {
+		case cctx.IsSet("signature"):
+			sigBytes, err := hex.DecodeString(strings.TrimSpace(cctx.String("signature")))
+			if err != nil {
+				return xerrors.Errorf("decoding signature: %w", err)
+			}
+			sig = new(crypto.Signature)
+			if err := sig.UnmarshalBinary(sigBytes); err != nil {
+				return xerrors.Errorf("parsing signature: %w", err)
+			}
+		default:
+			w, closer, err := offlineWallet(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			mb, err := msg.ToStorageBlock()
+			if err != nil {
+				return xerrors.Errorf("serializing message: %w", err)
+			}
+			sig, err = w.WalletSign(ctx, msg.From, mb.Cid().Bytes(), api.MsgMeta{
+				Type:  api.MTChainMsg,
+				Extra: mb.RawData(),
+			})
+			if err != nil {
+				return xerrors.Errorf("signing message: %w", err)
+			}
+		}
+
+		if err := sigs.Verify(sig, msg.From, msg.Cid().Bytes()); err != nil {
+			return xerrors.Errorf("signature doesn't match the sender %s: %w", msg.From, err)
+		}
+
+		fmt.Fprintf(cctx.App.ErrWriter, "Signed message %s on %s: %s from %s to %s, method %d, nonce %d, max fee %s\n",
+			msg.Cid(), mf.Network, types.FIL(msg.Value), msg.From, msg.To, msg.Method, msg.Nonce, types.FIL(msg.RequiredFunds()))
+
+		sm := &types.SignedMessage{Message: *msg, Signature: *sig}
+		return WriteMessageFile(cctx, cctx.String("out"), &MessageFile{
+			Version: MessageFileVersion,
+			Kind:    MessageFileSigned,
+			Network: mf.Network,
+			Cid:     sm.Cid(),
+			Signed:  sm,
+		})
+	},
+}
+
+// offlineWallet opens the wallet of the key given with --key-file, or of the
+// keystore of the repo.
+func offlineWallet(cctx *cli.Context) (*wallet.LocalWallet, func(), error) {
+	if cctx.IsSet("key-file") {
+		b, err := os.ReadFile(cctx.String("key-file"))
+		if err != nil {
+			return nil, nil, xerrors.Errorf("reading key file: %w", err)
+		}
+		kb, err := hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, nil, xerrors.Errorf("decoding key file: %w", err)
+		}
+		var ki types.KeyInfo
+		if err := json.Unmarshal(kb, &ki); err != nil {
+			return nil, nil, xerrors.Errorf("parsing key file: %w", err)
+		}
+		k, err := key.NewKey(ki)
+		if err != nil {
+			return nil, nil, err
+		}
+		return wallet.KeyWallet(k), func() {}, nil
+	}
+
+	repoPath := cctx.String("repo")
+	if repoPath == "" {
+		return nil, nil, xerrors.Errorf("specify the key of the sender with --key-file")
+	}
+	r, err := repo.NewFS(repoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	ok, err := r.Exists()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, xerrors.Errorf("repo at '%s' is not initialized, specify the key of the sender with --key-file", repoPath)
+	}
+	lr, err := r.LockRO(repo.FullNode)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("locking repo: %w", err)
+	}
+	ks, err := lr.KeyStore()
+	if err != nil {
+		_ = lr.Close()
+		return nil, nil, err
+	}
+	w, err := wallet.NewWallet(ks)
+	if err != nil {
+		_ = lr.Close()
+		return nil, nil, err
+	}
+	return w, func() { _ = lr.Close() }, nil
+}
+
+var msgSubmitCmd = &cli.Command{
+	Name:      "submit",
+	Usage:     "Push a signed message to the mpool",
+	ArgsUsage: "[signed message file]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		mf, err := ReadMessageFile(cctx.Args().First(), MessageFileSigned)
+		if err != nil {
+			return err
+		}
+
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		network, err := fapi.StateNetworkName(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting network name: %w", err)
+		}
+		if string(network) != mf.Network {
+			return xerrors.Errorf("message was created on network %s, the node is on %s", mf.Network, network)
+		}
+
+		c, err := fapi.MpoolPush(ctx, mf.Signed)
+		if err != nil {
+			return xerrors.Errorf("pushing message: %w", err)
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Println(c)
+		return nil
+	},
+}
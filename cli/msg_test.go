@@ -0,0 +1,127 @@
+// stm: #unit
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func TestMsgOfflineSigning(t *testing.T) {
+	dir := t.TempDir()
+	unsignedPath := filepath.Join(dir, "unsigned.json")
+	signedPath := filepath.Join(dir, "signed.json")
+	keyPath := filepath.Join(dir, "key")
+
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+	kb, err := json.Marshal(k.KeyInfo)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyPath, []byte(hex.EncodeToString(kb)), 0600))
+
+	fromID, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	to, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+
+	t.Run("create", func(t *testing.T) {
+		app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("msg", msgCreateCmd))
+		defer done()
+
+		gomock.InOrder(
+			mockApi.EXPECT().StateAccountKey(gomock.Any(), fromID, types.EmptyTSK).Return(k.Address, nil),
+			mockApi.EXPECT().MpoolGetNonce(gomock.Any(), k.Address).Return(uint64(7), nil),
+			mockApi.EXPECT().GasEstimateMessageGas(gomock.Any(), gomock.Any(), nil, types.EmptyTSK).DoAndReturn(
+				func(_ context.Context, m *types.Message, _ interface{}, _ types.TipSetKey) (*types.Message, error) {
+					out := *m
+					out.GasLimit = 1000
+					out.GasFeeCap = big.NewInt(100)
+					out.GasPremium = big.NewInt(10)
+					return &out, nil
+				}),
+			mockApi.EXPECT().StateNetworkName(gomock.Any()).Return(dtypes.NetworkName("testnet"), nil),
+		)
+
+		err := app.Run([]string{"msg", "create", "--from", fromID.String(), "--out", unsignedPath, to.String(), "1"})
+		require.NoError(t, err)
+
+		mf, err := ReadMessageFile(unsignedPath, MessageFileUnsigned)
+		require.NoError(t, err)
+		require.Equal(t, "testnet", mf.Network)
+		require.Equal(t, k.Address, mf.Message.From)
+		require.Equal(t, uint64(7), mf.Message.Nonce)
+		require.Equal(t, int64(1000), mf.Message.GasLimit)
+		require.Equal(t, types.FromFil(1), mf.Message.Value)
+	})
+
+	t.Run("sign", func(t *testing.T) {
+		// signing doesn't call the node
+		app, _, _, done := NewMockAppWithFullAPI(t, WithCategory("msg", msgSignCmd))
+		defer done()
+
+		_, err := ReadMessageFile(unsignedPath, MessageFileSigned)
+		require.Error(t, err)
+
+		err = app.Run([]string{"msg", "sign", "--key-file", keyPath, "--out", signedPath, unsignedPath})
+		require.NoError(t, err)
+
+		mf, err := ReadMessageFile(signedPath, MessageFileSigned)
+		require.NoError(t, err)
+		require.Equal(t, "testnet", mf.Network)
+		require.NoError(t, sigs.Verify(&mf.Signed.Signature, k.Address, mf.Signed.Message.Cid().Bytes()))
+	})
+
+	t.Run("submit", func(t *testing.T) {
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("msg", msgSubmitCmd))
+		defer done()
+
+		mf, err := ReadMessageFile(signedPath, MessageFileSigned)
+		require.NoError(t, err)
+
+		gomock.InOrder(
+			mockApi.EXPECT().StateNetworkName(gomock.Any()).Return(dtypes.NetworkName("testnet"), nil),
+			mockApi.EXPECT().MpoolPush(gomock.Any(), mf.Signed).Return(mf.Cid, nil),
+		)
+
+		err = app.Run([]string{"msg", "submit", signedPath})
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), mf.Cid.String())
+	})
+
+	t.Run("submit-other-network", func(t *testing.T) {
+		app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("msg", msgSubmitCmd))
+		defer done()
+
+		mockApi.EXPECT().StateNetworkName(gomock.Any()).Return(dtypes.NetworkName("mainnet"), nil)
+
+		err := app.Run([]string{"msg", "submit", signedPath})
+		require.ErrorContains(t, err, "network")
+	})
+
+	t.Run("newer-version", func(t *testing.T) {
+		mf, err := ReadMessageFile(signedPath, MessageFileSigned)
+		require.NoError(t, err)
+		mf.Version = MessageFileVersion + 1
+		b, err := json.Marshal(mf)
+		require.NoError(t, err)
+		path := filepath.Join(dir, "newer.json")
+		require.NoError(t, os.WriteFile(path, b, 0644))
+
+		_, err = ReadMessageFile(path, MessageFileSigned)
+		require.ErrorContains(t, err, "newer")
+	})
+}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/hex"
@@ -11,7 +10,6 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,13 +22,13 @@ import (
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
 	"github.com/urfave/cli/v2"
-	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	actorstypes "github.com/filecoin-project/go-state-types/actors"
 	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/filecoin-project/go-state-types/exitcode"
 	"github.com/filecoin-project/go-state-types/network"
 
@@ -41,12 +39,14 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 )
 
 var StateCmd = &cli.Command{
@@ -69,9 +69,12 @@ var StateCmd = &cli.Command{
 		StateGetActorCmd,
 		StateLookupIDCmd,
 		StateReplayCmd,
+		StateReplayTipSetCmd,
 		StateSectorSizeCmd,
 		StateReadStateCmd,
 		StateListMessagesCmd,
+		StateGasProfileCmd,
+		StateDiffCmd,
 		StateComputeStateCmd,
 		StateCallCmd,
 		StateGetDealSetCmd,
@@ -87,9 +90,13 @@ var StateCmd = &cli.Command{
 }
 
 var StateMinerProvingDeadlineCmd = &cli.Command{
-	Name:      "miner-proving-deadline",
-	Usage:     "Retrieve information about a given miner's proving deadline",
-	ArgsUsage: "[minerAddress]",
+	Name:         "miner-proving-deadline",
+	Usage:        "Retrieve information about a given miner's proving deadline",
+	ArgsUsage:    "[minerAddress]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -118,6 +125,10 @@ var StateMinerProvingDeadlineCmd = &cli.Command{
 			return xerrors.Errorf("getting miner info: %w", err)
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(cd)
+		}
+
 		fmt.Printf("Period Start:\t%s\n", cd.PeriodStart)
 		fmt.Printf("Index:\t\t%d\n", cd.Index)
 		fmt.Printf("Open:\t\t%s\n", cd.Open)
@@ -130,9 +141,13 @@ var StateMinerProvingDeadlineCmd = &cli.Command{
 }
 
 var StateMinerInfo = &cli.Command{
-	Name:      "miner-info",
-	Usage:     "Retrieve miner information",
-	ArgsUsage: "[minerAddress]",
+	Name:         "miner-info",
+	Usage:        "Retrieve miner information",
+	ArgsUsage:    "[minerAddress]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -165,6 +180,24 @@ var StateMinerInfo = &cli.Command{
 		if err != nil {
 			return xerrors.Errorf("getting miner available balance: %w", err)
 		}
+
+		if cctx.Bool("json") {
+			pow, err := api.StateMinerPower(ctx, addr, ts.Key())
+			if err != nil {
+				return err
+			}
+			cd, err := api.StateMinerProvingDeadline(ctx, addr, ts.Key())
+			if err != nil {
+				return xerrors.Errorf("getting miner info: %w", err)
+			}
+			return NewAppFmt(cctx.App).PrintJSON(struct {
+				Info             lapi.MinerInfo
+				AvailableBalance abi.TokenAmount
+				Power            *lapi.MinerPower
+				ProvingDeadline  *dline.Info
+			}{mi, availableBalance, pow, cd})
+		}
+
 		fmt.Printf("Available Balance: %s\n", types.FIL(availableBalance))
 		fmt.Printf("Owner:\t%s\n", mi.Owner)
 		fmt.Printf("Worker:\t%s\n", mi.Worker)
@@ -319,9 +352,13 @@ func ParseTipSetRefOffline(ctx context.Context, cs *store.ChainStore, tss string
 }
 
 var StatePowerCmd = &cli.Command{
-	Name:      "power",
-	Usage:     "Query network or miner power",
-	ArgsUsage: "[<minerAddress> (optional)]",
+	Name:         "power",
+	Usage:        "Query network or miner power",
+	ArgsUsage:    "[<minerAddress> (optional)]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -358,6 +395,10 @@ var StatePowerCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(power)
+		}
+
 		tp := power.TotalPower
 		if cctx.Args().Present() {
 			mp := power.MinerPower
@@ -379,9 +420,13 @@ var StatePowerCmd = &cli.Command{
 }
 
 var StateSectorsCmd = &cli.Command{
-	Name:      "sectors",
-	Usage:     "Query the sector set of a miner",
-	ArgsUsage: "[minerAddress]",
+	Name:         "sectors",
+	Usage:        "Query the sector set of a miner",
+	ArgsUsage:    "[minerAddress]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -410,6 +455,10 @@ var StateSectorsCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(sectors)
+		}
+
 		for _, s := range sectors {
 			fmt.Printf("%d: %s\n", s.SectorNumber, s.SealedCID)
 		}
@@ -419,9 +468,13 @@ var StateSectorsCmd = &cli.Command{
 }
 
 var StateActiveSectorsCmd = &cli.Command{
-	Name:      "active-sectors",
-	Usage:     "Query the active sector set of a miner",
-	ArgsUsage: "[minerAddress]",
+	Name:         "active-sectors",
+	Usage:        "Query the active sector set of a miner",
+	ArgsUsage:    "[minerAddress]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -450,6 +503,10 @@ var StateActiveSectorsCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(sectors)
+		}
+
 		for _, s := range sectors {
 			fmt.Printf("%d: %s\n", s.SectorNumber, s.SealedCID)
 		}
@@ -542,6 +599,10 @@ var StateReplayCmd = &cli.Command{
 			Name:  "detailed-gas",
 			Usage: "print out detailed gas costs for given message",
 		},
+		&cli.StringSliceFlag{
+			Name:  "break",
+			Usage: "print the invocation frames of the calls to an actor, or its methods: <actor|*>[:<method>,...]",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 1 {
@@ -553,7 +614,12 @@ var StateReplayCmd = &cli.Command{
 			return fmt.Errorf("message cid was invalid: %s", err)
 		}
 
-		fapi, closer, err := GetFullNodeAPI(cctx)
+		bps, err := parseBreakpoints(cctx.StringSlice("break"))
+		if err != nil {
+			return err
+		}
+
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -561,9 +627,20 @@ var StateReplayCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		res, err := fapi.StateReplay(ctx, types.EmptyTSK, mcid)
-		if err != nil {
-			return xerrors.Errorf("replay call failed: %w", err)
+		var res *lapi.InvocResult
+		var hits []lapi.BreakpointHit
+		var truncated bool
+		if len(bps) > 0 {
+			dres, err := fapi.StateDebugReplay(ctx, types.EmptyTSK, mcid, bps)
+			if err != nil {
+				return xerrors.Errorf("replay call failed: %w", err)
+			}
+			res, hits, truncated = dres.Result, dres.Hits, dres.Truncated
+		} else {
+			res, err = fapi.StateReplay(ctx, types.EmptyTSK, mcid)
+			if err != nil {
+				return xerrors.Errorf("replay call failed: %w", err)
+			}
 		}
 
 		fmt.Println("Replay receipt:")
@@ -589,10 +666,176 @@ var StateReplayCmd = &cli.Command{
 			printInternalExecutions("\t", res.ExecutionTrace.Subcalls)
 		}
 
+		for _, hit := range hits {
+			fmt.Printf("\nBreakpoint %d hit at depth %d:\n", hit.Breakpoint, hit.Frame.Depth)
+			for _, f := range hit.Stack {
+				fmt.Printf("  %s -> %s method %d\n", f.Caller, f.Receiver, f.Method)
+			}
+			printInvocationFrame(hit.Frame)
+		}
+		if truncated {
+			fmt.Printf("\nOnly the first %d breakpoint hits were printed\n", lapi.DebugMaxHits)
+		}
+
 		return nil
 	},
 }
 
+// parseBreakpoints parses breakpoints of the form <actor|*>[:<method>,...].
+func parseBreakpoints(specs []string) ([]lapi.Breakpoint, error) {
+	var bps []lapi.Breakpoint
+	for _, spec := range specs {
+		var bp lapi.Breakpoint
+		actor, methods, _ := strings.Cut(spec, ":")
+		if actor != "*" {
+			addr, err := address.NewFromString(actor)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing actor of breakpoint %q: %w", spec, err)
+			}
+			bp.Actor = addr
+		}
+		if methods != "" {
+			for _, ms := range strings.Split(methods, ",") {
+				m, err := strconv.ParseUint(ms, 10, 64)
+				if err != nil {
+					return nil, xerrors.Errorf("parsing method of breakpoint %q: %w", spec, err)
+				}
+				bp.Methods = append(bp.Methods, abi.MethodNum(m))
+			}
+		}
+		bps = append(bps, bp)
+	}
+	return bps, nil
+}
+
+func printInvocationFrame(f lapi.InvocationFrame) {
+	fmt.Printf("  Caller: %s\n", f.Caller)
+	fmt.Printf("  Receiver: %s (%s)\n", f.Receiver, f.Code)
+	fmt.Printf("  Method: %d\n", f.Method)
+	fmt.Printf("  Params: %x\n", f.Params)
+	if f.DecodedParams != nil {
+		b, err := json.MarshalIndent(f.DecodedParams, "  ", "  ")
+		if err == nil {
+			fmt.Printf("  Decoded params: %s\n", b)
+		}
+	}
+	fmt.Printf("  Value: %s\n", types.FIL(f.Value))
+	fmt.Printf("  Gas limit: %d\n", f.GasLimit)
+	fmt.Printf("  Exit code: %d\n", f.ExitCode)
+	fmt.Printf("  Return: %x\n", f.Return)
+	fmt.Printf("  Gas used: %d\n", f.GasUsed)
+}
+
+var StateReplayTipSetCmd = &cli.Command{
+	Name:  "replay-tipset",
+	Usage: "Re-execute a tipset against its parent state and print the state diff",
+	Description: `Re-executes the messages of the tipset selected with --tipset (the head
+   by default) against its parent state, and compares the state computed with the
+   state recorded by the chain. The actors changed and the messages executed are
+   listed, to help debug consensus faults and state mismatches.`,
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, &v0api.WrapperV1Full{FullNode: fapi})
+		if err != nil {
+			return err
+		}
+
+		res, err := fapi.StateReplayTipSet(ctx, ts.Key())
+		if err != nil {
+			return xerrors.Errorf("replaying tipset: %w", err)
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Tipset: %s (height %d)\n", res.TipSet, res.Height)
+		afmt.Printf("Parent state: %s\n", res.ParentState)
+		afmt.Printf("State: %s\n", res.State)
+		afmt.Printf("Receipts: %s\n", res.Receipts)
+		if res.State != res.ExpectedState || res.Receipts != res.ExpectedReceipts {
+			afmt.Printf("MISMATCH: the chain recorded state %s, receipts %s\n", res.ExpectedState, res.ExpectedReceipts)
+		} else {
+			afmt.Println("Matches the state recorded by the chain")
+		}
+		afmt.Printf("Gas used: %d\n", res.GasUsed)
+
+		afmt.Printf("\nActors changed: %d\n", len(res.Actors))
+		tw := tablewriter.New(
+			tablewriter.Col("Address"),
+			tablewriter.Col("Change"),
+			tablewriter.Col("Code"),
+			tablewriter.Col("Nonce"),
+			tablewriter.Col("Balance Delta"),
+		)
+		for _, c := range res.Actors {
+			change, act := "modified", c.New
+			switch {
+			case c.Old == nil:
+				change = "created"
+			case c.New == nil:
+				change, act = "deleted", c.Old
+			}
+			tw.Write(map[string]interface{}{
+				"Address":       c.Address,
+				"Change":        change,
+				"Code":          builtin.ActorNameByCode(act.Code),
+				"Nonce":         act.Nonce,
+				"Balance Delta": types.FIL(c.BalanceDelta).Short(),
+			})
+		}
+		if err := tw.Flush(cctx.App.Writer); err != nil {
+			return err
+		}
+
+		afmt.Printf("\nMessages executed: %d\n", len(res.Messages))
+		tw = tablewriter.New(
+			tablewriter.Col("Message"),
+			tablewriter.Col("From"),
+			tablewriter.Col("To"),
+			tablewriter.Col("Method"),
+			tablewriter.Col("Exit Code"),
+			tablewriter.Col("Gas Used"),
+			tablewriter.Col("Total Cost"),
+			tablewriter.NewLineCol("Error"),
+		)
+		for _, m := range res.Messages {
+			row := map[string]interface{}{
+				"Message":    m.MsgCid,
+				"From":       m.From,
+				"To":         m.To,
+				"Method":     m.Method,
+				"Total Cost": types.FIL(m.GasCost.TotalCost).Short(),
+			}
+			if m.MsgRct != nil {
+				row["Exit Code"] = m.MsgRct.ExitCode
+				row["Gas Used"] = m.MsgRct.GasUsed
+			}
+			if m.Error != "" {
+				row["Error"] = m.Error
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
 var StateGetDealSetCmd = &cli.Command{
 	Name:      "get-deal",
 	Usage:     "View on-chain deal info",
@@ -643,6 +886,7 @@ var StateListMinersCmd = &cli.Command{
 			Name:  "sort-by",
 			Usage: "criteria to sort miners by (none, num-deals)",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -674,8 +918,24 @@ var StateListMinersCmd = &cli.Command{
 				return ndm[miners[i]] > ndm[miners[j]]
 			})
 
-			for i := 0; i < 50 && i < len(miners); i++ {
-				fmt.Printf("%s %d\n", miners[i], ndm[miners[i]])
+			if len(miners) > 50 {
+				miners = miners[:50]
+			}
+
+			if cctx.Bool("json") {
+				type minerDeals struct {
+					Miner address.Address
+					Deals int
+				}
+				out := make([]minerDeals, len(miners))
+				for i, m := range miners {
+					out[i] = minerDeals{Miner: m, Deals: ndm[m]}
+				}
+				return NewAppFmt(cctx.App).PrintJSON(out)
+			}
+
+			for _, m := range miners {
+				fmt.Printf("%s %d\n", m, ndm[m])
 			}
 			return nil
 		default:
@@ -683,6 +943,10 @@ var StateListMinersCmd = &cli.Command{
 		case "", "none":
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(miners)
+		}
+
 		for _, m := range miners {
 			fmt.Println(m.String())
 		}
@@ -710,6 +974,9 @@ func getDealsCounts(ctx context.Context, lapi v0api.FullNode) (map[address.Addre
 var StateListActorsCmd = &cli.Command{
 	Name:  "list-actors",
 	Usage: "list all actors in the network",
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -729,6 +996,10 @@ var StateListActorsCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(actors)
+		}
+
 		for _, a := range actors {
 			fmt.Println(a.String())
 		}
@@ -738,9 +1009,13 @@ var StateListActorsCmd = &cli.Command{
 }
 
 var StateGetActorCmd = &cli.Command{
-	Name:      "get-actor",
-	Usage:     "Print actor information",
-	ArgsUsage: "[actorAddress]",
+	Name:         "get-actor",
+	Usage:        "Print actor information",
+	ArgsUsage:    "[actorAddress]",
+	BashComplete: completeArgs(CompleteWalletAddresses),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -771,6 +1046,14 @@ var StateGetActorCmd = &cli.Command{
 
 		strtype := builtin.ActorNameByCode(a.Code)
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(struct {
+				Address address.Address
+				Type    string
+				*types.Actor
+			}{addr, strtype, a})
+		}
+
 		fmt.Printf("Address:\t%s\n", addr)
 		fmt.Printf("Balance:\t%s\n", types.FIL(a.Balance))
 		fmt.Printf("Nonce:\t\t%d\n", a.Nonce)
@@ -782,15 +1065,17 @@ var StateGetActorCmd = &cli.Command{
 }
 
 var StateLookupIDCmd = &cli.Command{
-	Name:      "lookup",
-	Usage:     "Find corresponding ID address",
-	ArgsUsage: "[address]",
+	Name:         "lookup",
+	Usage:        "Find corresponding ID address",
+	ArgsUsage:    "[address]",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:    "reverse",
 			Aliases: []string{"r"},
 			Usage:   "Perform reverse lookup",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -826,6 +1111,10 @@ var StateLookupIDCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(a)
+		}
+
 		fmt.Printf("%s\n", a)
 
 		return nil
@@ -833,9 +1122,13 @@ var StateLookupIDCmd = &cli.Command{
 }
 
 var StateSectorSizeCmd = &cli.Command{
-	Name:      "sector-size",
-	Usage:     "Look up miners sector size",
-	ArgsUsage: "[minerAddress]",
+	Name:         "sector-size",
+	Usage:        "Look up miners sector size",
+	ArgsUsage:    "[minerAddress]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -864,15 +1157,20 @@ var StateSectorSizeCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(mi.SectorSize)
+		}
+
 		fmt.Printf("%s (%d)\n", types.SizeStr(types.NewInt(uint64(mi.SectorSize))), mi.SectorSize)
 		return nil
 	},
 }
 
 var StateReadStateCmd = &cli.Command{
-	Name:      "read-state",
-	Usage:     "View a json representation of an actors state",
-	ArgsUsage: "[actorAddress]",
+	Name:         "read-state",
+	Usage:        "View a json representation of an actors state",
+	ArgsUsage:    "[actorAddress]",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -923,17 +1221,37 @@ var StateListMessagesCmd = &cli.Command{
 			Name:  "from",
 			Usage: "return messages from a given address",
 		},
+		&cli.Int64SliceFlag{
+			Name:  "method",
+			Usage: "return messages calling one of the given method numbers",
+		},
+		&cli.Int64Flag{
+			Name:  "exit-code",
+			Usage: "return executed messages with the given exit code",
+		},
+		&cli.StringFlag{
+			Name:  "min-value",
+			Usage: "return messages sending at least the given value (FIL)",
+		},
+		&cli.StringFlag{
+			Name:  "max-value",
+			Usage: "return messages sending at most the given value (FIL)",
+		},
 		&cli.Uint64Flag{
 			Name:  "toheight",
 			Usage: "don't look before given block height",
 		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "maximum number of messages to list, 0 for all",
+		},
 		&cli.BoolFlag{
 			Name:  "cids",
 			Usage: "print message CIDs instead of messages",
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -941,13 +1259,15 @@ var StateListMessagesCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		var toa, froma address.Address
+		query := &lapi.MessageQuery{
+			MinHeight: abi.ChainEpoch(cctx.Uint64("toheight")),
+		}
 		if tos := cctx.String("to"); tos != "" {
 			a, err := address.NewFromString(tos)
 			if err != nil {
 				return fmt.Errorf("given 'to' address %q was invalid: %w", tos, err)
 			}
-			toa = a
+			query.To = a
 		}
 
 		if froms := cctx.String("from"); froms != "" {
@@ -955,67 +1275,320 @@ var StateListMessagesCmd = &cli.Command{
 			if err != nil {
 				return fmt.Errorf("given 'from' address %q was invalid: %w", froms, err)
 			}
-			froma = a
+			query.From = a
 		}
 
-		toh := abi.ChainEpoch(cctx.Uint64("toheight"))
+		for _, m := range cctx.Int64Slice("method") {
+			query.Methods = append(query.Methods, abi.MethodNum(m))
+		}
+		if cctx.IsSet("exit-code") {
+			ec := exitcode.ExitCode(cctx.Int64("exit-code"))
+			query.ExitCode = &ec
+		}
+		if v := cctx.String("min-value"); v != "" {
+			val, err := types.ParseFIL(v)
+			if err != nil {
+				return xerrors.Errorf("parsing min-value: %w", err)
+			}
+			amt := abi.TokenAmount(val)
+			query.MinValue = &amt
+		}
+		if v := cctx.String("max-value"); v != "" {
+			val, err := types.ParseFIL(v)
+			if err != nil {
+				return xerrors.Errorf("parsing max-value: %w", err)
+			}
+			amt := abi.TokenAmount(val)
+			query.MaxValue = &amt
+		}
 
-		ts, err := LoadTipSet(ctx, cctx, api)
+		ts, err := LoadTipSet(ctx, cctx, &v0api.WrapperV1Full{FullNode: api})
 		if err != nil {
 			return err
 		}
 
-		windowSize := abi.ChainEpoch(100)
-
-		cur := ts
-		for cur.Height() > toh {
+		limit := cctx.Int("limit")
+		listed := 0
+		for {
 			if ctx.Err() != nil {
 				return ctx.Err()
 			}
 
-			end := toh
-			if cur.Height()-windowSize > end {
-				end = cur.Height() - windowSize
+			if limit > 0 {
+				query.Limit = limit - listed
 			}
-
-			msgs, err := api.StateListMessages(ctx, &lapi.MessageMatch{To: toa, From: froma}, cur.Key(), end)
+			res, err := api.StateQueryMessages(ctx, query, ts.Key())
 			if err != nil {
 				return err
 			}
 
-			for _, c := range msgs {
+			for _, m := range res.Messages {
 				if cctx.Bool("cids") {
-					fmt.Println(c.String())
+					fmt.Println(m.Cid.String())
 					continue
 				}
 
-				m, err := api.ChainGetMessage(ctx, c)
-				if err != nil {
-					return err
-				}
-				b, err := json.MarshalIndent(m, "", "  ")
+				b, err := json.MarshalIndent(m.Message, "", "  ")
 				if err != nil {
 					return err
 				}
 				fmt.Println(string(b))
 			}
 
-			if end <= 0 {
-				break
+			listed += len(res.Messages)
+			if res.Next == nil || limit > 0 && listed >= limit {
+				return nil
 			}
+			query.Cursor = res.Next
+		}
+	},
+}
+
+var StateGasProfileCmd = &cli.Command{
+	Name:  "gas-profile",
+	Usage: "Aggregate the gas used by the messages of a range of epochs by actor and method",
+	Description: `Aggregates the gas used by the messages included in the tipsets of the
+   chain of the tipset selected with --tipset (the head by default) over the given
+   number of epochs, by the code of the actor the messages were sent to and the
+   method called.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epochs",
+			Usage: "number of epochs profiled",
+			Value: 120,
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "height of the last tipset profiled, the parent of the selected tipset by default",
+		},
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
 
-			next, err := api.ChainGetTipSetByHeight(ctx, end-1, cur.Key())
+		ts, err := LoadTipSet(ctx, cctx, &v0api.WrapperV1Full{FullNode: fapi})
+		if err != nil {
+			return err
+		}
+
+		to := ts.Height() - 1
+		if cctx.IsSet("to") {
+			to = abi.ChainEpoch(cctx.Int64("to"))
+		}
+		from := to - abi.ChainEpoch(cctx.Int64("epochs")) + 1
+
+		profile, err := fapi.StateGasProfile(ctx, from, to, ts.Key())
+		if err != nil {
+			return xerrors.Errorf("profiling gas: %w", err)
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(profile, "", "  ")
 			if err != nil {
 				return err
 			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Epochs: %d to %d\n", profile.From, profile.To)
+		afmt.Printf("Messages: %d\n", profile.Messages)
+		afmt.Printf("Gas used: %d\n\n", profile.GasUsed)
 
-			cur = next
+		tw := tablewriter.New(
+			tablewriter.Col("Actor"),
+			tablewriter.Col("Method"),
+			tablewriter.Col("Messages"),
+			tablewriter.Col("Failed"),
+			tablewriter.Col("Gas Used"),
+			tablewriter.Col("Share"),
+			tablewriter.Col("Gas Limit"),
+		)
+		for _, m := range profile.Methods {
+			actor, method := "<no actor>", fmt.Sprint(m.Method)
+			if m.Code.Defined() {
+				actor = builtin.ActorNameByCode(m.Code)
+				if name := getMethod(m.Code, m.Method); name != "" {
+					method = fmt.Sprintf("%s (%d)", name, m.Method)
+				}
+			}
+			share := 0.0
+			if profile.GasUsed > 0 {
+				share = float64(m.GasUsed) * 100 / float64(profile.GasUsed)
+			}
+			tw.Write(map[string]interface{}{
+				"Actor":     actor,
+				"Method":    method,
+				"Messages":  m.Messages,
+				"Failed":    m.Failed,
+				"Gas Used":  m.GasUsed,
+				"Share":     fmt.Sprintf("%.2f%%", share),
+				"Gas Limit": m.GasLimit,
+			})
 		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
 
+var StateDiffCmd = &cli.Command{
+	Name:      "diff",
+	Usage:     "Print the actors changed between the states of two epochs",
+	ArgsUsage: "[from epoch] [to epoch]",
+	Description: `Compares the parent states of the tipsets at the two epochs of the chain of
+   the tipset selected with --tipset (the head by default), and prints the
+   actors created, deleted and modified, with the changes of their balance and
+   nonce. With --decode, the changes of the collections in the states of the
+   builtin init, miner, market, power and multisig actors are summarized too.`,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "actor",
+			Usage: "only print the changes of this actor; can be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "decode",
+			Usage: "decode the changes of the states of the builtin actors",
+		},
+		&JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, &v0api.WrapperV1Full{FullNode: fapi})
+		if err != nil {
+			return err
+		}
+
+		var tss [2]*types.TipSet
+		for i := range tss {
+			h, err := strconv.ParseInt(cctx.Args().Get(i), 10, 64)
+			if err != nil {
+				return xerrors.Errorf("parsing epoch: %w", err)
+			}
+			if tss[i], err = fapi.ChainGetTipSetByHeight(ctx, abi.ChainEpoch(h), ts.Key()); err != nil {
+				return xerrors.Errorf("getting tipset at %d: %w", h, err)
+			}
+		}
+		from, to := tss[0], tss[1]
+
+		diff, err := fapi.StateDiff(ctx, from.Key(), to.Key(), cctx.Bool("decode"))
+		if err != nil {
+			return xerrors.Errorf("diffing states: %w", err)
+		}
+
+		if actors := cctx.StringSlice("actor"); len(actors) > 0 {
+			ids := map[address.Address]bool{}
+			for _, a := range actors {
+				addr, err := address.NewFromString(a)
+				if err != nil {
+					return xerrors.Errorf("parsing actor address: %w", err)
+				}
+				// deleted actors are only found in the first state
+				id, err := fapi.StateLookupID(ctx, addr, to.Key())
+				if err != nil {
+					if id, err = fapi.StateLookupID(ctx, addr, from.Key()); err != nil {
+						return xerrors.Errorf("looking up the id of %s: %w", addr, err)
+					}
+				}
+				ids[id] = true
+			}
+			diff.Created = filterActorDiffs(diff.Created, ids)
+			diff.Deleted = filterActorDiffs(diff.Deleted, ids)
+			diff.Modified = filterActorDiffs(diff.Modified, ids)
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		afmt.Printf("From: %d %s, state %s\n", from.Height(), diff.From, diff.FromState)
+		afmt.Printf("To: %d %s, state %s\n", to.Height(), diff.To, diff.ToState)
+		afmt.Printf("Created: %d, deleted: %d, modified: %d\n", len(diff.Created), len(diff.Deleted), len(diff.Modified))
+		for _, d := range diff.Created {
+			afmt.Printf("+ %s (%s): balance %s, nonce %d\n", d.Address, builtin.ActorNameByCode(d.New.Code), types.FIL(d.New.Balance), d.New.Nonce)
+		}
+		for _, d := range diff.Deleted {
+			afmt.Printf("- %s (%s): balance %s, nonce %d\n", d.Address, builtin.ActorNameByCode(d.Old.Code), types.FIL(d.Old.Balance), d.Old.Nonce)
+		}
+		for _, d := range diff.Modified {
+			afmt.Printf("~ %s (%s): balance %s, nonce %+d\n", d.Address, builtin.ActorNameByCode(d.New.Code), signedFIL(d.BalanceDelta), d.NonceDelta)
+			if d.Old.Code != d.New.Code {
+				afmt.Printf("    code: %s -> %s\n", builtin.ActorNameByCode(d.Old.Code), builtin.ActorNameByCode(d.New.Code))
+			}
+			if d.State != nil {
+				printActorStateDiff(afmt, d.State)
+			}
+		}
 		return nil
 	},
 }
 
+func filterActorDiffs(diffs []*lapi.ActorDiff, ids map[address.Address]bool) []*lapi.ActorDiff {
+	var out []*lapi.ActorDiff
+	for _, d := range diffs {
+		if ids[d.Address] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// signedFIL formats an amount with its sign, positive ones included.
+func signedFIL(amt abi.TokenAmount) string {
+	if amt.Sign() > 0 {
+		return "+" + types.FIL(amt).String()
+	}
+	return types.FIL(amt).String()
+}
+
+// printActorStateDiff prints the number of entries changed in each of the
+// collections decoded in d.
+func printActorStateDiff(afmt *AppFmt, d *lapi.ActorStateDiff) {
+	if c := d.AddressMap; c != nil {
+		afmt.Printf("    addresses: %d added, %d modified, %d removed\n", len(c.Added), len(c.Modified), len(c.Removed))
+	}
+	if c := d.PreCommits; c != nil {
+		afmt.Printf("    precommits: %d added, %d removed\n", len(c.Added), len(c.Removed))
+	}
+	if c := d.Sectors; c != nil {
+		afmt.Printf("    sectors: %d added, %d extended, %d removed\n", len(c.Added), len(c.Extended), len(c.Removed))
+	}
+	if c := d.DealProposals; c != nil {
+		afmt.Printf("    deal proposals: %d added, %d removed\n", len(c.Added), len(c.Removed))
+	}
+	if c := d.DealStates; c != nil {
+		afmt.Printf("    deal states: %d added, %d modified, %d removed\n", len(c.Added), len(c.Modified), len(c.Removed))
+	}
+	if c := d.Claims; c != nil {
+		afmt.Printf("    claims: %d added, %d modified, %d removed\n", len(c.Added), len(c.Modified), len(c.Removed))
+	}
+	if c := d.PendingTransactions; c != nil {
+		afmt.Printf("    pending transactions: %d added, %d modified, %d removed\n", len(c.Added), len(c.Modified), len(c.Removed))
+	}
+}
+
 var StateComputeStateCmd = &cli.Command{
 	Name:  "compute-state",
 	Usage: "Perform state computations",
@@ -1036,10 +1609,7 @@ var StateComputeStateCmd = &cli.Command{
 			Name:  "html",
 			Usage: "generate html report",
 		},
-		&cli.BoolFlag{
-			Name:  "json",
-			Usage: "generate json output",
-		},
+		&JSONFlag,
 		&cli.StringFlag{
 			Name:  "compute-state-output",
 			Usage: "a json file containing pre-existing compute-state output, to generate html reports without rerunning state changes",
@@ -1424,27 +1994,18 @@ func isVerySlow(t time.Duration) bool {
 }
 
 func JsonParams(code cid.Cid, method abi.MethodNum, params []byte) (string, error) {
-	p, err := stmgr.GetParamType(filcns.NewActorRegistry(), code, method) // todo use api for correct actor registry
+	p, err := stmgr.DecodeParams(filcns.NewActorRegistry(), code, method, params) // todo use api for correct actor registry
 	if err != nil {
 		return "", err
 	}
 
-	if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
-		return "", err
-	}
-
 	b, err := json.MarshalIndent(p, "", "  ")
 	return string(b), err
 }
 
 func jsonReturn(code cid.Cid, method abi.MethodNum, ret []byte) (string, error) {
-	methodMeta, found := filcns.NewActorRegistry().Methods[code][method] // TODO: use remote
-	if !found {
-		return "", fmt.Errorf("method %d not found on actor %s", method, code)
-	}
-	re := reflect.New(methodMeta.Ret.Elem())
-	p := re.Interface().(cbg.CBORUnmarshaler)
-	if err := p.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+	p, err := stmgr.DecodeReturn(filcns.NewActorRegistry(), code, method, ret) // TODO: use remote
+	if err != nil {
 		return "", err
 	}
 
@@ -1452,23 +2013,47 @@ func jsonReturn(code cid.Cid, method abi.MethodNum, ret []byte) (string, error)
 	return string(b), err
 }
 
+// The exit codes of wait-msg, besides 0 when the message landed and succeeded,
+// and 1 on errors.
+const (
+	WaitMsgExitFailed   = 2
+	WaitMsgExitReplaced = 3
+	WaitMsgExitTimeout  = 4
+)
+
 var StateWaitMsgCmd = &cli.Command{
 	Name:      "wait-msg",
 	Aliases:   []string{"wait-message"},
 	Usage:     "Wait for a message to appear on chain",
 	ArgsUsage: "[messageCid]",
+	Description: `Waits for the message, or a message of the same sender and nonce replacing
+   it, to land and get the confidence given, and prints its receipt. The exit
+   code tells how the wait ended:
+
+   0  the message landed and succeeded
+   1  waiting failed
+   2  the message landed and failed
+   3  the message was replaced, the receipt printed is that of the replacement
+   4  the message didn't land before the timeout`,
 	Flags: []cli.Flag{
-		&cli.StringFlag{
+		&cli.DurationFlag{
 			Name:  "timeout",
-			Value: "10m",
+			Usage: "time to wait for the message for",
+			Value: 10 * time.Minute,
 		},
+		&cli.Uint64Flag{
+			Name:  "confidence",
+			Usage: "number of epochs to wait for after the message landed",
+			Value: build.MessageConfidence,
+		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
 			return fmt.Errorf("must specify message cid to wait for")
 		}
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -1481,25 +2066,81 @@ var StateWaitMsgCmd = &cli.Command{
 			return err
 		}
 
-		mw, err := api.StateWaitMsg(ctx, msg, build.MessageConfidence)
-		if err != nil {
+		wctx, cancel := context.WithTimeout(ctx, cctx.Duration("timeout"))
+		defer cancel()
+
+		res := waitMsgResult{Message: msg}
+		res.Lookup, err = fapi.StateWaitMsg(wctx, msg, cctx.Uint64("confidence"), lapi.LookbackNoLimit, true)
+		switch {
+		case err != nil && wctx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+			res.Status = waitMsgTimeout
+		case err != nil:
 			return err
+		case res.Lookup.Message != msg:
+			res.Status = waitMsgReplaced
+		case res.Lookup.Receipt.ExitCode.IsError():
+			res.Status = waitMsgFailed
+		default:
+			res.Status = waitMsgLanded
 		}
 
-		m, err := api.ChainGetMessage(ctx, msg)
-		if err != nil {
-			return err
+		if cctx.Bool("json") {
+			if err := NewAppFmt(cctx.App).PrintJSON(res); err != nil {
+				return err
+			}
+		} else if res.Lookup != nil {
+			api := &v0api.WrapperV1Full{FullNode: fapi}
+			m, err := api.ChainGetMessage(ctx, res.Lookup.Message)
+			if err != nil {
+				return err
+			}
+			if err := printMsg(ctx, NewAppFmt(cctx.App), api, msg, res.Lookup, m); err != nil {
+				return err
+			}
 		}
 
-		return printMsg(ctx, api, msg, mw, m)
+		return res.exitErr(cctx.Duration("timeout"))
 	},
 }
 
+type waitMsgStatus string
+
+const (
+	waitMsgLanded   waitMsgStatus = "landed"
+	waitMsgFailed   waitMsgStatus = "failed"
+	waitMsgReplaced waitMsgStatus = "replaced"
+	waitMsgTimeout  waitMsgStatus = "timeout"
+)
+
+// waitMsgResult is the output of wait-msg. Lookup is nil on timeouts.
+type waitMsgResult struct {
+	Message cid.Cid
+	Status  waitMsgStatus
+	Lookup  *lapi.MsgLookup `json:",omitempty"`
+}
+
+// exitErr returns the error of the exit code of the result, nil when the
+// message landed and succeeded.
+func (r *waitMsgResult) exitErr(timeout time.Duration) error {
+	switch r.Status {
+	case waitMsgFailed:
+		return &ExitCodeErr{Code: WaitMsgExitFailed, Err: xerrors.Errorf("message %s failed with exit code %d", r.Message, r.Lookup.Receipt.ExitCode)}
+	case waitMsgReplaced:
+		return &ExitCodeErr{Code: WaitMsgExitReplaced, Err: xerrors.Errorf("message %s was replaced by %s", r.Message, r.Lookup.Message)}
+	case waitMsgTimeout:
+		return &ExitCodeErr{Code: WaitMsgExitTimeout, Err: xerrors.Errorf("message %s didn't land in %s", r.Message, timeout)}
+	}
+	return nil
+}
+
 var StateSearchMsgCmd = &cli.Command{
 	Name:      "search-msg",
 	Aliases:   []string{"search-message"},
 	Usage:     "Search to see whether a message has appeared on chain",
 	ArgsUsage: "[messageCid]",
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
 			return fmt.Errorf("must specify message cid to search for")
@@ -1527,16 +2168,20 @@ var StateSearchMsgCmd = &cli.Command{
 			return fmt.Errorf("failed to find message: %s", msg)
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(mw)
+		}
+
 		m, err := api.ChainGetMessage(ctx, msg)
 		if err != nil {
 			return err
 		}
 
-		return printMsg(ctx, api, msg, mw, m)
+		return printMsg(ctx, NewAppFmt(cctx.App), api, msg, mw, m)
 	},
 }
 
-func printReceiptReturn(ctx context.Context, api v0api.FullNode, m *types.Message, r types.MessageReceipt) error {
+func printReceiptReturn(ctx context.Context, afmt *AppFmt, api v0api.FullNode, m *types.Message, r types.MessageReceipt) error {
 	if len(r.Return) == 0 {
 		return nil
 	}
@@ -1551,26 +2196,26 @@ func printReceiptReturn(ctx context.Context, api v0api.FullNode, m *types.Messag
 		return err
 	}
 
-	fmt.Println("Decoded return value: ", jret)
+	afmt.Println("Decoded return value: ", jret)
 
 	return nil
 }
 
-func printMsg(ctx context.Context, api v0api.FullNode, msg cid.Cid, mw *lapi.MsgLookup, m *types.Message) error {
+func printMsg(ctx context.Context, afmt *AppFmt, api v0api.FullNode, msg cid.Cid, mw *lapi.MsgLookup, m *types.Message) error {
 	if mw == nil {
-		fmt.Println("message was not found on chain")
+		afmt.Println("message was not found on chain")
 		return nil
 	}
 
 	if mw.Message != msg {
-		fmt.Printf("Message was replaced: %s\n", mw.Message)
+		afmt.Printf("Message was replaced: %s\n", mw.Message)
 	}
 
-	fmt.Printf("Executed in tipset: %s\n", mw.TipSet.Cids())
-	fmt.Printf("Exit Code: %d\n", mw.Receipt.ExitCode)
-	fmt.Printf("Gas Used: %d\n", mw.Receipt.GasUsed)
-	fmt.Printf("Return: %x\n", mw.Receipt.Return)
-	if err := printReceiptReturn(ctx, api, m, mw.Receipt); err != nil {
+	afmt.Printf("Executed in tipset: %s\n", mw.TipSet.Cids())
+	afmt.Printf("Exit Code: %d\n", mw.Receipt.ExitCode)
+	afmt.Printf("Gas Used: %d\n", mw.Receipt.GasUsed)
+	afmt.Printf("Return: %x\n", mw.Receipt.Return)
+	if err := printReceiptReturn(ctx, afmt, api, m, mw.Receipt); err != nil {
 		return err
 	}
 
@@ -1713,6 +2358,7 @@ var StateCircSupplyCmd = &cli.Command{
 			Usage: "calculates the approximation of the circulating supply used internally by the VM (instead of the exact amount)",
 			Value: false,
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -1734,6 +2380,10 @@ var StateCircSupplyCmd = &cli.Command{
 				return err
 			}
 
+			if cctx.Bool("json") {
+				return NewAppFmt(cctx.App).PrintJSON(circ)
+			}
+
 			fmt.Println("Circulating supply: ", types.FIL(circ.FilCirculating))
 			fmt.Println("Mined: ", types.FIL(circ.FilMined))
 			fmt.Println("Vested: ", types.FIL(circ.FilVested))
@@ -1745,6 +2395,10 @@ var StateCircSupplyCmd = &cli.Command{
 				return err
 			}
 
+			if cctx.Bool("json") {
+				return NewAppFmt(cctx.App).PrintJSON(circ)
+			}
+
 			fmt.Println("Exact circulating supply: ", types.FIL(circ))
 			return nil
 		}
@@ -1754,10 +2408,14 @@ var StateCircSupplyCmd = &cli.Command{
 }
 
 var StateSectorCmd = &cli.Command{
-	Name:      "sector",
-	Aliases:   []string{"sector-info"},
-	Usage:     "Get miner sector info",
-	ArgsUsage: "[minerAddress] [sectorNumber]",
+	Name:         "sector",
+	Aliases:      []string{"sector-info"},
+	Usage:        "Get miner sector info",
+	ArgsUsage:    "[minerAddress] [sectorNumber]",
+	BashComplete: completeArgs(CompleteMinerIDs),
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -1794,6 +2452,17 @@ var StateSectorCmd = &cli.Command{
 			return xerrors.Errorf("sector %d for miner %s not found", sid, maddr)
 		}
 
+		if cctx.Bool("json") {
+			sp, err := api.StateSectorPartition(ctx, maddr, abi.SectorNumber(sid), ts.Key())
+			if err != nil {
+				return err
+			}
+			return NewAppFmt(cctx.App).PrintJSON(struct {
+				*miner.SectorOnChainInfo
+				*miner.SectorLocation
+			}{si, sp})
+		}
+
 		fmt.Println("SectorNumber: ", si.SectorNumber)
 		fmt.Println("SealProof: ", si.SealProof)
 		fmt.Println("SealedCID: ", si.SealedCID)
@@ -1835,6 +2504,9 @@ var StateMarketCmd = &cli.Command{
 var stateMarketBalanceCmd = &cli.Command{
 	Name:  "balance",
 	Usage: "Get the market balance (locked and escrowed) for a given account",
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
 			return ShowHelp(cctx, fmt.Errorf("must specify address to print market balance for"))
@@ -1863,6 +2535,10 @@ var stateMarketBalanceCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(balance)
+		}
+
 		fmt.Printf("Escrow: %s\n", types.FIL(balance.Escrow))
 		fmt.Printf("Locked: %s\n", types.FIL(balance.Locked))
 
@@ -1873,6 +2549,9 @@ var stateMarketBalanceCmd = &cli.Command{
 var StateNtwkVersionCmd = &cli.Command{
 	Name:  "network-version",
 	Usage: "Returns the network version",
+	Flags: []cli.Flag{
+		&JSONFlag,
+	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Present() {
 			return ShowHelp(cctx, fmt.Errorf("doesn't expect any arguments"))
@@ -1896,6 +2575,10 @@ var StateNtwkVersionCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("json") {
+			return NewAppFmt(cctx.App).PrintJSON(nv)
+		}
+
 		fmt.Printf("Network Version: %d\n", nv)
 
 		return nil
@@ -1910,6 +2593,7 @@ var StateSysActorCIDsCmd = &cli.Command{
 			Name:  "network-version",
 			Usage: "specify network version",
 		},
+		&JSONFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Present() {
@@ -1934,26 +2618,39 @@ var StateSysActorCIDsCmd = &cli.Command{
 			}
 		}
 
-		fmt.Printf("Network Version: %d\n", nv)
-
 		actorVersion, err := actorstypes.VersionForNetwork(nv)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Actor Version: %d\n", actorVersion)
 
 		manifestCid, ok := actors.GetManifest(actorVersion)
+
+		actorsCids, err := api.StateActorCodeCIDs(ctx, nv)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out := struct {
+				NetworkVersion network.Version
+				ActorVersion   actorstypes.Version
+				Manifest       *cid.Cid `json:",omitempty"`
+				Actors         map[string]cid.Cid
+			}{NetworkVersion: nv, ActorVersion: actorVersion, Actors: actorsCids}
+			if ok {
+				out.Manifest = &manifestCid
+			}
+			return NewAppFmt(cctx.App).PrintJSON(out)
+		}
+
+		fmt.Printf("Network Version: %d\n", nv)
+		fmt.Printf("Actor Version: %d\n", actorVersion)
 		if ok {
 			fmt.Printf("Manifest CID: %v\n", manifestCid)
 		}
 
 		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
 		_, _ = fmt.Fprintln(tw, "\nActor\tCID\t")
-
-		actorsCids, err := api.StateActorCodeCIDs(ctx, nv)
-		if err != nil {
-			return err
-		}
 		for name, cid := range actorsCids {
 			_, _ = fmt.Fprintf(tw, "%v\t%v\n", name, cid)
 		}
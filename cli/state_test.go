@@ -0,0 +1,117 @@
+// stm: #unit
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/specs-actors/v7/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestStateDiff(t *testing.T) {
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("state", StateDiffCmd))
+	defer done()
+
+	from := mock.TipSet(mock.MkBlock(nil, 0, 0))
+	to := mock.TipSet(mock.MkBlock(from, 1, 1))
+	head := mock.TipSet(mock.MkBlock(to, 2, 2))
+
+	acct := func(bal int64, nonce uint64) *types.Actor {
+		return &types.Actor{Code: builtin.AccountActorCodeID, Balance: big.NewInt(bal), Nonce: nonce}
+	}
+	diff := &api.StateDiff{
+		From: from.Key(),
+		To:   to.Key(),
+		Created: []*api.ActorDiff{{
+			ActorChange: api.ActorChange{Address: mock.Address(1000), New: acct(5, 0), BalanceDelta: big.NewInt(5)},
+		}},
+		Modified: []*api.ActorDiff{{
+			ActorChange: api.ActorChange{Address: mock.Address(1001), Old: acct(5, 1), New: acct(3, 2), BalanceDelta: big.NewInt(-2)},
+			NonceDelta:  1,
+		}},
+	}
+	expectDiff := func() {
+		mockApi.EXPECT().ChainHead(gomock.Any()).Return(head, nil)
+		mockApi.EXPECT().ChainGetTipSetByHeight(gomock.Any(), abi.ChainEpoch(0), head.Key()).Return(from, nil)
+		mockApi.EXPECT().ChainGetTipSetByHeight(gomock.Any(), abi.ChainEpoch(1), head.Key()).Return(to, nil)
+		mockApi.EXPECT().StateDiff(gomock.Any(), from.Key(), to.Key(), false).Return(diff, nil)
+	}
+
+	expectDiff()
+	err := app.Run([]string{"state", "diff", "0", "1"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Created: 1, deleted: 0, modified: 1")
+	assert.Contains(t, buf.String(), "+ f01000 (fil/7/account): balance 0.000000000000000005 FIL, nonce 0")
+	assert.Contains(t, buf.String(), "~ f01001 (fil/7/account): balance -0.000000000000000002 FIL, nonce +1")
+
+	// the actors are looked up in the second state
+	buf.Reset()
+	expectDiff()
+	mockApi.EXPECT().StateLookupID(gomock.Any(), mock.Address(1001), to.Key()).Return(mock.Address(1001), nil)
+	err = app.Run([]string{"state", "diff", "--actor", "f01001", "0", "1"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Created: 0, deleted: 0, modified: 1")
+	assert.NotContains(t, buf.String(), "f01000")
+}
+
+func TestStateWaitMsg(t *testing.T) {
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("state", StateWaitMsgCmd))
+	defer done()
+
+	ts := mock.TipSet(mock.MkBlock(nil, 0, 0))
+	msg := mock.UnsignedMessage(mock.Address(1000), mock.Address(1001), 1)
+	replacement := mock.UnsignedMessage(mock.Address(1000), mock.Address(1001), 1)
+	replacement.GasPremium = big.NewInt(2)
+
+	lookup := func(m *types.Message, code exitcode.ExitCode) *api.MsgLookup {
+		return &api.MsgLookup{Message: m.Cid(), Receipt: types.MessageReceipt{ExitCode: code}, TipSet: ts.Key(), Height: ts.Height()}
+	}
+	exitCode := func(err error) int {
+		var ece *ExitCodeErr
+		if !errors.As(err, &ece) {
+			return 0
+		}
+		return ece.Code
+	}
+
+	mockApi.EXPECT().StateWaitMsg(gomock.Any(), msg.Cid(), uint64(5), api.LookbackNoLimit, true).Return(lookup(msg, exitcode.Ok), nil)
+	mockApi.EXPECT().ChainGetMessage(gomock.Any(), msg.Cid()).Return(msg, nil)
+	err := app.Run([]string{"state", "wait-msg", msg.Cid().String()})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Exit Code: 0")
+
+	buf.Reset()
+	mockApi.EXPECT().StateWaitMsg(gomock.Any(), msg.Cid(), uint64(5), api.LookbackNoLimit, true).Return(lookup(msg, exitcode.ErrForbidden), nil)
+	err = app.Run([]string{"state", "wait-msg", "--json", msg.Cid().String()})
+	assert.Equal(t, WaitMsgExitFailed, exitCode(err))
+	assert.Contains(t, buf.String(), `"Status": "failed"`)
+
+	buf.Reset()
+	mockApi.EXPECT().StateWaitMsg(gomock.Any(), msg.Cid(), uint64(5), api.LookbackNoLimit, true).Return(lookup(replacement, exitcode.Ok), nil)
+	mockApi.EXPECT().ChainGetMessage(gomock.Any(), replacement.Cid()).Return(replacement, nil)
+	err = app.Run([]string{"state", "wait-msg", msg.Cid().String()})
+	assert.Equal(t, WaitMsgExitReplaced, exitCode(err))
+	assert.Contains(t, buf.String(), "Message was replaced: "+replacement.Cid().String())
+
+	buf.Reset()
+	mockApi.EXPECT().StateWaitMsg(gomock.Any(), msg.Cid(), uint64(1), api.LookbackNoLimit, true).DoAndReturn(
+		func(ctx context.Context, _ cid.Cid, _ uint64, _ abi.ChainEpoch, _ bool) (*api.MsgLookup, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	err = app.Run([]string{"state", "wait-msg", "--timeout", "10ms", "--confidence", "1", "--json", msg.Cid().String()})
+	assert.Equal(t, WaitMsgExitTimeout, exitCode(err))
+	assert.Contains(t, buf.String(), `"Status": "timeout"`)
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"text/tabwriter"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -25,6 +26,8 @@ var SyncCmd = &cli.Command{
 		SyncMarkBadCmd,
 		SyncUnmarkBadCmd,
 		SyncCheckBadCmd,
+		SyncListBadCmd,
+		SyncPeersCmd,
 		SyncCheckpointCmd,
 	},
 }
@@ -79,6 +82,15 @@ var SyncStatusCmd = &cli.Command{
 			if ss.Stage == api.StageSyncErrored {
 				afmt.Printf("\tError: %s\n", ss.Message)
 			}
+			for _, st := range ss.Stages {
+				afmt.Printf("\t%s:\t%d epochs in %s (%.2f epochs/s)\n", st.Stage, st.Epochs, st.Time.Truncate(time.Second), st.Rate)
+			}
+			if ss.EpochsRemaining > 0 {
+				afmt.Printf("\tEpochs remaining: %d\n", ss.EpochsRemaining)
+			}
+			if !ss.ETA.IsZero() {
+				afmt.Printf("\tETA: %s (in %s)\n", ss.ETA.Format(time.RFC3339), time.Until(ss.ETA).Truncate(time.Second))
+			}
 		}
 		return nil
 	},
@@ -203,6 +215,60 @@ var SyncCheckBadCmd = &cli.Command{
 	},
 }
 
+var SyncListBadCmd = &cli.Command{
+	Name:  "list-bad",
+	Usage: "List the blocks marked bad, with the reasons",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		bad, err := napi.SyncListBad(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "BLOCK\tADDED\tEXPIRES\tREASON")
+		for _, bb := range bad {
+			expires := "never"
+			if !bb.Expires.IsZero() {
+				expires = bb.Expires.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", bb.Cid, bb.Added.Format(time.RFC3339), expires, bb.Reason)
+		}
+		return tw.Flush()
+	},
+}
+
+var SyncPeersCmd = &cli.Command{
+	Name:  "peers",
+	Usage: "List the scores of the peers chain data is requested from, the peers requested first coming first",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		scores, err := napi.SyncPeerScores(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PEER\tSUCCESSES\tFAILURES\tLATENCY/ITEM\tBANDWIDTH\tCOST/ITEM")
+		for _, ps := range scores {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s/s\t%s\n", ps.Peer, ps.Successes, ps.Failures, ps.Latency, types.SizeStr(types.NewInt(uint64(ps.Bandwidth))), ps.Cost)
+		}
+		return tw.Flush()
+	},
+}
+
 var SyncCheckpointCmd = &cli.Command{
 	Name:      "checkpoint",
 	Usage:     "mark a certain tipset as checkpointed; the node will never fork away from this tipset",
@@ -178,9 +178,10 @@ var walletList = &cli.Command{
 }
 
 var walletBalance = &cli.Command{
-	Name:      "balance",
-	Usage:     "Get account balance",
-	ArgsUsage: "[address]",
+	Name:         "balance",
+	Usage:        "Get account balance",
+	ArgsUsage:    "[address]",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -240,9 +241,10 @@ var walletGetDefault = &cli.Command{
 }
 
 var walletSetDefault = &cli.Command{
-	Name:      "set-default",
-	Usage:     "Set default wallet address",
-	ArgsUsage: "[address]",
+	Name:         "set-default",
+	Usage:        "Set default wallet address",
+	ArgsUsage:    "[address]",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -266,9 +268,10 @@ var walletSetDefault = &cli.Command{
 }
 
 var walletExport = &cli.Command{
-	Name:      "export",
-	Usage:     "export keys",
-	ArgsUsage: "[address]",
+	Name:         "export",
+	Usage:        "export keys",
+	ArgsUsage:    "[address]",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -401,9 +404,10 @@ var walletImport = &cli.Command{
 }
 
 var walletSign = &cli.Command{
-	Name:      "sign",
-	Usage:     "sign a message",
-	ArgsUsage: "<signing address> <hexMessage>",
+	Name:         "sign",
+	Usage:        "sign a message",
+	ArgsUsage:    "<signing address> <hexMessage>",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -444,9 +448,10 @@ var walletSign = &cli.Command{
 }
 
 var walletVerify = &cli.Command{
-	Name:      "verify",
-	Usage:     "verify the signature of a message",
-	ArgsUsage: "<signing address> <hexMessage> <signature>",
+	Name:         "verify",
+	Usage:        "verify the signature of a message",
+	ArgsUsage:    "<signing address> <hexMessage> <signature>",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -498,9 +503,10 @@ var walletVerify = &cli.Command{
 }
 
 var walletDelete = &cli.Command{
-	Name:      "delete",
-	Usage:     "Soft delete an address from the wallet - hard deletion needed for permanent removal",
-	ArgsUsage: "<address> ",
+	Name:         "delete",
+	Usage:        "Soft delete an address from the wallet - hard deletion needed for permanent removal",
+	ArgsUsage:    "<address> ",
+	BashComplete: completeArgs(CompleteWalletAddresses),
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
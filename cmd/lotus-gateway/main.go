@@ -23,6 +23,7 @@ import (
 	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/gateway"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/lib/rpcbatch"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
 )
@@ -121,6 +122,11 @@ var runCmd = &cli.Command{
 			Name:  "api-max-req-size",
 			Usage: "maximum API request size accepted by the JSON RPC server",
 		},
+		&cli.IntFlag{
+			Name:  "api-max-batch-size",
+			Usage: "maximum number of requests in the JSON RPC batches accepted by the JSON RPC server, 0 rejects batches",
+			Value: rpcbatch.DefaultMaxBatchSize,
+		},
 		&cli.DurationFlag{
 			Name:  "api-max-lookback",
 			Usage: "maximum duration allowable for tipset lookbacks",
@@ -176,6 +182,10 @@ var runCmd = &cli.Command{
 			perConnRateLimit = cctx.Int64("per-conn-rate-limit")
 			rateLimitTimeout = cctx.Duration("rate-limit-timeout")
 			connPerMinute    = cctx.Int64("conn-per-minute")
+			batch            = rpcbatch.Config{
+				MaxBatchSize:   cctx.Int("api-max-batch-size"),
+				MaxRequestSize: int64(cctx.Int("api-max-req-size")),
+			}
 		)
 
 		serverOptions := make([]jsonrpc.ServerOption, 0)
@@ -196,7 +206,7 @@ var runCmd = &cli.Command{
 		}
 
 		gwapi := gateway.NewNode(api, lookbackCap, waitLookback, rateLimit, rateLimitTimeout)
-		h, err := gateway.Handler(gwapi, api, perConnRateLimit, connPerMinute, serverOptions...)
+		h, err := gateway.Handler(gwapi, api, perConnRateLimit, connPerMinute, batch, serverOptions...)
 		if err != nil {
 			return xerrors.Errorf("failed to set up gateway HTTP handler")
 		}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -37,6 +38,7 @@ var provingCmd = &cli.Command{
 		provingInfoCmd,
 		provingDeadlinesCmd,
 		provingDeadlineInfoCmd,
+		provingScheduleCmd,
 		provingFaultsCmd,
 		provingCheckProvableCmd,
 		workersCmd(false),
@@ -297,6 +299,60 @@ var provingDeadlinesCmd = &cli.Command{
 	},
 }
 
+var provingScheduleCmd = &cli.Command{
+	Name:  "schedule",
+	Usage: "View the upcoming proving windows of the miner",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epochs",
+			Usage: "number of epochs to project the windows for",
+			Value: int64(builtin.EpochsInDay),
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "include the windows without partitions to prove",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, acloser, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting chain head: %w", err)
+		}
+
+		windows, err := api.StateMinerProvingSchedule(ctx, maddr, abi.ChainEpoch(cctx.Int64("epochs")), head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting proving schedule: %w", err)
+		}
+
+		fmt.Printf("Miner: %s\n", color.BlueString("%s", maddr))
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "deadline\tpartitions\tsectors (faults)\topen\tclose\tfault cutoff")
+		for _, w := range windows {
+			if w.Partitions == 0 && !cctx.Bool("all") {
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d (%d)\t%s\t%s\t%s\n", w.Deadline, w.Partitions, w.LiveSectors, w.FaultySectors,
+				cliutil.EpochTime(head.Height(), w.Open), cliutil.EpochTime(head.Height(), w.Close), cliutil.EpochTime(head.Height(), w.FaultCutoff))
+		}
+
+		return tw.Flush()
+	},
+}
+
 var provingDeadlineInfoCmd = &cli.Command{
 	Name:  "deadline",
 	Usage: "View the current proving period deadline information by its index",
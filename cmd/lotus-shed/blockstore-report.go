@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	ipld "github.com/ipfs/go-ipld-format"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/urfave/cli/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// The categories of the objects of a blockstore report, in the order they are
+// walked and printed. An object reachable from several categories counts in
+// the first one.
+const (
+	reportHeaders    = "headers"
+	reportMessages   = "messages"
+	reportReceipts   = "receipts"
+	reportSectors    = "sector info"
+	reportMiners     = "miner state"
+	reportEVM        = "EVM state"
+	reportActors     = "other actor state"
+	reportStateHAMTs = "state tree HAMT"
+)
+
+var reportCategories = []string{
+	reportHeaders,
+	reportMessages,
+	reportReceipts,
+	reportSectors,
+	reportMiners,
+	reportEVM,
+	reportActors,
+	reportStateHAMTs,
+}
+
+type blockstoreReport struct {
+	Time   time.Time
+	Tipset types.TipSetKey
+	Height abi.ChainEpoch
+	// Epochs is the number of epochs of headers, messages and receipts walked
+	Epochs     abi.ChainEpoch
+	Categories map[string]api.BlockstoreObjects
+	Total      api.BlockstoreObjects
+	// Missing counts the reachable objects not in the blockstores, such as
+	// the ones the splitstore discarded
+	Missing uint64
+	Actors  []actorStateUsage
+}
+
+type actorStateUsage struct {
+	Address address.Address
+	Actor   string
+	State   api.BlockstoreObjects
+}
+
+var blockstoreReportCmd = &cli.Command{
+	Name:  "blockstore-report",
+	Usage: "Report the space used by the chain and state in the blockstores of an offline node",
+	Description: `Walks the chain back from a tipset through the blockstores of the repo, with the
+   node stopped, and reports the objects and bytes of the headers, messages and
+   receipts of the walked epochs, and of the state tree at the tipset by kind of
+   actor state. Every object counts once, in the first category it is reached
+   from, in the order the categories are printed.
+
+   Also reports the actors with the largest state. With --previous, reports the
+   growth since an earlier report written with --out.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "tipset to walk from, the head by default",
+		},
+		&cli.Int64Flag{
+			Name:  "epochs",
+			Usage: "number of epochs of headers, messages and receipts to walk",
+			Value: int64(build.Finality),
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of actors with the largest state to report",
+			Value: 20,
+		},
+		&cli.StringFlag{
+			Name:  "previous",
+			Usage: "report the growth since the report in this file",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "also write the report to this file, to compare a later report against",
+		},
+		&lcli.JSONFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+		afmt := lcli.NewAppFmt(cctx.App)
+
+		var prev *blockstoreReport
+		if cctx.IsSet("previous") {
+			b, err := os.ReadFile(cctx.String("previous"))
+			if err != nil {
+				return xerrors.Errorf("reading previous report: %w", err)
+			}
+			prev = new(blockstoreReport)
+			if err := json.Unmarshal(b, prev); err != nil {
+				return xerrors.Errorf("parsing previous report: %w", err)
+			}
+		}
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.LockRO(repo.FullNode)
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		tiers, closeTiers, err := openBlockstoreTiers(ctx, lr)
+		if err != nil {
+			return err
+		}
+		defer closeTiers()
+
+		stores := make([]blockstore.Blockstore, len(tiers))
+		for i, t := range tiers {
+			stores[i] = t.Blockstore
+		}
+		bs := blockstore.Union(stores...)
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		cs := store.NewChainStore(bs, bs, mds, nil, nil)
+		defer cs.Close() //nolint:errcheck
+
+		if err := cs.Load(ctx); err != nil {
+			return err
+		}
+
+		ts, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("tipset"))
+		if err != nil {
+			return err
+		}
+
+		rep, err := walkBlockstoreReport(ctx, cs, bs, ts, abi.ChainEpoch(cctx.Int64("epochs")), cctx.Int("top"))
+		if err != nil {
+			return err
+		}
+
+		if cctx.IsSet("out") {
+			b, err := json.MarshalIndent(rep, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(cctx.String("out"), b, 0644); err != nil {
+				return xerrors.Errorf("writing report: %w", err)
+			}
+		}
+
+		if cctx.Bool("json") {
+			return afmt.PrintJSON(rep)
+		}
+
+		return printBlockstoreReport(afmt, cctx.App.Writer, rep, prev)
+	},
+}
+
+// reportWalker counts the objects reachable from the roots it walks, each
+// once.
+type reportWalker struct {
+	ctx  context.Context
+	bs   blockstore.Blockstore
+	seen *cid.Set
+	rep  *blockstoreReport
+}
+
+// walk counts the objects reachable from root that weren't counted yet in
+// category, and returns them. Only the root is counted when recurse is false.
+func (w *reportWalker) walk(root cid.Cid, category string, recurse bool) (api.BlockstoreObjects, error) {
+	var counted api.BlockstoreObjects
+
+	stack := []cid.Cid{root}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// identity cids, such as the code of the builtin actors, aren't stored
+		if c.Prefix().MhType == mh.IDENTITY || !w.seen.Visit(c) {
+			continue
+		}
+
+		blk, err := w.bs.Get(w.ctx, c)
+		if ipld.IsNotFound(err) {
+			w.rep.Missing++
+			continue
+		}
+		if err != nil {
+			return counted, xerrors.Errorf("getting %s: %w", c, err)
+		}
+		counted.Add(len(blk.RawData()))
+
+		if !recurse || c.Prefix().Codec != cid.DagCBOR {
+			continue
+		}
+		err = cbg.ScanForLinks(bytes.NewReader(blk.RawData()), func(l cid.Cid) {
+			stack = append(stack, l)
+		})
+		if err != nil {
+			return counted, xerrors.Errorf("scanning %s for links: %w", c, err)
+		}
+	}
+
+	co := w.rep.Categories[category]
+	co.Count += counted.Count
+	co.Bytes += counted.Bytes
+	w.rep.Categories[category] = co
+	w.rep.Total.Count += counted.Count
+	w.rep.Total.Bytes += counted.Bytes
+	return counted, nil
+}
+
+// walkBlockstoreReport walks the chain in bs back from ts for epochs epochs,
+// and the state tree at ts, reporting the top actors with the largest state.
+func walkBlockstoreReport(ctx context.Context, cs *store.ChainStore, bs blockstore.Blockstore, ts *types.TipSet, epochs abi.ChainEpoch, top int) (*blockstoreReport, error) {
+	w := &reportWalker{
+		ctx:  ctx,
+		bs:   bs,
+		seen: cid.NewSet(),
+		rep: &blockstoreReport{
+			Time:       build.Clock.Now(),
+			Tipset:     ts.Key(),
+			Height:     ts.Height(),
+			Categories: map[string]api.BlockstoreObjects{},
+		},
+	}
+
+	// walk the headers first, so that they don't count as messages or receipts
+	// of their children
+	var walked []*types.TipSet
+	for cur := ts; ; {
+		walked = append(walked, cur)
+		for _, b := range cur.Blocks() {
+			if _, err := w.walk(b.Cid(), reportHeaders, false); err != nil {
+				return nil, err
+			}
+		}
+
+		if cur.Height() == 0 || ts.Height()-cur.Height()+1 >= epochs {
+			break
+		}
+		parent, err := cs.LoadTipSet(ctx, cur.Parents())
+		if err != nil {
+			log.Warnf("stopping the chain walk at epoch %d: %s", cur.Height(), err)
+			break
+		}
+		cur = parent
+	}
+	w.rep.Epochs = ts.Height() - walked[len(walked)-1].Height() + 1
+
+	for _, cur := range walked {
+		for _, b := range cur.Blocks() {
+			if _, err := w.walk(b.Messages, reportMessages, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, cur := range walked {
+		if _, err := w.walk(cur.Blocks()[0].ParentMessageReceipts, reportReceipts, true); err != nil {
+			return nil, err
+		}
+	}
+
+	// walk the actor states before the state tree, so that the state tree only
+	// counts its own nodes
+	root := ts.ParentState()
+	st, err := state.LoadStateTree(cbor.NewCborStore(bs), root)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree: %w", err)
+	}
+	adtStore := adt.WrapStore(ctx, cbor.NewCborStore(bs))
+
+	var actorUsage []actorStateUsage
+	err = st.ForEach(func(addr address.Address, act *types.Actor) error {
+		au := actorStateUsage{Address: addr, Actor: builtin.ActorNameByCode(act.Code)}
+
+		category := reportActors
+		switch name, _, _ := actors.GetActorMetaByCode(act.Code); {
+		case builtin.IsStorageMinerActor(act.Code):
+			category = reportMiners
+			if sectors, ok := minerSectorsRoot(adtStore, act); ok {
+				counted, err := w.walk(sectors, reportSectors, true)
+				if err != nil {
+					return err
+				}
+				au.State.Count += counted.Count
+				au.State.Bytes += counted.Bytes
+			}
+		case name == actors.EvmKey:
+			category = reportEVM
+		}
+
+		counted, err := w.walk(act.Head, category, true)
+		if err != nil {
+			return err
+		}
+		au.State.Count += counted.Count
+		au.State.Bytes += counted.Bytes
+
+		actorUsage = append(actorUsage, au)
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("walking actor states: %w", err)
+	}
+
+	if _, err := w.walk(root, reportStateHAMTs, true); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(actorUsage, func(i, j int) bool {
+		return actorUsage[i].State.Bytes > actorUsage[j].State.Bytes
+	})
+	if len(actorUsage) > top {
+		actorUsage = actorUsage[:top]
+	}
+	w.rep.Actors = actorUsage
+
+	return w.rep, nil
+}
+
+// minerSectorsRoot returns the root of the sectors of the miner act. The miner
+// states of all the actors versions hold it in their Sectors field.
+func minerSectorsRoot(store adt.Store, act *types.Actor) (cid.Cid, bool) {
+	mst, err := miner.Load(store, act)
+	if err != nil {
+		log.Warnf("loading miner state: %s", err)
+		return cid.Undef, false
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(mst.GetState()))
+	if v.Kind() != reflect.Struct {
+		return cid.Undef, false
+	}
+	f := v.FieldByName("Sectors")
+	if !f.IsValid() {
+		return cid.Undef, false
+	}
+	c, ok := f.Interface().(cid.Cid)
+	return c, ok
+}
+
+func printBlockstoreReport(afmt *lcli.AppFmt, out io.Writer, rep, prev *blockstoreReport) error {
+	afmt.Printf("Tipset: %s, height %d, %d epochs of chain walked\n", rep.Tipset, rep.Height, rep.Epochs)
+	if prev != nil {
+		afmt.Printf("Growth since the report at height %d, %s earlier\n", prev.Height, rep.Time.Sub(prev.Time).Truncate(time.Second))
+	}
+
+	afmt.Println()
+	tw := tablewriter.New(
+		tablewriter.Col("Category"),
+		tablewriter.Col("Objects"),
+		tablewriter.Col("Size"),
+		tablewriter.Col("Growth"),
+	)
+	row := func(name string, cur, old api.BlockstoreObjects) {
+		r := map[string]interface{}{
+			"Category": name,
+			"Objects":  cur.Count,
+			"Size":     types.SizeStr(types.NewInt(cur.Bytes)),
+		}
+		if prev != nil {
+			r["Growth"] = sizeGrowth(cur.Bytes, old.Bytes)
+		}
+		tw.Write(r)
+	}
+	for _, c := range reportCategories {
+		var old api.BlockstoreObjects
+		if prev != nil {
+			old = prev.Categories[c]
+		}
+		row(c, rep.Categories[c], old)
+	}
+	var oldTotal api.BlockstoreObjects
+	if prev != nil {
+		oldTotal = prev.Total
+	}
+	row("total", rep.Total, oldTotal)
+	if err := tw.Flush(out); err != nil {
+		return err
+	}
+
+	if rep.Missing > 0 {
+		afmt.Printf("%d reachable objects aren't in the blockstores\n", rep.Missing)
+	}
+
+	prevActors := map[address.Address]api.BlockstoreObjects{}
+	if prev != nil {
+		for _, a := range prev.Actors {
+			prevActors[a.Address] = a.State
+		}
+	}
+
+	afmt.Println()
+	tw = tablewriter.New(
+		tablewriter.Col("Actor"),
+		tablewriter.Col("Code"),
+		tablewriter.Col("Objects"),
+		tablewriter.Col("Size"),
+		tablewriter.Col("Growth"),
+	)
+	for _, a := range rep.Actors {
+		r := map[string]interface{}{
+			"Actor":   a.Address,
+			"Code":    a.Actor,
+			"Objects": a.State.Count,
+			"Size":    types.SizeStr(types.NewInt(a.State.Bytes)),
+		}
+		if prev != nil {
+			if old, ok := prevActors[a.Address]; ok {
+				r["Growth"] = sizeGrowth(a.State.Bytes, old.Bytes)
+			} else {
+				r["Growth"] = "not in the top"
+			}
+		}
+		tw.Write(r)
+	}
+	return tw.Flush(out)
+}
+
+func sizeGrowth(cur, old uint64) string {
+	if cur < old {
+		return fmt.Sprintf("-%s", types.SizeStr(types.NewInt(old-cur)))
+	}
+	return fmt.Sprintf("+%s", types.SizeStr(types.NewInt(cur-old)))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/blockstore/usage"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var blockstoreUsageCmd = &cli.Command{
+	Name:  "blockstore-usage",
+	Usage: "Report the space used by the blockstores of an offline node, per tier and codec",
+	Description: `Scans the blockstores of the repo like 'lotus chain blockstore-usage', with
+   the node stopped: the universal or the splitstore cold store, the splitstore
+   hot store when the splitstore is enabled, and the chain history cold store
+   when history pruning moves the history to one.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of largest objects to report",
+			Value: usage.DefaultTop,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.LockRO(repo.FullNode)
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		tiers, closeTiers, err := openBlockstoreTiers(ctx, lr)
+		if err != nil {
+			return err
+		}
+		defer closeTiers()
+
+		u, err := usage.Scan(ctx, tiers, cctx.Int("top"))
+		if err != nil {
+			return err
+		}
+
+		lcli.PrintBlockstoreUsage(lcli.NewAppFmt(cctx.App), u)
+		return nil
+	},
+}
+
+// openBlockstoreTiers opens the blockstores of the repo lr, in the order 'lotus
+// chain blockstore-usage' reports them. The returned function closes them.
+func openBlockstoreTiers(ctx context.Context, lr repo.LockedRepo) ([]usage.Tier, func(), error) {
+	cfg, err := lr.Config()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("error getting config: %w", err)
+	}
+	fncfg, ok := cfg.(*config.FullNode)
+	if !ok {
+		return nil, nil, xerrors.Errorf("wrong config type: %T", cfg)
+	}
+
+	var (
+		tiers   []usage.Tier
+		closers []io.Closer
+	)
+	closeAll := func() {
+		for _, c := range closers {
+			if err := c.Close(); err != nil {
+				log.Warnf("failed to close blockstore: %s", err)
+			}
+		}
+	}
+
+	bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open blockstore: %w", err)
+	}
+	if c, ok := bs.(io.Closer); ok {
+		closers = append(closers, c)
+	}
+
+	openBadger := func(name string, domain repo.BlockstoreDomain, path string) error {
+		opts, err := repo.BadgerBlockstoreOptions(domain, path, true)
+		if err != nil {
+			return err
+		}
+		bs, err := badgerbs.Open(opts)
+		if err != nil {
+			return xerrors.Errorf("opening %s store: %w", name, err)
+		}
+		closers = append(closers, bs)
+		tiers = append(tiers, usage.Tier{Name: name, Blockstore: bs})
+		return nil
+	}
+
+	if fncfg.Chainstore.EnableSplitstore {
+		ssPath, err := lr.SplitstorePath()
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		if err := openBadger("hot", repo.HotBlockstore, filepath.Join(ssPath, "hot.badger")); err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		tiers = append(tiers, usage.Tier{Name: "cold", Blockstore: bs})
+	} else {
+		tiers = append(tiers, usage.Tier{Name: "universal", Blockstore: bs})
+	}
+
+	if path := fncfg.Chainstore.HistoryPruning.ColdStorePath; path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(lr.Path(), path)
+		}
+		if err := openBadger("chain history", repo.ChainColdBlockstore, path); err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+	}
+
+	return tiers, closeAll, nil
+}
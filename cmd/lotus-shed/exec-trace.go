@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-car"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/beacon/drand"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/exectrace"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
+)
+
+var execTraceCmd = &cli.Command{
+	Name:  "exec-trace",
+	Usage: "Record and replay deterministic traces of the execution of tipsets",
+	Description: `Traces hold the calls made by the messages of a tipset, their results and the
+   gas they used, in a stable versioned format, along with the inputs of the
+   execution. They are recorded from a chain snapshot imported in memory, and
+   replayed on the state of a snapshot, or of any car file holding the state
+   the execution reads, to check that the execution of a tipset is
+   deterministic, or to compare it with other implementations.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "snapshot",
+			Usage:    "path to the chain snapshot (car file) to execute on; replays only need the state in it",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "trace format: json or cbor",
+			Value: string(exectrace.FormatJSON),
+		},
+	},
+	Subcommands: []*cli.Command{
+		execTraceRecordCmd,
+		execTraceReplayCmd,
+	},
+}
+
+var execTraceRecordCmd = &cli.Command{
+	Name:      "record",
+	Usage:     "Record the trace of the execution of the tipset at the given height",
+	ArgsUsage: "[height] [output file, - for stdout]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+		var height abi.ChainEpoch
+		if _, err := fmt.Sscan(cctx.Args().Get(0), &height); err != nil {
+			return xerrors.Errorf("parsing height: %w", err)
+		}
+
+		ctx := lcli.ReqContext(cctx)
+		sm, head, err := loadSnapshotStateManager(ctx, cctx.String("snapshot"))
+		if err != nil {
+			return err
+		}
+		defer sm.ChainStore().Close() //nolint:errcheck
+
+		ts, err := sm.ChainStore().GetTipsetByHeight(ctx, height, head, false)
+		if err != nil {
+			return xerrors.Errorf("loading tipset at %d: %w", height, err)
+		}
+		if ts.Height() != height {
+			return xerrors.Errorf("no tipset at height %d, a null round", height)
+		}
+
+		tr, err := exectrace.Record(ctx, sm, ts)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if path := cctx.Args().Get(1); path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck
+			out = f
+		}
+		if err := exectrace.Encode(out, tr, exectrace.Format(cctx.String("format"))); err != nil {
+			return xerrors.Errorf("writing trace: %w", err)
+		}
+		_, _ = fmt.Fprintf(cctx.App.ErrWriter, "recorded %d messages of tipset %s, state %s\n", len(tr.Messages), ts.Key(), tr.State)
+		return nil
+	},
+}
+
+var execTraceReplayCmd = &cli.Command{
+	Name:      "replay",
+	Usage:     "Replay a trace, and report the first divergence of the execution from it",
+	ArgsUsage: "[trace file]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		f, err := os.Open(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		tr, err := exectrace.Decode(f, exectrace.Format(cctx.String("format")))
+		if err != nil {
+			return err
+		}
+
+		ctx := lcli.ReqContext(cctx)
+		sf, err := os.Open(cctx.String("snapshot"))
+		if err != nil {
+			return err
+		}
+		defer sf.Close() //nolint:errcheck
+		bs := blockstore.NewMemorySync()
+		if _, err := car.LoadCar(ctx, bs, sf); err != nil {
+			return xerrors.Errorf("loading snapshot: %w", err)
+		}
+
+		if err := exectrace.Replay(ctx, bs, vm.Syscalls(ffiwrapper.ProofVerifier), tr); err != nil {
+			return err
+		}
+		fmt.Printf("execution of tipset at %d matches the trace: %d messages, state %s\n", tr.Height, len(tr.Messages), tr.State)
+		return nil
+	},
+}
+
+// loadSnapshotStateManager imports the chain snapshot at path in memory, and
+// returns a state manager on it, along with the head of the snapshot.
+func loadSnapshotStateManager(ctx context.Context, path string) (*stmgr.StateManager, *types.TipSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, dssync.MutexWrap(datastore.NewMapDatastore()), filcns.Weight, nil)
+	head, err := cs.Import(ctx, f)
+	if err != nil {
+		_ = cs.Close()
+		return nil, nil, xerrors.Errorf("importing snapshot: %w", err)
+	}
+
+	gen, err := cs.GetTipsetByHeight(ctx, 0, head, false)
+	if err != nil {
+		_ = cs.Close()
+		return nil, nil, xerrors.Errorf("loading genesis: %w", err)
+	}
+	shd := beacon.Schedule{}
+	for _, dc := range build.DrandConfigSchedule() {
+		bc, err := drand.NewDrandBeacon(gen.MinTimestamp(), build.BlockDelaySecs, nil, dc.Config)
+		if err != nil {
+			_ = cs.Close()
+			return nil, nil, xerrors.Errorf("creating drand beacon: %w", err)
+		}
+		shd = append(shd, beacon.BeaconPoint{Start: dc.Start, Beacon: bc})
+	}
+
+	sm, err := stmgr.NewStateManager(cs, filcns.NewTipSetExecutor(), vm.Syscalls(ffiwrapper.ProofVerifier), filcns.DefaultUpgradeSchedule(), shd)
+	if err != nil {
+		_ = cs.Close()
+		return nil, nil, err
+	}
+	return sm, head, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
@@ -28,12 +29,14 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/store"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/cmd/lotus-shed/shedgen"
+	"github.com/filecoin-project/lotus/lib/s3upload"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
@@ -55,12 +58,60 @@ var exportChainCmd = &cli.Command{
 		&cli.BoolFlag{
 			Name: "full-state",
 		},
+		&cli.StringFlag{
+			Name:  "recent-stateroots-policy",
+			Usage: "what to do when fewer than finality recent state roots are requested; one of 'reject', 'bump' or 'allow'",
+			Value: "reject",
+		},
 		&cli.BoolFlag{
 			Name: "skip-old-msgs",
 		},
+		&cli.BoolFlag{
+			Name:  "include-receipts",
+			Usage: "include the message receipts of every tipset whose messages are exported",
+		},
+		&cli.BoolFlag{
+			Name:  "carv2",
+			Usage: "write an indexed CARv2 file",
+		},
+		&cli.StringFlag{
+			Name:  "compress",
+			Usage: "compress the export; one of 'zstd' or 'gzip'",
+		},
+		&cli.IntFlag{
+			Name:  "compression-level",
+			Usage: "compression level to use with --compress; 0 uses the compressor default",
+		},
+		&cli.StringFlag{
+			Name:  "s3-endpoint",
+			Usage: "S3 compatible endpoint to upload s3:// and gs:// exports to; defaults to AWS S3 or Google Cloud Storage",
+		},
+		&cli.StringFlag{
+			Name:  "s3-part-size",
+			Usage: "size of the parts an export is uploaded to object storage in",
+			Value: "64MiB",
+		},
+		&cli.StringFlag{
+			Name:  "shard-size",
+			Usage: "split the export into CAR shards of at most this size, with a manifest to import them from",
+		},
+		&cli.StringFlag{
+			Name:  "spill-dir",
+			Usage: "keep the set of visited blocks in a database below this directory instead of in memory",
+		},
+		&cli.StringFlag{
+			Name:  "messages-csv",
+			Usage: "also write the messages of the export to this CSV file, keyed by epoch",
+		},
+		&cli.StringFlag{
+			Name:  "receipts-csv",
+			Usage: "also write the receipts of the export to this CSV file, keyed by epoch",
+		},
 	},
 	Subcommands: []*cli.Command{
 		exportRawCmd,
+		exportActorsCmd,
+		exportRangeCmd,
 	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
@@ -68,76 +119,372 @@ var exportChainCmd = &cli.Command{
 		}
 
 		ctx := context.TODO()
+		dest := cctx.Args().First()
 
-		r, err := repo.NewFS(cctx.String("repo"))
-		if err != nil {
-			return xerrors.Errorf("opening fs repo: %w", err)
+		if cctx.IsSet("spill-dir") {
+			store.ExportSpillDir = cctx.String("spill-dir")
 		}
 
-		exists, err := r.Exists()
+		policy, err := store.ParseRecentRootsPolicy(cctx.String("recent-stateroots-policy"))
 		if err != nil {
 			return err
 		}
-		if !exists {
-			return xerrors.Errorf("lotus repo doesn't exist")
+		store.ExportRecentRootsPolicy = policy
+
+		cs, closer, err := openChainStore(ctx, cctx.String("repo"), false)
+		if err != nil {
+			return err
 		}
+		defer closer()
 
-		lr, err := r.Lock(repo.FullNode)
+		nroots := abi.ChainEpoch(cctx.Int64("recent-stateroots"))
+		fullstate := cctx.Bool("full-state")
+		skipoldmsgs := cctx.Bool("skip-old-msgs")
+		inclreceipts := cctx.Bool("include-receipts")
+
+		ts, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("tipset"))
 		if err != nil {
 			return err
 		}
-		defer lr.Close() //nolint:errcheck
 
-		fi, err := os.Create(cctx.Args().First())
+		if fullstate {
+			nroots = ts.Height() + 1
+		}
+
+		withTables := cctx.IsSet("messages-csv") || cctx.IsSet("receipts-csv")
+		if withTables && (cctx.IsSet("shard-size") || cctx.Bool("carv2") || strings.Contains(dest, "://")) {
+			return xerrors.Errorf("--messages-csv and --receipts-csv require a CARv1 local output file")
+		}
+
+		if cctx.IsSet("shard-size") {
+			if cctx.Bool("carv2") || cctx.IsSet("compress") || strings.Contains(dest, "://") {
+				return xerrors.Errorf("--shard-size requires an uncompressed CARv1 local output file")
+			}
+
+			shardSize, err := humanize.ParseBytes(cctx.String("shard-size"))
+			if err != nil {
+				return xerrors.Errorf("parse --shard-size: %w", err)
+			}
+
+			m, err := cs.ExportShards(ctx, ts, nroots, skipoldmsgs, !inclreceipts, dest, shardSize)
+			if err != nil {
+				return xerrors.Errorf("export failed: %w", err)
+			}
+
+			if err := store.WriteManifest(dest+store.ManifestSuffix, m); err != nil {
+				return err
+			}
+			fmt.Printf("wrote %d shards, import them from %s\n", len(m.Shards), dest+store.ManifestSuffix)
+
+			return nil
+		}
+
+		if strings.HasPrefix(dest, "s3://") || strings.HasPrefix(dest, "gs://") {
+			if cctx.Bool("carv2") {
+				return xerrors.Errorf("--carv2 requires a local output file")
+			}
+
+			up, err := newObjectUpload(ctx, cctx, dest)
+			if err != nil {
+				return err
+			}
+
+			sink, err := store.NewCompressedSink(up, cctx.String("compress"), cctx.Int("compression-level"))
+			if err != nil {
+				return err
+			}
+
+			if err := cs.ExportToSink(ctx, ts, nroots, skipoldmsgs, !inclreceipts, sink); err != nil {
+				return xerrors.Errorf("export failed: %w", err)
+			}
+
+			return nil
+		}
+
+		fi, err := os.Create(dest)
 		if err != nil {
 			return xerrors.Errorf("opening the output file: %w", err)
 		}
 
 		defer fi.Close() //nolint:errcheck
 
-		bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+		if cctx.Bool("carv2") {
+			if cctx.IsSet("compress") {
+				return xerrors.Errorf("--carv2 cannot be combined with --compress")
+			}
+
+			if err := cs.ExportCARv2(ctx, ts, nroots, skipoldmsgs, !inclreceipts, fi); err != nil {
+				return xerrors.Errorf("export failed: %w", err)
+			}
+
+			return nil
+		}
+
+		bw := bufio.NewWriterSize(fi, 1<<20)
+		cw, err := store.NewCompressedWriter(bw, cctx.String("compress"), cctx.Int("compression-level"))
 		if err != nil {
-			return fmt.Errorf("failed to open blockstore: %w", err)
+			return err
 		}
 
-		defer func() {
-			if c, ok := bs.(io.Closer); ok {
-				if err := c.Close(); err != nil {
-					log.Warnf("failed to close blockstore: %s", err)
-				}
+		if withTables {
+			tables, closeTables, err := openMessageTables(cs, cctx.String("messages-csv"), cctx.String("receipts-csv"))
+			if err != nil {
+				return err
+			}
+			defer closeTables() //nolint:errcheck
+
+			if err := cs.ExportWithMessageTables(ctx, ts, nroots, skipoldmsgs, !inclreceipts, cw, tables); err != nil {
+				return xerrors.Errorf("export failed: %w", err)
+			}
+			if err := closeTables(); err != nil {
+				return err
+			}
+		} else if err := cs.Export(ctx, ts, nroots, skipoldmsgs, !inclreceipts, cw); err != nil {
+			return xerrors.Errorf("export failed: %w", err)
+		}
+
+		if err := cw.Close(); err != nil {
+			return xerrors.Errorf("closing compressed writer: %w", err)
+		}
+
+		if err := bw.Flush(); err != nil {
+			return xerrors.Errorf("flushing output file: %w", err)
+		}
+
+		return nil
+	},
+}
+
+// openChainStore opens and loads the chain store of the lotus repo at repoPath,
+// locked read-only if readonly is set. The returned closer releases the store,
+// its blockstore and the repo lock.
+func openChainStore(ctx context.Context, repoPath string, readonly bool) (*store.ChainStore, func(), error) {
+	r, err := repo.NewFS(repoPath)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("opening fs repo: %w", err)
+	}
+
+	exists, err := r.Exists()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, xerrors.Errorf("lotus repo doesn't exist")
+	}
+
+	lock := r.Lock
+	if readonly {
+		lock = r.LockRO
+	}
+	lr, err := lock(repo.FullNode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+	if err != nil {
+		_ = lr.Close()
+		return nil, nil, fmt.Errorf("failed to open blockstore: %w", err)
+	}
+	closeBlockstore := func() {
+		if c, ok := bs.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Warnf("failed to close blockstore: %s", err)
+			}
+		}
+		_ = lr.Close()
+	}
+
+	mds, err := lr.Datastore(ctx, "/metadata")
+	if err != nil {
+		closeBlockstore()
+		return nil, nil, err
+	}
+
+	cs := store.NewChainStore(bs, bs, mds, nil, nil)
+	closer := func() {
+		_ = cs.Close()
+		closeBlockstore()
+	}
+
+	if err := cs.Load(ctx); err != nil {
+		closer()
+		return nil, nil, err
+	}
+
+	return cs, closer, nil
+}
+
+// openMessageTables creates the message and receipt tables of an export in the
+// files at msgsPath and rcptsPath, either of which may be empty.
+func openMessageTables(cs *store.ChainStore, msgsPath, rcptsPath string) (*store.MessageTables, func() error, error) {
+	var files []*os.File
+	closeFiles := func() error {
+		var err error
+		for _, f := range files {
+			if cerr := f.Close(); cerr != nil && err == nil {
+				err = cerr
 			}
-		}()
+		}
+		files = nil
+		return err
+	}
 
-		mds, err := lr.Datastore(context.Background(), "/metadata")
+	var outs [2]io.Writer
+	for i, p := range []string{msgsPath, rcptsPath} {
+		if p == "" {
+			continue
+		}
+		f, err := os.Create(p)
 		if err != nil {
-			return err
+			_ = closeFiles()
+			return nil, nil, xerrors.Errorf("opening table file: %w", err)
 		}
+		files = append(files, f)
+		outs[i] = f
+	}
 
-		cs := store.NewChainStore(bs, bs, mds, nil, nil)
-		defer cs.Close() //nolint:errcheck
+	return cs.NewMessageTables(outs[0], outs[1]), closeFiles, nil
+}
+
+// newObjectUpload starts an upload to an s3://bucket/key or gs://bucket/key
+// destination, see s3upload.ConfigFromURL.
+func newObjectUpload(ctx context.Context, cctx *cli.Context, dest string) (*s3upload.Upload, error) {
+	cfg, err := s3upload.ConfigFromURL(dest, cctx.String("s3-endpoint"))
+	if err != nil {
+		return nil, err
+	}
 
-		if err := cs.Load(context.Background()); err != nil {
+	partSize, err := humanize.ParseBytes(cctx.String("s3-part-size"))
+	if err != nil {
+		return nil, xerrors.Errorf("parse --s3-part-size: %w", err)
+	}
+	cfg.PartSize = int64(partSize)
+
+	return s3upload.New(ctx, cfg)
+}
+
+var exportActorsCmd = &cli.Command{
+	Name:        "actors",
+	Description: "Export the state of a set of actors from repo (requires node to be offline)",
+	ArgsUsage:   "[outputPath]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "tipset to export the parent state of",
+		},
+		&cli.StringSliceFlag{
+			Name:     "actor",
+			Usage:    "address of an actor to include; can be repeated",
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must specify file name to write export to"))
+		}
+
+		ctx := context.TODO()
+
+		var actors []address.Address
+		for _, a := range cctx.StringSlice("actor") {
+			addr, err := address.NewFromString(a)
+			if err != nil {
+				return xerrors.Errorf("parsing actor address %q: %w", a, err)
+			}
+			actors = append(actors, addr)
+		}
+
+		cs, closer, err := openChainStore(ctx, cctx.String("repo"), true)
+		if err != nil {
 			return err
 		}
+		defer closer()
 
-		nroots := abi.ChainEpoch(cctx.Int64("recent-stateroots"))
-		fullstate := cctx.Bool("full-state")
-		skipoldmsgs := cctx.Bool("skip-old-msgs")
+		ts, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("tipset"))
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Create(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("opening the output file: %w", err)
+		}
+		defer fi.Close() //nolint:errcheck
+
+		bw := bufio.NewWriterSize(fi, 1<<20)
+		if err := cs.ExportActors(ctx, ts, actors, bw); err != nil {
+			return xerrors.Errorf("export failed: %w", err)
+		}
+
+		return bw.Flush()
+	},
+}
+
+var exportRangeCmd = &cli.Command{
+	Name:        "range",
+	Description: "Export the tipsets in a range of epochs, with the state the range starts from, from repo (requires node to be offline)",
+	ArgsUsage:   "[outputPath]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "head of the chain to take the range from",
+		},
+		&cli.Int64Flag{
+			Name:     "start",
+			Usage:    "first epoch of the range",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:     "end",
+			Usage:    "last epoch of the range",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "skip-receipts",
+			Usage: "leave out the message receipts of the tipsets in the range",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must specify file name to write export to"))
+		}
+
+		ctx := context.TODO()
+
+		cs, closer, err := openChainStore(ctx, cctx.String("repo"), true)
+		if err != nil {
+			return err
+		}
+		defer closer()
 
 		ts, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("tipset"))
 		if err != nil {
 			return err
 		}
 
-		if fullstate {
-			nroots = ts.Height() + 1
+		fi, err := os.Create(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("opening the output file: %w", err)
 		}
+		defer fi.Close() //nolint:errcheck
+
+		start, end := abi.ChainEpoch(cctx.Int64("start")), abi.ChainEpoch(cctx.Int64("end"))
 
-		if err := cs.Export(ctx, ts, nroots, skipoldmsgs, fi); err != nil {
+		bw := bufio.NewWriterSize(fi, 1<<20)
+		if err := cs.ExportRange(ctx, ts, start, end, cctx.Bool("skip-receipts"), bw); err != nil {
 			return xerrors.Errorf("export failed: %w", err)
 		}
 
-		return nil
+		return bw.Flush()
 	},
 }
 
@@ -234,7 +581,10 @@ var exportRawCmd = &cli.Command{
 			}
 
 			{
-				path := filepath.Join(lr.Path(), "datastore", "chain")
+				path, err := repo.UniversalBlockstorePath(lr.Path())
+				if err != nil {
+					return err
+				}
 				opts, err := repo.BadgerBlockstoreOptions(repo.UniversalBlockstore, path, false)
 				if err != nil {
 					return err
@@ -68,6 +68,8 @@ func main() {
 		minerPeeridCmd,
 		minerMultisigsCmd,
 		splitstoreCmd,
+		blockstoreUsageCmd,
+		blockstoreReportCmd,
 		fr32Cmd,
 		chainCmd,
 		balancerCmd,
@@ -81,6 +83,8 @@ func main() {
 		invariantsCmd,
 		gasTraceCmd,
 		replayOfflineCmd,
+		execTraceCmd,
+		rerootSnapshotCmd,
 	}
 
 	app := &cli.App{
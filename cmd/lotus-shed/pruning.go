@@ -191,13 +191,18 @@ var stateTreePruneCmd = &cli.Command{
 
 		rrLb := abi.ChainEpoch(cctx.Int64("keep-from-lookback"))
 
-		if err := cs.WalkSnapshot(ctx, ts, rrLb, true, true, func(c cid.Cid) error {
+		w := cs.NewChainWalker(store.ChainWalkerOpts{
+			StateDepth:      rrLb,
+			SkipOldMessages: true,
+			SkipReceipts:    true,
+		})
+		if err := w.Walk(ctx, ts, store.ChainVisitorFunc(func(c cid.Cid, _ store.SnapshotBlockType) error {
 			if goodSet.Len()%20 == 0 {
 				fmt.Printf("\renumerating keep set: %d             ", goodSet.Len())
 			}
 			goodSet.Add(c)
 			return nil
-		}); err != nil {
+		})); err != nil {
 			return fmt.Errorf("snapshot walk failed: %w", err)
 		}
 
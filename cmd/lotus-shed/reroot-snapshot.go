@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var rerootSnapshotCmd = &cli.Command{
+	Name:  "reroot-snapshot",
+	Usage: "Write a snapshot rooted at an older tipset of an existing snapshot",
+	Description: `The snapshot, plain or compressed, is imported in memory, or into a badger
+   blockstore under --blockstore-dir, and exported again from the tipset at
+   --height or --offset epochs below its root, without the newer tipsets. No
+   node is needed.
+
+   The new snapshot can only hold the state roots and messages the input holds.
+   By default, it has the state of every tipset from the new root down for
+   which the input has one.`,
+	ArgsUsage: "[input car] [output car]",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "height",
+			Usage: "height of the new root; the tipset below it is taken on a null round",
+		},
+		&cli.Int64Flag{
+			Name:  "offset",
+			Usage: "number of epochs of the new root below the root of the input",
+		},
+		&cli.Int64Flag{
+			Name:  "recent-stateroots",
+			Usage: "number of recent state roots to include; all the snapshot holds for the new root by default",
+		},
+		&cli.StringFlag{
+			Name:  "recent-stateroots-policy",
+			Usage: "what to do when fewer than finality recent state roots are exported; one of 'reject', 'bump' or 'allow'",
+			Value: "reject",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-old-msgs",
+			Usage: "only include the messages of the tipsets whose state is included, as needed when the input has no others",
+		},
+		&cli.BoolFlag{
+			Name:  "include-receipts",
+			Usage: "include the message receipts of every tipset whose messages are included",
+		},
+		&cli.StringFlag{
+			Name:  "compress",
+			Usage: "compress the new snapshot; one of 'zstd' or 'gzip'",
+		},
+		&cli.IntFlag{
+			Name:  "compression-level",
+			Usage: "compression level to use with --compress; 0 uses the compressor default",
+		},
+		&cli.StringFlag{
+			Name:  "blockstore-dir",
+			Usage: "import the snapshot into a badger blockstore in this directory instead of in memory, for snapshots larger than the memory",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+		if cctx.IsSet("height") == cctx.IsSet("offset") {
+			return xerrors.Errorf("one of --height or --offset must be set")
+		}
+
+		policy, err := store.ParseRecentRootsPolicy(cctx.String("recent-stateroots-policy"))
+		if err != nil {
+			return err
+		}
+		store.ExportRecentRootsPolicy = policy
+
+		ctx := lcli.ReqContext(cctx)
+
+		var bs blockstore.Blockstore = blockstore.NewMemorySync()
+		if dir := cctx.String("blockstore-dir"); dir != "" {
+			opts, err := repo.BadgerBlockstoreOptions(repo.UniversalBlockstore, dir, false)
+			if err != nil {
+				return err
+			}
+			bbs, err := badgerbs.Open(opts)
+			if err != nil {
+				return xerrors.Errorf("opening blockstore: %w", err)
+			}
+			defer bbs.Close() //nolint:errcheck
+			bs = bbs
+		}
+
+		cs := store.NewChainStore(bs, bs, dssync.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+		defer cs.Close() //nolint:errcheck
+
+		in, err := os.Open(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		defer in.Close() //nolint:errcheck
+		r, err := store.NewDecompressedReader(in)
+		if err != nil {
+			return err
+		}
+		defer r.Close() //nolint:errcheck
+
+		head, err := cs.Import(ctx, r)
+		if err != nil {
+			return xerrors.Errorf("importing snapshot: %w", err)
+		}
+
+		height := abi.ChainEpoch(cctx.Int64("height"))
+		if cctx.IsSet("offset") {
+			height = head.Height() - abi.ChainEpoch(cctx.Int64("offset"))
+		}
+		if height < 0 || height > head.Height() {
+			return xerrors.Errorf("height %d is out of the snapshot, rooted at %d", height, head.Height())
+		}
+		ts, err := cs.GetTipsetByHeight(ctx, height, head, true)
+		if err != nil {
+			return xerrors.Errorf("loading tipset at %d: %w", height, err)
+		}
+
+		nroots := abi.ChainEpoch(cctx.Int64("recent-stateroots"))
+		if !cctx.IsSet("recent-stateroots") {
+			if nroots, err = availableStateRoots(ctx, cs, bs, ts); err != nil {
+				return err
+			}
+		}
+
+		out, err := os.Create(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("opening the output file: %w", err)
+		}
+		defer out.Close() //nolint:errcheck
+
+		bw := bufio.NewWriterSize(out, 1<<20)
+		cw, err := store.NewCompressedWriter(bw, cctx.String("compress"), cctx.Int("compression-level"))
+		if err != nil {
+			return err
+		}
+		if err := cs.Export(ctx, ts, nroots, cctx.Bool("skip-old-msgs"), !cctx.Bool("include-receipts"), cw); err != nil {
+			return xerrors.Errorf("export failed: %w", err)
+		}
+		if err := cw.Close(); err != nil {
+			return xerrors.Errorf("closing compressed writer: %w", err)
+		}
+		if err := bw.Flush(); err != nil {
+			return xerrors.Errorf("flushing output file: %w", err)
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		fmt.Printf("rerooted the snapshot from %d to %d (%s), with %d recent state roots\n", head.Height(), ts.Height(), ts.Key(), nroots)
+		return nil
+	},
+}
+
+// availableStateRoots returns the number of epochs from ts down for which the
+// tipsets have their parent state root in bs, the height of ts plus one if all
+// of them have it.
+func availableStateRoots(ctx context.Context, cs *store.ChainStore, bs blockstore.Blockstore, ts *types.TipSet) (abi.ChainEpoch, error) {
+	cur := ts
+	lowest := ts.Height() + 1
+	for {
+		has, err := bs.Has(ctx, cur.ParentState())
+		if err != nil {
+			return 0, err
+		}
+		if !has {
+			break
+		}
+		lowest = cur.Height()
+		if cur.Height() == 0 {
+			break
+		}
+		if cur, err = cs.LoadTipSet(ctx, cur.Parents()); err != nil {
+			return 0, xerrors.Errorf("loading parents of tipset at %d: %w", lowest, err)
+		}
+	}
+	return ts.Height() - lowest + 1, nil
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/dgraph-io/badger/v2"
 	"github.com/ipfs/go-datastore"
@@ -18,6 +19,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -31,6 +33,7 @@ var splitstoreCmd = &cli.Command{
 		splitstoreClearCmd,
 		splitstoreCheckCmd,
 		splitstoreInfoCmd,
+		splitstoreCompactionCmd,
 	},
 }
 
@@ -192,7 +195,10 @@ var splitstoreClearCmd = &cli.Command{
 func copyHotstoreToColdstore(lr repo.LockedRepo, gcColdstore bool) error {
 	repoPath := lr.Path()
 	dataPath := filepath.Join(repoPath, "datastore")
-	coldPath := filepath.Join(dataPath, "chain")
+	coldPath, err := repo.UniversalBlockstorePath(repoPath)
+	if err != nil {
+		return err
+	}
 	hotPath := filepath.Join(dataPath, "splitstore", "hot.badger")
 
 	blog := &badgerLogger{
@@ -403,3 +409,69 @@ var splitstoreInfoCmd = &cli.Command{
 		return nil
 	},
 }
+
+var splitstoreCompactionCmd = &cli.Command{
+	Name:        "compaction",
+	Description: "prints the progress of the running compaction, or pauses and resumes compaction",
+	Subcommands: []*cli.Command{
+		{
+			Name:        "pause",
+			Description: "pauses the running compaction and holds off new compactions",
+			Action: func(cctx *cli.Context) error {
+				api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+				if err != nil {
+					return err
+				}
+				defer closer()
+
+				return api.ChainSplitstorePauseCompaction(lcli.ReqContext(cctx))
+			},
+		},
+		{
+			Name:        "resume",
+			Description: "resumes compaction",
+			Action: func(cctx *cli.Context) error {
+				api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+				if err != nil {
+					return err
+				}
+				defer closer()
+
+				return api.ChainSplitstoreResumeCompaction(lcli.ReqContext(cctx))
+			},
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+		p, err := api.ChainSplitstoreCompactionProgress(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("paused:", p.Paused)
+		if !p.Compacting {
+			fmt.Println("no compaction running")
+			return nil
+		}
+
+		fmt.Printf("phase: %s (since %s)\n", p.Phase, p.PhaseStarted.Format(time.RFC3339))
+		fmt.Println("started:", p.Started.Format(time.RFC3339))
+		if p.Total > 0 {
+			fmt.Printf("progress: %d/%d (%.1f%%)\n", p.Done, p.Total, p.Percent)
+		} else {
+			fmt.Printf("progress: %d\n", p.Done)
+		}
+		if p.ETA > 0 {
+			fmt.Println("eta:", p.ETA.Round(time.Second))
+		}
+		fmt.Println("moved:", types.SizeStr(types.NewInt(uint64(p.BytesMoved))))
+
+		return nil
+	},
+}
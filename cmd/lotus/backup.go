@@ -3,7 +3,6 @@ package main
 import (
 	"os"
 
-	dstore "github.com/ipfs/go-datastore"
 	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
@@ -109,8 +108,7 @@ func restore(cctx *cli.Context, r repo.Repo) error {
 
 	log.Info("Resetting chainstore metadata")
 
-	chainHead := dstore.NewKey("head")
-	if err := mds.Delete(cctx.Context, chainHead); err != nil {
+	if err := store.ClearHead(cctx.Context, mds); err != nil {
 		return xerrors.Errorf("clearing chain head: %w", err)
 	}
 	if err := store.FlushValidationCache(cctx.Context, mds); err != nil {
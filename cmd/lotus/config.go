@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
 )
@@ -16,6 +18,8 @@ var configCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		configDefaultCmd,
 		configUpdateCmd,
+		configValidateCmd,
+		configDiffCmd,
 	},
 }
 
@@ -92,3 +96,116 @@ var configUpdateCmd = &cli.Command{
 		return nil
 	},
 }
+
+var configValidateCmd = &cli.Command{
+	Name:      "validate",
+	Usage:     "Check the node config for unknown keys and invalid values",
+	ArgsUsage: "[config file]",
+	Description: `Parses the config file of the repo, or the given one, and reports the keys
+   that aren't config keys, such as typos, which are otherwise ignored leaving
+   the default values in place, the removed and deprecated keys, and the values
+   out of their range. LOTUS_* env var overrides are applied like when the node
+   starts. Fails when the config has errors; warnings don't fail it.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		path, err := configPath(cctx)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck // The file is RO
+
+		_, issues, err := config.Validate(f, config.DefaultFullNode())
+		if err != nil {
+			return xerrors.Errorf("parsing %s: %w", path, err)
+		}
+
+		var errs int
+		for _, i := range issues {
+			if i.Warning {
+				fmt.Printf("warning: %s\n", i)
+			} else {
+				fmt.Printf("error: %s\n", i)
+				errs++
+			}
+		}
+
+		if errs > 0 {
+			return xerrors.Errorf("%s has %d errors", path, errs)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("%s is valid\n", path)
+		}
+		return nil
+	},
+}
+
+var configDiffCmd = &cli.Command{
+	Name:      "diff",
+	Usage:     "Print the node config values that differ from the defaults",
+	ArgsUsage: "[config file]",
+	Description: `Prints the values of the effective config, that is the config file of the
+   repo, or the given one, with the LOTUS_* env var overrides applied, that
+   differ from the default values.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		path, err := configPath(cctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.FromFile(path, config.DefaultFullNode())
+		if err != nil {
+			return xerrors.Errorf("loading %s: %w", path, err)
+		}
+
+		changes, err := config.Diff(cfg, config.DefaultFullNode())
+		if err != nil {
+			return err
+		}
+
+		unset := func(v string) string {
+			if v == "" {
+				return "(unset)"
+			}
+			return v
+		}
+		for _, c := range changes {
+			fmt.Printf("%s: %s -> %s\n", c.Key, unset(c.Default), unset(c.Current))
+		}
+		return nil
+	},
+}
+
+// configPath returns the config file given as argument, or the one of the repo.
+func configPath(cctx *cli.Context) (string, error) {
+	if cctx.Args().Present() {
+		return cctx.Args().First(), nil
+	}
+
+	r, err := repo.NewFS(cctx.String("repo"))
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := r.Exists()
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", xerrors.Errorf("repo not initialized")
+	}
+
+	return r.ConfigPath(), nil
+}
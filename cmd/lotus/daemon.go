@@ -4,19 +4,19 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"runtime/pprof"
 	"strings"
 
-	"github.com/DataDog/zstd"
+	"github.com/dustin/go-humanize"
 	metricsprom "github.com/ipfs/go-metrics-prometheus"
 	"github.com/mitchellh/go-homedir"
 	"github.com/multiformats/go-multiaddr"
@@ -25,6 +25,7 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	"golang.org/x/time/rate"
 	"golang.org/x/xerrors"
 	"gopkg.in/cheggaaa/pb.v1"
 
@@ -41,7 +42,9 @@ import (
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/fsjournal"
+	"github.com/filecoin-project/lotus/lib/httpreader"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/lib/rpcbatch"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
@@ -114,7 +117,27 @@ var DaemonCmd = &cli.Command{
 		},
 		&cli.StringFlag{
 			Name:  "import-snapshot",
-			Usage: "import chain state from a given chain export file or url",
+			Usage: "import chain state from a given chain export file or url, or the manifest of a sharded export",
+		},
+		&cli.StringFlag{
+			Name:  "import-digest",
+			Usage: "path or url of a sha256sum file to check the downloaded chain or snapshot against",
+		},
+		&cli.StringFlag{
+			Name:  "import-manifest",
+			Usage: "path of a snapshot manifest the imported chain or snapshot must match",
+		},
+		&cli.StringFlag{
+			Name:  "import-bandwidth",
+			Usage: "limit the download rate of a chain or snapshot fetched over http, e.g. 50MiB (per second)",
+		},
+		&cli.BoolFlag{
+			Name:  "import-dedup",
+			Usage: "only write the blocks of the imported chain or snapshot that are not in the repo yet",
+		},
+		&cli.BoolFlag{
+			Name:  "verify-import",
+			Usage: "check that the header chain and head state are complete after importing a chain or snapshot",
 		},
 		&cli.BoolFlag{
 			Name:  "halt-after-import",
@@ -149,6 +172,15 @@ var DaemonCmd = &cli.Command{
 			Name:  "api-max-req-size",
 			Usage: "maximum API request size accepted by the JSON RPC server",
 		},
+		&cli.IntFlag{
+			Name:  "api-max-batch-size",
+			Usage: "maximum number of requests in the JSON RPC batches accepted by the JSON RPC server, 0 rejects batches",
+			Value: rpcbatch.DefaultMaxBatchSize,
+		},
+		&cli.StringFlag{
+			Name:  "api-grpc-listen",
+			Usage: "multiaddress to serve the gRPC API on, e.g. /ip4/127.0.0.1/tcp/1235, not served when empty",
+		},
 		&cli.PathFlag{
 			Name:  "restore",
 			Usage: "restore from backup file",
@@ -272,7 +304,21 @@ var DaemonCmd = &cli.Command{
 				issnapshot = true
 			}
 
-			if err := ImportChain(ctx, r, chainfile, issnapshot); err != nil {
+			opts := ImportOpts{
+				Verify:   cctx.Bool("verify-import"),
+				Digest:   cctx.String("import-digest"),
+				Manifest: cctx.String("import-manifest"),
+				Dedup:    cctx.Bool("import-dedup"),
+			}
+			if cctx.IsSet("import-bandwidth") {
+				bw, err := humanize.ParseBytes(cctx.String("import-bandwidth"))
+				if err != nil {
+					return xerrors.Errorf("parse --import-bandwidth: %w", err)
+				}
+				opts.Bandwidth = bw
+			}
+
+			if err := ImportChain(ctx, r, chainfile, issnapshot, opts); err != nil {
 				return err
 			}
 			if cctx.Bool("halt-after-import") {
@@ -365,9 +411,13 @@ var DaemonCmd = &cli.Command{
 		if maxRequestSize := cctx.Int("api-max-req-size"); maxRequestSize != 0 {
 			serverOptions = append(serverOptions, jsonrpc.WithMaxRequestSize(int64(maxRequestSize)))
 		}
+		batch := rpcbatch.Config{
+			MaxBatchSize:   cctx.Int("api-max-batch-size"),
+			MaxRequestSize: int64(cctx.Int("api-max-req-size")),
+		}
 
 		// Instantiate the full node handler.
-		h, err := node.FullNodeHandler(api, true, serverOptions...)
+		h, err := node.FullNodeHandler(api, true, batch, serverOptions...)
 		if err != nil {
 			return fmt.Errorf("failed to instantiate rpc handler: %s", err)
 		}
@@ -378,10 +428,24 @@ var DaemonCmd = &cli.Command{
 			return fmt.Errorf("failed to start json-rpc endpoint: %s", err)
 		}
 
+		shutdownHandlers := []node.ShutdownHandler{{Component: "rpc server", StopFunc: rpcStopper}}
+
+		// Serve the gRPC API.
+		if listen := cctx.String("api-grpc-listen"); listen != "" {
+			grpcEndpoint, err := multiaddr.NewMultiaddr(listen)
+			if err != nil {
+				return xerrors.Errorf("parsing grpc api endpoint: %w", err)
+			}
+			grpcStopper, err := node.ServeGRPC(node.FullNodeGRPCServer(api, "lotus-daemon-grpc", true), grpcEndpoint)
+			if err != nil {
+				return fmt.Errorf("failed to start grpc endpoint: %s", err)
+			}
+			shutdownHandlers = append(shutdownHandlers, node.ShutdownHandler{Component: "grpc server", StopFunc: grpcStopper})
+		}
+
 		// Monitor for shutdown.
 		finishCh := node.MonitorShutdown(shutdownChan,
-			node.ShutdownHandler{Component: "rpc server", StopFunc: rpcStopper},
-			node.ShutdownHandler{Component: "node", StopFunc: stop},
+			append(shutdownHandlers, node.ShutdownHandler{Component: "node", StopFunc: stop})...,
 		)
 		<-finishCh // fires when shutdown is complete.
 
@@ -427,28 +491,80 @@ func importKey(ctx context.Context, api lapi.FullNode, f string) error {
 	return nil
 }
 
-func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool) (err error) {
+// ImportOpts configures how ImportChain fetches and checks the chain.
+type ImportOpts struct {
+	// Verify checks that the header chain and head state are complete.
+	Verify bool
+	// Digest is the path or url of a sha256sum file the chain file must match.
+	Digest string
+	// Manifest is the path of a snapshot manifest the chain must match.
+	Manifest string
+	// Dedup skips writing blocks that are already in the repo.
+	Dedup bool
+	// Bandwidth limits the download rate of chains fetched over http, in
+	// bytes per second; 0 means unlimited.
+	Bandwidth uint64
+}
+
+func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool, opts ImportOpts) (err error) {
+	var digest []byte
+	if opts.Digest != "" {
+		digest, err = httpreader.LoadSha256Sum(ctx, opts.Digest)
+		if err != nil {
+			return xerrors.Errorf("loading import digest: %w", err)
+		}
+	}
+
+	sopts := store.ImportOpts{Verify: opts.Verify, Dedup: opts.Dedup}
+	if opts.Manifest != "" {
+		sopts.Manifest, err = store.ReadManifest(opts.Manifest)
+		if err != nil {
+			return xerrors.Errorf("loading import manifest: %w", err)
+		}
+	}
+
 	var rd io.Reader
 	var l int64
-	if strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://") {
-		resp, err := http.Get(fname) //nolint:gosec
+	remote := strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://")
+	if !remote {
+		fname, err = homedir.Expand(fname)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close() //nolint:errcheck
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return xerrors.Errorf("fetching chain CAR failed with non-200 response: %d", resp.StatusCode)
+	if remote {
+		rr, err := httpreader.NewResumableReader(ctx, fname)
+		if err != nil {
+			return xerrors.Errorf("fetching chain CAR: %w", err)
 		}
+		defer rr.Close() //nolint:errcheck
 
-		rd = resp.Body
-		l = resp.ContentLength
-	} else {
-		fname, err = homedir.Expand(fname)
+		rr.Digest = digest
+		if opts.Bandwidth > 0 {
+			rr.Limiter = rate.NewLimiter(rate.Limit(opts.Bandwidth), 1<<20)
+		}
+
+		rd = rr
+		l = rr.Size()
+	} else if strings.HasSuffix(fname, store.ManifestSuffix) {
+		if digest != nil {
+			return xerrors.Errorf("sharded snapshots are checked against their manifest, not a digest")
+		}
+
+		sr, err := store.OpenShards(fname)
 		if err != nil {
-			return err
+			return xerrors.Errorf("opening sharded snapshot: %w", err)
+		}
+		defer sr.Close() //nolint:errcheck
+
+		if sopts.Manifest == nil {
+			sopts.Manifest = sr.Manifest()
 		}
 
+		rd = sr
+		l = sr.Size()
+	} else {
 		fi, err := os.Open(fname)
 		if err != nil {
 			return err
@@ -462,6 +578,20 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 
 		rd = fi
 		l = st.Size()
+
+		if digest != nil {
+			log.Infof("checking sha256 of %s...", fname)
+			h := sha256.New()
+			if _, err := io.Copy(h, fi); err != nil {
+				return xerrors.Errorf("hashing chain file: %w", err)
+			}
+			if sum := h.Sum(nil); !bytes.Equal(sum, digest) {
+				return xerrors.Errorf("sha256 of %s is %x, expected %x", fname, sum, digest)
+			}
+			if _, err := fi.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
 	}
 
 	lr, err := r.Lock(repo.FullNode)
@@ -490,34 +620,25 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 
 	log.Infof("importing chain from %s...", fname)
 
-	bufr := bufio.NewReaderSize(rd, 1<<20)
-
-	header, err := bufr.Peek(4)
-	if err != nil {
-		return xerrors.Errorf("peek header: %w", err)
-	}
-
 	bar := pb.New64(l)
-	br := bar.NewProxyReader(bufr)
+	br := bar.NewProxyReader(rd)
 	bar.ShowTimeLeft = true
 	bar.ShowPercent = true
 	bar.ShowSpeed = true
 	bar.Units = pb.U_BYTES
 
-	var ir io.Reader = br
-
-	if string(header[1:]) == "\xB5\x2F\xFD" { // zstd
-		zr := zstd.NewReader(br)
-		defer func() {
-			if err := zr.Close(); err != nil {
-				log.Errorw("closing zstd reader", "error", err)
-			}
-		}()
-		ir = zr
+	ir, err := store.NewDecompressedReader(br)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		if err := ir.Close(); err != nil {
+			log.Errorw("closing decompressed reader", "error", err)
+		}
+	}()
 
 	bar.Start()
-	ts, err := cst.Import(ctx, ir)
+	ts, err := cst.ImportWithOpts(ctx, ir, sopts)
 	bar.Finish()
 
 	if err != nil {
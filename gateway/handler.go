@@ -17,6 +17,7 @@ import (
 	lapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/lib/rpcbatch"
 	"github.com/filecoin-project/lotus/metrics/proxy"
 	"github.com/filecoin-project/lotus/node"
 )
@@ -26,7 +27,8 @@ type perConnLimiterKeyType string
 const perConnLimiterKey perConnLimiterKeyType = "limiter"
 
 // Handler returns a gateway http.Handler, to be mounted as-is on the server.
-func Handler(gwapi lapi.Gateway, api lapi.FullNode, rateLimit int64, connPerMinute int64, opts ...jsonrpc.ServerOption) (http.Handler, error) {
+// The JSON-RPC endpoints serve the batches of requests configured by batch.
+func Handler(gwapi lapi.Gateway, api lapi.FullNode, rateLimit int64, connPerMinute int64, batch rpcbatch.Config, opts ...jsonrpc.ServerOption) (http.Handler, error) {
 	m := mux.NewRouter()
 
 	serveRpc := func(path string, hnd interface{}) {
@@ -34,7 +36,7 @@ func Handler(gwapi lapi.Gateway, api lapi.FullNode, rateLimit int64, connPerMinu
 		rpcServer.Register("Filecoin", hnd)
 		rpcServer.AliasMethod("rpc.discover", "Filecoin.Discover")
 
-		m.Handle(path, rpcServer)
+		m.Handle(path, rpcbatch.NewHandler(rpcServer, batch))
 	}
 
 	ma := proxy.MetricedGatewayAPI(gwapi)
@@ -9,6 +9,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/exchange"
+	"github.com/filecoin-project/lotus/chain/exectrace"
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
@@ -50,6 +51,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = gen.WriteTupleEncodersToFile("./chain/exectrace/cbor_gen.go", "exectrace",
+		exectrace.TipSetTrace{},
+		exectrace.MessageTrace{},
+		exectrace.GasCost{},
+		exectrace.Call{},
+		exectrace.VMTrace{},
+		exectrace.Randomness{},
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	err = gen.WriteMapEncodersToFile("./paychmgr/cbor_gen.go", "paychmgr",
 		paychmgr.VoucherInfo{},
 		paychmgr.ChannelInfo{},
@@ -95,6 +109,8 @@ func main() {
 	err = gen.WriteTupleEncodersToFile("./chain/exchange/cbor_gen.go", "exchange",
 		exchange.Request{},
 		exchange.Response{},
+		exchange.BatchRequest{},
+		exchange.BatchResponse{},
 		exchange.CompactedMessages{},
 		exchange.BSTipSet{},
 	)
@@ -28,6 +28,7 @@ import (
 	"github.com/filecoin-project/lotus/gateway"
 	"github.com/filecoin-project/lotus/itests/kit"
 	"github.com/filecoin-project/lotus/itests/multisig"
+	"github.com/filecoin-project/lotus/lib/rpcbatch"
 	"github.com/filecoin-project/lotus/node"
 )
 
@@ -291,7 +292,7 @@ func startNodes(
 
 	// Create a gateway server in front of the full node
 	gwapi := gateway.NewNode(full, lookbackCap, stateWaitLookbackLimit, 0, time.Minute)
-	handler, err := gateway.Handler(gwapi, full, 0, 0)
+	handler, err := gateway.Handler(gwapi, full, 0, 0, rpcbatch.DefaultConfig())
 	require.NoError(t, err)
 
 	l, err := net.Listen("tcp", "127.0.0.1:0")
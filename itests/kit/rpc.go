@@ -15,6 +15,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/cmd/lotus-worker/sealworker"
+	"github.com/filecoin-project/lotus/lib/rpcbatch"
 	"github.com/filecoin-project/lotus/node"
 )
 
@@ -42,7 +43,7 @@ func CreateRPCServer(t *testing.T, handler http.Handler, listener net.Listener)
 }
 
 func fullRpc(t *testing.T, f *TestFullNode) (*TestFullNode, Closer) {
-	handler, err := node.FullNodeHandler(f.FullNode, false)
+	handler, err := node.FullNodeHandler(f.FullNode, false, rpcbatch.DefaultConfig())
 	require.NoError(t, err)
 
 	l, err := net.Listen("tcp", "127.0.0.1:0")
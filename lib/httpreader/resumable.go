@@ -0,0 +1,244 @@
+package httpreader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("httpreader")
+
+// ResumableReader reads a http resource, resuming the transfer with a range
+// request when the connection fails part way through.
+type ResumableReader struct {
+	// Attempts is the number of times a failed transfer is retried without
+	// making progress before giving up.
+	Attempts int
+	// Backoff is the initial delay between retries; it doubles on every
+	// consecutive failure.
+	Backoff time.Duration
+	// Digest, if set, is the expected SHA-256 of the resource. Reaching the
+	// end of the resource fails if the data read does not match it.
+	Digest []byte
+	// Limiter, if set, limits the rate in bytes per second at which the
+	// resource is read.
+	Limiter *rate.Limiter
+
+	ctx    context.Context
+	client *http.Client
+	url    string
+
+	body   io.ReadCloser
+	offset int64
+	size   int64
+	etag   string
+	hash   hash.Hash
+}
+
+// NewResumableReader starts a transfer of url.
+func NewResumableReader(ctx context.Context, url string) (*ResumableReader, error) {
+	rr := &ResumableReader{
+		Attempts: 5,
+		Backoff:  time.Second,
+
+		ctx:    ctx,
+		client: http.DefaultClient,
+		url:    url,
+		size:   -1,
+		hash:   sha256.New(),
+	}
+
+	if err := rr.open(); err != nil {
+		return nil, err
+	}
+
+	return rr, nil
+}
+
+// Size returns the size of the resource, or -1 if the server did not report it.
+func (rr *ResumableReader) Size() int64 {
+	return rr.size
+}
+
+func (rr *ResumableReader) open() error {
+	req, err := http.NewRequestWithContext(rr.ctx, http.MethodGet, rr.url, nil)
+	if err != nil {
+		return err
+	}
+	if rr.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", rr.offset))
+		if rr.etag != "" {
+			// only resume if the resource did not change
+			req.Header.Set("If-Range", rr.etag)
+		}
+	}
+
+	resp, err := rr.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case rr.offset == 0 && resp.StatusCode == http.StatusOK:
+		rr.size = resp.ContentLength
+		rr.etag = resp.Header.Get("ETag")
+	case rr.offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case rr.offset > 0 && resp.StatusCode == http.StatusOK:
+		resp.Body.Close() //nolint:errcheck
+		return xerrors.Errorf("cannot resume transfer at byte %d: the resource changed or the server does not support range requests", rr.offset)
+	default:
+		resp.Body.Close() //nolint:errcheck
+		return xerrors.Errorf("fetching %s failed with non-200 response: %d", rr.url, resp.StatusCode)
+	}
+
+	rr.body = resp.Body
+	return nil
+}
+
+func (rr *ResumableReader) Read(p []byte) (int, error) {
+	if rr.Limiter != nil {
+		if b := rr.Limiter.Burst(); len(p) > b {
+			p = p[:b]
+		}
+		if err := rr.Limiter.WaitN(rr.ctx, len(p)); err != nil {
+			return 0, err
+		}
+	}
+
+	backoff := rr.Backoff
+	for attempt := 0; ; attempt++ {
+		var n int
+		var err error
+		if rr.body != nil {
+			n, err = rr.body.Read(p)
+			rr.offset += int64(n)
+			rr.hash.Write(p[:n]) //nolint:errcheck // hash writes don't fail
+
+			if err == io.EOF && rr.size >= 0 && rr.offset < rr.size {
+				err = io.ErrUnexpectedEOF
+			}
+			if err == nil || err == io.EOF {
+				if err == io.EOF {
+					err = rr.checkDigest()
+				}
+				return n, err
+			}
+
+			rr.body.Close() //nolint:errcheck
+			rr.body = nil
+			if n > 0 {
+				// made progress, hand out what we have and reconnect on the next read
+				return n, nil
+			}
+		} else {
+			if err = rr.open(); err == nil {
+				continue
+			}
+		}
+
+		if rr.ctx.Err() != nil {
+			return 0, rr.ctx.Err()
+		}
+		if attempt >= rr.Attempts {
+			return 0, xerrors.Errorf("transfer failed at byte %d after %d attempts: %w", rr.offset, attempt, err)
+		}
+
+		log.Warnw("http transfer interrupted, resuming", "url", rr.url, "offset", rr.offset, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-rr.ctx.Done():
+			return 0, rr.ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (rr *ResumableReader) checkDigest() error {
+	if rr.Digest == nil {
+		return io.EOF
+	}
+	if sum := rr.hash.Sum(nil); !bytes.Equal(sum, rr.Digest) {
+		return xerrors.Errorf("sha256 of %s is %x, expected %x", rr.url, sum, rr.Digest)
+	}
+	return io.EOF
+}
+
+func (rr *ResumableReader) Close() error {
+	if rr.body == nil {
+		return nil
+	}
+	err := rr.body.Close()
+	rr.body = nil
+	return err
+}
+
+// ParseSha256Sum parses the digest from the output of sha256sum, as used for
+// the digest files published next to snapshots.
+func ParseSha256Sum(data []byte) ([]byte, error) {
+	fields := bytes.Fields(data)
+	if len(fields) == 0 {
+		return nil, xerrors.Errorf("empty digest file")
+	}
+
+	d, err := hex.DecodeString(string(fields[0]))
+	if err != nil {
+		return nil, xerrors.Errorf("decoding digest: %w", err)
+	}
+	if len(d) != sha256.Size {
+		return nil, xerrors.Errorf("digest has %d bytes, expected %d", len(d), sha256.Size)
+	}
+
+	return d, nil
+}
+
+// LoadSha256Sum reads the digest of the sha256sum file at loc, a http url or a
+// local path.
+func LoadSha256Sum(ctx context.Context, loc string) ([]byte, error) {
+	var data []byte
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close() //nolint:errcheck
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, xerrors.Errorf("fetching digest failed with non-200 response: %d", resp.StatusCode)
+		}
+
+		data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p, err := homedir.Expand(loc)
+		if err != nil {
+			return nil, err
+		}
+		data, err = os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ParseSha256Sum(data)
+}
+
+var _ io.ReadCloser = &ResumableReader{}
@@ -0,0 +1,82 @@
+package httpreader
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cutWriter aborts the response after limit bytes of the body were written.
+type cutWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (cw *cutWriter) Write(p []byte) (int, error) {
+	if len(p) > cw.limit {
+		cw.ResponseWriter.Write(p[:cw.limit]) //nolint:errcheck
+		cw.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	cw.limit -= len(p)
+	return cw.ResponseWriter.Write(p)
+}
+
+func TestResumableReader(t *testing.T) {
+	data := make([]byte, 1<<20)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("ETag", `"snapshot"`)
+		// every response is cut off after 300KiB
+		http.ServeContent(&cutWriter{ResponseWriter: w, limit: 300 << 10}, r, "snapshot.car", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(data)
+
+	rr, err := NewResumableReader(context.Background(), srv.URL)
+	require.NoError(t, err)
+	rr.Backoff = time.Millisecond
+	rr.Digest = sum[:]
+
+	require.Equal(t, int64(len(data)), rr.Size())
+
+	got, err := io.ReadAll(rr)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+	require.Equal(t, int64(4), atomic.LoadInt64(&requests))
+
+	// a digest mismatch fails the transfer at the end
+	rr, err = NewResumableReader(context.Background(), srv.URL)
+	require.NoError(t, err)
+	rr.Backoff = time.Millisecond
+	rr.Digest = make([]byte, sha256.Size)
+
+	_, err = io.ReadAll(rr)
+	require.ErrorContains(t, err, "sha256")
+}
+
+func TestParseSha256Sum(t *testing.T) {
+	sum := sha256.Sum256([]byte("snapshot"))
+
+	d, err := ParseSha256Sum([]byte(fmt.Sprintf("%x  snapshot.car\n", sum)))
+	require.NoError(t, err)
+	require.Equal(t, sum[:], d)
+
+	_, err = ParseSha256Sum([]byte("abcd  snapshot.car\n"))
+	require.Error(t, err)
+}
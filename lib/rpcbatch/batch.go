@@ -0,0 +1,239 @@
+// Package rpcbatch adds JSON-RPC 2.0 batch requests to the HTTP handlers of
+// JSON-RPC servers answering one request per HTTP request.
+package rpcbatch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+var log = logging.Logger("rpcbatch")
+
+// DefaultMaxBatchSize is the default maximum number of requests in a batch.
+const DefaultMaxBatchSize = 100
+
+// concurrency is the number of requests of a batch served at once.
+const concurrency = 16
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcInternalError  = -32603
+)
+
+// Config configures the batches served by a Handler.
+type Config struct {
+	// MaxBatchSize is the maximum number of requests in a batch, 0 rejecting
+	// batches.
+	MaxBatchSize int
+	// MaxRequestSize is the maximum size of the body of a batch, 0 being the
+	// default of go-jsonrpc.
+	MaxRequestSize int64
+}
+
+// DefaultConfig returns the default batch config.
+func DefaultConfig() Config {
+	return Config{
+		MaxBatchSize:   DefaultMaxBatchSize,
+		MaxRequestSize: jsonrpc.DEFAULT_MAX_REQUEST_SIZE,
+	}
+}
+
+// Handler serves the JSON-RPC batches POSTed to it, that is arrays of
+// requests, by serving each of their requests with the next handler, and
+// passes the other requests to it as they are.
+type Handler struct {
+	next http.Handler
+	cfg  Config
+}
+
+// NewHandler returns a handler serving batches with next.
+func NewHandler(next http.Handler, cfg Config) *Handler {
+	if cfg.MaxRequestSize == 0 {
+		cfg.MaxRequestSize = jsonrpc.DEFAULT_MAX_REQUEST_SIZE
+	}
+	return &Handler{
+		next: next,
+		cfg:  cfg,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	body := bufio.NewReader(r.Body)
+	if !isBatch(body) {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, r.Body}
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := io.ReadAll(io.LimitReader(body, h.cfg.MaxRequestSize+1))
+	if err != nil {
+		writeError(w, rpcParseError, fmt.Sprintf("reading batch: %s", err))
+		return
+	}
+	if int64(len(data)) > h.cfg.MaxRequestSize {
+		writeError(w, rpcParseError, fmt.Sprintf("batch bigger than maximum %d allowed", h.cfg.MaxRequestSize))
+		return
+	}
+
+	var reqs []json.RawMessage
+	if err := json.Unmarshal(data, &reqs); err != nil {
+		writeError(w, rpcParseError, fmt.Sprintf("unmarshaling batch: %s", err))
+		return
+	}
+	switch {
+	case len(reqs) == 0:
+		writeError(w, rpcInvalidRequest, "empty batch")
+		return
+	case len(reqs) > h.cfg.MaxBatchSize:
+		writeError(w, rpcInvalidRequest, fmt.Sprintf("batch of %d requests exceeds the maximum of %d", len(reqs), h.cfg.MaxBatchSize))
+		return
+	}
+
+	resps := make([]json.RawMessage, len(reqs))
+	throttle := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		throttle <- struct{}{}
+		go func(i int, req json.RawMessage) {
+			defer func() {
+				<-throttle
+				wg.Done()
+			}()
+			resps[i] = h.serveOne(r, req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	// notifications get no response, and batches of notifications nothing at
+	// all
+	out := resps[:0]
+	for _, resp := range resps {
+		if len(resp) > 0 {
+			out = append(out, resp)
+		}
+	}
+	if len(out) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		log.Warnf("writing batch response: %s", err)
+	}
+}
+
+// serveOne serves req of the batch r with the next handler, and returns its
+// response, nil for notifications.
+func (h *Handler) serveOne(r *http.Request, req json.RawMessage) json.RawMessage {
+	sub := r.Clone(r.Context())
+	sub.Body = io.NopCloser(bytes.NewReader(req))
+	sub.ContentLength = int64(len(req))
+
+	rec := &recorder{header: http.Header{}}
+	h.next.ServeHTTP(rec, sub)
+
+	resp := bytes.TrimSpace(rec.body.Bytes())
+	if len(resp) == 0 || json.Valid(resp) {
+		return resp
+	}
+
+	// the next handler failed without a JSON-RPC response, e.g. with a plain
+	// http error
+	var id struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(req, &id); err != nil || id.ID == nil {
+		id.ID = json.RawMessage("null")
+	}
+	b, err := json.Marshal(response(id.ID, rpcInternalError, fmt.Sprintf("%d: %s", rec.status, resp)))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// isBatch tells whether the body starts with an array, skipping whitespace.
+func isBatch(body *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		b, err := body.Peek(n)
+		if err != nil || len(b) < n {
+			return false
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+type respError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type errResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *respError      `json:"error"`
+}
+
+func response(id json.RawMessage, code int, msg string) errResponse {
+	return errResponse{Jsonrpc: "2.0", ID: id, Error: &respError{Code: code, Message: msg}}
+}
+
+// writeError answers a batch that can't be served with a single error.
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(response(json.RawMessage("null"), code, msg)); err != nil {
+		log.Warnf("writing batch error: %s", err)
+	}
+}
+
+// recorder is the response writer of the requests of a batch.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
@@ -0,0 +1,89 @@
+// stm: #unit
+package rpcbatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+type testAPI struct{}
+
+func (testAPI) Double(ctx context.Context, n int) (int, error) {
+	return 2 * n, nil
+}
+
+func (testAPI) Fail(ctx context.Context) error {
+	return xerrors.New("failed")
+}
+
+func TestBatch(t *testing.T) {
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("Test", testAPI{})
+
+	srv := httptest.NewServer(NewHandler(rpcServer, Config{MaxBatchSize: 3}))
+	defer srv.Close()
+
+	post := func(t *testing.T, body string) (int, string) {
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+
+		var out json.RawMessage
+		if resp.StatusCode != http.StatusNoContent {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		}
+		return resp.StatusCode, string(out)
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		_, out := post(t, ` [
+			{"jsonrpc": "2.0", "id": 1, "method": "Test.Double", "params": [2]},
+			{"jsonrpc": "2.0", "method": "Test.Double", "params": [3]},
+			{"jsonrpc": "2.0", "id": 3, "method": "Test.Fail", "params": []}
+		]`)
+
+		var resps []struct {
+			ID     int
+			Result *int
+			Error  *struct{ Message string }
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &resps))
+		require.Len(t, resps, 2, "notifications have no response")
+
+		require.Equal(t, 1, resps[0].ID)
+		require.Equal(t, 4, *resps[0].Result)
+		require.Equal(t, 3, resps[1].ID)
+		require.Equal(t, "failed", resps[1].Error.Message)
+	})
+
+	t.Run("single", func(t *testing.T) {
+		_, out := post(t, `{"jsonrpc": "2.0", "id": 1, "method": "Test.Double", "params": [2]}`)
+		require.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "result": 4}`, out)
+	})
+
+	t.Run("notifications", func(t *testing.T) {
+		status, _ := post(t, `[{"jsonrpc": "2.0", "method": "Test.Double", "params": [2]}]`)
+		require.Equal(t, http.StatusNoContent, status)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for body, msg := range map[string]string{
+			`[]`:               "empty batch",
+			`[{}, {}, {}, {}]`: "batch of 4 requests exceeds the maximum of 3",
+			`[{`:               "unmarshaling batch",
+		} {
+			status, out := post(t, body)
+			require.Equal(t, http.StatusBadRequest, status)
+			require.Contains(t, out, msg)
+		}
+	})
+}
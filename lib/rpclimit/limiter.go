@@ -0,0 +1,232 @@
+// Package rpclimit limits the rate of the API calls of each caller, identified
+// by its JWT token, overall and to each method or group of methods.
+package rpclimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// sweepInterval is the interval between the removals of the limiters of the
+// callers that stopped calling.
+const sweepInterval = time.Minute
+
+// tokenRule is the rule of the limit on all the calls of a caller.
+const tokenRule = "token"
+
+type callerKey struct{}
+
+// WithCaller returns a context of the calls of caller.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// Caller returns the caller of the calls with ctx, empty if it isn't known.
+func Caller(ctx context.Context) string {
+	c, _ := ctx.Value(callerKey{}).(string)
+	return c
+}
+
+// CallerHandler identifies the callers of the requests it passes to next, with
+// CallerID of their JWT token, read like auth.Handler does.
+func CallerHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithCaller(r.Context(), CallerID(token, r.RemoteAddr))))
+	})
+}
+
+// CallerID returns the identity of a caller: a digest of its JWT token, else
+// its IP address.
+func CallerID(token, remoteAddr string) string {
+	if token != "" {
+		d := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(d[:8])
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "ip:" + host
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// PerToken is the rate of all the calls of a caller, in calls per second,
+	// 0 not limiting them.
+	PerToken float64
+	// Burst is the number of calls allowed at once above each rate.
+	Burst int
+	// Methods are the rates of the calls of a caller to the methods, by
+	// method name, or by prefix followed by '*' for groups of methods.
+	Methods map[string]float64
+}
+
+// rule is a limit of the calls to the methods it matches.
+type rule struct {
+	pattern string
+	limit   rate.Limit
+}
+
+func (r rule) matches(method string) bool {
+	if prefix := strings.TrimSuffix(r.pattern, "*"); prefix != r.pattern {
+		return strings.HasPrefix(method, prefix)
+	}
+	return method == r.pattern
+}
+
+type limiterKey struct {
+	caller string
+	rule   string
+}
+
+type entry struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+// Limiter limits the rate of the calls of each caller.
+type Limiter struct {
+	perToken rate.Limit
+	burst    int
+	// rules are the method rules, the most specific first
+	rules []rule
+
+	lk        sync.Mutex
+	limiters  map[limiterKey]*entry
+	lastSweep time.Time
+}
+
+// NewLimiter returns a limiter of the calls limited by cfg.
+func NewLimiter(cfg Config) (*Limiter, error) {
+	if cfg.PerToken < 0 {
+		return nil, xerrors.Errorf("negative rate %g per token", cfg.PerToken)
+	}
+	if cfg.Burst < 1 {
+		return nil, xerrors.Errorf("burst %d is below 1", cfg.Burst)
+	}
+
+	l := &Limiter{
+		perToken:  rate.Limit(cfg.PerToken),
+		burst:     cfg.Burst,
+		limiters:  map[limiterKey]*entry{},
+		lastSweep: time.Now(),
+	}
+	if cfg.PerToken == 0 {
+		l.perToken = rate.Inf
+	}
+
+	for pattern, r := range cfg.Methods {
+		if r <= 0 {
+			return nil, xerrors.Errorf("rate %g of %s is not positive", r, pattern)
+		}
+		if strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+			return nil, xerrors.Errorf("%s: '*' is only allowed at the end of method groups", pattern)
+		}
+		l.rules = append(l.rules, rule{pattern: pattern, limit: rate.Limit(r)})
+	}
+
+	// method names before groups, longer prefixes before shorter ones
+	sort.Slice(l.rules, func(i, j int) bool {
+		gi, gj := strings.HasSuffix(l.rules[i].pattern, "*"), strings.HasSuffix(l.rules[j].pattern, "*")
+		if gi != gj {
+			return gj
+		}
+		if len(l.rules[i].pattern) != len(l.rules[j].pattern) {
+			return len(l.rules[i].pattern) > len(l.rules[j].pattern)
+		}
+		return l.rules[i].pattern < l.rules[j].pattern
+	})
+
+	return l, nil
+}
+
+// Enabled tells whether l limits any call.
+func (l *Limiter) Enabled() bool {
+	return l.perToken != rate.Inf || len(l.rules) > 0
+}
+
+// Allow counts a call to method by the caller of ctx, and returns an
+// *api.ErrRateLimited error when the call is over one of its limits; the call
+// isn't counted then.
+func (l *Limiter) Allow(ctx context.Context, method string) error {
+	caller := Caller(ctx)
+	now := time.Now()
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	var reservations []*rate.Reservation
+	reserve := func(ruleName string, limit rate.Limit) error {
+		k := limiterKey{caller: caller, rule: ruleName}
+		e, ok := l.limiters[k]
+		if !ok {
+			e = &entry{limiter: rate.NewLimiter(limit, l.burst)}
+			l.limiters[k] = e
+		}
+		e.used = now
+
+		r := e.limiter.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+
+			rctx, _ := tag.New(ctx, tag.Upsert(metrics.Endpoint, method), tag.Upsert(metrics.RateLimitRule, ruleName))
+			stats.Record(rctx, metrics.RPCRateLimited.M(1))
+			return &api.ErrRateLimited{Limit: ruleName, RetryAfter: delay}
+		}
+		reservations = append(reservations, r)
+		return nil
+	}
+
+	for _, r := range l.rules {
+		if r.matches(method) {
+			if err := reserve(r.pattern, r.limit); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if l.perToken != rate.Inf {
+		return reserve(tokenRule, l.perToken)
+	}
+	return nil
+}
+
+// sweep removes the limiters unused for long enough to be full again.
+func (l *Limiter) sweep(now time.Time) {
+	for k, e := range l.limiters {
+		refill := time.Duration(float64(l.burst) / float64(e.limiter.Limit()) * float64(time.Second))
+		if now.Sub(e.used) > refill {
+			delete(l.limiters, k)
+		}
+	}
+	l.lastSweep = now
+}
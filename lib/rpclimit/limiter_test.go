@@ -0,0 +1,141 @@
+// stm: #unit
+package rpclimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestLimiter(t *testing.T) {
+	l, err := NewLimiter(Config{
+		PerToken: 1e-3,
+		Burst:    3,
+		Methods: map[string]float64{
+			"State*":      1e-3,
+			"StateReplay": 1e-3,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, l.Enabled())
+
+	a := WithCaller(context.Background(), "a")
+	b := WithCaller(context.Background(), "b")
+
+	// StateReplay is limited by its own rule, not by the State* group
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Allow(a, "StateReplay"))
+	}
+	err = l.Allow(a, "StateReplay")
+	var rl *api.ErrRateLimited
+	require.True(t, errors.As(err, &rl))
+	require.Equal(t, "StateReplay", rl.Limit)
+	require.Positive(t, rl.RetryAfter)
+
+	// the calls count towards the token limit too
+	err = l.Allow(a, "ChainHead")
+	require.True(t, errors.As(err, &rl))
+	require.Equal(t, tokenRule, rl.Limit)
+
+	// callers are limited separately
+	require.NoError(t, l.Allow(b, "StateReplay"))
+
+	t.Run("groups", func(t *testing.T) {
+		l, err := NewLimiter(Config{
+			Burst: 1,
+			Methods: map[string]float64{
+				"State*":      1e-3,
+				"StateWait*":  1e3,
+				"ChainHead":   1e3,
+				"ChainGetMsg": 1e3,
+			},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, l.Allow(a, "StateGetActor"))
+		require.Error(t, l.Allow(a, "StateCall"))
+		require.NoError(t, l.Allow(a, "StateWaitMsg"))
+		require.NoError(t, l.Allow(a, "ChainHead"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		l, err := NewLimiter(Config{Burst: 1})
+		require.NoError(t, err)
+		require.False(t, l.Enabled())
+		for i := 0; i < 10; i++ {
+			require.NoError(t, l.Allow(a, "ChainHead"))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, cfg := range []Config{
+			{PerToken: -1, Burst: 1},
+			{Burst: 0},
+			{Burst: 1, Methods: map[string]float64{"State*": 0}},
+			{Burst: 1, Methods: map[string]float64{"*Get": 1}},
+		} {
+			_, err := NewLimiter(cfg)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestCallerHandler(t *testing.T) {
+	var caller string
+	h := CallerHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = Caller(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/rpc/v1", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, "ip:10.0.0.1", caller)
+
+	r.Header.Set("Authorization", "Bearer abc")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	byHeader := caller
+	require.Contains(t, byHeader, "token:")
+
+	r = httptest.NewRequest(http.MethodPost, "/rpc/v1?token=abc", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, byHeader, caller)
+}
+
+type testNode struct {
+	api.FullNode
+}
+
+func (testNode) ChainHead(context.Context) (*types.TipSet, error) {
+	return &types.TipSet{}, nil
+}
+
+func TestLimitedFullAPI(t *testing.T) {
+	l, err := NewLimiter(Config{Burst: 1, Methods: map[string]float64{"ChainHead": 1e-3}})
+	require.NoError(t, err)
+
+	a := LimitedFullAPI(testNode{}, l)
+
+	ctx := WithCaller(context.Background(), "a")
+	ts, err := a.ChainHead(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, ts)
+
+	ts, err = a.ChainHead(ctx)
+	var rl *api.ErrRateLimited
+	require.True(t, errors.As(err, &rl))
+	require.Nil(t, ts)
+
+	// admins aren't limited
+	actx := auth.WithPerm(ctx, api.AllPermissions)
+	_, err = a.ChainHead(actx)
+	require.NoError(t, err)
+}
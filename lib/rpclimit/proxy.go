@@ -0,0 +1,59 @@
+package rpclimit
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// LimitedFullAPI returns a, with the calls over the limits of l failing with
+// an *api.ErrRateLimited error. Calls with the admin permission aren't
+// limited.
+func LimitedFullAPI(a api.FullNode, l *Limiter) api.FullNode {
+	var out api.FullNodeStruct
+	proxy(a, &out, l)
+	return &out
+}
+
+func proxy(in interface{}, outstr interface{}, l *Limiter) {
+	outs := api.GetInternalStructs(outstr)
+	for _, out := range outs {
+		rint := reflect.ValueOf(out).Elem()
+		ra := reflect.ValueOf(in)
+
+		for f := 0; f < rint.NumField(); f++ {
+			field := rint.Type().Field(f)
+			fn := ra.MethodByName(field.Name)
+			ft := field.Type
+
+			// methods without an error result can't be limited
+			if ft.NumOut() == 0 || ft.Out(ft.NumOut()-1) != errorType {
+				rint.Field(f).Set(fn)
+				continue
+			}
+
+			rint.Field(f).Set(reflect.MakeFunc(ft, func(args []reflect.Value) (results []reflect.Value) {
+				ctx := args[0].Interface().(context.Context)
+				if auth.HasPerm(ctx, nil, api.PermAdmin) {
+					return fn.Call(args)
+				}
+
+				if err := l.Allow(ctx, field.Name); err != nil {
+					results = make([]reflect.Value, ft.NumOut())
+					for i := range results[:len(results)-1] {
+						results[i] = reflect.Zero(ft.Out(i))
+					}
+					// the error result is an interface
+					results[len(results)-1] = reflect.ValueOf(&err).Elem()
+					return results
+				}
+				return fn.Call(args)
+			}))
+		}
+	}
+}
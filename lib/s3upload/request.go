@@ -0,0 +1,75 @@
+package s3upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// StatusError is returned by requests failing with a non 2xx status.
+type StatusError struct {
+	Method     string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s request failed with status %d: %s", e.Method, e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+// IsNotFound returns whether err is the failure of a request for an object
+// that doesn't exist.
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return xerrors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
+
+// Do sends a request for the object cfg.Key, signed with the credentials of
+// cfg, and returns the response body. An empty key addresses the bucket. If
+// respHdr is not nil, the response headers are copied to it.
+func Do(ctx context.Context, cfg Config, method string, q url.Values, body []byte, respHdr http.Header) ([]byte, error) {
+	cfg.setDefaults()
+
+	ep, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, xerrors.Errorf("parsing endpoint: %w", err)
+	}
+	ep.Path += "/" + cfg.Bucket + "/" + strings.TrimPrefix(cfg.Key, "/")
+	ep.RawPath = uriEncode(ep.Path, false)
+	ep.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, ep.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	signV4(req, cfg.AccessKey, cfg.SecretKey, cfg.Region, sha256Hex(body), time.Now())
+
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	rb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, xerrors.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, &StatusError{Method: method, StatusCode: resp.StatusCode, Body: rb}
+	}
+
+	if respHdr != nil {
+		for k, v := range resp.Header {
+			respHdr[k] = v
+		}
+	}
+
+	return rb, nil
+}
@@ -0,0 +1,340 @@
+// Package s3upload streams data to an object in an S3 compatible object store
+// using a multipart upload, so that large files can be uploaded without being
+// staged on local disk first.
+//
+// Besides AWS S3, this works with any store implementing the S3 multipart
+// upload API, such as Google Cloud Storage through its XML API (using HMAC
+// keys and GCSEndpoint) or MinIO.
+package s3upload
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("s3upload")
+
+// GCSEndpoint is the S3 compatible endpoint of Google Cloud Storage.
+const GCSEndpoint = "https://storage.googleapis.com"
+
+const (
+	// MinPartSize is the smallest part size S3 accepts for all but the last
+	// part of an upload.
+	MinPartSize = 5 << 20
+	// DefaultPartSize is used when Config.PartSize is not set.
+	DefaultPartSize = 64 << 20
+
+	// maxParts is the maximum number of parts of a multipart upload.
+	maxParts = 10000
+
+	partAttempts = 3
+)
+
+type Config struct {
+	// Endpoint is the base URL of the object store, e.g.
+	// https://s3.us-east-1.amazonaws.com. The bucket is addressed path-style.
+	Endpoint string
+	Region   string
+	Bucket   string
+	Key      string
+
+	AccessKey string
+	SecretKey string
+
+	// PartSize is the size of the parts the object is uploaded in; at most
+	// Concurrency parts are buffered in memory at a time.
+	PartSize int64
+	// Concurrency is the number of parts uploaded in parallel.
+	Concurrency int
+
+	Client *http.Client
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// Upload is an in-progress multipart upload. Data written to it is split into
+// parts which are uploaded in the background; the object only becomes visible
+// once Commit completes the upload.
+type Upload struct {
+	cfg      Config
+	ctx      context.Context
+	uploadID string
+
+	buf   []byte
+	parts int
+	sem   chan struct{}
+	wg    sync.WaitGroup
+
+	lk        sync.Mutex
+	completed []completedPart
+	err       error
+}
+
+// ConfigFromURL returns the config of an upload to an s3://bucket/key or
+// gs://bucket/key destination, using the credentials from the standard AWS
+// environment variables. Google Cloud Storage is accessed through its S3
+// compatible XML API, with HMAC keys as credentials. The endpoint defaults to
+// the one of AWS S3 in AWS_REGION or to GCSEndpoint if not set.
+func ConfigFromURL(dest, endpoint string) (Config, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return Config{}, xerrors.Errorf("parsing destination: %w", err)
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return Config{}, xerrors.Errorf("unsupported object store destination %q", dest)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	if endpoint == "" {
+		if u.Scheme == "gs" {
+			endpoint = GCSEndpoint
+		} else {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+	}
+
+	return Config{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    u.Host,
+		Key:       strings.TrimPrefix(u.Path, "/"),
+		AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+	}, nil
+}
+
+func (cfg *Config) setDefaults() {
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 4
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+}
+
+// New starts a multipart upload of cfg.Key.
+func New(ctx context.Context, cfg Config) (*Upload, error) {
+	cfg.setDefaults()
+	if cfg.PartSize < MinPartSize {
+		return nil, xerrors.Errorf("part size %d is smaller than the minimum of %d", cfg.PartSize, MinPartSize)
+	}
+
+	u := &Upload{
+		cfg: cfg,
+		ctx: ctx,
+		sem: make(chan struct{}, cfg.Concurrency),
+	}
+
+	resp, err := u.do(ctx, http.MethodPost, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("initiating multipart upload: %w", err)
+	}
+
+	var res struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp, &res); err != nil {
+		return nil, xerrors.Errorf("decoding initiate multipart upload response: %w", err)
+	}
+	if res.UploadID == "" {
+		return nil, xerrors.Errorf("initiate multipart upload response has no upload id")
+	}
+	u.uploadID = res.UploadID
+
+	log.Infow("started multipart upload", "bucket", cfg.Bucket, "key", cfg.Key, "upload", u.uploadID)
+	return u, nil
+}
+
+func (u *Upload) Write(p []byte) (int, error) {
+	if err := u.uploadErr(); err != nil {
+		return 0, err
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		if u.buf == nil {
+			u.buf = make([]byte, 0, u.cfg.PartSize)
+		}
+
+		c := int(u.cfg.PartSize) - len(u.buf)
+		if c > len(p) {
+			c = len(p)
+		}
+		u.buf = append(u.buf, p[:c]...)
+		p = p[c:]
+
+		if int64(len(u.buf)) == u.cfg.PartSize {
+			if err := u.uploadPart(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Commit uploads the remaining buffered data and completes the upload.
+func (u *Upload) Commit(ctx context.Context) error {
+	if len(u.buf) > 0 || u.parts == 0 {
+		if err := u.uploadPart(); err != nil {
+			return err
+		}
+	}
+
+	u.wg.Wait()
+	if err := u.uploadErr(); err != nil {
+		return err
+	}
+
+	sort.Slice(u.completed, func(i, j int) bool {
+		return u.completed[i].PartNumber < u.completed[j].PartNumber
+	})
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: u.completed})
+	if err != nil {
+		return xerrors.Errorf("encoding complete multipart upload request: %w", err)
+	}
+
+	resp, err := u.do(ctx, http.MethodPost, url.Values{"uploadId": {u.uploadID}}, body, nil)
+	if err != nil {
+		return xerrors.Errorf("completing multipart upload: %w", err)
+	}
+
+	// S3 may report a failure to complete the upload with a 200 response
+	var res struct {
+		XMLName xml.Name
+		Message string
+	}
+	if err := xml.Unmarshal(resp, &res); err == nil && res.XMLName.Local == "Error" {
+		return xerrors.Errorf("completing multipart upload: %s", res.Message)
+	}
+
+	log.Infow("completed multipart upload", "bucket", u.cfg.Bucket, "key", u.cfg.Key, "parts", len(u.completed))
+	return nil
+}
+
+// Abort discards the upload and all parts uploaded so far.
+func (u *Upload) Abort(ctx context.Context) error {
+	u.wg.Wait()
+
+	if _, err := u.do(ctx, http.MethodDelete, url.Values{"uploadId": {u.uploadID}}, nil, nil); err != nil {
+		return xerrors.Errorf("aborting multipart upload: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the object cfg.Key. Deleting an object that doesn't exist
+// succeeds.
+func Delete(ctx context.Context, cfg Config) error {
+	cfg.setDefaults()
+
+	u := &Upload{cfg: cfg, ctx: ctx}
+	if _, err := u.do(ctx, http.MethodDelete, nil, nil, nil); err != nil {
+		return xerrors.Errorf("deleting object: %w", err)
+	}
+	return nil
+}
+
+func (u *Upload) uploadErr() error {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	return u.err
+}
+
+func (u *Upload) uploadPart() error {
+	if u.parts == maxParts {
+		return xerrors.Errorf("upload exceeds %d parts of %d bytes", maxParts, u.cfg.PartSize)
+	}
+	u.parts++
+	num := u.parts
+	data := u.buf
+	u.buf = nil
+
+	select {
+	case u.sem <- struct{}{}:
+	case <-u.ctx.Done():
+		return u.ctx.Err()
+	}
+
+	u.wg.Add(1)
+	go func() {
+		defer func() {
+			<-u.sem
+			u.wg.Done()
+		}()
+
+		q := url.Values{
+			"partNumber": {strconv.Itoa(num)},
+			"uploadId":   {u.uploadID},
+		}
+
+		var etag string
+		var err error
+		backoff := time.Second
+		for i := 0; i < partAttempts; i++ {
+			if i > 0 {
+				log.Warnw("retrying part upload", "part", num, "error", err)
+				build.Clock.Sleep(backoff)
+				backoff *= 2
+			}
+
+			hdr := make(http.Header)
+			if _, err = u.do(u.ctx, http.MethodPut, q, data, hdr); err == nil {
+				etag = hdr.Get("ETag")
+				break
+			}
+			if u.ctx.Err() != nil {
+				break
+			}
+		}
+
+		u.lk.Lock()
+		defer u.lk.Unlock()
+
+		if err != nil {
+			if u.err == nil {
+				u.err = xerrors.Errorf("uploading part %d: %w", num, err)
+			}
+			return
+		}
+		u.completed = append(u.completed, completedPart{PartNumber: num, ETag: etag})
+	}()
+
+	return nil
+}
+
+// do sends a signed request for the object being uploaded and returns the
+// response body. If respHdr is not nil, the response headers are copied to it.
+func (u *Upload) do(ctx context.Context, method string, q url.Values, body []byte, respHdr http.Header) ([]byte, error) {
+	return Do(ctx, u.cfg, method, q, body, respHdr)
+}
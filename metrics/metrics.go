@@ -43,6 +43,12 @@ var (
 	MsgValid, _     = tag.NewKey("message_valid")
 	Endpoint, _     = tag.NewKey("endpoint")
 	APIInterface, _ = tag.NewKey("api") // to distinguish between gateway api and full node api endpoint calls
+	ChainStoreOp, _ = tag.NewKey("chainstore_op")
+	CacheName, _    = tag.NewKey("cache")
+	CacheHit, _     = tag.NewKey("cache_hit")
+
+	// api rate limits
+	RateLimitRule, _ = tag.NewKey("rate_limit")
 
 	// miner
 	TaskType, _       = tag.NewKey("task_type")
@@ -66,6 +72,7 @@ var (
 	LotusInfo          = stats.Int64("info", "Arbitrary counter to tag lotus info to", stats.UnitDimensionless)
 	PeerCount          = stats.Int64("peer/count", "Current number of FIL peers", stats.UnitDimensionless)
 	APIRequestDuration = stats.Float64("api/request_duration_ms", "Duration of API requests", stats.UnitMilliseconds)
+	RPCRateLimited     = stats.Int64("api/rate_limited", "API calls rejected by the rate limits", stats.UnitDimensionless)
 
 	// graphsync
 
@@ -87,6 +94,11 @@ var (
 	ChainNodeHeight                     = stats.Int64("chain/node_height", "Current Height of the node", stats.UnitDimensionless)
 	ChainNodeHeightExpected             = stats.Int64("chain/node_height_expected", "Expected Height of the node", stats.UnitDimensionless)
 	ChainNodeWorkerHeight               = stats.Int64("chain/node_worker_height", "Current Height of workers on the node", stats.UnitDimensionless)
+	ChainImportBlocks                   = stats.Int64("chain/import_blocks", "Counter for blocks written by chain imports", stats.UnitDimensionless)
+	ChainImportBytes                    = stats.Int64("chain/import_bytes", "Counter for block data written by chain imports", stats.UnitBytes)
+	ChainStoreOpDuration                = stats.Float64("chainstore/op_ms", "Duration of chainstore operations", stats.UnitMilliseconds)
+	ChainStoreCacheLookup               = stats.Int64("chainstore/cache_lookup", "Counter for chainstore cache lookups", stats.UnitDimensionless)
+	ChainStoreReorgQueue                = stats.Int64("chainstore/reorg_queue", "Number of head changes waiting to be delivered to the notifees", stats.UnitDimensionless)
 	IndexerMessageValidationFailure     = stats.Int64("indexer/failure", "Counter for indexer message validation failures", stats.UnitDimensionless)
 	IndexerMessageValidationSuccess     = stats.Int64("indexer/success", "Counter for indexer message validation successes", stats.UnitDimensionless)
 	MessagePublished                    = stats.Int64("message/published", "Counter for total locally published messages", stats.UnitDimensionless)
@@ -164,6 +176,10 @@ var (
 	SplitstoreCompactionCold        = stats.Int64("splitstore/cold", "Number of cold blocks in last compaction", stats.UnitDimensionless)
 	SplitstoreCompactionDead        = stats.Int64("splitstore/dead", "Number of dead blocks in last compaction", stats.UnitDimensionless)
 
+	// blockstore scrub
+	BlockstoreScrubbed     = stats.Int64("blockstore/scrub/scrubbed", "Number of blocks re-hashed by the blockstore scrubber", stats.UnitDimensionless)
+	BlockstoreScrubCorrupt = stats.Int64("blockstore/scrub/corrupt", "Number of corrupted blocks found by the blockstore scrubber", stats.UnitDimensionless)
+
 	// rcmgr
 	RcmgrAllowConn      = stats.Int64("rcmgr/allow_conn", "Number of allowed connections", stats.UnitDimensionless)
 	RcmgrBlockConn      = stats.Int64("rcmgr/block_conn", "Number of blocked connections", stats.UnitDimensionless)
@@ -204,6 +220,28 @@ var (
 		Measure:     ChainNodeWorkerHeight,
 		Aggregation: view.LastValue(),
 	}
+	ChainImportBlocksView = &view.View{
+		Measure:     ChainImportBlocks,
+		Aggregation: view.Sum(),
+	}
+	ChainImportBytesView = &view.View{
+		Measure:     ChainImportBytes,
+		Aggregation: view.Sum(),
+	}
+	ChainStoreOpDurationView = &view.View{
+		Measure:     ChainStoreOpDuration,
+		Aggregation: defaultMillisecondsDistribution,
+		TagKeys:     []tag.Key{ChainStoreOp},
+	}
+	ChainStoreCacheLookupView = &view.View{
+		Measure:     ChainStoreCacheLookup,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{CacheName, CacheHit},
+	}
+	ChainStoreReorgQueueView = &view.View{
+		Measure:     ChainStoreReorgQueue,
+		Aggregation: queueSizeDistribution,
+	}
 	BlockReceivedView = &view.View{
 		Measure:     BlockReceived,
 		Aggregation: view.Count(),
@@ -327,6 +365,11 @@ var (
 		Aggregation: defaultMillisecondsDistribution,
 		TagKeys:     []tag.Key{APIInterface, Endpoint},
 	}
+	RPCRateLimitedView = &view.View{
+		Measure:     RPCRateLimited,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Endpoint, RateLimitRule},
+	}
 	VMFlushCopyDurationView = &view.View{
 		Measure:     VMFlushCopyDuration,
 		Aggregation: view.Sum(),
@@ -520,6 +563,16 @@ var (
 		Aggregation: view.Sum(),
 	}
 
+	// blockstore scrub
+	BlockstoreScrubbedView = &view.View{
+		Measure:     BlockstoreScrubbed,
+		Aggregation: view.Sum(),
+	}
+	BlockstoreScrubCorruptView = &view.View{
+		Measure:     BlockstoreScrubCorrupt,
+		Aggregation: view.Sum(),
+	}
+
 	// graphsync
 	GraphsyncReceivingPeersCountView = &view.View{
 		Measure:     GraphsyncReceivingPeersCount,
@@ -689,6 +742,11 @@ var ChainNodeViews = append([]*view.View{
 	ChainNodeHeightView,
 	ChainNodeHeightExpectedView,
 	ChainNodeWorkerHeightView,
+	ChainImportBlocksView,
+	ChainImportBytesView,
+	ChainStoreOpDurationView,
+	ChainStoreCacheLookupView,
+	ChainStoreReorgQueueView,
 	BlockReceivedView,
 	BlockValidationFailureView,
 	BlockValidationSuccessView,
@@ -720,6 +778,9 @@ var ChainNodeViews = append([]*view.View{
 	SplitstoreCompactionHotView,
 	SplitstoreCompactionColdView,
 	SplitstoreCompactionDeadView,
+	BlockstoreScrubbedView,
+	BlockstoreScrubCorruptView,
+	RPCRateLimitedView,
 	VMApplyBlocksTotalView,
 	VMApplyMessagesView,
 	VMApplyEarlyView,
@@ -94,6 +94,15 @@ const (
 
 	// filecoin
 	SetGenesisKey
+	SetReorgGuardKey
+	SetTipSetCacheKey
+	SetExecutionCacheKey
+	SetSpeculativeExecutionKey
+	SetSyncPipelineDepthKey
+	SetMpoolPriorityAddrsKey
+	RunHistoryPruningKey
+	RunBlockstoreScrubKey
+	RunAutoResyncKey
 
 	RunHelloKey
 	RunChainExchangeKey
@@ -121,6 +130,7 @@ const (
 	SettlePaymentChannelsKey
 	RunPeerTaggerKey
 	SetupFallbackBlockstoresKey
+	SetTrustedCheckpointKey
 	GoRPCServer
 
 	SetApiEndpointKey
@@ -13,7 +13,9 @@ import (
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
 	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/actorindex"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
@@ -22,6 +24,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/snapshots"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	rpcstmgr "github.com/filecoin-project/lotus/chain/stmgr/rpc"
 	"github.com/filecoin-project/lotus/chain/store"
@@ -31,6 +34,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/wallet/remotewallet"
 	raftcns "github.com/filecoin-project/lotus/lib/consensus/raft"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/lib/rpclimit"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
@@ -77,6 +81,7 @@ var ChainNode = Options(
 	Override(new(store.WeightFunc), filcns.Weight),
 	Override(new(stmgr.Executor), filcns.NewTipSetExecutor()),
 	Override(new(consensus.Consensus), filcns.NewFilecoinExpectedConsensus),
+	Override(new(dtypes.ChainColdBlockstore), modules.NoChainColdBlockstore),
 	Override(new(*store.ChainStore), modules.ChainStore),
 	Override(new(*stmgr.StateManager), modules.StateManager),
 	Override(new(dtypes.ChainBitswap), modules.ChainBitswap),
@@ -184,6 +189,10 @@ func ConfigFullNode(c interface{}) Option {
 		ConfigCommon(&cfg.Common, enableLibp2pNode),
 
 		Override(new(dtypes.UniversalBlockstore), modules.UniversalBlockstore),
+		If(cfg.Chainstore.Backend.Type != "",
+			Override(new(dtypes.UniversalBlockstore), modules.BackendBlockstore(&cfg.Chainstore.Backend))),
+		If(len(cfg.Chainstore.ReadReplicas) > 0,
+			Override(new(dtypes.UniversalBlockstore), modules.ReplicatedBlockstore(&cfg.Chainstore))),
 
 		If(cfg.Chainstore.EnableSplitstore,
 			If(cfg.Chainstore.Splitstore.ColdStoreType == "universal" || cfg.Chainstore.Splitstore.ColdStoreType == "messages",
@@ -205,16 +214,59 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(dtypes.BaseBlockstore), From(new(dtypes.UniversalBlockstore))),
 			Override(new(dtypes.ExposedBlockstore), From(new(dtypes.UniversalBlockstore))),
 			Override(new(dtypes.GCReferenceProtector), modules.NoopGCReferenceProtector),
+			Override(new(*gcsched.Scheduler), modules.BlockstoreGCScheduler(&cfg.Chainstore.BlockstoreGC)),
 		),
 
 		Override(new(dtypes.ChainBlockstore), From(new(dtypes.BasicChainBlockstore))),
 		Override(new(dtypes.StateBlockstore), From(new(dtypes.BasicStateBlockstore))),
+		If(cfg.Chainstore.BlockstoreCache.Policy != "",
+			Override(new(dtypes.ChainBlockstore), modules.CachedChainBlockstore(&cfg.Chainstore.BlockstoreCache)),
+		),
+		If(cfg.Chainstore.BlockstoreCache.Policy != "" || cfg.Chainstore.StateWriteBatching.Enable,
+			Override(new(dtypes.StateBlockstore), modules.StateBlockstore(&cfg.Chainstore)),
+		),
+
+		Override(new(exchange.Client), modules.ChainExchangeClient(&cfg.ChainExchange)),
+		Override(SetSyncPipelineDepthKey, modules.SyncPipelineDepth(&cfg.Sync)),
+		Override(new(stmgr.Executor), &filcns.TipSetExecutor{ParallelWorkers: cfg.Sync.ParallelExecutionWorkers}),
+
+		Override(new(*snapshots.Scheduler), modules.SnapshotScheduler(&cfg.Chainstore.Snapshots)),
+		Override(new(*actorindex.Index), modules.ActorIndex(&cfg.Chainstore.ActorIndex)),
+		Override(SetReorgGuardKey, modules.ReorgGuard(&cfg.Chainstore)),
+		Override(SetTipSetCacheKey, modules.TipSetCache(&cfg.Chainstore.TipSetCache)),
+		Override(SetExecutionCacheKey, modules.ExecutionCache(&cfg.Chainstore.ExecutionCache)),
+		// the blocks synced headers only can't be executed
+		If(cfg.Sync.SpeculativeExecution && !cfg.Chainstore.HeadersOnly,
+			Override(SetSpeculativeExecutionKey, modules.SpeculativeExecution),
+		),
+		Override(SetMpoolPriorityAddrsKey, modules.MpoolPriorityAddrs(&cfg.Mpool)),
+		Override(RunHistoryPruningKey, modules.HistoryPruning(&cfg.Chainstore.HistoryPruning)),
+		If(cfg.Chainstore.AutoResync.Source != "",
+			Override(RunAutoResyncKey, modules.AutoResync(&cfg.Chainstore.AutoResync)),
+		),
+		If(cfg.Chainstore.BlockstoreScrub.Fraction > 0,
+			Override(RunBlockstoreScrubKey, modules.BlockstoreScrub(&cfg.Chainstore.BlockstoreScrub)),
+		),
+		If(cfg.Chainstore.HistoryPruning.ColdStorePath != "",
+			Override(new(dtypes.ChainColdBlockstore), modules.BadgerChainColdBlockstore(&cfg.Chainstore.HistoryPruning)),
+		),
 
-		If(os.Getenv("LOTUS_ENABLE_CHAINSTORE_FALLBACK") == "1",
+		// the chain below a trusted checkpoint, and the state not executed
+		// by a headers-only node, are fetched on demand
+		If(os.Getenv("LOTUS_ENABLE_CHAINSTORE_FALLBACK") == "1" || len(cfg.Chainstore.TrustedCheckpoint.TipSet) > 0 || cfg.Chainstore.HeadersOnly,
 			Override(new(dtypes.ChainBlockstore), modules.FallbackChainBlockstore),
 			Override(new(dtypes.StateBlockstore), modules.FallbackStateBlockstore),
 			Override(SetupFallbackBlockstoresKey, modules.InitFallbackBlockstores),
 		),
+		If(len(cfg.Chainstore.TrustedCheckpoint.TipSet) > 0,
+			Override(SetTrustedCheckpointKey, modules.TrustedCheckpoint(&cfg.Chainstore.TrustedCheckpoint)),
+		),
+		If(cfg.Chainstore.HeadersOnly,
+			Override(new(*chain.Syncer), modules.HeadersOnlySyncer),
+		),
+
+		Override(new(dtypes.ReadOnlyAPI), dtypes.ReadOnlyAPI(cfg.APIReadOnly)),
+		Override(new(*rpclimit.Limiter), modules.RPCLimiter(&cfg.APIRateLimits)),
 
 		Override(new(dtypes.ClientImportMgr), modules.ClientImportMgr),
 
@@ -83,6 +83,9 @@ func DefaultFullNode() *FullNode {
 		Fees: FeeConfig{
 			DefaultMaxFee: DefaultDefaultMaxFee,
 		},
+		Mpool: Mpool{
+			PriorityAddrs: []string{},
+		},
 		Client: Client{
 			SimultaneousTransfersForStorage:   DefaultSimultaneousTransfers,
 			SimultaneousTransfersForRetrieval: DefaultSimultaneousTransfers,
@@ -95,9 +98,53 @@ func DefaultFullNode() *FullNode {
 				MarkSetType:   "badger",
 
 				HotStoreFullGCFrequency: 20,
+
+				ColdArchiveMaxFileSize: 4 << 30,
+			},
+			Snapshots: Snapshots{
+				RecentStateRoots: uint64(policy.ChainFinality),
+				SkipOldMessages:  true,
+				Compression:      "zstd",
+				Retain:           3,
+			},
+			AutoResync: AutoResync{
+				MaxLag: 900,
+				Grace:  Duration(time.Hour),
+			},
+			HistoryPruning: HistoryPruning{
+				Interval: Duration(24 * time.Hour),
+			},
+			BlockstoreGC: BlockstoreGC{
+				MaxLoad: 0.75,
+			},
+			BlockstoreScrub: BlockstoreScrub{
+				Interval: Duration(time.Hour),
+			},
+			BlockstoreCache: BlockstoreCache{
+				ChainBytes:    512 << 20,
+				StateBytes:    2 << 30,
+				MaxBlockBytes: 256 << 10,
+			},
+			StateWriteBatching: StateWriteBatching{
+				FlushInterval: Duration(5 * time.Second),
+				MaxBytes:      64 << 20,
+			},
+			TrustedCheckpoint: TrustedCheckpoint{
+				TipSet: []string{},
 			},
 		},
+		ChainExchange: ChainExchange{
+			LatencyWeight:   1,
+			BandwidthWeight: 1,
+			FailureWeight:   1,
+		},
+		Sync: Sync{
+			PipelineDepth: 1,
+		},
 		Cluster: *DefaultUserRaftConfig(),
+		APIRateLimits: APIRateLimits{
+			Burst: 10,
+		},
 	}
 }
 
@@ -32,18 +32,24 @@ func FromFile(path string, def interface{}) (interface{}, error) {
 
 // FromReader loads config from a reader instance.
 func FromReader(reader io.Reader, def interface{}) (interface{}, error) {
+	cfg, _, err := decode(reader, def)
+	return cfg, err
+}
+
+// decode is FromReader returning the metadata of the decoded keys too.
+func decode(reader io.Reader, def interface{}) (interface{}, toml.MetaData, error) {
 	cfg := def
-	_, err := toml.NewDecoder(reader).Decode(cfg)
+	md, err := toml.NewDecoder(reader).Decode(cfg)
 	if err != nil {
-		return nil, err
+		return nil, md, err
 	}
 
 	err = envconfig.Process("LOTUS", cfg)
 	if err != nil {
-		return nil, fmt.Errorf("processing env vars overrides: %s", err)
+		return nil, md, fmt.Errorf("processing env vars overrides: %s", err)
 	}
 
-	return cfg, nil
+	return cfg, md, nil
 }
 
 func ConfigUpdate(cfgCur, cfgDef interface{}, comment bool) ([]byte, error) {
@@ -25,8 +25,22 @@ type FullNode struct {
 	Client     Client
 	Wallet     Wallet
 	Fees       FeeConfig
+	Mpool      Mpool
 	Chainstore Chainstore
-	Cluster    UserRaftConfig
+	// ChainExchange configures the requests for chain data to other nodes,
+	// made by the syncer.
+	ChainExchange ChainExchange
+	Sync          Sync
+	Cluster       UserRaftConfig
+	// APIRateLimits limits the rate of the API calls of each client, so that
+	// a public endpoint can't be overwhelmed by a few clients.
+	APIRateLimits APIRateLimits
+	// APIReadOnly makes the API reject the calls needing more than the read
+	// permission, such as MpoolPush or WalletSign, whatever the permissions of
+	// the JWT token of the caller, so that the API can be exposed publicly.
+	// The lotus commands changing the node, e.g. 'lotus daemon stop', fail
+	// too then.
+	APIReadOnly bool
 }
 
 // // Common
@@ -568,6 +582,236 @@ type Pubsub struct {
 type Chainstore struct {
 	EnableSplitstore bool
 	Splitstore       Splitstore
+
+	Backend BlockstoreBackend
+	// ReadReplicas are read-only replicas of the blockstore, e.g. mirrors of a
+	// snapshot on network or object storage, that reads are spread over to
+	// increase read throughput. Writes only go to the blockstore; reads of the
+	// blocks missing from a replica fall back to it. Replicas are configured
+	// like the Backend, and opened read-only.
+	ReadReplicas []BlockstoreBackend
+
+	BlockstoreGC BlockstoreGC
+
+	BlockstoreScrub BlockstoreScrub
+
+	BlockstoreCache BlockstoreCache
+
+	StateWriteBatching StateWriteBatching
+
+	Snapshots Snapshots
+
+	ActorIndex ActorIndex
+
+	AutoResync AutoResync
+
+	HistoryPruning HistoryPruning
+
+	TipSetCache TipSetCache
+
+	ExecutionCache ExecutionCache
+
+	// TrustedCheckpoint is a tipset the node starts its chain from when its
+	// head is below it, e.g. on its first start, rather than syncing from
+	// genesis.
+	TrustedCheckpoint TrustedCheckpoint
+
+	// HeadersOnly syncs only the headers of the chain, validated without their
+	// messages or the execution of the state, for nodes monitoring the head,
+	// its weight and the participation of miners. The state the validation
+	// needs, such as the power table, is fetched from the network on demand and
+	// kept; so is the state read through the API, which can't be computed for
+	// the tipsets not executed by the network yet. Best used with a
+	// TrustedCheckpoint.
+	HeadersOnly bool
+
+	// ReorgConfirmDepth is the depth, in epochs, beyond which reorgs of the
+	// chain are held back, and an alert raised, until an operator accepts or
+	// rejects them with 'lotus chain reorg'. A value of 0 (default) lets every
+	// reorg through.
+	ReorgConfirmDepth uint64
+}
+
+type BlockstoreBackend struct {
+	// Type is the name of the blockstore backend storing the chain, in place of
+	// the badger blockstore of the repo. It can be "s3" to store the chain in an
+	// S3 compatible object store, "badger" for a badger blockstore out of the
+	// repo, or the name of another registered backend. An empty value (default)
+	// uses the badger blockstore of the repo.
+	Type string
+	// Params are the parameters of the backend. The s3 backend takes the url of
+	// the blocks (s3://bucket/prefix or gs://bucket/prefix), the endpoint of the
+	// object store, the cache-size (the number of blocks cached in memory) and
+	// the concurrency of batch requests. Its credentials are read from the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables. The
+	// badger backend takes the path of the blockstore, and readonly.
+	Params map[string]string
+}
+
+type BlockstoreGC struct {
+	// Interval is the time between the starts of the online garbage collection
+	// cycles of the blockstore, run in the background. A value of 0 (default)
+	// only runs the cycles started with 'lotus chain blockstore-gc start'. GC
+	// is left to the splitstore when it is enabled.
+	Interval Duration
+	// Windows are the times of the day, in the HH:MM-HH:MM format and local
+	// time, scheduled GC cycles run in, e.g. ["01:00-05:00"]. A cycle still
+	// running at the end of its window stops. An empty list allows GC at any
+	// time.
+	Windows []string
+	// MaxLoad is the one minute system load average per CPU above which GC
+	// waits for the load to go down; GC also waits while the node is syncing.
+	// A value of 0 doesn't throttle GC on the system load.
+	MaxLoad float64
+}
+
+type BlockstoreScrub struct {
+	// Fraction is the fraction of the stored blocks, sampled at random, that are
+	// read and re-hashed every Interval to catch the blocks corrupted on disk;
+	// corrupted blocks raise an alert. A value of 0 (default) disables the
+	// scrubber.
+	Fraction float64
+	// Interval is the time between the scrub passes; default is an hour.
+	Interval Duration
+	// Refetch fetches the corrupted blocks from the network, replacing the
+	// stored copies; the alert is resolved once they are all replaced.
+	Refetch bool
+}
+
+type BlockstoreCache struct {
+	// Policy is the eviction policy of the in-memory caches of the blocks of the
+	// chain and the state blockstores: "arc" keeps both the recently and the
+	// frequently read blocks, "s3-fifo" quickly evicts the blocks read only
+	// once. An empty value (default) disables the caches.
+	Policy string
+	// ChainBytes is the size of the blocks cached for the chain blockstore.
+	ChainBytes int64
+	// StateBytes is the size of the blocks cached for the state blockstore.
+	StateBytes int64
+	// MaxBlockBytes is the size of the largest blocks admitted into the caches,
+	// so that a few huge blocks don't evict many small ones. A value of 0 admits
+	// the blocks up to a hundredth of the size of the cache.
+	MaxBlockBytes int
+}
+
+type StateWriteBatching struct {
+	// Enable aggregates the writes of the state, such as those of the execution
+	// of the tipsets during sync, into large batches committed to the
+	// blockstore at once. Fewer commits mean fewer fsyncs, which speeds up the
+	// sync on spinning disks. The chain head is only persisted once the state
+	// computed up to it is committed.
+	Enable bool
+	// FlushInterval is the longest time state writes are pending for.
+	FlushInterval Duration
+	// MaxBytes is the size of the pending state writes committed at once.
+	MaxBytes int
+}
+
+type TipSetCache struct {
+	// Size is the number of historical tipsets cached by the chainstore. The
+	// cache adapts to keep both the recently and the frequently requested ones.
+	// A value of 0 (default) uses the LOTUS_CHAIN_TIPSET_CACHE env var, or 8192.
+	Size int
+	// RecentEpochs is the number of epochs below the highest cached tipset whose
+	// tipsets are all cached, besides the historical ones. A value of 0 (default)
+	// uses the LOTUS_CHAIN_TIPSET_CACHE_RECENT env var, or the chain finality.
+	RecentEpochs uint64
+}
+
+type ExecutionCache struct {
+	// Enable caches the state and receipts roots of the executed tipsets in
+	// the metadata store, so that the tipsets executed again, as when reorgs
+	// flip-flop between forks, or after restarts, aren't re-executed. The
+	// results are tagged with the version of lotus, and aren't reused by other
+	// versions.
+	Enable bool
+	// Lookback is the number of epochs below the latest executed tipset whose
+	// results are kept. A value of 0 (default) keeps the chain finality.
+	Lookback uint64
+}
+
+type TrustedCheckpoint struct {
+	// TipSet are the block cids of a tipset, trusted to be in the canonical
+	// chain, that a node whose head is below it starts from instead of syncing
+	// the chain from genesis. Only the headers above it are fetched and
+	// validated; the chain below it, such as the state tree, is fetched from the
+	// network on demand. An empty list (default) disables the checkpoint.
+	TipSet []string
+	// StateRoot is the parent state root of the trusted tipset, checked against
+	// the fetched headers.
+	StateRoot string
+}
+
+type HistoryPruning struct {
+	// Retention is the number of epochs of chain history, that is block
+	// headers, messages and receipts, kept below the head. Older history is
+	// pruned every Interval, leaving the state alone. It is at least the chain
+	// finality; a value of 0 (default) keeps all history.
+	//
+	// The genesis, the checkpoint and the roots of the snapshots exported by the
+	// node are always kept.
+	Retention uint64
+	// Interval is the time between history prunings.
+	Interval Duration
+	// ColdStorePath is the directory of a blockstore, usually on cheaper and
+	// slower storage, that the pruned history is moved to instead of being
+	// deleted. The chainstore reads the history missing from its blockstore
+	// from there. Relative paths are relative to the repo. An empty value
+	// (default) deletes the pruned history.
+	ColdStorePath string
+}
+
+type Snapshots struct {
+	// Interval is the number of epochs between snapshots exported automatically
+	// by the node; snapshots are taken at the tipsets whose height is a multiple
+	// of it. A value of 0 (default) disables automatic snapshots.
+	Interval uint64
+	// Destination is the directory snapshots are written to, or an s3://bucket/prefix
+	// or gs://bucket/prefix url to upload them to, using the credentials from the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+	Destination string
+	// Endpoint overrides the S3 compatible endpoint snapshots are uploaded to.
+	Endpoint string
+	// RecentStateRoots is the number of recent state roots included in snapshots.
+	RecentStateRoots uint64
+	// SkipOldMessages excludes the messages of tipsets older than the recent state
+	// roots from snapshots.
+	SkipOldMessages bool
+	// Compression is the compression of snapshots; one of "zstd" (default), "gzip"
+	// or "" for uncompressed CARs.
+	Compression string
+	// Retain is the number of snapshots to keep, older ones are deleted as new
+	// snapshots are exported; 0 keeps all snapshots.
+	Retain uint64
+}
+
+type ActorIndex struct {
+	// Enable builds, as the node follows the chain, an index of the changes of
+	// the actors at every epoch, which StateGetActor answers from for the
+	// tipsets within the indexed range instead of resolving the actors through
+	// the state tree.
+	Enable bool
+	// Backfill is the number of epochs below the head, when the index is
+	// started, that get indexed too.
+	Backfill uint64
+}
+
+type AutoResync struct {
+	// Source is the http(s) url, or the local path, of the latest trusted
+	// snapshot, that the node resyncs from when its head falls behind and fails
+	// to catch up. The snapshot is imported, and the syncer resumes from its
+	// head. An empty value (default) disables automatic resyncs.
+	Source string
+	// Digest is the http(s) url, or the local path, of the sha256sum of the
+	// snapshot at Source, checked before its head is taken. Empty skips the
+	// check.
+	Digest string
+	// MaxLag is the number of epochs the head may be behind the current time
+	// before the node is considered to have fallen behind.
+	MaxLag uint64
+	// Grace is how long the head may stay more than MaxLag epochs behind without
+	// the lag going down before the node is resynced.
+	Grace Duration
 }
 
 type Splitstore struct {
@@ -588,6 +832,21 @@ type Splitstore struct {
 	// A value of 0 disables, while a value 1 will do full GC in every compaction.
 	// Default is 20 (about once a week).
 	HotStoreFullGCFrequency uint64
+
+	// ColdArchiveDestination is the directory, relative to the repo, or the
+	// s3://bucket/prefix or gs://bucket/prefix url that the blocks moved to the
+	// coldstore are appended to, as rolling CAR files named
+	// cold-<boundary epoch>-<part>.car. Uploads use the credentials from the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables. An
+	// empty value (default) disables the archive.
+	ColdArchiveDestination string
+	// ColdArchiveEndpoint overrides the S3 compatible endpoint the archive is
+	// uploaded to.
+	ColdArchiveEndpoint string
+	// ColdArchiveMaxFileSize is the size in bytes after which an archive file is
+	// completed and the next one started; files are also completed at the end of
+	// every compaction. Default is 4GiB.
+	ColdArchiveMaxFileSize int64
 }
 
 // // Full Node
@@ -615,6 +874,66 @@ type Wallet struct {
 	DisableLocal  bool
 }
 
+type ChainExchange struct {
+	// The chain data is requested from the peers with the lowest expected
+	// cost per item first. The cost is the sum of the average time per item
+	// of the requests to the peer, the time to transfer an average item at
+	// its average bandwidth, and its failure rate times the average time to
+	// get a response, each weighed by its weight here. The peer scores are
+	// listed by 'lotus sync peers'.
+	LatencyWeight   float64
+	BandwidthWeight float64
+	FailureWeight   float64
+}
+
+type Sync struct {
+	// PipelineDepth is the number of consecutive tipsets validated at once
+	// while syncing, overlapping the execution of each tipset with the
+	// signature checks of the next ones and the persistence of the previous
+	// ones, which speeds up catching up with the chain. A depth of 1
+	// (default) validates the tipsets one at a time.
+	PipelineDepth int
+	// SpeculativeExecution executes the tipsets formed by the blocks arriving
+	// from the network as they arrive, before they are validated, so that the
+	// state of the tipset taken as the head is usually computed by the time
+	// the blocks mined on it are validated or mined. It costs the execution of
+	// the tipsets that don't become the head, and of the invalid blocks.
+	SpeculativeExecution bool
+	// ParallelExecutionWorkers is the number of groups of the messages of a
+	// block, sent from and to independent actors, executed at once. Only the
+	// tipsets before network version 16, executed by the legacy VM, are
+	// executed in parallel, which speeds up syncing the early chain; every
+	// later tipset is executed by the FVM, one message at a time. A value
+	// below 2 (default) disables parallel execution.
+	ParallelExecutionWorkers int
+}
+
+type APIRateLimits struct {
+	// PerToken is the number of API calls per second allowed to each JWT token,
+	// or to each IP address for the calls without a token. Calls over it fail
+	// with a "too many requests" error telling when to retry. The calls with
+	// the admin permission, such as those of the lotus commands, aren't
+	// limited. A value of 0 (default) doesn't limit the calls.
+	PerToken float64
+	// Burst is the number of calls allowed at once above each of the rates.
+	Burst int
+	// Methods are the number of calls per second allowed to each token to a
+	// method, by method name, e.g. "StateReplay", or to a group of methods, by
+	// the prefix of their names followed by '*', e.g. "State*". A method is
+	// limited by its name, else by its longest matching prefix. The calls count
+	// towards PerToken too.
+	Methods map[string]float64
+}
+
+type Mpool struct {
+	// PriorityAddrs are addresses, such as the control addresses of the
+	// miners of the node, whose messages are never pruned from the mpool
+	// under pressure, are selected first in the blocks produced by the node,
+	// and aren't limited like the others when received from the network. They
+	// are used along with the PriorityAddrs of 'lotus mpool config'.
+	PriorityAddrs []string
+}
+
 type FeeConfig struct {
 	DefaultMaxFee types.FIL
 }
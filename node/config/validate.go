@@ -0,0 +1,470 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multiaddr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Issue is a problem with a config file found by Validate.
+type Issue struct {
+	// Key is the dotted path of the key, as written in the file.
+	Key string
+	// Warning is set for the problems that don't change how the node runs,
+	// such as deprecated keys.
+	Warning bool
+	Message string
+}
+
+func (i Issue) String() string {
+	return i.Key + ": " + i.Message
+}
+
+// removedKeys are the keys not read anymore, with what replaced them.
+var removedKeys = map[string]string{
+	"Metrics": "metrics are always served on the /debug/metrics endpoint of the API",
+	"Chainstore.Splitstore.EnableFullCompaction": "full compactions are scheduled with HotStoreFullGCFrequency",
+	"Chainstore.Splitstore.EnableGC":             "the hotstore is always garbage collected, see HotStoreFullGCFrequency",
+	"Chainstore.Splitstore.Archival":             "set ColdStoreType to \"universal\" to keep all the chain state",
+	"Chainstore.Splitstore.HotHeaders":           "the hotstore always keeps the headers above the compaction boundary",
+}
+
+// deprecatedKeys are the keys still read, but going away, with what to do
+// instead.
+var deprecatedKeys = map[string]string{
+	"Client.UseIpfs":             "the IPFS integration of the client will be removed",
+	"Client.IpfsOnlineMode":      "the IPFS integration of the client will be removed",
+	"Client.IpfsMAddr":           "the IPFS integration of the client will be removed",
+	"Client.IpfsUseForRetrieval": "the IPFS integration of the client will be removed",
+}
+
+// Validate decodes the config read from reader over def, like FromReader, and
+// returns it along with the problems of the file: unknown keys, which the
+// decoder otherwise silently ignores leaving the defaults in place, removed
+// and deprecated keys, and values out of their range.
+func Validate(reader io.Reader, def interface{}) (interface{}, []Issue, error) {
+	cfg, md, err := decode(reader, def)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := map[string][]string{}
+	knownKeys(reflect.TypeOf(cfg), "", known)
+
+	var issues []Issue
+	var unknown []string
+	for _, k := range md.Undecoded() {
+		key := k.String()
+
+		// report unknown tables without each of their keys
+		if len(unknown) > 0 && strings.HasPrefix(key, unknown[len(unknown)-1]+".") {
+			continue
+		}
+		unknown = append(unknown, key)
+
+		if hint, ok := lookupKey(removedKeys, key); ok {
+			issues = append(issues, Issue{Key: key, Warning: true, Message: "removed and ignored, " + hint})
+			continue
+		}
+
+		msg := "unknown key, ignored"
+		if s := suggestKey(known, k[:len(k)-1].String(), k[len(k)-1]); s != "" {
+			msg += fmt.Sprintf(", did you mean %s?", s)
+		}
+		issues = append(issues, Issue{Key: key, Message: msg})
+	}
+
+	for _, k := range md.Keys() {
+		if hint, ok := lookupKey(deprecatedKeys, k.String()); ok {
+			issues = append(issues, Issue{Key: k.String(), Warning: true, Message: "deprecated, " + hint})
+		}
+	}
+
+	return cfg, append(issues, checkValues(cfg)...), nil
+}
+
+// lookupKey looks key up in keys, ignoring case like the decoder does.
+func lookupKey(keys map[string]string, key string) (string, bool) {
+	for k, v := range keys {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// knownKeys adds the keys of the tables of t under prefix to known, by the key
+// of their table.
+func knownKeys(t reflect.Type, prefix string, known map[string][]string) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			knownKeys(f.Type, prefix, known)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		known[prefix] = append(known[prefix], f.Name)
+
+		key := f.Name
+		if prefix != "" {
+			key = prefix + "." + f.Name
+		}
+		knownKeys(f.Type, key, known)
+	}
+}
+
+// suggestKey returns the key of the table the closest to name, if it is close
+// enough to be a typo of it.
+func suggestKey(known map[string][]string, table, name string) string {
+	var keys []string
+	for t, k := range known {
+		if strings.EqualFold(t, table) {
+			keys = k
+			break
+		}
+	}
+
+	best, bestDist := "", 3
+	for _, k := range keys {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// checkValues returns the values of cfg out of their range.
+func checkValues(cfg interface{}) []Issue {
+	var c checker
+	c.nonNegative(reflect.ValueOf(cfg), "")
+
+	switch cfg := cfg.(type) {
+	case *FullNode:
+		c.common(&cfg.Common)
+		c.fullNode(cfg)
+	case *StorageMiner:
+		c.common(&cfg.Common)
+		c.storageMiner(cfg)
+	}
+	return c.issues
+}
+
+type checker struct {
+	issues []Issue
+}
+
+func (c *checker) errorf(key, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) oneOf(key, v string, values ...string) {
+	for _, val := range values {
+		if v == val {
+			return
+		}
+	}
+	c.errorf(key, "unknown value %q, expected one of %q", v, values)
+}
+
+func (c *checker) multiaddrs(key string, addrs ...string) {
+	for _, a := range addrs {
+		if _, err := multiaddr.NewMultiaddr(a); err != nil {
+			c.errorf(key, "invalid multiaddr %q: %s", a, err)
+		}
+	}
+}
+
+// nonNegative checks that the durations and the FIL amounts under v aren't
+// negative.
+func (c *checker) nonNegative(v reflect.Value, key string) {
+	switch x := v.Interface().(type) {
+	case Duration:
+		if x < 0 {
+			c.errorf(key, "negative duration %s", time.Duration(x))
+		}
+		return
+	case types.FIL:
+		if x.Int != nil && big.Int(x).Sign() < 0 {
+			c.errorf(key, "negative amount %s", x)
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			c.nonNegative(v.Elem(), key)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			fkey := key
+			if !f.Anonymous {
+				fkey = strings.TrimPrefix(key+"."+f.Name, ".")
+			}
+			c.nonNegative(v.Field(i), fkey)
+		}
+	}
+}
+
+func (c *checker) common(cfg *Common) {
+	if cfg.API.ListenAddress != "" {
+		c.multiaddrs("API.ListenAddress", cfg.API.ListenAddress)
+	}
+
+	subs := make([]string, 0, len(cfg.Logging.SubsystemLevels))
+	for sub := range cfg.Logging.SubsystemLevels {
+		subs = append(subs, sub)
+	}
+	sort.Strings(subs)
+	for _, sub := range subs {
+		lvl := cfg.Logging.SubsystemLevels[sub]
+		if _, err := logging.LevelFromString(lvl); err != nil {
+			c.errorf("Logging.SubsystemLevels."+sub, "unknown log level %q", lvl)
+		}
+	}
+
+	c.multiaddrs("Libp2p.ListenAddresses", cfg.Libp2p.ListenAddresses...)
+	c.multiaddrs("Libp2p.AnnounceAddresses", cfg.Libp2p.AnnounceAddresses...)
+	c.multiaddrs("Libp2p.NoAnnounceAddresses", cfg.Libp2p.NoAnnounceAddresses...)
+	c.multiaddrs("Libp2p.BootstrapPeers", cfg.Libp2p.BootstrapPeers...)
+	if cfg.Libp2p.ConnMgrLow > cfg.Libp2p.ConnMgrHigh {
+		c.errorf("Libp2p.ConnMgrLow", "%d is above ConnMgrHigh (%d)", cfg.Libp2p.ConnMgrLow, cfg.Libp2p.ConnMgrHigh)
+	}
+}
+
+func (c *checker) fullNode(cfg *FullNode) {
+	cs := &cfg.Chainstore
+
+	c.oneOf("Chainstore.Splitstore.ColdStoreType", cs.Splitstore.ColdStoreType, "messages", "universal", "discard")
+	c.oneOf("Chainstore.Splitstore.HotStoreType", cs.Splitstore.HotStoreType, "badger")
+	c.oneOf("Chainstore.Splitstore.MarkSetType", cs.Splitstore.MarkSetType, "map", "badger")
+
+	for _, w := range cs.BlockstoreGC.Windows {
+		from, to, ok := strings.Cut(w, "-")
+		if ok {
+			_, ferr := time.Parse("15:04", from)
+			_, terr := time.Parse("15:04", to)
+			ok = ferr == nil && terr == nil
+		}
+		if !ok {
+			c.errorf("Chainstore.BlockstoreGC.Windows", "invalid window %q, expected HH:MM-HH:MM", w)
+		}
+	}
+	if cs.BlockstoreGC.MaxLoad < 0 {
+		c.errorf("Chainstore.BlockstoreGC.MaxLoad", "negative load %g", cs.BlockstoreGC.MaxLoad)
+	}
+
+	if f := cs.BlockstoreScrub.Fraction; f < 0 || f > 1 {
+		c.errorf("Chainstore.BlockstoreScrub.Fraction", "%g is not between 0 and 1", f)
+	}
+
+	c.oneOf("Chainstore.BlockstoreCache.Policy", cs.BlockstoreCache.Policy, "", "arc", "s3-fifo")
+	if cs.BlockstoreCache.ChainBytes < 0 {
+		c.errorf("Chainstore.BlockstoreCache.ChainBytes", "negative size %d", cs.BlockstoreCache.ChainBytes)
+	}
+	if cs.BlockstoreCache.StateBytes < 0 {
+		c.errorf("Chainstore.BlockstoreCache.StateBytes", "negative size %d", cs.BlockstoreCache.StateBytes)
+	}
+
+	c.oneOf("Chainstore.Snapshots.Compression", cs.Snapshots.Compression, "", "zstd", "gzip")
+
+	if r := cs.HistoryPruning.Retention; r != 0 && r < uint64(policy.ChainFinality) {
+		c.errorf("Chainstore.HistoryPruning.Retention", "%d is below the chain finality (%d)", r, policy.ChainFinality)
+	}
+
+	for _, w := range []struct {
+		key string
+		v   float64
+	}{
+		{"ChainExchange.LatencyWeight", cfg.ChainExchange.LatencyWeight},
+		{"ChainExchange.BandwidthWeight", cfg.ChainExchange.BandwidthWeight},
+		{"ChainExchange.FailureWeight", cfg.ChainExchange.FailureWeight},
+	} {
+		if w.v < 0 {
+			c.errorf(w.key, "negative weight %g", w.v)
+		}
+	}
+
+	if cfg.Sync.PipelineDepth < 1 {
+		c.errorf("Sync.PipelineDepth", "%d is below 1", cfg.Sync.PipelineDepth)
+	}
+	if cfg.Sync.ParallelExecutionWorkers < 0 {
+		c.errorf("Sync.ParallelExecutionWorkers", "negative number of workers %d", cfg.Sync.ParallelExecutionWorkers)
+	}
+
+	rl := cfg.APIRateLimits
+	if rl.PerToken < 0 {
+		c.errorf("APIRateLimits.PerToken", "negative rate %g", rl.PerToken)
+	}
+	if rl.Burst < 1 {
+		c.errorf("APIRateLimits.Burst", "%d is below 1", rl.Burst)
+	}
+	methods := make([]string, 0, len(rl.Methods))
+	for m := range rl.Methods {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	for _, m := range methods {
+		if r := rl.Methods[m]; r <= 0 {
+			c.errorf("APIRateLimits.Methods."+m, "rate %g is not positive", r)
+		}
+		if strings.Contains(strings.TrimSuffix(m, "*"), "*") {
+			c.errorf("APIRateLimits.Methods."+m, "'*' is only allowed at the end of method groups")
+		}
+	}
+}
+
+func (c *checker) storageMiner(cfg *StorageMiner) {
+	if rp := cfg.Dealmaking.RetrievalPricing; rp != nil {
+		c.oneOf("Dealmaking.RetrievalPricing.Strategy", rp.Strategy, RetrievalPricingDefaultMode, RetrievalPricingExternalMode)
+		if rp.Strategy == RetrievalPricingExternalMode && (rp.External == nil || rp.External.Path == "") {
+			c.errorf("Dealmaking.RetrievalPricing.External.Path", "missing the path of the external pricing script")
+		}
+	}
+
+	s := &cfg.Sealing
+	if s.BatchPreCommits && s.MaxPreCommitBatch < 1 {
+		c.errorf("Sealing.MaxPreCommitBatch", "%d is below 1 with BatchPreCommits", s.MaxPreCommitBatch)
+	}
+	if s.AggregateCommits && s.MinCommitBatch > s.MaxCommitBatch {
+		c.errorf("Sealing.MinCommitBatch", "%d is above MaxCommitBatch (%d)", s.MinCommitBatch, s.MaxCommitBatch)
+	}
+	if s.TerminateBatchMin > s.TerminateBatchMax {
+		c.errorf("Sealing.TerminateBatchMin", "%d is above TerminateBatchMax (%d)", s.TerminateBatchMin, s.TerminateBatchMax)
+	}
+
+	if cfg.Proving.ParallelCheckLimit < 0 {
+		c.errorf("Proving.ParallelCheckLimit", "negative limit %d", cfg.Proving.ParallelCheckLimit)
+	}
+}
+
+// Change is a key whose value differs from its default.
+type Change struct {
+	Key string
+	// Default and Current are the values encoded as JSON, empty when the key
+	// isn't set.
+	Default string
+	Current string
+}
+
+// Diff returns the keys whose value in cur differs from the one in def, sorted.
+func Diff(cur, def interface{}) ([]Change, error) {
+	curVals, err := flattenConfig(cur)
+	if err != nil {
+		return nil, xerrors.Errorf("flattening config: %w", err)
+	}
+	defVals, err := flattenConfig(def)
+	if err != nil {
+		return nil, xerrors.Errorf("flattening default config: %w", err)
+	}
+
+	var changes []Change
+	for k, v := range curVals {
+		if defVals[k] != v {
+			changes = append(changes, Change{Key: k, Default: defVals[k], Current: v})
+		}
+	}
+	for k, v := range defVals {
+		if _, ok := curVals[k]; !ok {
+			changes = append(changes, Change{Key: k, Default: v})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+// flattenConfig encodes cfg to TOML and returns the JSON encoded values of its
+// keys, by their dotted path.
+func flattenConfig(cfg interface{}) (map[string]string, error) {
+	b, err := ConfigUpdate(cfg, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if _, err := toml.Decode(string(b), &m); err != nil {
+		return nil, err
+	}
+
+	out := map[string]string{}
+	var flatten func(prefix string, v interface{}) error
+	flatten = func(prefix string, v interface{}) error {
+		if t, ok := v.(map[string]interface{}); ok {
+			for k, v := range t {
+				if err := flatten(strings.TrimPrefix(prefix+"."+k, "."), v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		out[prefix] = string(b)
+		return nil
+	}
+	return out, flatten("", m)
+}
@@ -0,0 +1,119 @@
+// stm: #unit
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		for _, def := range []func() interface{}{
+			func() interface{} { return DefaultFullNode() },
+			func() interface{} { return DefaultStorageMiner() },
+		} {
+			s, err := ConfigComment(def())
+			require.NoError(t, err)
+
+			_, issues, err := Validate(strings.NewReader(string(s)), def())
+			require.NoError(t, err)
+			require.Empty(t, issues)
+		}
+	})
+
+	t.Run("issues", func(t *testing.T) {
+		cfgString := `
+		[API]
+		Timeuot = "10s"
+
+		[Libp2p]
+		ConnMgrLow = 200
+		ConnMgrHigh = 100
+
+		[Client]
+		UseIpfs = true
+
+		[Chainstore.Splitstore]
+		Archival = true
+		ColdStoreType = "hot"
+
+		[Chainstore.BlockstoreScrub]
+		Fraction = 1.5
+
+		[Nonsense]
+		Foo = 1
+		Bar = 2
+		`
+
+		_, issues, err := Validate(strings.NewReader(cfgString), DefaultFullNode())
+		require.NoError(t, err)
+
+		byKey := map[string]Issue{}
+		for _, i := range issues {
+			byKey[i.Key] = i
+		}
+		require.Len(t, byKey, len(issues), "keys reported more than once")
+
+		require.Contains(t, byKey["API.Timeuot"].Message, "did you mean Timeout?")
+		require.False(t, byKey["API.Timeuot"].Warning)
+		require.Contains(t, byKey, "Nonsense")
+		require.True(t, byKey["Client.UseIpfs"].Warning)
+		require.True(t, byKey["Chainstore.Splitstore.Archival"].Warning)
+		require.Contains(t, byKey, "Libp2p.ConnMgrLow")
+		require.Contains(t, byKey, "Chainstore.Splitstore.ColdStoreType")
+		require.Contains(t, byKey, "Chainstore.BlockstoreScrub.Fraction")
+		require.Len(t, issues, 7)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		cfgString := `
+		[API]
+		Timeout = "-10s"
+		`
+
+		_, issues, err := Validate(strings.NewReader(cfgString), DefaultFullNode())
+		require.NoError(t, err)
+		require.Equal(t, []Issue{{Key: "API.Timeout", Message: "negative duration -10s"}}, issues)
+	})
+
+	t.Run("rate limits", func(t *testing.T) {
+		cfgString := `
+		[APIRateLimits]
+		Burst = 0
+		[APIRateLimits.Methods]
+		"State*" = 10.0
+		"Chain*Get" = 5.0
+		StateReplay = 0.0
+		`
+
+		_, issues, err := Validate(strings.NewReader(cfgString), DefaultFullNode())
+		require.NoError(t, err)
+		require.Equal(t, []Issue{
+			{Key: "APIRateLimits.Burst", Message: "0 is below 1"},
+			{Key: "APIRateLimits.Methods.Chain*Get", Message: "'*' is only allowed at the end of method groups"},
+			{Key: "APIRateLimits.Methods.StateReplay", Message: "rate 0 is not positive"},
+		}, issues)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	cfg := DefaultFullNode()
+	cfg.API.Timeout = Duration(10 * time.Second)
+	cfg.Chainstore.EnableSplitstore = !cfg.Chainstore.EnableSplitstore
+	cfg.Logging.SubsystemLevels = nil
+
+	changes, err := Diff(cfg, DefaultFullNode())
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Key: "API.Timeout", Default: `"30s"`, Current: `"10s"`},
+		{Key: "Chainstore.EnableSplitstore", Default: "false", Current: "true"},
+		{Key: "Logging.SubsystemLevels.example-subsystem", Default: `"INFO"`},
+	}, changes)
+
+	changes, err = Diff(DefaultFullNode(), DefaultFullNode())
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
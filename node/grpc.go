@@ -0,0 +1,115 @@
+package node
+
+import (
+	"context"
+	"strings"
+
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api/grpcapi"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/lib/rpclimit"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// ServeGRPC serves a gRPC server over the supplied listen multiaddr, like
+// ServeRPC does with HTTP handlers.
+func ServeGRPC(srv *grpc.Server, addr multiaddr.Multiaddr) (StopFunc, error) {
+	lst, err := manet.Listen(addr)
+	if err != nil {
+		return nil, xerrors.Errorf("could not listen: %w", err)
+	}
+
+	go func() {
+		if err := srv.Serve(manet.NetListener(lst)); err != nil {
+			rpclog.Warnf("grpc server failed: %s", err)
+		}
+	}()
+
+	return func(ctx context.Context) error {
+		// the streams of head changes only end when stopped
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			srv.Stop()
+		}
+		return nil
+	}, nil
+}
+
+// FullNodeGRPCServer returns a gRPC server of the grpcapi Lotus service,
+// serving a like FullNodeHandler serves its JSON-RPC API. The callers send
+// their JWT token in the authorization metadata, as "Bearer <token>".
+//
+// The supplied ID is used in tracing, like in ServeRPC.
+func FullNodeGRPCServer(a v1api.FullNode, id string, permissioned bool) *grpc.Server {
+	callContext := func(ctx context.Context) (context.Context, error) {
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				token = strings.TrimPrefix(v[0], "Bearer ")
+			}
+		}
+		if permissioned && token != "" {
+			perms, err := a.AuthVerify(ctx, token)
+			if err != nil {
+				return nil, status.Errorf(codes.Unauthenticated, "verifying JWT token: %s", err)
+			}
+			ctx = auth.WithPerm(ctx, perms)
+		}
+
+		var remote string
+		if p, ok := peer.FromContext(ctx); ok {
+			remote = p.Addr.String()
+		}
+		ctx = rpclimit.WithCaller(ctx, rpclimit.CallerID(token, remote))
+
+		return tag.New(ctx, tag.Upsert(metrics.APIInterface, id))
+	}
+
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := callContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := callContext(ss.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &callStream{ServerStream: ss, ctx: ctx})
+		}),
+	)
+	grpcapi.RegisterLotusServer(srv, grpcapi.NewServer(servedFullAPI(a, permissioned)))
+
+	return srv
+}
+
+// callStream is a server stream with the context of its calls.
+type callStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *callStream) Context() context.Context {
+	return s.ctx
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/lib/rpclimit"
 	"github.com/filecoin-project/lotus/node/impl/client"
 	"github.com/filecoin-project/lotus/node/impl/common"
 	"github.com/filecoin-project/lotus/node/impl/full"
@@ -38,6 +39,9 @@ type FullNodeAPI struct {
 
 	DS          dtypes.MetadataDS
 	NetworkName dtypes.NetworkName
+
+	ReadOnlyAPI dtypes.ReadOnlyAPI `optional:"true"`
+	RPCLimiter  *rpclimit.Limiter  `optional:"true"`
 }
 
 func (n *FullNodeAPI) CreateBackup(ctx context.Context, fpath string) error {
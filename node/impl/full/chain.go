@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +30,10 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
+	"github.com/filecoin-project/lotus/blockstore/usage"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/snapshots"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -36,6 +41,7 @@ import (
 	"github.com/filecoin-project/lotus/lib/oldpath"
 	"github.com/filecoin-project/lotus/lib/oldpath/oldresolver"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/repo"
 )
 
 var log = logging.Logger("fullnode")
@@ -87,6 +93,12 @@ type ChainAPI struct {
 
 	// BaseBlockstore is the underlying blockstore
 	BaseBlockstore dtypes.BaseBlockstore
+
+	Snapshots *snapshots.Scheduler `optional:"true"`
+
+	BlockstoreGC *gcsched.Scheduler `optional:"true"`
+
+	Repo repo.LockedRepo `optional:"true"`
 }
 
 func (m *ChainModule) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
@@ -365,6 +377,14 @@ func (a *ChainAPI) ChainTipSetWeight(ctx context.Context, tsk types.TipSetKey) (
 	return a.Chain.Weight(ctx, ts)
 }
 
+func (a *ChainAPI) ChainTipSetWeightComponents(ctx context.Context, tsk types.TipSetKey) (api.TipSetWeightComponents, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return api.TipSetWeightComponents{}, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return filcns.WeightComponents(ctx, a.Chain.StateBlockstore(), ts)
+}
+
 // This allows us to lookup string keys in the actor's adt.Map type.
 type stringKey string
 
@@ -587,17 +607,38 @@ func (m *ChainModule) ChainGetMessage(ctx context.Context, mc cid.Cid) (*types.M
 	return cm.VMMessage(), nil
 }
 
-func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipoldmsgs bool, tsk types.TipSetKey) (<-chan []byte, error) {
+func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipoldmsgs bool, tsk types.TipSetKey, opts api.ChainExportOpts) (<-chan []byte, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
 	r, w := io.Pipe()
+	bw := bufio.NewWriterSize(w, 1<<20)
+	cw, err := store.NewCompressedWriter(bw, opts.Compression, opts.CompressionLevel)
+	if err != nil {
+		return nil, err
+	}
+	var filter store.SnapshotFilter
+	if len(opts.Codecs) > 0 {
+		filter = store.SnapshotCodecs(opts.Codecs...)
+	}
+	throttle := store.ExportThrottle{
+		BlocksPerSec: opts.MaxBlocksPerSec,
+		BytesPerSec:  opts.MaxBytesPerSec,
+		PauseBehind:  opts.PauseBehind,
+	}
+
 	out := make(chan []byte)
 	go func() {
-		bw := bufio.NewWriterSize(w, 1<<20)
-
-		err := a.Chain.Export(ctx, ts, nroots, skipoldmsgs, bw)
+		var err error
+		if opts.RangeEnd != 0 {
+			err = a.Chain.ExportRangeFiltered(ctx, ts, opts.RangeStart, opts.RangeEnd, !opts.IncludeReceipts, filter, throttle, cw)
+		} else {
+			err = a.Chain.ExportFiltered(ctx, ts, nroots, skipoldmsgs, !opts.IncludeReceipts, filter, throttle, cw)
+		}
+		if cerr := cw.Close(); err == nil {
+			err = cerr
+		}
 		bw.Flush()            //nolint:errcheck // it is a write to a pipe
 		w.CloseWithError(err) //nolint:errcheck // it is a pipe
 	}()
@@ -636,6 +677,117 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 	return out, nil
 }
 
+func (a *ChainAPI) ChainExportEstimate(ctx context.Context, nroots abi.ChainEpoch, skipoldmsgs bool, tsk types.TipSetKey, opts api.ChainExportOpts) (api.ExportSizeEstimate, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return api.ExportSizeEstimate{}, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if len(opts.Codecs) > 0 || opts.RangeEnd != 0 {
+		return api.ExportSizeEstimate{}, xerrors.Errorf("estimating the size of chain segments or of exports filtered by codec is not supported")
+	}
+
+	return a.Chain.EstimateExportSize(ctx, ts, nroots, skipoldmsgs, !opts.IncludeReceipts)
+}
+
+func (a *ChainAPI) ChainExportProgress(ctx context.Context) (<-chan api.ExportProgress, error) {
+	return a.Chain.SubExportProgress(ctx), nil
+}
+
+func (a *ChainAPI) ChainSnapshotStatus(ctx context.Context) (api.SnapshotStatus, error) {
+	if a.Snapshots == nil {
+		return api.SnapshotStatus{}, nil
+	}
+	return a.Snapshots.Status(), nil
+}
+
+func (a *ChainAPI) ChainPruneHistory(ctx context.Context, opts api.PruneHistoryOpts) (api.PruneHistoryResult, error) {
+	var protect []types.TipSetKey
+	if a.Snapshots != nil {
+		protect = a.Snapshots.Roots()
+	}
+
+	head := a.Chain.GetHeaviestTipSet()
+	return a.Chain.PruneChain(ctx, store.PruneOpts{
+		Horizon: head.Height() - opts.Retention,
+		Protect: protect,
+		DryRun:  opts.DryRun,
+		Offload: opts.Offload,
+	})
+}
+
+func (a *ChainAPI) ChainGetForkTips(ctx context.Context) ([]api.ForkTip, error) {
+	return a.Chain.ForkTips(ctx)
+}
+
+func (a *ChainAPI) ChainGetBlockDAG(ctx context.Context, epochs abi.ChainEpoch) (*api.BlockDAG, error) {
+	return a.Chain.BlockDAG(ctx, epochs)
+}
+
+func (a *ChainAPI) ChainGetHeadJournal(ctx context.Context, q api.HeadJournalQuery) ([]api.HeadJournalEntry, error) {
+	return a.Chain.HeadJournal(ctx, q)
+}
+
+func (a *ChainAPI) ChainTipSetCacheStats(ctx context.Context) (api.TipSetCacheStats, error) {
+	return a.Chain.TipSetCacheStats(), nil
+}
+
+func (a *ChainAPI) ChainGetPendingReorg(ctx context.Context) (*api.PendingReorg, error) {
+	return a.Chain.PendingReorg(), nil
+}
+
+func (a *ChainAPI) ChainResolveReorg(ctx context.Context, to types.TipSetKey, accept bool) error {
+	return a.Chain.ResolveReorg(ctx, to, accept)
+}
+
+func (a *ChainAPI) ChainRestoreSnapshot(ctx context.Context, path string) (*types.TipSet, error) {
+	// the universal blockstore is swapped for the standby, with the
+	// splitstore it only is the cold store
+	if _, ok := a.BaseBlockstore.(*blockstore.SwapStore); !ok || a.Repo == nil {
+		return nil, xerrors.Errorf("base blockstore does not support restoring snapshots (%T)", a.BaseBlockstore)
+	}
+
+	var opts store.ImportOpts
+	var r io.Reader
+	if strings.HasSuffix(path, store.ManifestSuffix) {
+		sr, err := store.OpenShards(path)
+		if err != nil {
+			return nil, xerrors.Errorf("opening sharded snapshot: %w", err)
+		}
+		defer sr.Close() //nolint:errcheck
+
+		opts.Manifest = sr.Manifest()
+		r = sr
+	} else {
+		fi, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer fi.Close() //nolint:errcheck
+
+		if _, err := os.Stat(path + store.ManifestSuffix); err == nil {
+			opts.Manifest, err = store.ReadManifest(path + store.ManifestSuffix)
+			if err != nil {
+				return nil, xerrors.Errorf("loading manifest: %w", err)
+			}
+		}
+
+		dr, err := store.NewDecompressedReader(fi)
+		if err != nil {
+			return nil, err
+		}
+		defer dr.Close() //nolint:errcheck
+		r = dr
+	}
+
+	standby, err := a.Repo.StandbyBlockstore(ctx, repo.UniversalBlockstore)
+	if err != nil {
+		return nil, xerrors.Errorf("opening standby blockstore: %w", err)
+	}
+
+	log.Infow("restoring snapshot into standby blockstore", "path", path)
+	return a.Chain.RestoreSnapshot(ctx, r, standby, opts)
+}
+
 func (a *ChainAPI) ChainCheckBlockstore(ctx context.Context) error {
 	checker, ok := a.BaseBlockstore.(interface{ Check() error })
 	if !ok {
@@ -654,6 +806,105 @@ func (a *ChainAPI) ChainBlockstoreInfo(ctx context.Context) (map[string]interfac
 	return info.Info(), nil
 }
 
+func (a *ChainAPI) ChainBlockstoreUsage(ctx context.Context, top int) (api.BlockstoreUsage, error) {
+	var tiers []usage.Tier
+	if ss, ok := a.BaseBlockstore.(interface {
+		HotStore() blockstore.Blockstore
+		ColdStore() blockstore.Blockstore
+	}); ok {
+		tiers = append(tiers, usage.Tier{Name: "hot", Blockstore: ss.HotStore()}, usage.Tier{Name: "cold", Blockstore: ss.ColdStore()})
+	} else {
+		tiers = append(tiers, usage.Tier{Name: "universal", Blockstore: a.BaseBlockstore})
+	}
+	if cold := a.Chain.ColdStore(); cold != nil {
+		tiers = append(tiers, usage.Tier{Name: "chain history", Blockstore: cold})
+	}
+
+	return usage.Scan(ctx, tiers, top)
+}
+
+func (a *ChainAPI) ChainStateGarbage(ctx context.Context, opts api.StateGarbageOpts) (api.StateGarbage, error) {
+	return a.Chain.StateGarbage(ctx, opts)
+}
+
+func (a *ChainAPI) blockstoreGC() (*gcsched.Scheduler, error) {
+	if a.BlockstoreGC == nil {
+		return nil, xerrors.Errorf("blockstore garbage collection is not scheduled by this node")
+	}
+	return a.BlockstoreGC, nil
+}
+
+func (a *ChainAPI) ChainBlockstoreGC(ctx context.Context) error {
+	s, err := a.blockstoreGC()
+	if err != nil {
+		return err
+	}
+
+	// the cycle outlives the request
+	return s.Start(context.Background())
+}
+
+func (a *ChainAPI) ChainBlockstoreGCAbort(ctx context.Context) error {
+	s, err := a.blockstoreGC()
+	if err != nil {
+		return err
+	}
+
+	return s.Abort()
+}
+
+func (a *ChainAPI) ChainBlockstoreGCStatus(ctx context.Context) (api.BlockstoreGCStatus, error) {
+	s, err := a.blockstoreGC()
+	if err != nil {
+		return api.BlockstoreGCStatus{}, err
+	}
+
+	return s.Status(), nil
+}
+
+type compactionController interface {
+	CompactionProgress() api.SplitstoreCompactionProgress
+	PauseCompaction()
+	ResumeCompaction()
+}
+
+func (a *ChainAPI) compactionController() (compactionController, error) {
+	cc, ok := a.BaseBlockstore.(compactionController)
+	if !ok {
+		return nil, xerrors.Errorf("base blockstore does not support compaction (%T)", a.BaseBlockstore)
+	}
+	return cc, nil
+}
+
+func (a *ChainAPI) ChainSplitstoreCompactionProgress(ctx context.Context) (api.SplitstoreCompactionProgress, error) {
+	cc, err := a.compactionController()
+	if err != nil {
+		return api.SplitstoreCompactionProgress{}, err
+	}
+
+	return cc.CompactionProgress(), nil
+}
+
+func (a *ChainAPI) ChainSplitstorePauseCompaction(ctx context.Context) error {
+	cc, err := a.compactionController()
+	if err != nil {
+		return err
+	}
+
+	cc.PauseCompaction()
+	return nil
+}
+
+func (a *ChainAPI) ChainSplitstoreResumeCompaction(ctx context.Context) error {
+	cc, err := a.compactionController()
+	if err != nil {
+		return err
+	}
+
+	cc.ResumeCompaction()
+	return nil
+}
+
 func (a *ChainAPI) ChainPrune(ctx context.Context, opts api.PruneOpts) error {
 	pruner, ok := a.BaseBlockstore.(interface {
 		PruneChain(opts api.PruneOpts) error
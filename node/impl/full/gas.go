@@ -19,6 +19,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	lbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
@@ -126,11 +127,7 @@ func (m *GasModule) GasEstimateFeeCap(
 func gasEstimateFeeCap(cstore *store.ChainStore, msg *types.Message, maxqueueblks int64) (types.BigInt, error) {
 	ts := cstore.GetHeaviestTipSet()
 
-	parentBaseFee := ts.Blocks()[0].ParentBaseFee
-	increaseFactor := math.Pow(1.+1./float64(build.BaseFeeMaxChangeDenom), float64(maxqueueblks))
-
-	feeInFuture := types.BigMul(parentBaseFee, types.NewInt(uint64(increaseFactor*(1<<8))))
-	out := types.BigDiv(feeInFuture, types.NewInt(1<<8))
+	out := futureBaseFee(ts.Blocks()[0].ParentBaseFee, maxqueueblks)
 
 	if msg.GasPremium != types.EmptyInt {
 		out = types.BigAdd(out, msg.GasPremium)
@@ -139,6 +136,123 @@ func gasEstimateFeeCap(cstore *store.ChainStore, msg *types.Message, maxqueueblk
 	return out, nil
 }
 
+// futureBaseFee returns baseFee after maxqueueblks blocks of base fee
+// increases.
+func futureBaseFee(baseFee abi.TokenAmount, maxqueueblks int64) abi.TokenAmount {
+	increaseFactor := math.Pow(1.+1./float64(build.BaseFeeMaxChangeDenom), float64(maxqueueblks))
+
+	feeInFuture := types.BigMul(baseFee, types.NewInt(uint64(increaseFactor*(1<<8))))
+	return types.BigDiv(feeInFuture, types.NewInt(1<<8))
+}
+
+// DefaultFeePercentiles are the percentiles of GasEstimateFeeCapPercentile when
+// none are given.
+var DefaultFeePercentiles = []float64{25, 50, 90}
+
+// feePercentilesQueueBlocks is the number of blocks of base fee increases the
+// fee caps suggested by GasEstimateFeeCapPercentile allow for.
+const feePercentilesQueueBlocks = 20
+
+func (a *GasAPI) GasEstimateFeeCapPercentile(ctx context.Context, ntipsets uint64, percentiles []float64, tsk types.TipSetKey) (*api.GasFeePercentiles, error) {
+	if ntipsets == 0 {
+		ntipsets = 1
+	}
+	if ntipsets > uint64(policy.ChainFinality) {
+		return nil, xerrors.Errorf("cannot look back more than %d tipsets", policy.ChainFinality)
+	}
+	if len(percentiles) == 0 {
+		percentiles = DefaultFeePercentiles
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, xerrors.Errorf("percentile %f out of range [0, 100]", p)
+		}
+	}
+
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset: %w", err)
+	}
+	baseFee, err := a.Chain.ComputeBaseFee(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing base fee: %w", err)
+	}
+
+	out := &api.GasFeePercentiles{BaseFee: baseFee}
+
+	var premiums []GasMeta
+	for cur := ts; out.Tipsets < int(ntipsets) && cur.Height() > 0; out.Tipsets++ {
+		meta, err := inclusionPremiums(ctx, a.Chain, cur)
+		if err != nil {
+			return nil, err
+		}
+		premiums = append(premiums, meta...)
+
+		cur, err = a.Chain.LoadTipSet(ctx, cur.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+	out.Messages = len(premiums)
+
+	sort.Slice(premiums, func(i, j int) bool {
+		return premiums[i].Price.LessThan(premiums[j].Price)
+	})
+	for _, p := range percentiles {
+		premium := premiumPercentile(premiums, p)
+		out.Suggestions = append(out.Suggestions, api.GasFeeSuggestion{
+			Percentile: p,
+			GasPremium: premium,
+			GasFeeCap:  types.BigAdd(futureBaseFee(baseFee, feePercentilesQueueBlocks), premium),
+		})
+	}
+
+	return out, nil
+}
+
+// inclusionPremiums returns the premiums the messages of ts paid over the base
+// fee, along with their gas limits.
+func inclusionPremiums(ctx context.Context, cstore *store.ChainStore, ts *types.TipSet) ([]GasMeta, error) {
+	msgs, err := cstore.MessagesForTipset(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading messages: %w", err)
+	}
+
+	baseFee := ts.Blocks()[0].ParentBaseFee
+	out := make([]GasMeta, 0, len(msgs))
+	for _, msg := range msgs {
+		m := msg.VMMessage()
+		premium := big.Min(m.GasPremium, big.Sub(m.GasFeeCap, baseFee))
+		if premium.Sign() < 0 {
+			premium = big.Zero()
+		}
+		out = append(out, GasMeta{
+			Price: premium,
+			Limit: m.GasLimit,
+		})
+	}
+	return out, nil
+}
+
+// premiumPercentile returns the premium under which the given percentile of the
+// gas of premiums, sorted by price, was paid.
+func premiumPercentile(premiums []GasMeta, percentile float64) abi.TokenAmount {
+	var total int64
+	for _, p := range premiums {
+		total += p.Limit
+	}
+
+	at := int64(float64(total) * percentile / 100)
+	var cumulated int64
+	for _, p := range premiums {
+		cumulated += p.Limit
+		if cumulated >= at {
+			return p.Price
+		}
+	}
+	return big.Zero()
+}
+
 // finds 55th percntile instead of median to put negative pressure on gas price
 func medianGasPremium(prices []GasMeta, blocks int) abi.TokenAmount {
 	sort.Slice(prices, func(i, j int) bool {
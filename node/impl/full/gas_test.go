@@ -40,3 +40,20 @@ func TestMedian(t *testing.T) {
 		{big.NewInt(30), build.BlockGasTarget / 2},
 	}, 2))
 }
+
+func TestPremiumPercentile(t *testing.T) {
+	premiums := []GasMeta{
+		{big.NewInt(1), 10},
+		{big.NewInt(2), 30},
+		{big.NewInt(5), 50},
+		{big.NewInt(10), 10},
+	}
+
+	require.Equal(t, big.NewInt(1), premiumPercentile(premiums, 0))
+	require.Equal(t, big.NewInt(1), premiumPercentile(premiums, 10))
+	require.Equal(t, big.NewInt(2), premiumPercentile(premiums, 25))
+	require.Equal(t, big.NewInt(5), premiumPercentile(premiums, 50))
+	require.Equal(t, big.NewInt(5), premiumPercentile(premiums, 90))
+	require.Equal(t, big.NewInt(10), premiumPercentile(premiums, 100))
+	require.Equal(t, big.Zero(), premiumPercentile(nil, 50))
+}
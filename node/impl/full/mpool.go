@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 
 	"github.com/google/uuid"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/ipfs/go-cid"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/api"
@@ -49,6 +51,7 @@ type MpoolAPI struct {
 	MessageSigner messagesigner.MsgSigner
 
 	PushLocks *dtypes.MpoolLocker
+	GetMaxFee dtypes.DefaultMaxFeeFunc
 }
 
 func (a *MpoolAPI) MpoolGetConfig(context.Context) (*types.MpoolConfig, error) {
@@ -59,6 +62,11 @@ func (a *MpoolAPI) MpoolSetConfig(ctx context.Context, cfg *types.MpoolConfig) e
 	return a.Mpool.SetConfig(ctx, cfg)
 }
 
+func (a *MpoolAPI) MpoolGetRBFPolicy(ctx context.Context) (*api.MpoolRBFPolicy, error) {
+	p := a.Mpool.RBFPolicy()
+	return &p, nil
+}
+
 func (a *MpoolAPI) MpoolSelect(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) ([]*types.SignedMessage, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -250,6 +258,116 @@ func (a *MpoolAPI) MpoolBatchPush(ctx context.Context, smsgs []*types.SignedMess
 	return messageCids, nil
 }
 
+func (a *MpoolAPI) MpoolPushBatch(ctx context.Context, smsgs []*types.SignedMessage) (*api.MpoolPushBatchResult, error) {
+	errs, err := a.Mpool.PushBatch(ctx, smsgs, true)
+	if err != nil && !xerrors.Is(err, messagepool.ErrBatchRejected) {
+		return nil, err
+	}
+
+	res := &api.MpoolPushBatchResult{
+		Pushed:  err == nil,
+		Results: make([]api.MpoolPushResult, len(smsgs)),
+	}
+	for i, smsg := range smsgs {
+		res.Results[i].Cid = smsg.Cid()
+		if errs[i] != nil {
+			res.Results[i].Error = errs[i].Error()
+		}
+	}
+	return res, nil
+}
+
+func (a *MpoolAPI) MpoolRepricePending(ctx context.Context, addrs []address.Address, spec *api.MessageSendSpec) (*api.MpoolRepriceResult, error) {
+	if len(addrs) == 0 {
+		addrs = a.Mpool.LocalAddresses(ctx)
+	}
+	ratio := a.Mpool.GetConfig().ReplaceByFeeRatio
+
+	res := &api.MpoolRepriceResult{}
+	for _, addr := range addrs {
+		msgs, err := a.repriceSender(ctx, addr, ratio, spec)
+		if err != nil {
+			return nil, xerrors.Errorf("repricing the messages of %s: %w", addr, err)
+		}
+		res.Messages = append(res.Messages, msgs...)
+	}
+
+	compacted, err := a.Mpool.CompactLocal(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("compacting local messages: %w", err)
+	}
+	res.Compacted = compacted
+
+	return res, nil
+}
+
+// repriceSender reprices the pending messages of addr in the order of their
+// nonces.
+func (a *MpoolAPI) repriceSender(ctx context.Context, addr address.Address, ratio float64, spec *api.MessageSendSpec) ([]api.MpoolRepricedMessage, error) {
+	fromA, err := a.Stmgr.ResolveToKeyAddress(ctx, addr, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("getting key address: %w", err)
+	}
+	done, err := a.PushLocks.TakeLock(ctx, fromA)
+	if err != nil {
+		return nil, xerrors.Errorf("taking lock: %w", err)
+	}
+	defer done()
+
+	pending, _ := a.Mpool.PendingFor(ctx, fromA)
+
+	out := make([]api.MpoolRepricedMessage, 0, len(pending))
+	for _, old := range pending {
+		r := api.MpoolRepricedMessage{
+			From:       old.Message.From,
+			Nonce:      old.Message.Nonce,
+			Old:        old.Cid(),
+			GasPremium: old.Message.GasPremium,
+			GasFeeCap:  old.Message.GasFeeCap,
+		}
+
+		smsg, err := a.repriceMessage(ctx, old.Message, ratio, spec)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.New = smsg.Cid()
+			r.GasPremium = smsg.Message.GasPremium
+			r.GasFeeCap = smsg.Message.GasFeeCap
+		}
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+// repriceMessage signs and pushes msg with the gas parameters estimated again,
+// bumped to replace msg.
+func (a *MpoolAPI) repriceMessage(ctx context.Context, msg types.Message, ratio float64, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	minRBF := messagepool.ComputeRBF(msg.GasPremium, ratio)
+
+	msg.GasFeeCap = big.Zero()
+	msg.GasPremium = big.Zero()
+	est, err := a.GasEstimateMessageGas(ctx, &msg, spec, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("estimating gas: %w", err)
+	}
+
+	msg.GasPremium = big.Max(est.GasPremium, minRBF)
+	msg.GasFeeCap = big.Max(est.GasFeeCap, msg.GasPremium)
+	messagepool.CapGasFee(a.GetMaxFee, &msg, spec)
+
+	smsg, err := a.WalletSignMessage(ctx, msg.From, &msg)
+	if err != nil {
+		return nil, xerrors.Errorf("signing message: %w", err)
+	}
+
+	if _, err := a.Mpool.Push(ctx, smsg, true); err != nil {
+		return nil, xerrors.Errorf("pushing message: %w", err)
+	}
+
+	return smsg, nil
+}
+
 func (a *MpoolAPI) MpoolBatchPushUntrusted(ctx context.Context, smsgs []*types.SignedMessage) ([]cid.Cid, error) {
 	var messageCids []cid.Cid
 	for _, smsg := range smsgs {
@@ -290,6 +408,191 @@ func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uin
 	return a.Mpool.GetNonce(ctx, addr, types.EmptyTSK)
 }
 
+func (a *MpoolAPI) MpoolReserveNonce(ctx context.Context, addr address.Address, count uint64) (*api.NonceReservation, error) {
+	fromA, err := a.Stmgr.ResolveToKeyAddress(ctx, addr, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("getting key address: %w", err)
+	}
+	return a.MessageSigner.ReserveNonces(ctx, fromA, count)
+}
+
+func (a *MpoolAPI) MpoolReleaseNonce(ctx context.Context, id uuid.UUID, used uint64) error {
+	return a.MessageSigner.ReleaseNonces(ctx, id, used)
+}
+
+func (a *MpoolAPI) MpoolListNonceReservations(ctx context.Context) ([]api.NonceReservation, error) {
+	return a.MessageSigner.NonceReservations(ctx)
+}
+
+func (a *MpoolAPI) MpoolPendingTimes(ctx context.Context) ([]api.MpoolPendingTime, error) {
+	return a.Mpool.PendingTimes(), nil
+}
+
+func (a *MpoolAPI) MpoolJournalList(ctx context.Context, statuses []api.MpoolMessageStatus) ([]api.MpoolJournalEntry, error) {
+	return a.Mpool.LocalJournal(statuses...), nil
+}
+
+func (a *MpoolAPI) MpoolJournalRebroadcast(ctx context.Context, cids []cid.Cid) ([]cid.Cid, error) {
+	return a.Mpool.Rebroadcast(ctx, cids)
+}
+
 func (a *MpoolAPI) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
 	return a.Mpool.Updates(ctx)
 }
+
+func (a *MpoolAPI) MpoolSubFiltered(ctx context.Context, filter api.MpoolSubFilter) (<-chan api.MpoolUpdate, error) {
+	f, err := newMpoolSubFilter(ctx, filter, a.lookupID, a.actorCode)
+	if err != nil {
+		return nil, err
+	}
+	return a.Mpool.UpdatesFiltered(ctx, func(u api.MpoolUpdate) bool {
+		return f.match(ctx, &u.Message.Message)
+	})
+}
+
+func (a *MpoolAPI) lookupID(ctx context.Context, addr address.Address) (address.Address, error) {
+	return a.Stmgr.LookupID(ctx, addr, a.Chain.GetHeaviestTipSet())
+}
+
+func (a *MpoolAPI) actorCode(ctx context.Context, addr address.Address) (cid.Cid, error) {
+	act, err := a.Stmgr.LoadActor(ctx, addr, a.Chain.GetHeaviestTipSet())
+	if err != nil {
+		return cid.Undef, err
+	}
+	return act.Code, nil
+}
+
+// mpoolSubFilter matches the messages of mpool updates against an
+// api.MpoolSubFilter. The sets are nil for the fields not set.
+type mpoolSubFilter struct {
+	from, to map[address.Address]struct{}
+	methods  map[abi.MethodNum]struct{}
+	codes    map[cid.Cid]struct{}
+
+	lookupID  func(context.Context, address.Address) (address.Address, error)
+	actorCode func(context.Context, address.Address) (cid.Cid, error)
+
+	// ids caches the ID addresses of the addresses of the messages, and
+	// actorCodes the codes of their recipients
+	ids        *lru.Cache
+	actorCodes *lru.Cache
+}
+
+func newMpoolSubFilter(ctx context.Context, filter api.MpoolSubFilter,
+	lookupID func(context.Context, address.Address) (address.Address, error),
+	actorCode func(context.Context, address.Address) (cid.Cid, error)) (*mpoolSubFilter, error) {
+	ids, err := lru.New(1024)
+	if err != nil {
+		return nil, err
+	}
+	actorCodes, err := lru.New(1024)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &mpoolSubFilter{
+		lookupID:   lookupID,
+		actorCode:  actorCode,
+		ids:        ids,
+		actorCodes: actorCodes,
+	}
+
+	f.from = f.addrSet(ctx, filter.From)
+	f.to = f.addrSet(ctx, filter.To)
+	if len(filter.Methods) > 0 {
+		f.methods = make(map[abi.MethodNum]struct{}, len(filter.Methods))
+		for _, m := range filter.Methods {
+			f.methods[m] = struct{}{}
+		}
+	}
+	if len(filter.ActorCodes) > 0 {
+		f.codes = make(map[cid.Cid]struct{}, len(filter.ActorCodes))
+		for _, c := range filter.ActorCodes {
+			f.codes[c] = struct{}{}
+		}
+	}
+	return f, nil
+}
+
+// addrSet returns the set of addrs, and of their ID addresses.
+func (f *mpoolSubFilter) addrSet(ctx context.Context, addrs []address.Address) map[address.Address]struct{} {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	set := make(map[address.Address]struct{}, 2*len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+		if id, ok := f.id(ctx, addr); ok {
+			set[id] = struct{}{}
+		}
+	}
+	return set
+}
+
+func (f *mpoolSubFilter) match(ctx context.Context, m *types.Message) bool {
+	if f.methods != nil {
+		if _, ok := f.methods[m.Method]; !ok {
+			return false
+		}
+	}
+	if f.from != nil && !f.matchAddr(ctx, f.from, m.From) {
+		return false
+	}
+	if f.to != nil && !f.matchAddr(ctx, f.to, m.To) {
+		return false
+	}
+	if f.codes != nil {
+		code, ok := f.code(ctx, m.To)
+		if !ok {
+			return false
+		}
+		if _, ok := f.codes[code]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *mpoolSubFilter) matchAddr(ctx context.Context, set map[address.Address]struct{}, addr address.Address) bool {
+	if _, ok := set[addr]; ok {
+		return true
+	}
+	id, ok := f.id(ctx, addr)
+	if !ok {
+		return false
+	}
+	_, ok = set[id]
+	return ok
+}
+
+// id returns the ID address of addr, if it has one.
+func (f *mpoolSubFilter) id(ctx context.Context, addr address.Address) (address.Address, bool) {
+	if addr.Protocol() == address.ID {
+		return addr, true
+	}
+	if id, ok := f.ids.Get(addr); ok {
+		return id.(address.Address), true
+	}
+
+	id, err := f.lookupID(ctx, addr)
+	if err != nil {
+		return address.Undef, false
+	}
+	f.ids.Add(addr, id)
+	return id, true
+}
+
+// code returns the code of the actor at addr, if it exists.
+func (f *mpoolSubFilter) code(ctx context.Context, addr address.Address) (cid.Cid, bool) {
+	if c, ok := f.actorCodes.Get(addr); ok {
+		return c.(cid.Cid), true
+	}
+
+	c, err := f.actorCode(ctx, addr)
+	if err != nil {
+		return cid.Undef, false
+	}
+	f.actorCodes.Add(addr, c)
+	return c, true
+}
@@ -0,0 +1,106 @@
+// stm: #unit
+package full
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestMpoolSubFilter(t *testing.T) {
+	ctx := context.Background()
+
+	robust, err := address.NewActorAddress([]byte("sender"))
+	require.NoError(t, err)
+	unknown, err := address.NewActorAddress([]byte("unknown"))
+	require.NoError(t, err)
+	id, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+
+	minerCode, err := abi.CidBuilder.Sum([]byte("miner"))
+	require.NoError(t, err)
+	accountCode, err := abi.CidBuilder.Sum([]byte("account"))
+	require.NoError(t, err)
+
+	lookups := 0
+	lookupID := func(_ context.Context, addr address.Address) (address.Address, error) {
+		lookups++
+		if addr == robust {
+			return id, nil
+		}
+		return address.Undef, xerrors.Errorf("actor not found")
+	}
+	actorCode := func(_ context.Context, addr address.Address) (cid.Cid, error) {
+		switch addr {
+		case miner:
+			return minerCode, nil
+		case id, robust:
+			return accountCode, nil
+		}
+		return cid.Undef, xerrors.Errorf("actor not found")
+	}
+
+	msg := func(from, to address.Address, method abi.MethodNum) *types.Message {
+		return &types.Message{From: from, To: to, Method: method}
+	}
+
+	tests := []struct {
+		name   string
+		filter api.MpoolSubFilter
+		match  []*types.Message
+		skip   []*types.Message
+	}{{
+		name:   "empty",
+		filter: api.MpoolSubFilter{},
+		match:  []*types.Message{msg(robust, miner, 0), msg(unknown, unknown, 5)},
+	}, {
+		name:   "from id",
+		filter: api.MpoolSubFilter{From: []address.Address{id}},
+		match:  []*types.Message{msg(robust, miner, 0), msg(id, miner, 0)},
+		skip:   []*types.Message{msg(unknown, miner, 0), msg(miner, robust, 0)},
+	}, {
+		name:   "to robust",
+		filter: api.MpoolSubFilter{To: []address.Address{robust}},
+		match:  []*types.Message{msg(miner, id, 0), msg(miner, robust, 0)},
+		skip:   []*types.Message{msg(robust, miner, 0)},
+	}, {
+		name:   "methods and codes",
+		filter: api.MpoolSubFilter{Methods: []abi.MethodNum{5, 6}, ActorCodes: []cid.Cid{minerCode}},
+		match:  []*types.Message{msg(robust, miner, 5), msg(unknown, miner, 6)},
+		skip:   []*types.Message{msg(robust, miner, 0), msg(miner, robust, 5), msg(robust, unknown, 5)},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newMpoolSubFilter(ctx, tt.filter, lookupID, actorCode)
+			require.NoError(t, err)
+
+			for _, m := range tt.match {
+				require.True(t, f.match(ctx, m), "%s -> %s method %d", m.From, m.To, m.Method)
+			}
+			for _, m := range tt.skip {
+				require.False(t, f.match(ctx, m), "%s -> %s method %d", m.From, m.To, m.Method)
+			}
+		})
+	}
+
+	// the ID addresses found are cached
+	f, err := newMpoolSubFilter(ctx, api.MpoolSubFilter{From: []address.Address{id}}, lookupID, actorCode)
+	require.NoError(t, err)
+	lookups = 0
+	for i := 0; i < 3; i++ {
+		require.True(t, f.match(ctx, msg(robust, miner, 0)))
+	}
+	require.Equal(t, 1, lookups)
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
 	"github.com/libp2p/go-libp2p/core/peer"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.uber.org/fx"
@@ -24,11 +26,13 @@ import (
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/filecoin-project/go-state-types/network"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
 	market2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
 	market5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/market"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actorindex"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/datacap"
@@ -82,6 +86,7 @@ type StateModule struct {
 
 	StateManager *stmgr.StateManager
 	Chain        *store.ChainStore
+	ActorIndex   *actorindex.Index `optional:"true"`
 }
 
 var _ StateModuleAPI = (*StateModule)(nil)
@@ -122,6 +127,94 @@ func (a *StateAPI) StateMinerSectors(ctx context.Context, addr address.Address,
 	return mas.LoadSectors(sectorNos)
 }
 
+func (a *StateAPI) StateMinersSectors(ctx context.Context, query *api.MinersSectorsQuery, tsk types.TipSetKey) (*api.MinersSectorsResult, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if query == nil {
+		query = &api.MinersSectorsQuery{}
+	}
+	limit := query.Limit
+	if limit <= 0 || limit > api.MinersSectorsMaxLimit {
+		limit = api.MinersSectorsMaxLimit
+	}
+	var start api.MinersSectorsCursor
+	if query.Cursor != nil {
+		start = *query.Cursor
+	}
+	if start.Miner < 0 {
+		return nil, xerrors.Errorf("invalid cursor miner index %d", start.Miner)
+	}
+
+	store := a.Chain.ActorStore(ctx)
+	st, err := state.LoadStateTree(store, ts.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	res := &api.MinersSectorsResult{}
+	count := 0
+	for mi := start.Miner; mi < len(query.Miners); mi++ {
+		maddr := query.Miners[mi]
+		act, err := st.GetActor(maddr)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load miner actor %s: %w", maddr, err)
+		}
+		mas, err := miner.Load(store, act)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load miner actor state %s: %w", maddr, err)
+		}
+		allocated, err := mas.GetAllocatedSectors()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load allocated sectors of %s: %w", maddr, err)
+		}
+		it, err := allocated.BitIterator()
+		if err != nil {
+			return nil, xerrors.Errorf("iterating allocated sectors of %s: %w", maddr, err)
+		}
+
+		var from abi.SectorNumber
+		if mi == start.Miner {
+			from = start.Sector
+		}
+		ms := api.MinerSectorInfos{Miner: maddr}
+		for it.HasNext() {
+			n, err := it.Next()
+			if err != nil {
+				return nil, xerrors.Errorf("iterating allocated sectors of %s: %w", maddr, err)
+			}
+			sno := abi.SectorNumber(n)
+			if sno < from {
+				continue
+			}
+			if count == limit {
+				res.Next = &api.MinersSectorsCursor{Miner: mi, Sector: sno}
+				break
+			}
+
+			// allocated numbers of sectors not proven yet or removed have no sector
+			info, err := mas.GetSector(sno)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to load sector %d of %s: %w", sno, maddr, err)
+			}
+			if info == nil {
+				continue
+			}
+			ms.Sectors = append(ms.Sectors, info)
+			count++
+		}
+		if len(ms.Sectors) > 0 {
+			res.Miners = append(res.Miners, ms)
+		}
+		if res.Next != nil {
+			break
+		}
+	}
+
+	return res, nil
+}
+
 func (a *StateAPI) StateMinerActiveSectors(ctx context.Context, maddr address.Address, tsk types.TipSetKey) ([]*miner.SectorOnChainInfo, error) { // TODO: only used in cli
 	act, err := a.StateManager.LoadActorTsk(ctx, maddr, tsk)
 	if err != nil {
@@ -311,6 +404,87 @@ func (m *StateModule) StateMinerProvingDeadline(ctx context.Context, addr addres
 	return di.NextNotElapsed(), nil
 }
 
+func (a *StateAPI) StateMinerProvingSchedule(ctx context.Context, addr address.Address, epochs abi.ChainEpoch, tsk types.TipSetKey) ([]api.ProvingWindow, error) {
+	if epochs < 0 || epochs > api.ProvingScheduleMaxEpochs {
+		return nil, xerrors.Errorf("epochs %d out of range [0, %d]", epochs, api.ProvingScheduleMaxEpochs)
+	}
+
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	act, err := a.StateManager.LoadActor(ctx, addr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor: %w", err)
+	}
+
+	mas, err := miner.Load(a.StateManager.ChainStore().ActorStore(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor state: %w", err)
+	}
+
+	// the partitions proven at the windows of a deadline in the current state
+	var deadlines []api.ProvingWindow
+	if err := mas.ForEachDeadline(func(idx uint64, dl miner.Deadline) error {
+		w := api.ProvingWindow{Deadline: idx}
+		err := dl.ForEachPartition(func(_ uint64, part miner.Partition) error {
+			live, err := part.LiveSectors()
+			if err != nil {
+				return err
+			}
+			lc, err := live.Count()
+			if err != nil {
+				return err
+			}
+			if lc == 0 {
+				return nil
+			}
+			faulty, err := part.FaultySectors()
+			if err != nil {
+				return err
+			}
+			fc, err := faulty.Count()
+			if err != nil {
+				return err
+			}
+
+			w.Partitions++
+			w.LiveSectors += lc
+			w.FaultySectors += fc
+			return nil
+		})
+		deadlines = append(deadlines, w)
+		return err
+	}); err != nil {
+		return nil, xerrors.Errorf("loading deadlines: %w", err)
+	}
+
+	di, err := mas.DeadlineInfo(ts.Height())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get deadline info: %w", err)
+	}
+	di = di.NextNotElapsed()
+
+	var out []api.ProvingWindow
+	for end := ts.Height() + epochs; di.Open <= end; {
+		if di.Index >= uint64(len(deadlines)) {
+			return nil, xerrors.Errorf("deadline %d out of the %d deadlines of the miner", di.Index, len(deadlines))
+		}
+		w := deadlines[di.Index]
+		w.Open, w.Close, w.Challenge, w.FaultCutoff = di.Open, di.Close, di.Challenge, di.FaultCutoff
+		out = append(out, w)
+
+		periodStart, idx := di.PeriodStart, di.Index+1
+		if idx == di.WPoStPeriodDeadlines {
+			periodStart, idx = periodStart+di.WPoStProvingPeriod, 0
+		}
+		di = dline.NewInfo(periodStart, idx, ts.Height(), di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff)
+	}
+
+	return out, nil
+}
+
 func (a *StateAPI) StateMinerFaults(ctx context.Context, addr address.Address, tsk types.TipSetKey) (bitfield.BitField, error) {
 	act, err := a.StateManager.LoadActorTsk(ctx, addr, tsk)
 	if err != nil {
@@ -401,9 +575,16 @@ func (m *StateModule) StateMinerPower(ctx context.Context, addr address.Address,
 }
 
 func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.TipSetKey) (res *api.InvocResult, err error) {
-	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	res, _, err = a.call(ctx, msg, tsk)
+	return res, err
+}
+
+// call runs msg on the parent state of the tipset of tsk, or of its first
+// ancestor not followed by an expensive fork, and returns the tipset used.
+func (a *StateAPI) call(ctx context.Context, msg *types.Message, tsk types.TipSetKey) (res *api.InvocResult, ts *types.TipSet, err error) {
+	ts, err = a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
-		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+		return nil, nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
 	for {
 		res, err = a.StateManager.Call(ctx, msg, ts)
@@ -412,46 +593,53 @@ func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.
 		}
 		ts, err = a.Chain.GetTipSetFromKey(ctx, ts.Parents())
 		if err != nil {
-			return nil, xerrors.Errorf("getting parent tipset: %w", err)
+			return nil, nil, xerrors.Errorf("getting parent tipset: %w", err)
 		}
 	}
-	return res, err
+	return res, ts, err
 }
 
 func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
+	res, _, err := a.replay(ctx, tsk, mc)
+	return res, err
+}
+
+// replay replays mc in the tipset of tsk, or in the tipset it was included
+// in when tsk is empty, and returns the tipset used.
+func (a *StateAPI) replay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, *types.TipSet, error) {
 	msgToReplay := mc
 	var ts *types.TipSet
 	var err error
 	if tsk == types.EmptyTSK {
 		mlkp, err := a.StateSearchMsg(ctx, types.EmptyTSK, mc, stmgr.LookbackNoLimit, true)
 		if err != nil {
-			return nil, xerrors.Errorf("searching for msg %s: %w", mc, err)
+			return nil, nil, xerrors.Errorf("searching for msg %s: %w", mc, err)
 		}
 		if mlkp == nil {
-			return nil, xerrors.Errorf("didn't find msg %s", mc)
+			return nil, nil, xerrors.Errorf("didn't find msg %s", mc)
 		}
 
 		msgToReplay = mlkp.Message
 
 		executionTs, err := a.Chain.GetTipSetFromKey(ctx, mlkp.TipSet)
 		if err != nil {
-			return nil, xerrors.Errorf("loading tipset %s: %w", mlkp.TipSet, err)
+			return nil, nil, xerrors.Errorf("loading tipset %s: %w", mlkp.TipSet, err)
 		}
 
 		ts, err = a.Chain.LoadTipSet(ctx, executionTs.Parents())
 		if err != nil {
-			return nil, xerrors.Errorf("loading parent tipset %s: %w", mlkp.TipSet, err)
+			return nil, nil, xerrors.Errorf("loading parent tipset %s: %w", mlkp.TipSet, err)
 		}
 	} else {
 		ts, err = a.Chain.LoadTipSet(ctx, tsk)
 		if err != nil {
-			return nil, xerrors.Errorf("loading specified tipset %s: %w", tsk, err)
+			return nil, nil, xerrors.Errorf("loading specified tipset %s: %w", tsk, err)
 		}
 	}
 
 	m, r, err := a.StateManager.Replay(ctx, ts, msgToReplay)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var errstr string
@@ -467,14 +655,169 @@ func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.
 		ExecutionTrace: r.ExecutionTrace,
 		Error:          errstr,
 		Duration:       r.Duration,
+	}, ts, nil
+}
+
+func (a *StateAPI) StateDebugCall(ctx context.Context, msg *types.Message, bps []api.Breakpoint, tsk types.TipSetKey) (*api.DebugResult, error) {
+	res, ts, err := a.call(ctx, msg, tsk)
+	if err != nil {
+		return nil, err
+	}
+	return a.debugInvocation(res, ts, bps)
+}
+
+func (a *StateAPI) StateDebugReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid, bps []api.Breakpoint) (*api.DebugResult, error) {
+	res, ts, err := a.replay(ctx, tsk, mc)
+	if err != nil {
+		return nil, err
+	}
+	return a.debugInvocation(res, ts, bps)
+}
+
+// debugInvocation looks for the calls matching bps in the execution trace of
+// res, computed on the parent state of ts.
+func (a *StateAPI) debugInvocation(res *api.InvocResult, ts *types.TipSet, bps []api.Breakpoint) (*api.DebugResult, error) {
+	st, err := a.StateManager.ParentState(ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent state of %s: %w", ts.Key(), err)
+	}
+	ar := a.TsExec.NewActorRegistry()
+
+	d := &breakpointDebugger{
+		bps: bps,
+		lookup: func(addr address.Address) (address.Address, cid.Cid) {
+			act, err := st.GetActor(addr)
+			if err != nil {
+				return addr, cid.Undef
+			}
+			if id, err := st.LookupID(addr); err == nil {
+				addr = id
+			}
+			return addr, act.Code
+		},
+		decode: func(code cid.Cid, method abi.MethodNum, params []byte) interface{} {
+			if !code.Defined() || len(params) == 0 {
+				return nil
+			}
+			decoded, err := stmgr.DecodeParams(ar, code, method, params)
+			if err != nil {
+				return nil
+			}
+			return decoded
+		},
+	}
+	hits, truncated := d.run(res.ExecutionTrace)
+	return &api.DebugResult{
+		Result:    res,
+		Hits:      hits,
+		Truncated: truncated,
 	}, nil
 }
 
+// breakpointDebugger walks execution traces for the calls matching its
+// breakpoints. Actors are compared by ID address when lookup resolves them.
+type breakpointDebugger struct {
+	bps []api.Breakpoint
+	// lookup returns the ID address and code of an actor of the state the
+	// message was executed on, addr and cid.Undef if it has no actor there
+	lookup func(addr address.Address) (address.Address, cid.Cid)
+	// decode returns the params decoded, nil if they can't be
+	decode func(code cid.Cid, method abi.MethodNum, params []byte) interface{}
+
+	hits      []api.BreakpointHit
+	truncated bool
+}
+
+func (d *breakpointDebugger) run(et types.ExecutionTrace) ([]api.BreakpointHit, bool) {
+	d.hits, d.truncated = nil, false
+	addrs := make([]address.Address, len(d.bps))
+	for i, bp := range d.bps {
+		if bp.Actor != address.Undef {
+			addrs[i], _ = d.lookup(bp.Actor)
+		}
+	}
+	d.walk(et, addrs, nil)
+	return d.hits, d.truncated
+}
+
+func (d *breakpointDebugger) walk(et types.ExecutionTrace, addrs []address.Address, stack []api.InvocationFrame) {
+	if et.Msg == nil || d.truncated {
+		return
+	}
+	receiver, code := d.lookup(et.Msg.To)
+	frame := api.InvocationFrame{
+		Depth:         len(stack),
+		Caller:        et.Msg.From,
+		Receiver:      et.Msg.To,
+		Code:          code,
+		Method:        et.Msg.Method,
+		Params:        et.Msg.Params,
+		DecodedParams: d.decode(code, et.Msg.Method, et.Msg.Params),
+		Value:         et.Msg.Value,
+		GasLimit:      et.Msg.GasLimit,
+	}
+	if et.MsgRct != nil {
+		frame.ExitCode, frame.Return, frame.GasUsed = et.MsgRct.ExitCode, et.MsgRct.Return, et.MsgRct.GasUsed
+	}
+
+	for i, bp := range d.bps {
+		if !breakpointMatches(bp, addrs[i], receiver, code, et.Msg.Method) {
+			continue
+		}
+		if len(d.hits) >= api.DebugMaxHits {
+			d.truncated = true
+			return
+		}
+		d.hits = append(d.hits, api.BreakpointHit{
+			Breakpoint: i,
+			Frame:      frame,
+			Stack:      append([]api.InvocationFrame(nil), stack...),
+		})
+		break
+	}
+
+	stack = append(stack, frame)
+	for _, sub := range et.Subcalls {
+		d.walk(sub, addrs, stack)
+	}
+}
+
+func breakpointMatches(bp api.Breakpoint, actor, receiver address.Address, code cid.Cid, method abi.MethodNum) bool {
+	if actor != address.Undef && actor != receiver {
+		return false
+	}
+	if bp.Code.Defined() && bp.Code != code {
+		return false
+	}
+	if len(bp.Methods) == 0 {
+		return true
+	}
+	for _, m := range bp.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (a *types.Actor, err error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
+
+	if m.ActorIndex != nil && ts.Height() < m.Chain.GetHeaviestTipSet().Height() {
+		act, ok, err := m.ActorIndex.Lookup(ctx, actor, ts)
+		if err != nil {
+			log.Warnf("looking up actor %s in the actor index: %s", actor, err)
+		} else if ok {
+			if act == nil {
+				return nil, xerrors.Errorf("resolution lookup failed (%s): %w", actor, types.ErrActorNotFound)
+			}
+			return act, nil
+		}
+	}
+
 	return m.StateManager.LoadActor(ctx, actor, ts)
 }
 
@@ -543,16 +886,7 @@ func (a *StateAPI) StateDecodeParams(ctx context.Context, toAddr address.Address
 		return nil, xerrors.Errorf("getting actor: %w", err)
 	}
 
-	paramType, err := stmgr.GetParamType(a.TsExec.NewActorRegistry(), act.Code, method)
-	if err != nil {
-		return nil, xerrors.Errorf("getting params type: %w", err)
-	}
-
-	if err = paramType.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
-		return nil, err
-	}
-
-	return paramType, nil
+	return stmgr.DecodeParams(a.TsExec.NewActorRegistry(), act.Code, method, params)
 }
 
 func (a *StateAPI) StateEncodeParams(ctx context.Context, toActCode cid.Cid, method abi.MethodNum, params json.RawMessage) ([]byte, error) {
@@ -615,16 +949,10 @@ func (m *StateModule) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence
 
 		vmsg := cmsg.VMMessage()
 
-		t, err := stmgr.GetReturnType(ctx, m.StateManager, vmsg.To, vmsg.Method, ts)
+		returndec, err = stmgr.DecodeMessageReturn(ctx, m.StateManager, vmsg.To, vmsg.Method, recpt.Return, ts)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to get return type: %w", err)
+			return nil, xerrors.Errorf("failed to decode return value: %w", err)
 		}
-
-		if err := t.UnmarshalCBOR(bytes.NewReader(recpt.Return)); err != nil {
-			return nil, err
-		}
-
-		returndec = t
 	}
 
 	return &api.MsgLookup{
@@ -979,6 +1307,26 @@ func (a *StateAPI) StateChangedActors(ctx context.Context, old cid.Cid, new cid.
 	return state.Diff(ctx, oldTree, newTree)
 }
 
+func (a *StateAPI) StateReplayTipSet(ctx context.Context, tsk types.TipSetKey) (*api.TipSetReplay, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return a.StateManager.ReplayTipSet(ctx, ts)
+}
+
+func (a *StateAPI) StateDiff(ctx context.Context, from, to types.TipSetKey, decode bool) (*api.StateDiff, error) {
+	fromTs, err := a.Chain.GetTipSetFromKey(ctx, from)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", from, err)
+	}
+	toTs, err := a.Chain.GetTipSetFromKey(ctx, to)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", to, err)
+	}
+	return a.StateManager.DiffStates(ctx, fromTs, toTs, decode)
+}
+
 func (a *StateAPI) StateMinerSectorCount(ctx context.Context, addr address.Address, tsk types.TipSetKey) (api.MinerSectors, error) {
 	act, err := a.StateManager.LoadActorTsk(ctx, addr, tsk)
 	if err != nil {
@@ -1113,45 +1461,448 @@ func (a *StateAPI) StateListMessages(ctx context.Context, match *api.MessageMatc
 		}
 	}
 
-	// TODO: This should probably match on both ID and robust address, no?
-	matchFunc := func(msg *types.Message) bool {
-		if match.From != address.Undef && match.From != msg.From {
-			return false
+	var out []cid.Cid
+	query := &api.MessageQuery{From: match.From, To: match.To, MinHeight: toheight}
+	for {
+		res, err := a.queryMessages(ctx, query, ts)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range res.Messages {
+			out = append(out, m.Cid)
+		}
+		if res.Next == nil {
+			return out, nil
+		}
+		query.Cursor = res.Next
+	}
+}
+
+// messageQueryBudget is the number of tipsets walked, or of indexed messages
+// looked at, for a page of StateQueryMessages, so that queries scanning most
+// of the chain return before timing out.
+const messageQueryBudget = 10000
+
+// messageIndexLag is the number of the most recent tipsets whose messages
+// StateQueryMessages reads from the chain rather than from the index.
+const messageIndexLag = 5
+
+func (a *StateAPI) StateQueryMessages(ctx context.Context, query *api.MessageQuery, tsk types.TipSetKey) (*api.MessageQueryResult, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if query == nil {
+		query = &api.MessageQuery{}
+	}
+	return a.queryMessages(ctx, query, ts)
+}
+
+// messageQuery is a page of messages being queried on the chain of ts.
+type messageQuery struct {
+	*api.MessageQuery
+	ts     *types.TipSet
+	start  api.MessageCursor
+	limit  int
+	budget int
+	res    *api.MessageQueryResult
+	// execs are the tipsets executing indexed messages, nil for those not in
+	// the chain of ts
+	execs map[types.TipSetKey]*types.TipSet
+}
+
+func (a *StateAPI) queryMessages(ctx context.Context, query *api.MessageQuery, ts *types.TipSet) (*api.MessageQueryResult, error) {
+	q := &messageQuery{
+		MessageQuery: query,
+		ts:           ts,
+		limit:        query.Limit,
+		budget:       messageQueryBudget,
+		res:          &api.MessageQueryResult{},
+		execs:        make(map[types.TipSetKey]*types.TipSet),
+	}
+	if q.limit <= 0 || q.limit > api.MessageQueryMaxLimit {
+		q.limit = api.MessageQueryMaxLimit
+	}
+
+	hi := ts.Height()
+	if query.MaxHeight > 0 && query.MaxHeight < hi {
+		hi = query.MaxHeight
+	}
+	q.start = api.MessageCursor{Height: hi}
+	if query.Cursor != nil && query.Cursor.Height <= hi {
+		q.start = *query.Cursor
+	}
+	lo := query.MinHeight
+	if lo < 0 {
+		lo = 0
+	}
+	if q.start.Height < lo {
+		return q.res, nil
+	}
+
+	addr := query.From
+	if addr == address.Undef {
+		addr = query.To
+	}
+	since, indexed := abi.ChainEpoch(0), false
+	if addr != address.Undef {
+		var err error
+		since, indexed, err = a.Chain.MsgIndexSince(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !indexed {
+		_, err := a.walkMessages(ctx, q, q.start.Height, lo)
+		if err != nil {
+			return nil, err
+		}
+		return q.res, nil
+	}
+
+	// the messages of ts aren't executed in its chain, and the index is
+	// updated asynchronously, the most recent messages are read from the chain
+	recent := ts.Height() - messageIndexLag
+	if q.start.Height > recent {
+		walkLo := recent + 1
+		if walkLo < lo {
+			walkLo = lo
+		}
+		done, err := a.walkMessages(ctx, q, q.start.Height, walkLo)
+		if err != nil || done {
+			return q.res, err
+		}
+	}
+
+	idxHi, idxLo := q.start.Height, since
+	if idxHi > recent {
+		idxHi = recent
+	}
+	if idxLo < lo {
+		idxLo = lo
+	}
+	if idxHi >= idxLo {
+		done, err := a.indexedMessages(ctx, q, addr, idxHi, idxLo)
+		if err != nil || done {
+			return q.res, err
+		}
+	}
+
+	// the messages included before the index was enabled
+	if since-1 >= lo {
+		walkHi := since - 1
+		if q.start.Height < walkHi {
+			walkHi = q.start.Height
 		}
+		if _, err := a.walkMessages(ctx, q, walkHi, lo); err != nil {
+			return nil, err
+		}
+	}
+
+	return q.res, nil
+}
 
-		if match.To != address.Undef && match.To != msg.To {
+// from returns the index of the first message of the tipset at height h in
+// the page.
+func (q *messageQuery) from(h abi.ChainEpoch) int {
+	if h == q.start.Height {
+		return q.start.Index
+	}
+	return 0
+}
+
+func (q *messageQuery) match(m *types.Message) bool {
+	if q.From != address.Undef && q.From != m.From {
+		return false
+	}
+	if q.To != address.Undef && q.To != m.To {
+		return false
+	}
+	if len(q.Methods) > 0 {
+		found := false
+		for _, method := range q.Methods {
+			if method == m.Method {
+				found = true
+				break
+			}
+		}
+		if !found {
 			return false
 		}
+	}
+	if q.MinValue != nil && m.Value.LessThan(*q.MinValue) {
+		return false
+	}
+	if q.MaxValue != nil && m.Value.GreaterThan(*q.MaxValue) {
+		return false
+	}
+	return true
+}
 
-		return true
+// add adds the matching message to the page, and returns whether it is full.
+func (q *messageQuery) add(c cid.Cid, m *types.Message, incl types.TipSetKey, h abi.ChainEpoch, idx int, r *types.MessageReceipt) bool {
+	if q.ExitCode != nil && (r == nil || r.ExitCode != *q.ExitCode) {
+		return false
 	}
 
-	var out []cid.Cid
-	for ts.Height() >= toheight {
-		msgs, err := a.Chain.MessagesForTipset(ctx, ts)
+	q.res.Messages = append(q.res.Messages, api.MessageQueryMatch{
+		Cid:     c,
+		Message: m,
+		Height:  h,
+		TipSet:  incl,
+		Receipt: r,
+	})
+	if len(q.res.Messages) < q.limit {
+		return false
+	}
+	q.res.Next = &api.MessageCursor{Height: h, Index: idx + 1}
+	return true
+}
+
+// walkMessages adds the matching messages of the tipsets of the chain with
+// heights from hi down to lo, and returns whether the page is done.
+func (a *StateAPI) walkMessages(ctx context.Context, q *messageQuery, hi, lo abi.ChainEpoch) (bool, error) {
+	incl, err := a.Chain.GetTipsetByHeight(ctx, hi, q.ts, true)
+	if err != nil {
+		return false, xerrors.Errorf("loading tipset at %d: %w", hi, err)
+	}
+	var exec *types.TipSet
+	if !incl.Equals(q.ts) {
+		exec, err = a.Chain.GetTipsetByHeight(ctx, incl.Height()+1, q.ts, false)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to get messages for tipset (%s): %w", ts.Key(), err)
+			return false, xerrors.Errorf("loading tipset at %d: %w", incl.Height()+1, err)
+		}
+	}
+
+	for incl.Height() >= lo {
+		if q.budget == 0 {
+			q.res.Next = &api.MessageCursor{Height: incl.Height(), Index: q.from(incl.Height())}
+			return true, nil
 		}
+		q.budget--
 
-		for _, msg := range msgs {
-			if matchFunc(msg.VMMessage()) {
-				out = append(out, msg.Cid())
+		msgs, err := a.Chain.MessagesForTipset(ctx, incl)
+		if err != nil {
+			return false, xerrors.Errorf("failed to get messages for tipset (%s): %w", incl.Key(), err)
+		}
+
+		for i := q.from(incl.Height()); i < len(msgs); i++ {
+			m := msgs[i].VMMessage()
+			if !q.match(m) {
+				continue
+			}
+
+			var r *types.MessageReceipt
+			if exec != nil {
+				r, err = a.Chain.GetParentReceipt(ctx, exec.Blocks()[0], i)
+				if err != nil {
+					return false, xerrors.Errorf("loading receipt of %s: %w", msgs[i].Cid(), err)
+				}
 			}
+			if q.add(msgs[i].Cid(), m, incl.Key(), incl.Height(), i, r) {
+				return true, nil
+			}
+		}
+
+		if incl.Height() == 0 {
+			break
+		}
+		exec = incl
+		incl, err = a.Chain.LoadTipSet(ctx, incl.Parents())
+		if err != nil {
+			return false, xerrors.Errorf("loading next tipset: %w", err)
+		}
+	}
+
+	return false, nil
+}
+
+// indexedMessages adds the matching messages of addr in the message index,
+// included at heights from hi down to lo, and returns whether the page is
+// done.
+func (a *StateAPI) indexedMessages(ctx context.Context, q *messageQuery, addr address.Address, hi, lo abi.ChainEpoch) (bool, error) {
+	done := false
+	err := a.Chain.ForEachIndexedMsg(ctx, addr, hi, lo, func(im store.IndexedMsg) (bool, error) {
+		if im.Height == q.start.Height && im.Index < q.start.Index {
+			return true, nil
+		}
+		if q.budget == 0 {
+			q.res.Next = &api.MessageCursor{Height: im.Height, Index: im.Index}
+			done = true
+			return false, nil
+		}
+		q.budget--
+
+		exec, err := a.indexedExec(ctx, q, im.Exec)
+		if err != nil || exec == nil {
+			return err == nil, err
+		}
+
+		cm, err := a.Chain.GetCMessage(ctx, im.Cid)
+		if err != nil {
+			return false, xerrors.Errorf("loading message %s: %w", im.Cid, err)
+		}
+		m := cm.VMMessage()
+		if !q.match(m) {
+			return true, nil
 		}
 
-		if ts.Height() == 0 {
+		r, err := a.Chain.GetParentReceipt(ctx, exec.Blocks()[0], im.Index)
+		if err != nil {
+			return false, xerrors.Errorf("loading receipt of %s: %w", im.Cid, err)
+		}
+		if q.add(im.Cid, m, exec.Parents(), im.Height, im.Index, r) {
+			done = true
+			return false, nil
+		}
+		return true, nil
+	})
+	return done, err
+}
+
+// indexedExec returns the tipset executing indexed messages, or nil if it
+// isn't in the chain of the query.
+func (a *StateAPI) indexedExec(ctx context.Context, q *messageQuery, tsk types.TipSetKey) (*types.TipSet, error) {
+	if exec, ok := q.execs[tsk]; ok {
+		return exec, nil
+	}
+
+	exec, err := a.Chain.LoadTipSet(ctx, tsk)
+	if ipld.IsNotFound(err) {
+		// pruned
+		q.execs[tsk] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("loading indexed tipset: %w", err)
+	}
+
+	cur, err := a.Chain.GetTipsetByHeight(ctx, exec.Height(), q.ts, false)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at %d: %w", exec.Height(), err)
+	}
+	if !cur.Equals(exec) {
+		exec = nil
+	}
+	q.execs[tsk] = exec
+	return exec, nil
+}
+
+func (a *StateAPI) StateGasProfile(ctx context.Context, from, to abi.ChainEpoch, tsk types.TipSetKey) (*api.GasProfile, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if to >= ts.Height() {
+		to = ts.Height() - 1
+	}
+	if from < 0 {
+		from = 0
+	}
+	if from > to {
+		return nil, xerrors.Errorf("no executed tipsets from height %d to %d", from, to)
+	}
+	if to-from >= api.GasProfileMaxEpochs {
+		return nil, xerrors.Errorf("cannot profile more than %d epochs", api.GasProfileMaxEpochs)
+	}
+
+	type methodKey struct {
+		code   cid.Cid
+		method abi.MethodNum
+	}
+	methods := make(map[methodKey]*api.GasProfileMethod)
+	res := &api.GasProfile{From: from, To: to}
+
+	exec, err := a.Chain.GetTipsetByHeight(ctx, to+1, ts, false)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at %d: %w", to+1, err)
+	}
+	for {
+		incl, err := a.Chain.LoadTipSet(ctx, exec.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %w", exec.Parents(), err)
+		}
+		if incl.Height() < from {
 			break
 		}
 
-		next, err := a.Chain.LoadTipSet(ctx, ts.Parents())
+		msgs, err := a.Chain.MessagesForTipset(ctx, incl)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get messages for tipset (%s): %w", incl.Key(), err)
+		}
+		rcpts, err := blockadt.AsArray(a.Chain.ActorStore(ctx), exec.Blocks()[0].ParentMessageReceipts)
 		if err != nil {
-			return nil, xerrors.Errorf("loading next tipset: %w", err)
+			return nil, xerrors.Errorf("loading receipts of tipset (%s): %w", incl.Key(), err)
+		}
+		// the messages may create or delete their recipient
+		pre, err := a.StateManager.StateTree(incl.ParentState())
+		if err != nil {
+			return nil, xerrors.Errorf("loading state of tipset (%s): %w", incl.Key(), err)
+		}
+		post, err := a.StateManager.StateTree(exec.ParentState())
+		if err != nil {
+			return nil, xerrors.Errorf("loading state of tipset (%s): %w", exec.Key(), err)
+		}
+
+		for i, cm := range msgs {
+			m := cm.VMMessage()
+			var r types.MessageReceipt
+			if found, err := rcpts.Get(uint64(i), &r); err != nil {
+				return nil, xerrors.Errorf("loading receipt of %s: %w", cm.Cid(), err)
+			} else if !found {
+				return nil, xerrors.Errorf("no receipt for %s", cm.Cid())
+			}
+
+			var code cid.Cid
+			for _, tree := range []*state.StateTree{post, pre} {
+				act, err := tree.GetActor(m.To)
+				if err == nil {
+					code = act.Code
+					break
+				}
+				if !xerrors.Is(err, types.ErrActorNotFound) {
+					return nil, xerrors.Errorf("loading actor %s: %w", m.To, err)
+				}
+			}
+
+			k := methodKey{code: code, method: m.Method}
+			gm, ok := methods[k]
+			if !ok {
+				gm = &api.GasProfileMethod{Code: code, Method: m.Method}
+				methods[k] = gm
+			}
+			gm.Messages++
+			if r.ExitCode != 0 {
+				gm.Failed++
+			}
+			gm.GasUsed += r.GasUsed
+			gm.GasLimit += m.GasLimit
+
+			res.Messages++
+			res.GasUsed += r.GasUsed
 		}
 
-		ts = next
+		if incl.Height() == 0 {
+			break
+		}
+		exec = incl
 	}
 
-	return out, nil
+	res.Methods = make([]api.GasProfileMethod, 0, len(methods))
+	for _, gm := range methods {
+		res.Methods = append(res.Methods, *gm)
+	}
+	sort.Slice(res.Methods, func(i, j int) bool {
+		mi, mj := res.Methods[i], res.Methods[j]
+		if mi.GasUsed != mj.GasUsed {
+			return mi.GasUsed > mj.GasUsed
+		}
+		if mi.Code != mj.Code {
+			return mi.Code.KeyString() < mj.Code.KeyString()
+		}
+		return mi.Method < mj.Method
+	})
+	return res, nil
 }
 
 func (a *StateAPI) StateCompute(ctx context.Context, height abi.ChainEpoch, msgs []*types.Message, tsk types.TipSetKey) (*api.ComputeStateOutput, error) {
@@ -1170,6 +1921,40 @@ func (a *StateAPI) StateCompute(ctx context.Context, height abi.ChainEpoch, msgs
 	}, nil
 }
 
+func (a *StateAPI) StateComputeStream(ctx context.Context, height abi.ChainEpoch, msgs []*types.Message, tsk types.TipSetKey) (<-chan api.ComputeStateUpdate, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	out := make(chan api.ComputeStateUpdate, 16)
+	go func() {
+		defer close(out)
+
+		send := func(u api.ComputeStateUpdate) error {
+			select {
+			case out <- u:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		st, err := stmgr.ComputeStateWithMonitor(ctx, a.StateManager, height, msgs, ts, stmgr.InvocationFunc(func(ctx context.Context, ir *api.InvocResult) error {
+			return send(api.ComputeStateUpdate{Trace: ir})
+		}))
+		if err != nil {
+			if ctx.Err() == nil {
+				_ = send(api.ComputeStateUpdate{Error: err.Error()})
+			}
+			return
+		}
+		_ = send(api.ComputeStateUpdate{Root: &st})
+	}()
+
+	return out, nil
+}
+
 func (m *StateModule) MsigGetAvailableBalance(ctx context.Context, addr address.Address, tsk types.TipSetKey) (types.BigInt, error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -0,0 +1,485 @@
+// stm: #unit
+package full
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+	miner7 "github.com/filecoin-project/specs-actors/v7/actors/builtin/miner"
+	adt7 "github.com/filecoin-project/specs-actors/v7/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// queryChain builds a chain of 12 tipsets over cs, including msgs at their
+// heights, the messages executed with exit code 16 when their method is 2, and
+// using half of their gas limit. The state has an account actor at address 101.
+func queryChain(ctx context.Context, t *testing.T, cs *store.ChainStore, msgs map[abi.ChainEpoch][]*types.Message) *types.TipSet {
+	arr := func(vals ...cbg.CBORMarshaler) cid.Cid {
+		a := blockadt.MakeEmptyArray(cs.ActorStore(ctx))
+		for i, v := range vals {
+			require.NoError(t, a.Set(uint64(i), v))
+		}
+		root, err := a.Root()
+		require.NoError(t, err)
+		return root
+	}
+
+	// the messages of a tipset are selected against its parent state
+	st, err := state.NewStateTree(cbor.NewCborStore(cs.StateBlockstore()), types.StateTreeVersion4)
+	require.NoError(t, err)
+	account, ok := actors.GetActorCodeID(actorstypes.Version7, actors.AccountKey)
+	require.True(t, ok)
+	require.NoError(t, st.SetActor(mock.Address(101), &types.Actor{Code: account, Head: account, Balance: big.Zero()}))
+	root, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	var ts *types.TipSet
+	for h := abi.ChainEpoch(0); h <= 12; h++ {
+		var mcids, rcts []cbg.CBORMarshaler
+		for _, m := range msgs[h] {
+			c, err := cs.PutMessage(ctx, m)
+			require.NoError(t, err)
+			cc := cbg.CborCid(c)
+			mcids = append(mcids, &cc)
+		}
+		for _, m := range msgs[h-1] {
+			r := &types.MessageReceipt{ExitCode: exitcode.Ok, GasUsed: m.GasLimit / 2}
+			if m.Method == 2 {
+				r.ExitCode = exitcode.ErrIllegalArgument
+			}
+			rcts = append(rcts, r)
+		}
+		meta, err := cs.ActorStore(ctx).Put(ctx, &types.MsgMeta{BlsMessages: arr(mcids...), SecpkMessages: arr()})
+		require.NoError(t, err)
+
+		blk := mock.MkBlock(ts, 1, 1)
+		blk.ParentStateRoot, blk.Messages, blk.ParentMessageReceipts = root, meta, arr(rcts...)
+		require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+		ts = mock.TipSet(blk)
+	}
+	return ts
+}
+
+func TestStateQueryMessages(t *testing.T) {
+	ctx := context.Background()
+
+	from, to, other := mock.Address(100), mock.Address(101), mock.Address(102)
+	msg := func(from, to address.Address, nonce uint64, method abi.MethodNum, value int64) *types.Message {
+		m := mock.UnsignedMessage(from, to, nonce)
+		m.Method, m.Value = method, abi.NewTokenAmount(value)
+		return m
+	}
+	m0, m1 := msg(from, to, 0, 0, 1), msg(from, other, 1, 2, 5)
+	m2, m3 := msg(other, to, 0, 2, 10), msg(from, to, 2, 0, 3)
+	m4 := msg(from, to, 3, 0, 1)
+	msgs := map[abi.ChainEpoch][]*types.Message{2: {m0, m1}, 4: {m2}, 5: {m3}, 12: {m4}}
+
+	cids := func(res []api.MessageQueryMatch) []cid.Cid {
+		var out []cid.Cid
+		for _, m := range res {
+			out = append(out, m.Cid)
+		}
+		return out
+	}
+	amt := func(v int64) *abi.TokenAmount {
+		a := abi.NewTokenAmount(v)
+		return &a
+	}
+	illegal := exitcode.ErrIllegalArgument
+
+	for _, indexed := range []bool{false, true} {
+		func() {
+			defer func(enabled bool) { store.MsgIndex = enabled }(store.MsgIndex)
+			store.MsgIndex = indexed
+
+			bs := blockstore.NewMemorySync()
+			cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+			defer cs.Close() //nolint:errcheck
+
+			head := queryChain(ctx, t, cs, msgs)
+			gen, err := cs.GetTipsetByHeight(ctx, 0, head, false)
+			require.NoError(t, err)
+			require.NoError(t, cs.SetHead(ctx, gen))
+			require.NoError(t, cs.SetHead(ctx, head))
+			if indexed {
+				require.Eventually(t, func() bool {
+					_, _, ok, err := cs.LookupMsgExecution(ctx, m3.Cid())
+					require.NoError(t, err)
+					return ok
+				}, 5*time.Second, 10*time.Millisecond)
+			}
+
+			a := &StateAPI{Chain: cs}
+			query := func(q api.MessageQuery) []api.MessageQueryMatch {
+				res, err := a.StateQueryMessages(ctx, &q, head.Key())
+				require.NoError(t, err)
+				return res.Messages
+			}
+
+			// newest first, in inclusion order at a height
+			res := query(api.MessageQuery{From: from})
+			require.Equal(t, []cid.Cid{m4.Cid(), m3.Cid(), m0.Cid(), m1.Cid()}, cids(res), "indexed %t", indexed)
+			require.Nil(t, res[0].Receipt)
+			require.Equal(t, abi.ChainEpoch(5), res[1].Height)
+			require.Equal(t, exitcode.Ok, res[1].Receipt.ExitCode)
+
+			require.Equal(t, []cid.Cid{m4.Cid(), m3.Cid(), m2.Cid(), m0.Cid()}, cids(query(api.MessageQuery{To: to})))
+			require.Equal(t, []cid.Cid{m1.Cid()}, cids(query(api.MessageQuery{From: from, Methods: []abi.MethodNum{2}})))
+			require.Equal(t, []cid.Cid{m2.Cid(), m1.Cid()}, cids(query(api.MessageQuery{ExitCode: &illegal})))
+			require.Equal(t, []cid.Cid{m3.Cid(), m1.Cid()}, cids(query(api.MessageQuery{From: from, MinValue: amt(2), MaxValue: amt(5)})))
+			require.Equal(t, []cid.Cid{m3.Cid(), m2.Cid()}, cids(query(api.MessageQuery{MinHeight: 3, MaxHeight: 10})))
+
+			// paging through the messages one at a time
+			var paged []cid.Cid
+			q := &api.MessageQuery{From: from, Limit: 1}
+			for {
+				res, err := a.StateQueryMessages(ctx, q, head.Key())
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(res.Messages), 1)
+				paged = append(paged, cids(res.Messages)...)
+				if res.Next == nil {
+					break
+				}
+				q.Cursor = res.Next
+			}
+			require.Equal(t, []cid.Cid{m4.Cid(), m3.Cid(), m0.Cid(), m1.Cid()}, paged)
+		}()
+	}
+}
+
+func TestStateGasProfile(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	from, account, none := mock.Address(100), mock.Address(101), mock.Address(102)
+	msg := func(from, to address.Address, nonce uint64, method abi.MethodNum) *types.Message {
+		m := mock.UnsignedMessage(from, to, nonce)
+		m.Method = method
+		return m
+	}
+	head := queryChain(ctx, t, cs, map[abi.ChainEpoch][]*types.Message{
+		2:  {msg(from, account, 0, 0), msg(from, none, 1, 2)},
+		4:  {msg(none, account, 0, 2)},
+		5:  {msg(from, account, 2, 0)},
+		12: {msg(from, account, 3, 0)},
+	})
+
+	sm, err := stmgr.NewStateManager(cs, nil, nil, nil, nil)
+	require.NoError(t, err)
+	a := &StateAPI{Chain: cs, StateManager: sm}
+
+	code, ok := actors.GetActorCodeID(actorstypes.Version7, actors.AccountKey)
+	require.True(t, ok)
+
+	// the messages of the head are not executed
+	profile, err := a.StateGasProfile(ctx, 0, 12, head.Key())
+	require.NoError(t, err)
+	require.Equal(t, &api.GasProfile{
+		From:     0,
+		To:       11,
+		Messages: 4,
+		GasUsed:  2000000,
+		Methods: []api.GasProfileMethod{
+			{Code: code, Method: 0, Messages: 2, GasUsed: 1000000, GasLimit: 2000000},
+			{Code: cid.Undef, Method: 2, Messages: 1, Failed: 1, GasUsed: 500000, GasLimit: 1000000},
+			{Code: code, Method: 2, Messages: 1, Failed: 1, GasUsed: 500000, GasLimit: 1000000},
+		},
+	}, profile)
+
+	profile, err = a.StateGasProfile(ctx, 3, 4, head.Key())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), profile.Messages)
+	require.Len(t, profile.Methods, 1)
+
+	_, err = a.StateGasProfile(ctx, 12, 12, head.Key())
+	require.Error(t, err)
+}
+
+func TestStateMinersSectors(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+	adtStore := cs.ActorStore(ctx)
+
+	minerCode, ok := actors.GetActorCodeID(actorstypes.Version7, actors.MinerKey)
+	require.True(t, ok)
+	emptyArr, err := adt7.StoreEmptyArray(adtStore, miner7.SectorsAmtBitwidth)
+	require.NoError(t, err)
+
+	// the sectors of a miner are those of its allocated sector numbers with a
+	// sector on chain
+	minerActor := func(allocated []uint64, sectors ...abi.SectorNumber) *types.Actor {
+		arr, err := adt7.MakeEmptyArray(adtStore, miner7.SectorsAmtBitwidth)
+		require.NoError(t, err)
+		for _, sno := range sectors {
+			require.NoError(t, arr.Set(uint64(sno), &miner7.SectorOnChainInfo{SectorNumber: sno, SealedCID: emptyArr}))
+		}
+		sectorsRoot, err := arr.Root()
+		require.NoError(t, err)
+		allocatedRoot, err := adtStore.Put(ctx, bitfield.NewFromSet(allocated))
+		require.NoError(t, err)
+
+		head, err := adtStore.Put(ctx, &miner7.State{
+			Info:                       emptyArr,
+			PreCommitDeposits:          big.Zero(),
+			LockedFunds:                big.Zero(),
+			VestingFunds:               emptyArr,
+			FeeDebt:                    big.Zero(),
+			InitialPledge:              big.Zero(),
+			PreCommittedSectors:        emptyArr,
+			PreCommittedSectorsCleanUp: emptyArr,
+			AllocatedSectors:           allocatedRoot,
+			Sectors:                    sectorsRoot,
+			Deadlines:                  emptyArr,
+			EarlyTerminations:          bitfield.New(),
+		})
+		require.NoError(t, err)
+		return &types.Actor{Code: minerCode, Head: head, Balance: big.Zero()}
+	}
+	m1, m2, m3 := mock.Address(1000), mock.Address(1001), mock.Address(1002)
+
+	tree, err := state.NewStateTree(adtStore, types.StateTreeVersion4)
+	require.NoError(t, err)
+	require.NoError(t, tree.SetActor(m1, minerActor([]uint64{1, 2, 3, 5}, 1, 3, 5)))
+	require.NoError(t, tree.SetActor(m2, minerActor([]uint64{0, 7}, 0, 7)))
+	require.NoError(t, tree.SetActor(m3, minerActor(nil)))
+	root, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.ParentStateRoot = root
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	ts := mock.TipSet(blk)
+
+	a := &StateAPI{Chain: cs}
+	sectors := func(res *api.MinersSectorsResult) map[address.Address][]abi.SectorNumber {
+		out := make(map[address.Address][]abi.SectorNumber)
+		for _, ms := range res.Miners {
+			for _, s := range ms.Sectors {
+				out[ms.Miner] = append(out[ms.Miner], s.SectorNumber)
+			}
+		}
+		return out
+	}
+
+	res, err := a.StateMinersSectors(ctx, &api.MinersSectorsQuery{Miners: []address.Address{m1, m3, m2}}, ts.Key())
+	require.NoError(t, err)
+	require.Nil(t, res.Next)
+	require.Len(t, res.Miners, 2)
+	require.Equal(t, m1, res.Miners[0].Miner)
+	require.Equal(t, map[address.Address][]abi.SectorNumber{m1: {1, 3, 5}, m2: {0, 7}}, sectors(res))
+
+	// paging through the sectors two at a time
+	q := &api.MinersSectorsQuery{Miners: []address.Address{m1, m3, m2}, Limit: 2}
+	res, err = a.StateMinersSectors(ctx, q, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, map[address.Address][]abi.SectorNumber{m1: {1, 3}}, sectors(res))
+	require.Equal(t, &api.MinersSectorsCursor{Miner: 0, Sector: 5}, res.Next)
+
+	q.Cursor = res.Next
+	res, err = a.StateMinersSectors(ctx, q, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, map[address.Address][]abi.SectorNumber{m1: {5}, m2: {0}}, sectors(res))
+	require.Equal(t, &api.MinersSectorsCursor{Miner: 2, Sector: 7}, res.Next)
+
+	q.Cursor = res.Next
+	res, err = a.StateMinersSectors(ctx, q, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, map[address.Address][]abi.SectorNumber{m2: {7}}, sectors(res))
+	require.Nil(t, res.Next)
+
+	// not a miner
+	_, err = a.StateMinersSectors(ctx, &api.MinersSectorsQuery{Miners: []address.Address{mock.Address(1003)}}, ts.Key())
+	require.Error(t, err)
+}
+
+func TestStateMinerProvingSchedule(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, syncds.MutexWrap(datastore.NewMapDatastore()), nil, nil)
+	defer cs.Close() //nolint:errcheck
+	adtStore := cs.ActorStore(ctx)
+
+	sm, err := stmgr.NewStateManager(cs, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	// a miner with sectors 1 to 3 in a partition of deadline 3, 2 faulty
+	emptyArr, err := adt7.StoreEmptyArray(adtStore, miner7.SectorsAmtBitwidth)
+	require.NoError(t, err)
+	mst, err := miner7.ConstructState(adtStore, emptyArr, 0, 0)
+	require.NoError(t, err)
+
+	part, err := miner7.ConstructPartition(adtStore)
+	require.NoError(t, err)
+	part.Sectors, part.Faults = bitfield.NewFromSet([]uint64{1, 2, 3}), bitfield.NewFromSet([]uint64{2})
+	dl, err := miner7.ConstructDeadline(adtStore)
+	require.NoError(t, err)
+	parts, err := adt7.AsArray(adtStore, dl.Partitions, miner7.DeadlinePartitionsAmtBitwidth)
+	require.NoError(t, err)
+	require.NoError(t, parts.Set(0, part))
+	dl.Partitions, err = parts.Root()
+	require.NoError(t, err)
+	dls, err := mst.LoadDeadlines(adtStore)
+	require.NoError(t, err)
+	require.NoError(t, dls.UpdateDeadline(adtStore, 3, dl))
+	require.NoError(t, mst.SaveDeadlines(adtStore, dls))
+
+	minerCode, ok := actors.GetActorCodeID(actorstypes.Version7, actors.MinerKey)
+	require.True(t, ok)
+	head, err := adtStore.Put(ctx, mst)
+	require.NoError(t, err)
+	maddr := mock.Address(1000)
+
+	tree, err := state.NewStateTree(adtStore, types.StateTreeVersion4)
+	require.NoError(t, err)
+	require.NoError(t, tree.SetActor(maddr, &types.Actor{Code: minerCode, Head: head, Balance: big.Zero()}))
+	root, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.Height, blk.ParentStateRoot = 10, root
+	require.NoError(t, cs.PersistBlockHeaders(ctx, blk))
+	ts := mock.TipSet(blk)
+
+	a := &StateAPI{Chain: cs, StateManager: sm}
+
+	// the window open at the height, and those opening in the epochs after it
+	window := miner7.WPoStChallengeWindow
+	windows, err := a.StateMinerProvingSchedule(ctx, maddr, 4*window, ts.Key())
+	require.NoError(t, err)
+	require.Len(t, windows, 5)
+	for i, w := range windows {
+		require.Equal(t, uint64(i), w.Deadline)
+		require.Equal(t, abi.ChainEpoch(i)*window, w.Open)
+		require.Equal(t, w.Open+window, w.Close)
+		require.Less(t, w.Challenge, w.Open)
+		require.Less(t, w.FaultCutoff, w.Open)
+	}
+	require.Equal(t, 1, windows[3].Partitions)
+	require.Equal(t, uint64(3), windows[3].LiveSectors)
+	require.Equal(t, uint64(1), windows[3].FaultySectors)
+	require.Zero(t, windows[2].Partitions)
+
+	// the schedule wraps around the proving period
+	windows, err = a.StateMinerProvingSchedule(ctx, maddr, miner7.WPoStProvingPeriod, ts.Key())
+	require.NoError(t, err)
+	last := windows[len(windows)-1]
+	require.Equal(t, uint64(0), last.Deadline)
+	require.Equal(t, miner7.WPoStProvingPeriod, last.Open)
+
+	_, err = a.StateMinerProvingSchedule(ctx, maddr, api.ProvingScheduleMaxEpochs+1, ts.Key())
+	require.Error(t, err)
+}
+
+func TestBreakpointDebugger(t *testing.T) {
+	accountCode, err := abi.CidBuilder.Sum([]byte("account"))
+	require.NoError(t, err)
+	minerCode, err := abi.CidBuilder.Sum([]byte("miner"))
+	require.NoError(t, err)
+
+	// the state executed on has accounts 100 and 101, and the miner 102 of
+	// robust address maddr; 103 is created by the message
+	maddr, err := address.NewActorAddress([]byte("miner"))
+	require.NoError(t, err)
+	from, acc, mid, created := mock.Address(100), mock.Address(101), mock.Address(102), mock.Address(103)
+	actors := map[address.Address]cid.Cid{from: accountCode, acc: accountCode, mid: minerCode}
+
+	call := func(from, to address.Address, method abi.MethodNum, exit exitcode.ExitCode, subcalls ...types.ExecutionTrace) types.ExecutionTrace {
+		return types.ExecutionTrace{
+			Msg:      &types.Message{From: from, To: to, Method: method, Value: big.Zero(), Params: []byte{byte(method)}, GasLimit: 1000},
+			MsgRct:   &types.MessageReceipt{ExitCode: exit, GasUsed: 10},
+			Subcalls: subcalls,
+		}
+	}
+	et := call(from, maddr, 5, exitcode.ErrForbidden,
+		call(mid, acc, 0, exitcode.Ok),
+		call(mid, created, 2, exitcode.Ok,
+			call(created, maddr, 6, exitcode.ErrForbidden),
+		),
+	)
+
+	run := func(bps ...api.Breakpoint) []api.BreakpointHit {
+		d := &breakpointDebugger{
+			bps: bps,
+			lookup: func(addr address.Address) (address.Address, cid.Cid) {
+				if addr == maddr {
+					addr = mid
+				}
+				code, ok := actors[addr]
+				if !ok {
+					return addr, cid.Undef
+				}
+				return addr, code
+			},
+			decode: func(code cid.Cid, method abi.MethodNum, params []byte) interface{} {
+				return nil
+			},
+		}
+		hits, truncated := d.run(et)
+		require.False(t, truncated)
+		return hits
+	}
+
+	// the miner is matched by its robust and ID addresses
+	for _, addr := range []address.Address{maddr, mid} {
+		hits := run(api.Breakpoint{Actor: addr})
+		require.Len(t, hits, 2)
+		require.Equal(t, abi.MethodNum(5), hits[0].Frame.Method)
+		require.Equal(t, minerCode, hits[0].Frame.Code)
+		require.Empty(t, hits[0].Stack)
+
+		require.Equal(t, 2, hits[1].Frame.Depth)
+		require.Equal(t, created, hits[1].Frame.Caller)
+		require.Equal(t, exitcode.ErrForbidden, hits[1].Frame.ExitCode)
+		require.Equal(t, int64(1000), hits[1].Frame.GasLimit)
+		require.Len(t, hits[1].Stack, 2)
+		require.Equal(t, maddr, hits[1].Stack[0].Receiver)
+		require.Equal(t, created, hits[1].Stack[1].Receiver)
+	}
+
+	// code and methods
+	hits := run(api.Breakpoint{Code: accountCode})
+	require.Len(t, hits, 1)
+	require.Equal(t, acc, hits[0].Frame.Receiver)
+
+	hits = run(api.Breakpoint{Methods: []abi.MethodNum{2, 6}})
+	require.Len(t, hits, 2)
+	require.Equal(t, cid.Undef, hits[0].Frame.Code)
+	require.Equal(t, abi.MethodNum(6), hits[1].Frame.Method)
+
+	require.Empty(t, run(api.Breakpoint{Actor: mid, Methods: []abi.MethodNum{2}}))
+
+	// a call is hit once, by the first breakpoint matched
+	hits = run(api.Breakpoint{Actor: acc}, api.Breakpoint{})
+	require.Len(t, hits, 4)
+	require.Equal(t, []int{1, 0, 1, 1}, []int{hits[0].Breakpoint, hits[1].Breakpoint, hits[2].Breakpoint, hits[3].Breakpoint})
+}
@@ -3,7 +3,9 @@ package full
 import (
 	"context"
 	"os"
+	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -28,6 +30,10 @@ type SyncAPI struct {
 	NetName     dtypes.NetworkName
 }
 
+// SyncProgressInterval is the interval at which SyncProgress streams the
+// sync state.
+var SyncProgressInterval = 5 * time.Second
+
 func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
 	states := a.Syncer.State()
 
@@ -46,11 +52,45 @@ func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
 			Start:    ss.Start,
 			End:      ss.End,
 			Message:  ss.Message,
+
+			Stages:          ss.Stages,
+			EpochsRemaining: ss.EpochsRemaining,
+			ETA:             ss.ETA,
 		})
 	}
 	return out, nil
 }
 
+func (a *SyncAPI) SyncProgress(ctx context.Context) (<-chan *api.SyncState, error) {
+	out := make(chan *api.SyncState)
+	go func() {
+		defer close(out)
+
+		ticker := build.Clock.Ticker(SyncProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			state, err := a.SyncState(ctx)
+			if err != nil {
+				log.Errorw("getting sync state", "error", err)
+				return
+			}
+			select {
+			case out <- state:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
 func (a *SyncAPI) SyncSubmitBlock(ctx context.Context, blk *types.BlockMsg) error {
 	parent, err := a.Syncer.ChainStore().GetBlock(ctx, blk.Header.Parents[0])
 	if err != nil {
@@ -137,6 +177,54 @@ func (a *SyncAPI) SyncCheckBad(ctx context.Context, bcid cid.Cid) (string, error
 	return reason, nil
 }
 
+func (a *SyncAPI) SyncInspectBad(ctx context.Context, bcid cid.Cid) (*api.BadBlock, error) {
+	e, ok := a.Syncer.BadBlock(bcid)
+	if !ok {
+		return nil, nil
+	}
+	bb := badBlock(bcid, e)
+	return &bb, nil
+}
+
+func (a *SyncAPI) SyncListBad(ctx context.Context) ([]api.BadBlock, error) {
+	entries := a.Syncer.BadBlocks()
+	out := make([]api.BadBlock, 0, len(entries))
+	for c, e := range entries {
+		out = append(out, badBlock(c, e))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Added.Before(out[j].Added)
+	})
+	return out, nil
+}
+
+func (a *SyncAPI) SyncPeerScores(ctx context.Context) ([]api.SyncPeerScore, error) {
+	scores := a.Syncer.Exchange.PeerScores()
+	out := make([]api.SyncPeerScore, len(scores))
+	for i, ps := range scores {
+		out[i] = api.SyncPeerScore{
+			Peer:      ps.Peer,
+			FirstSeen: ps.FirstSeen,
+			Successes: ps.Successes,
+			Failures:  ps.Failures,
+			Latency:   ps.Latency,
+			Bandwidth: ps.Bandwidth,
+			Cost:      ps.Cost,
+		}
+	}
+	return out, nil
+}
+
+func badBlock(c cid.Cid, e chain.BadBlockEntry) api.BadBlock {
+	return api.BadBlock{
+		Cid:     c,
+		Reason:  e.Reason.String(),
+		TipSet:  e.Reason.TipSet,
+		Added:   e.Added,
+		Expires: e.Expires,
+	}
+}
+
 func (a *SyncAPI) SyncValidateTipset(ctx context.Context, tsk types.TipSetKey) (bool, error) {
 	ts, err := a.Syncer.ChainStore().LoadTipSet(ctx, tsk)
 	if err != nil {
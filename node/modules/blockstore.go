@@ -5,13 +5,17 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 	"go.uber.org/fx"
+	"go.uber.org/multierr"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/blockstore"
 	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	_ "github.com/filecoin-project/lotus/blockstore/s3"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -37,6 +41,60 @@ func UniversalBlockstore(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.Locked
 	return bs, err
 }
 
+// BackendBlockstore returns the universal blockstore opened from the blockstore
+// backend of the config, in place of the badger blockstore of the repo.
+func BackendBlockstore(cfg *config.BlockstoreBackend) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.UniversalBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.UniversalBlockstore, error) {
+		path := filepath.Join(r.Path(), "datastore", cfg.Type)
+		bs, err := blockstore.OpenBackend(helpers.LifecycleCtx(mctx, lc), cfg.Type, path, cfg.Params)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := bs.(io.Closer); ok {
+			lc.Append(fx.Hook{
+				OnStop: func(_ context.Context) error {
+					return c.Close()
+				},
+			})
+		}
+		return bs, nil
+	}
+}
+
+// ReplicatedBlockstore returns the universal blockstore of the config, spreading
+// its reads over the read replicas of the config.
+func ReplicatedBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.UniversalBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.UniversalBlockstore, error) {
+		var primary dtypes.UniversalBlockstore
+		var err error
+		if cfg.Backend.Type != "" {
+			primary, err = BackendBlockstore(&cfg.Backend)(lc, mctx, r)
+		} else {
+			primary, err = UniversalBlockstore(lc, mctx, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		replicas := make([]blockstore.Blockstore, 0, len(cfg.ReadReplicas))
+		for i, rcfg := range cfg.ReadReplicas {
+			params := map[string]string{"readonly": "true"}
+			for k, v := range rcfg.Params {
+				params[k] = v
+			}
+			rcfg.Params = params
+
+			rep, err := BackendBlockstore(&rcfg)(lc, mctx, r)
+			if err != nil {
+				return nil, xerrors.Errorf("opening read replica %d: %w", i, err)
+			}
+			replicas = append(replicas, rep)
+		}
+
+		return blockstore.NewReplicated(primary, replicas...), nil
+	}
+}
+
 func MemoryBlockstore() dtypes.UniversalBlockstore {
 	return blockstore.NewMemory()
 }
@@ -74,6 +132,42 @@ func BadgerHotBlockstore(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.HotBlocksto
 	return bs, nil
 }
 
+func NoChainColdBlockstore() dtypes.ChainColdBlockstore {
+	return nil
+}
+
+// BadgerChainColdBlockstore opens the badger blockstore at the ColdStorePath of
+// the history pruning config, relative to the repo, that the pruned chain
+// history is moved to.
+func BadgerChainColdBlockstore(cfg *config.HistoryPruning) func(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.ChainColdBlockstore, error) {
+	return func(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.ChainColdBlockstore, error) {
+		path := cfg.ColdStorePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.Path(), path)
+		}
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return nil, err
+		}
+
+		opts, err := repo.BadgerBlockstoreOptions(repo.ChainColdBlockstore, path, r.Readonly())
+		if err != nil {
+			return nil, err
+		}
+
+		bs, err := badgerbs.Open(opts)
+		if err != nil {
+			return nil, xerrors.Errorf("opening chain cold store: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return bs.Close()
+			}})
+
+		return bs, nil
+	}
+}
+
 func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.LockedRepo, ds dtypes.MetadataDS, cold dtypes.ColdBlockstore, hot dtypes.HotBlockstore) (dtypes.SplitBlockstore, error) {
 	return func(lc fx.Lifecycle, r repo.LockedRepo, ds dtypes.MetadataDS, cold dtypes.ColdBlockstore, hot dtypes.HotBlockstore) (dtypes.SplitBlockstore, error) {
 		path, err := r.SplitstorePath()
@@ -81,20 +175,42 @@ func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.Locked
 			return nil, err
 		}
 
-		cfg := &splitstore.Config{
+		ssCfg := &splitstore.Config{
 			MarkSetType:              cfg.Splitstore.MarkSetType,
 			DiscardColdBlocks:        cfg.Splitstore.ColdStoreType == "discard",
 			UniversalColdBlocks:      cfg.Splitstore.ColdStoreType == "universal",
 			HotStoreMessageRetention: cfg.Splitstore.HotStoreMessageRetention,
 			HotStoreFullGCFrequency:  cfg.Splitstore.HotStoreFullGCFrequency,
 		}
-		ss, err := splitstore.Open(path, ds, hot, cold, cfg)
+
+		var archive *splitstore.CarArchive
+		if dest := cfg.Splitstore.ColdArchiveDestination; dest != "" {
+			if !strings.Contains(dest, "://") && !filepath.IsAbs(dest) {
+				dest = filepath.Join(r.Path(), dest)
+			}
+			archive, err = splitstore.NewCarArchive(splitstore.CarArchiveConfig{
+				Destination: dest,
+				Endpoint:    cfg.Splitstore.ColdArchiveEndpoint,
+				MaxFileSize: cfg.Splitstore.ColdArchiveMaxFileSize,
+			})
+			if err != nil {
+				return nil, err
+			}
+			ssCfg.ColdExporter = archive
+		}
+
+		ss, err := splitstore.Open(path, ds, hot, cold, ssCfg)
 		if err != nil {
 			return nil, err
 		}
 		lc.Append(fx.Hook{
 			OnStop: func(context.Context) error {
-				return ss.Close()
+				err := ss.Close()
+				if archive != nil {
+					// the archive is closed once no compaction is moving blocks
+					err = multierr.Append(err, archive.Close())
+				}
+				return err
 			},
 		})
 
@@ -138,6 +254,65 @@ func FallbackStateBlockstore(sbs dtypes.BasicStateBlockstore) dtypes.StateBlocks
 	return &blockstore.FallbackStore{Blockstore: sbs}
 }
 
+// CachedChainBlockstore caches the blocks of the chain blockstore in memory.
+func CachedChainBlockstore(cfg *config.BlockstoreCache) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, cbs dtypes.BasicChainBlockstore) (dtypes.ChainBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, cbs dtypes.BasicChainBlockstore) (dtypes.ChainBlockstore, error) {
+		return cachedBlockstore(lc, mctx, cbs, "chain", cfg.ChainBytes, cfg)
+	}
+}
+
+// StateBlockstore batches the writes of the state blockstore, and caches its
+// blocks in memory, as configured.
+func StateBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, sbs dtypes.BasicStateBlockstore) (dtypes.StateBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, sbs dtypes.BasicStateBlockstore) (dtypes.StateBlockstore, error) {
+		var bs blockstore.Blockstore = sbs
+		if wb := cfg.StateWriteBatching; wb.Enable {
+			gbs := blockstore.NewGroupCommit(helpers.LifecycleCtx(mctx, lc), bs, blockstore.GroupCommitConfig{
+				FlushInterval: time.Duration(wb.FlushInterval),
+				MaxBytes:      wb.MaxBytes,
+			})
+			lc.Append(fx.Hook{
+				OnStop: gbs.Shutdown,
+			})
+			bs = gbs
+		}
+
+		if cfg.BlockstoreCache.Policy == "" {
+			return bs, nil
+		}
+		return cachedBlockstore(lc, mctx, bs, "state", cfg.BlockstoreCache.StateBytes, &cfg.BlockstoreCache)
+	}
+}
+
+func cachedBlockstore(lc fx.Lifecycle, mctx helpers.MetricsCtx, bs blockstore.Blockstore, name string, size int64, cfg *config.BlockstoreCache) (blockstore.Blockstore, error) {
+	if size <= 0 {
+		return bs, nil
+	}
+
+	cbs, err := blockstore.NewCached(bs, blockstore.CacheConfig{
+		Name:         name,
+		Policy:       cfg.Policy,
+		MaxBytes:     size,
+		MaxBlockSize: cfg.MaxBlockBytes,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("creating %s blockstore cache: %w", name, err)
+	}
+
+	ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go cbs.EmitMetrics(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return cbs, nil
+}
+
 func InitFallbackBlockstores(cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockstore, rem dtypes.ChainBitswap) error {
 	for _, bs := range []bstore.Blockstore{cbs, sbs} {
 		if fbs, ok := bs.(*blockstore.FallbackStore); ok {
@@ -7,25 +7,40 @@ import (
 	"github.com/ipfs/go-bitswap"
 	"github.com/ipfs/go-bitswap/network"
 	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	ipfsexchange "github.com/ipfs/go-ipfs-exchange-interface"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/routing"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/actorindex"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/exchange"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/snapshots"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
@@ -73,17 +88,38 @@ func MessagePool(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSched
 	return mp, nil
 }
 
+// MpoolPriorityAddrs sets the priority addresses of the Mpool section of the
+// config on the mpool.
+func MpoolPriorityAddrs(cfg *config.Mpool) func(*messagepool.MessagePool) error {
+	return func(mp *messagepool.MessagePool) error {
+		addrs := make([]address.Address, 0, len(cfg.PriorityAddrs))
+		for _, s := range cfg.PriorityAddrs {
+			addr, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing mpool priority address %q: %w", s, err)
+			}
+			addrs = append(addrs, addr)
+		}
+		mp.SetPriorityAddrs(addrs)
+		return nil
+	}
+}
+
 func ChainStore(lc fx.Lifecycle,
 	mctx helpers.MetricsCtx,
 	cbs dtypes.ChainBlockstore,
 	sbs dtypes.StateBlockstore,
 	ds dtypes.MetadataDS,
 	basebs dtypes.BaseBlockstore,
+	cold dtypes.ChainColdBlockstore,
 	weight store.WeightFunc,
 	us stmgr.UpgradeSchedule,
 	j journal.Journal) *store.ChainStore {
 
 	chain := store.NewChainStore(cbs, sbs, ds, weight, j)
+	if cold != nil {
+		chain.SetColdStore(cold)
+	}
 
 	if err := chain.Load(helpers.LifecycleCtx(mctx, lc)); err != nil {
 		log.Warnf("loading chain state from disk: %s", err)
@@ -174,6 +210,37 @@ func NewSyncer(params SyncerParams) (*chain.Syncer, error) {
 	return syncer, nil
 }
 
+// SyncPipelineDepth sets the depth of the validation pipeline of the syncer to
+// the one of the Sync section of the config.
+func SyncPipelineDepth(cfg *config.Sync) func(*chain.Syncer) {
+	return func(syncer *chain.Syncer) {
+		syncer.PipelineDepth = cfg.PipelineDepth
+	}
+}
+
+// ChainExchangeClient returns the chain exchange client, scoring the peers
+// with the weights of the ChainExchange section of the config.
+func ChainExchangeClient(cfg *config.ChainExchange) func(fx.Lifecycle, host.Host, peermgr.MaybePeerMgr) exchange.Client {
+	return func(lc fx.Lifecycle, h host.Host, pmgr peermgr.MaybePeerMgr) exchange.Client {
+		return exchange.NewClientWithScoring(lc, h, pmgr, exchange.ScoringConfig{
+			LatencyWeight:   cfg.LatencyWeight,
+			BandwidthWeight: cfg.BandwidthWeight,
+			FailureWeight:   cfg.FailureWeight,
+		})
+	}
+}
+
+// HeadersOnlySyncer is NewSyncer, with a syncer only syncing the headers of the
+// chain.
+func HeadersOnlySyncer(params SyncerParams) (*chain.Syncer, error) {
+	syncer, err := NewSyncer(params)
+	if err != nil {
+		return nil, err
+	}
+	syncer.HeadersOnly = true
+	return syncer, nil
+}
+
 func NewSlashFilter(ds dtypes.MetadataDS) *slashfilter.SlashFilter {
 	return slashfilter.New(ds)
 }
@@ -181,3 +248,350 @@ func NewSlashFilter(ds dtypes.MetadataDS) *slashfilter.SlashFilter {
 func UpgradeSchedule() stmgr.UpgradeSchedule {
 	return filcns.DefaultUpgradeSchedule()
 }
+
+// ReorgGuard holds back the reorgs deeper than the Chainstore.ReorgConfirmDepth
+// of the config until they are accepted or rejected through the API.
+func ReorgGuard(cfg *config.Chainstore) func(*store.ChainStore, *alerting.Alerting) {
+	return func(cs *store.ChainStore, al *alerting.Alerting) {
+		cs.SetReorgConfirmDepth(abi.ChainEpoch(cfg.ReorgConfirmDepth), al)
+	}
+}
+
+// TipSetCache sizes the tipset cache of the chainstore as configured in the
+// Chainstore.TipSetCache section of the config.
+func TipSetCache(cfg *config.TipSetCache) func(*store.ChainStore) {
+	return func(cs *store.ChainStore) {
+		if cfg.Size == 0 && cfg.RecentEpochs == 0 {
+			return
+		}
+		size, recent := store.DefaultTipSetCacheSize, store.DefaultTipSetCacheRecentEpochs
+		if cfg.Size > 0 {
+			size = cfg.Size
+		}
+		if cfg.RecentEpochs > 0 {
+			recent = abi.ChainEpoch(cfg.RecentEpochs)
+		}
+		cs.SetTipSetCache(size, recent)
+	}
+}
+
+// TrustedCheckpointTimeout is how long fetching the trusted checkpoint of the
+// config from the network may take.
+var TrustedCheckpointTimeout = 10 * time.Minute
+
+// TrustedCheckpoint starts the chain from the tipset of the
+// Chainstore.TrustedCheckpoint section of the config, if the head is below it.
+func TrustedCheckpoint(cfg *config.TrustedCheckpoint) func(helpers.MetricsCtx, fx.Lifecycle, *store.ChainStore) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore) error {
+		cids := make([]cid.Cid, 0, len(cfg.TipSet))
+		for _, s := range cfg.TipSet {
+			c, err := cid.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("parsing trusted checkpoint block cid %q: %w", s, err)
+			}
+			cids = append(cids, c)
+		}
+		stateRoot, err := cid.Decode(cfg.StateRoot)
+		if err != nil {
+			return xerrors.Errorf("parsing trusted checkpoint state root %q: %w", cfg.StateRoot, err)
+		}
+
+		ctx, cancel := context.WithTimeout(helpers.LifecycleCtx(mctx, lc), TrustedCheckpointTimeout)
+		defer cancel()
+
+		moved, err := cs.StartFromTrustedTipSet(ctx, types.NewTipSetKey(cids...), stateRoot)
+		if err != nil {
+			return xerrors.Errorf("starting from the trusted checkpoint: %w", err)
+		}
+		if !moved {
+			log.Infow("the head is past the trusted checkpoint, syncing from the head", "head", cs.GetHeaviestTipSet().Height())
+		}
+		return nil
+	}
+}
+
+// HistoryPruning prunes the chain history periodically, as configured in the
+// Chainstore.HistoryPruning section of the config.
+func HistoryPruning(cfg *config.HistoryPruning) func(helpers.MetricsCtx, fx.Lifecycle, *store.ChainStore, *snapshots.Scheduler) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, ss *snapshots.Scheduler) error {
+		if cfg.Retention == 0 {
+			return nil
+		}
+		retention := abi.ChainEpoch(cfg.Retention)
+		if retention < build.Finality {
+			return xerrors.Errorf("history retention %d is below finality (%d)", retention, build.Finality)
+		}
+		if cfg.Interval <= 0 {
+			return xerrors.Errorf("history pruning interval must be positive")
+		}
+
+		prune := func(ctx context.Context) {
+			head := cs.GetHeaviestTipSet()
+			_, err := cs.PruneChain(ctx, store.PruneOpts{
+				Horizon: head.Height() - retention,
+				Protect: ss.Roots(),
+				Offload: cs.ColdStore() != nil,
+			})
+			if err != nil {
+				log.Errorw("pruning chain history", "error", err)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+
+					ticker := build.Clock.Ticker(time.Duration(cfg.Interval))
+					defer ticker.Stop()
+					for {
+						select {
+						case <-ticker.C:
+							prune(ctx)
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
+// BlockstoreGCScheduler runs the online garbage collection of the universal
+// blockstore as configured in the Chainstore.BlockstoreGC section of the
+// config, holding it off while the node is syncing or the system is loaded.
+func BlockstoreGCScheduler(cfg *config.BlockstoreGC) func(helpers.MetricsCtx, fx.Lifecycle, *store.ChainStore, dtypes.UniversalBlockstore) (*gcsched.Scheduler, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, bs dtypes.UniversalBlockstore) (*gcsched.Scheduler, error) {
+		gc, ok := bs.(blockstore.BlockstoreGC)
+		if !ok {
+			log.Infof("blockstore (%T) doesn't support garbage collection, not scheduling it", bs)
+			return nil, nil
+		}
+
+		windows := make([]gcsched.Window, 0, len(cfg.Windows))
+		for _, s := range cfg.Windows {
+			w, err := gcsched.ParseWindow(s)
+			if err != nil {
+				return nil, err
+			}
+			windows = append(windows, w)
+		}
+
+		busy := func() bool {
+			// the node is syncing when its head is more than a few epochs old
+			head := cs.GetHeaviestTipSet()
+			if head == nil || build.Clock.Since(time.Unix(int64(head.MinTimestamp()), 0)) > 10*time.Duration(build.BlockDelaySecs)*time.Second {
+				return true
+			}
+			if cfg.MaxLoad <= 0 {
+				return false
+			}
+			load, err := gcsched.SystemLoad()
+			return err == nil && load > cfg.MaxLoad
+		}
+
+		s := gcsched.New(gc, gcsched.Config{
+			Interval: time.Duration(cfg.Interval),
+			Windows:  windows,
+		}, busy)
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					s.Run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+
+		return s, nil
+	}
+}
+
+type BlockstoreScrubParams struct {
+	fx.In
+
+	MetricsCtx helpers.MetricsCtx
+	Lifecycle  fx.Lifecycle
+	Universal  dtypes.UniversalBlockstore
+	Hot        dtypes.HotBlockstore `optional:"true"`
+	Bitswap    dtypes.ChainBitswap
+	Alerting   *alerting.Alerting
+}
+
+// BlockstoreScrub verifies a sample of the blocks of the universal blockstore,
+// and of the hotstore when the splitstore is enabled, as configured in the
+// Chainstore.BlockstoreScrub section of the config.
+func BlockstoreScrub(cfg *config.BlockstoreScrub) func(BlockstoreScrubParams) error {
+	return func(p BlockstoreScrubParams) error {
+		stores := []scrub.Store{{Name: "universal", Blockstore: p.Universal}}
+		if p.Hot != nil {
+			stores[0].Name = "cold"
+			stores = append(stores, scrub.Store{Name: "hot", Blockstore: p.Hot})
+		}
+
+		var fetcher ipfsexchange.Fetcher
+		if cfg.Refetch {
+			fetcher = p.Bitswap
+		}
+
+		s, err := scrub.New(stores, scrub.Config{
+			Interval: time.Duration(cfg.Interval),
+			Fraction: cfg.Fraction,
+		}, fetcher, p.Alerting)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(p.MetricsCtx, p.Lifecycle))
+		done := make(chan struct{})
+		p.Lifecycle.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					s.Run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
+// AutoResync resyncs the node from a trusted snapshot when it falls behind, as
+// configured in the Chainstore.AutoResync section of the config.
+func AutoResync(cfg *config.AutoResync) func(helpers.MetricsCtx, fx.Lifecycle, *store.ChainStore) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore) error {
+		w, err := snapshots.NewWatchdog(cs, snapshots.ResyncConfig{
+			Source: cfg.Source,
+			Digest: cfg.Digest,
+			MaxLag: abi.ChainEpoch(cfg.MaxLag),
+			Grace:  time.Duration(cfg.Grace),
+		})
+		if err != nil {
+			return xerrors.Errorf("setting up automatic resyncs: %w", err)
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					w.Run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
+// ActorIndex indexes the actors of the chain states as configured in the
+// Chainstore.ActorIndex section of the config.
+func ActorIndex(cfg *config.ActorIndex) func(helpers.MetricsCtx, fx.Lifecycle, *store.ChainStore, dtypes.MetadataDS) (*actorindex.Index, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, ds dtypes.MetadataDS) (*actorindex.Index, error) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		idx, err := actorindex.New(ctx, cs, namespace.Wrap(ds, datastore.NewKey("/actorindex")), actorindex.Config{
+			Enable:   cfg.Enable,
+			Backfill: abi.ChainEpoch(cfg.Backfill),
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("setting up actor index: %w", err)
+		}
+		if !idx.Enabled() {
+			return idx, nil
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					idx.Run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+
+		return idx, nil
+	}
+}
+
+// SnapshotScheduler exports snapshots of the chain as configured in the
+// Chainstore.Snapshots section of the config.
+func SnapshotScheduler(cfg *config.Snapshots) func(helpers.MetricsCtx, fx.Lifecycle, *store.ChainStore, dtypes.MetadataDS, dtypes.NetworkName) (*snapshots.Scheduler, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, ds dtypes.MetadataDS, nn dtypes.NetworkName) (*snapshots.Scheduler, error) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		s, err := snapshots.New(ctx, cs, ds, string(nn), snapshots.Config{
+			Interval:         abi.ChainEpoch(cfg.Interval),
+			Destination:      cfg.Destination,
+			Endpoint:         cfg.Endpoint,
+			RecentStateRoots: abi.ChainEpoch(cfg.RecentStateRoots),
+			SkipOldMessages:  cfg.SkipOldMessages,
+			Compression:      cfg.Compression,
+			Retain:           int(cfg.Retain),
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("setting up snapshots: %w", err)
+		}
+		if !s.Enabled() {
+			return s, nil
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					s.Run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+
+		return s, nil
+	}
+}
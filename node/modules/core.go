@@ -26,6 +26,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/lib/rpclimit"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -181,6 +182,20 @@ func APISecret(keystore types.KeyStore, lr repo.LockedRepo) (*dtypes.APIAlg, err
 	return (*dtypes.APIAlg)(jwt.NewHS256(key.PrivateKey)), nil
 }
 
+func RPCLimiter(cfg *config.APIRateLimits) func() (*rpclimit.Limiter, error) {
+	return func() (*rpclimit.Limiter, error) {
+		l, err := rpclimit.NewLimiter(rpclimit.Config{
+			PerToken: cfg.PerToken,
+			Burst:    cfg.Burst,
+			Methods:  cfg.Methods,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("setting up API rate limits: %w", err)
+		}
+		return l, nil
+	}
+}
+
 func ConfigBootstrap(peers []string) func() (dtypes.BootstrapPeers, error) {
 	return func() (dtypes.BootstrapPeers, error) {
 		return addrutil.ParseAddresses(context.TODO(), peers)
@@ -12,3 +12,7 @@ type APIAlg jwt.HMACSHA
 type APIEndpoint multiaddr.Multiaddr
 
 type NodeStartTime time.Time
+
+// ReadOnlyAPI tells whether the API only serves the calls needing no more than
+// the read permission.
+type ReadOnlyAPI bool
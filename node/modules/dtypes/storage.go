@@ -46,6 +46,10 @@ type (
 	// patterns.
 	ChainBlockstore blockstore.Blockstore
 
+	// ChainColdBlockstore is the tier the chainstore moves the old chain history
+	// to, or nil if it is deleted instead.
+	ChainColdBlockstore blockstore.Blockstore
+
 	// BasicStateBlockstore is like StateBlockstore, but without the optional
 	// network fallback support
 	BasicStateBlockstore blockstore.Blockstore
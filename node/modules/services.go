@@ -99,6 +99,7 @@ func RunPeerMgr(mctx helpers.MetricsCtx, lc fx.Lifecycle, pmgr *peermgr.PeerMgr)
 
 func RunChainExchange(h host.Host, svc exchange.Server) {
 	h.SetStreamHandler(exchange.ChainExchangeProtocolID, svc.HandleStream) // new
+	h.SetStreamHandler(exchange.ChainExchangeBatchProtocolID, svc.HandleBatchStream)
 }
 
 func waitForSync(stmgr *stmgr.StateManager, epochs int, subscribe func()) {
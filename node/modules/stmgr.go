@@ -1,12 +1,20 @@
 package modules
 
 import (
+	"context"
+
 	"go.uber.org/fx"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
 
 func StateManager(lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sys vm.SyscallBuilder, us stmgr.UpgradeSchedule, b beacon.Schedule) (*stmgr.StateManager, error) {
@@ -20,3 +28,50 @@ func StateManager(lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sy
 	})
 	return sm, nil
 }
+
+// ExecutionCache enables the execution cache of the state manager as configured
+// in the Chainstore.ExecutionCache section of the config.
+func ExecutionCache(cfg *config.ExecutionCache) func(*stmgr.StateManager) {
+	return func(sm *stmgr.StateManager) {
+		if !cfg.Enable {
+			return
+		}
+		lookback := stmgr.DefaultExecutionCacheLookback
+		if cfg.Lookback > 0 {
+			lookback = abi.ChainEpoch(cfg.Lookback)
+		}
+		sm.SetExecutionCache(lookback, build.UserVersion())
+	}
+}
+
+// SpeculativeExecution executes the candidate tipsets formed by the blocks
+// arriving to the syncer, from pubsub or the hello protocol, as enabled by the
+// Sync.SpeculativeExecution option of the config.
+func SpeculativeExecution(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm *stmgr.StateManager, syncer *chain.Syncer) {
+	sm.SetSpeculativeExecution(true)
+
+	ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			blocks, err := syncer.IncomingBlocks(ctx)
+			if err != nil {
+				return err
+			}
+			go func() {
+				for {
+					select {
+					case blk := <-blocks:
+						sm.SpeculateBlock(blk)
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
@@ -1,6 +1,29 @@
 package repo
 
-import badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+)
+
+func init() {
+	// the badger backend opens a chain blockstore out of the repo, e.g. a
+	// read replica
+	blockstore.RegisterBackend("badger", func(_ context.Context, _ string, params map[string]string) (blockstore.Blockstore, error) {
+		if params["path"] == "" {
+			return nil, xerrors.Errorf("the badger blockstore backend needs a path param")
+		}
+
+		opts, err := BadgerBlockstoreOptions(UniversalBlockstore, params["path"], params["readonly"] == "true")
+		if err != nil {
+			return nil, err
+		}
+		return badgerbs.Open(opts)
+	})
+}
 
 // BadgerBlockstoreOptions returns the badger options to apply for the provided
 // domain.
@@ -238,6 +238,11 @@ func (fsr *FsRepo) SetConfigPath(cfgPath string) {
 	fsr.configPath = cfgPath
 }
 
+// ConfigPath returns the path of the config file of the repo.
+func (fsr *FsRepo) ConfigPath() string {
+	return fsr.configPath
+}
+
 func (fsr *FsRepo) Exists() (bool, error) {
 	_, err := os.Stat(filepath.Join(fsr.path, fsDatastore))
 	notexist := os.IsNotExist(err)
@@ -407,6 +412,14 @@ type fsLockedRepo struct {
 	bs     blockstore.Blockstore
 	bsErr  error
 	bsOnce sync.Once
+	// bsSwap serves the universal blockstore in bsDir, until a standby
+	// blockstore is promoted
+	bsSwap *blockstore.SwapStore
+	bsDir  string
+
+	standbyLk sync.Mutex
+	standby   *fsStandby
+
 	ssPath string
 	ssErr  error
 	ssOnce sync.Once
@@ -441,6 +454,12 @@ func (fsr *fsLockedRepo) Close() error {
 		}
 	}
 
+	if fsr.standby != nil {
+		if err := fsr.standby.Discard(); err != nil {
+			return xerrors.Errorf("could not discard standby blockstore: %w", err)
+		}
+	}
+
 	// type assertion will return ok=false if fsr.bs is nil altogether.
 	if c, ok := fsr.bs.(io.Closer); ok && c != nil {
 		if err := c.Close(); err != nil {
@@ -460,46 +479,62 @@ func (fsr *fsLockedRepo) Blockstore(ctx context.Context, domain BlockstoreDomain
 	}
 
 	fsr.bsOnce.Do(func() {
-		path := fsr.join(filepath.Join(fsDatastore, "chain"))
-		readonly := fsr.readonly
-
-		if err := os.MkdirAll(path, 0755); err != nil {
-			fsr.bsErr = err
-			return
-		}
-
-		opts, err := BadgerBlockstoreOptions(domain, path, readonly)
+		dir, err := fsr.chainDir()
 		if err != nil {
 			fsr.bsErr = err
 			return
 		}
 
-		//
-		// Tri-state environment variable LOTUS_CHAIN_BADGERSTORE_DISABLE_FSYNC
-		// - unset == the default (currently fsync enabled)
-		// - set with a false-y value == fsync enabled no matter what a future default is
-		// - set with any other value == fsync is disabled ignored defaults (recommended for day-to-day use)
-		//
-		if nosyncBs, nosyncBsSet := os.LookupEnv("LOTUS_CHAIN_BADGERSTORE_DISABLE_FSYNC"); nosyncBsSet {
-			nosyncBs = strings.ToLower(nosyncBs)
-			if nosyncBs == "" || nosyncBs == "0" || nosyncBs == "false" || nosyncBs == "no" {
-				opts.SyncWrites = true
-			} else {
-				opts.SyncWrites = false
-			}
-		}
-
-		bs, err := badgerbs.Open(opts)
+		bs, err := fsr.openChainBlockstore(dir)
 		if err != nil {
 			fsr.bsErr = err
 			return
 		}
-		fsr.bs = blockstore.WrapIDStore(bs)
+		fsr.bsDir = dir
+		fsr.bsSwap = blockstore.NewSwapStore(bs)
+		fsr.bs = fsr.bsSwap
 	})
 
 	return fsr.bs, fsr.bsErr
 }
 
+// openChainBlockstore opens the universal blockstore in the given directory of
+// the datastore.
+func (fsr *fsLockedRepo) openChainBlockstore(dir string) (blockstore.Blockstore, error) {
+	path := fsr.join(filepath.Join(fsDatastore, dir))
+	readonly := fsr.readonly
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	opts, err := BadgerBlockstoreOptions(UniversalBlockstore, path, readonly)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	// Tri-state environment variable LOTUS_CHAIN_BADGERSTORE_DISABLE_FSYNC
+	// - unset == the default (currently fsync enabled)
+	// - set with a false-y value == fsync enabled no matter what a future default is
+	// - set with any other value == fsync is disabled ignored defaults (recommended for day-to-day use)
+	//
+	if nosyncBs, nosyncBsSet := os.LookupEnv("LOTUS_CHAIN_BADGERSTORE_DISABLE_FSYNC"); nosyncBsSet {
+		nosyncBs = strings.ToLower(nosyncBs)
+		if nosyncBs == "" || nosyncBs == "0" || nosyncBs == "false" || nosyncBs == "no" {
+			opts.SyncWrites = true
+		} else {
+			opts.SyncWrites = false
+		}
+	}
+
+	bs, err := badgerbs.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return blockstore.WrapIDStore(bs), nil
+}
+
 func (fsr *fsLockedRepo) SplitstorePath() (string, error) {
 	fsr.ssOnce.Do(func() {
 		path := fsr.join(filepath.Join(fsDatastore, "splitstore"))
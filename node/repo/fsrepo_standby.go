@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+const (
+	// fsChainDir is the directory of the universal blockstore in the
+	// datastore, standby blockstores are in directories named after it.
+	fsChainDir = "chain"
+	// fsChainActive holds the name of the directory of the universal
+	// blockstore once a standby blockstore was promoted.
+	fsChainActive = "chain.active"
+)
+
+// chainDir returns the directory of the universal blockstore in the datastore.
+func (fsr *fsLockedRepo) chainDir() (string, error) {
+	return chainDir(fsr.join(fsDatastore))
+}
+
+func chainDir(datastorePath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(datastorePath, fsChainActive))
+	if os.IsNotExist(err) {
+		return fsChainDir, nil
+	}
+	if err != nil {
+		return "", xerrors.Errorf("reading %s: %w", fsChainActive, err)
+	}
+
+	dir := strings.TrimSpace(string(data))
+	if dir == "" || dir != filepath.Base(dir) || !isChainDir(dir) {
+		return "", xerrors.Errorf("invalid universal blockstore directory %q in %s", dir, fsChainActive)
+	}
+	return dir, nil
+}
+
+// UniversalBlockstorePath returns the path of the universal blockstore of the
+// fs repo at repoPath, which moves when a standby blockstore is promoted.
+func UniversalBlockstorePath(repoPath string) (string, error) {
+	dir, err := chainDir(filepath.Join(repoPath, fsDatastore))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoPath, fsDatastore, dir), nil
+}
+
+func isChainDir(name string) bool {
+	return name == fsChainDir || strings.HasPrefix(name, fsChainDir+"-")
+}
+
+type fsStandby struct {
+	blockstore.Blockstore
+
+	fsr *fsLockedRepo
+	dir string
+}
+
+// StandbyBlockstore opens an empty universal blockstore in a new directory of
+// the datastore. Once it is promoted, the repo opens it in place of the
+// current one from then on.
+func (fsr *fsLockedRepo) StandbyBlockstore(ctx context.Context, domain BlockstoreDomain) (blockstore.StandbyBlockstore, error) {
+	if domain != UniversalBlockstore {
+		return nil, ErrInvalidBlockstoreDomain
+	}
+	if fsr.readonly {
+		return nil, xerrors.Errorf("cannot open a standby blockstore in a readonly repo")
+	}
+	if _, err := fsr.Blockstore(ctx, domain); err != nil {
+		return nil, err
+	}
+
+	fsr.standbyLk.Lock()
+	defer fsr.standbyLk.Unlock()
+
+	if fsr.standby != nil {
+		return nil, xerrors.Errorf("a standby blockstore is already open")
+	}
+
+	// standby blockstores that were never promoted, and blockstores that
+	// were replaced but not removed, are left behind by crashes
+	entries, err := os.ReadDir(fsr.join(fsDatastore))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !isChainDir(e.Name()) || e.Name() == fsr.bsDir {
+			continue
+		}
+		log.Warnw("removing stale universal blockstore", "dir", e.Name())
+		if err := os.RemoveAll(fsr.join(fsDatastore, e.Name())); err != nil {
+			return nil, xerrors.Errorf("removing stale universal blockstore: %w", err)
+		}
+	}
+
+	dir := fmt.Sprintf("%s-%d", fsChainDir, time.Now().UnixNano())
+	bs, err := fsr.openChainBlockstore(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("opening standby blockstore: %w", err)
+	}
+
+	fsr.standby = &fsStandby{Blockstore: bs, fsr: fsr, dir: dir}
+	return fsr.standby, nil
+}
+
+// Promote records the standby as the universal blockstore of the repo, then
+// swaps it in for the blockstore returned by Blockstore, and removes the
+// blockstore it replaces.
+func (s *fsStandby) Promote() error {
+	fsr := s.fsr
+	fsr.standbyLk.Lock()
+	defer fsr.standbyLk.Unlock()
+
+	if fsr.standby != s {
+		return xerrors.Errorf("standby blockstore is no longer open")
+	}
+	fsr.standby = nil
+
+	if err := writeChainActive(fsr.join(fsDatastore, fsChainActive), s.dir); err != nil {
+		_ = s.remove()
+		return xerrors.Errorf("recording the promoted blockstore: %w", err)
+	}
+
+	old := fsr.bsSwap.Swap(s.Blockstore)
+	oldDir := fsr.bsDir
+	fsr.bsDir = s.dir
+
+	if c, ok := old.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return xerrors.Errorf("closing replaced blockstore: %w", err)
+		}
+	}
+	if err := os.RemoveAll(fsr.join(fsDatastore, oldDir)); err != nil {
+		return xerrors.Errorf("removing replaced blockstore: %w", err)
+	}
+	return nil
+}
+
+func (s *fsStandby) Discard() error {
+	fsr := s.fsr
+	fsr.standbyLk.Lock()
+	defer fsr.standbyLk.Unlock()
+
+	if fsr.standby != s {
+		return nil
+	}
+	fsr.standby = nil
+	return s.remove()
+}
+
+func (s *fsStandby) remove() error {
+	if c, ok := s.Blockstore.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return xerrors.Errorf("closing standby blockstore: %w", err)
+		}
+	}
+	return os.RemoveAll(s.fsr.join(fsDatastore, s.dir))
+}
+
+func writeChainActive(path, dir string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(dir + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
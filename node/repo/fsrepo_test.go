@@ -2,7 +2,13 @@
 package repo
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/stretchr/testify/require"
 )
 
 func genFsRepo(t *testing.T) *FsRepo {
@@ -29,3 +35,63 @@ func TestFsBasic(t *testing.T) {
 	repo := genFsRepo(t)
 	basicTest(t, repo)
 }
+
+func TestFsStandbyBlockstore(t *testing.T) {
+	ctx := context.Background()
+	repo := genFsRepo(t)
+
+	a, b := blocks.NewBlock([]byte("a")), blocks.NewBlock([]byte("b"))
+
+	lr, err := repo.Lock(FullNode)
+	require.NoError(t, err)
+	bs, err := lr.Blockstore(ctx, UniversalBlockstore)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, a))
+
+	// a discarded standby leaves the blockstore untouched
+	standby, err := lr.StandbyBlockstore(ctx, UniversalBlockstore)
+	require.NoError(t, err)
+	_, err = lr.StandbyBlockstore(ctx, UniversalBlockstore)
+	require.Error(t, err)
+	require.NoError(t, standby.Put(ctx, b))
+	require.NoError(t, standby.Discard())
+
+	has, err := bs.Has(ctx, b.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// a promoted standby serves in place of the blockstore
+	standby, err = lr.StandbyBlockstore(ctx, UniversalBlockstore)
+	require.NoError(t, err)
+	require.NoError(t, standby.Put(ctx, b))
+	require.NoError(t, standby.Promote())
+
+	has, err = bs.Has(ctx, b.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = bs.Has(ctx, a.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	entries, err := os.ReadDir(filepath.Join(repo.path, fsDatastore))
+	require.NoError(t, err)
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && isChainDir(e.Name()) {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	require.Len(t, dirs, 1)
+	require.NotEqual(t, fsChainDir, dirs[0])
+	require.NoError(t, lr.Close())
+
+	// and is opened after a restart
+	lr, err = repo.Lock(FullNode)
+	require.NoError(t, err)
+	defer lr.Close() //nolint:errcheck
+	bs, err = lr.Blockstore(ctx, UniversalBlockstore)
+	require.NoError(t, err)
+	has, err = bs.Has(ctx, b.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+}
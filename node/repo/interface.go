@@ -23,6 +23,9 @@ const (
 	// domains.
 	UniversalBlockstore = BlockstoreDomain("universal")
 	HotBlockstore       = BlockstoreDomain("hot")
+	// ChainColdBlockstore is the domain of the tier old chain history is moved
+	// to.
+	ChainColdBlockstore = BlockstoreDomain("chaincold")
 )
 
 var (
@@ -66,6 +69,12 @@ type LockedRepo interface {
 	// the lifecycle.
 	Blockstore(ctx context.Context, domain BlockstoreDomain) (blockstore.Blockstore, error)
 
+	// StandbyBlockstore returns an empty blockstore for the requested domain,
+	// to be filled while the current one keeps serving. Once it is promoted,
+	// the blockstore returned by Blockstore serves from it, also after the
+	// repo is reopened. Only one standby blockstore can be open at a time.
+	StandbyBlockstore(ctx context.Context, domain BlockstoreDomain) (blockstore.StandbyBlockstore, error)
+
 	// SplitstorePath returns the path for the SplitStore
 	SplitstorePath() (string, error)
 
@@ -34,7 +34,8 @@ type MemRepo struct {
 
 	datastore  datastore.Datastore
 	keystore   map[string]types.KeyInfo
-	blockstore blockstore.Blockstore
+	blockstore *blockstore.SwapStore
+	standby    *memStandby
 
 	sc      *storiface.StorageConfig
 	tempDir string
@@ -171,7 +172,7 @@ func NewMemory(opts *MemRepoOptions) *MemRepo {
 
 	return &MemRepo{
 		repoLock:   make(chan struct{}, 1),
-		blockstore: blockstore.WrapIDStore(blockstore.NewMemorySync()),
+		blockstore: blockstore.NewSwapStore(blockstore.WrapIDStore(blockstore.NewMemorySync())),
 		datastore:  opts.Ds,
 		keystore:   opts.KeyStore,
 	}
@@ -269,6 +270,49 @@ func (lmem *lockedMemRepo) Blockstore(ctx context.Context, domain BlockstoreDoma
 	return lmem.mem.blockstore, nil
 }
 
+type memStandby struct {
+	blockstore.Blockstore
+
+	lmem *lockedMemRepo
+}
+
+func (lmem *lockedMemRepo) StandbyBlockstore(ctx context.Context, domain BlockstoreDomain) (blockstore.StandbyBlockstore, error) {
+	if domain != UniversalBlockstore {
+		return nil, ErrInvalidBlockstoreDomain
+	}
+
+	lmem.Lock()
+	defer lmem.Unlock()
+	if lmem.mem.standby != nil {
+		return nil, xerrors.Errorf("a standby blockstore is already open")
+	}
+	lmem.mem.standby = &memStandby{
+		Blockstore: blockstore.WrapIDStore(blockstore.NewMemorySync()),
+		lmem:       lmem,
+	}
+	return lmem.mem.standby, nil
+}
+
+func (s *memStandby) Promote() error {
+	s.lmem.Lock()
+	defer s.lmem.Unlock()
+	if s.lmem.mem.standby != s {
+		return xerrors.Errorf("standby blockstore is no longer open")
+	}
+	s.lmem.mem.standby = nil
+	s.lmem.mem.blockstore.Swap(s.Blockstore)
+	return nil
+}
+
+func (s *memStandby) Discard() error {
+	s.lmem.Lock()
+	defer s.lmem.Unlock()
+	if s.lmem.mem.standby == s {
+		s.lmem.mem.standby = nil
+	}
+	return nil
+}
+
 func (lmem *lockedMemRepo) SplitstorePath() (string, error) {
 	splitstorePath := filepath.Join(lmem.Path(), "splitstore")
 	if err := os.MkdirAll(splitstorePath, 0755); err != nil {
@@ -28,7 +28,9 @@ import (
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/lib/rpcbatch"
 	"github.com/filecoin-project/lotus/lib/rpcenc"
+	"github.com/filecoin-project/lotus/lib/rpclimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/metrics/proxy"
 	"github.com/filecoin-project/lotus/node/impl"
@@ -71,7 +73,11 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 }
 
 // FullNodeHandler returns a full node handler, to be mounted as-is on the server.
-func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.ServerOption) (http.Handler, error) {
+// The JSON-RPC endpoints serve the batches of requests configured by batch, and
+// limit the rate of the calls of each caller as configured in the node. When the
+// node serves a read-only API, only the calls needing no more than the read
+// permission are served, whatever the permissions of the caller.
+func FullNodeHandler(a v1api.FullNode, permissioned bool, batch rpcbatch.Config, opts ...jsonrpc.ServerOption) (http.Handler, error) {
 	m := mux.NewRouter()
 
 	serveRpc := func(path string, hnd interface{}) {
@@ -79,18 +85,16 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 		rpcServer.Register("Filecoin", hnd)
 		rpcServer.AliasMethod("rpc.discover", "Filecoin.Discover")
 
-		var handler http.Handler = rpcServer
+		var handler http.Handler = rpcbatch.NewHandler(rpcServer, batch)
 		if permissioned {
-			handler = &auth.Handler{Verify: a.AuthVerify, Next: rpcServer.ServeHTTP}
+			handler = &auth.Handler{Verify: a.AuthVerify, Next: handler.ServeHTTP}
 		}
 
-		m.Handle(path, handler)
+		m.Handle(path, rpclimit.CallerHandler(handler))
 	}
 
-	fnapi := proxy.MetricedFullAPI(a)
-	if permissioned {
-		fnapi = api.PermissionedFullAPI(fnapi)
-	}
+	readOnly := bool(a.(*impl.FullNodeAPI).ReadOnlyAPI)
+	fnapi := servedFullAPI(a, permissioned)
 
 	serveRpc("/rpc/v1", fnapi)
 	serveRpc("/rpc/v0", &v0api.WrapperV1Full{FullNode: fnapi})
@@ -99,7 +103,10 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	handleImportFunc := handleImport(a.(*impl.FullNodeAPI))
 	handleExportFunc := handleExport(a.(*impl.FullNodeAPI))
 	handleRemoteStoreFunc := handleRemoteStore(a.(*impl.FullNodeAPI))
-	if permissioned {
+	switch {
+	case readOnly:
+		// the REST endpoints all need the write permission
+	case permissioned:
 		importAH := &auth.Handler{
 			Verify: a.AuthVerify,
 			Next:   handleImportFunc,
@@ -117,7 +124,7 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 			Next:   handleRemoteStoreFunc,
 		}
 		m.Handle("/rest/v0/store/{uuid}", storeAH)
-	} else {
+	default:
 		m.HandleFunc("/rest/v0/import", handleImportFunc)
 		m.HandleFunc("/rest/v0/export", handleExportFunc)
 		m.HandleFunc("/rest/v0/store/{uuid}", handleRemoteStoreFunc)
@@ -136,6 +143,22 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	return m, nil
 }
 
+// servedFullAPI returns a as served to the callers of the node: read-only,
+// rate limited and permissioned as configured.
+func servedFullAPI(a v1api.FullNode, permissioned bool) v1api.FullNode {
+	fnapi := proxy.MetricedFullAPI(a)
+	if a.(*impl.FullNodeAPI).ReadOnlyAPI {
+		fnapi = api.ReadOnlyFullAPI(fnapi)
+	}
+	if l := a.(*impl.FullNodeAPI).RPCLimiter; l != nil && l.Enabled() {
+		fnapi = rpclimit.LimitedFullAPI(fnapi, l)
+	}
+	if permissioned {
+		fnapi = api.PermissionedFullAPI(fnapi)
+	}
+	return fnapi
+}
+
 // MinerHandler returns a miner handler, to be mounted as-is on the server.
 func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 	mapi := proxy.MetricedStorMinerAPI(a)
@@ -0,0 +1,13 @@
+function __lotus_cli_complete
+  set -l args (commandline -opc)
+  set -l cur (commandline -ct)
+  if string match -q -- '-*' $cur
+    $args $cur --generate-bash-completion 2>/dev/null
+  else
+    $args --generate-bash-completion 2>/dev/null
+  end
+end
+
+for cmd in lotus lotus-miner lotus-worker
+  complete -c $cmd -f -a '(__lotus_cli_complete)'
+end